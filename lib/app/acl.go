@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/storage"
@@ -130,6 +131,13 @@ func (r *ApplicationsACL) GetAppInstaller(req InstallerRequest) (io.ReadCloser,
 	return r.applications.GetAppInstaller(req)
 }
 
+func (r *ApplicationsACL) GetAppSBOM(locator loc.Locator) (*sbom.Document, error) {
+	if err := r.checkApp(locator, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.applications.GetAppSBOM(locator)
+}
+
 func (r *ApplicationsACL) ListApps(req ListAppsRequest) ([]Application, error) {
 	if req.Repository == "" {
 		return nil, trace.BadParameter("missing parameter repository")
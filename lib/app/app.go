@@ -24,10 +24,12 @@ import (
 	"io"
 	"time"
 
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/storage"
 
 	"github.com/gravitational/trace"
@@ -98,6 +100,10 @@ type Applications interface {
 	// compressed as gzipped tar archive
 	GetAppInstaller(InstallerRequest) (io.ReadCloser, error)
 
+	// GetAppSBOM returns the software bill of materials of the specified
+	// application: its packages, docker images and Helm charts
+	GetAppSBOM(locator loc.Locator) (*sbom.Document, error)
+
 	// StartAppHook starts application hook specified with req asynchronously
 	StartAppHook(ctx context.Context, req HookRunRequest) (*HookRef, error)
 
@@ -272,6 +278,17 @@ type InstallerRequest struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// BuildTime is the time recorded in the installer tarball and package
+	// metadata. If set, the installer is built reproducibly: the same
+	// packages and build time produce the same tarball
+	BuildTime time.Time `json:"build_time,omitempty"`
+	// Signer is the optional signer to sign the installer packages with.
+	// It is not passed in API calls so the signing key never leaves the host
+	Signer *signing.Signer `json:"-"`
+	// SBOM is the optional software bill of materials of the application
+	// to embed into the installer. If unset, it is generated from the
+	// application packages
+	SBOM *sbom.Document `json:"-"`
 }
 
 // Check validates this request
@@ -297,6 +314,7 @@ func (r InstallerRequest) ToRaw() (*InstallerRequestRaw, error) {
 		TrustedCluster: json.RawMessage(bytes),
 		CACert:         r.CACert,
 		EncryptionKey:  r.EncryptionKey,
+		BuildTime:      r.BuildTime,
 	}, nil
 }
 
@@ -313,6 +331,8 @@ type InstallerRequestRaw struct {
 	CACert string `json:"ca_cert,omitempty"`
 	// EncryptionKey is encryption key to encrypt installer packages with
 	EncryptionKey string `json:"encryption_key,omitempty"`
+	// BuildTime is the time recorded in the reproducible installer
+	BuildTime time.Time `json:"build_time,omitempty"`
 }
 
 // ToNative converts the request from API-friendly to its regular format
@@ -327,6 +347,7 @@ func (r InstallerRequestRaw) ToNative() (*InstallerRequest, error) {
 		TrustedCluster: cluster,
 		CACert:         r.CACert,
 		EncryptionKey:  r.EncryptionKey,
+		BuildTime:      r.BuildTime,
 	}, nil
 }
 
@@ -30,6 +30,7 @@ import (
 
 	"github.com/gravitational/gravity/lib/app"
 	serviceapi "github.com/gravitational/gravity/lib/app/api"
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/storage"
@@ -249,6 +250,20 @@ func (c *Client) GetAppInstaller(req app.InstallerRequest) (io.ReadCloser, error
 		"standalone-installer"), values)
 }
 
+// GET app/v1/applications/:repository_id/:package_id/:version/sbom
+func (c *Client) GetAppSBOM(locator loc.Locator) (*sbom.Document, error) {
+	out, err := c.Get(c.Endpoint("applications", locator.Repository, locator.Name,
+		locator.Version, "sbom"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var doc sbom.Document
+	if err = json.Unmarshal(out.Bytes(), &doc); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &doc, nil
+}
+
 // GET app/v1/applications/:repository_id/
 func (c *Client) ListApps(req app.ListAppsRequest) (apps []app.Application, err error) {
 	// repository may be empty, and if it is, there will be extra slashes in the endpoint
@@ -0,0 +1,300 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delta
+
+import (
+	"archive/tar"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/signing"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// ApplyRequest describes a request to restore the full application
+// version from a delta installer
+type ApplyRequest struct {
+	// Dir is the directory with the unpacked delta installer
+	Dir string
+	// Packages is the package service of the delta installer.
+	// It encrypts the restored packages if the installer is encrypted
+	Packages pack.PackageService
+	// BasePackages is the package service with the packages of the base
+	// version, e.g. the cluster package service
+	BasePackages pack.PackageService
+	// Verifier, if set, is used to verify the signature of the delta manifest
+	// which the patched packages are verified against
+	Verifier *signing.Verifier
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+func (r *ApplyRequest) checkAndSetDefaults() error {
+	if r.Dir == "" {
+		return trace.BadParameter("missing Dir")
+	}
+	if r.Packages == nil {
+		return trace.BadParameter("missing Packages")
+	}
+	if r.BasePackages == nil {
+		return trace.BadParameter("missing BasePackages")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "delta")
+	}
+	return nil
+}
+
+// Apply restores the packages of the full application version in the delta
+// installer using the packages of the base version and verifies them against
+// the full version.
+// Returns trace.NotFound if the installer is not a delta installer
+func Apply(req ApplyRequest) (*Manifest, error) {
+	if err := req.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	manifest, err := ReadManifest(req.Dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.Verifier != nil {
+		if err := verifyManifest(req.Dir, req.Verifier); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	for _, pkg := range manifest.Packages {
+		req.WithFields(logrus.Fields{
+			"package": pkg.Locator,
+			"source":  pkg.Source,
+		}).Info("Restore package.")
+		switch pkg.Source {
+		case SourceDelta:
+			err = checkPackage(req, pkg)
+		case SourceBase:
+			err = restorePackage(req, pkg)
+		case SourcePatch:
+			err = patchPackage(req, pkg)
+		default:
+			err = trace.BadParameter("unknown source %q of package %v", pkg.Source, pkg.Locator)
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return manifest, nil
+}
+
+// checkPackage makes sure the package included in the delta as is
+// matches the full version
+func checkPackage(req ApplyRequest, pkg Package) error {
+	envelope, err := req.Packages.ReadPackageEnvelope(pkg.Locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if envelope.SHA512 != pkg.SHA512 {
+		return trace.BadParameter("package %v in the delta installer is corrupted", pkg.Locator)
+	}
+	return nil
+}
+
+// restorePackage copies the package from the base version
+func restorePackage(req ApplyRequest, pkg Package) error {
+	envelope, err := req.Packages.ReadPackageEnvelope(pkg.Locator)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if envelope != nil && envelope.SHA512 == pkg.SHA512 {
+		// restored previously
+		return nil
+	}
+	baseEnvelope, reader, err := req.BasePackages.ReadPackage(*pkg.Base)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("package %v of the base version is not available, "+
+				"use the full installer", pkg.Base)
+		}
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	if baseEnvelope.SHA512 != pkg.SHA512 {
+		return trace.BadParameter("package %v does not match package %v of the full version, "+
+			"use the full installer", pkg.Base, pkg.Locator)
+	}
+	err = req.Packages.UpsertRepository(pkg.Locator.Repository, time.Time{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	hasher := sha512.New()
+	_, err = req.Packages.UpsertPackage(pkg.Locator, io.TeeReader(reader, hasher), pkg.options()...)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	// the package service might encrypt the package so the data
+	// is checked instead of the hash of the stored package
+	if fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2]) != pkg.SHA512 {
+		return trace.BadParameter("restored package %v does not match the full version", pkg.Locator)
+	}
+	return nil
+}
+
+// patchPackage adds the files omitted from the package in the delta
+// installer from the base version packages
+func patchPackage(req ApplyRequest, pkg Package) error {
+	envelope, err := req.Packages.ReadPackageEnvelope(pkg.Locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if envelope.SHA512 != pkg.PatchSHA512 {
+		// restored previously
+		return nil
+	}
+	dir, err := ioutil.TempDir("", "delta")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(dir)
+	modTimes, err := extractFiles(req.BasePackages, pkg.Files, dir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	_, reader, err := req.Packages.ReadPackage(pkg.Locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	restored, err := spool(dir, func(w io.Writer) error {
+		return rewriteTarball(w, reader, nil, func(tarball *tar.Writer) error {
+			for _, file := range pkg.Files {
+				err := addFile(tarball, file, modTimes[file.SHA512], filepath.Join(dir, file.SHA512))
+				if err != nil {
+					return trace.Wrap(err)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer removeFile(restored)
+
+	digest, err := ContentDigest(restored)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if digest != pkg.ContentDigest {
+		return trace.BadParameter("restored package %v does not match the full version", pkg.Locator)
+	}
+	if _, err := restored.Seek(0, io.SeekStart); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	// the package is replaced in place so the patched package
+	// is kept if the upsert fails and the restore can be retried
+	_, err = req.Packages.UpsertPackage(pkg.Locator, restored, pkg.patchOptions()...)
+	return trace.Wrap(err)
+}
+
+// extractFiles extracts the specified files from the base version packages
+// into dir naming them after their hashes and returns their modification times
+func extractFiles(packages pack.PackageService, files []File, dir string) (map[string]time.Time, error) {
+	byPackage := make(map[loc.Locator]map[string]File)
+	for _, file := range files {
+		if byPackage[file.Package] == nil {
+			byPackage[file.Package] = make(map[string]File)
+		}
+		byPackage[file.Package][file.Path] = file
+	}
+	modTimes := make(map[string]time.Time)
+	for locator, files := range byPackage {
+		_, reader, err := packages.ReadPackage(locator)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return nil, trace.NotFound("package %v of the base version is not available, "+
+					"use the full installer", locator)
+			}
+			return nil, trace.Wrap(err)
+		}
+		err = walkTarball(reader, func(header *tar.Header, data io.Reader) error {
+			file, ok := files[header.Name]
+			if !ok {
+				return nil
+			}
+			if err := extractFile(file, data, filepath.Join(dir, file.SHA512)); err != nil {
+				return trace.Wrap(err)
+			}
+			modTimes[file.SHA512] = header.ModTime
+			delete(files, header.Name)
+			return nil
+		})
+		reader.Close()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for path := range files {
+			return nil, trace.NotFound("file %v not found in package %v of the base version, "+
+				"use the full installer", path, locator)
+		}
+	}
+	return modTimes, nil
+}
+
+func extractFile(file File, data io.Reader, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	hash, err := hashData(io.TeeReader(data, f))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if hash != file.SHA512 {
+		return trace.BadParameter("file %v in package %v of the base version does not match "+
+			"the full version, use the full installer", file.Path, file.Package)
+	}
+	return nil
+}
+
+func addFile(tarball *tar.Writer, file File, modTime time.Time, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	err = tarball.WriteHeader(&tar.Header{
+		Name:     file.Name,
+		Mode:     file.Mode,
+		Size:     file.SizeBytes,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = io.Copy(tarball, f)
+	return trace.Wrap(err)
+}
@@ -0,0 +1,409 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package delta implements delta installers that only carry the data
+an application version does not share with a previous version.
+
+A delta installer has the same layout as a regular installer tarball but:
+
+  - packages identical to the packages of the base version are omitted
+  - application packages omit the files, e.g. docker image layers,
+    found in the application packages of the base version
+
+The delta manifest (delta.json) describes every package of the full version
+and where its data comes from so the full version can be restored using
+the packages of the base version already present in the cluster.
+
+Patched packages cannot be restored byte for byte so they lose the signature
+of the full version. Instead, the manifest carries their content digests
+and is signed (delta.json.sig) if the installer packages are signed.
+*/
+package delta
+
+import (
+	"archive/tar"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/blob/fs"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/localpack"
+	"github.com/gravitational/gravity/lib/signing"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/gravitational/trace"
+)
+
+// ManifestFilename is the name of the delta manifest in the delta installer
+const ManifestFilename = "delta.json"
+
+// Manifest describes the delta between two application versions
+type Manifest struct {
+	// Application is the application version the delta installs
+	Application loc.Locator `json:"application"`
+	// Base is the application version the delta has been built against
+	Base loc.Locator `json:"base"`
+	// Packages lists all packages of the full application version
+	Packages []Package `json:"packages"`
+}
+
+// Package describes a package of the full application version
+type Package struct {
+	// Locator is the package locator
+	Locator loc.Locator `json:"locator"`
+	// SHA512 is the hash of the package in the full version
+	SHA512 string `json:"sha512"`
+	// SizeBytes is the size of the package in the full version
+	SizeBytes int64 `json:"size_bytes"`
+	// Source defines where the package data comes from
+	Source Source `json:"source"`
+	// Base is the base version package with the same data
+	// if the package is taken from the base version
+	Base *loc.Locator `json:"base,omitempty"`
+	// PatchSHA512 is the hash of the package in the delta installer
+	// if the package has been patched
+	PatchSHA512 string `json:"patch_sha512,omitempty"`
+	// ContentDigest is the digest of the package contents in the full
+	// version if the package has been patched
+	ContentDigest string `json:"content_digest,omitempty"`
+	// Files lists the files of the patched package omitted from the delta
+	Files []File `json:"files,omitempty"`
+	// Labels is the package runtime labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Hidden is whether the package is hidden
+	Hidden bool `json:"hidden,omitempty"`
+	// CreatedBy is the user who created the package
+	CreatedBy string `json:"created_by,omitempty"`
+	// Type is the package type
+	Type string `json:"type,omitempty"`
+	// Manifest is the application manifest for application packages
+	Manifest []byte `json:"manifest,omitempty"`
+}
+
+// Source defines where the data of a package comes from
+type Source string
+
+const (
+	// SourceDelta means the package is included in the delta as is
+	SourceDelta Source = "delta"
+	// SourceBase means the package is omitted from the delta as
+	// the base version has an identical package
+	SourceBase Source = "base"
+	// SourcePatch means the package is included in the delta without
+	// the files found in the packages of the base version
+	SourcePatch Source = "patch"
+)
+
+// File describes a file of a patched package that is restored
+// from a base version package
+type File struct {
+	// Name is the file path inside the package
+	Name string `json:"name"`
+	// Mode is the file mode
+	Mode int64 `json:"mode"`
+	// SHA512 is the hash of the file data
+	SHA512 string `json:"sha512"`
+	// SizeBytes is the file size
+	SizeBytes int64 `json:"size_bytes"`
+	// Package is the base version package with the file
+	Package loc.Locator `json:"package"`
+	// Path is the file path inside the base version package
+	Path string `json:"path"`
+}
+
+// Stats returns the number of packages of the full version and the number
+// of packages included in the delta as is and patched
+func (m Manifest) Stats() (total, included, patched int) {
+	for _, pkg := range m.Packages {
+		switch pkg.Source {
+		case SourceDelta:
+			included++
+		case SourcePatch:
+			patched++
+		}
+	}
+	return len(m.Packages), included, patched
+}
+
+// Patched returns the packages restored by patching. They do not carry
+// signatures and are verified against the content digests in the manifest
+func (m Manifest) Patched() (patched []loc.Locator) {
+	for _, pkg := range m.Packages {
+		if pkg.Source == SourcePatch {
+			patched = append(patched, pkg.Locator)
+		}
+	}
+	return patched
+}
+
+// ReadManifest reads the delta manifest from the unpacked installer
+// in the specified directory.
+// Returns trace.NotFound if the installer is not a delta installer
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &manifest, nil
+}
+
+// writeManifest writes the delta manifest to the unpacked installer in
+// the specified directory along with its signature if signer is not nil
+func writeManifest(dir string, manifest Manifest, signer *signing.Signer) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	path := filepath.Join(dir, ManifestFilename)
+	if err := ioutil.WriteFile(path, data, defaults.SharedReadMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if signer == nil {
+		return nil
+	}
+	signature, err := signer.Sign(data)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ioutil.WriteFile(signing.SignatureFile(path), signature, defaults.SharedReadMask)
+	return trace.ConvertSystemError(err)
+}
+
+// verifyManifest verifies the signature of the delta manifest in the
+// unpacked installer in the specified directory.
+// Returns trace.AccessDenied if the manifest is not signed or the signature is invalid
+func verifyManifest(dir string, verifier *signing.Verifier) error {
+	path := filepath.Join(dir, ManifestFilename)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	signature, err := ioutil.ReadFile(signing.SignatureFile(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return trace.AccessDenied("delta manifest is not signed")
+		}
+		return trace.ConvertSystemError(err)
+	}
+	if err := verifier.Verify(data, signature); err != nil {
+		return trace.AccessDenied("delta manifest failed signature verification")
+	}
+	return nil
+}
+
+// ContentDigest returns the digest of the contents of the specified
+// package tarball.
+// Unlike the package hash, the digest does not depend on the order of
+// the tarball entries, file modification times or compression
+func ContentDigest(r io.Reader) (string, error) {
+	var entries []string
+	err := walkTarball(r, func(header *tar.Header, data io.Reader) error {
+		hash, err := hashData(data)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		entries = append(entries, digestEntry(header, hash))
+		return nil
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return digest(entries), nil
+}
+
+func digestEntry(header *tar.Header, hash string) string {
+	return fmt.Sprintf("%c %q %o %q %v", header.Typeflag, strings.TrimPrefix(header.Name, "./"),
+		header.Mode, header.Linkname, hash)
+}
+
+func digest(entries []string) string {
+	sort.Strings(entries)
+	hasher := sha512.New()
+	for _, entry := range entries {
+		fmt.Fprintln(hasher, entry)
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+}
+
+func hashData(r io.Reader) (string, error) {
+	hasher := sha512.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2]), nil
+}
+
+// isLargeFile returns true if the tarball entry is a regular file
+// large enough to be omitted from the delta
+func isLargeFile(header *tar.Header) bool {
+	return header.Typeflag == tar.TypeReg && header.Size >= defaults.DeltaMinFileSize
+}
+
+// walkTarball invokes fn for each entry of the specified tarball
+func walkTarball(r io.Reader, fn func(header *tar.Header, data io.Reader) error) error {
+	decompressed, err := dockerarchive.DecompressStream(r)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	tarball := tar.NewReader(decompressed)
+	for {
+		header, err := tarball.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := fn(header, tarball); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// rewriteTarball writes the entries of the tarball r accepted by filter
+// followed by the entries added by extra into w as a compressed tarball
+func rewriteTarball(w io.Writer, r io.Reader, filter func(*tar.Header) bool, extra func(*tar.Writer) error) error {
+	compressed, err := dockerarchive.CompressStream(nopWriteCloser{w}, dockerarchive.Gzip)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	tarball := tar.NewWriter(compressed)
+	err = walkTarball(r, func(header *tar.Header, data io.Reader) error {
+		if filter != nil && !filter(header) {
+			return nil
+		}
+		if err := tarball.WriteHeader(header); err != nil {
+			return trace.Wrap(err)
+		}
+		_, err := io.Copy(tarball, data)
+		return trace.Wrap(err)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if extra != nil {
+		if err := extra(tarball); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if err := tarball.Close(); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(compressed.Close())
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// options returns the options to recreate the package with
+// the same metadata as in the full version
+func (p Package) options() []pack.PackageOption {
+	options := []pack.PackageOption{
+		pack.WithLabels(p.Labels),
+		pack.WithHidden(p.Hidden),
+		pack.WithCreatedBy(p.CreatedBy),
+	}
+	if len(p.Manifest) != 0 {
+		options = append(options, pack.WithManifest(p.Type, p.Manifest))
+	}
+	return options
+}
+
+// patchOptions returns the options to recreate the patched package.
+// The package data differs from the full version so the signature
+// of the full version is dropped
+func (p Package) patchOptions() []pack.PackageOption {
+	labels := make(map[string]string, len(p.Labels))
+	for name, value := range p.Labels {
+		if name != pack.SignatureLabel {
+			labels[name] = value
+		}
+	}
+	p.Labels = labels
+	return p.options()
+}
+
+// openPackages returns the package service of the unpacked installer
+// in the specified directory
+func openPackages(dir string) (*localpack.PackageServer, storage.Backend, error) {
+	backend, err := keyval.NewBolt(keyval.BoltConfig{
+		Path: filepath.Join(dir, defaults.GravityDBFile),
+	})
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	objects, err := fs.New(filepath.Join(dir, defaults.PackagesDir))
+	if err != nil {
+		backend.Close()
+		return nil, nil, trace.Wrap(err)
+	}
+	packages, err := localpack.New(localpack.Config{
+		Backend:     backend,
+		UnpackedDir: filepath.Join(dir, defaults.PackagesDir, defaults.UnpackedDir),
+		Objects:     objects,
+	})
+	if err != nil {
+		backend.Close()
+		return nil, nil, trace.Wrap(err)
+	}
+	return packages, backend, nil
+}
+
+// spool writes the data into a temporary file and returns the file
+// positioned at the beginning
+func spool(dir string, fn func(w io.Writer) error) (*os.File, error) {
+	f, err := ioutil.TempFile(dir, "delta")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, trace.Wrap(err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, trace.ConvertSystemError(err)
+	}
+	return f, nil
+}
+
+// removeFile closes and removes the temporary file
+func removeFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
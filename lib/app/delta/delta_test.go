@@ -0,0 +1,358 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delta
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	mathrand "math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/encryptedpack"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/signing"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+func TestDelta(t *testing.T) { TestingT(t) }
+
+type DeltaSuite struct{}
+
+var _ = Suite(&DeltaSuite{})
+
+func (s *DeltaSuite) TestGeneratesAndAppliesDelta(c *C) {
+	layer := randomString(1, defaults.DeltaMinFileSize)
+	runtime := randomString(2, 1024)
+	baseDir := newInstallerDir(c, "0.0.1", runtime, map[string]string{
+		"registry/layer": layer,
+		"registry/old":   randomString(3, defaults.DeltaMinFileSize),
+	})
+	dir := newInstallerDir(c, "0.0.2", runtime, map[string]string{
+		"registry/layer": layer,
+		"registry/new":   randomString(4, defaults.DeltaMinFileSize),
+	})
+	fullPackages, fullBackend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	full := readPackages(c, fullPackages)
+	c.Assert(fullBackend.Close(), IsNil)
+
+	manifest, err := generate(baseDir, dir, nil, logrus.StandardLogger())
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Application, Equals, appLocator("0.0.2"))
+	c.Assert(manifest.Base, Equals, appLocator("0.0.1"))
+	total, included, patched := manifest.Stats()
+	c.Assert(total, Equals, 2)
+	c.Assert(included, Equals, 0)
+	c.Assert(patched, Equals, 1)
+
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	// the runtime package is omitted and the application package
+	// does not include the shared layer
+	_, err = packages.ReadPackageEnvelope(runtimeLocator)
+	c.Assert(trace.IsNotFound(err), Equals, true)
+	envelope, err := packages.ReadPackageEnvelope(appLocator("0.0.2"))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.SizeBytes < int64(2*defaults.DeltaMinFileSize), Equals, true)
+
+	basePackages, baseBackend, err := openPackages(baseDir)
+	c.Assert(err, IsNil)
+	defer baseBackend.Close()
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+	})
+	c.Assert(err, IsNil)
+
+	c.Assert(readPackages(c, packages), DeepEquals, full)
+
+	// applying the delta again is a no-op
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+	})
+	c.Assert(err, IsNil)
+}
+
+func (s *DeltaSuite) TestAppliesDeltaToEncryptedPackages(c *C) {
+	layer := randomString(1, defaults.DeltaMinFileSize)
+	runtime := randomString(2, 1024)
+	baseDir := newInstallerDir(c, "0.0.1", runtime, map[string]string{
+		"registry/layer": layer,
+	})
+	dir := newInstallerDir(c, "0.0.2", runtime, map[string]string{
+		"registry/layer": layer,
+		"registry/new":   randomString(3, defaults.DeltaMinFileSize),
+	})
+	fullPackages, fullBackend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	full := readPackages(c, fullPackages)
+	c.Assert(fullBackend.Close(), IsNil)
+	_, err = generate(baseDir, dir, nil, logrus.StandardLogger())
+	c.Assert(err, IsNil)
+
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	basePackages, baseBackend, err := openPackages(baseDir)
+	c.Assert(err, IsNil)
+	defer baseBackend.Close()
+	encrypted := encryptedpack.New(packages, "secret")
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     encrypted,
+		BasePackages: basePackages,
+	})
+	c.Assert(err, IsNil)
+
+	// the restored package is encrypted
+	restored := readPackages(c, encrypted)
+	c.Assert(restored[appLocator("0.0.2")], Equals, full[appLocator("0.0.2")])
+	envelope, reader, err := encrypted.ReadPackage(runtimeLocator)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	c.Assert(envelope.Encrypted, Equals, true)
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(utils.MustSHA512Half(data), Equals, full[runtimeLocator])
+}
+
+func (s *DeltaSuite) TestRequiresBasePackages(c *C) {
+	runtime := randomString(2, 1024)
+	baseDir := newInstallerDir(c, "0.0.1", runtime, nil)
+	dir := newInstallerDir(c, "0.0.2", runtime, nil)
+	_, err := generate(baseDir, dir, nil, logrus.StandardLogger())
+	c.Assert(err, IsNil)
+
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	emptyDir := c.MkDir()
+	basePackages, baseBackend, err := openPackages(emptyDir)
+	c.Assert(err, IsNil)
+	defer baseBackend.Close()
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+	})
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *DeltaSuite) TestVerifiesSignedDelta(c *C) {
+	signer := newSigner(c)
+	layer := randomString(1, defaults.DeltaMinFileSize)
+	runtime := randomString(2, 1024)
+	baseDir := newInstallerDir(c, "0.0.1", runtime, map[string]string{
+		"registry/layer": layer,
+	})
+	dir := newInstallerDir(c, "0.0.2", runtime, map[string]string{
+		"registry/layer": layer,
+		"registry/new":   randomString(3, defaults.DeltaMinFileSize),
+	})
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	c.Assert(pack.SignPackages(packages, signer), IsNil)
+	c.Assert(backend.Close(), IsNil)
+	manifest, err := generate(baseDir, dir, signer, logrus.StandardLogger())
+	c.Assert(err, IsNil)
+	c.Assert(manifest.Patched(), DeepEquals, []loc.Locator{appLocator("0.0.2")})
+
+	packages, backend, err = openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	basePackages, baseBackend, err := openPackages(baseDir)
+	c.Assert(err, IsNil)
+	defer baseBackend.Close()
+
+	// the manifest is rejected by another key
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+		Verifier:     newSigner(c).Verifier(),
+	})
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+		Verifier:     signer.Verifier(),
+	})
+	c.Assert(err, IsNil)
+
+	// packages restored as is keep their signatures while
+	// patched packages are verified against the manifest
+	envelope, err := packages.ReadPackageEnvelope(runtimeLocator)
+	c.Assert(err, IsNil)
+	c.Assert(pack.VerifyPackage(packages, *envelope, signer.Verifier()), IsNil)
+	envelope, err = packages.ReadPackageEnvelope(appLocator("0.0.2"))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.RuntimeLabels[pack.SignatureLabel], Equals, "")
+	c.Assert(envelope.Manifest, Not(HasLen), 0)
+}
+
+func (s *DeltaSuite) TestRequiresSignedManifest(c *C) {
+	runtime := randomString(2, 1024)
+	baseDir := newInstallerDir(c, "0.0.1", runtime, nil)
+	dir := newInstallerDir(c, "0.0.2", runtime, nil)
+	_, err := generate(baseDir, dir, nil, logrus.StandardLogger())
+	c.Assert(err, IsNil)
+
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	basePackages, baseBackend, err := openPackages(baseDir)
+	c.Assert(err, IsNil)
+	defer baseBackend.Close()
+	_, err = Apply(ApplyRequest{
+		Dir:          dir,
+		Packages:     packages,
+		BasePackages: basePackages,
+		Verifier:     newSigner(c).Verifier(),
+	})
+	c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *DeltaSuite) TestReadManifestNotDelta(c *C) {
+	_, err := ReadManifest(c.MkDir())
+	c.Assert(trace.IsNotFound(err), Equals, true)
+}
+
+func (s *DeltaSuite) TestContentDigestIgnoresOrder(c *C) {
+	first := newTarball(c, map[string]string{"a": "foo", "b": "bar"}, []string{"a", "b"})
+	second := newTarball(c, map[string]string{"a": "foo", "b": "bar"}, []string{"b", "a"})
+	third := newTarball(c, map[string]string{"a": "foo", "b": "baz"}, []string{"a", "b"})
+	digests := make([]string, 0, 3)
+	for _, data := range [][]byte{first, second, third} {
+		digest, err := ContentDigest(bytes.NewReader(data))
+		c.Assert(err, IsNil)
+		digests = append(digests, digest)
+	}
+	c.Assert(digests[0], Equals, digests[1])
+	c.Assert(digests[0], Not(Equals), digests[2])
+}
+
+// newInstallerDir creates an unpacked installer with the application
+// package of the specified version that includes the specified files
+// and a runtime package with the specified data
+func newInstallerDir(c *C, version, runtime string, files map[string]string) string {
+	dir := c.MkDir()
+	manifest := fmt.Sprintf(manifestTemplate, version)
+	err := ioutil.WriteFile(filepath.Join(dir, defaults.ManifestFileName), []byte(manifest), defaults.SharedReadMask)
+	c.Assert(err, IsNil)
+
+	packages, backend, err := openPackages(dir)
+	c.Assert(err, IsNil)
+	defer backend.Close()
+	c.Assert(packages.UpsertRepository(defaults.SystemAccountOrg, time.Time{}), IsNil)
+	_, err = packages.CreatePackage(runtimeLocator, bytes.NewReader([]byte(runtime)))
+	c.Assert(err, IsNil)
+
+	if files == nil {
+		files = make(map[string]string)
+	}
+	files["resources/app.yaml"] = manifest
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	_, err = packages.CreatePackage(appLocator(version), bytes.NewReader(newTarball(c, files, names)),
+		pack.WithManifest(schema.KindBundle, []byte(manifest)))
+	c.Assert(err, IsNil)
+	return dir
+}
+
+// readPackages returns the contents of all packages in the package service:
+// content digests of application packages and hashes of other packages
+func readPackages(c *C, packages pack.PackageService) map[loc.Locator]string {
+	contents := make(map[loc.Locator]string)
+	err := pack.ForeachPackage(packages, func(envelope pack.PackageEnvelope) error {
+		if len(envelope.Manifest) == 0 {
+			contents[envelope.Locator] = envelope.SHA512
+			return nil
+		}
+		_, reader, err := packages.ReadPackage(envelope.Locator)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer reader.Close()
+		contents[envelope.Locator], err = ContentDigest(reader)
+		return trace.Wrap(err)
+	})
+	c.Assert(err, IsNil)
+	return contents
+}
+
+func newTarball(c *C, files map[string]string, names []string) []byte {
+	var buf bytes.Buffer
+	tarball := archive.NewTarAppender(&buf)
+	for _, name := range names {
+		c.Assert(tarball.Add(archive.ItemFromString(name, files[name])), IsNil)
+	}
+	c.Assert(tarball.Close(), IsNil)
+	return buf.Bytes()
+}
+
+func newSigner(c *C) *signing.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	signer, err := signing.NewSigner(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+	c.Assert(err, IsNil)
+	return signer
+}
+
+func randomString(seed int64, size int) string {
+	data := make([]byte, size)
+	mathrand.New(mathrand.NewSource(seed)).Read(data)
+	return string(data)
+}
+
+func appLocator(version string) loc.Locator {
+	return loc.MustCreateLocator(defaults.SystemAccountOrg, "app", version)
+}
+
+var runtimeLocator = loc.MustParseLocator("gravitational.io/runtime:0.0.1")
+
+const manifestTemplate = `apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: app
+  resourceVersion: %v
+`
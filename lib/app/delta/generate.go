@@ -0,0 +1,322 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package delta
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/signing"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// GenerateRequest describes a request to generate a delta installer
+type GenerateRequest struct {
+	// BasePath is the path to the installer tarball of the base version
+	BasePath string
+	// Path is the path to the installer tarball of the new version
+	Path string
+	// OutPath is the path to write the delta installer tarball to
+	OutPath string
+	// Signer, if set, signs the delta manifest
+	Signer *signing.Signer
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+}
+
+func (r *GenerateRequest) checkAndSetDefaults() error {
+	if r.BasePath == "" {
+		return trace.BadParameter("missing BasePath")
+	}
+	if r.Path == "" {
+		return trace.BadParameter("missing Path")
+	}
+	if r.OutPath == "" {
+		return trace.BadParameter("missing OutPath")
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = logrus.WithField(trace.Component, "delta")
+	}
+	return nil
+}
+
+// Generate generates the delta installer for the application version
+// from the installer in Path against the base version installer in BasePath
+func Generate(req GenerateRequest) (*Manifest, error) {
+	if err := req.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	baseDir, err := archive.Unpack(req.BasePath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer os.RemoveAll(baseDir)
+	dir, err := archive.Unpack(req.Path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest, err := generate(baseDir, dir, req.Signer, req.FieldLogger)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	f, err := os.Create(req.OutPath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	if err := archive.CompressDirectory(dir, f); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return manifest, nil
+}
+
+// generate turns the unpacked installer in dir into the delta installer
+// against the unpacked base version installer in baseDir.
+// If signer is not nil, the delta manifest is signed
+func generate(baseDir, dir string, signer *signing.Signer, log logrus.FieldLogger) (*Manifest, error) {
+	base, err := readApplication(baseDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	application, err := readApplication(dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	basePackages, baseBackend, err := openPackages(baseDir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer baseBackend.Close()
+	packages, backend, err := openPackages(dir)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer backend.Close()
+
+	index, err := newBaseIndex(basePackages)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var envelopes []pack.PackageEnvelope
+	err = pack.ForeachPackage(packages, func(envelope pack.PackageEnvelope) error {
+		if envelope.Encrypted {
+			return trace.BadParameter("delta installers cannot be built from "+
+				"encrypted installers: package %v is encrypted", envelope.Locator)
+		}
+		envelopes = append(envelopes, envelope)
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	manifest := Manifest{
+		Application: application,
+		Base:        base,
+	}
+	for _, envelope := range envelopes {
+		pkg, err := generatePackage(packages, *index, envelope)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		log.WithFields(logrus.Fields{
+			"package": pkg.Locator,
+			"source":  pkg.Source,
+		}).Info("Add package to delta.")
+		manifest.Packages = append(manifest.Packages, *pkg)
+	}
+	if err := writeManifest(dir, manifest, signer); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &manifest, nil
+}
+
+// generatePackage removes the specified package from the delta installer
+// or replaces it with the patched version depending on the data
+// the base version has
+func generatePackage(packages pack.PackageService, index baseIndex, envelope pack.PackageEnvelope) (*Package, error) {
+	pkg := Package{
+		Locator:   envelope.Locator,
+		SHA512:    envelope.SHA512,
+		SizeBytes: envelope.SizeBytes,
+		Source:    SourceDelta,
+		Labels:    envelope.RuntimeLabels,
+		Hidden:    envelope.Hidden,
+		CreatedBy: envelope.CreatedBy,
+		Type:      envelope.Type,
+		Manifest:  envelope.Manifest,
+	}
+	if base := index.findPackage(envelope); base != nil {
+		pkg.Source = SourceBase
+		pkg.Base = base
+		return &pkg, trace.Wrap(packages.DeletePackage(envelope.Locator))
+	}
+	if len(envelope.Manifest) == 0 {
+		return &pkg, nil
+	}
+
+	// find the files the base version has computing the digest
+	// of the package contents along the way
+	var entries []string
+	omitted := make(map[string]struct{})
+	_, reader, err := packages.ReadPackage(envelope.Locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = walkTarball(reader, func(header *tar.Header, data io.Reader) error {
+		hash, err := hashData(data)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		entries = append(entries, digestEntry(header, hash))
+		if !isLargeFile(header) {
+			return nil
+		}
+		file, ok := index.files[hash]
+		if !ok {
+			return nil
+		}
+		file.Name = header.Name
+		file.Mode = header.Mode
+		pkg.Files = append(pkg.Files, file)
+		omitted[header.Name] = struct{}{}
+		return nil
+	})
+	reader.Close()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(pkg.Files) == 0 {
+		return &pkg, nil
+	}
+
+	_, reader, err = packages.ReadPackage(envelope.Locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	patched, err := spool("", func(w io.Writer) error {
+		return rewriteTarball(w, reader, func(header *tar.Header) bool {
+			_, ok := omitted[header.Name]
+			return !ok
+		}, nil)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer removeFile(patched)
+	// delete the package first so the original data does not end up
+	// in the delta installer
+	if err := packages.DeletePackage(envelope.Locator); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	patchedEnvelope, err := packages.UpsertPackage(envelope.Locator, patched, pkg.patchOptions()...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pkg.Source = SourcePatch
+	pkg.PatchSHA512 = patchedEnvelope.SHA512
+	pkg.ContentDigest = digest(entries)
+	return &pkg, nil
+}
+
+// baseIndex indexes the data of the base version
+type baseIndex struct {
+	// packages maps package hashes to base version packages
+	packages map[string]loc.Locator
+	// hashes maps base version packages to their hashes
+	hashes map[loc.Locator]string
+	// files maps file hashes to the files of base version application packages
+	files map[string]File
+}
+
+func newBaseIndex(packages pack.PackageService) (*baseIndex, error) {
+	index := baseIndex{
+		packages: make(map[string]loc.Locator),
+		hashes:   make(map[loc.Locator]string),
+		files:    make(map[string]File),
+	}
+	err := pack.ForeachPackage(packages, func(envelope pack.PackageEnvelope) error {
+		index.packages[envelope.SHA512] = envelope.Locator
+		index.hashes[envelope.Locator] = envelope.SHA512
+		if len(envelope.Manifest) == 0 || envelope.Encrypted {
+			return nil
+		}
+		_, reader, err := packages.ReadPackage(envelope.Locator)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer reader.Close()
+		return walkTarball(reader, func(header *tar.Header, data io.Reader) error {
+			if !isLargeFile(header) {
+				return nil
+			}
+			hash, err := hashData(data)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			index.files[hash] = File{
+				SHA512:    hash,
+				SizeBytes: header.Size,
+				Package:   envelope.Locator,
+				Path:      header.Name,
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &index, nil
+}
+
+// findPackage returns the base version package with the same data
+// as the specified package, preferring the package with the same locator
+func (r baseIndex) findPackage(envelope pack.PackageEnvelope) *loc.Locator {
+	if r.hashes[envelope.Locator] == envelope.SHA512 {
+		return &envelope.Locator
+	}
+	if base, ok := r.packages[envelope.SHA512]; ok {
+		return &base
+	}
+	return nil
+}
+
+// readApplication returns the locator of the application
+// in the unpacked installer in the specified directory
+func readApplication(dir string) (loc.Locator, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, defaults.ManifestFileName))
+	if err != nil {
+		return loc.Locator{}, trace.ConvertSystemError(err)
+	}
+	manifest, err := schema.ParseManifestYAMLNoValidate(data)
+	if err != nil {
+		return loc.Locator{}, trace.Wrap(err)
+	}
+	return manifest.Locator(), nil
+}
@@ -37,6 +37,7 @@ func GetDependencies(app *Application, apps Applications) (result *Dependencies,
 	result.Packages = append(result.Packages, state.packages...)
 	if state.runtimePackage != nil {
 		result.Packages = append(result.Packages, *state.runtimePackage)
+		result.RuntimePackage = state.runtimePackage
 	}
 	for _, locator := range state.apps {
 		if !locator.IsEqualTo(app.Package) {
@@ -84,6 +85,9 @@ type Dependencies struct {
 	Packages []loc.Locator
 	// Apps defines a set of application package dependencies
 	Apps []loc.Locator
+	// RuntimePackage is the runtime package of the application.
+	// It is also listed in Packages
+	RuntimePackage *loc.Locator
 }
 
 func getDependencies(app *Application, apps Applications, state *state) error {
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// readDockerCredentials returns the registry credentials from the Docker client
+// configuration file at path by registry host. Credential helpers are not supported
+func readDockerCredentials(path string) (map[string]staticCredentials, error) {
+	credentials := make(map[string]staticCredentials)
+	if path == "" {
+		return credentials, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return credentials, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, trace.Wrap(err, "failed to parse %v", path)
+	}
+	if config.CredentialsStore != "" || len(config.CredentialHelpers) != 0 {
+		log.Warnf("Docker credential helpers configured in %v are not supported, "+
+			"only the credentials stored in the file are used.", path)
+	}
+	for registry, auth := range config.Auths {
+		username, password := auth.Username, auth.Password
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, trace.BadParameter("invalid credentials for %v in %v", registry, path)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return nil, trace.BadParameter("invalid credentials for %v in %v", registry, path)
+			}
+			username, password = parts[0], parts[1]
+		}
+		credentials[registryHost(registry)] = staticCredentials{
+			username: username,
+			password: password,
+		}
+	}
+	return credentials, nil
+}
+
+// registryHost returns the host of the registry address from
+// the Docker client configuration, e.g. docker.io for
+// https://index.docker.io/v1/
+func registryHost(addr string) string {
+	if u, err := url.Parse(addr); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	addr = strings.SplitN(addr, "/", 2)[0]
+	switch addr {
+	case "index.docker.io", dockerHubRegistry:
+		return dockerHubDomain
+	}
+	return addr
+}
+
+// dockerConfig is the Docker client configuration
+type dockerConfig struct {
+	// Auths maps registry addresses to credentials
+	Auths map[string]dockerAuth `json:"auths"`
+	// CredentialsStore is the default credential helper
+	CredentialsStore string `json:"credsStore,omitempty"`
+	// CredentialHelpers maps registry addresses to credential helpers
+	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+}
+
+// dockerAuth defines registry credentials in the Docker client configuration
+type dockerAuth struct {
+	// Auth is the base64-encoded username:password pair
+	Auth string `json:"auth,omitempty"`
+	// Username is the registry username
+	Username string `json:"username,omitempty"`
+	// Password is the registry password
+	Password string `json:"password,omitempty"`
+}
+
+// staticCredentials implements auth.CredentialStore with fixed credentials
+type staticCredentials struct {
+	username string
+	password string
+}
+
+// Basic returns the username and password
+func (r staticCredentials) Basic(*url.URL) (string, string) {
+	return r.username, r.password
+}
+
+// RefreshToken returns an empty refresh token
+func (r staticCredentials) RefreshToken(*url.URL, string) string {
+	return ""
+}
+
+// SetRefreshToken does nothing as refresh tokens are not stored
+func (r staticCredentials) SetRefreshToken(*url.URL, string, string) {}
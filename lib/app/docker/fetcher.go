@@ -0,0 +1,484 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/run"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	distreference "github.com/docker/distribution/reference"
+	registryclient "github.com/docker/distribution/registry/client"
+	"github.com/docker/distribution/registry/client/auth"
+	"github.com/docker/distribution/registry/client/auth/challenge"
+	"github.com/docker/distribution/registry/client/transport"
+	"github.com/gravitational/trace"
+	"github.com/opencontainers/go-digest"
+	log "github.com/sirupsen/logrus"
+)
+
+// ImageFetcherConfig defines the configuration of the image fetcher
+type ImageFetcherConfig struct {
+	// Sources lists OCI layout directories and docker-archive tarballs
+	// to look up images in before pulling them from registries
+	Sources []string
+	// Mirrors maps registry domains to the addresses of their mirrors
+	// which are tried before the registry itself, e.g.
+	// "docker.io" -> ["mirror.gcr.io"]
+	Mirrors map[string][]string
+	// CacheDir is the directory with the cached image layers.
+	// If unspecified, layers are cached in a temporary directory
+	// removed by Close
+	CacheDir string
+	// DockerConfigPath is the path to the Docker client configuration
+	// file with registry credentials, defaults to ~/.docker/config.json
+	DockerConfigPath string
+	// Insecure disables the verification of registry TLS certificates
+	Insecure bool
+	// Platforms lists the platforms to vendor the images for, defaults
+	// to linux/amd64. With multiple platforms, images are vendored as
+	// manifest lists so that the nodes pull the image for their platform
+	Platforms []Platform
+	// FieldLogger is used for logging
+	log.FieldLogger
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (r *ImageFetcherConfig) CheckAndSetDefaults() error {
+	if r.DockerConfigPath == "" {
+		r.DockerConfigPath = dockerConfigPath()
+	}
+	if len(r.Platforms) == 0 {
+		r.Platforms = []Platform{DefaultPlatform}
+	}
+	if r.FieldLogger == nil {
+		r.FieldLogger = log.WithField(trace.Component, "fetcher")
+	}
+	return nil
+}
+
+// NewImageFetcher returns a new image fetcher for the specified configuration.
+// The fetcher vendors images without Docker daemon: it reads the images from
+// the configured image sources or pulls them over the Docker Registry v2 API.
+func NewImageFetcher(config ImageFetcherConfig) (*ImageFetcher, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	f := &ImageFetcher{ImageFetcherConfig: config}
+	cacheDir := config.CacheDir
+	if cacheDir == "" {
+		dir, err := ioutil.TempDir("", "layers")
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		f.tempDir, cacheDir = dir, dir
+	}
+	cache, err := newBlobCache(cacheDir)
+	if err != nil {
+		f.Close()
+		return nil, trace.Wrap(err)
+	}
+	f.cache = cache
+	f.credentials, err = readDockerCredentials(config.DockerConfigPath)
+	if err != nil {
+		f.Close()
+		return nil, trace.Wrap(err)
+	}
+	f.sources, err = indexImageSources(config.Sources, cache)
+	if err != nil {
+		f.Close()
+		return nil, trace.Wrap(err)
+	}
+	f.transport = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   fetcherConnectTimeout,
+			KeepAlive: fetcherConnectTimeout,
+		}).DialContext,
+		TLSHandshakeTimeout: fetcherConnectTimeout,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: config.Insecure},
+	}
+	return f, nil
+}
+
+// ImageFetcher vendors Docker images without Docker daemon
+type ImageFetcher struct {
+	ImageFetcherConfig
+	cache       *blobCache
+	credentials map[string]staticCredentials
+	sources     map[string]imageSource
+	transport   *http.Transport
+	tempDir     string
+}
+
+// Close removes the temporary layer cache
+func (f *ImageFetcher) Close() error {
+	if f.tempDir == "" {
+		return nil
+	}
+	return trace.ConvertSystemError(os.RemoveAll(f.tempDir))
+}
+
+// Export stores the specified images into the directory in the
+// Docker registry 2.x format. The images are stored under their
+// repository names without the registry, as Docker-based vendoring does
+func (f *ImageFetcher) Export(ctx context.Context, images []string, dir string, parallel int, progress utils.Progress) error {
+	store, err := openLocal(dir)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	group, groupCtx := run.WithContext(ctx, run.WithParallel(parallel))
+	for _, image := range images {
+		image := image
+		group.Go(groupCtx, func() error {
+			if err := f.export(groupCtx, store, image, progress); err != nil {
+				return trace.Wrap(err, "failed to vendor image %v", image)
+			}
+			progress.PrintSubStep("Vendored image %v", image)
+			return nil
+		})
+	}
+	return trace.Wrap(group.Wait())
+}
+
+// Unpack unpacks the filesystem of the specified image into the directory.
+// With multiple platforms, the image for the first one is unpacked
+func (f *ImageFetcher) Unpack(ctx context.Context, image string, dir string) error {
+	img, err := f.resolve(ctx, image)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	manifest, err := img.manifest(ctx, f.Platforms[0])
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == schema2.MediaTypeForeignLayer {
+			return trace.BadParameter("image %v has foreign layer %v which can't be unpacked",
+				image, layer.Digest)
+		}
+		if err := f.applyLayer(ctx, img, layer, dir); err != nil {
+			return trace.Wrap(err, "failed to apply layer %v of %v", layer.Digest, image)
+		}
+	}
+	return nil
+}
+
+func (f *ImageFetcher) applyLayer(ctx context.Context, img imageSource, layer distribution.Descriptor, dir string) error {
+	// the layer is read twice, so make sure it is in the cache first
+	rc, err := img.open(ctx, layer)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer rc.Close()
+	path, err := f.cache.ensure(layer.Digest, rc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(applyLayer(path, dir))
+}
+
+func (f *ImageFetcher) export(ctx context.Context, store *localStore, image string, progress utils.Progress) error {
+	parsed, err := loc.ParseDockerImage(image)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if strings.Contains(parsed.Tag, ":") {
+		return trace.BadParameter("image %v is referenced by digest, please use a tag", image)
+	}
+	tag := parsed.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	img, err := f.resolve(ctx, image)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	progress.PrintSubStep("Fetching image %v from %v", image, img)
+	repo, err := store.Repository(ctx, parsed.Repository)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var descriptors []manifestlist.ManifestDescriptor
+	for _, platform := range f.Platforms {
+		manifest, err := img.manifest(ctx, platform)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		desc, err := putManifest(ctx, img, repo, manifest)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		descriptors = append(descriptors, manifestlist.ManifestDescriptor{
+			Descriptor: *desc,
+			Platform:   platform.spec(),
+		})
+	}
+	desc := &descriptors[0].Descriptor
+	if len(descriptors) > 1 {
+		list, err := manifestlist.FromDescriptors(descriptors)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if desc, err = putManifest(ctx, img, repo, list); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	// registry storage ignores the tag option of Put, so tag explicitly
+	return trace.Wrap(repo.Tags(ctx).Tag(ctx, tag, *desc))
+}
+
+// putManifest stores the manifest and the blobs it references in the
+// repository and returns its descriptor
+func putManifest(ctx context.Context, img imageSource, repo distribution.Repository, manifest distribution.Manifest) (*distribution.Descriptor, error) {
+	if _, ok := manifest.(*schema2.DeserializedManifest); ok {
+		blobs := repo.Blobs(ctx)
+		for _, desc := range manifest.References() {
+			if desc.MediaType == schema2.MediaTypeForeignLayer {
+				continue
+			}
+			if err := copyBlob(ctx, img, blobs, desc); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+	}
+	manifests, err := repo.Manifests(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	dgst, err := manifests.Put(ctx, manifest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	mediaType, payload, err := manifest.Payload()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &distribution.Descriptor{
+		MediaType: mediaType,
+		Digest:    dgst,
+		Size:      int64(len(payload)),
+	}, nil
+}
+
+// resolve looks up the image in the image sources and falls back
+// to the registry from the image reference and its mirrors
+func (f *ImageFetcher) resolve(ctx context.Context, image string) (imageSource, error) {
+	named, err := normalizeImage(image)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if img, ok := f.sources[named.String()]; ok {
+		return img, nil
+	}
+	tagged, ok := named.(distreference.Tagged)
+	if !ok {
+		return nil, trace.BadParameter("image %v is referenced by digest, please use a tag", image)
+	}
+	domain := distreference.Domain(named)
+	var errors []error
+	for _, endpoint := range append(f.Mirrors[domain], domain) {
+		img, err := f.pull(ctx, endpoint, domain, distreference.Path(named), tagged.Tag())
+		if err == nil {
+			return img, nil
+		}
+		f.WithError(err).Warnf("Failed to pull %v from %v.", named, endpoint)
+		errors = append(errors, trace.Wrap(err, "failed to pull from %v", endpoint))
+	}
+	return nil, trace.NewAggregate(errors...)
+}
+
+func (f *ImageFetcher) pull(ctx context.Context, endpoint, domain, path, tag string) (*remoteImage, error) {
+	baseURL := registryURL(endpoint)
+	rt, err := f.connect(ctx, baseURL, endpoint, domain, path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	named, err := distreference.WithName(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	repo, err := registryclient.NewRepository(ctx, named, baseURL, rt)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	client := &http.Client{Transport: rt}
+	top, err := fetchManifest(ctx, client, baseURL, path, tag)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &remoteImage{
+		endpoint: endpoint,
+		blobs:    repo.Blobs(ctx),
+		cache:    f.cache,
+		top:      top,
+		read: func(ctx context.Context, desc distribution.Descriptor) (*rawManifest, error) {
+			return fetchManifest(ctx, client, baseURL, path, desc.Digest.String())
+		},
+	}, nil
+}
+
+// connect returns the transport for the repository with the specified path in
+// the registry at baseURL authenticating with credentials from Docker configuration
+func (f *ImageFetcher) connect(ctx context.Context, baseURL, endpoint, domain, path string) (http.RoundTripper, error) {
+	client := &http.Client{Transport: f.transport, Timeout: fetcherConnectTimeout}
+	resp, err := client.Get(baseURL + "/v2/")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp.Body.Close()
+	manager := challenge.NewSimpleManager()
+	if err := manager.AddResponse(resp); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	creds, ok := f.credentials[registryHost(endpoint)]
+	if !ok {
+		creds = f.credentials[registryHost(domain)]
+	}
+	authorizer := auth.NewAuthorizer(manager,
+		auth.NewTokenHandler(f.transport, creds, path, "pull"),
+		auth.NewBasicHandler(creds))
+	return transport.NewTransport(f.transport, authorizer), nil
+}
+
+// fetchManifest returns the manifest with the specified reference (tag or digest)
+// from the repository. Unlike the registry client, it accepts OCI manifests
+func fetchManifest(ctx context.Context, client *http.Client, baseURL, path, ref string) (*rawManifest, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%v/v2/%v/manifests/%v", baseURL, path, ref), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.Wrap(registryclient.HandleErrorResponse(resp))
+	}
+	payload, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if dgst, err := digest.Parse(ref); err == nil && digest.FromBytes(payload) != dgst {
+		return nil, trace.BadParameter("manifest %v of %v failed digest verification", ref, path)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	return &rawManifest{mediaType: mediaType, payload: payload}, nil
+}
+
+// copyBlob copies the blob from the image into the local blob store
+// unless the store already has it
+func copyBlob(ctx context.Context, img imageSource, blobs distribution.BlobStore, desc distribution.Descriptor) error {
+	if _, err := blobs.Stat(ctx, desc.Digest); err == nil {
+		return nil
+	}
+	reader, err := img.open(ctx, desc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	writer, err := blobs.Create(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer writer.Close()
+	size, err := io.Copy(writer, reader)
+	if err != nil {
+		writer.Cancel(ctx)
+		return trace.Wrap(err)
+	}
+	_, err = writer.Commit(ctx, distribution.Descriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      size,
+	})
+	return trace.Wrap(err)
+}
+
+// normalizeImage returns the fully-qualified reference of the image,
+// e.g. docker.io/library/nginx:latest for nginx
+func normalizeImage(image string) (distreference.Named, error) {
+	named, err := distreference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, trace.BadParameter("invalid image reference %q: %v", image, err)
+	}
+	return distreference.TagNameOnly(named), nil
+}
+
+// registryURL returns the base URL of the registry with the specified address
+func registryURL(addr string) string {
+	if addr == dockerHubDomain {
+		addr = dockerHubRegistry
+	}
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		return strings.TrimSuffix(addr, "/")
+	}
+	return fmt.Sprintf("https://%v", strings.TrimSuffix(addr, "/"))
+}
+
+// dockerConfigPath returns the path to the Docker client configuration file
+func dockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home := os.Getenv(constants.EnvHome)
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+const (
+	// dockerHubDomain is the domain of Docker Hub images
+	dockerHubDomain = "docker.io"
+	// dockerHubRegistry is the address of Docker Hub registry
+	dockerHubRegistry = "registry-1.docker.io"
+	// maxManifestSize is the maximum size of the manifest fetched from registries
+	maxManifestSize = 4 << 20
+	// fetcherConnectTimeout is the timeout for connecting to registries
+	fetcherConnectTimeout = 30 * time.Second
+)
+
+// manifestMediaTypes lists the supported manifest media types
+var manifestMediaTypes = []string{
+	schema2.MediaTypeManifest,
+	manifestlist.MediaTypeManifestList,
+	ociMediaTypeImageManifest,
+	ociMediaTypeImageIndex,
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/opencontainers/go-digest"
+	. "gopkg.in/check.v1"
+)
+
+type FetcherSuite struct{}
+
+var _ = Suite(&FetcherSuite{})
+
+func (s *FetcherSuite) TestExportsImageFromArchive(c *C) {
+	dir := c.MkDir()
+	archive := filepath.Join(dir, "image.tar")
+	writeTestArchive(c, archive, "example.com/app:1.0.0", map[string]string{
+		"etc/app.conf": "hello",
+	})
+	fetcher, err := NewImageFetcher(ImageFetcherConfig{
+		Sources:          []string{archive},
+		CacheDir:         c.MkDir(),
+		DockerConfigPath: filepath.Join(dir, "missing.json"),
+	})
+	c.Assert(err, IsNil)
+	defer fetcher.Close()
+
+	registryDir := c.MkDir()
+	err = fetcher.Export(context.TODO(), []string{"example.com/app:1.0.0"},
+		registryDir, 1, utils.NewNopProgress())
+	c.Assert(err, IsNil)
+
+	store, err := openLocal(registryDir)
+	c.Assert(err, IsNil)
+	repo, err := store.Repository(context.TODO(), "app")
+	c.Assert(err, IsNil)
+	tags, err := repo.Tags(context.TODO()).All(context.TODO())
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, []string{"1.0.0"})
+
+	rootfs := c.MkDir()
+	err = fetcher.Unpack(context.TODO(), "example.com/app:1.0.0", rootfs)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filepath.Join(rootfs, "etc", "app.conf"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
+}
+
+func (s *FetcherSuite) TestSyncsMultiPlatformImage(c *C) {
+	layout := c.MkDir()
+	platforms := []Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+		{OS: "linux", Architecture: "ppc64le"},
+	}
+	writeTestOCILayout(c, layout, "example.com/app:1.0.0", platforms)
+	fetcher, err := NewImageFetcher(ImageFetcherConfig{
+		Sources:   []string{layout},
+		CacheDir:  c.MkDir(),
+		Platforms: platforms[:2],
+	})
+	c.Assert(err, IsNil)
+	defer fetcher.Close()
+
+	registryDir := c.MkDir()
+	err = fetcher.Export(context.TODO(), []string{"example.com/app:1.0.0"},
+		registryDir, 1, utils.NewNopProgress())
+	c.Assert(err, IsNil)
+
+	store, err := openLocal(registryDir)
+	c.Assert(err, IsNil)
+	repo, err := store.Repository(context.TODO(), "app")
+	c.Assert(err, IsNil)
+	desc, err := repo.Tags(context.TODO()).Get(context.TODO(), "1.0.0")
+	c.Assert(err, IsNil)
+	manifests, err := repo.Manifests(context.TODO())
+	c.Assert(err, IsNil)
+	manifest, err := manifests.Get(context.TODO(), desc.Digest)
+	c.Assert(err, IsNil)
+	list, ok := manifest.(*manifestlist.DeserializedManifestList)
+	c.Assert(ok, Equals, true)
+	c.Assert(list.Manifests, HasLen, 2)
+	c.Assert(list.Manifests[1].Platform.Architecture, Equals, "arm64")
+
+	registry, err := NewRegistry(BasicConfiguration("127.0.0.1:0", c.MkDir()))
+	c.Assert(err, IsNil)
+	c.Assert(registry.Start(), IsNil)
+	defer registry.Close()
+	service, err := NewImageService(RegistryConnectionRequest{
+		RegistryAddress: registry.Addr(),
+	})
+	c.Assert(err, IsNil)
+	tags, err := service.Sync(context.TODO(), registryDir, utils.NopEmitter())
+	c.Assert(err, IsNil)
+	c.Assert(tags, DeepEquals, []TagSpec{{Name: "app", Version: "1.0.0"}})
+
+	// pull the arm64 image back from the registry
+	remote, err := NewImageFetcher(ImageFetcherConfig{
+		Mirrors:   map[string][]string{registry.Addr(): {"http://" + registry.Addr()}},
+		Platforms: platforms[1:2],
+	})
+	c.Assert(err, IsNil)
+	defer remote.Close()
+	rootfs := c.MkDir()
+	err = remote.Unpack(context.TODO(), registry.Addr()+"/app:1.0.0", rootfs)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filepath.Join(rootfs, "platform"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "linux/arm64")
+
+	// the registry has no image for other platforms
+	remote.Platforms = platforms[2:]
+	err = remote.Unpack(context.TODO(), registry.Addr()+"/app:1.0.0", c.MkDir())
+	c.Assert(err, ErrorMatches, "(?s).*no image for platform linux/ppc64le.*")
+}
+
+func (s *FetcherSuite) TestParsesPlatforms(c *C) {
+	platforms, err := ParsePlatforms([]string{"linux/amd64", "linux/arm/v7", "linux/amd64"})
+	c.Assert(err, IsNil)
+	c.Assert(platforms, DeepEquals, []Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm", Variant: "v7"},
+	})
+	for _, platform := range []string{"", "linux", "linux/", "linux/arm/v7/x"} {
+		_, err := ParsePlatform(platform)
+		c.Assert(err, NotNil, Commentf(platform))
+	}
+}
+
+func (s *FetcherSuite) TestAppliesWhiteouts(c *C) {
+	rootfs := c.MkDir()
+	for _, path := range []string{"removed", "opaque/a", "opaque/b", "kept"} {
+		c.Assert(os.MkdirAll(filepath.Dir(filepath.Join(rootfs, path)), 0755), IsNil)
+		c.Assert(ioutil.WriteFile(filepath.Join(rootfs, path), []byte(path), 0644), IsNil)
+	}
+	layer := filepath.Join(c.MkDir(), "layer.tar")
+	c.Assert(ioutil.WriteFile(layer, testTar(c, map[string]string{
+		".wh.removed":         "",
+		"opaque/.wh..wh..opq": "",
+		"opaque/c":            "c",
+	}), 0644), IsNil)
+
+	c.Assert(applyLayer(layer, rootfs), IsNil)
+
+	var files []string
+	err := filepath.Walk(rootfs, func(path string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			rel, _ := filepath.Rel(rootfs, path)
+			files = append(files, rel)
+		}
+		return err
+	})
+	c.Assert(err, IsNil)
+	c.Assert(files, DeepEquals, []string{"kept", "opaque/c"})
+}
+
+func (s *FetcherSuite) TestReadsDockerCredentials(c *C) {
+	path := filepath.Join(c.MkDir(), "config.json")
+	config := dockerConfig{
+		Auths: map[string]dockerAuth{
+			"https://index.docker.io/v1/": {
+				Auth: base64.StdEncoding.EncodeToString([]byte("user:secret")),
+			},
+			"quay.io": {Username: "robot", Password: "token"},
+		},
+	}
+	data, err := json.Marshal(config)
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(path, data, 0600), IsNil)
+
+	credentials, err := readDockerCredentials(path)
+	c.Assert(err, IsNil)
+	c.Assert(credentials, DeepEquals, map[string]staticCredentials{
+		"docker.io": {username: "user", password: "secret"},
+		"quay.io":   {username: "robot", password: "token"},
+	})
+}
+
+func (s *FetcherSuite) TestRegistryHost(c *C) {
+	var testCases = []struct {
+		addr string
+		host string
+	}{
+		{addr: "https://index.docker.io/v1/", host: "docker.io"},
+		{addr: "registry-1.docker.io", host: "docker.io"},
+		{addr: "quay.io", host: "quay.io"},
+		{addr: "https://registry.example.com:5000", host: "registry.example.com:5000"},
+	}
+	for _, testCase := range testCases {
+		c.Assert(registryHost(testCase.addr), Equals, testCase.host, Commentf(testCase.addr))
+	}
+}
+
+// writeTestArchive writes a single-layer image in the docker-archive
+// format as produced by docker save
+func writeTestArchive(c *C, path, tag string, files map[string]string) {
+	layer := testTar(c, files)
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+	})
+	c.Assert(err, IsNil)
+	manifest, err := json.Marshal([]archiveManifest{{
+		Config:   "config.json",
+		RepoTags: []string{tag},
+		Layers:   []string{"layer/layer.tar"},
+	}})
+	c.Assert(err, IsNil)
+	c.Assert(ioutil.WriteFile(path, testTarBytes(c, map[string][]byte{
+		"config.json":     config,
+		"layer/layer.tar": layer,
+		"manifest.json":   manifest,
+	}), 0644), IsNil)
+}
+
+// writeTestOCILayout writes an OCI image layout with an image for each of
+// the platforms. Each image has a single file with the name of its platform
+func writeTestOCILayout(c *C, dir, name string, platforms []Platform) {
+	c.Assert(os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755), IsNil)
+	writeBlob := func(mediaType string, data []byte) distribution.Descriptor {
+		dgst := digest.FromBytes(data)
+		c.Assert(ioutil.WriteFile(ociBlobPath(dir, dgst), data, 0644), IsNil)
+		return distribution.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(data))}
+	}
+	marshal := func(v interface{}) []byte {
+		data, err := json.Marshal(v)
+		c.Assert(err, IsNil)
+		return data
+	}
+	var manifests []ociDescriptor
+	for _, platform := range platforms {
+		var layer bytes.Buffer
+		gz := gzip.NewWriter(&layer)
+		_, err := gz.Write(testTar(c, map[string]string{"platform": platform.String()}))
+		c.Assert(err, IsNil)
+		c.Assert(gz.Close(), IsNil)
+		config := writeBlob("application/vnd.oci.image.config.v1+json", marshal(imageConfig{
+			OS:           platform.OS,
+			Architecture: platform.Architecture,
+		}))
+		manifest := writeBlob(ociMediaTypeImageManifest, marshal(map[string]interface{}{
+			"schemaVersion": 2,
+			"config":        config,
+			"layers": []distribution.Descriptor{
+				writeBlob("application/vnd.oci.image.layer.v1.tar+gzip", layer.Bytes()),
+			},
+		}))
+		manifests = append(manifests, ociDescriptor{Descriptor: manifest, Platform: platform.spec()})
+	}
+	index := writeBlob(ociMediaTypeImageIndex, marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests":     manifests,
+	}))
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ociLayoutFile),
+		[]byte(`{"imageLayoutVersion":"1.0.0"}`), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ociIndexFile), marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests": []ociDescriptor{{
+			Descriptor:  index,
+			Annotations: map[string]string{containerdImageNameAnnotation: name},
+		}},
+	}), 0644), IsNil)
+}
+
+func testTar(c *C, files map[string]string) []byte {
+	contents := make(map[string][]byte, len(files))
+	for name, data := range files {
+		contents[name] = []byte(data)
+	}
+	return testTarBytes(c, contents)
+}
+
+func testTarBytes(c *C, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		c.Assert(tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(data)),
+		}), IsNil)
+		_, err := tw.Write(data)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tw.Close(), IsNil)
+	return buf.Bytes()
+}
@@ -39,6 +39,8 @@ import (
 
 	"github.com/docker/distribution"
 	"github.com/docker/distribution/context"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
 	"github.com/docker/distribution/registry/api/errcode"
 	registryclient "github.com/docker/distribution/registry/client"
 	registrystorage "github.com/docker/distribution/registry/storage"
@@ -482,10 +484,45 @@ func (s *remoteStore) updateRepo(ctx context.Context, remote, local distribution
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if list, ok := manifest.(*manifestlist.DeserializedManifestList); ok {
+		// the registry only accepts manifest lists referencing existing
+		// manifests, so push the manifests for all platforms first
+		localManifests, err := local.Manifests(ctx)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, desc := range list.References() {
+			platformManifest, err := localManifests.Get(ctx, desc.Digest)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if err := s.pushBlobs(ctx, remote, local, platformManifest); err != nil {
+				return trace.Wrap(err)
+			}
+			s.Debugf("Updating manifest %v for %v.", desc.Digest, local.Named())
+			if _, err := remoteManifests.Put(ctx, platformManifest); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	} else if err := s.pushBlobs(ctx, remote, local, manifest); err != nil {
+		return trace.Wrap(err)
+	}
+	s.Debugf("Updating manifest for %v.", local.Named())
+	_, err = remoteManifests.Put(ctx, manifest, distribution.WithTag(tag))
+	return trace.Wrap(err)
+}
+
+// pushBlobs copies the blobs referenced by the image manifest from the local
+// repository to the remote one unless the remote repository has them
+func (s *remoteStore) pushBlobs(ctx context.Context, remote, local distribution.Repository, manifest distribution.Manifest) error {
 	localBlobs := local.Blobs(ctx)
 	remoteBlobs := remote.Blobs(ctx)
 	// copy layers:
 	for _, localDesc := range manifest.References() {
+		if localDesc.MediaType == schema2.MediaTypeForeignLayer {
+			s.Debugf("Skipping foreign layer %v.", localDesc.Digest)
+			continue
+		}
 		desc, err := remoteBlobs.Stat(ctx, localDesc.Digest)
 		if err == nil && desc.Digest == localDesc.Digest {
 			s.Debugf("Skipping layer %v.", localDesc.Digest)
@@ -512,7 +549,5 @@ func (s *remoteStore) updateRepo(ctx context.Context, remote, local distribution
 		}
 		s.Debugf("Written %v bytes.", written)
 	}
-	s.Debugf("Updating manifest for %v.", local.Named())
-	_, err = remoteManifests.Put(ctx, manifest, distribution.WithTag(tag))
-	return trace.Wrap(err)
+	return nil
 }
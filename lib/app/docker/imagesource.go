@@ -0,0 +1,612 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/docker/distribution"
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/docker/distribution/manifest/schema2"
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/gravitational/trace"
+	"github.com/opencontainers/go-digest"
+)
+
+// imageSource is an image from a registry or a local image source
+type imageSource interface {
+	// manifest returns the image manifest for the specified platform
+	manifest(ctx context.Context, platform Platform) (*schema2.DeserializedManifest, error)
+	// open returns the contents of the blob with the specified descriptor
+	open(ctx context.Context, desc distribution.Descriptor) (io.ReadCloser, error)
+	// String describes where the image comes from
+	String() string
+}
+
+// rawManifest is an image manifest, an image index or a manifest list
+// as stored in the registry or the image source
+type rawManifest struct {
+	mediaType string
+	payload   []byte
+}
+
+// manifestReader returns the raw manifest with the specified descriptor
+type manifestReader func(ctx context.Context, desc distribution.Descriptor) (*rawManifest, error)
+
+// platformManifest returns the manifest for the platform starting with the raw
+// manifest which is either an image manifest or an index of the manifests for
+// multiple platforms. OCI manifests are converted to Docker ones
+func platformManifest(ctx context.Context, img imageSource, raw *rawManifest, read manifestReader, platform Platform) (*schema2.DeserializedManifest, error) {
+	var selected bool
+	for {
+		switch mediaType := raw.detectMediaType(); mediaType {
+		case manifestlist.MediaTypeManifestList, ociMediaTypeImageIndex:
+			var index ociIndex
+			if err := json.Unmarshal(raw.payload, &index); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			desc, err := selectPlatform(index.platformManifests(), platform)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if raw, err = read(ctx, desc.Descriptor); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			selected = true
+		case schema2.MediaTypeManifest, ociMediaTypeImageManifest:
+			manifest, err := raw.convert()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			// single-platform images are checked against their configuration
+			if !selected {
+				if err := checkPlatform(ctx, img, manifest, platform); err != nil {
+					return nil, trace.Wrap(err)
+				}
+			}
+			return manifest, nil
+		default:
+			return nil, trace.BadParameter("unsupported manifest type %q, only image manifest "+
+				"v2 schema 2, manifest lists and OCI images are supported", mediaType)
+		}
+	}
+}
+
+// detectMediaType returns the media type of the manifest. OCI manifests
+// are not required to specify it, so it is deduced from the contents
+func (r rawManifest) detectMediaType() string {
+	var versioned struct {
+		MediaType string            `json:"mediaType"`
+		Manifests []json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(r.payload, &versioned); err == nil && versioned.MediaType != "" {
+		return versioned.MediaType
+	}
+	if r.mediaType != "" && r.mediaType != "application/json" {
+		return r.mediaType
+	}
+	if versioned.Manifests != nil {
+		return ociMediaTypeImageIndex
+	}
+	return ociMediaTypeImageManifest
+}
+
+// convert returns the image manifest as the Docker image manifest. Docker
+// manifests are returned as is, OCI manifests are converted preserving layer
+// digests as the media types only differ in the name
+func (r rawManifest) convert() (*schema2.DeserializedManifest, error) {
+	if r.detectMediaType() == schema2.MediaTypeManifest {
+		var deserialized schema2.DeserializedManifest
+		if err := deserialized.UnmarshalJSON(r.payload); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &deserialized, nil
+	}
+	var manifest schema2.Manifest
+	if err := json.Unmarshal(r.payload, &manifest); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	manifest.Versioned = schema2.SchemaVersion
+	manifest.Config.MediaType = schema2.MediaTypeImageConfig
+	for i, layer := range manifest.Layers {
+		mediaType, ok := ociLayerMediaTypes[layer.MediaType]
+		if !ok {
+			return nil, trace.BadParameter("unsupported layer media type %q", layer.MediaType)
+		}
+		manifest.Layers[i].MediaType = mediaType
+	}
+	deserialized, err := schema2.FromStruct(manifest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return deserialized, nil
+}
+
+// checkPlatform makes sure the image is built for the specified platform.
+// Images that do not record the platform in their configuration are accepted
+func checkPlatform(ctx context.Context, img imageSource, manifest *schema2.DeserializedManifest, platform Platform) error {
+	rc, err := img.open(ctx, manifest.Config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer rc.Close()
+	var config imageConfig
+	if err := json.NewDecoder(rc).Decode(&config); err != nil {
+		return trace.Wrap(err)
+	}
+	if config.OS == "" || config.Architecture == "" {
+		return nil
+	}
+	if !platform.matches(config.OS, config.Architecture, config.Variant) {
+		built := Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}
+		return trace.NotFound("no image for platform %v, image is built for %v", platform, built)
+	}
+	return nil
+}
+
+// remoteImage is an image pulled from a registry
+type remoteImage struct {
+	endpoint string
+	blobs    distribution.BlobStore
+	cache    *blobCache
+	// top is the manifest the image tag points to
+	top *rawManifest
+	// read fetches manifests from the registry
+	read manifestReader
+}
+
+func (r *remoteImage) manifest(ctx context.Context, platform Platform) (*schema2.DeserializedManifest, error) {
+	return platformManifest(ctx, r, r.top, r.read, platform)
+}
+
+// open returns the blob from the layer cache, downloading it first if necessary
+func (r *remoteImage) open(ctx context.Context, desc distribution.Descriptor) (io.ReadCloser, error) {
+	if rc, err := r.cache.open(desc.Digest); err == nil {
+		return rc, nil
+	}
+	reader, err := r.blobs.Open(ctx, desc.Digest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	if _, err := r.cache.ensure(desc.Digest, reader); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.cache.open(desc.Digest)
+}
+
+func (r *remoteImage) String() string {
+	return r.endpoint
+}
+
+// ociImage is an image from an OCI image layout directory
+type ociImage struct {
+	dir  string
+	desc distribution.Descriptor
+}
+
+func (r *ociImage) manifest(ctx context.Context, platform Platform) (*schema2.DeserializedManifest, error) {
+	top, err := r.read(ctx, r.desc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return platformManifest(ctx, r, top, r.read, platform)
+}
+
+func (r *ociImage) open(ctx context.Context, desc distribution.Descriptor) (io.ReadCloser, error) {
+	f, err := os.Open(ociBlobPath(r.dir, desc.Digest))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return f, nil
+}
+
+// read returns the manifest blob with the specified descriptor
+func (r *ociImage) read(ctx context.Context, desc distribution.Descriptor) (*rawManifest, error) {
+	data, err := ioutil.ReadFile(ociBlobPath(r.dir, desc.Digest))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if digest.FromBytes(data) != desc.Digest {
+		return nil, trace.BadParameter("blob %v in %v is corrupted", desc.Digest, r.dir)
+	}
+	return &rawManifest{mediaType: desc.MediaType, payload: data}, nil
+}
+
+func (r *ociImage) String() string {
+	return r.dir
+}
+
+// archiveImage is an image from a tarball created with 'docker save'
+type archiveImage struct {
+	path   string
+	entry  archiveManifest
+	cache  *blobCache
+	once   sync.Once
+	schema *schema2.DeserializedManifest
+	err    error
+}
+
+// manifest builds the image manifest. The configuration and the layers
+// are copied from the tarball into the layer cache, layers are compressed
+// as Docker saves them uncompressed
+func (r *archiveImage) manifest(ctx context.Context, platform Platform) (*schema2.DeserializedManifest, error) {
+	r.once.Do(func() {
+		r.schema, r.err = r.buildManifest()
+	})
+	if r.err != nil {
+		return nil, trace.Wrap(r.err)
+	}
+	if err := checkPlatform(ctx, r, r.schema, platform); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return r.schema, nil
+}
+
+func (r *archiveImage) buildManifest() (*schema2.DeserializedManifest, error) {
+	manifest := schema2.Manifest{Versioned: schema2.SchemaVersion}
+	err := readArchiveFile(r.path, r.entry.Config, func(reader io.Reader) error {
+		dgst, size, err := r.cache.add(reader)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		manifest.Config = distribution.Descriptor{
+			MediaType: schema2.MediaTypeImageConfig,
+			Digest:    dgst,
+			Size:      size,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, layer := range r.entry.Layers {
+		err := readArchiveFile(r.path, layer, func(reader io.Reader) error {
+			dgst, size, err := r.cache.add(compressed(reader))
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			manifest.Layers = append(manifest.Layers, distribution.Descriptor{
+				MediaType: schema2.MediaTypeLayer,
+				Digest:    dgst,
+				Size:      size,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	deserialized, err := schema2.FromStruct(manifest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return deserialized, nil
+}
+
+func (r *archiveImage) open(ctx context.Context, desc distribution.Descriptor) (io.ReadCloser, error) {
+	return r.cache.open(desc.Digest)
+}
+
+func (r *archiveImage) String() string {
+	return r.path
+}
+
+// indexImageSources returns the images from the specified OCI layout
+// directories and docker-archive tarballs by their normalized references
+func indexImageSources(paths []string, cache *blobCache) (map[string]imageSource, error) {
+	images := make(map[string]imageSource)
+	add := func(ref string, img imageSource) error {
+		named, err := normalizeImage(ref)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		images[named.String()] = img
+		return nil
+	}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		if fi.IsDir() {
+			index, err := readOCIIndex(path)
+			if err != nil {
+				return nil, trace.Wrap(err, "failed to read OCI image layout %v", path)
+			}
+			for _, manifest := range index.Manifests {
+				ref := ociImageName(manifest.Annotations)
+				if ref == "" {
+					continue
+				}
+				err := add(ref, &ociImage{dir: path, desc: manifest.Descriptor})
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+			}
+			continue
+		}
+		var entries []archiveManifest
+		err = readArchiveFile(path, archiveManifestFile, func(reader io.Reader) error {
+			return trace.Wrap(json.NewDecoder(reader).Decode(&entries))
+		})
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to read docker-archive %v", path)
+		}
+		for _, entry := range entries {
+			img := &archiveImage{path: path, entry: entry, cache: cache}
+			for _, ref := range entry.RepoTags {
+				if err := add(ref, img); err != nil {
+					return nil, trace.Wrap(err)
+				}
+			}
+		}
+	}
+	return images, nil
+}
+
+func readOCIIndex(dir string) (*ociIndex, error) {
+	if _, err := os.Stat(filepath.Join(dir, ociLayoutFile)); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, ociIndexFile))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var index ociIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &index, nil
+}
+
+// ociImageName returns the full image reference from the OCI manifest
+// annotations: containerd sets its own annotation to the full reference
+// while other tools may set just the tag as the ref name
+func ociImageName(annotations map[string]string) string {
+	if name := annotations[containerdImageNameAnnotation]; name != "" {
+		return name
+	}
+	name := annotations[ociRefNameAnnotation]
+	if strings.ContainsAny(name, ":/") {
+		return name
+	}
+	return ""
+}
+
+func ociBlobPath(dir string, dgst digest.Digest) string {
+	return filepath.Join(dir, "blobs", dgst.Algorithm().String(), dgst.Hex())
+}
+
+// readArchiveFile calls fn with the contents of the file with the specified
+// name from the (optionally compressed) tarball at path
+func readArchiveFile(archivePath, name string, fn func(io.Reader) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	reader, err := dockerarchive.DecompressStream(f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return trace.NotFound("file %v is not found in %v", name, archivePath)
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if path.Clean(hdr.Name) == path.Clean(name) {
+			return trace.Wrap(fn(tr))
+		}
+	}
+}
+
+// compressed returns the gzip-compressed contents of the reader unless
+// it is compressed already
+func compressed(reader io.Reader) io.Reader {
+	buffered := bufio.NewReader(reader)
+	header, _ := buffered.Peek(10)
+	if dockerarchive.DetectCompression(header) == dockerarchive.Gzip {
+		return buffered
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, buffered)
+		if err == nil {
+			err = gz.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func newBlobCache(dir string) (*blobCache, error) {
+	if err := os.MkdirAll(dir, defaults.SharedDirMask); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &blobCache{dir: dir}, nil
+}
+
+// blobCache is a directory with image blobs named by their digests
+type blobCache struct {
+	dir string
+}
+
+// open returns the contents of the cached blob
+func (r *blobCache) open(dgst digest.Digest) (io.ReadCloser, error) {
+	f, err := os.Open(r.path(dgst))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return f, nil
+}
+
+// ensure caches the blob with the specified digest unless it is cached already,
+// and returns the path to the cached blob. The contents are verified against the digest
+func (r *blobCache) ensure(dgst digest.Digest, reader io.Reader) (path string, err error) {
+	path = r.path(dgst)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	verifier := dgst.Verifier()
+	err = r.write(path, io.TeeReader(reader, verifier))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if !verifier.Verified() {
+		os.Remove(path)
+		return "", trace.BadParameter("blob %v failed digest verification", dgst)
+	}
+	return path, nil
+}
+
+// add caches the contents of the reader and returns its digest and size
+func (r *blobCache) add(reader io.Reader) (digest.Digest, int64, error) {
+	f, err := ioutil.TempFile(r.dir, "blob")
+	if err != nil {
+		return "", 0, trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(f, io.TeeReader(reader, digester.Hash()))
+	if err != nil {
+		return "", 0, trace.ConvertSystemError(err)
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, trace.ConvertSystemError(err)
+	}
+	dgst := digester.Digest()
+	if err := os.Rename(f.Name(), r.path(dgst)); err != nil {
+		return "", 0, trace.ConvertSystemError(err)
+	}
+	return dgst, size, nil
+}
+
+// write atomically writes the contents of the reader into the file at path
+func (r *blobCache) write(path string, reader io.Reader) error {
+	f, err := ioutil.TempFile(r.dir, "blob")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := io.Copy(f, reader); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if err := f.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(os.Rename(f.Name(), path))
+}
+
+func (r *blobCache) path(dgst digest.Digest) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%v-%v", dgst.Algorithm(), dgst.Hex()))
+}
+
+// ociIndex is the OCI image index
+type ociIndex struct {
+	// Manifests lists the image manifests
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// platformManifests returns the index manifests as manifest list entries
+func (r ociIndex) platformManifests() (manifests []manifestlist.ManifestDescriptor) {
+	for _, manifest := range r.Manifests {
+		manifests = append(manifests, manifestlist.ManifestDescriptor{
+			Descriptor: manifest.Descriptor,
+			Platform:   manifest.Platform,
+		})
+	}
+	return manifests
+}
+
+// ociDescriptor is the OCI content descriptor
+type ociDescriptor struct {
+	distribution.Descriptor
+	// Platform is the platform of the referenced image
+	Platform manifestlist.PlatformSpec `json:"platform"`
+	// Annotations are the descriptor annotations
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// imageConfig is the part of the image configuration with the platform
+type imageConfig struct {
+	// OS is the operating system the image is built for
+	OS string `json:"os"`
+	// Architecture is the CPU architecture the image is built for
+	Architecture string `json:"architecture"`
+	// Variant is the CPU variant the image is built for
+	Variant string `json:"variant,omitempty"`
+}
+
+// archiveManifest describes an image in the tarball created with 'docker save'
+type archiveManifest struct {
+	// Config is the path to the image configuration
+	Config string `json:"Config"`
+	// RepoTags lists the image references
+	RepoTags []string `json:"RepoTags"`
+	// Layers lists the paths to the image layers
+	Layers []string `json:"Layers"`
+}
+
+// ociLayerMediaTypes maps OCI layer media types to Docker ones
+var ociLayerMediaTypes = map[string]string{
+	"application/vnd.oci.image.layer.v1.tar+gzip":                  schema2.MediaTypeLayer,
+	"application/vnd.oci.image.layer.v1.tar":                       schema2.MediaTypeUncompressedLayer,
+	"application/vnd.oci.image.layer.nondistributable.v1.tar+gzip": schema2.MediaTypeForeignLayer,
+	schema2.MediaTypeLayer:                                         schema2.MediaTypeLayer,
+	schema2.MediaTypeUncompressedLayer:                             schema2.MediaTypeUncompressedLayer,
+	schema2.MediaTypeForeignLayer:                                  schema2.MediaTypeForeignLayer,
+}
+
+const (
+	// ociLayoutFile is the marker file of the OCI image layout
+	ociLayoutFile = "oci-layout"
+	// ociIndexFile is the image index of the OCI image layout
+	ociIndexFile = "index.json"
+	// ociMediaTypeImageIndex is the media type of the OCI image index
+	ociMediaTypeImageIndex = "application/vnd.oci.image.index.v1+json"
+	// ociMediaTypeImageManifest is the media type of the OCI image manifest
+	ociMediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	// ociRefNameAnnotation is the OCI annotation with the image reference
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+	// containerdImageNameAnnotation is the containerd annotation with the image reference
+	containerdImageNameAnnotation = "io.containerd.image.name"
+	// archiveManifestFile is the manifest of the tarball created with 'docker save'
+	archiveManifestFile = "manifest.json"
+)
@@ -0,0 +1,127 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/gravitational/trace"
+)
+
+// applyLayer applies the image layer from the file at path to the root
+// filesystem in dir. Whiteout entries of the layer remove the files of
+// the lower layers, the rest of the layer is extracted on top of them.
+// Unlike Docker, it does not require root privileges: file ownership is
+// not preserved and device nodes are skipped when running as a regular user
+func applyLayer(path, dir string) error {
+	err := walkLayer(path, func(hdr *tar.Header, _ io.Reader) error {
+		name := filepath.Clean("/" + hdr.Name)
+		base, parent := filepath.Base(name), filepath.Join(dir, filepath.Dir(name))
+		switch {
+		case base == whiteoutOpaqueDir:
+			return trace.Wrap(removeDirContents(parent))
+		case strings.HasPrefix(base, whiteoutPrefix):
+			target := filepath.Join(parent, strings.TrimPrefix(base, whiteoutPrefix))
+			return trace.ConvertSystemError(os.RemoveAll(target))
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := walkLayer(path, func(hdr *tar.Header, r io.Reader) error {
+			if strings.HasPrefix(filepath.Base(hdr.Name), whiteoutPrefix) {
+				return nil
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return trace.Wrap(err)
+			}
+			_, err := io.Copy(tw, r)
+			return trace.Wrap(err)
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	defer reader.Close()
+	return trace.Wrap(dockerarchive.Untar(reader, dir, &dockerarchive.TarOptions{
+		NoLchown: true,
+		InUserNS: os.Geteuid() != 0,
+	}))
+}
+
+// walkLayer calls fn for each entry of the (optionally compressed) layer at path
+func walkLayer(path string, fn func(*tar.Header, io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	reader, err := dockerarchive.DecompressStream(f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+}
+
+// removeDirContents removes the contents of the directory if it exists
+func removeDirContents(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
+
+const (
+	// whiteoutPrefix marks the files removed by the layer
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaqueDir marks the directory whose contents in
+	// the lower layers are hidden by the layer
+	whiteoutOpaqueDir = ".wh..wh..opq"
+)
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/distribution/manifest/manifestlist"
+	"github.com/gravitational/trace"
+)
+
+// Platform identifies the operating system and CPU architecture
+// of the nodes an image is built for
+type Platform struct {
+	// OS is the operating system, e.g. linux
+	OS string
+	// Architecture is the CPU architecture, e.g. amd64 or arm64
+	Architecture string
+	// Variant is the optional CPU variant, e.g. v7 for arm
+	Variant string
+}
+
+// DefaultPlatform is the platform of the vendored images
+// unless specified otherwise
+var DefaultPlatform = Platform{OS: "linux", Architecture: "amd64"}
+
+// ParsePlatform parses the platform in the os/arch[/variant] format,
+// e.g. linux/arm64 or linux/arm/v7
+func ParsePlatform(platform string) (*Platform, error) {
+	parts := strings.Split(platform, "/")
+	for _, part := range parts {
+		if part == "" {
+			parts = nil
+			break
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return &Platform{OS: parts[0], Architecture: parts[1]}, nil
+	case 3:
+		return &Platform{OS: parts[0], Architecture: parts[1], Variant: parts[2]}, nil
+	}
+	return nil, trace.BadParameter("invalid platform %q, expected os/arch[/variant], e.g. linux/arm64", platform)
+}
+
+// ParsePlatforms parses the list of platforms skipping duplicates
+func ParsePlatforms(platforms []string) (result []Platform, err error) {
+	seen := make(map[Platform]bool)
+	for _, platform := range platforms {
+		parsed, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if !seen[*parsed] {
+			seen[*parsed] = true
+			result = append(result, *parsed)
+		}
+	}
+	return result, nil
+}
+
+// String returns the platform in the os/arch[/variant] format
+func (r Platform) String() string {
+	if r.Variant != "" {
+		return fmt.Sprintf("%v/%v/%v", r.OS, r.Architecture, r.Variant)
+	}
+	return fmt.Sprintf("%v/%v", r.OS, r.Architecture)
+}
+
+// matches returns true if the image for the specified platform can be
+// used for this platform. The CPU variant is only compared if both specify it
+func (r Platform) matches(platformOS, arch, variant string) bool {
+	if r.OS != platformOS || r.Architecture != arch {
+		return false
+	}
+	return r.Variant == "" || variant == "" || r.Variant == variant
+}
+
+// spec returns the platform as the manifest list platform specification
+func (r Platform) spec() manifestlist.PlatformSpec {
+	return manifestlist.PlatformSpec{
+		OS:           r.OS,
+		Architecture: r.Architecture,
+		Variant:      r.Variant,
+	}
+}
+
+// selectPlatform returns the manifest for the specified platform from the manifest list
+func selectPlatform(manifests []manifestlist.ManifestDescriptor, platform Platform) (*manifestlist.ManifestDescriptor, error) {
+	for _, manifest := range manifests {
+		if platform.matches(manifest.Platform.OS, manifest.Platform.Architecture, manifest.Platform.Variant) {
+			return &manifest, nil
+		}
+	}
+	return nil, trace.NotFound("no image for platform %v", platform)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package docker
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -37,19 +38,7 @@ import (
 
 // TranslateRuntimeImage translates the specified docker image
 // into a gravity package specified in req.
-func TranslateRuntimeImage(req TranslateImageRequest) error {
-	f, err := ioutil.TempFile("", "gravity-runtime")
-	if err != nil {
-		return trace.ConvertSystemError(err)
-	}
-	defer func() {
-		f.Close()
-		if errRemove := os.Remove(f.Name()); errRemove != nil {
-			log.Warnf("Failed to remove tarball %v: %v.",
-				f.Name(), errRemove)
-		}
-	}()
-
+func TranslateRuntimeImage(req TranslateImageRequest) (err error) {
 	packageDir, err := ioutil.TempDir("", "runtime")
 	if err != nil {
 		return trace.ConvertSystemError(err)
@@ -65,6 +54,62 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 		}
 	}()
 
+	rootfsDir := filepath.Join(packageDir, "rootfs")
+	if err := os.MkdirAll(rootfsDir, defaults.SharedDirMask); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if req.Fetcher != nil {
+		log.WithField("package", req.Package).Info("Unpacking docker image to the gravity package.")
+		err = req.Fetcher.Unpack(context.TODO(), req.Image, rootfsDir)
+	} else {
+		err = exportContainer(req, rootfsDir)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := utils.CopyFile(
+		filepath.Join(packageDir, pack.ManifestFilename),
+		filepath.Join(rootfsDir, "/etc/planet", pack.ManifestFilename)); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Info("Compressing intermediate package directory.")
+	reader, err := dockerarchive.Tar(packageDir, dockerarchive.Gzip)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	err = req.UpsertRepository(req.Package.Repository, time.Time{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Info("Creating resulting package.")
+	_, err = req.UpsertPackage(req.Package, reader,
+		pack.WithLabels(pack.RuntimePackageLabels))
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// exportContainer exports the filesystem of the image into rootfsDir
+// using a temporary container
+func exportContainer(req TranslateImageRequest, rootfsDir string) error {
+	f, err := ioutil.TempFile("", "gravity-runtime")
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer func() {
+		f.Close()
+		if errRemove := os.Remove(f.Name()); errRemove != nil {
+			log.Warnf("Failed to remove tarball %v: %v.",
+				f.Name(), errRemove)
+		}
+	}()
+
 	createOpts := dockerapi.CreateContainerOptions{
 		Name: fmt.Sprintf("planet-export-%v", utilrand.String(4)),
 		Config: &dockerapi.Config{
@@ -78,10 +123,9 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 	}
 
 	log := log.WithFields(log.Fields{
-		"intermediate tarball":           f.Name(),
-		"intermediate package directory": packageDir,
-		"container ID":                   container.ID,
-		"package":                        req.Package,
+		"intermediate tarball": f.Name(),
+		"container ID":         container.ID,
+		"package":              req.Package,
 	})
 	log.Info("Translating docker image to the gravity package.")
 
@@ -113,39 +157,11 @@ func TranslateRuntimeImage(req TranslateImageRequest) error {
 			"failed to seek file")
 	}
 
-	rootfsDir := filepath.Join(packageDir, "rootfs")
-	if err := os.MkdirAll(rootfsDir, defaults.SharedDirMask); err != nil {
-		return trace.ConvertSystemError(err)
-	}
 	if err := dockerarchive.Untar(f, rootfsDir,
 		&dockerarchive.TarOptions{NoLchown: true}); err != nil {
 		return trace.Wrap(err)
 	}
 
-	if err := utils.CopyFile(
-		filepath.Join(packageDir, pack.ManifestFilename),
-		filepath.Join(rootfsDir, "/etc/planet", pack.ManifestFilename)); err != nil {
-		return trace.Wrap(err)
-	}
-
-	log.Info("Compressing intermediate package directory.")
-	reader, err := dockerarchive.Tar(packageDir, dockerarchive.Gzip)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	err = req.UpsertRepository(req.Package.Repository, time.Time{})
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	log.Info("Creating resulting package.")
-	_, err = req.UpsertPackage(req.Package, reader,
-		pack.WithLabels(pack.RuntimePackageLabels))
-	if err != nil && !trace.IsAlreadyExists(err) {
-		return trace.Wrap(err)
-	}
-
 	return nil
 }
 
@@ -160,6 +176,8 @@ type TranslateImageRequest struct {
 	Package loc.Locator
 	// Client is the docker client
 	DockerInterface
+	// Fetcher, if set, unpacks the image without Docker daemon
+	Fetcher *ImageFetcher
 	// PackageService is the package service to create package in
 	pack.PackageService
 }
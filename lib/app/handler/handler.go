@@ -99,6 +99,7 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.GET("/app/v1/applications/:repository_id/:package_id/:version/manifest", h.needsAuth(h.getAppManifest))
 	h.GET("/app/v1/applications/:repository_id/:package_id/:version/resources", h.needsAuth(h.getAppResources))
 	h.GET("/app/v1/applications/:repository_id/:package_id/:version/standalone-installer", h.needsAuth(h.getAppInstaller))
+	h.GET("/app/v1/applications/:repository_id/:package_id/:version/sbom", h.needsAuth(h.getAppSBOM))
 	// this method will allow to pass access token in headers, so web api should submit a form to the following URL
 	// the server will reply with the download response
 	h.POST("/app/v1/applications/:repository_id/:package_id/:version/standalone-installer", h.needsAuth(h.getAppInstaller))
@@ -387,6 +388,33 @@ func (h *WebHandler) getAppInstaller(w http.ResponseWriter,
 	return nil
 }
 
+/* getAppSBOM returns the software bill of materials of the specified application
+
+GET /app/v1/applications/:repository_id/:package_id/:version/sbom
+
+Success Response:
+
+  sbom.Document
+
+*/
+func (h *WebHandler) getAppSBOM(w http.ResponseWriter,
+	req *http.Request, params httprouter.Params,
+	context *handlerContext) error {
+
+	locator, err := appPackage(params)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	doc, err := context.applications.GetAppSBOM(*locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	roundtrip.ReplyJSON(w, http.StatusOK, doc)
+	return nil
+}
+
 /* listApps returns a list of applications (optionally for specific repository)
 
 GET /app/v1/applications/:repository_id
@@ -0,0 +1,212 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+)
+
+// cdxDocument is the CycloneDX 1.4 bill of materials in the JSON format
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components,omitempty"`
+	Dependencies []cdxDependency `json:"dependencies,omitempty"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Group      string         `json:"group,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Purl       string         `json:"purl,omitempty"`
+	Hashes     []cdxHash      `json:"hashes,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// toCycloneDX converts the bill of materials to the CycloneDX document.
+// The document has no serial number to keep it the same for the same application
+func toCycloneDX(doc Document) *cdxDocument {
+	name, ver := tool()
+	var refs idGenerator
+	newComponent := func(componentType string, locator loc.Locator, kind string) cdxComponent {
+		return cdxComponent{
+			Type:       componentType,
+			BOMRef:     refs.next(purl(locator)),
+			Group:      locator.Repository,
+			Name:       locator.Name,
+			Version:    locator.Version,
+			Purl:       purl(locator),
+			Properties: []cdxProperty{{Name: cdxKindProperty, Value: kind}},
+		}
+	}
+	app := newComponent("application", doc.Application, "application")
+	out := &cdxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.Created.UTC().Format(time.RFC3339),
+			Tools:     []cdxTool{{Vendor: "Gravitational", Name: name, Version: ver}},
+			Component: app,
+		},
+	}
+	dependencies := map[string][]string{}
+	var order []string
+	dependOn := func(ref, dependency string) {
+		if _, ok := dependencies[ref]; !ok {
+			order = append(order, ref)
+		}
+		dependencies[ref] = append(dependencies[ref], dependency)
+	}
+	appRefs := map[string]string{doc.Application.String(): app.BOMRef}
+	for _, dependency := range doc.Apps {
+		component := newComponent("application", dependency, "application")
+		appRefs[dependency.String()] = component.BOMRef
+		out.Components = append(out.Components, component)
+		dependOn(app.BOMRef, component.BOMRef)
+	}
+	for _, pkg := range doc.Packages {
+		kind := "package"
+		if doc.Runtime != nil && pkg.IsEqualTo(*doc.Runtime) {
+			kind = "runtime"
+		}
+		component := newComponent("file", pkg, kind)
+		out.Components = append(out.Components, component)
+		dependOn(app.BOMRef, component.BOMRef)
+	}
+	containerRef := func(locator loc.Locator) string {
+		if ref, ok := appRefs[locator.String()]; ok {
+			return ref
+		}
+		return app.BOMRef
+	}
+	for _, image := range doc.Images {
+		component := cdxComponent{
+			Type:       "container",
+			BOMRef:     refs.next(imagePurl(image.Repository, image.Digest)),
+			Name:       image.Repository,
+			Version:    image.Tag,
+			Purl:       imagePurl(image.Repository, image.Digest),
+			Hashes:     cdxHashes(image.Digest),
+			Properties: []cdxProperty{{Name: cdxKindProperty, Value: "image"}},
+		}
+		for _, manifest := range image.Manifests {
+			if manifest.Platform == "" {
+				component.Components = append(component.Components, cdxLayers(manifest.Layers)...)
+				continue
+			}
+			component.Components = append(component.Components, cdxComponent{
+				Type:    "container",
+				Name:    image.Repository,
+				Version: image.Tag,
+				Purl:    imagePurl(image.Repository, manifest.Digest),
+				Hashes:  cdxHashes(manifest.Digest),
+				Properties: []cdxProperty{
+					{Name: cdxKindProperty, Value: "image"},
+					{Name: cdxPlatformProperty, Value: manifest.Platform},
+				},
+				Components: cdxLayers(manifest.Layers),
+			})
+		}
+		out.Components = append(out.Components, component)
+		dependOn(containerRef(image.App), component.BOMRef)
+	}
+	for _, chart := range doc.Charts {
+		component := cdxComponent{
+			Type:       "application",
+			BOMRef:     refs.next("chart:" + chart.Name + "@" + chart.Version),
+			Name:       chart.Name,
+			Version:    chart.Version,
+			Properties: []cdxProperty{{Name: cdxKindProperty, Value: "helm-chart"}},
+		}
+		if chart.AppVersion != "" {
+			component.Properties = append(component.Properties,
+				cdxProperty{Name: cdxAppVersionProperty, Value: chart.AppVersion})
+		}
+		out.Components = append(out.Components, component)
+		dependOn(containerRef(chart.App), component.BOMRef)
+	}
+	for _, ref := range order {
+		out.Dependencies = append(out.Dependencies, cdxDependency{
+			Ref:       ref,
+			DependsOn: dependencies[ref],
+		})
+	}
+	return out
+}
+
+func cdxLayers(layers []string) (components []cdxComponent) {
+	for _, layer := range layers {
+		components = append(components, cdxComponent{
+			Type:       "file",
+			Name:       layer,
+			Hashes:     cdxHashes(layer),
+			Properties: []cdxProperty{{Name: cdxKindProperty, Value: "layer"}},
+		})
+	}
+	return components
+}
+
+func cdxHashes(digest string) []cdxHash {
+	if hash := hexDigest(digest); hash != "" {
+		return []cdxHash{{Algorithm: "SHA-256", Content: hash}}
+	}
+	return nil
+}
+
+const (
+	// cdxKindProperty is the property with the kind of the gravity component
+	cdxKindProperty = "gravity:kind"
+	// cdxPlatformProperty is the property with the platform of the image
+	cdxPlatformProperty = "gravity:platform"
+	// cdxAppVersionProperty is the property with the application version of the chart
+	cdxAppVersionProperty = "gravity:appVersion"
+)
@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+)
+
+const (
+	// FormatSPDX is the SPDX JSON format
+	FormatSPDX = "spdx"
+	// FormatCycloneDX is the CycloneDX JSON format
+	FormatCycloneDX = "cyclonedx"
+)
+
+// AllFormats lists all supported bill of materials formats
+var AllFormats = []string{FormatSPDX, FormatCycloneDX}
+
+// Encode returns the bill of materials in the specified format
+func Encode(doc Document, format string) ([]byte, error) {
+	var out interface{}
+	var err error
+	switch format {
+	case FormatSPDX:
+		out, err = toSPDX(doc)
+	case FormatCycloneDX:
+		out = toCycloneDX(doc)
+	default:
+		return nil, trace.BadParameter("unsupported bill of materials format %q, supported are: %v",
+			format, strings.Join(AllFormats, ", "))
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return append(data, '\n'), nil
+}
+
+// FileName returns the name of the bill of materials file in the specified format
+func FileName(format string) string {
+	switch format {
+	case FormatCycloneDX:
+		return "sbom.cdx.json"
+	default:
+		return "sbom.spdx.json"
+	}
+}
+
+// purl returns the package URL of the gravity package
+func purl(locator loc.Locator) string {
+	return fmt.Sprintf("pkg:generic/%v/%v@%v", locator.Repository, locator.Name, locator.Version)
+}
+
+// imagePurl returns the package URL of the docker image
+func imagePurl(repository, digest string) string {
+	return fmt.Sprintf("pkg:docker/%v@%v", repository, strings.Replace(digest, ":", "%3A", 1))
+}
+
+// hexDigest returns the hex encoded hash of the SHA-256 digest
+// or an empty string for other digests
+func hexDigest(digest string) string {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return ""
+	}
+	return strings.TrimPrefix(digest, "sha256:")
+}
+
+// tool returns the name of the tool generating bill of materials
+func tool() (name, ver string) {
+	ver = version.Get().Version
+	if ver == "" {
+		ver = "unknown"
+	}
+	return "gravity", ver
+}
+
+// idGenerator generates unique document element identifiers
+type idGenerator struct {
+	seen map[string]bool
+}
+
+// next returns the unique identifier for the specified name
+func (r *idGenerator) next(name string) string {
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+	id := name
+	for i := 2; r.seen[id]; i++ {
+		id = fmt.Sprintf("%v-%v", name, i)
+	}
+	r.seen[id] = true
+	return id
+}
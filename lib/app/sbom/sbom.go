@@ -0,0 +1,364 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sbom implements the software bill of materials of applications.
+//
+// The bill of materials lists the gravity packages the application depends on,
+// including the runtime package, the docker images vendored into the application
+// packages with their digests and layers, and the Helm charts the applications
+// ship. It is rendered as SPDX or CycloneDX JSON documents.
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+
+	dockerarchive "github.com/docker/docker/pkg/archive"
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/trace"
+)
+
+// Document is the software bill of materials of an application
+type Document struct {
+	// Application is the application the bill of materials is for
+	Application loc.Locator `json:"application"`
+	// Created is the time the application package was built at
+	Created time.Time `json:"created"`
+	// Runtime is the runtime package of the application.
+	// It is nil for applications without a runtime
+	Runtime *loc.Locator `json:"runtime,omitempty"`
+	// Packages lists all gravity packages the application depends on,
+	// including the runtime package
+	Packages []loc.Locator `json:"packages,omitempty"`
+	// Apps lists the applications the application depends on
+	Apps []loc.Locator `json:"apps,omitempty"`
+	// Images lists the docker images vendored into the application
+	// and its dependencies
+	Images []Image `json:"images,omitempty"`
+	// Charts lists the Helm charts of the application and its dependencies
+	Charts []Chart `json:"charts,omitempty"`
+}
+
+// Image describes a docker image vendored into an application package
+type Image struct {
+	// Repository is the image repository, e.g. gravitational/debian-tall
+	Repository string `json:"repository"`
+	// Tag is the image tag
+	Tag string `json:"tag"`
+	// Digest is the digest of the image manifest or manifest list
+	Digest string `json:"digest"`
+	// App is the application package the image is vendored into
+	App loc.Locator `json:"app"`
+	// Manifests lists the image manifests.
+	// Multi-platform images have a manifest per platform
+	Manifests []ImageManifest `json:"manifests,omitempty"`
+}
+
+// ImageManifest describes a single platform image manifest
+type ImageManifest struct {
+	// Platform is the platform of the image in the os/arch[/variant] format.
+	// It is empty for single platform images
+	Platform string `json:"platform,omitempty"`
+	// Digest is the digest of the manifest
+	Digest string `json:"digest"`
+	// Layers lists digests of the image layers
+	Layers []string `json:"layers,omitempty"`
+}
+
+// Chart describes a Helm chart shipped with an application
+type Chart struct {
+	// Name is the chart name
+	Name string `json:"name"`
+	// Version is the chart version
+	Version string `json:"version"`
+	// AppVersion is the optional version of the software in the chart
+	AppVersion string `json:"appVersion,omitempty"`
+	// App is the application package the chart is shipped with
+	App loc.Locator `json:"app"`
+}
+
+// ScanApp adds the docker images and Helm charts found in the application
+// package tarball read from reader to the bill of materials
+func (r *Document) ScanApp(app loc.Locator, reader io.Reader) error {
+	decompressed, err := dockerarchive.DecompressStream(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer decompressed.Close()
+	scanner := newScanner(app)
+	tarball := tar.NewReader(decompressed)
+	for {
+		hdr, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := scanner.add(path.Clean(hdr.Name), hdr.Size, tarball); err != nil {
+			return trace.Wrap(err, "failed to scan %v in %v", hdr.Name, app)
+		}
+	}
+	images, err := scanner.images()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.Images = append(r.Images, images...)
+	r.Charts = append(r.Charts, scanner.charts...)
+	r.sort()
+	return nil
+}
+
+func (r *Document) sort() {
+	sort.Slice(r.Packages, func(i, j int) bool {
+		return r.Packages[i].String() < r.Packages[j].String()
+	})
+	sort.Slice(r.Apps, func(i, j int) bool {
+		return r.Apps[i].String() < r.Apps[j].String()
+	})
+	sort.Slice(r.Images, func(i, j int) bool {
+		a, b := r.Images[i], r.Images[j]
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Tag != b.Tag {
+			return a.Tag < b.Tag
+		}
+		return a.App.String() < b.App.String()
+	})
+	sort.Slice(r.Charts, func(i, j int) bool {
+		a, b := r.Charts[i], r.Charts[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.App.String() < b.App.String()
+	})
+}
+
+func newScanner(app loc.Locator) *scanner {
+	return &scanner{
+		app:   app,
+		tags:  make(map[imageTag]string),
+		blobs: make(map[string][]byte),
+	}
+}
+
+// scanner collects image tags, image manifests and chart metadata
+// from the application package tarball in a single pass
+type scanner struct {
+	app loc.Locator
+	// tags maps image tags to manifest digests
+	tags map[imageTag]string
+	// blobs maps digests to the contents of the registry blobs
+	// that can be image manifests
+	blobs  map[string][]byte
+	charts []Chart
+}
+
+type imageTag struct {
+	repository string
+	tag        string
+}
+
+func (r *scanner) add(name string, size int64, reader io.Reader) error {
+	switch {
+	case path.Base(name) == constants.HelmChartFile &&
+		strings.HasPrefix(name, defaults.ResourcesDir+"/"):
+		return r.addChart(reader)
+	case strings.HasPrefix(name, registryPrefix):
+		return r.addRegistryFile(strings.TrimPrefix(name, registryPrefix), size, reader)
+	}
+	return nil
+}
+
+func (r *scanner) addChart(reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var chart chartMetadata
+	if err := yaml.Unmarshal(data, &chart); err != nil {
+		return trace.Wrap(err)
+	}
+	r.charts = append(r.charts, Chart{
+		Name:       chart.Name,
+		Version:    chart.Version,
+		AppVersion: chart.AppVersion,
+		App:        r.app,
+	})
+	return nil
+}
+
+func (r *scanner) addRegistryFile(name string, size int64, reader io.Reader) error {
+	// registry tag links are stored as
+	// repositories/<repository>/_manifests/tags/<tag>/current/link
+	if strings.HasPrefix(name, "repositories/") && strings.HasSuffix(name, "/current/link") {
+		name = strings.TrimSuffix(strings.TrimPrefix(name, "repositories/"), "/current/link")
+		index := strings.LastIndex(name, "/_manifests/tags/")
+		if index == -1 {
+			return nil
+		}
+		link, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		tag := imageTag{
+			repository: name[:index],
+			tag:        name[index+len("/_manifests/tags/"):],
+		}
+		r.tags[tag] = strings.TrimSpace(string(link))
+		return nil
+	}
+	// blobs are stored as blobs/sha256/<prefix>/<hex>/data. Only small JSON
+	// blobs are retained since they are the only ones that can be manifests
+	parts := strings.Split(name, "/")
+	if len(parts) != 5 || parts[0] != "blobs" || parts[4] != "data" || size > maxManifestSize {
+		return nil
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil
+	}
+	r.blobs[parts[1]+":"+parts[3]] = data
+	return nil
+}
+
+// images resolves the collected image tags into images
+func (r *scanner) images() (images []Image, err error) {
+	for tag, digest := range r.tags {
+		image := Image{
+			Repository: tag.repository,
+			Tag:        tag.tag,
+			Digest:     digest,
+			App:        r.app,
+		}
+		image.Manifests, err = r.manifests(digest)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to read manifest of %v:%v", tag.repository, tag.tag)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (r *scanner) manifests(digest string) ([]ImageManifest, error) {
+	manifest, err := r.manifest(digest)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(manifest.Manifests) == 0 {
+		return []ImageManifest{{Digest: digest, Layers: manifest.layers()}}, nil
+	}
+	var result []ImageManifest
+	for _, descriptor := range manifest.Manifests {
+		platform, err := r.manifest(descriptor.Digest)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		result = append(result, ImageManifest{
+			Platform: descriptor.Platform.String(),
+			Digest:   descriptor.Digest,
+			Layers:   platform.layers(),
+		})
+	}
+	return result, nil
+}
+
+func (r *scanner) manifest(digest string) (*imageManifest, error) {
+	data, ok := r.blobs[digest]
+	if !ok {
+		return nil, trace.NotFound("manifest %v not found", digest)
+	}
+	var manifest imageManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &manifest, nil
+}
+
+// imageManifest lists the fields of the docker schema1 and schema2 manifests,
+// manifest lists and OCI image manifests and indexes used in the bill of materials
+type imageManifest struct {
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+	FSLayers []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers"`
+	Manifests []struct {
+		Digest   string   `json:"digest"`
+		Platform platform `json:"platform"`
+	} `json:"manifests"`
+}
+
+func (r imageManifest) layers() (layers []string) {
+	for _, layer := range r.Layers {
+		layers = append(layers, layer.Digest)
+	}
+	// schema1 manifests list layers starting with the topmost one
+	for i := len(r.FSLayers) - 1; i >= 0; i-- {
+		layers = append(layers, r.FSLayers[i].BlobSum)
+	}
+	return layers
+}
+
+type platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+func (r platform) String() string {
+	parts := []string{r.OS, r.Architecture}
+	if r.Variant != "" {
+		parts = append(parts, r.Variant)
+	}
+	return strings.Join(parts, "/")
+}
+
+type chartMetadata struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	AppVersion string `json:"appVersion"`
+}
+
+// registryPrefix is the location of the docker registry storage
+// inside the application package tarball
+var registryPrefix = path.Join(defaults.RegistryDir, "docker/registry/v2") + "/"
+
+// maxManifestSize is the maximum size of the registry blob
+// considered as an image manifest
+const maxManifestSize = 4 * 1024 * 1024
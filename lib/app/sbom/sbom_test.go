@@ -0,0 +1,190 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/archive"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+	. "gopkg.in/check.v1"
+)
+
+func TestSBOM(t *testing.T) { TestingT(t) }
+
+type SBOMSuite struct{}
+
+var _ = Suite(&SBOMSuite{})
+
+func (s *SBOMSuite) TestScansApp(c *C) {
+	doc := newTestDocument(c)
+	c.Assert(doc.Charts, DeepEquals, []Chart{
+		{Name: "app", Version: "0.0.1", AppVersion: "1.0.0", App: testApp},
+	})
+	c.Assert(doc.Images, DeepEquals, []Image{
+		{
+			Repository: "gravitational/debian-tall",
+			Tag:        "0.0.1",
+			Digest:     digest("a0"),
+			App:        testApp,
+			Manifests: []ImageManifest{
+				{Digest: digest("a0"), Layers: []string{digest("b0"), digest("b1")}},
+			},
+		},
+		{
+			Repository: "gravitational/nginx",
+			Tag:        "1.17",
+			Digest:     digest("c0"),
+			App:        testApp,
+			Manifests: []ImageManifest{
+				{Platform: "linux/amd64", Digest: digest("c1"), Layers: []string{digest("b0")}},
+				{Platform: "linux/arm/v7", Digest: digest("c2"), Layers: []string{digest("b2")}},
+			},
+		},
+	})
+}
+
+func (s *SBOMSuite) TestEncodesSPDX(c *C) {
+	data, err := Encode(*newTestDocument(c), FormatSPDX)
+	c.Assert(err, IsNil)
+	var doc spdxDocument
+	c.Assert(json.Unmarshal(data, &doc), IsNil)
+	c.Assert(doc.SPDXVersion, Equals, "SPDX-2.2")
+	c.Assert(doc.CreationInfo.Created, Equals, "2019-07-01T00:00:00Z")
+
+	ids := make(map[string]spdxPackage)
+	for _, pkg := range doc.Packages {
+		_, exists := ids[pkg.SPDXID]
+		c.Assert(exists, Equals, false, Commentf("duplicate %v", pkg.SPDXID))
+		ids[pkg.SPDXID] = pkg
+	}
+	// application, runtime package, 2 images, 3 unique layers and a chart
+	c.Assert(ids, HasLen, 8)
+	image := ids["SPDXRef-Image-gravitational-nginx-1.17"]
+	c.Assert(image.ExternalRefs[0].ReferenceLocator, Equals,
+		fmt.Sprintf("pkg:docker/gravitational/nginx@sha256%%3A%v", hexDigest(digest("c0"))))
+	c.Assert(image.Checksums, DeepEquals, []spdxChecksum{
+		{Algorithm: "SHA256", ChecksumValue: hexDigest(digest("c0"))},
+	})
+	for _, relationship := range doc.Relationships {
+		if relationship.SPDXElementID != spdxDocumentID {
+			c.Assert(ids[relationship.SPDXElementID], Not(DeepEquals), spdxPackage{})
+		}
+		c.Assert(ids[relationship.RelatedSPDXElement], Not(DeepEquals), spdxPackage{})
+	}
+	c.Assert(doc.Relationships[1], DeepEquals, spdxRelationship{
+		SPDXElementID:      "SPDXRef-Package-planet-0.0.1",
+		RelationshipType:   "RUNTIME_DEPENDENCY_OF",
+		RelatedSPDXElement: "SPDXRef-App-app-0.0.1",
+	})
+
+	again, err := Encode(*newTestDocument(c), FormatSPDX)
+	c.Assert(err, IsNil)
+	c.Assert(string(again), Equals, string(data))
+}
+
+func (s *SBOMSuite) TestEncodesCycloneDX(c *C) {
+	data, err := Encode(*newTestDocument(c), FormatCycloneDX)
+	c.Assert(err, IsNil)
+	var doc cdxDocument
+	c.Assert(json.Unmarshal(data, &doc), IsNil)
+	c.Assert(doc.BOMFormat, Equals, "CycloneDX")
+	c.Assert(doc.Metadata.Component.Purl, Equals, "pkg:generic/gravitational.io/app@0.0.1")
+	c.Assert(doc.Components, HasLen, 4)
+	image := doc.Components[2]
+	c.Assert(image.Name, Equals, "gravitational/nginx")
+	c.Assert(image.Components, HasLen, 2)
+	c.Assert(image.Components[1].Properties[1], DeepEquals,
+		cdxProperty{Name: cdxPlatformProperty, Value: "linux/arm/v7"})
+	c.Assert(image.Components[1].Components[0].Hashes, DeepEquals, []cdxHash{
+		{Algorithm: "SHA-256", Content: hexDigest(digest("b2"))},
+	})
+	c.Assert(doc.Dependencies, DeepEquals, []cdxDependency{{
+		Ref: doc.Metadata.Component.BOMRef,
+		DependsOn: []string{
+			doc.Components[0].BOMRef,
+			doc.Components[1].BOMRef,
+			image.BOMRef,
+			doc.Components[3].BOMRef,
+		},
+	}})
+}
+
+func (s *SBOMSuite) TestRejectsUnknownFormat(c *C) {
+	_, err := Encode(*newTestDocument(c), "xml")
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func newTestDocument(c *C) *Document {
+	runtime := loc.MustParseLocator("gravitational.io/planet:0.0.1")
+	doc := &Document{
+		Application: testApp,
+		Created:     time.Date(2019, 7, 1, 0, 0, 0, 0, time.UTC),
+		Runtime:     &runtime,
+		Packages:    []loc.Locator{runtime},
+	}
+	manifest := func(layers ...string) string {
+		var descriptors []string
+		for _, layer := range layers {
+			descriptors = append(descriptors, fmt.Sprintf(`{"digest":%q}`, layer))
+		}
+		return fmt.Sprintf(`{"schemaVersion":2,"layers":[%v]}`, strings.Join(descriptors, ","))
+	}
+	index := fmt.Sprintf(`{"schemaVersion":2,"manifests":[`+
+		`{"digest":%q,"platform":{"os":"linux","architecture":"amd64"}},`+
+		`{"digest":%q,"platform":{"os":"linux","architecture":"arm","variant":"v7"}}]}`,
+		digest("c1"), digest("c2"))
+	items := []*archive.Item{
+		archive.ItemFromString("resources/app.yaml", "kind: Bundle"),
+		archive.ItemFromString("./resources/charts/app/Chart.yaml",
+			"name: app\nversion: 0.0.1\nappVersion: 1.0.0\n"),
+		tagLink("gravitational/debian-tall", "0.0.1", digest("a0")),
+		blob(digest("a0"), manifest(digest("b0"), digest("b1"))),
+		tagLink("gravitational/nginx", "1.17", digest("c0")),
+		blob(digest("c0"), index),
+		blob(digest("c1"), manifest(digest("b0"))),
+		blob(digest("c2"), manifest(digest("b2"))),
+		blob(digest("b0"), "layer data"),
+	}
+	c.Assert(doc.ScanApp(testApp, archive.MustCreateMemArchive(items)), IsNil)
+	return doc
+}
+
+func tagLink(repository, tag, digest string) *archive.Item {
+	return archive.ItemFromString(fmt.Sprintf(
+		"registry/docker/registry/v2/repositories/%v/_manifests/tags/%v/current/link",
+		repository, tag), digest)
+}
+
+func blob(digest, data string) *archive.Item {
+	hash := hexDigest(digest)
+	return archive.ItemFromString(fmt.Sprintf(
+		"registry/docker/registry/v2/blobs/sha256/%v/%v/data", hash[:2], hash), data)
+}
+
+// digest returns the fake SHA-256 digest starting with the prefix
+func digest(prefix string) string {
+	return fmt.Sprintf("sha256:%v%062d", prefix, 0)
+}
+
+var testApp = loc.MustParseLocator("gravitational.io/app:0.0.1")
@@ -0,0 +1,230 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// spdxDocument is the SPDX 2.2 document in the JSON format
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// toSPDX converts the bill of materials to the SPDX document
+func toSPDX(doc Document) (*spdxDocument, error) {
+	namespace, err := spdxNamespace(doc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	name, ver := tool()
+	out := &spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              fmt.Sprintf("%v-%v", doc.Application.Name, doc.Application.Version),
+		DocumentNamespace: namespace,
+		CreationInfo: spdxCreationInfo{
+			Created:  doc.Created.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %v-%v", name, ver)},
+		},
+	}
+	var ids idGenerator
+	relate := func(id, relationship, related string) {
+		out.Relationships = append(out.Relationships, spdxRelationship{
+			SPDXElementID:      id,
+			RelationshipType:   relationship,
+			RelatedSPDXElement: related,
+		})
+	}
+	addPackage := func(prefix string, locator loc.Locator, comment string) string {
+		id := ids.next(spdxID(prefix, locator.Name, locator.Version))
+		out.Packages = append(out.Packages, newSPDXPackage(id, locator.Name, locator.Version,
+			purl(locator), "", comment))
+		return id
+	}
+
+	appID := addPackage("App", doc.Application, "gravity application package")
+	relate(spdxDocumentID, "DESCRIBES", appID)
+	appIDs := map[string]string{doc.Application.String(): appID}
+	for _, app := range doc.Apps {
+		id := addPackage("App", app, "gravity application package")
+		appIDs[app.String()] = id
+		relate(appID, "DEPENDS_ON", id)
+	}
+	for _, pkg := range doc.Packages {
+		if doc.Runtime != nil && pkg.IsEqualTo(*doc.Runtime) {
+			id := addPackage("Package", pkg, "gravity runtime package")
+			relate(id, "RUNTIME_DEPENDENCY_OF", appID)
+			continue
+		}
+		id := addPackage("Package", pkg, "gravity package")
+		relate(appID, "DEPENDS_ON", id)
+	}
+	containerID := func(app loc.Locator) string {
+		if id, ok := appIDs[app.String()]; ok {
+			return id
+		}
+		return appID
+	}
+	layerIDs := make(map[string]string)
+	for _, image := range doc.Images {
+		id := ids.next(spdxID("Image", image.Repository, image.Tag))
+		var platforms []string
+		for _, manifest := range image.Manifests {
+			if manifest.Platform != "" {
+				platforms = append(platforms, manifest.Platform)
+			}
+		}
+		comment := "docker image"
+		if len(platforms) != 0 {
+			comment = fmt.Sprintf("docker image for platforms %v", strings.Join(platforms, ", "))
+		}
+		out.Packages = append(out.Packages, newSPDXPackage(id, image.Repository, image.Tag,
+			imagePurl(image.Repository, image.Digest), image.Digest, comment))
+		relate(containerID(image.App), "CONTAINS", id)
+		for _, manifest := range image.Manifests {
+			for _, layer := range manifest.Layers {
+				layerID, ok := layerIDs[layer]
+				if !ok {
+					layerID = ids.next(spdxID("Layer", layer))
+					layerIDs[layer] = layerID
+					out.Packages = append(out.Packages, newSPDXPackage(layerID, layer, "",
+						"", layer, "docker image layer"))
+				}
+				relate(id, "CONTAINS", layerID)
+			}
+		}
+	}
+	for _, chart := range doc.Charts {
+		id := ids.next(spdxID("Chart", chart.Name, chart.Version))
+		comment := "Helm chart"
+		if chart.AppVersion != "" {
+			comment = fmt.Sprintf("Helm chart of application version %v", chart.AppVersion)
+		}
+		out.Packages = append(out.Packages, newSPDXPackage(id, chart.Name, chart.Version,
+			"", "", comment))
+		relate(containerID(chart.App), "CONTAINS", id)
+	}
+	return out, nil
+}
+
+func newSPDXPackage(id, name, version, purl, digest, comment string) spdxPackage {
+	pkg := spdxPackage{
+		SPDXID:           id,
+		Name:             name,
+		VersionInfo:      version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          comment,
+	}
+	if hash := hexDigest(digest); hash != "" {
+		pkg.Checksums = []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: hash}}
+	}
+	if purl != "" {
+		pkg.ExternalRefs = []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  purl,
+		}}
+	}
+	return pkg
+}
+
+// spdxNamespace returns the unique namespace of the SPDX document.
+// The namespace is derived from the bill of materials contents
+// so the same application always gets the same document
+func spdxNamespace(doc Document) (string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("https://gravitational.io/spdx/%v/%v-%v-%v", doc.Application.Repository,
+		doc.Application.Name, doc.Application.Version, hex.EncodeToString(hash[:16])), nil
+}
+
+// spdxID returns the SPDX element identifier built from the specified parts
+func spdxID(prefix string, parts ...string) string {
+	id := strings.Join(append([]string{"SPDXRef", prefix}, parts...), "-")
+	return spdxInvalidChars.ReplaceAllString(id, "-")
+}
+
+// spdxInvalidChars matches characters not allowed in SPDX element identifiers
+var spdxInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+const (
+	// spdxDocumentID is the identifier of the SPDX document
+	spdxDocumentID = "SPDXRef-DOCUMENT"
+	// spdxNoAssertion indicates that no information about the field is provided
+	spdxNoAssertion = "NOASSERTION"
+)
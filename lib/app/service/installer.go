@@ -26,6 +26,7 @@ import (
 	"time"
 
 	appservice "github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/constants"
@@ -42,6 +43,8 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/gravitational/license/authority"
 	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/mailgun/timetools"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -92,6 +95,8 @@ func (r *applications) getClusterInstaller(
 //  * import {web-assets,gravity,dns,teleport,planet-master,planet-node,application}
 //    packages from application package service into local package service running
 //    in ./packages
+//  * add the software bill of materials of the application as ./sbom.spdx.json
+//    and ./sbom.cdx.json
 //
 func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (installer io.ReadCloser, err error) {
 	if err := req.Check(); err != nil {
@@ -109,11 +114,21 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 		}
 	}()
 
-	backendPath := filepath.Join(tempDir, "gravity.db")
+	backendConfig := keyval.BoltConfig{
+		Path: filepath.Join(tempDir, "gravity.db"),
+	}
+	packagesConfig := localpack.Config{
+		UnpackedDir: filepath.Join(tempDir, defaults.PackagesDir, defaults.UnpackedDir),
+	}
+	if !req.BuildTime.IsZero() {
+		// record the build time instead of the current time so that
+		// the same build produces the same package metadata
+		backendConfig.Clock = clockwork.NewFakeClockAt(req.BuildTime)
+		packagesConfig.Clock = &timetools.FreezedTime{CurrentTime: req.BuildTime.UTC()}
+	}
+
 	var localBackend storage.Backend
-	localBackend, err = keyval.NewBolt(keyval.BoltConfig{
-		Path: backendPath,
-	})
+	localBackend, err = keyval.NewBolt(backendConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -123,12 +138,10 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 		return nil, trace.Wrap(err)
 	}
 
+	packagesConfig.Backend = localBackend
+	packagesConfig.Objects = objects
 	var localPackages pack.PackageService
-	localPackages, err = localpack.New(localpack.Config{
-		Backend:     localBackend,
-		UnpackedDir: filepath.Join(tempDir, defaults.PackagesDir, defaults.UnpackedDir),
-		Objects:     objects,
-	})
+	localPackages, err = localpack.New(packagesConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -176,6 +189,35 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 		return nil, trace.Wrap(err)
 	}
 
+	if req.Signer != nil {
+		r.Info("Signing installer packages.")
+		if err = pack.SignPackages(localPackages, req.Signer); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	bom := req.SBOM
+	if bom == nil {
+		if bom, err = r.GetAppSBOM(app.Package); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	for _, format := range sbom.AllFormats {
+		var data []byte
+		if data, err = sbom.Encode(*bom, format); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items = append(items, archive.ItemFromStringMode(
+			sbom.FileName(format), string(data), defaults.SharedReadMask))
+	}
+
+	compress := archive.CompressDirectory
+	if !req.BuildTime.IsZero() {
+		compress = func(dir string, writer io.Writer, items ...*archive.Item) error {
+			return archive.CompressDirectoryReproducible(dir, writer, req.BuildTime, items...)
+		}
+	}
+
 	reader, writer := io.Pipe()
 	go func() {
 		uploadScript, err := renderUploadScript(*app)
@@ -186,7 +228,7 @@ func (r *applications) GetAppInstaller(req appservice.InstallerRequest) (install
 			}
 			return
 		}
-		err = archive.CompressDirectory(tempDir, writer, append(items,
+		err = compress(tempDir, writer, append(items,
 			archive.ItemFromStringMode(
 				defaults.ManifestFileName, string(manifestBytes), defaults.SharedReadMask),
 			archive.ItemFromStringMode(
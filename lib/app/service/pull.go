@@ -18,6 +18,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"io/ioutil"
 	"sync"
 	"time"
@@ -28,6 +29,7 @@ import (
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/run"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/cenkalti/backoff"
@@ -53,6 +55,13 @@ type PackagePullRequest struct {
 	Upsert bool
 	// MetadataOnly allows to pull only package metadata without body
 	MetadataOnly bool
+	// Verifier, if set, is used to verify the package signature before
+	// pulling it. Packages without a valid signature are rejected
+	Verifier *signing.Verifier
+	// Verified lists the packages that have been verified by other means,
+	// e.g. restored from a delta installer with a signed manifest,
+	// and are pulled without checking their signatures
+	Verified []loc.Locator
 }
 
 // CheckAndSetDefaults checks the package pull request and sets some defaults
@@ -90,6 +99,12 @@ type AppPullRequest struct {
 	// If < 0, the number of tasks is unrestricted.
 	// If in [0,1], the tasks are executed sequentially.
 	Parallel int
+	// Verifier, if set, is used to verify the signatures of the application
+	// and its dependencies before pulling them
+	Verifier *signing.Verifier
+	// Verified lists the packages that have been verified by other means
+	// and are pulled without checking their signatures
+	Verified []loc.Locator
 }
 
 // CheckAndSetDefaults checks the app pull request and sets some defaults
@@ -113,6 +128,8 @@ func (r *AppPullRequest) Clone(locator loc.Locator) AppPullRequest {
 		Progress:     r.Progress,
 		Parallel:     r.Parallel,
 		MetadataOnly: r.MetadataOnly,
+		Verifier:     r.Verifier,
+		Verified:     r.Verified,
 	}
 }
 
@@ -135,6 +152,8 @@ func pullPackageHandler(loc loc.Locator, req AppPullRequest, state *pullState) f
 			Upsert:       req.Upsert,
 			Progress:     req.Progress,
 			MetadataOnly: req.MetadataOnly,
+			Verifier:     req.Verifier,
+			Verified:     req.Verified,
 		}, state)
 		if !trace.IsAlreadyExists(err) {
 			return trace.Wrap(err)
@@ -180,6 +199,21 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 
 	req.Infof("Pulling package %v.", req.Package)
 
+	if err := req.verify(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if !req.MetadataOnly {
+		env, err := pullPackageChunks(req)
+		if err == nil {
+			return env, nil
+		}
+		if !trace.IsNotImplemented(err) && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		req.Debugf("Package %v cannot be pulled as chunks: %v.", req.Package, err)
+	}
+
 	reader := ioutil.NopCloser(utils.NopReader())
 	if req.MetadataOnly {
 		env, err = req.SrcPack.ReadPackageEnvelope(req.Package)
@@ -203,15 +237,7 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	// Copy runtime labels
-	if req.Labels == nil {
-		req.Labels = make(map[string]string)
-	}
-	for label, value := range env.RuntimeLabels {
-		if _, exists := req.Labels[label]; !exists {
-			req.Labels[label] = value
-		}
-	}
+	req.copyLabels(*env)
 
 	if req.Upsert {
 		env, err = req.DstPack.UpsertPackage(
@@ -227,6 +253,96 @@ func pullPackage(req PackagePullRequest) (*pack.PackageEnvelope, error) {
 	return env, nil
 }
 
+// pullPackageChunks pulls the package stored as content-defined chunks
+// transferring only the chunks missing from the destination service.
+// Returns trace.NotImplemented if either service does not support chunks
+// and trace.NotFound if the package is not stored as chunks
+func pullPackageChunks(req PackagePullRequest) (*pack.PackageEnvelope, error) {
+	src, ok := req.SrcPack.(pack.ChunkReader)
+	if !ok {
+		return nil, trace.NotImplemented("source package service does not support chunks")
+	}
+	dst, ok := req.DstPack.(pack.ChunkWriter)
+	if !ok {
+		return nil, trace.NotImplemented("destination package service does not support chunks")
+	}
+	env, err := req.SrcPack.ReadPackageEnvelope(req.Package)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chunks, err := src.GetPackageChunks(env.Locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	err = req.DstPack.UpsertRepository(env.Locator.Repository, time.Time{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	req.copyLabels(*env)
+
+	var progress io.Writer
+	if req.Progress != nil {
+		progress = &pack.ProgressWriter{
+			Size: env.SizeBytes,
+			R:    req.Progress,
+		}
+	}
+	fetch := func(chunk pack.Chunk) (io.ReadCloser, error) {
+		reader, err := src.ReadPackageChunk(env.Locator, chunk.SHA512)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if progress != nil {
+			reader = utils.TeeReadCloser(reader, progress)
+		}
+		return reader, nil
+	}
+	env, err = dst.CreatePackageFromChunks(*env, chunks, fetch, req.Upsert, pack.WithLabels(req.Labels))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return env, nil
+}
+
+// verify verifies the signature of the package in the source package service
+// if the request has a verifier. Packages pulled without contents
+// and packages verified by other means are not verified
+func (r *PackagePullRequest) verify() error {
+	if r.Verifier == nil || r.MetadataOnly || isVerified(r.Package, r.Verified) {
+		return nil
+	}
+	env, err := r.SrcPack.ReadPackageEnvelope(r.Package)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(pack.VerifyPackage(r.SrcPack, *env, r.Verifier))
+}
+
+// isVerified returns true if the package is in the list of verified packages
+func isVerified(locator loc.Locator, verified []loc.Locator) bool {
+	for _, v := range verified {
+		if v == locator {
+			return true
+		}
+	}
+	return false
+}
+
+// copyLabels adds the runtime labels of the pulled package
+// to the labels of the request
+func (r *PackagePullRequest) copyLabels(env pack.PackageEnvelope) {
+	if r.Labels == nil {
+		r.Labels = make(map[string]string)
+	}
+	for label, value := range env.RuntimeLabels {
+		if _, exists := r.Labels[label]; !exists {
+			r.Labels[label] = value
+		}
+	}
+}
+
 // PullApp pulls the application specified with app, along with all its dependencies
 // and base application, from the "source" application service and replicates it in
 // the "destination" application service
@@ -312,6 +428,13 @@ func pullApp(req AppPullRequest, state *pullState) (*app.Application, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	defer reader.Close()
+
+	if req.Verifier != nil && !req.MetadataOnly && !isVerified(req.Package, req.Verified) {
+		if err := pack.VerifyPackage(req.SrcPack, *env, req.Verifier); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 
 	if req.Progress != nil {
 		reader = utils.TeeReadCloser(reader, &pack.ProgressWriter{
@@ -319,7 +442,6 @@ func pullApp(req AppPullRequest, state *pullState) (*app.Application, error) {
 			R:    req.Progress,
 		})
 	}
-	defer reader.Close()
 
 	if req.Upsert {
 		application, err = req.DstApp.UpsertApp(env.Locator, reader, req.Labels)
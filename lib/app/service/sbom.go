@@ -0,0 +1,67 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	appservice "github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/sbom"
+	"github.com/gravitational/gravity/lib/loc"
+
+	"github.com/gravitational/trace"
+)
+
+// GetAppSBOM returns the software bill of materials of the specified application.
+// Docker images and Helm charts are collected from the application package
+// and the packages of all applications it depends on
+func (r *applications) GetAppSBOM(locator loc.Locator) (*sbom.Document, error) {
+	app, err := r.GetApp(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	dependencies, err := appservice.GetDependencies(app, r)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	doc := &sbom.Document{
+		Application: app.Package,
+		Created:     app.Manifest.Metadata.CreatedTimestamp,
+		Runtime:     dependencies.RuntimePackage,
+		Packages:    dependencies.Packages,
+		Apps:        dependencies.Apps,
+	}
+	if doc.Created.IsZero() {
+		doc.Created = app.PackageEnvelope.Created
+	}
+	for _, locator := range append([]loc.Locator{app.Package}, dependencies.Apps...) {
+		if err := r.scanAppPackage(doc, locator); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return doc, nil
+}
+
+func (r *applications) scanAppPackage(doc *sbom.Document, locator loc.Locator) error {
+	_, reader, err := r.Packages.ReadPackage(locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer reader.Close()
+	if err := doc.ScanApp(locator, reader); err != nil {
+		return trace.Wrap(err, "failed to scan application package %v", locator)
+	}
+	return nil
+}
@@ -60,20 +60,36 @@ type VendorerConfig struct {
 	RegistryURL string
 	// Packages is the pack service
 	Packages pack.PackageService
+	// ImageFetcher, if set, configures vendoring images directly from
+	// registries and image archives without Docker daemon
+	ImageFetcher *docker.ImageFetcherConfig
 }
 
 // NewVendorer creates a new vendorer instance.
 func NewVendorer(conf VendorerConfig) (*vendorer, error) {
-	dockerClient, err := docker.NewClient(conf.DockerURL)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
 	imageService, err := docker.NewImageService(docker.RegistryConnectionRequest{
 		RegistryAddress: conf.RegistryURL,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if conf.ImageFetcher != nil {
+		fetcher, err := docker.NewImageFetcher(*conf.ImageFetcher)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		v, err := NewVendorerFromClients(nil, imageService, conf.RegistryURL, conf.Packages)
+		if err != nil {
+			fetcher.Close()
+			return nil, trace.Wrap(err)
+		}
+		v.fetcher = fetcher
+		return v, nil
+	}
+	dockerClient, err := docker.NewClient(conf.DockerURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return NewVendorerFromClients(dockerClient, imageService, conf.RegistryURL, conf.Packages)
 }
 
@@ -132,6 +148,9 @@ type VendorRequest struct {
 	// ProgressReporter is a special writer, if set, vendorer will output user-friendly
 	// information during vendoring
 	ProgressReporter utils.Progress
+	// BuildTime, if set, is recorded as the creation time of the application
+	// instead of the current time to make the build reproducible
+	BuildTime time.Time
 }
 
 // vendorer is a helper struct that encapsulates all services needed to vendor/rewrite images in
@@ -142,6 +161,16 @@ type vendorer struct {
 	dockerPuller docker.DockerPuller
 	registryURL  string
 	packages     pack.PackageService
+	// fetcher vendors images without Docker daemon if set
+	fetcher *docker.ImageFetcher
+}
+
+// Close releases the resources used by the vendorer
+func (v *vendorer) Close() error {
+	if v.fetcher != nil {
+		return trace.Wrap(v.fetcher.Close())
+	}
+	return nil
 }
 
 // VendorTarball is the same as VendorDir but accepts a tarball stream and unpacks it before vendoring.
@@ -236,7 +265,7 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	manifestRewrites := []resources.ManifestRewriteFunc{
 		makeRewriteDepsFunc(req.SetDeps),
 		makeRewritePackagesMetadataFunc(v.packages),
-		makeRewriteAppMetadataFunc(req.Repository, req.PackageName, req.PackageVersion),
+		makeRewriteAppMetadataFunc(req.Repository, req.PackageName, req.PackageVersion, req.BuildTime),
 	}
 	if req.VendorRuntime {
 		manifestRewrites = append(manifestRewrites, fetchRuntimeImages(&runtimeImages))
@@ -251,6 +280,13 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	imagesToPull := append(images, defaults.ContainerImage)
 	imagesToPull = append(imagesToPull, runtimeImages...)
 
+	// remember the original references of the images as the fetcher
+	// pulls them from their registries when exporting
+	origins := make(map[string]string)
+	for _, image := range imagesToPull {
+		origins[v.imageService.Unwrap(v.imageService.Wrap(image))] = image
+	}
+
 	group, groupCtx := run.WithContext(ctx, run.WithParallel(req.Parallel))
 	for _, image := range imagesToPull {
 		log := log.WithField("image", image)
@@ -258,6 +294,10 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 			// image has already been vendored
 			continue
 		}
+		if v.fetcher != nil {
+			// images are fetched from registries during export
+			continue
+		}
 		image := image // create new variable for go routine below
 		group.Go(groupCtx, func() error {
 
@@ -306,6 +346,10 @@ func (v *vendorer) VendorDir(ctx context.Context, unpackedDir string, req Vendor
 	for i, image := range images {
 		images[i] = v.imageService.Unwrap(image)
 	}
+	if v.fetcher != nil {
+		images = originalImages(images, origins)
+		chartImages = originalImages(chartImages, origins)
+	}
 
 	log.Infof("No registry layers found, will pull and export images %q.", images)
 	if err = v.pullAndExportImages(ctx, teleutils.Deduplicate(images), unpackedDir, req.Parallel, req.ProgressReporter); err != nil {
@@ -371,6 +415,10 @@ func (v *vendorer) pullAndExportImages(ctx context.Context, images []string, exp
 			"failed to create %q", layersDir)
 	}
 
+	if v.fetcher != nil {
+		return trace.Wrap(v.fetcher.Export(ctx, images, layersDir, parallel, progress))
+	}
+
 	if err := exportLayers(ctx, exportDir, images, v.dockerClient,
 		log.WithField("export-directory", exportDir), parallel, progress); err != nil {
 		return trace.Wrap(err)
@@ -395,6 +443,7 @@ func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
 			Image:           m.SystemOptions.BaseImage,
 			Package:         *runtimePackage,
 			DockerInterface: v.dockerClient,
+			Fetcher:         v.fetcher,
 			PackageService:  v.packages,
 		}
 		if err := docker.TranslateRuntimeImage(req); err != nil {
@@ -423,6 +472,7 @@ func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
 				Image:           profile.SystemOptions.BaseImage,
 				Package:         runtimePackage,
 				DockerInterface: v.dockerClient,
+				Fetcher:         v.fetcher,
 				PackageService:  v.packages,
 			}
 			if err := docker.TranslateRuntimeImage(req); err != nil {
@@ -437,6 +487,19 @@ func (v *vendorer) translateRuntimeImages(m *schema.Manifest) error {
 	return nil
 }
 
+// originalImages returns the original references of the specified images
+// which have been stripped of their registries
+func originalImages(images []string, origins map[string]string) []string {
+	result := make([]string, 0, len(images))
+	for _, image := range images {
+		if origin, ok := origins[image]; ok {
+			image = origin
+		}
+		result = append(result, image)
+	}
+	return teleutils.Deduplicate(result)
+}
+
 // newRuntimePackage returns a generator to generate package names.
 // Generated package names are guaranteed to not collide with legacy runtime
 // package names and be unique within a single generator.
@@ -522,8 +585,9 @@ func makeRewriteSetImagesFunc(setImages []loc.DockerImage) rewriteFunc {
 	}
 }
 
-// makeRewriteAppMetadataFunc returns a function to rewrite application metadata: repository, name or version
-func makeRewriteAppMetadataFunc(setRepository, setName, setVersion string) resources.ManifestRewriteFunc {
+// makeRewriteAppMetadataFunc returns a function to rewrite application metadata: repository, name or version.
+// The creation time is set to created or to the current time if created is zero
+func makeRewriteAppMetadataFunc(setRepository, setName, setVersion string, created time.Time) resources.ManifestRewriteFunc {
 	return func(m *schema.Manifest) error {
 		if setRepository != "" {
 			m.Metadata.Repository = setRepository
@@ -534,7 +598,10 @@ func makeRewriteAppMetadataFunc(setRepository, setName, setVersion string) resou
 		if setVersion != "" {
 			m.Metadata.ResourceVersion = setVersion
 		}
-		m.Metadata.CreatedTimestamp = time.Now().UTC()
+		m.Metadata.CreatedTimestamp = created.UTC()
+		if created.IsZero() {
+			m.Metadata.CreatedTimestamp = time.Now().UTC()
+		}
 		return nil
 	}
 }
@@ -43,7 +43,17 @@ func DefaultOptions() *dockerarchive.TarOptions {
 // CompressDirectory compresses the directory given with dir, using writer as a sink
 // for the archive
 func CompressDirectory(dir string, writer io.Writer, items ...*Item) error {
-	archive := NewTarAppender(writer)
+	return compressDirectory(NewTarAppender(writer), dir, items...)
+}
+
+// CompressDirectoryReproducible compresses the directory given with dir like
+// CompressDirectory but produces the same archive for the same directory contents
+// regardless of file timestamps and ownership. See NewReproducibleTarAppender
+func CompressDirectoryReproducible(dir string, writer io.Writer, modTime time.Time, items ...*Item) error {
+	return compressDirectory(NewReproducibleTarAppender(writer, modTime), dir, items...)
+}
+
+func compressDirectory(archive *TarAppender, dir string, items ...*Item) error {
 	defer archive.Close()
 
 	if err := archive.Add(items...); err != nil {
@@ -176,11 +186,24 @@ func TarGlob(source *tar.Reader, dir string, patterns []string, handler func(mat
 // TarAppender wraps a tar writer and can append items to it
 type TarAppender struct {
 	tw *tar.Writer
+	// modTime is the timestamp of all items in reproducible mode
+	modTime time.Time
 }
 
 // NewTarAppender creates a new tar appender writing to w
 func NewTarAppender(w io.Writer) *TarAppender {
-	return &TarAppender{tar.NewWriter(w)}
+	return &TarAppender{tw: tar.NewWriter(w)}
+}
+
+// NewReproducibleTarAppender creates a new tar appender writing to w that
+// produces the same archive for the same items: item timestamps are set
+// to modTime, ownership is reset to defaults.ArchiveUID/ArchiveGID and
+// extended attributes are dropped
+func NewReproducibleTarAppender(w io.Writer, modTime time.Time) *TarAppender {
+	return &TarAppender{
+		tw:      tar.NewWriter(w),
+		modTime: modTime.UTC().Truncate(time.Second),
+	}
 }
 
 // Add adds the specified items to the underlined archive
@@ -196,6 +219,9 @@ func (r *TarAppender) Add(items ...*Item) (err error) {
 		if item.ModTime.IsZero() {
 			item.ModTime = time.Now()
 		}
+		if !r.modTime.IsZero() {
+			normalizeHeader(&item.Header, r.modTime)
+		}
 		if err = r.tw.WriteHeader(&item.Header); err != nil {
 			return trace.Wrap(err)
 		}
@@ -214,6 +240,21 @@ func (r *TarAppender) Close() error {
 	return r.tw.Close()
 }
 
+// normalizeHeader strips the header of the attributes that depend on
+// when and by whom the file was created
+func normalizeHeader(hdr *tar.Header, modTime time.Time) {
+	hdr.ModTime = modTime
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+	hdr.Uid = defaults.ArchiveUID
+	hdr.Gid = defaults.ArchiveGID
+	hdr.Uname = ""
+	hdr.Gname = ""
+	hdr.Xattrs = nil
+	hdr.PAXRecords = nil
+	hdr.Format = tar.FormatUnknown
+}
+
 // ItemFromString creates an Item from given string
 func ItemFromString(path, value string) *Item {
 	return ItemFromStringMode(path, value, defaults.SharedExecutableMask)
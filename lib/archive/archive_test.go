@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
 
@@ -119,6 +120,43 @@ func (_ *S) TestCompressesDirectory(c *C) {
 	AssertArchiveHasItems(c, ioutil.NopCloser(&buf), nil, testCases[0], testCases[1], testCases[2])
 }
 
+func (_ *S) TestCompressesDirectoryReproducibly(c *C) {
+	var testCases = []file{
+		{name: "dir", isDir: true},
+		{name: "dir/file1", data: []byte("brown")},
+		{name: "dir/file2", data: []byte("fox")},
+	}
+	buildTime := time.Date(2019, time.March, 1, 0, 0, 0, 0, time.UTC)
+	compress := func(modTime time.Time) []byte {
+		dir := c.MkDir()
+		write(c, dir, testCases)
+		for _, file := range testCases {
+			path := filepath.Join(dir, file.name)
+			c.Assert(os.Chtimes(path, modTime, modTime), IsNil)
+		}
+		var buf bytes.Buffer
+		err := CompressDirectoryReproducible(dir, &buf, buildTime, ItemFromString("extra", "jumps"))
+		c.Assert(err, IsNil)
+		return buf.Bytes()
+	}
+
+	first := compress(time.Now().Add(-time.Hour))
+	second := compress(time.Now())
+	c.Assert(first, DeepEquals, second)
+
+	tarball := tar.NewReader(bytes.NewReader(first))
+	for {
+		hdr, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		c.Assert(hdr.ModTime.Equal(buildTime), Equals, true, Commentf(hdr.Name))
+		c.Assert(hdr.Uid, Equals, defaults.ArchiveUID, Commentf(hdr.Name))
+		c.Assert(hdr.Uname, Equals, "", Commentf(hdr.Name))
+	}
+}
+
 func (_ *S) TestExtractsWithoutPermissions(c *C) {
 	var data = []byte("root")
 	rc := ioutil.NopCloser(bytes.NewReader(data))
@@ -0,0 +1,464 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package s3 implements BLOB storage backed by an S3-compatible bucket.
+//
+// BLOBs are stored under the following keys in the bucket:
+//
+//	<prefix>
+//	∟ blobs
+//	  ∟ <first 3 characters of hash>
+//	    ∟ <hash>
+//	∟ tmp
+//	  ∟ <uuid>
+//
+// where hash is the half SHA512 hash of the BLOB. Since the hash is only
+// known once the data has been consumed, BLOBs are first streamed to a
+// temporary key and then copied to the final location on the server side.
+// Temporary objects left behind by interrupted writes can be expired with
+// a bucket lifecycle rule on the tmp prefix
+package s3
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/blob"
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Config is the S3 BLOB storage configuration
+type Config struct {
+	// Bucket is the name of the bucket to store BLOBs in
+	Bucket string `yaml:"bucket" json:"bucket"`
+	// Prefix is an optional key prefix for all objects in the bucket
+	Prefix string `yaml:"prefix" json:"prefix"`
+	// Region is the bucket region
+	Region string `yaml:"region" json:"region"`
+	// Endpoint is an optional custom S3 API endpoint, e.g. http://minio:9000
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// ForcePathStyle enables path-style bucket addressing required
+	// by most S3-compatible servers
+	ForcePathStyle bool `yaml:"force_path_style" json:"force_path_style"`
+	// AccessKeyID is the access key ID.
+	// If unspecified, credentials are resolved from the environment
+	AccessKeyID string `yaml:"access_key_id" json:"access_key_id"`
+	// SecretAccessKey is the secret access key
+	SecretAccessKey string `yaml:"secret_access_key" json:"secret_access_key"`
+	// PartSize is the size of parts BLOBs are uploaded in
+	PartSize int64 `yaml:"part_size" json:"part_size"`
+	// Concurrency is the number of parts uploaded in parallel
+	Concurrency int `yaml:"concurrency" json:"concurrency"`
+	// S3 is optional S3 API client
+	S3 s3iface.S3API `yaml:"-" json:"-"`
+	// FieldLogger is used for logging
+	logrus.FieldLogger `yaml:"-" json:"-"`
+}
+
+// Check makes sure the configuration is valid
+func (c Config) Check() error {
+	if c.Bucket == "" {
+		return trace.BadParameter("missing parameter Bucket")
+	}
+	if c.AccessKeyID != "" && c.SecretAccessKey == "" {
+		return trace.BadParameter("missing parameter SecretAccessKey")
+	}
+	if c.PartSize != 0 && c.PartSize < s3manager.MinUploadPartSize {
+		return trace.BadParameter("part size should be at least %v bytes",
+			s3manager.MinUploadPartSize)
+	}
+	return nil
+}
+
+// CheckAndSetDefaults validates the configuration and sets defaults
+func (c *Config) CheckAndSetDefaults() error {
+	if err := c.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Region == "" {
+		c.Region = defaults.AWSRegion
+	}
+	if c.PartSize == 0 {
+		c.PartSize = defaults.S3BlobPartSize
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = defaults.S3BlobUploadConcurrency
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithFields(logrus.Fields{
+			trace.Component: constants.ComponentBLOB,
+			"bucket":        c.Bucket,
+		})
+	}
+	if c.S3 == nil {
+		config := &aws.Config{
+			Region:           aws.String(c.Region),
+			S3ForcePathStyle: aws.Bool(c.ForcePathStyle),
+		}
+		if c.Endpoint != "" {
+			config.Endpoint = aws.String(c.Endpoint)
+		}
+		if c.AccessKeyID != "" {
+			config.Credentials = credentials.NewStaticCredentials(
+				c.AccessKeyID, c.SecretAccessKey, "")
+		}
+		session, err := session.NewSession(config)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		c.S3 = s3.New(session)
+	}
+	return nil
+}
+
+// New returns BLOB storage backed by the configured S3 bucket
+func New(config Config) (blob.Objects, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &objects{
+		Config: config,
+		uploader: s3manager.NewUploaderWithClient(config.S3, func(u *s3manager.Uploader) {
+			u.PartSize = config.PartSize
+			u.Concurrency = config.Concurrency
+		}),
+	}, nil
+}
+
+type objects struct {
+	Config
+	uploader *s3manager.Uploader
+}
+
+func (o *objects) Close() error {
+	return nil
+}
+
+// GetBLOBs returns a list of BLOBs in the storage
+func (o *objects) GetBLOBs() ([]string, error) {
+	var out []string
+	err := o.S3.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(o.Bucket),
+		Prefix: aws.String(o.blobPrefix() + "/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			out = append(out, path.Base(aws.StringValue(object.Key)))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// WriteBLOB writes object to the storage, returns object envelope
+func (o *objects) WriteBLOB(data io.Reader) (*blob.Envelope, error) {
+	// step1 : stream the data to a temporary object computing its hash
+	// on the way, the uploader consumes the reader sequentially so
+	// the hash is computed over the data in order
+	tempKey := o.tempKey()
+	hasher := sha512.New()
+	_, err := o.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(tempKey),
+		Body:   io.TeeReader(data, hasher),
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	defer o.deleteObject(tempKey)
+	// step2 : now once we computed the hash, copy the object to the
+	// right place unless the same BLOB has already been stored
+	hash := fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+	targetKey := o.blobKey(hash)
+	_, err = o.headObject(targetKey)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	if trace.IsNotFound(err) {
+		temp, err := o.headObject(tempKey)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		err = o.copyObject(tempKey, targetKey, aws.Int64Value(temp.ContentLength))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	envelope, err := o.GetBLOBEnvelope(hash)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return envelope, nil
+}
+
+// GetBLOBEnvelope returns file information identified by hash
+func (o *objects) GetBLOBEnvelope(hash string) (*blob.Envelope, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	object, err := o.headObject(o.blobKey(hash))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &blob.Envelope{
+		SizeBytes: aws.Int64Value(object.ContentLength),
+		SHA512:    hash,
+		Modified:  aws.TimeValue(object.LastModified).UTC(),
+	}, nil
+}
+
+// OpenBLOB opens file identified by hash and returns reader.
+// The data is fetched lazily with ranged requests starting at the
+// current reader offset so seeking does not download skipped data
+func (o *objects) OpenBLOB(hash string) (blob.ReadSeekCloser, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	key := o.blobKey(hash)
+	object, err := o.headObject(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &reader{
+		client: o.S3,
+		bucket: o.Bucket,
+		key:    key,
+		size:   aws.Int64Value(object.ContentLength),
+	}, nil
+}
+
+// DeleteBLOB deletes BLOB from the storage
+func (o *objects) DeleteBLOB(hash string) error {
+	if err := checkHash(hash); err != nil {
+		return trace.Wrap(err)
+	}
+	key := o.blobKey(hash)
+	// S3 does not report deletes of missing objects
+	_, err := o.headObject(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = o.S3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return nil
+}
+
+// copyObject copies the object at key src to key dst on the server side.
+// Objects larger than the single request copy limit are copied in parts
+func (o *objects) copyObject(src, dst string, size int64) error {
+	source := o.copySource(src)
+	if size <= defaults.S3BlobMaxCopySize {
+		_, err := o.S3.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(o.Bucket),
+			Key:        aws.String(dst),
+			CopySource: aws.String(source),
+		})
+		return trace.Wrap(utils.ConvertS3Error(err))
+	}
+	upload, err := o.S3.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(dst),
+	})
+	if err != nil {
+		return trace.Wrap(utils.ConvertS3Error(err))
+	}
+	var parts []*s3.CompletedPart
+	for offset, number := int64(0), int64(1); offset < size; number++ {
+		end := offset + defaults.S3BlobCopyPartSize
+		if end > size {
+			end = size
+		}
+		out, err := o.S3.UploadPartCopy(&s3.UploadPartCopyInput{
+			Bucket:          aws.String(o.Bucket),
+			Key:             aws.String(dst),
+			UploadId:        upload.UploadId,
+			PartNumber:      aws.Int64(number),
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%v-%v", offset, end-1)),
+		})
+		if err != nil {
+			o.abortUpload(dst, upload.UploadId)
+			return trace.Wrap(utils.ConvertS3Error(err))
+		}
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       out.CopyPartResult.ETag,
+			PartNumber: aws.Int64(number),
+		})
+		offset = end
+	}
+	_, err = o.S3.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(o.Bucket),
+		Key:             aws.String(dst),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		o.abortUpload(dst, upload.UploadId)
+		return trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return nil
+}
+
+func (o *objects) abortUpload(key string, uploadID *string) {
+	_, err := o.S3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(o.Bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		o.WithError(err).Warnf("Failed to abort multipart upload of %v.", key)
+	}
+}
+
+func (o *objects) headObject(key string) (*s3.HeadObjectOutput, error) {
+	out, err := o.S3.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, trace.Wrap(utils.ConvertS3Error(err))
+	}
+	return out, nil
+}
+
+func (o *objects) deleteObject(key string) {
+	_, err := o.S3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(o.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		o.WithError(err).Warnf("Failed to delete %v.", key)
+	}
+}
+
+// copySource returns the URL-encoded source of a copy request for key
+func (o *objects) copySource(key string) string {
+	return (&url.URL{Path: path.Join(o.Bucket, key)}).EscapedPath()
+}
+
+func (o *objects) blobPrefix() string {
+	return path.Join(o.Prefix, "blobs")
+}
+
+// blobKey returns the key of the BLOB with the specified hash.
+// Similar to the filesystem storage, the keys are grouped by the first
+// 3 characters of the hash to make listing by prefix cheap
+func (o *objects) blobKey(hash string) string {
+	return path.Join(o.blobPrefix(), hash[0:3], hash)
+}
+
+func (o *objects) tempKey() string {
+	return path.Join(o.Prefix, "tmp", uuid.New())
+}
+
+func checkHash(hash string) error {
+	if len(hash) < 3 {
+		return trace.BadParameter("invalid BLOB hash %q", hash)
+	}
+	return nil
+}
+
+// reader reads the object with ranged GET requests
+type reader struct {
+	client s3iface.S3API
+	bucket string
+	key    string
+	// size is the object size in bytes
+	size int64
+	// offset is the current read offset
+	offset int64
+	// body is the body of the active GET request.
+	// It is reset when the reader is repositioned
+	body io.ReadCloser
+}
+
+// Read reads the object data at the current offset
+func (r *reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		out, err := r.client.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(r.bucket),
+			Key:    aws.String(r.key),
+			Range:  aws.String(fmt.Sprintf("bytes=%v-", r.offset)),
+		})
+		if err != nil {
+			return 0, trace.Wrap(utils.ConvertS3Error(err))
+		}
+		r.body = out.Body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek sets the offset for the next Read
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = r.offset + offset
+	case io.SeekEnd:
+		position = r.size + offset
+	default:
+		return 0, trace.BadParameter("invalid whence %v", whence)
+	}
+	if position < 0 {
+		return 0, trace.BadParameter("negative position %v", position)
+	}
+	if position != r.offset {
+		r.Close()
+	}
+	r.offset = position
+	return position, nil
+}
+
+// Close closes the active request if any
+func (r *reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return trace.Wrap(err)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/gravitational/gravity/lib/blob/suite"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+	. "gopkg.in/check.v1"
+)
+
+func TestS3(t *testing.T) { TestingT(t) }
+
+type ReaderSuite struct{}
+
+var _ = Suite(&ReaderSuite{})
+
+func (s *ReaderSuite) TestReadsRanges(c *C) {
+	client := &rangeS3{data: []byte("hello, ranged blob")}
+	r := &reader{client: client, bucket: "bucket", key: "key", size: int64(len(client.data))}
+	defer r.Close()
+
+	pos, err := r.Seek(7, io.SeekStart)
+	c.Assert(err, IsNil)
+	c.Assert(pos, Equals, int64(7))
+	// seeking does not issue requests
+	c.Assert(client.ranges, HasLen, 0)
+
+	out, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, "ranged blob")
+
+	pos, err = r.Seek(-4, io.SeekEnd)
+	c.Assert(err, IsNil)
+	c.Assert(pos, Equals, int64(len(client.data)-4))
+	out, err = ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, "blob")
+	c.Assert(client.ranges, DeepEquals, []string{"bytes=7-", "bytes=14-"})
+
+	_, err = r.Seek(-1, io.SeekStart)
+	c.Assert(err, NotNil)
+}
+
+// rangeS3 serves ranged GET requests for a single object
+type rangeS3 struct {
+	s3iface.S3API
+	data   []byte
+	ranges []string
+}
+
+func (s *rangeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	var offset int
+	_, err := fmt.Sscanf(aws.StringValue(input.Range), "bytes=%d-", &offset)
+	if err != nil {
+		return nil, err
+	}
+	s.ranges = append(s.ranges, aws.StringValue(input.Range))
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(s.data[offset:])),
+	}, nil
+}
+
+// S3Suite runs the BLOB storage tests against a real bucket,
+// e.g. a local MinIO instance:
+//
+//	TEST_S3=true TEST_S3_CONFIG='{"bucket":"test","endpoint":"http://127.0.0.1:9000",
+//	  "force_path_style":true,"access_key_id":"minio","secret_access_key":"minio123"}'
+type S3Suite struct {
+	suite   suite.BLOBSuite
+	objects *objects
+}
+
+var _ = Suite(&S3Suite{})
+
+func (s *S3Suite) SetUpTest(c *C) {
+	log.SetOutput(os.Stderr)
+
+	if ok, _ := strconv.ParseBool(os.Getenv(defaults.TestS3)); !ok {
+		c.Skip("Skipping test suite for S3")
+		return
+	}
+
+	var config Config
+	c.Assert(json.Unmarshal([]byte(os.Getenv(defaults.TestS3Config)), &config), IsNil)
+	// every test gets its own prefix in the bucket
+	config.Prefix = uuid.New()
+
+	obj, err := New(config)
+	c.Assert(err, IsNil)
+
+	s.objects = obj.(*objects)
+	s.suite.Objects = obj
+}
+
+func (s *S3Suite) TearDownTest(c *C) {
+	if s.objects == nil {
+		return
+	}
+	hashes, err := s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	for _, hash := range hashes {
+		c.Assert(s.objects.DeleteBLOB(hash), IsNil)
+	}
+}
+
+func (s *S3Suite) TestBLOB(c *C) {
+	s.suite.BLOB(c)
+}
+
+func (s *S3Suite) TestBLOBSeek(c *C) {
+	s.suite.BLOBSeek(c)
+}
+
+func (s *S3Suite) TestBLOBWriteTwice(c *C) {
+	s.suite.BLOBWriteTwice(c)
+}
+
+func (s *S3Suite) TestBLOBList(c *C) {
+	s.suite.BLOBList(c)
+}
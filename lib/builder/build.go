@@ -98,6 +98,12 @@ func Build(ctx context.Context, builder *Builder) error {
 		return trace.Wrap(err)
 	}
 
+	builder.NextStep("Generating the software bill of materials")
+	err = builder.GenerateSBOM(*application)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
 	builder.NextStep("Generating the cluster snapshot")
 	installer, err := builder.GenerateInstaller(*application)
 	if err != nil {
@@ -105,13 +111,34 @@ func Build(ctx context.Context, builder *Builder) error {
 	}
 	defer installer.Close()
 
+	if builder.DeltaFrom != "" {
+		builder.NextStep("Saving the delta snapshot against %v as %v",
+			builder.DeltaFrom, builder.OutPath)
+		manifest, err := builder.WriteDeltaInstaller(installer)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		total, included, patched := manifest.Stats()
+		builder.PrintInfo("Delta includes %v of %v packages, %v without the data shared with %v",
+			included+patched, total, patched, manifest.Base)
+		if err := builder.WriteSBOM(); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(builder.SignInstaller())
+	}
+
 	builder.NextStep("Saving the snapshot as %v", builder.OutPath)
 	err = builder.WriteInstaller(installer)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	return nil
+	err = builder.WriteSBOM()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return trace.Wrap(builder.SignInstaller())
 }
 
 // checkBuildEnv makes sure that the environment "tele build" is invoked in is
@@ -138,7 +165,7 @@ func checkBuildEnv() error {
 
 const (
 	// clusterBuildSteps is a number of steps when building a cluster image.
-	clusterBuildSteps = 6
+	clusterBuildSteps = 7
 	// appBuildSteps is a number of steps when building an app image.
-	appBuildSteps = 4
+	appBuildSteps = 5
 )
@@ -25,10 +25,16 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
+	"github.com/gravitational/gravity/lib/app/delta"
+	"github.com/gravitational/gravity/lib/app/docker"
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/app/service"
+	"github.com/gravitational/gravity/lib/archive"
 	blobfs "github.com/gravitational/gravity/lib/blob/fs"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -38,13 +44,13 @@ import (
 	"github.com/gravitational/gravity/lib/pack/layerpack"
 	"github.com/gravitational/gravity/lib/pack/localpack"
 	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/utils"
 	"k8s.io/helm/pkg/chartutil"
 
 	"github.com/coreos/go-semver/semver"
-	"github.com/docker/docker/pkg/archive"
 	"github.com/ghodss/yaml"
 	"github.com/gravitational/trace"
 	"github.com/gravitational/version"
@@ -69,12 +75,24 @@ type Config struct {
 	OutPath string
 	// Overwrite indicates whether or not to overwrite an existing installer file
 	Overwrite bool
+	// DeltaFrom is the path to the installer tarball of the previous
+	// application version to build the delta installer against
+	DeltaFrom string
 	// Repository represents the source package repository
 	Repository string
 	// SkipVersionCheck allows to skip tele/runtime compatibility check
 	SkipVersionCheck bool
 	// VendorReq combines vendoring options
 	VendorReq service.VendorRequest
+	// ImageFetcher, if set, configures vendoring images directly from
+	// registries and image archives without Docker daemon
+	ImageFetcher *docker.ImageFetcherConfig
+	// BuildTime is the time recorded in the installer tarball and the package
+	// metadata. Pin it to make builds of the same application reproducible.
+	// Defaults to SOURCE_DATE_EPOCH if set or to the current time otherwise
+	BuildTime time.Time
+	// Signer, if set, signs the installer packages and the installer tarball
+	Signer *signing.Signer
 	// Generator is used to generate installer
 	Generator Generator
 	// NewSyncer is used to initialize package cache syncer for the builder
@@ -112,9 +130,31 @@ func (c *Config) CheckAndSetDefaults() error {
 				defaults.ManifestFileName)
 		}
 	}
+	if c.DeltaFrom != "" {
+		if _, err := os.Stat(c.DeltaFrom); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
 	if c.VendorReq.Parallel == 0 {
 		c.VendorReq.Parallel = runtime.NumCPU()
 	}
+	if c.BuildTime.IsZero() {
+		c.BuildTime, err = sourceDateEpoch()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if c.ImageFetcher != nil {
+		c.ImageFetcher.Insecure = c.ImageFetcher.Insecure || c.Insecure
+		if c.ImageFetcher.CacheDir == "" {
+			// layer cache directory is ~/.gravity/cache/layers/
+			dir, err := utils.EnsureLocalPath("", defaults.LocalCacheDir, defaults.LayerCacheDir)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			c.ImageFetcher.CacheDir = dir
+		}
+	}
 	if c.Generator == nil {
 		c.Generator = &generator{}
 	}
@@ -204,6 +244,9 @@ type Builder struct {
 	Packages pack.PackageService
 	// Apps is the application service based on the layered package service
 	Apps app.Applications
+	// SBOM is the software bill of materials of the application being built,
+	// see GenerateSBOM
+	SBOM *sbom.Document
 }
 
 // Locator returns locator of the application that's being built
@@ -305,21 +348,28 @@ func (b *Builder) Vendor(ctx context.Context, dir string) (io.ReadCloser, error)
 		}
 	}
 	vendorer, err := service.NewVendorer(service.VendorerConfig{
-		DockerURL:   constants.DockerEngineURL,
-		RegistryURL: constants.DockerRegistry,
-		Packages:    b.Packages,
+		DockerURL:    constants.DockerEngineURL,
+		RegistryURL:  constants.DockerRegistry,
+		Packages:     b.Packages,
+		ImageFetcher: b.ImageFetcher,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	defer vendorer.Close()
 	vendorReq := b.VendorReq
 	vendorReq.ManifestPath = manifestPath
 	vendorReq.ProgressReporter = b.Progress
+	vendorReq.BuildTime = b.BuildTime
 	err = vendorer.VendorDir(ctx, dir, vendorReq)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return archive.Tar(dir, archive.Uncompressed)
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(archive.CompressDirectoryReproducible(dir, writer, b.BuildTime))
+	}()
+	return reader, nil
 }
 
 // CreateApplication creates a Gravity application from the provided
@@ -361,8 +411,96 @@ func (b *Builder) WriteInstaller(data io.ReadCloser) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	defer f.Close()
 	_, err = io.Copy(f, data)
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.ConvertSystemError(f.Close())
+}
+
+// GenerateSBOM generates the software bill of materials of the specified application
+func (b *Builder) GenerateSBOM(application app.Application) error {
+	doc, err := b.Apps.GetAppSBOM(application.Package)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	b.SBOM = doc
+	b.PrintSubStep("Found %v images and %v charts in %v packages",
+		len(doc.Images), len(doc.Charts), len(doc.Packages)+len(doc.Apps)+1)
+	return nil
+}
+
+// WriteSBOM writes the software bill of materials in all supported formats
+// next to the installer tarball, see SBOMFile
+func (b *Builder) WriteSBOM() error {
+	if b.SBOM == nil {
+		return nil
+	}
+	for _, format := range sbom.AllFormats {
+		data, err := sbom.Encode(*b.SBOM, format)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		path := SBOMFile(b.OutPath, format)
+		if err := ioutil.WriteFile(path, data, defaults.SharedReadMask); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		b.PrintSubStep("Saved the software bill of materials as %v", path)
+	}
+	return nil
+}
+
+// SBOMFile returns the path to the software bill of materials in the specified
+// format for the installer tarball at path, e.g. app-1.0.0.sbom.spdx.json
+// for app-1.0.0.tar
+func SBOMFile(path, format string) string {
+	return fmt.Sprintf("%v.%v", strings.TrimSuffix(path, ".tar"), sbom.FileName(format))
+}
+
+// SignInstaller writes the detached signature of the installer tarball
+// next to it, see signing.SignatureFile
+func (b *Builder) SignInstaller() error {
+	if b.Signer == nil {
+		return nil
+	}
+	f, err := os.Open(b.OutPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	signature, err := b.Signer.SignReader(f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = ioutil.WriteFile(signing.SignatureFile(b.OutPath), signature, defaults.SharedReadMask)
+	return trace.ConvertSystemError(err)
+}
+
+// WriteDeltaInstaller writes the delta installer built from the provided
+// installer tarball data against the installer specified with DeltaFrom
+func (b *Builder) WriteDeltaInstaller(data io.ReadCloser) (*delta.Manifest, error) {
+	f, err := ioutil.TempFile(filepath.Dir(b.OutPath), "installer")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, data)
+	f.Close()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	manifest, err := delta.Generate(delta.GenerateRequest{
+		BasePath:    b.DeltaFrom,
+		Path:        f.Name(),
+		OutPath:     b.OutPath,
+		Signer:      b.Signer,
+		FieldLogger: b.FieldLogger,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return manifest, nil
 }
 
 // initServices initializes the builder backend, package and apps services
@@ -490,6 +628,21 @@ func (b *Builder) Close() error {
 	return trace.NewAggregate(errors...)
 }
 
+// sourceDateEpoch returns the build time from SOURCE_DATE_EPOCH environment
+// variable or the current time if it is not set
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv(constants.EnvSourceDateEpoch)
+	if value == "" {
+		return time.Now().UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, trace.BadParameter("invalid %v %q, expected the number of "+
+			"seconds since Unix epoch", constants.EnvSourceDateEpoch, value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 // ensureCacheDir makes sure a local cache directory for the provided Ops Center
 // exists
 func ensureCacheDir(opsURL string) (string, error) {
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/utils"
 	"github.com/gravitational/trace"
@@ -97,6 +98,13 @@ func (s *BuilderSuite) TestSelectRuntimeVersion(c *check.C) {
 	c.Assert(err, check.ErrorMatches, "unsupported base image .*")
 }
 
+func (s *BuilderSuite) TestSBOMFile(c *check.C) {
+	c.Assert(SBOMFile("build/app-1.0.0.tar", sbom.FormatSPDX), check.Equals,
+		"build/app-1.0.0.sbom.spdx.json")
+	c.Assert(SBOMFile("app-1.0.0", sbom.FormatCycloneDX), check.Equals,
+		"app-1.0.0.sbom.cdx.json")
+}
+
 const (
 	manifestWithBase = `apiVersion: cluster.gravitational.io/v2
 kind: Cluster
@@ -36,5 +36,8 @@ type generator struct{}
 func (g *generator) Generate(builder *Builder, application app.Application) (io.ReadCloser, error) {
 	return builder.Apps.GetAppInstaller(app.InstallerRequest{
 		Application: application.Package,
+		BuildTime:   builder.BuildTime,
+		Signer:      builder.Signer,
+		SBOM:        builder.SBOM,
 	})
 }
@@ -0,0 +1,143 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+)
+
+// CompareNodeSpec returns the differences between the node from
+// the cluster spec and the runtime configuration of the joined agent
+func CompareNodeSpec(node storage.NodeSpec, config pb.RuntimeConfig) (diffs []string) {
+	if config.Role != node.Profile {
+		diffs = append(diffs, fmt.Sprintf("profile: expected %q, got %q", node.Profile, config.Role))
+	}
+	if config.DockerDevice != node.DockerDevice {
+		diffs = append(diffs, fmt.Sprintf("docker device: expected %q, got %q",
+			node.DockerDevice, config.DockerDevice))
+	}
+	if config.SystemDevice != node.SystemDevice {
+		diffs = append(diffs, fmt.Sprintf("system device: expected %q, got %q",
+			node.SystemDevice, config.SystemDevice))
+	}
+	mounts := make(map[string]string, len(config.Mounts))
+	for _, mount := range config.Mounts {
+		mounts[mount.Name] = mount.Source
+	}
+	for _, name := range sortedKeys(node.Mounts, mounts) {
+		expected, actual := node.Mounts[name], mounts[name]
+		if expected != actual {
+			diffs = append(diffs, fmt.Sprintf("mount %v: expected %q, got %q", name, expected, actual))
+		}
+	}
+	return diffs
+}
+
+// FindNodeSpec returns the node from the cluster spec with the specified advertise address
+func FindNodeSpec(nodes []storage.NodeSpec, addr string) *storage.NodeSpec {
+	ip, _ := utils.SplitHostPort(addr, "")
+	for i := range nodes {
+		if nodes[i].AdvertiseAddr == ip {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// DiffNodeSpecs compares the nodes from the cluster spec with the joined agents
+func DiffNodeSpecs(nodes []storage.NodeSpec, servers []ServerInfo) NodeSpecDiff {
+	diff := NodeSpecDiff{Mismatched: make(map[string][]string)}
+	joined := make(map[string]bool)
+	for _, server := range servers {
+		node := FindNodeSpec(nodes, server.AdvertiseAddr)
+		if node == nil {
+			diff.Unexpected = append(diff.Unexpected, server)
+			continue
+		}
+		joined[node.AdvertiseAddr] = true
+		if diffs := CompareNodeSpec(*node, server.RuntimeConfig); len(diffs) != 0 {
+			diff.Mismatched[node.AdvertiseAddr] = diffs
+		}
+	}
+	for _, node := range nodes {
+		if !joined[node.AdvertiseAddr] {
+			diff.Missing = append(diff.Missing, node)
+		}
+	}
+	return diff
+}
+
+// NodeSpecDiff describes the differences between the cluster spec
+// and the agents that have joined the operation
+type NodeSpecDiff struct {
+	// Missing lists the nodes that have not joined yet
+	Missing []storage.NodeSpec
+	// Unexpected lists the agents that are not in the cluster spec
+	Unexpected []ServerInfo
+	// Mismatched maps the advertise addresses of the joined nodes
+	// to the differences from their specs
+	Mismatched map[string][]string
+}
+
+// IsEmpty returns true if the agents match the cluster spec
+func (d NodeSpecDiff) IsEmpty() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0 && len(d.Mismatched) == 0
+}
+
+// String formats the differences, one per line
+func (d NodeSpecDiff) String() string {
+	var buf bytes.Buffer
+	for _, node := range d.Missing {
+		fmt.Fprintf(&buf, "- %v: has not joined\n", node.AdvertiseAddr)
+	}
+	for _, server := range d.Unexpected {
+		fmt.Fprintf(&buf, "+ %v: not in the cluster spec (profile %q, hostname %q)\n",
+			utils.ExtractHost(server.AdvertiseAddr), server.Role, server.GetHostname())
+	}
+	addrs := make([]string, 0, len(d.Mismatched))
+	for addr := range d.Mismatched {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		for _, diff := range d.Mismatched[addr] {
+			fmt.Fprintf(&buf, "~ %v: %v\n", addr, diff)
+		}
+	}
+	return buf.String()
+}
+
+// sortedKeys returns the sorted union of keys of the specified maps
+func sortedKeys(maps ...map[string]string) (keys []string) {
+	seen := make(map[string]bool)
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
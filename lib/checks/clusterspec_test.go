@@ -0,0 +1,62 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/storage"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ChecksSuite) TestDiffNodeSpecs(c *C) {
+	nodes := []storage.NodeSpec{
+		{AdvertiseAddr: "10.0.0.1", Profile: "master", DockerDevice: "/dev/sdb"},
+		{AdvertiseAddr: "10.0.0.2", Profile: "node", Mounts: map[string]string{"data": "/mnt/data"}},
+		{AdvertiseAddr: "10.0.0.3", Profile: "node"},
+	}
+	servers := []ServerInfo{
+		{
+			System:        storage.NewSystemInfo(storage.SystemSpecV2{Hostname: "master"}),
+			RuntimeConfig: pb.RuntimeConfig{AdvertiseAddr: "10.0.0.1:3012", Role: "master", DockerDevice: "/dev/sdb"},
+		},
+		{
+			System: storage.NewSystemInfo(storage.SystemSpecV2{Hostname: "node-1"}),
+			RuntimeConfig: pb.RuntimeConfig{
+				AdvertiseAddr: "10.0.0.2",
+				Role:          "master",
+				Mounts:        []*pb.Mount{{Name: "data", Source: "/data"}},
+			},
+		},
+		{
+			System:        storage.NewSystemInfo(storage.SystemSpecV2{Hostname: "stray"}),
+			RuntimeConfig: pb.RuntimeConfig{AdvertiseAddr: "10.0.0.4", Role: "node"},
+		},
+	}
+
+	diff := DiffNodeSpecs(nodes, servers)
+	c.Assert(diff.IsEmpty(), Equals, false)
+	c.Assert(diff.String(), Equals, `- 10.0.0.3: has not joined
++ 10.0.0.4: not in the cluster spec (profile "node", hostname "stray")
+~ 10.0.0.2: profile: expected "node", got "master"
+~ 10.0.0.2: mount data: expected "/mnt/data", got "/data"
+`)
+
+	diff = DiffNodeSpecs(nodes[:1], servers[:1])
+	c.Assert(diff.IsEmpty(), Equals, true)
+	c.Assert(diff.String(), Equals, "")
+}
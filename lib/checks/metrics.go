@@ -0,0 +1,65 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	"github.com/dustin/go-humanize"
+)
+
+// MetricsWarnings returns the list of resource shortages detected
+// on the node with the specified agent metrics.
+// numCPU specifies the number of CPUs on the node, the load
+// average is not checked if it is 0
+func MetricsWarnings(metrics pb.AgentMetrics, numCPU uint) (warnings []string) {
+	if numCPU != 0 && metrics.Load5 > float64(numCPU)*defaults.AgentHighLoadPerCPU {
+		warnings = append(warnings, fmt.Sprintf("high load average %.2f on %v CPUs",
+			metrics.Load5, numCPU))
+	}
+	if isLow(metrics.MemoryFree, metrics.MemoryTotal, defaults.AgentLowMemoryPercent) {
+		warnings = append(warnings, fmt.Sprintf("low memory: %v available out of %v",
+			humanize.Bytes(metrics.MemoryFree), humanize.Bytes(metrics.MemoryTotal)))
+	}
+	for _, disk := range metrics.Disks {
+		if isLow(disk.Free, disk.Total, defaults.AgentLowDiskPercent) {
+			warnings = append(warnings, fmt.Sprintf("low disk space on %v: %v free out of %v",
+				disk.Path, humanize.Bytes(disk.Free), humanize.Bytes(disk.Total)))
+		}
+	}
+	return warnings
+}
+
+// FormatMetrics returns a short human-readable summary of the specified agent metrics
+func FormatMetrics(metrics pb.AgentMetrics) string {
+	summary := fmt.Sprintf("load %.2f %.2f %.2f, memory %v/%v available",
+		metrics.Load1, metrics.Load5, metrics.Load15,
+		humanize.Bytes(metrics.MemoryFree), humanize.Bytes(metrics.MemoryTotal))
+	for _, disk := range metrics.Disks {
+		summary += fmt.Sprintf(", %v %v/%v free", disk.Path,
+			humanize.Bytes(disk.Free), humanize.Bytes(disk.Total))
+	}
+	return fmt.Sprintf("%v, %v running commands, version %v",
+		summary, metrics.RunningCommands, metrics.Version)
+}
+
+func isLow(free, total uint64, percent uint64) bool {
+	return total != 0 && free*100 < total*percent
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checks
+
+import (
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ChecksSuite) TestMetricsWarnings(c *C) {
+	healthy := pb.AgentMetrics{
+		Load5:       3,
+		MemoryTotal: 1000,
+		MemoryFree:  500,
+		Disks:       []*pb.DiskUsage{{Path: "/var/lib/gravity", Total: 1000, Free: 200}},
+	}
+	c.Assert(MetricsWarnings(healthy, 2), HasLen, 0)
+
+	struggling := pb.AgentMetrics{
+		Load5:       5,
+		MemoryTotal: 1000,
+		MemoryFree:  50,
+		Disks: []*pb.DiskUsage{
+			{Path: "/var/lib/gravity", Total: 1000, Free: 50},
+			{Path: "/tmp", Total: 1000, Free: 500},
+		},
+	}
+	c.Assert(MetricsWarnings(struggling, 2), DeepEquals, []string{
+		"high load average 5.00 on 2 CPUs",
+		"low memory: 50B available out of 1.0kB",
+		"low disk space on /var/lib/gravity: 50B free out of 1.0kB",
+	})
+	// load average is not checked without the number of CPUs
+	c.Assert(MetricsWarnings(struggling, 0), HasLen, 2)
+}
@@ -65,6 +65,7 @@ func (r ServerInfo) Transport() (*RawServerInfo, error) {
 		RuntimeConfig: r.RuntimeConfig,
 		LocalTime:     r.LocalTime,
 		ServerTime:    r.ServerTime,
+		Metrics:       r.Metrics,
 	}, nil
 }
 
@@ -79,6 +80,9 @@ type ServerInfo struct {
 	LocalTime time.Time `json:"local_time"`
 	// ServerTime is the remote time
 	ServerTime time.Time `json:"server_time"`
+	// Metrics describes the health and resource usage of the server.
+	// Can be empty if the agent does not report metrics
+	Metrics *pb.AgentMetrics `json:"metrics,omitempty"`
 }
 
 // FromTransport converts from transport-friendly representation
@@ -94,6 +98,7 @@ func (r RawServerInfo) FromTransport() (*ServerInfo, error) {
 		RuntimeConfig: r.RuntimeConfig,
 		LocalTime:     r.LocalTime,
 		ServerTime:    r.ServerTime,
+		Metrics:       r.Metrics,
 	}, nil
 }
 
@@ -109,6 +114,10 @@ type RawServerInfo struct {
 	LocalTime time.Time `json:"local_time"`
 	// ServerTime is the remote time
 	ServerTime time.Time `json:"server_time"`
+	// Metrics describes the health and resource usage of the server.
+	// Populated from the agent group and can be empty if the agent
+	// does not report metrics
+	Metrics *pb.AgentMetrics `json:"metrics,omitempty"`
 }
 
 // GetServerInfo fetches remote server information
@@ -105,6 +105,13 @@ const (
 	// ETCDBackend defines storage backend as Etcd
 	ETCDBackend = "etcd"
 
+	// BlobBackendFS defines package BLOB storage as the local filesystem
+	// replicated across peers
+	BlobBackendFS = "fs"
+
+	// BlobBackendS3 defines package BLOB storage as an S3-compatible bucket
+	BlobBackendS3 = "s3"
+
 	// WebAssetsPackage names the web assets package
 	WebAssetsPackage = "web-assets"
 
@@ -337,6 +344,10 @@ const (
 	// EnvSudoGID is environment variable containing id of the group of the user who invoked "sudo"
 	EnvSudoGID = "SUDO_GID"
 
+	// EnvSourceDateEpoch is environment variable with the build time of reproducible
+	// builds in seconds since Unix epoch, see https://reproducible-builds.org/specs/source-date-epoch/
+	EnvSourceDateEpoch = "SOURCE_DATE_EPOCH"
+
 	// EnvKubeConfig is environment variable for kubeconfig
 	EnvKubeConfig = "KUBECONFIG"
 
@@ -540,6 +551,10 @@ const (
 	// AlertTargetConfigMap specifies the name of the ConfigMap with alert target configuration
 	AlertTargetConfigMap = "alert-target-update"
 
+	// AlertHandlerSecret specifies the name of the Secret with the Kapacitor
+	// HTTP post endpoint that forwards alerts to the cluster controller
+	AlertHandlerSecret = "alert-handler-update"
+
 	// MonitoringType specifies the name of the type label for monitoring
 	MonitoringType = "monitoring"
 
@@ -552,9 +567,22 @@ const (
 	// MonitoringTypeSMTP specifies the value of the component label for monitoring SMTP updates
 	MonitoringTypeSMTP = "smtp"
 
+	// MonitoringTypeAlertHandler specifies the value of the component label for monitoring alert handler updates
+	MonitoringTypeAlertHandler = "alert-handler"
+
+	// MonitoringBackendInfluxDB is the InfluxDB metrics backend
+	MonitoringBackendInfluxDB = "influxdb"
+
+	// MonitoringBackendPrometheus is the Prometheus metrics backend
+	MonitoringBackendPrometheus = "prometheus"
+
 	// ResourceSpecKey specifies the name of the key with raw resource specification
 	ResourceSpecKey = "spec"
 
+	// AlertFormulaKey specifies the name of the key with the alert formula
+	// as specified by the user, before the alert handler has been added to it
+	AlertFormulaKey = "formula"
+
 	// AuthGatewayConfigMap is the name of config map with auth gateway configuration.
 	AuthGatewayConfigMap = "auth-gateway"
 
@@ -666,6 +694,8 @@ const (
 	FailureMark = "×"
 	// InProgressMark is used in CLI to visually indicate progress
 	InProgressMark = "→"
+	// WarningMark is used in CLI to visually indicate a warning
+	WarningMark = "!"
 
 	// WireguardNetworkType is a network type that is used for wireguard/wormhole support
 	WireguardNetworkType = "wireguard"
@@ -708,6 +738,10 @@ var (
 	EncodingText Format = "text"
 	// EncodingYAML is for the YAML encoding format
 	EncodingYAML Format = "yaml"
+	// EncodingDOT is for the Graphviz DOT graph format
+	EncodingDOT Format = "dot"
+	// EncodingMermaid is for the Mermaid flowchart format
+	EncodingMermaid Format = "mermaid"
 	// OutputFormats is a list of recognized output formats for gravity CLI commands
 	OutputFormats = []Format{
 		EncodingText,
@@ -362,6 +362,12 @@ const (
 	// TestK8s controls whether k8s tests are run
 	TestK8s = "TEST_K8S"
 
+	// TestS3 instructs us to test the S3 BLOB storage backend
+	TestS3 = "TEST_S3"
+
+	// TestS3Config is a JSON BLOB with S3 BLOB storage config
+	TestS3Config = "TEST_S3_CONFIG"
+
 	// LocalDir is the gravity subdirectory where local data is stored
 	LocalDir = "local"
 
@@ -375,6 +381,49 @@ const (
 	// PackagesDir is the place where we put all local packages
 	PackagesDir = "packages"
 
+	// PackageUploadsDir is the subdirectory of the packages directory
+	// with resumable package upload sessions
+	PackageUploadsDir = "uploads"
+
+	// PackageUploadChunkSize is the size of chunks packages are uploaded in
+	PackageUploadChunkSize = 8 * 1024 * 1024
+
+	// PackageTransferAttempts is how many times a package chunk upload or
+	// download is attempted before giving up
+	PackageTransferAttempts = 10
+
+	// PackageTransferRetryPeriod is the period between package chunk
+	// upload or download attempts
+	PackageTransferRetryPeriod = 3 * time.Second
+
+	// PackageUploadSessionTTL is how long an idle package upload session
+	// is kept before it is removed
+	PackageUploadSessionTTL = 24 * time.Hour
+
+	// PackageUploadQuota is the total size of data active package upload
+	// sessions can reserve on disk
+	PackageUploadQuota = 50 * 1024 * 1024 * 1024
+
+	// PackageBlobDeleteTimeout is how long a package BLOB can be marked as
+	// being deleted before the mark is considered abandoned
+	PackageBlobDeleteTimeout = 10 * time.Minute
+
+	// PackageChunkMinSize is the minimum size of a content-defined chunk
+	// packages are split into in deduplicating storage mode
+	PackageChunkMinSize = 512 * 1024
+
+	// PackageChunkMaxSize is the maximum size of a content-defined chunk
+	PackageChunkMaxSize = 8 * 1024 * 1024
+
+	// PackageChunkAverageBits defines the average size of a content-defined
+	// chunk as a power of two, i.e. 1MB on average
+	PackageChunkAverageBits = 20
+
+	// DeltaMinFileSize is the minimum size of a file in an application
+	// package, e.g. a docker image layer, to be omitted from a delta
+	// installer if the base application version already has it
+	DeltaMinFileSize = 64 * 1024
+
 	// UpdateDir is the gravity subdirectory where update related data is stored
 	UpdateDir = "update"
 
@@ -532,15 +581,43 @@ const (
 	InfluxDBServiceAddr = "influxdb.monitoring.svc.cluster.local"
 	// InfluxDBServicePort is the API port of InfluxDB service
 	InfluxDBServicePort = 8086
+	// PrometheusServiceAddr is the address of Prometheus service
+	PrometheusServiceAddr = "prometheus.monitoring.svc.cluster.local"
+	// PrometheusServicePort is the API port of Prometheus service
+	PrometheusServicePort = 9090
+	// PrometheusStatefulSet is the name of the Prometheus statefulset
+	PrometheusStatefulSet = "prometheus"
+	// PrometheusRetention is the TSDB retention Prometheus uses unless configured otherwise
+	PrometheusRetention = 15 * 24 * time.Hour
 	// InfluxDBAdminUser is the InfluxDB admin user name
 	InfluxDBAdminUser = "root"
 	// InfluxDBAdminPassword is the InfluxDB admin user password
 	InfluxDBAdminPassword = "root"
 
+	// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// AlertTargetTimeout is the timeout for delivering an alert to an alert target
+	AlertTargetTimeout = 10 * time.Second
+	// AlertTestSince is the default time period 'gravity alert test' evaluates the alert for
+	AlertTestSince = time.Hour
+
 	// WriteFactor is a default amount of acknowledged writes for object storage
 	// to be considered successfull
 	WriteFactor = 1
 
+	// S3BlobPartSize is the default size of parts BLOBs are uploaded to
+	// S3 in. It caps the size of a single BLOB at 10000 parts, i.e. ~640GB
+	S3BlobPartSize = 64 * 1024 * 1024
+	// S3BlobCopyPartSize is the size of parts BLOBs larger than the S3 single
+	// copy limit are copied in
+	S3BlobCopyPartSize = 1024 * 1024 * 1024
+	// S3BlobMaxCopySize is the maximum size of an object S3 can copy
+	// in a single request
+	S3BlobMaxCopySize = 5 * 1024 * 1024 * 1024
+	// S3BlobUploadConcurrency is the default number of parts uploaded
+	// to S3 in parallel
+	S3BlobUploadConcurrency = 4
+
 	// ElectionTerm is a leader election term for multiple gravity instances
 	ElectionTerm = 10 * time.Second
 
@@ -675,6 +752,10 @@ const (
 	// LocalDataDir is a default directory where gravity stores its local data
 	LocalDataDir = ".gravity"
 
+	// LayerCacheDir is the name of the directory in the local cache where
+	// tele build caches the image layers fetched without Docker daemon
+	LayerCacheDir = "layers"
+
 	// DistributionOpsCenter is the address of OpsCenter used for distributing dependencies for app builds
 	DistributionOpsCenter = "https://get.gravitational.io"
 	// DistributionOpsCenterName is the name of the distribution Ops Center.
@@ -846,6 +927,17 @@ const (
 
 	// EtcdUpgradeBackupFile is the filename to store a temporary backup of the etcd database when recreating the etcd datastore
 	EtcdUpgradeBackupFile = "etcd.bak"
+	// EtcdPhaseRetries is the number of times idempotent etcd upgrade phases
+	// are retried before the upgrade is considered failed
+	EtcdPhaseRetries = 3
+	// EtcdPhaseRetryBackoff is the initial delay between retries of etcd upgrade phases
+	EtcdPhaseRetryBackoff = 5 * time.Second
+	// NodePhaseRetries is the number of times the upgrade phases that drain, taint
+	// or uncordon a node via the Kubernetes API are retried before the upgrade
+	// is considered failed
+	NodePhaseRetries = 3
+	// NodePhaseRetryBackoff is the initial delay between retries of node upgrade phases
+	NodePhaseRetryBackoff = 5 * time.Second
 
 	// EtcdPeerPort is etcd inter-cluster communication port
 	EtcdPeerPort = 2380
@@ -872,6 +964,89 @@ const (
 	// upon completing an operation
 	RPCAgentShutdownTimeout = 1 * time.Minute
 
+	// RPCAgentFileChunkSize is the size of chunks files are transferred
+	// to and from RPC agents in
+	RPCAgentFileChunkSize = 1024 * 1024
+
+	// PartialFileSuffix is the suffix of the files being transferred
+	// to and from RPC agents
+	PartialFileSuffix = ".partial"
+
+	// RPCAgentExecBufferSize is the size of the buffer used to stream
+	// the output of interactive sessions
+	RPCAgentExecBufferSize = 32 * 1024
+
+	// RPCAgentSessionRecordInterval defines how often the recorded lines
+	// of an interactive session are submitted to the operation log
+	RPCAgentSessionRecordInterval = 1 * time.Second
+
+	// RPCAgentSessionRecordBatchSize is the maximum number of lines
+	// of an interactive session submitted as a single operation log entry
+	RPCAgentSessionRecordBatchSize = 100
+
+	// RPCAgentSessionRecordBacklog is the maximum number of lines
+	// of an interactive session waiting to be recorded
+	RPCAgentSessionRecordBacklog = 10000
+
+	// RPCAgentCredentialsExpiryThreshold defines the time before the agent
+	// credentials expire to start warning about the expiration
+	RPCAgentCredentialsExpiryThreshold = 30 * 24 * time.Hour
+
+	// RPCAgentCredentialsCheckInterval defines how often the agent
+	// checks its credentials for expiration
+	RPCAgentCredentialsCheckInterval = 12 * time.Hour
+
+	// RPCAgentCredentialsUpdateTimeout defines how long the credentials
+	// of an agent are retried to be updated during the rotation
+	RPCAgentCredentialsUpdateTimeout = 1 * time.Minute
+
+	// AuditEventsLimit defines the default maximum number of audit log
+	// events returned by a query
+	AuditEventsLimit = 500
+
+	// AuditEventsSince defines the default time range of the audit log query
+	AuditEventsSince = 24 * time.Hour
+
+	// AuditFileMaxSizeMB defines the default size in megabytes
+	// after which the audit sink file is rotated
+	AuditFileMaxSizeMB = 100
+
+	// AuditFileMaxBackups defines the default number of rotated
+	// audit sink files to keep
+	AuditFileMaxBackups = 5
+
+	// AuditSinkQueueSize defines the maximum number of audit events
+	// queued for delivery to a single audit sink
+	AuditSinkQueueSize = 1000
+
+	// AuditSinkTimeout defines the maximum time to deliver an audit
+	// event to an audit sink in a single attempt
+	AuditSinkTimeout = 10 * time.Second
+
+	// AuditSinkMaxRetryInterval defines the maximum interval between
+	// attempts to deliver an audit event to an audit sink
+	AuditSinkMaxRetryInterval = time.Minute
+
+	// AuditSinkReloadInterval defines how often the audit sink
+	// configuration is reloaded from the backend
+	AuditSinkReloadInterval = 30 * time.Second
+
+	// AgentMetricsTimeout defines the maximum time to wait for the agent
+	// to report its health and resource usage metrics
+	AgentMetricsTimeout = 5 * time.Second
+
+	// AgentHighLoadPerCPU defines the system load average per CPU
+	// above which the node is considered overloaded
+	AgentHighLoadPerCPU = 2.0
+
+	// AgentLowMemoryPercent defines the percentage of available memory
+	// below which the node is considered low on memory
+	AgentLowMemoryPercent = 10
+
+	// AgentLowDiskPercent defines the percentage of free disk space
+	// below which the node is considered low on disk space
+	AgentLowDiskPercent = 10
+
 	// RPCAgentSecretsPackage specifies the name of the RPC credentials package
 	RPCAgentSecretsPackage = "rpcagent-secrets"
 
@@ -251,7 +251,7 @@ func (b *planBuilder) AddPostHookPhase(plan *storage.OperationPlan) {
 			Package:     &b.Application.Package,
 			ServiceUser: &b.ServiceUser,
 		},
-		Requires: []string{installphases.WaitPhase},
+		Requires: fsm.RequireIfPresent(plan, installphases.WaitPhase, StopAgentPhase),
 	})
 }
 
@@ -264,7 +264,7 @@ func (b *planBuilder) AddElectPhase(plan *storage.OperationPlan) {
 			Server:     &b.JoiningNode,
 			ExecServer: &b.JoiningNode,
 		},
-		Requires: []string{installphases.WaitPhase},
+		Requires: fsm.RequireIfPresent(plan, installphases.WaitPhase, StopAgentPhase, PostHookPhase),
 	}
 	if !b.JoiningNode.IsMaster() {
 		phase.Description = "Disable leader election on the joined node"
@@ -65,6 +65,8 @@ type FSMConfig struct {
 	DebugMode bool
 	// Insecure turns on FSM insecure mode
 	Insecure bool
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
 }
 
 // CheckAndSetDefaults validates expand FSM configuration and sets defaults
@@ -118,14 +120,16 @@ func NewFSM(config FSMConfig) (*fsm.FSM, error) {
 		FieldLogger: logger,
 	}
 	fsm, err := fsm.New(fsm.Config{
-		Engine: engine,
-		Runner: config.Runner,
-		Logger: logger,
+		Engine:      engine,
+		Runner:      config.Runner,
+		Logger:      logger,
+		MaxParallel: config.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	fsm.SetPreExec(engine.UpdateProgress)
+	fsm.SetPreRollback(engine.UpdateProgress)
 	return fsm, nil
 }
 
@@ -161,6 +165,7 @@ func (e *fsmEngine) ChangePhaseState(ctx context.Context, change fsm.StateChange
 		PhaseID:     change.Phase,
 		NewState:    change.State,
 		Error:       utils.ToRawTrace(change.Error),
+		Attempt:     change.Attempt,
 		Created:     time.Now().UTC(),
 	}
 	_, err := e.JoinBackend.CreateOperationPlanChange(planChange)
@@ -186,6 +191,10 @@ func (e *fsmEngine) GetPlan() (*storage.OperationPlan, error) {
 // server using the provided runner
 func (e *fsmEngine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, node storage.Server, p fsm.Params) error {
 	args := []string{"join", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force)}
+	if p.Rollback {
+		args = []string{"plan", "rollback", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force),
+			fmt.Sprintf("--attempt=%v", p.Attempt)}
+	}
 	if e.DebugMode {
 		args = append([]string{"--debug"}, args...)
 	}
@@ -236,7 +245,7 @@ func (e *fsmEngine) UpdateProgress(ctx context.Context, p fsm.Params) error {
 		Completion:  100 / len(fsm.FlattenPlan(plan)) * phase.Step,
 		Step:        phase.Step,
 		State:       ops.ProgressStateInProgress,
-		Message:     phase.Description,
+		Message:     fsm.ProgressMessage(*phase, p),
 		Created:     time.Now().UTC(),
 	}
 	err = e.Operator.CreateProgressEntry(e.OperationKey, entry)
@@ -76,6 +76,7 @@ func NewEtcd(p fsm.ExecutorParams, operator ops.Operator, runner fsm.AgentReposi
 		Etcd:           etcdClient,
 		Runner:         runner,
 		Master:         *p.Phase.Data.Master,
+		StateDir:       stateDir,
 		ExecutorParams: p,
 	}, nil
 }
@@ -89,6 +90,8 @@ type etcdExecutor struct {
 	Runner fsm.AgentRepository
 	// Master is one of the master nodes
 	Master storage.Server
+	// StateDir is the local gravity state directory
+	StateDir string
 	// ExecutorParams is common executor params
 	fsm.ExecutorParams
 }
@@ -137,12 +140,26 @@ func (p *etcdExecutor) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// checkBackup makes sure the backup file is present on the master node
+// restoring it from the local copy if necessary
 func (p *etcdExecutor) checkBackup(ctx context.Context, agent rpcclient.Client, backupPath string) error {
-	var out bytes.Buffer
-	err := agent.Command(ctx, p.FieldLogger, &out, utils.PlanetEnterCommand(
-		defaults.StatBin, backupPath)...)
-	if err != nil {
-		return trace.Wrap(err, "failed to check backup file %v: %s", backupPath, out.String())
+	hostPath := getHostBackupPath(p.Master, p.Plan.OperationID)
+	_, err := agent.StatFile(ctx, hostPath)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsNotFound(err) {
+		return trace.Wrap(err, "failed to check backup file %v", backupPath)
+	}
+	localPath := getLocalBackupPath(p.StateDir, p.Plan.OperationID)
+	if _, err := utils.StatFile(localPath); err != nil {
+		return trace.NotFound("backup file %v is missing on %v and there is no local copy",
+			backupPath, p.Master.AdvertiseIP)
+	}
+	p.Infof("Backup file is missing on %v, uploading the local copy %v.",
+		p.Master.AdvertiseIP, localPath)
+	if _, err := agent.UploadFile(ctx, localPath, hostPath); err != nil {
+		return trace.Wrap(err, "failed to upload backup file to %v", p.Master.AdvertiseIP)
 	}
 	return nil
 }
@@ -209,10 +226,15 @@ func NewEtcdBackup(p fsm.ExecutorParams, operator ops.Operator, runner fsm.Agent
 		Operator: operator,
 		Server:   p.Phase.Data.Server,
 	}
+	stateDir, err := state.GetStateDir()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return &etcdBackupExecutor{
 		FieldLogger:    logger,
 		Master:         *p.Phase.Data.Server,
 		Runner:         runner,
+		StateDir:       stateDir,
 		ExecutorParams: p,
 	}, nil
 }
@@ -224,6 +246,8 @@ type etcdBackupExecutor struct {
 	Master storage.Server
 	// Runner is used to run remote commands
 	Runner fsm.AgentRepository
+	// StateDir is the local gravity state directory
+	StateDir string
 	// ExecutorParams is common executor params
 	fsm.ExecutorParams
 }
@@ -241,6 +265,12 @@ func (p *etcdBackupExecutor) Execute(ctx context.Context) error {
 		return trace.Wrap(err)
 	}
 	p.Infof("Backed up etcd data to %v.", backupPath)
+	// keep a copy of the backup on this node in case the master loses it
+	localPath := getLocalBackupPath(p.StateDir, p.Plan.OperationID)
+	_, err = agentClient.DownloadFile(ctx, getHostBackupPath(p.Master, p.Plan.OperationID), localPath)
+	if err != nil {
+		p.Warnf("Failed to download backup copy to %v: %v.", localPath, trace.DebugReport(err))
+	}
 	return nil
 }
 
@@ -276,6 +306,19 @@ func getBackupPath(operationID string) string {
 		fmt.Sprintf("join-%v.backup", operationID))
 }
 
+// getHostBackupPath returns the path to the etcd data backup for the provided
+// operation on the host of the specified server
+func getHostBackupPath(server storage.Server, operationID string) string {
+	return filepath.Join(server.StateDir(), defaults.PlanetDir,
+		fmt.Sprintf("join-%v.backup", operationID))
+}
+
+// getLocalBackupPath returns the path to the local copy of the etcd data
+// backup for the provided operation
+func getLocalBackupPath(stateDir, operationID string) string {
+	return filepath.Join(stateDir, fmt.Sprintf("join-%v.backup", operationID))
+}
+
 func opKey(plan storage.OperationPlan) ops.SiteOperationKey {
 	return ops.SiteOperationKey{
 		AccountID:   plan.AccountID,
@@ -381,7 +381,7 @@ func (s *PlanSuite) verifyPostHookPhase(c *check.C, phase storage.OperationPhase
 			Package:     &s.appPackage,
 			ServiceUser: &s.serviceUser,
 		},
-		Requires: []string{installphases.WaitPhase},
+		Requires: []string{installphases.WaitPhase, StopAgentPhase},
 	}, phase)
 }
 
@@ -392,6 +392,6 @@ func (s *PlanSuite) verifyElectPhase(c *check.C, phase storage.OperationPhase) {
 			Server:     &s.joiningNode,
 			ExecServer: &s.joiningNode,
 		},
-		Requires: []string{installphases.WaitPhase},
+		Requires: []string{installphases.WaitPhase, StopAgentPhase, PostHookPhase},
 	}, phase)
 }
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// CheckpointVersion is the current version of the checkpoint bundle format
+const CheckpointVersion = "v1"
+
+// Checkpoint captures the state of an operation sufficient to resume it
+// from another node
+type Checkpoint struct {
+	// Created is the time the checkpoint was taken
+	Created time.Time `json:"created"`
+	// Cluster is the cluster the operation belongs to
+	Cluster storage.Site `json:"cluster"`
+	// Operation is the operation record
+	Operation storage.SiteOperation `json:"operation"`
+	// Plan is the operation plan
+	Plan storage.OperationPlan `json:"plan"`
+	// Changelog is the list of plan state transitions
+	Changelog storage.PlanChangelog `json:"changelog"`
+	// Progress is the list of operation progress entries
+	Progress []storage.ProgressEntry `json:"progress,omitempty"`
+}
+
+// Check makes sure the checkpoint is consistent
+func (c Checkpoint) Check() error {
+	if c.Operation.ID == "" {
+		return trace.BadParameter("checkpoint is missing operation")
+	}
+	if c.Plan.OperationID != c.Operation.ID {
+		return trace.BadParameter("checkpoint plan belongs to operation %v, not %v",
+			c.Plan.OperationID, c.Operation.ID)
+	}
+	if c.Cluster.Domain != c.Operation.SiteDomain || c.Plan.ClusterName != c.Operation.SiteDomain {
+		return trace.BadParameter("checkpoint operation %v does not belong to cluster %v",
+			c.Operation.ID, c.Cluster.Domain)
+	}
+	for _, change := range c.Changelog {
+		if change.OperationID != c.Operation.ID {
+			return trace.BadParameter("changelog entry %v belongs to operation %v, not %v",
+				change.ID, change.OperationID, c.Operation.ID)
+		}
+	}
+	return nil
+}
+
+// NewCheckpoint captures the state of the specified operation from the given backend
+func NewCheckpoint(backend storage.Backend, clusterName, operationID string) (*Checkpoint, error) {
+	cluster, err := backend.GetSite(clusterName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	operation, err := backend.GetSiteOperation(clusterName, operationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	plan, err := backend.GetOperationPlan(clusterName, operationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	changelog, err := backend.GetOperationPlanChangelog(clusterName, operationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	progress, err := backend.GetProgressEntries(clusterName, operationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Checkpoint{
+		Created:   time.Now().UTC(),
+		Cluster:   *cluster,
+		Operation: *operation,
+		Plan:      *plan,
+		Changelog: changelog,
+		Progress:  progress,
+	}, nil
+}
+
+// ImportCheckpoint restores the operation captured in the specified checkpoint
+// into the given backend.
+//
+// Records that already exist in the backend are left intact, only missing
+// changelog and progress entries are added so the import can be repeated
+func ImportCheckpoint(backend storage.Backend, checkpoint Checkpoint) error {
+	err := checkpoint.Check()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = backend.CreateSite(checkpoint.Cluster)
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	_, err = backend.CreateSiteOperation(checkpoint.Operation)
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	_, err = backend.CreateOperationPlan(checkpoint.Plan)
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	key := OperationKey(checkpoint.Plan)
+	changelog, err := backend.GetOperationPlanChangelog(key.SiteDomain, key.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, change := range DiffChangelog(checkpoint.Changelog, changelog) {
+		_, err = backend.CreateOperationPlanChange(change)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	progress, err := backend.GetProgressEntries(key.SiteDomain, key.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	existing := make(map[string]struct{}, len(progress))
+	for _, entry := range progress {
+		existing[entry.ID] = struct{}{}
+	}
+	for _, entry := range checkpoint.Progress {
+		if _, ok := existing[entry.ID]; ok {
+			continue
+		}
+		_, err = backend.CreateProgressEntry(entry)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// CheckpointSigner signs checkpoint bundles
+type CheckpointSigner interface {
+	// Sign returns the signature of the payload along with
+	// the PEM-encoded certificate of the signer
+	Sign(payload []byte) (signature, certificate []byte, err error)
+}
+
+// CheckpointVerifier verifies the signatures of checkpoint bundles
+type CheckpointVerifier interface {
+	// Verify makes sure that the payload has been signed by the owner
+	// of the specified PEM-encoded certificate and that the signer is trusted.
+	// Returns trace.AccessDenied if the signature cannot be trusted
+	Verify(payload, signature, certificate []byte) error
+}
+
+// WriteCheckpoint serializes the checkpoint as a bundle signed with
+// the specified signer into w
+func WriteCheckpoint(w io.Writer, checkpoint Checkpoint, signer CheckpointSigner) error {
+	payload, err := json.Marshal(checkpoint)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	signature, certificate, err := signer.Sign(payload)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	bundle := checkpointBundle{
+		Version:     CheckpointVersion,
+		Checkpoint:  payload,
+		Certificate: certificate,
+		Signature:   signature,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return trace.Wrap(enc.Encode(bundle))
+}
+
+// ReadCheckpoint reads the checkpoint bundle from r.
+// If verifier is nil, the bundle signature is not verified
+func ReadCheckpoint(r io.Reader, verifier CheckpointVerifier) (*Checkpoint, error) {
+	var bundle checkpointBundle
+	err := json.NewDecoder(r).Decode(&bundle)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to decode checkpoint bundle")
+	}
+	if bundle.Version != CheckpointVersion {
+		return nil, trace.BadParameter("unsupported checkpoint version %q, expected %q",
+			bundle.Version, CheckpointVersion)
+	}
+	if verifier != nil {
+		err = verifier.Verify(bundle.Checkpoint, bundle.Signature, bundle.Certificate)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	var checkpoint Checkpoint
+	err = json.Unmarshal(bundle.Checkpoint, &checkpoint)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to decode checkpoint")
+	}
+	err = checkpoint.Check()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &checkpoint, nil
+}
+
+// checkpointBundle is the serialized form of a signed checkpoint
+type checkpointBundle struct {
+	// Version is the bundle format version
+	Version string `json:"version"`
+	// Checkpoint is the serialized checkpoint.
+	// It is kept opaque so the signed bytes are preserved verbatim
+	Checkpoint []byte `json:"checkpoint"`
+	// Certificate is the PEM-encoded certificate of the signer
+	Certificate []byte `json:"certificate"`
+	// Signature is the signature of the serialized checkpoint
+	Signature []byte `json:"signature"`
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestFSM(t *testing.T) { check.TestingT(t) }
+
+type CheckpointSuite struct {
+	creds *rpc.CheckpointCredentials
+}
+
+var _ = check.Suite(&CheckpointSuite{})
+
+func (s *CheckpointSuite) SetUpSuite(c *check.C) {
+	s.creds = newTestCredentials(c)
+}
+
+func (s *CheckpointSuite) TestExportsAndImportsCheckpoint(c *check.C) {
+	src := newTestBackend(c)
+	createTestOperation(c, src)
+
+	checkpoint, err := NewCheckpoint(src, "example.com", "operation-1")
+	c.Assert(err, check.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(WriteCheckpoint(&buf, *checkpoint, *s.creds), check.IsNil)
+
+	imported, err := ReadCheckpoint(&buf, s.creds)
+	c.Assert(err, check.IsNil)
+
+	dst := newTestBackend(c)
+	// import is idempotent
+	for i := 0; i < 2; i++ {
+		c.Assert(ImportCheckpoint(dst, *imported), check.IsNil)
+	}
+
+	plan, err := GetOperationPlan(dst, "example.com", "operation-1")
+	c.Assert(err, check.IsNil)
+	phase, err := FindPhase(plan, "/phase1")
+	c.Assert(err, check.IsNil)
+	c.Assert(phase.GetState(), check.Equals, storage.OperationPhaseStateCompleted)
+
+	changelog, err := dst.GetOperationPlanChangelog("example.com", "operation-1")
+	c.Assert(err, check.IsNil)
+	c.Assert(changelog, check.HasLen, 2)
+
+	progress, err := dst.GetProgressEntries("example.com", "operation-1")
+	c.Assert(err, check.IsNil)
+	c.Assert(progress, check.DeepEquals, checkpoint.Progress)
+}
+
+func (s *CheckpointSuite) TestRejectsTamperedCheckpoint(c *check.C) {
+	src := newTestBackend(c)
+	createTestOperation(c, src)
+
+	checkpoint, err := NewCheckpoint(src, "example.com", "operation-1")
+	c.Assert(err, check.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(WriteCheckpoint(&buf, *checkpoint, *s.creds), check.IsNil)
+
+	var bundle checkpointBundle
+	c.Assert(json.Unmarshal(buf.Bytes(), &bundle), check.IsNil)
+	bundle.Checkpoint = bytes.Replace(bundle.Checkpoint,
+		[]byte(storage.OperationPhaseStateCompleted), []byte(storage.OperationPhaseStateFailed), -1)
+	tampered, err := json.Marshal(bundle)
+	c.Assert(err, check.IsNil)
+
+	_, err = ReadCheckpoint(bytes.NewReader(tampered), s.creds)
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+
+	// signer from a different cluster is not trusted
+	_, err = ReadCheckpoint(bytes.NewReader(buf.Bytes()), newTestCredentials(c))
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+func newTestCredentials(c *check.C) *rpc.CheckpointCredentials {
+	archive, err := rpc.GenerateAgentCredentials(nil, "example.com", true)
+	c.Assert(err, check.IsNil)
+	dir := c.MkDir()
+	for name, keyPair := range archive {
+		c.Assert(ioutil.WriteFile(filepath.Join(dir, name+".cert"), keyPair.CertPEM, 0600), check.IsNil)
+		if len(keyPair.KeyPEM) != 0 {
+			c.Assert(ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPair.KeyPEM, 0600), check.IsNil)
+		}
+	}
+	creds, err := rpc.LoadCheckpointCredentials(dir)
+	c.Assert(err, check.IsNil)
+	return creds
+}
+
+func newTestBackend(c *check.C) storage.Backend {
+	backend, err := keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(c.MkDir(), "bolt.db")})
+	c.Assert(err, check.IsNil)
+	return backend
+}
+
+func createTestOperation(c *check.C, backend storage.Backend) {
+	now := time.Now().UTC()
+	_, err := backend.CreateSite(storage.Site{
+		Domain:    "example.com",
+		AccountID: "account-1",
+		Created:   now,
+	})
+	c.Assert(err, check.IsNil)
+	_, err = backend.CreateSiteOperation(storage.SiteOperation{
+		ID:         "operation-1",
+		AccountID:  "account-1",
+		SiteDomain: "example.com",
+		Type:       "test_operation",
+		Created:    now,
+	})
+	c.Assert(err, check.IsNil)
+	_, err = backend.CreateOperationPlan(storage.OperationPlan{
+		OperationID:   "operation-1",
+		OperationType: "test_operation",
+		AccountID:     "account-1",
+		ClusterName:   "example.com",
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1"},
+			{ID: "/phase2", Requires: []string{"/phase1"}},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	for i, state := range []string{storage.OperationPhaseStateInProgress, storage.OperationPhaseStateCompleted} {
+		_, err = backend.CreateOperationPlanChange(storage.PlanChange{
+			ID:          state,
+			ClusterName: "example.com",
+			OperationID: "operation-1",
+			PhaseID:     "/phase1",
+			NewState:    state,
+			Created:     now.Add(time.Duration(i) * time.Second),
+		})
+		c.Assert(err, check.IsNil)
+	}
+	_, err = backend.CreateProgressEntry(storage.ProgressEntry{
+		SiteDomain:  "example.com",
+		OperationID: "operation-1",
+		Created:     now,
+		Completion:  50,
+		Message:     "Executing phase /phase1",
+	})
+	c.Assert(err, check.IsNil)
+}
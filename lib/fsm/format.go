@@ -66,6 +66,56 @@ func FormatOperationPlanText(w io.Writer, plan storage.OperationPlan) {
 	t.Flush()
 }
 
+// FormatSimulationJSON outputs the specified plan simulation as JSON
+func FormatSimulationJSON(w io.Writer, sim Simulation) error {
+	bytes, err := json.MarshalIndent(sim, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if _, err := w.Write(bytes); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// FormatSimulationText outputs the specified plan simulation in human-readable form
+func FormatSimulationText(w io.Writer, sim Simulation) {
+	var t tabwriter.Writer
+	t.Init(w, 0, 10, 5, ' ', 0)
+	common.PrintTableHeader(&t, []string{"Phase", "Node", "Action", "Target"})
+	for _, phase := range sim.Phases {
+		printPhaseSimulation(&t, phase)
+	}
+	t.Flush()
+}
+
+func printPhaseSimulation(w io.Writer, phase PhaseSimulation) {
+	node := phase.Node
+	if node == "" {
+		node = "-"
+	}
+	switch {
+	case phase.Skipped:
+		fmt.Fprintf(w, "%v\t%v\t(skipped: %v)\t-\n", phase.PhaseID, node, phase.Reason)
+	case phase.Error != "":
+		fmt.Fprintf(w, "%v\t%v\t(failed to describe: %v)\t-\n", phase.PhaseID, node, phase.Error)
+	case len(phase.Actions) == 0:
+		fmt.Fprintf(w, "%v\t%v\t(no actions)\t-\n", phase.PhaseID, node)
+	}
+	for i, action := range phase.Actions {
+		phaseID := phase.PhaseID
+		if i > 0 {
+			phaseID, node = "", ""
+		}
+		target := action.Target
+		if target == "" {
+			target = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t[%v] %v\t%v\n", phaseID, node, action.Kind, action.Description, target)
+	}
+}
+
 func printPhase(w io.Writer, phase storage.OperationPhase, indent int) {
 	marker := "*"
 	if phase.GetState() == storage.OperationPhaseStateInProgress {
@@ -80,7 +130,7 @@ func printPhase(w io.Writer, phase storage.OperationPhase, indent int) {
 		marker,
 		formatName(phase.ID),
 		phase.Description,
-		formatState(phase.GetState()),
+		formatPhaseState(phase),
 		formatNode(phase),
 		formatRequires(phase.Requires),
 		formatTimestamp(phase.GetLastUpdateTime()))
@@ -115,6 +165,21 @@ func formatTimestamp(t time.Time) string {
 	return t.Format(constants.HumanDateFormat)
 }
 
+// formatPhaseState returns the state of the specified phase along with
+// the number of the latest attempt for phases that have been retried
+// or have a retry policy
+func formatPhaseState(phase storage.OperationPhase) string {
+	state := formatState(phase.GetState())
+	switch {
+	case phase.Retries > 0 && phase.Attempt > 0:
+		return fmt.Sprintf("%v (attempt %v, %v retries)", state, phase.Attempt, phase.Retries)
+	case phase.Attempt > 1:
+		return fmt.Sprintf("%v (attempt %v)", state, phase.Attempt)
+	default:
+		return state
+	}
+}
+
 func formatState(state string) string {
 	switch state {
 	case storage.OperationPhaseStateUnstarted:
@@ -20,7 +20,10 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sync"
 
+	"github.com/gravitational/gravity/lib/checks"
+	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
@@ -75,6 +78,12 @@ type Params struct {
 	Force bool
 	// Progress is optional progress reporter
 	Progress utils.Progress
+	// Rollback specifies whether the phase is being rolled back
+	Rollback bool
+	// Attempt is the number of the attempt to execute or rollback the phase
+	// assigned by the node that dispatched the phase to this node.
+	// The phase is then attempted once regardless of its retry policy
+	Attempt int
 }
 
 // CheckAndSetDefaults makes sure all required parameters are set
@@ -99,6 +108,13 @@ type FSM struct {
 	preExecFn PhaseHookFn
 	// postExecFn is called after phase execution if set
 	postExecFn PhaseHookFn
+	// preRollbackFn is called before phase rollback during plan rollback if set
+	preRollbackFn PhaseHookFn
+	// hookMu serializes execution hooks invoked for phases executing
+	// concurrently
+	hookMu sync.Mutex
+	// slots limits the number of phases executing concurrently
+	slots chan struct{}
 }
 
 // PhaseHookFn defines the phase hook function
@@ -114,6 +130,9 @@ type Config struct {
 	Insecure bool
 	// Logger allows to override default logger
 	Logger logrus.FieldLogger
+	// MaxParallel limits the number of phases that can be executed
+	// concurrently. If unspecified, the number is not restricted
+	MaxParallel int
 }
 
 // CheckAndSetDefaults makes sure the config is valid and sets some defaults
@@ -133,30 +152,27 @@ func New(config Config) (*FSM, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	return &FSM{
+	fsm := &FSM{
 		Config:      config,
 		FieldLogger: config.Logger,
-	}, nil
+	}
+	if config.MaxParallel > 0 {
+		fsm.slots = make(chan struct{}, config.MaxParallel)
+	}
+	return fsm, nil
 }
 
-// ExecutePlan iterates over all phases of the plan and executes them in order
+// ExecutePlan executes all phases of the plan in order of their dependencies
 func (f *FSM) ExecutePlan(ctx context.Context, progress utils.Progress, force bool) error {
 	plan, err := f.GetPlan()
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	for _, phase := range plan.Phases {
-		f.Debugf("Executing phase %q.", phase.ID)
-		err := f.ExecutePhase(ctx, Params{
-			PhaseID:  phase.ID,
-			Progress: progress,
-			Force:    force,
-		})
-		if err != nil {
-			return trace.Wrap(err, "failed to execute phase %q", phase.ID)
-		}
-	}
-	return nil
+	err = f.executePhaseGroup(ctx, Params{
+		Progress: progress,
+		Force:    force,
+	}, plan.Phases, false)
+	return trace.Wrap(err)
 }
 
 // ExecutePhase executes the specified phase of the plan
@@ -184,23 +200,31 @@ func (f *FSM) ExecutePhase(ctx context.Context, p Params) error {
 	if err != nil && !p.Force {
 		return trace.Wrap(err)
 	}
-	if f.preExecFn != nil {
-		if err := f.preExecFn(ctx, p); err != nil {
-			return trace.Wrap(err)
-		}
+	if err := f.runHook(ctx, f.preExecFn, p); err != nil {
+		return trace.Wrap(err)
 	}
 	err = f.executePhase(ctx, p, *phase)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	if f.postExecFn != nil {
-		if err := f.postExecFn(ctx, p); err != nil {
-			return trace.Wrap(err)
-		}
+	if err := f.runHook(ctx, f.postExecFn, p); err != nil {
+		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// runHook invokes the specified phase hook if it is set.
+// Hooks are not required to be safe for concurrent use so
+// invocations from phases executing concurrently are serialized
+func (f *FSM) runHook(ctx context.Context, fn PhaseHookFn, p Params) error {
+	if fn == nil {
+		return nil
+	}
+	f.hookMu.Lock()
+	defer f.hookMu.Unlock()
+	return fn(ctx, p)
+}
+
 // RollbackPhase rolls back the specified phase of the plan
 func (f *FSM) RollbackPhase(ctx context.Context, p Params) error {
 	err := p.CheckAndSetDefaults()
@@ -227,15 +251,7 @@ func (f *FSM) RollbackPhase(ctx context.Context, p Params) error {
 		// Check whether this phase should be run on a local or remote server
 		// If it should be run on a remote server, throw an error
 		//
-		var execServer *storage.Server
-		if phase.Data != nil {
-			if phase.Data.ExecServer != nil {
-				execServer = phase.Data.ExecServer
-			} else {
-				execServer = phase.Data.Server
-			}
-		}
-
+		execServer := phaseExecServer(*phase)
 		if execServer != nil {
 			execWhere, err := canExecuteOnServer(ctx, *execServer, f.Runner, f.FieldLogger)
 			if err != nil {
@@ -273,6 +289,12 @@ func (f *FSM) SetPostExec(fn PhaseHookFn) {
 	f.postExecFn = fn
 }
 
+// SetPreRollback sets the hook that's called before each phase is rolled
+// back during plan rollback
+func (f *FSM) SetPreRollback(fn PhaseHookFn) {
+	f.preRollbackFn = fn
+}
+
 // Close releases all FSM resources
 func (f *FSM) Close() error {
 	return trace.Wrap(f.Runner.Close())
@@ -288,16 +310,17 @@ func (f *FSM) executePhase(ctx context.Context, p Params, phase storage.Operatio
 	}
 
 	// Choose server to execute phase on
-	var execServer *storage.Server
-	if phase.Data != nil {
-		if phase.Data.ExecServer != nil {
-			execServer = phase.Data.ExecServer
-		} else {
-			execServer = phase.Data.Server
+	execServer := phaseExecServer(phase)
+
+	var err error
+	if !phase.HasSubphases() {
+		release, err := f.acquireSlot(ctx)
+		if err != nil {
+			return trace.Wrap(err)
 		}
+		defer release()
 	}
 
-	var err error
 	execWhere := CanRunLocally
 	if execServer != nil {
 		execWhere, err = canExecuteOnServer(ctx, *execServer, f.Runner, f.FieldLogger)
@@ -315,13 +338,17 @@ func (f *FSM) executePhase(ctx context.Context, p Params, phase storage.Operatio
 		err = trace.Wrap(f.executePhaseLocally(ctx, p, phase))
 
 	case CanRunRemotely:
-		err = trace.Wrap(f.executePhaseRemotely(ctx, p, phase, *execServer))
+		var attempt int
+		attempt, err = withRemoteRetries(ctx, p, phase, f.FieldLogger, func(ctx context.Context, p Params) error {
+			return f.executePhaseRemotely(ctx, p, phase, *execServer)
+		})
 		if err == nil {
 			// if the remote upgrade phase is successfull, we need to mark it in our local database
 			// because etcd might not be available to synchronize the changes back to us
 			err = f.ChangePhaseState(ctx, StateChange{
-				Phase: phase.ID,
-				State: storage.OperationPhaseStateCompleted,
+				Phase:   phase.ID,
+				State:   storage.OperationPhaseStateCompleted,
+				Attempt: attempt,
 			})
 		}
 
@@ -342,49 +369,51 @@ func (f *FSM) executePhaseRemotely(ctx context.Context, p Params, phase storage.
 			"phase %v has subphases and should not be executed remotely", phase.ID)
 	}
 
+	f.checkServerMetrics(ctx, p, server)
+
 	p.Progress.NextStep("Executing %q on remote node %v", phase.ID,
 		server.Hostname)
 
 	return f.RunCommand(ctx, f.Runner, server, p)
 }
 
-// executePhaseLocally executes the specified operation phase on this server
-func (f *FSM) executePhaseLocally(ctx context.Context, p Params, phase storage.OperationPhase) error {
-	if !phase.HasSubphases() {
-		p.Progress.NextStep("Executing %q locally", phase.ID)
-		return trace.Wrap(f.executeOnePhase(ctx, p, phase))
+// checkServerMetrics queries the health and resource usage metrics of the
+// agent on the specified server and warns about resource shortages before
+// a phase is dispatched to it.
+// Metrics are informational so failure to query them is not an error
+func (f *FSM) checkServerMetrics(ctx context.Context, p Params, server storage.Server) {
+	agents, ok := f.Runner.(AgentRepository)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaults.AgentMetricsTimeout)
+	defer cancel()
+	clt, err := agents.GetClient(ctx, server.AdvertiseIP)
+	if err != nil {
+		f.WithError(err).Warnf("Failed to connect to agent on %v.", serverName(server))
+		return
 	}
-	if phase.Parallel {
-		return trace.Wrap(f.executeSubphasesConcurrently(ctx, p, phase))
+	metrics, err := clt.GetMetrics(ctx)
+	if err != nil {
+		f.WithError(err).Warnf("Failed to query agent metrics on %v.", serverName(server))
+		return
 	}
-	return trace.Wrap(f.executeSubphasesSequentially(ctx, p, phase))
-}
-
-func (f *FSM) executeSubphasesSequentially(ctx context.Context, p Params, phase storage.OperationPhase) error {
-	for _, subphase := range phase.Phases {
-		p.PhaseID = subphase.ID
-		err := f.ExecutePhase(ctx, p)
-		if err != nil {
-			return trace.Wrap(err)
-		}
+	var numCPU uint
+	if info, err := clt.GetSystemInfo(ctx); err == nil {
+		numCPU = info.GetNumCPU()
+	}
+	for _, warning := range checks.MetricsWarnings(*metrics, numCPU) {
+		p.Progress.PrintWarn(nil, "Node %v: %v", serverName(server), warning)
 	}
-	return nil
 }
 
-func (f *FSM) executeSubphasesConcurrently(ctx context.Context, p Params, phase storage.OperationPhase) error {
-	errorsCh := make(chan error, len(phase.Phases))
-	for _, subphase := range phase.Phases {
-		go func(p Params, subphase storage.OperationPhase) {
-			p.PhaseID = subphase.ID
-			err := f.ExecutePhase(ctx, p)
-			if err != nil {
-				logrus.Warnf("Failed to execute phase %q: %v.",
-					p.PhaseID, trace.DebugReport(err))
-			}
-			errorsCh <- trace.Wrap(err, "failed to execute phase %q", p.PhaseID)
-		}(p, subphase)
+// executePhaseLocally executes the specified operation phase on this server
+func (f *FSM) executePhaseLocally(ctx context.Context, p Params, phase storage.OperationPhase) error {
+	if !phase.HasSubphases() {
+		p.Progress.NextStep("Executing %q locally", phase.ID)
+		return trace.Wrap(f.executeOnePhase(ctx, p, phase))
 	}
-	return utils.CollectErrors(ctx, errorsCh)
+	return trace.Wrap(f.executePhaseGroup(ctx, p, phase.Phases, phase.Parallel))
 }
 
 func (f *FSM) executeOnePhase(ctx context.Context, p Params, phase storage.OperationPhase) error {
@@ -407,28 +436,38 @@ func (f *FSM) executeOnePhase(ctx context.Context, p Params, phase storage.Opera
 		return trace.Wrap(err)
 	}
 
-	err = f.ChangePhaseState(ctx,
-		StateChange{
-			Phase: phase.ID,
-			State: storage.OperationPhaseStateInProgress,
-		})
-	if err != nil {
-		return trace.Wrap(err)
-	}
+	var attempt int
+	err = withRetries(ctx, retryPolicy(p, phase), executor, func(attemptCtx context.Context, n int) error {
+		attempt = n
+		err := f.ChangePhaseState(ctx,
+			StateChange{
+				Phase:   phase.ID,
+				State:   storage.OperationPhaseStateInProgress,
+				Attempt: attempt,
+			})
+		if err != nil {
+			return permanent(trace.Wrap(err))
+		}
 
-	executor.Infof("Executing phase: %v.", phase.ID)
+		executor.Infof("Executing phase: %v.", phase.ID)
 
-	err = executor.Execute(ctx)
-	if err != nil {
-		executor.Errorf("Phase execution failed: %v.", err)
-		if err := f.ChangePhaseState(ctx,
-			StateChange{
-				Phase: phase.ID,
-				State: storage.OperationPhaseStateFailed,
-				Error: trace.Wrap(err),
-			}); err != nil {
+		err = executor.Execute(attemptCtx)
+		if err != nil {
+			executor.Errorf("Phase execution failed: %v.", err)
+			if err := f.ChangePhaseState(ctx,
+				StateChange{
+					Phase:   phase.ID,
+					State:   storage.OperationPhaseStateFailed,
+					Error:   trace.Wrap(err),
+					Attempt: attempt,
+				}); err != nil {
+				return permanent(trace.Wrap(err))
+			}
 			return trace.Wrap(err)
 		}
+		return nil
+	})
+	if err != nil {
 		return trace.Wrap(err)
 	}
 
@@ -440,8 +479,9 @@ func (f *FSM) executeOnePhase(ctx context.Context, p Params, phase storage.Opera
 
 	err = f.ChangePhaseState(ctx,
 		StateChange{
-			Phase: phase.ID,
-			State: storage.OperationPhaseStateCompleted,
+			Phase:   phase.ID,
+			State:   storage.OperationPhaseStateCompleted,
+			Attempt: attempt,
 		})
 	if err != nil {
 		return trace.Wrap(err)
@@ -464,33 +504,44 @@ func (f *FSM) rollbackPhase(ctx context.Context, p Params, phase storage.Operati
 		return trace.Wrap(err)
 	}
 
-	err = f.ChangePhaseState(ctx,
-		StateChange{
-			Phase: phase.ID,
-			State: storage.OperationPhaseStateInProgress,
-		})
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	err = executor.Rollback(ctx)
-	if err != nil {
-		executor.Errorf("Phase %v rollback failed: %v.", phase.ID, err)
-		if err := f.ChangePhaseState(ctx,
+	var attempt int
+	err = withRetries(ctx, retryPolicy(p, phase), executor, func(attemptCtx context.Context, n int) error {
+		attempt = n
+		err := f.ChangePhaseState(ctx,
 			StateChange{
-				Phase: phase.ID,
-				State: storage.OperationPhaseStateFailed,
-				Error: trace.Wrap(err),
-			}); err != nil {
+				Phase:   phase.ID,
+				State:   storage.OperationPhaseStateInProgress,
+				Attempt: attempt,
+			})
+		if err != nil {
+			return permanent(trace.Wrap(err))
+		}
+
+		err = executor.Rollback(attemptCtx)
+		if err != nil {
+			executor.Errorf("Phase %v rollback failed: %v.", phase.ID, err)
+			if err := f.ChangePhaseState(ctx,
+				StateChange{
+					Phase:   phase.ID,
+					State:   storage.OperationPhaseStateFailed,
+					Error:   trace.Wrap(err),
+					Attempt: attempt,
+				}); err != nil {
+				return permanent(trace.Wrap(err))
+			}
 			return trace.Wrap(err)
 		}
+		return nil
+	})
+	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	err = f.ChangePhaseState(ctx,
 		StateChange{
-			Phase: phase.ID,
-			State: storage.OperationPhaseStateRolledBack,
+			Phase:   phase.ID,
+			State:   storage.OperationPhaseStateRolledBack,
+			Attempt: attempt,
 		})
 	if err != nil {
 		return trace.Wrap(err)
@@ -533,16 +584,18 @@ type StateChange struct {
 	State string
 	// Error is the error that happened during phase execution
 	Error trace.Error
+	// Attempt is the number of the attempt to execute or rollback the phase
+	Attempt int
 }
 
 // String returns a textual representation of this state change
 func (c StateChange) String() string {
 	if c.Error != nil {
-		return fmt.Sprintf("StateChange(Phase=%v, State=%v, Error=%v)",
-			c.Phase, c.State, c.Error)
+		return fmt.Sprintf("StateChange(Phase=%v, State=%v, Attempt=%v, Error=%v)",
+			c.Phase, c.State, c.Attempt, c.Error)
 	}
-	return fmt.Sprintf("StateChange(Phase=%v, State=%v)",
-		c.Phase, c.State)
+	return fmt.Sprintf("StateChange(Phase=%v, State=%v, Attempt=%v)",
+		c.Phase, c.State, c.Attempt)
 }
 
 // RootPhase is the name of the top-level phase
@@ -0,0 +1,566 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/check.v1"
+)
+
+type FSMSuite struct{}
+
+var _ = check.Suite(&FSMSuite{})
+
+func (s *FSMSuite) TestOrdersPhasesByRequirements(c *check.C) {
+	tcs := []struct {
+		comment  string
+		phases   []storage.OperationPhase
+		parallel bool
+		order    []string
+	}{
+		{
+			comment: "phases without requirements keep the plan order",
+			phases:  []storage.OperationPhase{{ID: "/a"}, {ID: "/b"}, {ID: "/c"}},
+			order:   []string{"/a", "/b", "/c"},
+		},
+		{
+			comment: "requirement on a subphase orders the whole subtree",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Phases: []storage.OperationPhase{{ID: "/a/1"}, {ID: "/a/2"}}},
+				{ID: "/b", Requires: []string{"/a/1"}},
+				{ID: "/c"},
+			},
+			order: []string{"/a/1", "/a/2", "/b", "/c"},
+		},
+		{
+			comment: "parallel phases are only ordered by requirements",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Phases: []storage.OperationPhase{{ID: "/a/1", Requires: []string{"/b/1"}}}},
+				{ID: "/b", Phases: []storage.OperationPhase{{ID: "/b/1"}}},
+			},
+			parallel: true,
+			order:    []string{"/b/1", "/a/1"},
+		},
+		{
+			comment: "requirements within the subtree of a phase are scheduled with its subphases",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Parallel: true, Phases: []storage.OperationPhase{
+					{ID: "/a/1", Requires: []string{"/a/2"}},
+					{ID: "/a/2"},
+				}},
+				{ID: "/b"},
+			},
+			parallel: true,
+			order:    []string{"/a/2", "/a/1", "/b"},
+		},
+	}
+	for _, tc := range tcs {
+		phases, err := executionOrder(tc.phases, tc.parallel)
+		c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+		var order []string
+		for _, phase := range phases {
+			order = append(order, phase.ID)
+		}
+		c.Assert(order, check.DeepEquals, tc.order, check.Commentf(tc.comment))
+	}
+}
+
+func (s *FSMSuite) TestChecksPlan(c *check.C) {
+	tcs := []struct {
+		comment string
+		phases  []storage.OperationPhase
+		valid   bool
+	}{
+		{
+			comment: "valid plan",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Retries: 2, Timeout: time.Minute},
+				{ID: "/b", Parallel: true, Phases: []storage.OperationPhase{
+					{ID: "/b/1"},
+					{ID: "/b/2", Requires: []string{"/b/1", "/a"}},
+				}},
+			},
+			valid: true,
+		},
+		{
+			comment: "cycle between parallel phases",
+			phases: []storage.OperationPhase{
+				{ID: "/x", Parallel: true, Phases: []storage.OperationPhase{
+					{ID: "/x/a", Requires: []string{"/x/b"}},
+					{ID: "/x/b", Requires: []string{"/x/a"}},
+				}},
+			},
+		},
+		{
+			comment: "cycle between subtrees",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Phases: []storage.OperationPhase{
+					{ID: "/a/1", Requires: []string{"/b/1"}},
+					{ID: "/a/2"},
+				}},
+				{ID: "/b", Phases: []storage.OperationPhase{
+					{ID: "/b/1", Requires: []string{"/a/2"}},
+				}},
+			},
+		},
+		{
+			comment: "phase without requirements is ordered after the preceding phase",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Requires: []string{"/b"}},
+				{ID: "/b"},
+			},
+		},
+		{
+			comment: "negative number of retries",
+			phases:  []storage.OperationPhase{{ID: "/a", Retries: -1}},
+		},
+		{
+			comment: "negative timeout",
+			phases:  []storage.OperationPhase{{ID: "/a", Timeout: -time.Second}},
+		},
+		{
+			comment: "retry policy on a phase with subphases",
+			phases: []storage.OperationPhase{
+				{ID: "/a", Retries: 1, Phases: []storage.OperationPhase{{ID: "/a/1"}}},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		err := CheckPlan(storage.OperationPlan{Phases: tc.phases})
+		if tc.valid {
+			c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+			continue
+		}
+		c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v: %v", tc.comment, err))
+	}
+}
+
+func (s *FSMSuite) TestExecutesPhasesAfterRequirements(c *check.C) {
+	phases := []storage.OperationPhase{
+		{ID: "/init"},
+		{ID: "/deploy", Parallel: true, Phases: []storage.OperationPhase{
+			{ID: "/deploy/a"},
+			{ID: "/deploy/b", Requires: []string{"/deploy/a"}},
+			{ID: "/deploy/c"},
+			{ID: "/deploy/d", Requires: []string{"/deploy/b", "/deploy/c"}},
+		}},
+	}
+	recorder := newTestRecorder()
+	machine := newTestFSM(c, phases, recorder, 0)
+
+	c.Assert(machine.ExecutePlan(context.TODO(), nil, false), check.IsNil)
+
+	plan, err := machine.GetPlan()
+	c.Assert(err, check.IsNil)
+	c.Assert(IsCompleted(plan), check.Equals, true)
+	for _, phase := range FlattenPlan(plan) {
+		if phase.HasSubphases() {
+			continue
+		}
+		requires := phase.Requires
+		if phase.ID == "/deploy/a" || phase.ID == "/deploy/c" {
+			requires = []string{"/init"}
+		}
+		started := recorder.index("start " + phase.ID)
+		c.Assert(started, check.Not(check.Equals), -1, check.Commentf(phase.ID))
+		for _, required := range requires {
+			finished := recorder.index("finish " + required)
+			c.Assert(finished != -1 && finished < started, check.Equals, true,
+				check.Commentf("%v started before %v finished: %v", phase.ID, required, recorder.events))
+		}
+	}
+}
+
+func (s *FSMSuite) TestStopsSchedulingAfterFailure(c *check.C) {
+	phases := []storage.OperationPhase{
+		{ID: "/deploy", Parallel: true, Phases: []storage.OperationPhase{
+			{ID: "/deploy/a"},
+			{ID: "/deploy/b", Requires: []string{"/deploy/a"}},
+		}},
+	}
+	recorder := newTestRecorder()
+	recorder.failures["/deploy/a"] = 1
+	machine := newTestFSM(c, phases, recorder, 0)
+
+	c.Assert(machine.ExecutePlan(context.TODO(), nil, false), check.NotNil)
+
+	plan, err := machine.GetPlan()
+	c.Assert(err, check.IsNil)
+	c.Assert(phaseState(c, plan, "/deploy/a"), check.Equals, storage.OperationPhaseStateFailed)
+	c.Assert(phaseState(c, plan, "/deploy/b"), check.Equals, storage.OperationPhaseStateUnstarted)
+}
+
+// TestLimitsConcurrentPhases executes many independent phases concurrently.
+// Run with -race to detect unsynchronized access to the plan
+// from the phases executing concurrently
+func (s *FSMSuite) TestLimitsConcurrentPhases(c *check.C) {
+	tcs := []struct {
+		comment     string
+		maxParallel int
+	}{
+		{comment: "unlimited"},
+		{comment: "one phase at a time", maxParallel: 1},
+		{comment: "three phases at a time", maxParallel: 3},
+	}
+	for _, tc := range tcs {
+		var subphases []storage.OperationPhase
+		for i := 0; i < 10; i++ {
+			subphases = append(subphases, storage.OperationPhase{ID: fmt.Sprintf("/nodes/node-%v", i)})
+		}
+		phases := []storage.OperationPhase{{ID: "/nodes", Parallel: true, Phases: subphases}}
+		recorder := newTestRecorder()
+		machine := newTestFSM(c, phases, recorder, tc.maxParallel)
+
+		c.Assert(machine.ExecutePlan(context.TODO(), nil, false), check.IsNil, check.Commentf(tc.comment))
+
+		plan, err := machine.GetPlan()
+		c.Assert(err, check.IsNil)
+		c.Assert(IsCompleted(plan), check.Equals, true, check.Commentf(tc.comment))
+		if tc.maxParallel == 0 {
+			c.Assert(recorder.maxRunning > 1, check.Equals, true, check.Commentf(tc.comment))
+			continue
+		}
+		c.Assert(recorder.maxRunning <= tc.maxParallel, check.Equals, true,
+			check.Commentf("%v: %v phases executed concurrently", tc.comment, recorder.maxRunning))
+	}
+}
+
+func (s *FSMSuite) TestRetriesPhases(c *check.C) {
+	tcs := []struct {
+		comment  string
+		phase    storage.OperationPhase
+		failures int
+		hang     bool
+		state    string
+		attempt  int
+		failed   int
+	}{
+		{
+			comment:  "succeeds after retries",
+			phase:    storage.OperationPhase{ID: "/a", Retries: 2},
+			failures: 2,
+			state:    storage.OperationPhaseStateCompleted,
+			attempt:  3,
+			failed:   2,
+		},
+		{
+			comment:  "fails after retries are exhausted",
+			phase:    storage.OperationPhase{ID: "/a", Retries: 2},
+			failures: 3,
+			state:    storage.OperationPhaseStateFailed,
+			attempt:  3,
+			failed:   2,
+		},
+		{
+			comment:  "fails without retries",
+			phase:    storage.OperationPhase{ID: "/a"},
+			failures: 1,
+			state:    storage.OperationPhaseStateFailed,
+			attempt:  1,
+		},
+		{
+			comment: "retries attempts that time out",
+			phase:   storage.OperationPhase{ID: "/a", Retries: 1, Timeout: 10 * time.Millisecond},
+			hang:    true,
+			state:   storage.OperationPhaseStateFailed,
+			attempt: 2,
+			failed:  1,
+		},
+	}
+	for _, tc := range tcs {
+		recorder := newTestRecorder()
+		recorder.failures[tc.phase.ID] = tc.failures
+		recorder.hang = tc.hang
+		machine := newTestFSM(c, []storage.OperationPhase{tc.phase}, recorder, 0)
+
+		err := machine.ExecutePhase(context.TODO(), Params{PhaseID: tc.phase.ID})
+		if tc.state == storage.OperationPhaseStateCompleted {
+			c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+		} else {
+			c.Assert(err, check.NotNil, check.Commentf(tc.comment))
+		}
+		if tc.hang {
+			c.Assert(trace.IsLimitExceeded(err), check.Equals, true, check.Commentf("%v: %v", tc.comment, err))
+		}
+
+		plan, err := machine.GetPlan()
+		c.Assert(err, check.IsNil)
+		phase, err := FindPhase(plan, tc.phase.ID)
+		c.Assert(err, check.IsNil)
+		c.Assert(phase.State, check.Equals, tc.state, check.Commentf(tc.comment))
+		c.Assert(phase.Attempt, check.Equals, tc.attempt, check.Commentf(tc.comment))
+		c.Assert(phase.FailedAttempts, check.HasLen, tc.failed, check.Commentf(tc.comment))
+	}
+}
+
+func (s *FSMSuite) TestRollsBackStartedPhasesInReverseOrder(c *check.C) {
+	phases := []storage.OperationPhase{
+		{ID: "/a"},
+		{ID: "/b", Parallel: true, Phases: []storage.OperationPhase{
+			{ID: "/b/1"},
+			{ID: "/b/2", Requires: []string{"/b/1"}},
+		}},
+		{ID: "/c"},
+		{ID: "/d"},
+	}
+	recorder := newTestRecorder()
+	recorder.failures["/c"] = 1
+	machine := newTestFSM(c, phases, recorder, 0)
+	var hooks []string
+	machine.SetPreRollback(func(ctx context.Context, p Params) error {
+		hooks = append(hooks, p.PhaseID)
+		return nil
+	})
+	c.Assert(machine.ExecutePlan(context.TODO(), nil, false), check.NotNil)
+
+	c.Assert(machine.RollbackPlan(context.TODO(), nil, false), check.IsNil)
+
+	expected := []string{"/c", "/b/2", "/b/1", "/a"}
+	c.Assert(hooks, check.DeepEquals, expected)
+	c.Assert(recorder.rolledBack, check.DeepEquals, expected)
+	plan, err := machine.GetPlan()
+	c.Assert(err, check.IsNil)
+	for _, id := range expected {
+		c.Assert(phaseState(c, plan, id), check.Equals, storage.OperationPhaseStateRolledBack, check.Commentf(id))
+	}
+	c.Assert(phaseState(c, plan, "/d"), check.Equals, storage.OperationPhaseStateUnstarted)
+}
+
+func (s *FSMSuite) TestSimulatesPlan(c *check.C) {
+	phases := []storage.OperationPhase{
+		{ID: "/a", State: storage.OperationPhaseStateCompleted},
+		{ID: "/b", Executor: "described"},
+		{ID: "/c", Executor: "opaque", Description: "Opaque phase"},
+	}
+	recorder := newTestRecorder()
+	machine := newTestFSM(c, phases, recorder, 0)
+
+	sim, err := machine.SimulatePlan(context.TODO(), false)
+	c.Assert(err, check.IsNil)
+	c.Assert(sim.Phases, check.DeepEquals, []PhaseSimulation{
+		{
+			PhaseID: "/a",
+			Skipped: true,
+			Reason:  "phase is already completed",
+		},
+		{
+			PhaseID:   "/b",
+			Executor:  "described",
+			Described: true,
+			Actions:   []Action{CommandAction("Run command", "echo", "/b")},
+		},
+		{
+			PhaseID:     "/c",
+			Description: "Opaque phase",
+			Executor:    "opaque",
+			Actions:     []Action{PhaseAction(phases[2])},
+		},
+	})
+	// simulation changes neither the phases nor their state
+	c.Assert(recorder.events, check.HasLen, 0)
+	engine := machine.Engine.(*testEngine)
+	c.Assert(engine.changelog, check.HasLen, 0)
+}
+
+func newTestFSM(c *check.C, phases []storage.OperationPhase, recorder *testRecorder, maxParallel int) *FSM {
+	engine := &testEngine{
+		plan: storage.OperationPlan{
+			OperationID:   "operation-1",
+			OperationType: "test_operation",
+			ClusterName:   "example.com",
+			Phases:        phases,
+		},
+		recorder: recorder,
+	}
+	machine, err := New(Config{
+		Engine:      engine,
+		MaxParallel: maxParallel,
+	})
+	c.Assert(err, check.IsNil)
+	return machine
+}
+
+func phaseState(c *check.C, plan *storage.OperationPlan, phaseID string) string {
+	phase, err := FindPhase(plan, phaseID)
+	c.Assert(err, check.IsNil)
+	return phase.GetState()
+}
+
+// testEngine is the FSM engine that keeps the plan in memory.
+// The plan is resolved from the changelog after each state change
+type testEngine struct {
+	sync.Mutex
+	plan      storage.OperationPlan
+	changelog storage.PlanChangelog
+	recorder  *testRecorder
+}
+
+func (r *testEngine) GetExecutor(p ExecutorParams, remote Remote) (PhaseExecutor, error) {
+	phase := &testPhase{
+		FieldLogger: logrus.WithField(trace.Component, "test"),
+		id:          p.Phase.ID,
+		recorder:    r.recorder,
+	}
+	if p.Phase.Executor == "described" {
+		return &testDescribedPhase{testPhase: phase}, nil
+	}
+	return phase, nil
+}
+
+func (r *testEngine) ChangePhaseState(ctx context.Context, change StateChange) error {
+	r.Lock()
+	defer r.Unlock()
+	r.changelog = append(r.changelog, storage.PlanChange{
+		ID:          fmt.Sprint(len(r.changelog)),
+		ClusterName: r.plan.ClusterName,
+		OperationID: r.plan.OperationID,
+		PhaseID:     change.Phase,
+		NewState:    change.State,
+		Error:       utils.ToRawTrace(change.Error),
+		Attempt:     change.Attempt,
+		Created:     time.Unix(int64(len(r.changelog)), 0).UTC(),
+	})
+	r.plan = *ResolvePlan(r.plan, r.changelog)
+	return nil
+}
+
+func (r *testEngine) GetPlan() (*storage.OperationPlan, error) {
+	r.Lock()
+	plan := r.plan
+	r.Unlock()
+	return &plan, nil
+}
+
+func (r *testEngine) RunCommand(context.Context, RemoteRunner, storage.Server, Params) error {
+	return trace.NotImplemented("remote execution is not supported")
+}
+
+func (r *testEngine) Complete(error) error {
+	return nil
+}
+
+// testPhase is the phase executor that records its execution
+type testPhase struct {
+	logrus.FieldLogger
+	id       string
+	recorder *testRecorder
+}
+
+func (p *testPhase) PreCheck(context.Context) error {
+	return nil
+}
+
+func (p *testPhase) PostCheck(context.Context) error {
+	return nil
+}
+
+func (p *testPhase) Execute(ctx context.Context) error {
+	return p.recorder.execute(ctx, p.id)
+}
+
+func (p *testPhase) Rollback(context.Context) error {
+	p.recorder.rollback(p.id)
+	return nil
+}
+
+// testDescribedPhase is the phase executor that describes its actions
+type testDescribedPhase struct {
+	*testPhase
+}
+
+func (p *testDescribedPhase) Describe(context.Context) ([]Action, error) {
+	return []Action{CommandAction("Run command", "echo", p.id)}, nil
+}
+
+func newTestRecorder() *testRecorder {
+	return &testRecorder{failures: make(map[string]int)}
+}
+
+// testRecorder records execution and rollback of phases
+type testRecorder struct {
+	sync.Mutex
+	// failures maps a phase to the number of times it fails before it succeeds
+	failures map[string]int
+	// hang makes phases block until their context expires
+	hang bool
+	// events lists phase starts and finishes in order
+	events []string
+	// rolledBack lists phases in the order they were rolled back
+	rolledBack []string
+	running    int
+	maxRunning int
+}
+
+func (r *testRecorder) execute(ctx context.Context, id string) error {
+	r.Lock()
+	r.events = append(r.events, "start "+id)
+	r.running++
+	if r.running > r.maxRunning {
+		r.maxRunning = r.running
+	}
+	fail := r.failures[id] > 0
+	if fail {
+		r.failures[id]--
+	}
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		r.running--
+		r.events = append(r.events, "finish "+id)
+		r.Unlock()
+	}()
+	if r.hang {
+		<-ctx.Done()
+		return trace.Wrap(ctx.Err())
+	}
+	if fail {
+		return trace.ConnectionProblem(nil, "phase %v failed", id)
+	}
+	select {
+	case <-time.After(10 * time.Millisecond):
+		return nil
+	case <-ctx.Done():
+		return trace.Wrap(ctx.Err())
+	}
+}
+
+func (r *testRecorder) rollback(id string) {
+	r.Lock()
+	defer r.Unlock()
+	r.rolledBack = append(r.rolledBack, id)
+}
+
+// index returns the position of the specified event or -1
+func (r *testRecorder) index(event string) int {
+	r.Lock()
+	defer r.Unlock()
+	for i, e := range r.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// CheckPlan makes sure that dependencies between phases of the specified
+// plan can be satisfied, i.e. that there are no dependency cycles, and that
+// phase retry policies are valid
+func CheckPlan(plan storage.OperationPlan) error {
+	return trace.Wrap(checkPhaseGroup(plan.Phases, false))
+}
+
+func checkPhaseGroup(phases []storage.OperationPhase, parallel bool) error {
+	err := newPhaseGraph(phases, parallel).checkCycles()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, phase := range phases {
+		err := checkRetryPolicy(phase)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = checkPhaseGroup(phase.Phases, phase.Parallel)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// newPhaseGraph builds the dependency graph for the specified group of
+// sibling phases.
+//
+// A phase depends on its sibling if any phase in its subtree requires any
+// phase from the sibling's subtree. Phases are scheduled from these
+// requirements alone, so independent phases execute concurrently.
+//
+// Phases that do not declare a requirement on any of their siblings
+// carry no dependency information: unless the group is parallel, such a
+// phase is ordered after the phase preceding it in the group.
+func newPhaseGraph(phases []storage.OperationPhase, parallel bool) phaseGraph {
+	subtrees := make([]utils.StringSet, len(phases))
+	for i, phase := range phases {
+		subtrees[i] = utils.NewStringSet()
+		for _, p := range flattenPhase(&phase) {
+			subtrees[i].Add(p.ID)
+		}
+	}
+	graph := phaseGraph{
+		phases:   phases,
+		requires: make([]map[int]struct{}, len(phases)),
+	}
+	for i, phase := range phases {
+		graph.requires[i] = make(map[int]struct{})
+		for _, p := range flattenPhase(&phase) {
+			for _, required := range p.Requires {
+				for j := range phases {
+					// requirements within the subtree of the phase are
+					// handled when its subphases are scheduled
+					if j != i && subtrees[j].Has(required) {
+						graph.requires[i][j] = struct{}{}
+					}
+				}
+			}
+		}
+		if !parallel && i > 0 && len(graph.requires[i]) == 0 {
+			graph.requires[i][i-1] = struct{}{}
+		}
+	}
+	return graph
+}
+
+// phaseGraph describes dependencies between sibling phases
+type phaseGraph struct {
+	phases []storage.OperationPhase
+	// requires maps the index of a phase to the set of indexes
+	// of the phases it depends on
+	requires []map[int]struct{}
+}
+
+// dependents returns the reverse dependency mapping for the graph
+func (r phaseGraph) dependents() [][]int {
+	dependents := make([][]int, len(r.phases))
+	for i := range r.phases {
+		for j := range r.requires[i] {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	return dependents
+}
+
+// checkCycles returns an error if the graph contains a dependency cycle
+func (r phaseGraph) checkCycles() error {
+	_, err := r.order()
+	return trace.Wrap(err)
+}
+
+// order returns the indexes of the phases in the order they can be
+// executed in, or an error if the graph contains a dependency cycle
+func (r phaseGraph) order() ([]int, error) {
+	pending := make([]int, len(r.phases))
+	var ready []int
+	for i := range r.phases {
+		pending[i] = len(r.requires[i])
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	dependents := r.dependents()
+	order := make([]int, 0, len(r.phases))
+	for len(ready) != 0 {
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, i)
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(order) == len(r.phases) {
+		return order, nil
+	}
+	var cycle []string
+	for i, phase := range r.phases {
+		if pending[i] != 0 {
+			cycle = append(cycle, phase.ID)
+		}
+	}
+	return nil, trace.BadParameter("dependency cycle detected between phases %v", cycle)
+}
+
+// executePhaseGroup executes the specified group of sibling phases honoring
+// dependencies between them.
+//
+// Phases whose dependencies have been satisfied are executed concurrently.
+// After a phase has failed, no new phases are scheduled and the group
+// completes once all phases that are already running have finished.
+func (f *FSM) executePhaseGroup(ctx context.Context, p Params, phases []storage.OperationPhase, parallel bool) error {
+	graph := newPhaseGraph(phases, parallel)
+	if err := graph.checkCycles(); err != nil {
+		return trace.Wrap(err)
+	}
+	dependents := graph.dependents()
+	pending := make([]int, len(phases))
+	var ready []int
+	for i := range phases {
+		pending[i] = len(graph.requires[i])
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	type result struct {
+		index int
+		err   error
+	}
+	resultCh := make(chan result, len(phases))
+	var errors []error
+	running := 0
+	for {
+		for len(ready) != 0 && len(errors) == 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			go func(p Params, i int) {
+				p.PhaseID = phases[i].ID
+				resultCh <- result{index: i, err: f.ExecutePhase(ctx, p)}
+			}(p, i)
+		}
+		if running == 0 {
+			break
+		}
+		r := <-resultCh
+		running--
+		if r.err != nil {
+			f.Warnf("Failed to execute phase %q: %v.", phases[r.index].ID, trace.DebugReport(r.err))
+			errors = append(errors, trace.Wrap(r.err, "failed to execute phase %q", phases[r.index].ID))
+			continue
+		}
+		for _, j := range dependents[r.index] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(errors) == 1 {
+		return errors[0]
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// acquireSlot blocks until the number of phases executing concurrently
+// falls below the configured limit.
+// Returns a function to release the acquired slot
+func (f *FSM) acquireSlot(ctx context.Context) (release func(), err error) {
+	if f.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case f.slots <- struct{}{}:
+		return func() { <-f.slots }, nil
+	case <-ctx.Done():
+		return nil, trace.Wrap(ctx.Err())
+	}
+}
+
+// flattenPhase returns a slice of pointers to the specified phase
+// and all of its subphases
+func flattenPhase(phase *storage.OperationPhase) []*storage.OperationPhase {
+	var result []*storage.OperationPhase
+	addPhases(phase, &result)
+	return result
+}
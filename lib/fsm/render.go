@@ -0,0 +1,278 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// FormatOperationPlanDot renders the specified plan as a Graphviz digraph.
+//
+// Phases with subphases are rendered as clusters, leaf phases as nodes
+// colored according to their state. Solid edges connect required phases to
+// phases that require them, dotted edges denote the implicit ordering of
+// phases in sequential groups.
+func FormatOperationPlanDot(w io.Writer, plan storage.OperationPlan) error {
+	r := newPlanRenderer(plan)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %v {\n", dotQuote(plan.OperationID))
+	fmt.Fprintln(&buf, `  compound=true;`)
+	fmt.Fprintln(&buf, `  rankdir=TB;`)
+	fmt.Fprintln(&buf, `  node [shape=box, style="rounded,filled", fontname="Helvetica"];`)
+	fmt.Fprintln(&buf, `  graph [fontname="Helvetica"];`)
+	r.writeDotGroup(&buf, plan.Phases, 1)
+	for _, edge := range r.edges {
+		if !r.hasEdge(edge) {
+			continue
+		}
+		from, to := r.anchor(edge.from), r.anchor(edge.to)
+		var attrs []string
+		if r.isGroup(edge.from) {
+			attrs = append(attrs, fmt.Sprintf("ltail=%v", dotQuote(dotClusterID(edge.from))))
+		}
+		if r.isGroup(edge.to) {
+			attrs = append(attrs, fmt.Sprintf("lhead=%v", dotQuote(dotClusterID(edge.to))))
+		}
+		if edge.implicit {
+			attrs = append(attrs, "style=dotted")
+		}
+		fmt.Fprintf(&buf, "  %v -> %v", dotQuote(from), dotQuote(to))
+		if len(attrs) != 0 {
+			fmt.Fprintf(&buf, " [%v]", strings.Join(attrs, ", "))
+		}
+		fmt.Fprintln(&buf, ";")
+	}
+	fmt.Fprintln(&buf, "}")
+	_, err := buf.WriteTo(w)
+	return trace.Wrap(err)
+}
+
+// FormatOperationPlanMermaid renders the specified plan as a Mermaid flowchart.
+//
+// The layout follows FormatOperationPlanDot: phases with subphases are
+// rendered as subgraphs and leaf phases are styled according to their state
+func FormatOperationPlanMermaid(w io.Writer, plan storage.OperationPlan) error {
+	r := newPlanRenderer(plan)
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "flowchart TB")
+	r.writeMermaidGroup(&buf, plan.Phases, 1)
+	for _, edge := range r.edges {
+		if !r.hasEdge(edge) {
+			continue
+		}
+		arrow := "-->"
+		if edge.implicit {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&buf, "  %v %v %v\n", r.ids[edge.from], arrow, r.ids[edge.to])
+	}
+	for _, state := range phaseStates {
+		fmt.Fprintf(&buf, "  classDef %v fill:%v,stroke:#555\n", mermaidClass(state), stateColors[state])
+	}
+	for _, phase := range r.leaves {
+		fmt.Fprintf(&buf, "  class %v %v\n", r.ids[phase.ID], mermaidClass(phase.GetState()))
+	}
+	_, err := buf.WriteTo(w)
+	return trace.Wrap(err)
+}
+
+func newPlanRenderer(plan storage.OperationPlan) *planRenderer {
+	r := &planRenderer{
+		ids:    make(map[string]string),
+		groups: make(map[string]storage.OperationPhase),
+	}
+	r.collect(plan.Phases, false)
+	return r
+}
+
+// collect assigns identifiers to the phases and gathers the dependency edges
+func (r *planRenderer) collect(phases []storage.OperationPhase, parallel bool) {
+	for i, phase := range phases {
+		r.ids[phase.ID] = fmt.Sprintf("phase%v", len(r.ids))
+		if phase.HasSubphases() {
+			r.groups[phase.ID] = phase
+			r.collect(phase.Phases, phase.Parallel)
+		} else {
+			r.leaves = append(r.leaves, phase)
+		}
+		implicit := -1
+		if !parallel && i > 0 {
+			implicit = len(r.edges)
+			r.edges = append(r.edges, planEdge{from: phases[i-1].ID, to: phase.ID, implicit: true})
+		}
+		for _, required := range phase.Requires {
+			if implicit != -1 && r.edges[implicit].from == required {
+				// explicit requirement coincides with the order of the group
+				r.edges[implicit].implicit = false
+				continue
+			}
+			r.edges = append(r.edges, planEdge{from: required, to: phase.ID})
+		}
+	}
+}
+
+func (r *planRenderer) writeDotGroup(w io.Writer, phases []storage.OperationPhase, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	for _, phase := range phases {
+		if !phase.HasSubphases() {
+			fmt.Fprintf(w, "%v%v [label=%v, fillcolor=%v];\n", prefix, dotQuote(phase.ID),
+				dotQuote(phaseLabel(phase, "\n")), dotQuote(stateColors[phase.GetState()]))
+			continue
+		}
+		fmt.Fprintf(w, "%vsubgraph %v {\n", prefix, dotQuote(dotClusterID(phase.ID)))
+		fmt.Fprintf(w, "%v  label=%v;\n", prefix, dotQuote(groupLabel(phase)))
+		if phase.Parallel {
+			fmt.Fprintf(w, "%v  style=dashed;\n", prefix)
+		} else {
+			fmt.Fprintf(w, "%v  style=solid;\n", prefix)
+		}
+		r.writeDotGroup(w, phase.Phases, indent+1)
+		fmt.Fprintf(w, "%v}\n", prefix)
+	}
+}
+
+func (r *planRenderer) writeMermaidGroup(w io.Writer, phases []storage.OperationPhase, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	for _, phase := range phases {
+		if !phase.HasSubphases() {
+			fmt.Fprintf(w, "%v%v[\"%v\"]\n", prefix, r.ids[phase.ID],
+				mermaidEscape(phaseLabel(phase, "<br/>")))
+			continue
+		}
+		fmt.Fprintf(w, "%vsubgraph %v[\"%v\"]\n", prefix, r.ids[phase.ID],
+			mermaidEscape(groupLabel(phase)))
+		r.writeMermaidGroup(w, phase.Phases, indent+1)
+		fmt.Fprintf(w, "%vend\n", prefix)
+	}
+}
+
+// anchor returns the ID of the node to attach edges of the specified phase to.
+// Graphviz cannot connect clusters directly so edges of a phase with subphases
+// are attached to its first leaf phase and clipped at the cluster boundary
+func (r *planRenderer) anchor(phaseID string) string {
+	for {
+		group, ok := r.groups[phaseID]
+		if !ok {
+			return phaseID
+		}
+		phaseID = group.Phases[0].ID
+	}
+}
+
+// hasEdge returns true if both ends of the specified edge are phases of the plan
+func (r *planRenderer) hasEdge(edge planEdge) bool {
+	_, hasFrom := r.ids[edge.from]
+	_, hasTo := r.ids[edge.to]
+	return hasFrom && hasTo
+}
+
+func (r *planRenderer) isGroup(phaseID string) bool {
+	_, ok := r.groups[phaseID]
+	return ok
+}
+
+// planRenderer renders the phase tree of an operation plan as a graph
+type planRenderer struct {
+	// ids maps phase IDs to identifiers safe to use as graph node names
+	ids map[string]string
+	// groups maps IDs of phases with subphases to the phases
+	groups map[string]storage.OperationPhase
+	// leaves lists phases without subphases
+	leaves []storage.OperationPhase
+	// edges lists dependencies between phases
+	edges []planEdge
+}
+
+// planEdge describes a dependency between two phases
+type planEdge struct {
+	// from is the ID of the required phase
+	from string
+	// to is the ID of the phase that requires it
+	to string
+	// implicit is set if the dependency is implied by the order of
+	// phases in a sequential group
+	implicit bool
+}
+
+func phaseLabel(phase storage.OperationPhase, separator string) string {
+	lines := []string{formatName(phase.ID)}
+	if phase.Description != "" {
+		lines = append(lines, phase.Description)
+	}
+	if server := phaseExecServer(phase); server != nil {
+		lines = append(lines, fmt.Sprintf("node: %v", serverName(*server)))
+	}
+	lines = append(lines, formatPhaseState(phase))
+	return strings.Join(lines, separator)
+}
+
+func groupLabel(phase storage.OperationPhase) string {
+	label := phase.ID
+	if phase.Description != "" {
+		label = fmt.Sprintf("%v: %v", phase.ID, phase.Description)
+	}
+	if phase.Parallel {
+		label += " (parallel)"
+	}
+	return label
+}
+
+// dotQuote returns s as a quoted Graphviz string with line breaks
+// replaced by centered line escapes
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
+
+func dotClusterID(phaseID string) string {
+	return "cluster_" + phaseID
+}
+
+func mermaidClass(state string) string {
+	return strings.Replace(state, "_", "", -1)
+}
+
+func mermaidEscape(s string) string {
+	return strings.Replace(s, `"`, "#quot;", -1)
+}
+
+// phaseStates lists the states a phase can be in
+var phaseStates = []string{
+	storage.OperationPhaseStateUnstarted,
+	storage.OperationPhaseStateInProgress,
+	storage.OperationPhaseStateCompleted,
+	storage.OperationPhaseStateFailed,
+	storage.OperationPhaseStateRolledBack,
+}
+
+// stateColors maps phase states to the colors phases are rendered with
+var stateColors = map[string]string{
+	storage.OperationPhaseStateUnstarted:  "#ffffff",
+	storage.OperationPhaseStateInProgress: "#fff3b0",
+	storage.OperationPhaseStateCompleted:  "#c8e6c9",
+	storage.OperationPhaseStateFailed:     "#ffcdd2",
+	storage.OperationPhaseStateRolledBack: "#e0e0e0",
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"bytes"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+type RenderSuite struct{}
+
+var _ = check.Suite(&RenderSuite{})
+
+func (s *RenderSuite) TestRendersDot(c *check.C) {
+	var buf bytes.Buffer
+	c.Assert(FormatOperationPlanDot(&buf, testRenderPlan()), check.IsNil)
+	c.Assert(buf.String(), check.Equals, `digraph "operation-1" {
+  compound=true;
+  rankdir=TB;
+  node [shape=box, style="rounded,filled", fontname="Helvetica"];
+  graph [fontname="Helvetica"];
+  "/init" [label="init\nInitialize \"update\"\nCompleted", fillcolor="#c8e6c9"];
+  subgraph "cluster_/masters" {
+    label="/masters: Update masters (parallel)";
+    style=dashed;
+    "/masters/node-1" [label="node-1\nnode: node-1/10.0.0.1\nFailed", fillcolor="#ffcdd2"];
+    "/masters/node-2" [label="node-2\nnode: node-2/10.0.0.2\nUnstarted", fillcolor="#ffffff"];
+  }
+  "/init" -> "/masters/node-1" [lhead="cluster_/masters"];
+}
+`)
+}
+
+func (s *RenderSuite) TestRendersMermaid(c *check.C) {
+	var buf bytes.Buffer
+	c.Assert(FormatOperationPlanMermaid(&buf, testRenderPlan()), check.IsNil)
+	c.Assert(buf.String(), check.Equals, `flowchart TB
+  phase0["init<br/>Initialize #quot;update#quot;<br/>Completed"]
+  subgraph phase1["/masters: Update masters (parallel)"]
+    phase2["node-1<br/>node: node-1/10.0.0.1<br/>Failed"]
+    phase3["node-2<br/>node: node-2/10.0.0.2<br/>Unstarted"]
+  end
+  phase0 --> phase1
+  classDef unstarted fill:#ffffff,stroke:#555
+  classDef inprogress fill:#fff3b0,stroke:#555
+  classDef completed fill:#c8e6c9,stroke:#555
+  classDef failed fill:#ffcdd2,stroke:#555
+  classDef rolledback fill:#e0e0e0,stroke:#555
+  class phase0 completed
+  class phase2 failed
+  class phase3 unstarted
+`)
+}
+
+func testRenderPlan() storage.OperationPlan {
+	return storage.OperationPlan{
+		OperationID: "operation-1",
+		Phases: []storage.OperationPhase{
+			{
+				ID:          "/init",
+				Description: `Initialize "update"`,
+				State:       storage.OperationPhaseStateCompleted,
+			},
+			{
+				ID:          "/masters",
+				Description: "Update masters",
+				Requires:    []string{"/init"},
+				Parallel:    true,
+				Phases: []storage.OperationPhase{
+					{
+						ID:    "/masters/node-1",
+						State: storage.OperationPhaseStateFailed,
+						Data: &storage.OperationPhaseData{
+							Server: &storage.Server{Hostname: "node-1", AdvertiseIP: "10.0.0.1"},
+						},
+					},
+					{
+						ID: "/masters/node-2",
+						Data: &storage.OperationPhaseData{
+							Server: &storage.Server{Hostname: "node-2", AdvertiseIP: "10.0.0.2"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// checkRetryPolicy validates the timeout and retry settings of the specified phase
+func checkRetryPolicy(phase storage.OperationPhase) error {
+	if phase.Timeout < 0 {
+		return trace.BadParameter("phase %v has negative timeout %v", phase.ID, phase.Timeout)
+	}
+	if phase.Retries < 0 {
+		return trace.BadParameter("phase %v has negative number of retries %v", phase.ID, phase.Retries)
+	}
+	if phase.RetryBackoff < 0 {
+		return trace.BadParameter("phase %v has negative retry backoff %v", phase.ID, phase.RetryBackoff)
+	}
+	if len(phase.Phases) != 0 && (phase.Timeout != 0 || phase.Retries != 0) {
+		return trace.BadParameter("retry policy can only be set on phases without subphases, "+
+			"but phase %v has %v subphases", phase.ID, len(phase.Phases))
+	}
+	return nil
+}
+
+// attemptFunc implements a single attempt to execute or rollback a phase.
+// ctx is limited by the phase timeout, attempt is the number of the attempt
+type attemptFunc func(ctx context.Context, attempt int) error
+
+// withRetries invokes fn according to the retry policy of the specified phase.
+//
+// Attempts are numbered starting after the last attempt recorded for the phase
+// so that attempts made by previous runs of the operation are accounted for
+// in the changelog, while the number of retries applies to each run.
+// Errors marked with permanent are not retried
+func withRetries(ctx context.Context, phase storage.OperationPhase, logger logrus.FieldLogger, fn attemptFunc) error {
+	backoff := phase.RetryBackoff
+	first := phase.Attempt + 1
+	for attempt := first; ; attempt++ {
+		err := runAttempt(ctx, phase, attempt, fn)
+		if err == nil {
+			return nil
+		}
+		if perm, ok := trace.Unwrap(err).(*permanentError); ok {
+			return trace.Wrap(perm.err)
+		}
+		if attempt-first >= phase.Retries || ctx.Err() != nil {
+			return trace.Wrap(err)
+		}
+		logger.WithError(err).Warnf("Attempt %v of phase %v failed, will retry in %v (%v retries left).",
+			attempt, phase.ID, backoff, phase.Retries-(attempt-first))
+		if backoff > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return trace.Wrap(err)
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// withRemoteRetries invokes fn to execute or rollback the phase on a remote node
+// according to the retry policy of the phase and returns the number of the last
+// attempt. The number of each attempt is passed to the remote node with params
+// so that the attempts are numbered consistently on both nodes
+func withRemoteRetries(ctx context.Context, p Params, phase storage.OperationPhase, logger logrus.FieldLogger, fn func(context.Context, Params) error) (attempt int, err error) {
+	err = withRetries(ctx, retryPolicy(p, phase), logger, func(ctx context.Context, n int) error {
+		attempt = n
+		p.Attempt = n
+		return fn(ctx, p)
+	})
+	return attempt, trace.Wrap(err)
+}
+
+// retryPolicy returns the phase with the retry policy to apply.
+// A phase dispatched by another node is attempted once with the number
+// of the attempt assigned by that node
+func retryPolicy(p Params, phase storage.OperationPhase) storage.OperationPhase {
+	if p.Attempt > 0 {
+		phase.Attempt = p.Attempt - 1
+		phase.Retries = 0
+	}
+	return phase
+}
+
+func runAttempt(ctx context.Context, phase storage.OperationPhase, attempt int, fn attemptFunc) error {
+	if phase.Timeout == 0 {
+		return fn(ctx, attempt)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, phase.Timeout)
+	defer cancel()
+	err := fn(attemptCtx, attempt)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return trace.LimitExceeded("phase %v attempt %v timed out after %v: %v",
+			phase.ID, attempt, phase.Timeout, err)
+	}
+	return err
+}
+
+// permanent marks the specified error as not subject to retries
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+// Error returns the message of the underlying error
+func (r *permanentError) Error() string {
+	return r.err.Error()
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// RollbackPlan rolls back all phases of the plan that have been started
+// in the reverse order of their dependencies.
+//
+// Phases that are bound to other nodes are rolled back on those nodes
+// using the configured remote runner. Rollback stops at the first phase
+// that fails to roll back so it can be resumed after the issue has been fixed
+func (f *FSM) RollbackPlan(ctx context.Context, progress utils.Progress, force bool) error {
+	plan, err := f.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	phases, err := RollbackOrder(*plan)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(phases) == 0 {
+		f.Info("No phases to roll back.")
+		return nil
+	}
+	if progress == nil {
+		progress = utils.NewNopProgress()
+	}
+	for _, phase := range phases {
+		p := Params{
+			PhaseID:  phase.ID,
+			Force:    force,
+			Progress: progress,
+			Rollback: true,
+		}
+		if err := f.runHook(ctx, f.preRollbackFn, p); err != nil {
+			return trace.Wrap(err)
+		}
+		err = f.rollbackPhaseOnServer(ctx, p, phase)
+		if err != nil {
+			return trace.Wrap(err, "failed to roll back phase %v", phase.ID)
+		}
+	}
+	return nil
+}
+
+// RollbackOrder returns the leaf phases of the specified plan that need to be
+// rolled back, in the order they should be rolled back in
+func RollbackOrder(plan storage.OperationPlan) ([]storage.OperationPhase, error) {
+	phases, err := executionOrder(plan.Phases, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var result []storage.OperationPhase
+	for i := len(phases) - 1; i >= 0; i-- {
+		if phases[i].IsUnstarted() || phases[i].IsRolledBack() {
+			continue
+		}
+		result = append(result, phases[i])
+	}
+	return result, nil
+}
+
+// executionOrder returns the leaf phases from the specified group of sibling
+// phases in an order that satisfies the dependencies between them
+func executionOrder(phases []storage.OperationPhase, parallel bool) ([]storage.OperationPhase, error) {
+	order, err := newPhaseGraph(phases, parallel).order()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var result []storage.OperationPhase
+	for _, i := range order {
+		if !phases[i].HasSubphases() {
+			result = append(result, phases[i])
+			continue
+		}
+		subphases, err := executionOrder(phases[i].Phases, phases[i].Parallel)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		result = append(result, subphases...)
+	}
+	return result, nil
+}
+
+// rollbackPhaseOnServer rolls back the specified leaf phase either locally
+// or on the node the phase is bound to
+func (f *FSM) rollbackPhaseOnServer(ctx context.Context, p Params, phase storage.OperationPhase) error {
+	execServer := phaseExecServer(phase)
+	execWhere := CanRunLocally
+	if execServer != nil {
+		var err error
+		execWhere, err = canExecuteOnServer(ctx, *execServer, f.Runner, f.FieldLogger)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	switch execWhere {
+	case CanRunLocally:
+		p.Progress.NextStep("Rolling back %q locally", phase.ID)
+		return trace.Wrap(f.rollbackPhase(ctx, p, phase))
+	case CanRunRemotely:
+		p.Progress.NextStep("Rolling back %q on remote node %v", phase.ID, execServer.Hostname)
+		attempt, err := withRemoteRetries(ctx, p, phase, f.FieldLogger, func(ctx context.Context, p Params) error {
+			return f.RunCommand(ctx, f.Runner, *execServer, p)
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// Record the state locally as the remote node might not be able
+		// to synchronize it back
+		return trace.Wrap(f.ChangePhaseState(ctx, StateChange{
+			Phase:   phase.ID,
+			State:   storage.OperationPhaseStateRolledBack,
+			Attempt: attempt,
+		}))
+	case ShouldRunRemotely:
+		return trace.NotFound("no agent is running on node %v, please roll back phase %q locally on that node",
+			serverName(*execServer), phase.ID)
+	default:
+		return trace.BadParameter("unsupported execution location: %v", execWhere)
+	}
+}
+
+// ProgressMessage returns the operation progress message for the specified
+// phase being executed or rolled back according to params
+func ProgressMessage(phase storage.OperationPhase, p Params) string {
+	if p.Rollback {
+		return fmt.Sprintf("Rolling back: %v", phase.Description)
+	}
+	return phase.Description
+}
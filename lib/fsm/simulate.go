@@ -0,0 +1,253 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fsm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// PhaseDescriber is an optional interface a PhaseExecutor can implement
+// to describe the actions it would perform without actually performing them.
+//
+// It is used by the FSM in simulation (dry-run) mode.
+type PhaseDescriber interface {
+	// Describe returns the list of actions the phase would perform
+	Describe(context.Context) ([]Action, error)
+}
+
+// ActionKind defines the kind of the action performed by a phase
+type ActionKind string
+
+const (
+	// ActionCommand is a command executed on a node
+	ActionCommand ActionKind = "command"
+	// ActionPackage is an operation on a package
+	ActionPackage ActionKind = "package"
+	// ActionFile is an operation on a file or a directory
+	ActionFile ActionKind = "file"
+	// ActionKubernetes is an operation on a Kubernetes object
+	ActionKubernetes ActionKind = "kubernetes"
+	// ActionPhase is the execution of a phase whose executor
+	// does not describe its individual actions
+	ActionPhase ActionKind = "phase"
+)
+
+// Action describes a single action performed by a phase executor
+type Action struct {
+	// Kind specifies the kind of the action
+	Kind ActionKind `json:"kind"`
+	// Description is a human-readable description of the action
+	Description string `json:"description"`
+	// Target identifies the subject of the action: command line,
+	// package locator, file path or Kubernetes object reference
+	Target string `json:"target,omitempty"`
+}
+
+// CommandAction returns an action that runs the command specified with args
+func CommandAction(description string, args ...string) Action {
+	return Action{
+		Kind:        ActionCommand,
+		Description: description,
+		Target:      strings.Join(args, " "),
+	}
+}
+
+// PackageAction returns an action that operates on the specified package
+func PackageAction(description string, locator loc.Locator) Action {
+	return Action{
+		Kind:        ActionPackage,
+		Description: description,
+		Target:      locator.String(),
+	}
+}
+
+// FileAction returns an action that operates on the specified path
+func FileAction(description, path string) Action {
+	return Action{
+		Kind:        ActionFile,
+		Description: description,
+		Target:      path,
+	}
+}
+
+// PhaseAction returns the action that executes the specified phase.
+// It describes the phases whose executors do not implement PhaseDescriber
+func PhaseAction(phase storage.OperationPhase) Action {
+	description := phase.Description
+	if description == "" {
+		description = fmt.Sprintf("Execute phase %v", phase.ID)
+	}
+	return Action{
+		Kind:        ActionPhase,
+		Description: description,
+		Target:      phase.Executor,
+	}
+}
+
+// KubernetesAction returns an action that operates on the specified
+// Kubernetes object given as kind/name
+func KubernetesAction(description, object string) Action {
+	return Action{
+		Kind:        ActionKubernetes,
+		Description: description,
+		Target:      object,
+	}
+}
+
+// Simulation is the result of a simulated (dry-run) plan execution
+type Simulation struct {
+	// OperationID is the ID of the simulated operation
+	OperationID string `json:"operation_id"`
+	// OperationType is the type of the simulated operation
+	OperationType string `json:"operation_type"`
+	// Phases lists descriptions of all simulated phases in execution order
+	Phases []PhaseSimulation `json:"phases"`
+}
+
+// PhaseSimulation describes what a single phase would do if executed
+type PhaseSimulation struct {
+	// PhaseID is the ID of the phase
+	PhaseID string `json:"phase_id"`
+	// Description is the phase description
+	Description string `json:"description,omitempty"`
+	// Executor is the name of the phase executor
+	Executor string `json:"executor"`
+	// Node is the address of the node the phase would run on
+	Node string `json:"node,omitempty"`
+	// Skipped is set if the phase would not be executed
+	Skipped bool `json:"skipped,omitempty"`
+	// Reason explains why the phase would be skipped
+	Reason string `json:"reason,omitempty"`
+	// Described is set if the executor provided the list of actions.
+	// Otherwise, the phase is described with a single PhaseAction
+	Described bool `json:"described"`
+	// Actions is the list of actions the phase would perform
+	Actions []Action `json:"actions,omitempty"`
+	// Error is set if the phase could not be described
+	Error string `json:"error,omitempty"`
+}
+
+// SimulatePlan describes all phases of the plan the way ExecutePlan would
+// execute them without making any changes
+func (f *FSM) SimulatePlan(ctx context.Context, force bool) (*Simulation, error) {
+	return f.SimulatePhase(ctx, Params{
+		PhaseID: RootPhase,
+		Force:   force,
+	})
+}
+
+// SimulatePhase describes the specified phase (and all its subphases) the
+// way ExecutePhase would execute it without making any changes.
+//
+// Phase executors are queried for their actions if they implement
+// PhaseDescriber, phase state is left intact and pre/post execution hooks
+// are not invoked.
+func (f *FSM) SimulatePhase(ctx context.Context, p Params) (*Simulation, error) {
+	err := p.CheckAndSetDefaults()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	plan, err := f.GetPlan()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sim := &Simulation{
+		OperationID:   plan.OperationID,
+		OperationType: plan.OperationType,
+	}
+	if p.PhaseID == RootPhase {
+		for _, phase := range plan.Phases {
+			f.simulatePhase(ctx, *plan, p, phase, sim)
+		}
+		return sim, nil
+	}
+	phase, err := FindPhase(plan, p.PhaseID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = f.prerequisitesComplete(phase.ID)
+	if err != nil && !p.Force {
+		return nil, trace.Wrap(err)
+	}
+	f.simulatePhase(ctx, *plan, p, *phase, sim)
+	return sim, nil
+}
+
+func (f *FSM) simulatePhase(ctx context.Context, plan storage.OperationPlan, p Params, phase storage.OperationPhase, sim *Simulation) {
+	result := PhaseSimulation{
+		PhaseID:     phase.ID,
+		Description: phase.Description,
+		Executor:    phase.Executor,
+	}
+	if phase.IsCompleted() && !p.Force {
+		result.Skipped = true
+		result.Reason = "phase is already completed"
+		sim.Phases = append(sim.Phases, result)
+		return
+	}
+	if phase.HasSubphases() {
+		for _, subphase := range phase.Phases {
+			f.simulatePhase(ctx, plan, p, subphase, sim)
+		}
+		return
+	}
+	if server := phaseExecServer(phase); server != nil {
+		result.Node = serverName(*server)
+	}
+	executor, err := f.GetExecutor(ExecutorParams{
+		Plan:     plan,
+		Phase:    phase,
+		Progress: p.Progress,
+	}, f)
+	if err != nil {
+		result.Error = trace.UserMessage(err)
+		sim.Phases = append(sim.Phases, result)
+		return
+	}
+	describer, ok := executor.(PhaseDescriber)
+	if !ok {
+		result.Actions = []Action{PhaseAction(phase)}
+		sim.Phases = append(sim.Phases, result)
+		return
+	}
+	actions, err := describer.Describe(ctx)
+	if err != nil {
+		result.Error = trace.UserMessage(err)
+	}
+	result.Described = err == nil
+	result.Actions = actions
+	sim.Phases = append(sim.Phases, result)
+}
+
+// phaseExecServer returns the server the specified phase is supposed
+// to be executed on or nil, if the phase can be executed anywhere
+func phaseExecServer(phase storage.OperationPhase) *storage.Server {
+	if phase.Data == nil {
+		return nil
+	}
+	if phase.Data.ExecServer != nil {
+		return phase.Data.ExecServer
+	}
+	return phase.Data.Server
+}
@@ -99,6 +99,19 @@ func FlattenPlan(plan *storage.OperationPlan) []*storage.OperationPhase {
 	return result
 }
 
+// copyPhases returns a copy of the specified phases including their subphases
+func copyPhases(phases []storage.OperationPhase) []storage.OperationPhase {
+	if phases == nil {
+		return nil
+	}
+	result := make([]storage.OperationPhase, len(phases))
+	for i, phase := range phases {
+		result[i] = phase
+		result[i].Phases = copyPhases(phase.Phases)
+	}
+	return result
+}
+
 // SplitServers splits the specified server list into servers with master cluster role
 // and regular nodes.
 func SplitServers(servers []storage.Server) (masters, nodes []storage.Server) {
@@ -115,13 +128,27 @@ func SplitServers(servers []storage.Server) (masters, nodes []storage.Server) {
 
 // ResolvePlan applies changelog to the provided plan and returns the resulting plan
 func ResolvePlan(plan storage.OperationPlan, changelog storage.PlanChangelog) *storage.OperationPlan {
+	// Phases are copied so that the provided plan is left intact and
+	// can be read concurrently while the resulting plan is being resolved
+	plan.Phases = copyPhases(plan.Phases)
 	allPhases := FlattenPlan(&plan)
 	for i, phase := range allPhases {
 		latest := changelog.Latest(phase.ID)
-		if latest != nil {
-			allPhases[i].State = latest.NewState
-			allPhases[i].Updated = latest.Created
-			allPhases[i].Error = latest.Error
+		if latest == nil {
+			continue
+		}
+		allPhases[i].State = latest.NewState
+		allPhases[i].Updated = latest.Created
+		allPhases[i].Error = latest.Error
+		allPhases[i].Attempt = latest.Attempt
+		allPhases[i].FailedAttempts = changelog.FailedAttempts(phase.ID)
+		started := changelog.LatestWithState(phase.ID, storage.OperationPhaseStateInProgress)
+		if started == nil {
+			continue
+		}
+		allPhases[i].Started = started.Created
+		if latest.NewState != storage.OperationPhaseStateInProgress {
+			allPhases[i].Finished = latest.Created
 		}
 	}
 	return &plan
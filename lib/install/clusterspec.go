@@ -0,0 +1,281 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/checks"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// ClusterSpec describes the cluster installed with 'gravity install --cluster-spec':
+// all of its nodes along with the flavor, network and DNS settings
+type ClusterSpec struct {
+	// Kind is the resource kind, "ClusterSpec"
+	Kind string `json:"kind"`
+	// Version is the resource version, "v1"
+	Version string `json:"version"`
+	// Metadata is the resource metadata, the name is the cluster name
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the cluster spec
+	Spec ClusterSpecSpec `json:"spec"`
+}
+
+// ClusterSpecSpec defines the cluster to install
+type ClusterSpecSpec struct {
+	// Flavor is the name of the install flavor
+	Flavor string `json:"flavor,omitempty"`
+	// PodCIDR is the pod network subnet
+	PodCIDR string `json:"pod_cidr,omitempty"`
+	// ServiceCIDR is the service network subnet
+	ServiceCIDR string `json:"service_cidr,omitempty"`
+	// DNS is the cluster DNS configuration
+	DNS *storage.DNSConfig `json:"dns,omitempty"`
+	// Nodes lists all nodes of the cluster
+	Nodes []storage.NodeSpec `json:"nodes"`
+}
+
+// ReadClusterSpec reads the cluster spec from the file at the specified path
+func ReadClusterSpec(path string) (*ClusterSpec, error) {
+	data, err := utils.ReadPath(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	spec, err := ParseClusterSpec(data)
+	if err != nil {
+		return nil, trace.Wrap(err, "invalid cluster spec %v", path)
+	}
+	return spec, nil
+}
+
+// ParseClusterSpec parses the cluster spec in YAML or JSON format
+func ParseClusterSpec(data []byte) (*ClusterSpec, error) {
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var header teleservices.ResourceHeader
+	if err := json.Unmarshal(jsonData, &header); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if header.Kind != KindClusterSpec {
+		return nil, trace.BadParameter("expected %v, got %q", KindClusterSpec, header.Kind)
+	}
+	if header.Version != teleservices.V1 {
+		return nil, trace.BadParameter("%v version %q is not supported", KindClusterSpec, header.Version)
+	}
+	var spec ClusterSpec
+	err = teleutils.UnmarshalWithSchema(getClusterSpecSchema(), &spec, jsonData)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := spec.check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &spec, nil
+}
+
+// check validates the cluster spec independently of the application manifest
+func (s ClusterSpec) check() error {
+	if len(s.Spec.Nodes) == 0 {
+		return trace.BadParameter("cluster spec must list at least one node")
+	}
+	addrs := make(map[string]bool, len(s.Spec.Nodes))
+	for _, node := range s.Spec.Nodes {
+		if net.ParseIP(node.AdvertiseAddr) == nil {
+			return trace.BadParameter("invalid advertise address %q of %v, expected IP address",
+				node.AdvertiseAddr, node)
+		}
+		if addrs[node.AdvertiseAddr] {
+			return trace.BadParameter("advertise address %v is listed more than once",
+				node.AdvertiseAddr)
+		}
+		addrs[node.AdvertiseAddr] = true
+	}
+	if s.Spec.PodCIDR != "" || s.Spec.ServiceCIDR != "" {
+		return trace.Wrap(utils.ValidateKubernetesSubnets(s.Spec.PodCIDR, s.Spec.ServiceCIDR))
+	}
+	return nil
+}
+
+// Check validates the cluster spec against the application manifest:
+// the nodes must use the profiles and mount the volumes defined in
+// the manifest and match the node counts of the flavor
+func (s ClusterSpec) Check(manifest schema.Manifest) (*schema.Flavor, error) {
+	flavorName := s.Spec.Flavor
+	if flavorName == "" && manifest.Installer != nil {
+		flavorName = manifest.Installer.Flavors.Default
+		if flavorName == "" && len(manifest.Installer.Flavors.Items) != 0 {
+			flavorName = manifest.Installer.Flavors.Items[0].Name
+		}
+	}
+	flavor := manifest.FindFlavor(flavorName)
+	if flavor == nil {
+		return nil, trace.NotFound("install flavor %q is not found", flavorName)
+	}
+	var errors []error
+	counts := make(map[string]int)
+	for _, node := range s.Spec.Nodes {
+		profile, err := manifest.NodeProfiles.ByName(node.Profile)
+		if err != nil {
+			errors = append(errors, trace.NotFound("%v: profile %q is not defined in the manifest",
+				node.AdvertiseAddr, node.Profile))
+			continue
+		}
+		counts[node.Profile]++
+		for name := range node.Mounts {
+			if !hasVolume(*profile, name) {
+				errors = append(errors, trace.BadParameter("%v: profile %q has no volume %q",
+					node.AdvertiseAddr, node.Profile, name))
+			}
+		}
+	}
+	for _, node := range flavor.Nodes {
+		if counts[node.Profile] != node.Count {
+			errors = append(errors, trace.BadParameter("flavor %q requires %v %q node(s), cluster spec lists %v",
+				flavor.Name, node.Count, node.Profile, counts[node.Profile]))
+		}
+		delete(counts, node.Profile)
+	}
+	for profile, count := range counts {
+		errors = append(errors, trace.BadParameter("flavor %q has no %q nodes, cluster spec lists %v",
+			flavor.Name, profile, count))
+	}
+	if len(errors) != 0 {
+		return nil, trace.NewAggregate(errors...)
+	}
+	return flavor, nil
+}
+
+// FindNode returns the node with the specified advertise address
+func (s ClusterSpec) FindNode(addr string) (*storage.NodeSpec, error) {
+	node := checks.FindNodeSpec(s.Spec.Nodes, addr)
+	if node == nil {
+		return nil, trace.NotFound("node %v is not in the cluster spec", addr)
+	}
+	return node, nil
+}
+
+// FindLocalNode returns the node with the advertise address
+// assigned to one of the local network interfaces
+func (s ClusterSpec) FindLocalNode() (*storage.NodeSpec, error) {
+	networks, err := utils.LocalIPNetworks()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, network := range networks {
+		if node, err := s.FindNode(network.IP.String()); err == nil {
+			return node, nil
+		}
+	}
+	return nil, trace.NotFound("none of the cluster spec nodes is assigned to this host, " +
+		"please set the advertise address via --advertise-addr flag")
+}
+
+// JoinCommands returns the join commands for the specified nodes
+func (s ClusterSpec) JoinCommands(nodes []storage.NodeSpec, peerAddr, token string) string {
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		fmt.Fprintf(&buf, "%v: gravity join %v --token=%v --advertise-addr=%v --role=%v",
+			node.AdvertiseAddr, peerAddr, token, node.AdvertiseAddr, node.Profile)
+		if node.DockerDevice != "" {
+			fmt.Fprintf(&buf, " --docker-device=%v", node.DockerDevice)
+		}
+		if node.SystemDevice != "" {
+			fmt.Fprintf(&buf, " --system-device=%v", node.SystemDevice)
+		}
+		var mounts []string
+		for name, path := range node.Mounts {
+			mounts = append(mounts, fmt.Sprintf("%v:%v", name, path))
+		}
+		if len(mounts) != 0 {
+			sort.Strings(mounts)
+			fmt.Fprintf(&buf, " --mount=%v", strings.Join(mounts, ","))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func hasVolume(profile schema.NodeProfile, name string) bool {
+	for _, volume := range profile.Requirements.Volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func getClusterSpecSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, storage.MetadataSchema,
+		clusterSpecSchema, "")
+}
+
+// clusterSpecSchema defines the cluster spec schema
+const clusterSpecSchema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "required": ["nodes"],
+  "properties": {
+    "flavor": {"type": "string"},
+    "pod_cidr": {"type": "string"},
+    "service_cidr": {"type": "string"},
+    "dns": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "addrs": {"type": "array", "items": {"type": "string"}},
+        "port": {"type": "integer"}
+      }
+    },
+    "nodes": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["advertise_addr", "profile"],
+        "properties": {
+          "advertise_addr": {"type": "string"},
+          "profile": {"type": "string"},
+          "docker_device": {"type": "string"},
+          "system_device": {"type": "string"},
+          "mounts": {
+            "type": "object",
+            "patternProperties": {
+              "^.*$": {"type": "string"}
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// KindClusterSpec is the kind of the cluster spec
+const KindClusterSpec = "ClusterSpec"
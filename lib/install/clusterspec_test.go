@@ -0,0 +1,131 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"gopkg.in/check.v1"
+)
+
+type ClusterSpecSuite struct {
+	manifest schema.Manifest
+}
+
+var _ = check.Suite(&ClusterSpecSuite{})
+
+func (s *ClusterSpecSuite) SetUpTest(c *check.C) {
+	s.manifest = schema.Manifest{
+		Installer: &schema.Installer{
+			Flavors: schema.Flavors{
+				Default: "three",
+				Items: []schema.Flavor{
+					{Name: "one", Nodes: []schema.FlavorNode{{Profile: "master", Count: 1}}},
+					{Name: "three", Nodes: []schema.FlavorNode{
+						{Profile: "master", Count: 1},
+						{Profile: "node", Count: 2},
+					}},
+				},
+			},
+		},
+		NodeProfiles: schema.NodeProfiles{
+			{Name: "master"},
+			{Name: "node", Requirements: schema.Requirements{
+				Volumes: []schema.Volume{{Name: "data", Path: "/var/data"}},
+			}},
+		},
+	}
+}
+
+func (s *ClusterSpecSuite) TestParsesClusterSpec(c *check.C) {
+	spec, err := ParseClusterSpec([]byte(`kind: ClusterSpec
+version: v1
+metadata:
+  name: example.com
+spec:
+  pod_cidr: 10.244.0.0/16
+  service_cidr: 10.100.0.0/16
+  nodes:
+  - advertise_addr: 10.0.0.1
+    profile: master
+    docker_device: /dev/sdb
+  - advertise_addr: 10.0.0.2
+    profile: node
+    mounts:
+      data: /mnt/data
+  - advertise_addr: 10.0.0.3
+    profile: node`))
+	c.Assert(err, check.IsNil)
+	c.Assert(spec.Metadata.Name, check.Equals, "example.com")
+	c.Assert(spec.Spec.Nodes, check.DeepEquals, []storage.NodeSpec{
+		{AdvertiseAddr: "10.0.0.1", Profile: "master", DockerDevice: "/dev/sdb"},
+		{AdvertiseAddr: "10.0.0.2", Profile: "node", Mounts: map[string]string{"data": "/mnt/data"}},
+		{AdvertiseAddr: "10.0.0.3", Profile: "node"},
+	})
+
+	flavor, err := spec.Check(s.manifest)
+	c.Assert(err, check.IsNil)
+	c.Assert(flavor.Name, check.Equals, "three")
+
+	c.Assert(spec.JoinCommands(spec.Spec.Nodes[1:2], "10.0.0.1:61009", "token"), check.Equals,
+		"10.0.0.2: gravity join 10.0.0.1:61009 --token=token --advertise-addr=10.0.0.2 --role=node --mount=data:/mnt/data\n")
+}
+
+func (s *ClusterSpecSuite) TestRejectsInvalidClusterSpec(c *check.C) {
+	_, err := ParseClusterSpec([]byte(`kind: ClusterSpec
+version: v1
+metadata:
+  name: example.com
+spec:
+  nodes:
+  - advertise_addr: 10.0.0.1
+    profile: master
+  - advertise_addr: 10.0.0.1
+    profile: node`))
+	c.Assert(err, check.ErrorMatches, ".*listed more than once.*")
+
+	_, err = ParseClusterSpec([]byte(`kind: ClusterSpec
+version: v1
+metadata:
+  name: example.com
+spec:
+  nodes:
+  - advertise_addr: node-1
+    profile: master`))
+	c.Assert(err, check.ErrorMatches, ".*expected IP address.*")
+}
+
+func (s *ClusterSpecSuite) TestChecksClusterSpecAgainstManifest(c *check.C) {
+	spec := ClusterSpec{Spec: ClusterSpecSpec{
+		Flavor: "one",
+		Nodes: []storage.NodeSpec{
+			{AdvertiseAddr: "10.0.0.1", Profile: "master", Mounts: map[string]string{"data": "/data"}},
+			{AdvertiseAddr: "10.0.0.2", Profile: "db"},
+		},
+	}}
+	_, err := spec.Check(s.manifest)
+	c.Assert(err, check.NotNil)
+	c.Assert(err.Error(), check.Matches, `(?s).*profile "master" has no volume "data".*`)
+	c.Assert(err.Error(), check.Matches, `(?s).*profile "db" is not defined in the manifest.*`)
+
+	spec.Spec.Flavor = "three"
+	spec.Spec.Nodes = spec.Spec.Nodes[:1]
+	spec.Spec.Nodes[0].Mounts = nil
+	_, err = spec.Check(s.manifest)
+	c.Assert(err, check.ErrorMatches, `.*flavor "three" requires 2 "node" node\(s\), cluster spec lists 0.*`)
+}
@@ -132,7 +132,11 @@ func (i *Installer) StartCLIInstall() (err error) {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	i.flavor, err = i.getFlavor()
+	if i.ClusterSpec != nil {
+		i.flavor, err = i.ClusterSpec.Check(i.Cluster.App.Manifest)
+	} else {
+		i.flavor, err = i.getFlavor()
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -168,6 +172,7 @@ func (i *Installer) StartCLIInstall() (err error) {
 				PodCIDR:     i.PodCIDR,
 				ServiceCIDR: i.ServiceCIDR,
 				VxlanPort:   i.VxlanPort,
+				Nodes:       i.getClusterNodes(),
 			},
 		},
 		Profiles: ServerRequirements(*i.flavor),
@@ -385,7 +390,8 @@ func (i *Installer) formatProfiles(profiles map[string]int) string {
 
 // canContinue returns true if the installation can commence based on the
 // provided agent report and false if not all agents have joined yet.
-func (i *Installer) canContinue(report *ops.AgentReport) bool {
+// Returns an error if the joined agents do not match the cluster spec
+func (i *Installer) canContinue(report *ops.AgentReport) (bool, error) {
 	// See if any new nodes have joined or left since previous agent report.
 	joined, left := report.Diff(i.agentReport)
 	for _, server := range joined {
@@ -398,16 +404,20 @@ func (i *Installer) canContinue(report *ops.AgentReport) bool {
 	}
 	// Save the current agent report so we can compare against it on next iteration.
 	i.agentReport = report
+	if i.ClusterSpec != nil {
+		return i.matchClusterSpec(report, len(joined) != 0 || len(left) != 0)
+	}
 	// See if the current agent report satisfies the selected flavor.
 	needed, extra := report.MatchFlavor(i.flavor)
 	if len(needed) == 0 && len(extra) == 0 {
 		i.sendMessage(color.GreenString("All agents have connected!"))
-		return true
+		i.checkMetrics(report)
+		return true, nil
 	}
 	// If there were no changes compared to previous report, do not
 	// output anything.
 	if len(joined) == 0 && len(left) == 0 {
-		return false
+		return false, nil
 	}
 	// Dump the table with remaining nodes that need to join.
 	i.sendMessage(fmt.Sprintf("Please execute the following join commands on target nodes:\n%v",
@@ -419,7 +429,51 @@ func (i *Installer) canContinue(report *ops.AgentReport) bool {
 			server.Role, utils.ExtractHost(server.AdvertiseAddr)))
 	}
 	// We can't proceed yet.
-	return false
+	return false, nil
+}
+
+// matchClusterSpec returns true if the agents from the provided report
+// match the cluster spec and false if not all nodes have joined yet.
+// Returns an error if unexpected agents have joined or the joined agents
+// are configured differently from the cluster spec
+func (i *Installer) matchClusterSpec(report *ops.AgentReport, changed bool) (bool, error) {
+	diff := checks.DiffNodeSpecs(i.ClusterSpec.Spec.Nodes, report.Servers)
+	if diff.IsEmpty() {
+		i.sendMessage("%v", color.GreenString("All nodes from the cluster spec have connected!"))
+		i.checkMetrics(report)
+		return true, nil
+	}
+	if len(diff.Unexpected) != 0 || len(diff.Mismatched) != 0 {
+		return false, trace.BadParameter("joined nodes do not match the cluster spec:\n%v", diff)
+	}
+	if changed {
+		i.sendMessage("Please execute the following join commands on target nodes:\n%v",
+			i.ClusterSpec.JoinCommands(diff.Missing, i.AdvertiseAddr, i.Token.Token))
+	}
+	return false, nil
+}
+
+// getClusterNodes returns the nodes from the cluster spec or nil
+// if the cluster is not installed from a cluster spec
+func (i *Installer) getClusterNodes() []storage.NodeSpec {
+	if i.ClusterSpec == nil {
+		return nil
+	}
+	return i.ClusterSpec.Spec.Nodes
+}
+
+// checkMetrics warns about nodes from the specified agent report
+// that are short on resources
+func (i *Installer) checkMetrics(report *ops.AgentReport) {
+	for _, server := range report.Servers {
+		if server.Metrics == nil {
+			continue
+		}
+		for _, warning := range checks.MetricsWarnings(*server.Metrics, server.GetNumCPU()) {
+			i.sendMessage("%v", color.YellowString("Node %q on %v: %v",
+				server.GetHostname(), utils.ExtractHost(server.AdvertiseAddr), warning))
+		}
+	}
 }
 
 func (i *Installer) waitForAgents() error {
@@ -437,14 +491,18 @@ func (i *Installer) waitForAgents() error {
 			return trace.Wrap(i.Context.Err())
 		case tm := <-ticker.C:
 			if tm.IsZero() {
-				return trace.ConnectionProblem(nil, "timed out waiting for agents to join")
+				return i.agentsTimeoutError()
 			}
 			report, err := i.Operator.GetSiteInstallOperationAgentReport(i.OperationKey)
 			if err != nil {
 				log.Warningf("Failed to get agent report: %v.", err)
 				continue
 			}
-			if !i.canContinue(report) {
+			ok, err := i.canContinue(report)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if !ok {
 				continue
 			}
 			log.Infof("Installation can proceed! %v", report)
@@ -457,6 +515,16 @@ func (i *Installer) waitForAgents() error {
 	}
 }
 
+// agentsTimeoutError returns the error for when not all agents have joined
+// in time, with the nodes missing from the cluster spec if there is one
+func (i *Installer) agentsTimeoutError() error {
+	if i.ClusterSpec == nil || i.agentReport == nil {
+		return trace.ConnectionProblem(nil, "timed out waiting for agents to join")
+	}
+	diff := checks.DiffNodeSpecs(i.ClusterSpec.Spec.Nodes, i.agentReport.Servers)
+	return trace.ConnectionProblem(nil, "timed out waiting for nodes from the cluster spec to join:\n%v", diff)
+}
+
 // UpdateOperationState updates the operation data according to the agent report
 func (i *Installer) UpdateOperationState() error {
 	report, err := i.Operator.GetSiteInstallOperationAgentReport(i.OperationKey)
@@ -82,6 +82,8 @@ type FSMConfig struct {
 	ReportProgress bool
 	// DNSConfig specifies the DNS configuration to use
 	DNSConfig storage.DNSConfig
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
 }
 
 // Check validates install FSM config and sets some defaults
@@ -148,10 +150,11 @@ func NewFSM(config FSMConfig) (*fsm.FSM, error) {
 	}
 	runner := fsm.NewAgentRunner(config.Credentials)
 	fsm, err := fsm.New(fsm.Config{
-		Engine:   engine,
-		Runner:   runner,
-		Insecure: config.Insecure,
-		Logger:   logger,
+		Engine:      engine,
+		Runner:      runner,
+		Insecure:    config.Insecure,
+		Logger:      logger,
+		MaxParallel: config.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -161,6 +164,7 @@ func NewFSM(config FSMConfig) (*fsm.FSM, error) {
 	// a phase executes
 	if config.ReportProgress {
 		fsm.SetPreExec(engine.UpdateProgress)
+		fsm.SetPreRollback(engine.UpdateProgress)
 	}
 	return fsm, nil
 }
@@ -181,7 +185,7 @@ func (f *fsmEngine) UpdateProgress(ctx context.Context, p fsm.Params) error {
 		Completion:  100 / utils.Max(len(plan.Phases), 1) * phase.Step,
 		Step:        phase.Step,
 		State:       ops.ProgressStateInProgress,
-		Message:     phase.Description,
+		Message:     fsm.ProgressMessage(*phase, p),
 		Created:     time.Now().UTC(),
 	}
 	err = f.Operator.CreateProgressEntry(f.OperationKey, entry)
@@ -221,6 +225,7 @@ func (f *fsmEngine) ChangePhaseState(ctx context.Context, change fsm.StateChange
 			PhaseID:     change.Phase,
 			NewState:    change.State,
 			Error:       utils.ToRawTrace(change.Error),
+			Attempt:     change.Attempt,
 			Created:     time.Now().UTC(),
 		})
 	if err != nil {
@@ -250,6 +255,10 @@ func (f *fsmEngine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.Ph
 // using the provided runner
 func (f *fsmEngine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, p fsm.Params) error {
 	args := []string{"install", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force)}
+	if p.Rollback {
+		args = []string{"plan", "rollback", "--phase", p.PhaseID, fmt.Sprintf("--force=%v", p.Force),
+			fmt.Sprintf("--attempt=%v", p.Attempt)}
+	}
 	if f.RemoteOpsURL != "" && f.RemoteOpsToken != "" {
 		args = append(args,
 			fmt.Sprintf("--ops-url=%v", f.RemoteOpsURL),
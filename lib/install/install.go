@@ -178,6 +178,9 @@ type Config struct {
 	DockerDevice string
 	// Mounts is a list of mount points (name -> source pairs)
 	Mounts map[string]string
+	// ClusterSpec optionally describes all nodes of the cluster.
+	// If set, the installer waits for exactly these nodes to join
+	ClusterSpec *ClusterSpec
 	// DNSOverrides contains installer node DNS overrides
 	DNSOverrides storage.DNSOverrides
 	// Mode is the installation mode (wizard or CLI or via Ops Center)
@@ -391,7 +391,7 @@ func (s *PlanSuite) verifyNodesPhase(c *check.C, phase storage.OperationPhase) {
 				Requires: []string{fmt.Sprintf("%v/%v", phases.PullPhase, s.regularNode.Hostname)},
 			},
 		},
-		Requires: []string{phases.PullPhase},
+		Requires: []string{phases.PullPhase, phases.MastersPhase},
 		Parallel: true,
 	}, phase)
 }
@@ -412,7 +412,8 @@ func (s *PlanSuite) verifyHealthPhase(c *check.C, phase storage.OperationPhase)
 		Data: &storage.OperationPhaseData{
 			Server: &s.masterNode,
 		},
-		Requires: []string{phases.InstallOverlayPhase, phases.ExportPhase},
+		Requires: []string{phases.CorednsPhase, phases.ResourcesPhase,
+			phases.InstallOverlayPhase, phases.ExportPhase},
 	}, phase)
 }
 
@@ -446,7 +447,7 @@ func (s *PlanSuite) verifyCorednsPhase(c *check.C, phase storage.OperationPhase)
 		Data: &storage.OperationPhaseData{
 			Server: &s.masterNode,
 		},
-		Requires: []string{phases.WaitPhase},
+		Requires: []string{phases.WaitPhase, phases.RBACPhase},
 	}, phase)
 }
 
@@ -483,7 +484,7 @@ func (s *PlanSuite) verifyResourcesPhase(c *check.C, phase storage.OperationPhas
 			Server:  &s.masterNode,
 			Install: &storage.InstallOperationData{},
 		},
-		Requires: []string{phases.RBACPhase},
+		Requires: []string{phases.RBACPhase, phases.CorednsPhase},
 	}, phase)
 	validateResources(c, obtained, expected)
 }
@@ -546,7 +547,7 @@ func (s *PlanSuite) verifyRuntimePhase(c *check.C, phase storage.OperationPhase)
 				Requires: []string{phases.RBACPhase},
 			},
 		},
-		Requires: []string{phases.RBACPhase},
+		Requires: []string{phases.RBACPhase, phases.HealthPhase},
 	}, phase)
 }
 
@@ -631,7 +632,7 @@ func (s *PlanSuite) verifyEnableElectionPhase(c *check.C, phase storage.Operatio
 		Data: &storage.OperationPhaseData{
 			Server: &s.masterNode,
 		},
-		Requires: []string{phases.AppPhase},
+		Requires: []string{phases.AppPhase, phases.ConnectInstallerPhase},
 	}, phase)
 }
 
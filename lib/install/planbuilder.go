@@ -276,7 +276,7 @@ func (b *PlanBuilder) AddNodesPhase(plan *storage.OperationPlan) error {
 		ID:          phases.NodesPhase,
 		Description: "Install system software on regular nodes",
 		Phases:      nodePhases,
-		Requires:    []string{phases.PullPhase},
+		Requires:    []string{phases.PullPhase, phases.MastersPhase},
 		Parallel:    true,
 		Step:        4,
 	})
@@ -301,7 +301,8 @@ func (b *PlanBuilder) AddHealthPhase(plan *storage.OperationPlan) {
 	plan.Phases = append(plan.Phases, storage.OperationPhase{
 		ID:          phases.HealthPhase,
 		Description: "Wait for cluster to pass health checks",
-		Requires:    fsm.RequireIfPresent(plan, phases.InstallOverlayPhase, phases.ExportPhase),
+		Requires: fsm.RequireIfPresent(plan, phases.CorednsPhase, phases.ResourcesPhase,
+			phases.InstallOverlayPhase, phases.ExportPhase),
 		Data: &storage.OperationPhaseData{
 			Server: &b.Master,
 		},
@@ -338,7 +339,7 @@ func (b *PlanBuilder) AddResourcesPhase(plan *storage.OperationPlan) {
 				Resources: b.resources,
 			},
 		},
-		Requires: []string{phases.RBACPhase},
+		Requires: []string{phases.RBACPhase, phases.CorednsPhase},
 		Step:     4,
 	})
 }
@@ -386,7 +387,7 @@ func (b *PlanBuilder) AddCorednsPhase(plan *storage.OperationPlan) {
 		Data: &storage.OperationPhaseData{
 			Server: &b.Master,
 		},
-		Requires: []string{phases.WaitPhase},
+		Requires: []string{phases.WaitPhase, phases.RBACPhase},
 		Step:     4,
 	})
 }
@@ -446,7 +447,7 @@ func (b *PlanBuilder) AddRuntimePhase(plan *storage.OperationPlan) error {
 		ID:          phases.RuntimePhase,
 		Description: "Install system applications",
 		Phases:      runtimePhases,
-		Requires:    []string{phases.RBACPhase},
+		Requires:    []string{phases.RBACPhase, phases.HealthPhase},
 		Step:        5,
 	})
 	return nil
@@ -507,7 +508,7 @@ func (b *PlanBuilder) AddEnableElectionPhase(plan *storage.OperationPlan) {
 	plan.Phases = append(plan.Phases, storage.OperationPhase{
 		ID:          phases.EnableElectionPhase,
 		Description: "Enable cluster leader elections",
-		Requires:    []string{phases.AppPhase},
+		Requires:    fsm.RequireIfPresent(plan, phases.AppPhase, phases.ConnectInstallerPhase),
 		Data: &storage.OperationPhaseData{
 			Server: &b.Master,
 		},
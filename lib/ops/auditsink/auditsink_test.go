@@ -0,0 +1,268 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/jonboulle/clockwork"
+	"gopkg.in/check.v1"
+)
+
+func TestAuditSink(t *testing.T) { check.TestingT(t) }
+
+type AuditSinkSuite struct {
+	dir string
+}
+
+var _ = check.Suite(&AuditSinkSuite{})
+
+func (s *AuditSinkSuite) SetUpTest(c *check.C) {
+	s.dir = c.MkDir()
+}
+
+func (s *AuditSinkSuite) TestFileRotation(c *check.C) {
+	path := filepath.Join(s.dir, "audit", "events.log")
+	sink, err := newFileSink(storage.AuditSinkFile{
+		Path:       path,
+		MaxBackups: 2,
+	})
+	c.Assert(err, check.IsNil)
+	// rotate after every event
+	sink.maxSize = 1
+	defer sink.Close()
+
+	for i := 0; i < 4; i++ {
+		err := sink.Send(context.TODO(), events.EventFields{"index": i})
+		c.Assert(err, check.IsNil)
+	}
+
+	for path, index := range map[string]int{
+		path:                3,
+		backupPath(path, 1): 2,
+		backupPath(path, 2): 1,
+	} {
+		data, err := ioutil.ReadFile(path)
+		c.Assert(err, check.IsNil)
+		var event events.EventFields
+		c.Assert(json.Unmarshal(data, &event), check.IsNil)
+		c.Assert(event.GetInt("index"), check.Equals, index, check.Commentf(path))
+	}
+	_, err = os.Stat(backupPath(path, 3))
+	c.Assert(os.IsNotExist(err), check.Equals, true)
+}
+
+func (s *AuditSinkSuite) TestSyslogMessage(c *check.C) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, check.IsNil)
+	defer listener.Close()
+	messagesC := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			size, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			message := make([]byte, size)
+			if _, err := reader.Read(message); err != nil {
+				return
+			}
+			messagesC <- string(message)
+		}
+	}()
+
+	sink, err := newSyslogSink(storage.AuditSinkSyslog{
+		Address:  listener.Addr().String(),
+		Protocol: storage.AuditSinkProtocolTCP,
+	})
+	c.Assert(err, check.IsNil)
+	sink.hostname = "node-1"
+	defer sink.Close()
+
+	timestamp := time.Date(2019, time.March, 1, 10, 0, 0, 0, time.UTC)
+	err = sink.Send(context.TODO(), events.EventFields{
+		events.EventType: "operation.install.failure",
+		events.EventCode: "G0001E",
+		events.EventTime: timestamp,
+	})
+	c.Assert(err, check.IsNil)
+
+	select {
+	case message := <-messagesC:
+		prefix := "<107>1 2019-03-01T10:00:00Z node-1 gravity - operation.install.failure - "
+		c.Assert(strings.HasPrefix(message, prefix), check.Equals, true, check.Commentf(message))
+		var event events.EventFields
+		c.Assert(json.Unmarshal([]byte(strings.TrimPrefix(message, prefix)), &event), check.IsNil)
+		c.Assert(event.GetCode(), check.Equals, "G0001E")
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for syslog message")
+	}
+}
+
+func (s *AuditSinkSuite) TestForwardsToWebhookWithRetries(c *check.C) {
+	var attempts int
+	eventsC := make(chan events.EventFields, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		c.Assert(r.Header.Get("Authorization"), check.Equals, "Bearer secret")
+		var event events.EventFields
+		c.Assert(json.NewDecoder(r.Body).Decode(&event), check.IsNil)
+		eventsC <- event
+	}))
+	defer server.Close()
+
+	backend, err := keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(s.dir, "bolt.db")})
+	c.Assert(err, check.IsNil)
+	defer backend.Close()
+	_, err = backend.CreateAccount(storage.Account{ID: "account", Org: "example.com"})
+	c.Assert(err, check.IsNil)
+	for _, cluster := range []storage.Site{
+		{Domain: "example.com", Local: true},
+		{Domain: "remote.example.com"},
+	} {
+		cluster.AccountID = "account"
+		cluster.Created = time.Now()
+		_, err = backend.CreateSite(cluster)
+		c.Assert(err, check.IsNil)
+	}
+	err = backend.UpsertAuditSink("example.com", storage.NewAuditSink("hook", storage.AuditSinkSpecV2{
+		Webhook: &storage.AuditSinkWebhook{
+			URL:     server.URL,
+			Headers: map[string]string{"Authorization": "Bearer secret"},
+			CACert: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: server.Certificate().Raw,
+			})),
+		},
+	}))
+	c.Assert(err, check.IsNil)
+
+	forwarder, err := NewForwarder(Config{
+		Backend: backend,
+		Clock:   clockwork.NewFakeClock(),
+	})
+	c.Assert(err, check.IsNil)
+	defer forwarder.Close()
+	c.Assert(forwarder.Reload(), check.IsNil)
+
+	// events of other clusters are not forwarded to the sinks of the cluster
+	forwarder.Emit(events.Event{Name: "user.logout", Code: "T1001I"},
+		events.EventFields{events.EventUser: "bob@example.com", "cluster": "remote.example.com"})
+	// events without the cluster name are attributed to the local cluster
+	forwarder.Emit(events.Event{Name: "user.login", Code: "T1000I"},
+		events.EventFields{events.EventUser: "alice@example.com"})
+
+	select {
+	case event := <-eventsC:
+		c.Assert(event.GetType(), check.Equals, "user.login")
+		c.Assert(event.GetCode(), check.Equals, "T1000I")
+		c.Assert(event.GetString(events.EventUser), check.Equals, "alice@example.com")
+		c.Assert(attempts, check.Equals, 2)
+	case <-time.After(10 * time.Second):
+		c.Fatal("timeout waiting for webhook delivery")
+	}
+
+	// removing the sink stops the forwarding
+	c.Assert(backend.DeleteAuditSink("example.com", "hook"), check.IsNil)
+	c.Assert(forwarder.Reload(), check.IsNil)
+	c.Assert(forwarder.queues, check.HasLen, 0)
+}
+
+func (s *AuditSinkSuite) TestCountsDroppedEvents(c *check.C) {
+	backend, err := keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(s.dir, "bolt.db")})
+	c.Assert(err, check.IsNil)
+	defer backend.Close()
+	_, err = backend.CreateAccount(storage.Account{ID: "account", Org: "example.com"})
+	c.Assert(err, check.IsNil)
+	_, err = backend.CreateSite(storage.Site{
+		AccountID: "account",
+		Domain:    "example.com",
+		Local:     true,
+		Created:   time.Now(),
+	})
+	c.Assert(err, check.IsNil)
+	err = backend.UpsertAuditSink("example.com", storage.NewAuditSink("blocked", storage.AuditSinkSpecV2{
+		File: &storage.AuditSinkFile{Path: filepath.Join(s.dir, "events.log")},
+	}))
+	c.Assert(err, check.IsNil)
+
+	forwarder, err := NewForwarder(Config{
+		Backend:   backend,
+		Clock:     clockwork.NewFakeClock(),
+		QueueSize: 1,
+		NewSink: func(storage.AuditSink) (Sink, error) {
+			return blockingSink{}, nil
+		},
+	})
+	c.Assert(err, check.IsNil)
+	defer forwarder.Close()
+	c.Assert(forwarder.Reload(), check.IsNil)
+
+	for i := 0; i < 3; i++ {
+		forwarder.Emit(events.Event{Name: "user.login", Code: "T1000I"}, events.EventFields{})
+	}
+	forwarder.mu.Lock()
+	defer forwarder.mu.Unlock()
+	c.Assert(forwarder.queues, check.HasLen, 1)
+	for _, q := range forwarder.queues {
+		// the sink might have picked up the first event before blocking
+		c.Assert(q.dropped >= 1, check.Equals, true, check.Commentf("dropped %v", q.dropped))
+	}
+}
+
+// blockingSink blocks delivery until the context is done
+type blockingSink struct{}
+
+func (blockingSink) Send(ctx context.Context, _ events.EventFields) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (blockingSink) Close() error {
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+func newFileSink(config storage.AuditSinkFile) (*fileSink, error) {
+	return &fileSink{
+		config:  config,
+		maxSize: int64(config.MaxSizeMB) * 1024 * 1024,
+	}, nil
+}
+
+// fileSink writes audit events to a file as JSON lines.
+// The file is rotated once it exceeds the configured size:
+// the current file is renamed to <path>.1, the previous <path>.1
+// to <path>.2 and so on, keeping up to the configured number of backups
+type fileSink struct {
+	config  storage.AuditSinkFile
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Send appends the specified event to the file
+func (s *fileSink) Send(ctx context.Context, event events.EventFields) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return trace.BadParameter("failed to format audit event: %v", err)
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return trace.ConvertSystemError(err)
+}
+
+func (s *fileSink) open() error {
+	err := os.MkdirAll(filepath.Dir(s.config.Path), defaults.PrivateDirMask)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	file, err := os.OpenFile(s.config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, defaults.PrivateFileMask)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return trace.ConvertSystemError(err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	s.file = nil
+	for i := s.config.MaxBackups - 1; i > 0; i-- {
+		err := os.Rename(backupPath(s.config.Path, i), backupPath(s.config.Path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	err := os.Rename(s.config.Path, backupPath(s.config.Path, 1))
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%v.%v", path, index)
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	opsevents "github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	"github.com/gravitational/teleport/lib/events"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+)
+
+// Backend provides access to the clusters and their audit sinks
+type Backend interface {
+	// GetAllSites returns all clusters
+	GetAllSites() ([]storage.Site, error)
+	// GetAuditSinks returns the audit sinks of the specified cluster
+	GetAuditSinks(clusterName string) ([]storage.AuditSink, error)
+}
+
+// Config defines the audit events forwarder configuration
+type Config struct {
+	// Backend provides access to the audit sink resources
+	Backend Backend
+	// FieldLogger is used for logging
+	logrus.FieldLogger
+	// Clock is used to timestamp the events
+	Clock clockwork.Clock
+	// NewSink creates a sink for the audit sink resource
+	NewSink func(storage.AuditSink) (Sink, error)
+	// QueueSize is the maximum number of events queued per sink
+	QueueSize int
+}
+
+// CheckAndSetDefaults validates the configuration and sets defaults
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Backend == nil {
+		return trace.BadParameter("missing Backend")
+	}
+	if c.FieldLogger == nil {
+		c.FieldLogger = logrus.WithField(trace.Component, "auditsink")
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	if c.NewSink == nil {
+		c.NewSink = New
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = defaults.AuditSinkQueueSize
+	}
+	return nil
+}
+
+// NewForwarder returns a new forwarder of audit events to the audit sinks
+// configured in the backend
+func NewForwarder(config Config) (*Forwarder, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Forwarder{
+		Config: config,
+		ctx:    ctx,
+		cancel: cancel,
+		uid:    teleutils.NewRealUID(),
+		queues: make(map[string]*queue),
+	}, nil
+}
+
+// Forwarder fans out audit events to the configured audit sinks.
+// Each sink has a dedicated queue so a slow or unavailable sink
+// does not delay delivery to the others. Failed deliveries are
+// retried with exponential backoff
+type Forwarder struct {
+	// Config is the forwarder configuration
+	Config
+	ctx    context.Context
+	cancel context.CancelFunc
+	uid    teleutils.UID
+
+	mu sync.Mutex
+	// queues maps the cluster name and the name of the sink to its queue
+	queues map[string]*queue
+	// localCluster is the name of the cluster the forwarder is running in
+	localCluster string
+}
+
+// Start loads the audit sink configuration and periodically reloads
+// it in the background until the forwarder is closed.
+// Reloading picks up the changes made through other cluster controllers
+func (f *Forwarder) Start() {
+	if err := f.Reload(); err != nil {
+		f.WithError(err).Warn("Failed to load audit sinks.")
+	}
+	go func() {
+		ticker := time.NewTicker(defaults.AuditSinkReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := f.Reload(); err != nil {
+					f.WithError(err).Warn("Failed to reload audit sinks.")
+				}
+			case <-f.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Reload synchronizes the active sinks with the configuration in the backend
+func (f *Forwarder) Reload() error {
+	clusters, err := f.Backend.GetAllSites()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var localCluster string
+	type clusterSink struct {
+		cluster string
+		config  storage.AuditSink
+	}
+	var configs []clusterSink
+	for _, cluster := range clusters {
+		if cluster.Local {
+			localCluster = cluster.Domain
+		}
+		sinks, err := f.Backend.GetAuditSinks(cluster.Domain)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, sink := range sinks {
+			configs = append(configs, clusterSink{cluster: cluster.Domain, config: sink})
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.localCluster = localCluster
+	active := make(map[string]struct{}, len(configs))
+	var errors []error
+	for _, item := range configs {
+		key := path.Join(item.cluster, item.config.GetName())
+		active[key] = struct{}{}
+		spec, err := storage.MarshalAuditSink(item.config)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		if q, ok := f.queues[key]; ok {
+			if bytes.Equal(q.spec, spec) {
+				continue
+			}
+			q.stop()
+			delete(f.queues, key)
+		}
+		sink, err := f.NewSink(item.config)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to create audit sink %q of cluster %v",
+				item.config.GetName(), item.cluster))
+			continue
+		}
+		f.queues[key] = f.startQueue(item.cluster, item.config.GetName(), spec, sink)
+		f.WithField("sink", key).Info("Started audit sink.")
+	}
+	for key, q := range f.queues {
+		if _, ok := active[key]; !ok {
+			q.stop()
+			delete(f.queues, key)
+			f.WithField("sink", key).Info("Stopped audit sink.")
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// Emit queues the specified event for delivery to the active sinks of the
+// cluster that generated the event. Events without the cluster name are
+// attributed to the local cluster.
+// Never blocks: if the queue of a sink is full, the event is dropped
+// for that sink
+func (f *Forwarder) Emit(event events.Event, fields events.EventFields) {
+	fields = copyFields(fields)
+	if err := events.UpdateEventFields(event, fields, f.Clock, f.uid); err != nil {
+		f.WithError(err).Warn("Failed to update audit event fields.")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cluster := fields.GetString(opsevents.FieldCluster)
+	if cluster == "" {
+		cluster = f.localCluster
+	}
+	for _, q := range f.queues {
+		if q.cluster != cluster {
+			continue
+		}
+		select {
+		case q.events <- fields:
+		default:
+			q.dropped++
+			q.Warnf("Audit sink queue is full, dropping event %v (%v events dropped so far).",
+				event.Name, q.dropped)
+		}
+	}
+}
+
+// Close stops all sinks
+func (f *Forwarder) Close() error {
+	f.cancel()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for name, q := range f.queues {
+		q.stop()
+		delete(f.queues, name)
+	}
+	return nil
+}
+
+func (f *Forwarder) startQueue(cluster, name string, spec []byte, sink Sink) *queue {
+	ctx, cancel := context.WithCancel(f.ctx)
+	q := &queue{
+		FieldLogger: f.WithFields(logrus.Fields{"sink": name, "cluster": cluster}),
+		cluster:     cluster,
+		spec:        spec,
+		sink:        sink,
+		events:      make(chan events.EventFields, f.QueueSize),
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	go q.run(ctx)
+	return q
+}
+
+// queue delivers events to a single sink in order
+type queue struct {
+	logrus.FieldLogger
+	// cluster is the name of the cluster the sink is configured for
+	cluster string
+	// spec is the serialized sink configuration used to detect changes
+	spec   []byte
+	sink   Sink
+	events chan events.EventFields
+	cancel context.CancelFunc
+	done   chan struct{}
+	// dropped is the number of events dropped because the queue was full.
+	// Guarded by the forwarder's mutex
+	dropped uint64
+}
+
+func (q *queue) run(ctx context.Context) {
+	defer close(q.done)
+	defer q.sink.Close()
+	for {
+		select {
+		case event := <-q.events:
+			if err := q.deliver(ctx, event); err != nil && ctx.Err() == nil {
+				q.WithError(err).Warnf("Failed to deliver audit event %v.",
+					event.GetString(events.EventType))
+			}
+		case <-ctx.Done():
+			if len(q.events) != 0 {
+				q.Warnf("Dropped %v undelivered audit events.", len(q.events))
+			}
+			return
+		}
+	}
+}
+
+func (q *queue) deliver(ctx context.Context, event events.EventFields) error {
+	interval := utils.NewUnlimitedExponentialBackOff()
+	interval.(*backoff.ExponentialBackOff).MaxInterval = defaults.AuditSinkMaxRetryInterval
+	return utils.RetryWithInterval(ctx, interval, func() error {
+		ctx, cancel := context.WithTimeout(ctx, defaults.AuditSinkTimeout)
+		defer cancel()
+		err := q.sink.Send(ctx, event)
+		if err != nil && trace.IsBadParameter(err) {
+			return &backoff.PermanentError{Err: err}
+		}
+		return trace.Wrap(err)
+	})
+}
+
+func (q *queue) stop() {
+	q.cancel()
+	<-q.done
+}
+
+func copyFields(fields events.EventFields) events.EventFields {
+	result := make(events.EventFields, len(fields))
+	for k, v := range fields {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package auditsink implements forwarding of the cluster audit events
+// to external systems configured with the auditsink resources
+package auditsink
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+// Sink delivers audit events to an external system
+type Sink interface {
+	// Send delivers the specified event.
+	// The error is considered transient and the delivery is retried
+	// unless it is a trace.BadParameter error
+	Send(ctx context.Context, event events.EventFields) error
+	// Close releases the resources of the sink
+	Close() error
+}
+
+// New returns a new sink for the specified audit sink resource
+func New(config storage.AuditSink) (Sink, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch {
+	case config.GetSyslog() != nil:
+		return newSyslogSink(*config.GetSyslog())
+	case config.GetFile() != nil:
+		return newFileSink(*config.GetFile())
+	case config.GetWebhook() != nil:
+		return newWebhookSink(*config.GetWebhook())
+	}
+	return nil, trace.BadParameter("audit sink %q has no configuration", config.GetName())
+}
+
+// tlsConfig returns the client TLS configuration that trusts the specified
+// certificate authority in addition to the system ones
+func tlsConfig(caCert string) (*tls.Config, error) {
+	if caCert == "" {
+		return &tls.Config{}, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(caCert)) {
+		return nil, trace.BadParameter("failed to parse the certificate authority")
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditsink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+func newSyslogSink(config storage.AuditSinkSyslog) (*syslogSink, error) {
+	sink := &syslogSink{config: config}
+	if config.Protocol == storage.AuditSinkProtocolTLS {
+		tlsConfig, err := tlsConfig(config.CACert)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		tlsConfig.ServerName = host
+		sink.tlsConfig = tlsConfig
+	}
+	sink.hostname, _ = os.Hostname()
+	return sink, nil
+}
+
+// syslogSink sends audit events to a syslog server over TCP or TLS
+// as RFC5424 messages with octet-counting framing (RFC6587)
+type syslogSink struct {
+	config    storage.AuditSinkSyslog
+	tlsConfig *tls.Config
+	hostname  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Send delivers the specified event to the syslog server.
+// The connection is re-established on failure
+func (s *syslogSink) Send(ctx context.Context, event events.EventFields) error {
+	message, err := formatSyslogMessage(s.hostname, event)
+	if err != nil {
+		return trace.BadParameter("failed to format audit event: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if s.conn, err = s.dial(ctx); err != nil {
+			return trace.ConnectionProblem(err, "failed to connect to syslog server %v", s.config.Address)
+		}
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaults.AuditSinkTimeout)
+	}
+	s.conn.SetWriteDeadline(deadline)
+	_, err = fmt.Fprintf(s.conn, "%d %s", len(message), message)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return trace.ConnectionProblem(err, "failed to write to syslog server %v", s.config.Address)
+	}
+	return nil
+}
+
+// Close closes the connection to the syslog server
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return trace.Wrap(err)
+}
+
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: defaults.AuditSinkTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Address)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if s.tlsConfig == nil {
+		return conn, nil
+	}
+	tlsConn := tls.Client(conn, s.tlsConfig)
+	tlsConn.SetDeadline(time.Now().Add(defaults.AuditSinkTimeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, trace.Wrap(err)
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
+
+// formatSyslogMessage formats the event as an RFC5424 message:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+//
+// The message body is the JSON-encoded event
+func formatSyslogMessage(hostname string, event events.EventFields) (string, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	timestamp := event.GetTimestamp()
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	priority := syslogFacilityAudit*8 + syslogSeverity(event.GetString(events.EventCode))
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		priority,
+		timestamp.UTC().Format(time.RFC3339Nano),
+		syslogHeaderValue(hostname, 255),
+		syslogAppName,
+		syslogHeaderValue(event.GetString(events.EventType), 32),
+		body), nil
+}
+
+// syslogSeverity returns the severity of the event based on the
+// suffix of its code
+func syslogSeverity(code string) int {
+	switch {
+	case strings.HasSuffix(code, "E"):
+		return syslogSeverityError
+	case strings.HasSuffix(code, "W"):
+		return syslogSeverityWarning
+	}
+	return syslogSeverityNotice
+}
+
+// syslogHeaderValue returns the value suitable for a header field
+// of the specified maximum length. Empty values are replaced with the nil value
+func syslogHeaderValue(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		return value[:maxLen]
+	}
+	return value
+}
+
+const (
+	// syslogAppName is the application name of the audit messages
+	syslogAppName = "gravity"
+	// syslogFacilityAudit is the "log audit" syslog facility
+	syslogFacilityAudit   = 13
+	syslogSeverityError   = 3
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+)
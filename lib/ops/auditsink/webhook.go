@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+func newWebhookSink(config storage.AuditSinkWebhook) (*webhookSink, error) {
+	tlsConfig, err := tlsConfig(config.CACert)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &webhookSink{
+		config: config,
+		client: &http.Client{
+			Timeout: defaults.AuditSinkTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}, nil
+}
+
+// webhookSink posts audit events to an HTTPS endpoint as JSON
+type webhookSink struct {
+	config storage.AuditSinkWebhook
+	client *http.Client
+}
+
+// Send posts the specified event to the webhook.
+// Server errors and rate limiting are retried, other
+// client errors are treated as permanent
+func (s *webhookSink) Send(ctx context.Context, event events.EventFields) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return trace.BadParameter("failed to format audit event: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return trace.BadParameter("invalid webhook request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.config.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return trace.ConnectionProblem(err, "failed to post audit event to %v", s.config.URL)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return trace.ConnectionProblem(nil, "webhook %v replied with %v", s.config.URL, resp.Status)
+	}
+	return trace.BadParameter("webhook %v rejected the audit event with %v", s.config.URL, resp.Status)
+}
+
+// Close releases the idle connections to the webhook
+func (s *webhookSink) Close() error {
+	if transport, ok := s.client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+	return nil
+}
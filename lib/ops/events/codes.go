@@ -179,6 +179,16 @@ var (
 		Name: LogForwarderDeletedEvent,
 		Code: LogForwarderDeletedCode,
 	}
+	// AuditSinkCreated is emitted when an audit sink is created/updated.
+	AuditSinkCreated = events.Event{
+		Name: AuditSinkCreatedEvent,
+		Code: AuditSinkCreatedCode,
+	}
+	// AuditSinkDeleted is emitted when an audit sink is deleted.
+	AuditSinkDeleted = events.Event{
+		Name: AuditSinkDeletedEvent,
+		Code: AuditSinkDeletedCode,
+	}
 	// TLSKeyPairCreated is emitted when cluster web certificate is updated.
 	TLSKeyPairCreated = events.Event{
 		Name: TLSKeyPairCreatedEvent,
@@ -368,6 +378,10 @@ const (
 	AuthGatewayUpdatedCode = "G1009I"
 	// UserInviteCreatedCode is the user invite created event code.
 	UserInviteCreatedCode = "G1010I"
+	// AuditSinkCreatedCode is the audit sink created event code.
+	AuditSinkCreatedCode = "G1011I"
+	// AuditSinkDeletedCode is the audit sink deleted event code.
+	AuditSinkDeletedCode = "G2011I"
 	// ClusterUnhealthyCode is the cluster goes unhealthy event code.
 	ClusterUnhealthyCode = "G3000W"
 	// ClusterHealthyCode is the cluster goes healthy event code.
@@ -415,6 +429,10 @@ const (
 	LogForwarderCreatedEvent = "logforwarder.created"
 	// LogForwarderDeletedEvent fires when a log forwarder is deleted.
 	LogForwarderDeletedEvent = "logforwarder.delete"
+	// AuditSinkCreatedEvent fires when an audit sink is created/updated.
+	AuditSinkCreatedEvent = "auditsink.created"
+	// AuditSinkDeletedEvent fires when an audit sink is deleted.
+	AuditSinkDeletedEvent = "auditsink.deleted"
 	// TLSKeyPairCreatedEvent fires when a TLS key pair is created/updated.
 	TLSKeyPairCreatedEvent = "tlskeypair.created"
 	// TLSKeyPairDeletedEvent fires when a TLS key pair is deleted.
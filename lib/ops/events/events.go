@@ -54,6 +54,9 @@ func emit(ctx context.Context, operator ops.Operator, event events.Event, fields
 	if fields.GetString(FieldUser) == "" && storage.UserFromContext(ctx) != "" {
 		fields[FieldUser] = storage.UserFromContext(ctx)
 	}
+	if fields.GetString(FieldCluster) == "" {
+		fields[FieldCluster] = cluster.Domain
+	}
 	return operator.EmitAuditEvent(ctx, ops.AuditEventRequest{
 		SiteKey: cluster.Key(),
 		Event:   event,
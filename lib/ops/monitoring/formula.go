@@ -0,0 +1,545 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/utils"
+
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+)
+
+// Formula is a parsed alert formula (TICKscript)
+type Formula struct {
+	// Measurements is the list of measurements the formula reads
+	Measurements []string
+	// vars maps the names of the variables to their literal values
+	vars map[string]interface{}
+	// pipelines maps the names of the pipeline variables to their statements
+	pipelines map[string]statement
+	// statements is the list of parsed statements
+	statements []statement
+}
+
+// ParseFormula parses and validates the alert formula
+func ParseFormula(formula string) (*Formula, error) {
+	statements, err := parseScript(formula)
+	if err != nil {
+		return nil, trace.Wrap(err, "invalid alert formula")
+	}
+	f := &Formula{
+		vars:       make(map[string]interface{}),
+		pipelines:  make(map[string]statement),
+		statements: statements,
+	}
+	var hasSource, hasAlert bool
+	for _, stmt := range statements {
+		if stmt.Database != "" {
+			// dbrp statements only select the database of stream tasks
+			continue
+		}
+		if stmt.Value != nil {
+			if stmt.Value.Var != "" {
+				v, ok := f.vars[stmt.Value.Var]
+				if !ok {
+					return nil, trace.BadParameter("undefined variable %v", stmt.Value.Var)
+				}
+				f.vars[stmt.Var] = v
+				continue
+			}
+			f.vars[stmt.Var] = stmt.Value.Literal
+			continue
+		}
+		switch stmt.Source {
+		case sourceStream, sourceBatch:
+			hasSource = true
+		default:
+			if _, ok := f.pipelines[stmt.Source]; !ok {
+				return nil, trace.BadParameter("undefined pipeline %v", stmt.Source)
+			}
+		}
+		for _, node := range stmt.Nodes {
+			if err := f.checkNode(node); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			switch node.Name {
+			case "alert", "deadman":
+				// deadman is a shorthand for an alert node that
+				// fires when the throughput drops below a threshold
+				hasAlert = true
+			case "from":
+				if args, ok := node.Property("measurement"); ok {
+					if len(args) != 1 {
+						return nil, trace.BadParameter("line %v: measurement expects a single argument", node.Line)
+					}
+					name, ok := f.stringValue(args[0])
+					if !ok {
+						return nil, trace.BadParameter("line %v: measurement expects a string", node.Line)
+					}
+					f.Measurements = append(f.Measurements, name)
+				}
+			case "query":
+				if len(node.Args) == 1 {
+					if query, ok := f.stringValue(node.Args[0]); ok {
+						f.Measurements = append(f.Measurements, queryMeasurements(query)...)
+					}
+				}
+			}
+		}
+		if stmt.Var != "" {
+			f.pipelines[stmt.Var] = stmt
+		}
+	}
+	if !hasSource {
+		return nil, trace.BadParameter("alert formula does not define a stream or batch data source")
+	}
+	if !hasAlert {
+		return nil, trace.BadParameter("alert formula does not define an alert node")
+	}
+	f.Measurements = teleutils.Deduplicate(f.Measurements)
+	sort.Strings(f.Measurements)
+	return f, nil
+}
+
+// CheckMeasurements verifies that all measurements the formula reads
+// are present in the provided list of available measurements
+func (f *Formula) CheckMeasurements(available []string) error {
+	var missing []string
+	for _, measurement := range f.Measurements {
+		if !utils.StringInSlice(available, measurement) {
+			missing = append(missing, measurement)
+		}
+	}
+	if len(missing) != 0 {
+		return trace.NotFound("alert formula references unknown measurements: %v",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkNode makes sure that all variables referenced by the node are defined
+func (f *Formula) checkNode(node pipelineNode) error {
+	values := append([]value{}, node.Args...)
+	for _, p := range node.Properties {
+		values = append(values, p.Args...)
+	}
+	for _, v := range values {
+		if v.Var != "" {
+			if _, ok := f.vars[v.Var]; !ok {
+				if _, ok := f.pipelines[v.Var]; !ok {
+					return trace.BadParameter("line %v: undefined variable %v", node.Line, v.Var)
+				}
+			}
+		}
+		var err error
+		if v.Lambda != nil {
+			v.Lambda.walk(func(e expr) {
+				if ident, ok := e.(identExpr); ok && err == nil {
+					if _, ok := f.vars[string(ident)]; !ok {
+						err = trace.BadParameter("line %v: undefined variable %v", node.Line, string(ident))
+					}
+				}
+			})
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (f *Formula) stringValue(v value) (string, bool) {
+	if v.Var != "" {
+		s, ok := f.vars[v.Var].(string)
+		return s, ok
+	}
+	s, ok := v.Literal.(string)
+	return s, ok
+}
+
+func (f *Formula) durationValue(v value) (time.Duration, bool) {
+	literal := v.Literal
+	if v.Var != "" {
+		literal = f.vars[v.Var]
+	}
+	d, ok := literal.(time.Duration)
+	return d, ok
+}
+
+// alertPipeline returns the nodes of the pipeline that ends with
+// the alert node, with the nodes of the parent pipelines included
+func (f *Formula) alertPipeline() (source string, nodes []pipelineNode, err error) {
+	var alerts []statement
+	for _, stmt := range f.statements {
+		for _, node := range stmt.Nodes {
+			if node.Name == "alert" {
+				alerts = append(alerts, stmt)
+			}
+		}
+	}
+	if len(alerts) != 1 {
+		return "", nil, trace.NotImplemented("formulas with %v alert nodes cannot be evaluated", len(alerts))
+	}
+	stmt := alerts[0]
+	nodes = stmt.Nodes
+	for stmt.Source != sourceStream && stmt.Source != sourceBatch {
+		stmt = f.pipelines[stmt.Source]
+		nodes = append(append([]pipelineNode{}, stmt.Nodes...), nodes...)
+	}
+	return stmt.Source, nodes, nil
+}
+
+// queryMeasurements returns the measurements referenced in the FROM clauses
+// of the InfluxQL query
+func queryMeasurements(query string) (measurements []string) {
+	for _, match := range fromClause.FindAllStringSubmatch(query, -1) {
+		parts := strings.Split(match[1], ".")
+		measurements = append(measurements, strings.Trim(parts[len(parts)-1], `"`))
+	}
+	return measurements
+}
+
+// AlertEvaluation is the result of evaluating an alert formula against
+// historical metrics
+type AlertEvaluation struct {
+	// Query is the query used to retrieve the metrics
+	Query string `json:"query"`
+	// From is the start of the evaluated time range
+	From time.Time `json:"from"`
+	// To is the end of the evaluated time range
+	To time.Time `json:"to"`
+	// Points is the number of evaluated points
+	Points int `json:"points"`
+	// Transitions lists the changes of the alert level
+	Transitions []AlertTransition `json:"transitions"`
+	// Notes lists the limitations of the evaluation
+	Notes []string `json:"notes,omitempty"`
+}
+
+// AlertTransition is a change of the alert level
+type AlertTransition struct {
+	// Time is the time of the change
+	Time time.Time `json:"time"`
+	// Level is the new alert level
+	Level string `json:"level"`
+	// Tags are the tags of the series the alert changed level for
+	Tags map[string]string `json:"tags,omitempty"`
+	// Fields are the field values of the point that triggered the change
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Evaluate evaluates the formula against the metrics in the specified time range
+// and returns the changes of the alert level.
+//
+// Only formulas with a single stream pipeline of the following nodes can be
+// evaluated: from, where, window, a single aggregation and alert.
+// The metrics are queried with InfluxQL so the evaluation requires InfluxDB backend
+func (f *Formula) Evaluate(backend Monitoring, from, to time.Time) (*AlertEvaluation, error) {
+	if backend.GetType() != constants.MonitoringBackendInfluxDB {
+		return nil, trace.NotImplemented("alert evaluation is not supported with %v metrics backend",
+			backend.GetType())
+	}
+	plan, err := f.plan(from, to)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	series, err := backend.Query(plan.query)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	result := &AlertEvaluation{
+		Query:       plan.query,
+		From:        from,
+		To:          to,
+		Notes:       plan.notes,
+		Transitions: []AlertTransition{},
+	}
+	for _, s := range series {
+		transitions, points, err := plan.evaluate(f.vars, s)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		result.Points += points
+		result.Transitions = append(result.Transitions, transitions...)
+	}
+	sort.SliceStable(result.Transitions, func(i, j int) bool {
+		return result.Transitions[i].Time.Before(result.Transitions[j].Time)
+	})
+	return result, nil
+}
+
+// evaluationPlan describes how to evaluate the formula
+type evaluationPlan struct {
+	query string
+	// filters are the conditions of the where nodes
+	filters []expr
+	// levels are the alert level conditions, highest level first
+	levels []levelCondition
+	notes  []string
+}
+
+type levelCondition struct {
+	level     string
+	condition expr
+}
+
+func (f *Formula) plan(from, to time.Time) (*evaluationPlan, error) {
+	source, nodes, err := f.alertPipeline()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if source != sourceStream {
+		return nil, trace.NotImplemented("only stream formulas can be evaluated")
+	}
+	var (
+		plan        evaluationPlan
+		measurement string
+		conditions  = []string{
+			fmt.Sprintf("time >= '%v'", from.UTC().Format(time.RFC3339Nano)),
+			fmt.Sprintf("time <= '%v'", to.UTC().Format(time.RFC3339Nano)),
+		}
+		groupBy   []string
+		selection = "*"
+		every     time.Duration
+	)
+	for _, node := range nodes {
+		switch node.Name {
+		case "from":
+			args, ok := node.Property("measurement")
+			if !ok {
+				return nil, trace.NotImplemented("line %v: formulas without a measurement cannot be evaluated", node.Line)
+			}
+			measurement, _ = f.stringValue(args[0])
+			if args, ok := node.Property("where"); ok && len(args) == 1 && args[0].Lambda != nil {
+				condition, err := args[0].Lambda.influxQL(f.vars)
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				conditions = append(conditions, condition)
+			}
+			if args, ok := node.Property("groupBy"); ok {
+				for _, arg := range args {
+					tag, ok := f.stringValue(arg)
+					if !ok {
+						return nil, trace.NotImplemented("line %v: groupBy expects tag names", node.Line)
+					}
+					groupBy = append(groupBy, strconv.Quote(tag))
+				}
+			}
+		case "where":
+			if len(node.Args) != 1 || node.Args[0].Lambda == nil {
+				return nil, trace.BadParameter("line %v: where expects a lambda", node.Line)
+			}
+			plan.filters = append(plan.filters, node.Args[0].Lambda)
+		case "window":
+			period, hasPeriod := node.Property("period")
+			everyArgs, hasEvery := node.Property("every")
+			if !hasPeriod || !hasEvery || len(period) != 1 || len(everyArgs) != 1 {
+				return nil, trace.BadParameter("line %v: window expects period and every", node.Line)
+			}
+			periodValue, ok := f.durationValue(period[0])
+			if !ok {
+				return nil, trace.BadParameter("line %v: window period expects a duration", node.Line)
+			}
+			if every, ok = f.durationValue(everyArgs[0]); !ok {
+				return nil, trace.BadParameter("line %v: window every expects a duration", node.Line)
+			}
+			if periodValue != every {
+				plan.notes = append(plan.notes, fmt.Sprintf(
+					"The window period %v differs from the interval %v, the metrics are aggregated over %v intervals.",
+					periodValue, every, every))
+			}
+		case "mean", "max", "min", "sum", "count", "median", "first", "last", "spread", "stddev":
+			if selection != "*" {
+				return nil, trace.NotImplemented("line %v: formulas with multiple aggregations cannot be evaluated", node.Line)
+			}
+			if len(node.Args) != 1 {
+				return nil, trace.BadParameter("line %v: %v expects a field name", node.Line, node.Name)
+			}
+			field, ok := f.stringValue(node.Args[0])
+			if !ok {
+				return nil, trace.BadParameter("line %v: %v expects a field name", node.Line, node.Name)
+			}
+			as := node.Name
+			if args, ok := node.Property("as"); ok && len(args) == 1 {
+				as, _ = f.stringValue(args[0])
+			}
+			selection = fmt.Sprintf("%v(%q) AS %q", node.Name, field, as)
+		case "alert":
+			for _, level := range []string{AlertLevelCritical, AlertLevelWarning, AlertLevelInfo} {
+				args, ok := node.Property(alertLevelProperties[level])
+				if !ok {
+					continue
+				}
+				if len(args) != 1 || args[0].Lambda == nil {
+					return nil, trace.BadParameter("line %v: %v expects a lambda",
+						node.Line, alertLevelProperties[level])
+				}
+				plan.levels = append(plan.levels, levelCondition{level: level, condition: args[0].Lambda})
+			}
+			for _, p := range node.Properties {
+				if strings.HasSuffix(p.Name, "Reset") {
+					plan.notes = append(plan.notes, fmt.Sprintf(
+						"The %v condition is not evaluated.", p.Name))
+				}
+			}
+		default:
+			return nil, trace.NotImplemented("line %v: formulas with %v nodes cannot be evaluated",
+				node.Line, node.Name)
+		}
+	}
+	if len(plan.levels) == 0 {
+		return nil, trace.BadParameter("alert node does not define any of info, warn or crit conditions")
+	}
+	if every != 0 {
+		if selection == "*" {
+			return nil, trace.NotImplemented("formulas with a window but no aggregation cannot be evaluated")
+		}
+		groupBy = append([]string{fmt.Sprintf("time(%v)", formatInfluxQLDuration(every))}, groupBy...)
+	}
+	plan.query = fmt.Sprintf("SELECT %v FROM %q WHERE %v", selection, measurement,
+		strings.Join(conditions, " AND "))
+	if len(groupBy) != 0 {
+		plan.query += " GROUP BY " + strings.Join(groupBy, ", ")
+	}
+	return &plan, nil
+}
+
+// evaluate evaluates the alert conditions for every point of the series
+// and returns the changes of the alert level along with the number of
+// evaluated points
+func (p *evaluationPlan) evaluate(vars map[string]interface{}, s Series) ([]AlertTransition, int, error) {
+	var (
+		transitions []AlertTransition
+		points      int
+		previous    = AlertLevelOK
+	)
+	timeIndex := -1
+	for i, column := range s.Columns {
+		if column == "time" {
+			timeIndex = i
+		}
+	}
+	if timeIndex < 0 {
+		return nil, 0, trace.BadParameter("series %v has no time column", s.Name)
+	}
+rows:
+	for _, row := range s.Values {
+		fields := make(map[string]interface{}, len(row)+len(s.Tags))
+		for tag, value := range s.Tags {
+			fields[tag] = value
+		}
+		for i, column := range s.Columns {
+			if i == timeIndex || i >= len(row) || row[i] == nil {
+				continue
+			}
+			fields[column] = row[i]
+		}
+		if len(fields) == len(s.Tags) {
+			// no data in this interval
+			continue
+		}
+		timestamp, err := parseSeriesTime(row[timeIndex])
+		if err != nil {
+			return nil, 0, trace.Wrap(err)
+		}
+		scope := scope{vars: vars, fields: fields}
+		for _, filter := range p.filters {
+			matches, err := evalCondition(filter, scope)
+			if err != nil {
+				return nil, 0, trace.Wrap(err)
+			}
+			if !matches {
+				continue rows
+			}
+		}
+		points++
+		level := AlertLevelOK
+		for _, condition := range p.levels {
+			fired, err := evalCondition(condition.condition, scope)
+			if err != nil {
+				return nil, 0, trace.Wrap(err)
+			}
+			if fired {
+				level = condition.level
+				break
+			}
+		}
+		if level != previous {
+			transitions = append(transitions, AlertTransition{
+				Time:   timestamp,
+				Level:  level,
+				Tags:   s.Tags,
+				Fields: fields,
+			})
+			previous = level
+		}
+	}
+	return transitions, points, nil
+}
+
+func parseSeriesTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, trace.Wrap(err)
+	case float64:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)).UTC(), nil
+	}
+	return time.Time{}, trace.BadParameter("unexpected time value %v", v)
+}
+
+// formatInfluxQLDuration formats the duration as an InfluxQL duration literal
+func formatInfluxQLDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+		{"ms", time.Millisecond},
+	} {
+		if d%unit.duration == 0 {
+			return fmt.Sprintf("%v%v", int64(d/unit.duration), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%vu", int64(d/time.Microsecond))
+}
+
+const (
+	sourceStream = "stream"
+	sourceBatch  = "batch"
+)
+
+// alertLevelProperties maps the alert levels to the alert node properties
+var alertLevelProperties = map[string]string{
+	AlertLevelCritical: "crit",
+	AlertLevelWarning:  "warn",
+	AlertLevelInfo:     "info",
+}
+
+// fromClause matches the measurement in the FROM clause of an InfluxQL query
+var fromClause = regexp.MustCompile(`(?i)\bFROM\s+((?:"[^"]+"|[^\s."]+)(?:\.(?:"[^"]+"|[^\s."]+))*)`)
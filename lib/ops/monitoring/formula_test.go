@@ -0,0 +1,259 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type FormulaSuite struct{}
+
+var _ = check.Suite(&FormulaSuite{})
+
+func (s *FormulaSuite) TestParseFormula(c *check.C) {
+	formula, err := ParseFormula(highCPUFormula)
+	c.Assert(err, check.IsNil)
+	c.Assert(formula.Measurements, check.DeepEquals, []string{"cpu/usage_rate"})
+
+	formula, err = ParseFormula(`
+var data = batch
+    |query('SELECT mean("value") FROM "k8s"."default"."memory/usage" WHERE time > now() - 5m')
+        .period(5m)
+        .every(1m)
+data
+    |alert()
+        .crit(lambda: "mean" > 90)
+`)
+	c.Assert(err, check.IsNil)
+	c.Assert(formula.Measurements, check.DeepEquals, []string{"memory/usage"})
+
+	formula, err = ParseFormula(`
+stream
+    |from()
+        .measurement('uptime')
+        .groupBy('nodename')
+    |deadman(0.0, 5m)
+`)
+	c.Assert(err, check.IsNil)
+	c.Assert(formula.Measurements, check.DeepEquals, []string{"uptime"})
+
+	valid := []struct {
+		comment      string
+		formula      string
+		measurements []string
+	}{
+		{
+			comment: "escaped quotes in string",
+			formula: `stream
+    |from().measurement('cpu/usage_rate')
+    |alert()
+        .message('{{ index .Tags \'nodename\' }} CPU usage is high')
+        .crit(lambda: "value" > 90)`,
+			measurements: []string{"cpu/usage_rate"},
+		},
+		{
+			comment:      "escaped quotes in reference",
+			formula:      `stream|from().measurement('cpu')|alert().crit(lambda: "usage \"total\"" > 90)`,
+			measurements: []string{"cpu"},
+		},
+		{
+			comment:      "triple-quoted string",
+			formula:      `stream|from().measurement('cpu')|alert().details('''CPU usage on '{{ .ID }}' is high''').crit(lambda: "value" > 90)`,
+			measurements: []string{"cpu"},
+		},
+		{
+			comment: "dbrp statement",
+			formula: `dbrp "k8s"."default"
+stream|from().measurement('cpu')|alert().crit(lambda: "value" > 90)`,
+			measurements: []string{"cpu"},
+		},
+		{
+			comment:      "star and modulo",
+			formula:      `stream|from().measurement('cpu').groupBy(*)|alert().crit(lambda: "value" % 2 == 1)`,
+			measurements: []string{"cpu"},
+		},
+	}
+	for _, tc := range valid {
+		formula, err := ParseFormula(tc.formula)
+		c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+		c.Assert(formula.Measurements, check.DeepEquals, tc.measurements, check.Commentf(tc.comment))
+	}
+
+	tcs := []struct {
+		comment string
+		formula string
+	}{
+		{
+			comment: "unterminated string",
+			formula: `stream|from().measurement('cpu)|alert()`,
+		},
+		{
+			comment: "unbalanced parentheses",
+			formula: `stream|from().measurement('cpu'|alert()`,
+		},
+		{
+			comment: "no data source",
+			formula: `var x = 10`,
+		},
+		{
+			comment: "no alert node",
+			formula: `stream|from().measurement('cpu')`,
+		},
+		{
+			comment: "undefined variable in lambda",
+			formula: `stream|from().measurement('cpu')|alert().crit(lambda: "value" > threshold)`,
+		},
+		{
+			comment: "undefined pipeline",
+			formula: `data|alert().crit(lambda: "value" > 10)`,
+		},
+		{
+			comment: "unterminated escaped string",
+			formula: `stream|from().measurement('cpu\')|alert()`,
+		},
+		{
+			comment: "incomplete dbrp statement",
+			formula: `dbrp "k8s"
+stream|from().measurement('cpu')|alert().crit(lambda: "value" > 90)`,
+		},
+		{
+			comment: "expression outside of lambda",
+			formula: `stream|from().measurement('cpu')|alert().crit("value" > 10)`,
+		},
+	}
+	for _, tc := range tcs {
+		_, err := ParseFormula(tc.formula)
+		c.Assert(err, check.NotNil, check.Commentf(tc.comment))
+	}
+}
+
+func (s *FormulaSuite) TestCheckMeasurements(c *check.C) {
+	formula, err := ParseFormula(highCPUFormula)
+	c.Assert(err, check.IsNil)
+	c.Assert(formula.CheckMeasurements([]string{"cpu/usage_rate", "memory/usage"}), check.IsNil)
+	err = formula.CheckMeasurements([]string{"memory/usage"})
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+}
+
+func (s *FormulaSuite) TestEvaluate(c *check.C) {
+	formula, err := ParseFormula(highCPUFormula)
+	c.Assert(err, check.IsNil)
+	from := time.Date(2019, time.March, 1, 10, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	backend := &fakeBackend{series: []Series{
+		{
+			Name:    "cpu/usage_rate",
+			Tags:    map[string]string{"nodename": "node-1"},
+			Columns: []string{"time", "cpu_usage"},
+			Values: [][]interface{}{
+				{"2019-03-01T10:00:00Z", 50.0},
+				{"2019-03-01T10:01:00Z", 80.0},
+				{"2019-03-01T10:02:00Z", nil},
+				{"2019-03-01T10:03:00Z", 95.0},
+				{"2019-03-01T10:04:00Z", 40.0},
+			},
+		},
+		{
+			Name:    "cpu/usage_rate",
+			Tags:    map[string]string{"nodename": "node-2"},
+			Columns: []string{"time", "cpu_usage"},
+			Values: [][]interface{}{
+				{"2019-03-01T10:00:00Z", 10.0},
+			},
+		},
+	}}
+
+	result, err := formula.Evaluate(backend, from, to)
+	c.Assert(err, check.IsNil)
+	c.Assert(backend.query, check.Equals, `SELECT mean("value") AS "cpu_usage" FROM "cpu/usage_rate" `+
+		`WHERE time >= '2019-03-01T10:00:00Z' AND time <= '2019-03-01T10:05:00Z' AND ("type" = 'node') `+
+		`GROUP BY time(1m), "nodename"`)
+	c.Assert(result.Query, check.Equals, backend.query)
+	c.Assert(result.Points, check.Equals, 5)
+	c.Assert(result.Notes, check.HasLen, 1)
+	var levels []string
+	for _, transition := range result.Transitions {
+		c.Assert(transition.Tags["nodename"], check.Equals, "node-1")
+		levels = append(levels, transition.Time.Format("15:04")+" "+transition.Level)
+	}
+	c.Assert(levels, check.DeepEquals, []string{
+		"10:01 WARNING",
+		"10:03 CRITICAL",
+		"10:04 OK",
+	})
+}
+
+func (s *FormulaSuite) TestEvaluateUnsupported(c *check.C) {
+	formula, err := ParseFormula(`
+var a = stream|from().measurement('cpu')
+var b = stream|from().measurement('memory')
+a|join(b).as('cpu', 'memory')|alert().crit(lambda: "cpu.value" > 10)
+`)
+	c.Assert(err, check.IsNil)
+	_, err = formula.Evaluate(&fakeBackend{}, time.Now().Add(-time.Hour), time.Now())
+	c.Assert(trace.IsNotImplemented(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+type fakeBackend struct {
+	Monitoring
+	query  string
+	series []Series
+}
+
+func (b *fakeBackend) Query(query string) ([]Series, error) {
+	b.query = query
+	return b.series, nil
+}
+
+func (b *fakeBackend) GetType() string {
+	return constants.MonitoringBackendInfluxDB
+}
+
+const highCPUFormula = `
+// fires when the average CPU usage is high
+var period = 5m
+var every = 1m
+var warnRate = 75
+var critRate = 90
+
+var usage = stream
+    |from()
+        .measurement('cpu/usage_rate')
+        .groupBy('nodename')
+        .where(lambda: "type" == 'node')
+    |window()
+        .period(period)
+        .every(every)
+    |mean('value')
+        .as('cpu_usage')
+
+usage
+    |alert()
+        .message('{{ .Level }}: node {{ index .Tags "nodename" }} CPU usage is {{ index .Fields "cpu_usage" }}%')
+        .details('''
+CPU usage is high
+''')
+        .warn(lambda: "cpu_usage" > warnRate)
+        .crit(lambda: "cpu_usage" > critRate AND "nodename" =~ /node-.*/)
+        .stateChangesOnly()
+        .email()
+`
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/roundtrip"
 	"github.com/gravitational/teleport/lib/httplib"
@@ -106,6 +107,60 @@ func (i *influxDB) UpdateRetentionPolicy(policy RetentionPolicy) error {
 	return trace.Wrap(err)
 }
 
+// GetMeasurements returns the names of the measurements in the metrics database
+func (i *influxDB) GetMeasurements() ([]string, error) {
+	series, err := i.Query(showMeasurementsQuery)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var measurements []string
+	for _, s := range series {
+		for _, values := range s.Values {
+			if len(values) == 0 {
+				continue
+			}
+			name, ok := values[0].(string)
+			if !ok {
+				return nil, trace.BadParameter("expected measurement name to be string: %v", values)
+			}
+			measurements = append(measurements, name)
+		}
+	}
+	return measurements, nil
+}
+
+// Query executes the query against the metrics database.
+// Timestamps are returned in RFC3339 format
+func (i *influxDB) Query(query string) ([]Series, error) {
+	response, err := i.Get(i.Endpoint("query"), url.Values{
+		"db": []string{influxDBDatabase},
+		"q":  []string{query},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var parsed influxDBResponse
+	err = json.Unmarshal(response.Bytes(), &parsed)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var series []Series
+	for _, result := range parsed.Results {
+		if result.Error != "" {
+			return nil, trace.BadParameter("query %q failed: %v", query, result.Error)
+		}
+		series = append(series, result.Series...)
+	}
+	return series, nil
+}
+
+// GetType returns the type of the metrics backend
+func (i *influxDB) GetType() string {
+	return constants.MonitoringBackendInfluxDB
+}
+
 // Get is like roundtrip.Client.Get but converts returned HTTP errors into trace errors
 func (i *influxDB) Get(endpoint string, params url.Values) (*roundtrip.Response, error) {
 	return httplib.ConvertResponse(i.Client.Get(context.TODO(), endpoint, params))
@@ -125,17 +180,9 @@ type influxDBResponse struct {
 // influxDBResult represents a single result in InfluxDB API response
 type influxDBResult struct {
 	// Series is the list of series
-	Series []influxDBSeries `json:"series"`
-}
-
-// influxDBSeries represents a single series in InfluxDB API result
-type influxDBSeries struct {
-	// Columns is the list of columns in this series, length should be
-	// equals to the length of each slice in values
-	Columns []string `json:"columns"`
-	// Values is the list of values in this series; values may be of different
-	// types hence interface
-	Values [][]interface{} `json:"values"`
+	Series []Series `json:"series"`
+	// Error is the error message if the query has failed
+	Error string `json:"error,omitempty"`
 }
 
 var (
@@ -143,4 +190,8 @@ var (
 	showQuery = "show retention policies on k8s"
 	// updateQuery is InfluxDB query to update retention policy
 	updateQuery = "alter retention policy %v on k8s duration %vh"
+	// showMeasurementsQuery is InfluxDB query to list measurements
+	showMeasurementsQuery = "show measurements on k8s"
+	// influxDBDatabase is the name of the database with cluster metrics
+	influxDBDatabase = "k8s"
 )
@@ -0,0 +1,359 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// expr is a TICKscript lambda expression
+type expr interface {
+	// eval evaluates the expression in the specified scope
+	eval(scope) (interface{}, error)
+	// influxQL formats the expression as an InfluxQL condition
+	influxQL(vars map[string]interface{}) (string, error)
+	// walk calls fn for this expression and all its subexpressions
+	walk(fn func(expr))
+}
+
+// scope defines the values available to a lambda expression
+type scope struct {
+	// vars are the formula variables
+	vars map[string]interface{}
+	// fields are the fields and tags of the evaluated point
+	fields map[string]interface{}
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+type identExpr string
+
+type referenceExpr string
+
+type regexLiteral string
+
+type unaryExpr struct {
+	op      string
+	operand expr
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+type callExpr struct {
+	name string
+	args []expr
+}
+
+func (e literalExpr) eval(scope) (interface{}, error) {
+	return e.value, nil
+}
+
+func (e identExpr) eval(s scope) (interface{}, error) {
+	v, ok := s.vars[string(e)]
+	if !ok {
+		return nil, trace.BadParameter("undefined variable %v", string(e))
+	}
+	return v, nil
+}
+
+func (e referenceExpr) eval(s scope) (interface{}, error) {
+	v, ok := s.fields[string(e)]
+	if !ok {
+		return nil, trace.NotFound("no field or tag %q", string(e))
+	}
+	return v, nil
+}
+
+func (e unaryExpr) eval(s scope) (interface{}, error) {
+	v, err := e.operand.eval(s)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch e.op {
+	case "-":
+		switch v := v.(type) {
+		case float64:
+			return -v, nil
+		case time.Duration:
+			return -v, nil
+		}
+	case "!":
+		if v, ok := v.(bool); ok {
+			return !v, nil
+		}
+	}
+	return nil, trace.BadParameter("invalid operand %v for %v", v, e.op)
+}
+
+func (e binaryExpr) eval(s scope) (interface{}, error) {
+	left, err := e.left.eval(s)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if e.op == "AND" || e.op == "OR" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, trace.BadParameter("%v expects boolean operands, got %v", e.op, left)
+		}
+		if (e.op == "AND" && !l) || (e.op == "OR" && l) {
+			return l, nil
+		}
+		right, err := e.right.eval(s)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, trace.BadParameter("%v expects boolean operands, got %v", e.op, right)
+		}
+		return r, nil
+	}
+	right, err := e.right.eval(s)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch e.op {
+	case "=~", "!~":
+		str, ok := left.(string)
+		pattern, isRegex := right.(regexLiteral)
+		if !ok || !isRegex {
+			return nil, trace.BadParameter("%v expects a string and a regular expression", e.op)
+		}
+		re, err := regexp.Compile(string(pattern))
+		if err != nil {
+			return nil, trace.BadParameter("invalid regular expression %v: %v", pattern, err)
+		}
+		return re.MatchString(str) == (e.op == "=~"), nil
+	}
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, trace.BadParameter("cannot compare %v with %v", left, right)
+		}
+		return evalNumeric(e.op, l, r)
+	case time.Duration:
+		r, ok := right.(time.Duration)
+		if !ok {
+			return nil, trace.BadParameter("cannot compare %v with %v", left, right)
+		}
+		return evalNumeric(e.op, float64(l), float64(r))
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, trace.BadParameter("cannot compare %v with %v", left, right)
+		}
+		switch e.op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		case "+":
+			return l + r, nil
+		}
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			return nil, trace.BadParameter("cannot compare %v with %v", left, right)
+		}
+		switch e.op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		}
+	}
+	return nil, trace.BadParameter("unsupported operation %v %v %v", left, e.op, right)
+}
+
+func evalNumeric(op string, l, r float64) (interface{}, error) {
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, trace.BadParameter("division by zero")
+		}
+		return l / r, nil
+	}
+	return nil, trace.BadParameter("unsupported numeric operator %v", op)
+}
+
+func (e callExpr) eval(s scope) (interface{}, error) {
+	if len(e.args) != 1 {
+		return nil, trace.BadParameter("%v expects a single argument", e.name)
+	}
+	arg, err := e.args[0].eval(s)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch e.name {
+	case "float":
+		switch v := arg.(type) {
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			return f, trace.Wrap(err)
+		}
+	case "int":
+		if v, ok := arg.(float64); ok {
+			return math.Trunc(v), nil
+		}
+	case "abs":
+		if v, ok := arg.(float64); ok {
+			return math.Abs(v), nil
+		}
+	case "string":
+		return fmt.Sprint(arg), nil
+	default:
+		return nil, trace.NotImplemented("function %v is not supported", e.name)
+	}
+	return nil, trace.BadParameter("invalid argument %v for %v", arg, e.name)
+}
+
+func (e literalExpr) influxQL(map[string]interface{}) (string, error) {
+	return formatInfluxQLLiteral(e.value)
+}
+
+func (e identExpr) influxQL(vars map[string]interface{}) (string, error) {
+	v, ok := vars[string(e)]
+	if !ok {
+		return "", trace.BadParameter("undefined variable %v", string(e))
+	}
+	return formatInfluxQLLiteral(v)
+}
+
+func (e referenceExpr) influxQL(map[string]interface{}) (string, error) {
+	return strconv.Quote(string(e)), nil
+}
+
+func (e unaryExpr) influxQL(vars map[string]interface{}) (string, error) {
+	if e.op != "-" {
+		return "", trace.NotImplemented("operator %v is not supported in InfluxQL", e.op)
+	}
+	operand, err := e.operand.influxQL(vars)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return "-" + operand, nil
+}
+
+func (e binaryExpr) influxQL(vars map[string]interface{}) (string, error) {
+	left, err := e.left.influxQL(vars)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	right, err := e.right.influxQL(vars)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	op := e.op
+	if op == "==" {
+		op = "="
+	}
+	return fmt.Sprintf("(%v %v %v)", left, op, right), nil
+}
+
+func (e callExpr) influxQL(map[string]interface{}) (string, error) {
+	return "", trace.NotImplemented("function %v is not supported in InfluxQL", e.name)
+}
+
+func formatInfluxQLLiteral(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case string:
+		return "'" + strings.Replace(v, "'", `\'`, -1) + "'", nil
+	case regexLiteral:
+		return "/" + string(v) + "/", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", trace.NotImplemented("value %v is not supported in InfluxQL", v)
+}
+
+func (e literalExpr) walk(fn func(expr))   { fn(e) }
+func (e identExpr) walk(fn func(expr))     { fn(e) }
+func (e referenceExpr) walk(fn func(expr)) { fn(e) }
+
+func (e unaryExpr) walk(fn func(expr)) {
+	fn(e)
+	e.operand.walk(fn)
+}
+
+func (e binaryExpr) walk(fn func(expr)) {
+	fn(e)
+	e.left.walk(fn)
+	e.right.walk(fn)
+}
+
+func (e callExpr) walk(fn func(expr)) {
+	fn(e)
+	for _, arg := range e.args {
+		arg.walk(fn)
+	}
+}
+
+// evalCondition evaluates the lambda expression as a condition
+func evalCondition(e expr, s scope) (bool, error) {
+	v, err := e.eval(s)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	result, ok := v.(bool)
+	if !ok {
+		return false, trace.BadParameter("expected a boolean condition, got %v", v)
+	}
+	return result, nil
+}
@@ -19,6 +19,7 @@ package monitoring
 import (
 	"time"
 
+	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/rigging"
 	"github.com/gravitational/trace"
@@ -33,6 +34,47 @@ type Monitoring interface {
 	GetRetentionPolicies() ([]RetentionPolicy, error)
 	// UpdateRetentionPolicy updates a retention policy
 	UpdateRetentionPolicy(RetentionPolicy) error
+	// GetMeasurements returns the names of the measurements in the metrics database
+	GetMeasurements() ([]string, error)
+	// Query executes the query against the metrics database.
+	// The query is written in the query language of the backend
+	Query(query string) ([]Series, error)
+	// GetType returns the type of the metrics backend
+	GetType() string
+}
+
+// Config defines the metrics backend configuration
+type Config struct {
+	// Backend is the metrics backend type, influxdb or prometheus
+	Backend string
+	// Prometheus is the Prometheus backend configuration
+	Prometheus PrometheusConfig
+}
+
+// New returns a new monitoring provider for the configured metrics backend
+func New(config Config) (Monitoring, error) {
+	switch config.Backend {
+	case "", constants.MonitoringBackendInfluxDB:
+		return NewInfluxDB()
+	case constants.MonitoringBackendPrometheus:
+		return NewPrometheus(config.Prometheus)
+	}
+	return nil, trace.BadParameter("unsupported metrics backend %q, supported are: %v",
+		config.Backend, []string{constants.MonitoringBackendInfluxDB, constants.MonitoringBackendPrometheus})
+}
+
+// Series is a series of points returned by a metrics query
+type Series struct {
+	// Name is the series name
+	Name string `json:"name"`
+	// Tags are the tags of the series if the query groups by tags
+	Tags map[string]string `json:"tags,omitempty"`
+	// Columns is the list of columns in this series, length should be
+	// equals to the length of each slice in values
+	Columns []string `json:"columns"`
+	// Values is the list of values in this series; values may be of different
+	// types hence interface
+	Values [][]interface{} `json:"values"`
 }
 
 // RetentionPolicy represents a single retention policy
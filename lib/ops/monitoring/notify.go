@@ -0,0 +1,332 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// Alert is an alert triggered by Kapacitor, in the format
+// Kapacitor's HTTP post alert handler sends it in
+type Alert struct {
+	// ID is the alert ID, identical for all state changes of the same alert
+	ID string `json:"id"`
+	// Message is the alert message
+	Message string `json:"message"`
+	// Details is the optional alert details
+	Details string `json:"details,omitempty"`
+	// Time is the time the alert changed state
+	Time time.Time `json:"time"`
+	// Duration is for how long the alert has been in a non-OK state
+	Duration time.Duration `json:"duration"`
+	// Level is the alert level: OK, INFO, WARNING or CRITICAL
+	Level string `json:"level"`
+	// PreviousLevel is the alert level before the state change
+	PreviousLevel string `json:"previousLevel,omitempty"`
+}
+
+// Check validates the alert
+func (a Alert) Check() error {
+	if a.ID == "" {
+		return trace.BadParameter("missing alert ID")
+	}
+	switch a.Level {
+	case AlertLevelOK, AlertLevelInfo, AlertLevelWarning, AlertLevelCritical:
+	default:
+		return trace.BadParameter("unsupported alert level %q", a.Level)
+	}
+	return nil
+}
+
+// IsResolved returns true if the alert has recovered
+func (a Alert) IsResolved() bool {
+	return a.Level == AlertLevelOK
+}
+
+// Notify delivers the alert to the specified alert target.
+//
+// Email alerts are sent by Kapacitor directly so only webhook,
+// Slack and PagerDuty targets are supported
+func Notify(ctx context.Context, client *http.Client, target storage.AlertTarget, alert Alert) error {
+	req, err := NewAlertRequest(target, alert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaults.AlertTargetTimeout}
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return trace.ConnectionProblem(err, "failed to deliver alert to %v", req.URL.Host)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return trace.BadParameter("alert target %v replied with %v", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// NewAlertRequest returns the request that delivers the alert
+// to the specified alert target
+func NewAlertRequest(target storage.AlertTarget, alert Alert) (*http.Request, error) {
+	if err := alert.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var (
+		addr    string
+		headers map[string]string
+		body    []byte
+		err     error
+	)
+	switch target.GetType() {
+	case storage.AlertTargetWebhook:
+		webhook := target.GetWebhook()
+		addr, headers = webhook.URL, webhook.Headers
+		body, err = webhookBody(*webhook, alert)
+	case storage.AlertTargetSlack:
+		addr = target.GetSlack().URL
+		body, err = slackBody(*target.GetSlack(), alert)
+	case storage.AlertTargetPagerDuty:
+		addr = target.GetPagerDuty().URL
+		body, err = pagerDutyBody(*target.GetPagerDuty(), alert)
+	default:
+		return nil, trace.BadParameter("alert target of type %q is handled by Kapacitor",
+			target.GetType())
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if webhook := target.GetWebhook(); webhook != nil && webhook.SigningSecret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(webhook.SigningSecret), body))
+	}
+	return req, nil
+}
+
+// AlertHandler is the Kapacitor HTTP post endpoint that forwards
+// alerts to the cluster controller for delivery to the alert target.
+//
+// Alert formulas reference the endpoint by name so the credentials
+// in the request headers are only kept in the Kapacitor configuration
+type AlertHandler struct {
+	// Endpoint is the name of the Kapacitor HTTP post endpoint
+	Endpoint string `json:"endpoint"`
+	// URL is the URL the alerts are posted to
+	URL string `json:"url"`
+	// Headers are the request headers, e.g. with the credentials
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// AlertHandlerEndpoint is the name of the Kapacitor HTTP post endpoint
+// that forwards alerts to the cluster controller
+const AlertHandlerEndpoint = "gravity"
+
+// RenderFormula returns the alert formula with the specified HTTP post handler
+// added to every alert node so that Kapacitor posts the alerts to the handler
+func RenderFormula(formula string, handler AlertHandler) (string, error) {
+	statements, err := parseScript(formula)
+	if err != nil {
+		return "", trace.Wrap(err, "invalid alert formula")
+	}
+	properties, err := handler.properties()
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	runes := []rune(formula)
+	var buf bytes.Buffer
+	var offset int
+	for _, stmt := range statements {
+		for _, node := range stmt.Nodes {
+			if node.Name != "alert" && node.Name != "deadman" {
+				continue
+			}
+			buf.WriteString(string(runes[offset:node.End]))
+			buf.WriteString(properties)
+			offset = node.End
+		}
+	}
+	if offset == 0 {
+		return "", trace.BadParameter("alert formula does not define an alert node")
+	}
+	buf.WriteString(string(runes[offset:]))
+	return buf.String(), nil
+}
+
+// properties formats the handler as TICKscript alert node properties
+func (h AlertHandler) properties() (string, error) {
+	if h.Endpoint == "" {
+		return "", trace.BadParameter("alert handler endpoint is required")
+	}
+	if strings.ContainsAny(h.Endpoint, "'\n") {
+		return "", trace.BadParameter("unsupported character in alert handler endpoint %q", h.Endpoint)
+	}
+	return fmt.Sprintf(".post().endpoint('%v')", h.Endpoint), nil
+}
+
+// Sign returns the HMAC-SHA256 signature of the body in the format
+// of the signature header: sha256=<hex-encoded signature>
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%v", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// webhookBody renders the webhook request body with the configured
+// template or as JSON if no template has been configured
+func webhookBody(webhook storage.AlertWebhook, alert Alert) ([]byte, error) {
+	tmpl, err := webhook.ParseBody()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if tmpl == nil {
+		body, err := json.Marshal(alert)
+		return body, trace.Wrap(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, alert); err != nil {
+		return nil, trace.BadParameter("failed to render webhook body: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// slackBody formats the alert as a Slack incoming webhook message
+func slackBody(slack storage.AlertSlack, alert Alert) ([]byte, error) {
+	text := alert.Message
+	if alert.Details != "" {
+		text = fmt.Sprintf("%v\n%v", text, alert.Details)
+	}
+	body, err := json.Marshal(slackMessage{
+		Channel:  slack.Channel,
+		Username: slack.Username,
+		Attachments: []slackAttachment{{
+			Fallback:  alert.Message,
+			Color:     slackColors[alert.Level],
+			Title:     fmt.Sprintf("[%v] %v", alert.Level, alert.ID),
+			Text:      text,
+			Timestamp: alert.Time.Unix(),
+		}},
+	})
+	return body, trace.Wrap(err)
+}
+
+// pagerDutyBody formats the alert as a PagerDuty Events API v2 event.
+// The alert ID is used as a deduplication key so the recovery of the
+// alert resolves the incident
+func pagerDutyBody(pagerDuty storage.AlertPagerDuty, alert Alert) ([]byte, error) {
+	event := pagerDutyEvent{
+		RoutingKey:  pagerDuty.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.ID,
+	}
+	if alert.IsResolved() {
+		event.EventAction = "resolve"
+	} else {
+		event.Payload = &pagerDutyPayload{
+			Summary:   alert.Message,
+			Source:    pagerDutySource,
+			Severity:  pagerDutySeverities[alert.Level],
+			Timestamp: alert.Time.Format(time.RFC3339),
+			CustomDetails: map[string]string{
+				"details": alert.Details,
+			},
+		}
+	}
+	body, err := json.Marshal(event)
+	return body, trace.Wrap(err)
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Fallback  string `json:"fallback"`
+	Color     string `json:"color,omitempty"`
+	Title     string `json:"title"`
+	Text      string `json:"text"`
+	Timestamp int64  `json:"ts"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+const (
+	// AlertLevelOK is the level of a recovered alert
+	AlertLevelOK = "OK"
+	// AlertLevelInfo is the informational alert level
+	AlertLevelInfo = "INFO"
+	// AlertLevelWarning is the warning alert level
+	AlertLevelWarning = "WARNING"
+	// AlertLevelCritical is the critical alert level
+	AlertLevelCritical = "CRITICAL"
+
+	// SignatureHeader is the webhook request header with the body signature
+	SignatureHeader = "X-Gravity-Signature"
+
+	// pagerDutySource is the source of the PagerDuty events
+	pagerDutySource = "gravity"
+)
+
+var slackColors = map[string]string{
+	AlertLevelOK:       "good",
+	AlertLevelWarning:  "warning",
+	AlertLevelCritical: "danger",
+}
+
+var pagerDutySeverities = map[string]string{
+	AlertLevelInfo:     "info",
+	AlertLevelWarning:  "warning",
+	AlertLevelCritical: "critical",
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+func TestMonitoring(t *testing.T) { check.TestingT(t) }
+
+type NotifySuite struct{}
+
+var _ = check.Suite(&NotifySuite{})
+
+func (s *NotifySuite) TestWebhookTemplateAndSignature(c *check.C) {
+	target := newAlertTarget(c, storage.AlertTargetSpecV2{
+		Webhook: &storage.AlertWebhook{
+			URL:           "https://hooks.example.com/alerts",
+			Headers:       map[string]string{"X-Team": "ops"},
+			Body:          `{"text": {{json .Message}}, "level": "{{.Level}}"}`,
+			SigningSecret: "secret",
+		},
+	})
+	req, err := NewAlertRequest(target, testAlert)
+	c.Assert(err, check.IsNil)
+	body, err := ioutil.ReadAll(req.Body)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(body), check.Equals, `{"text": "CPU usage is \"high\"", "level": "CRITICAL"}`)
+	c.Assert(req.Header.Get("X-Team"), check.Equals, "ops")
+	c.Assert(req.Header.Get(SignatureHeader), check.Equals, Sign([]byte("secret"), body))
+}
+
+func (s *NotifySuite) TestSlackMessage(c *check.C) {
+	target := newAlertTarget(c, storage.AlertTargetSpecV2{
+		Slack: &storage.AlertSlack{
+			URL:     "https://hooks.slack.com/services/T000/B000/XXX",
+			Channel: "#ops",
+		},
+	})
+	var message slackMessage
+	decodeAlertRequest(c, target, testAlert, &message)
+	c.Assert(message, check.DeepEquals, slackMessage{
+		Channel: "#ops",
+		Attachments: []slackAttachment{{
+			Fallback:  testAlert.Message,
+			Color:     "danger",
+			Title:     "[CRITICAL] cpu/node-1",
+			Text:      "CPU usage is \"high\"\nnode-1: 95%",
+			Timestamp: testAlert.Time.Unix(),
+		}},
+	})
+}
+
+func (s *NotifySuite) TestPagerDutyEvents(c *check.C) {
+	target := newAlertTarget(c, storage.AlertTargetSpecV2{
+		PagerDuty: &storage.AlertPagerDuty{RoutingKey: "routing-key"},
+	})
+	var event pagerDutyEvent
+	req := decodeAlertRequest(c, target, testAlert, &event)
+	c.Assert(req.URL.String(), check.Equals, "https://events.pagerduty.com/v2/enqueue")
+	c.Assert(event, check.DeepEquals, pagerDutyEvent{
+		RoutingKey:  "routing-key",
+		EventAction: "trigger",
+		DedupKey:    "cpu/node-1",
+		Payload: &pagerDutyPayload{
+			Summary:       testAlert.Message,
+			Source:        "gravity",
+			Severity:      "critical",
+			Timestamp:     "2019-03-01T10:00:00Z",
+			CustomDetails: map[string]string{"details": testAlert.Details},
+		},
+	})
+
+	resolved := testAlert
+	resolved.Level = AlertLevelOK
+	event = pagerDutyEvent{}
+	decodeAlertRequest(c, target, resolved, &event)
+	c.Assert(event, check.DeepEquals, pagerDutyEvent{
+		RoutingKey:  "routing-key",
+		EventAction: "resolve",
+		DedupKey:    "cpu/node-1",
+	})
+}
+
+func (s *NotifySuite) TestNotify(c *check.C) {
+	var signature string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(SignatureHeader)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	spec := storage.AlertTargetSpecV2{
+		Webhook: &storage.AlertWebhook{
+			URL:           server.URL,
+			Headers:       map[string]string{"Authorization": "Bearer token"},
+			SigningSecret: "secret",
+		},
+	}
+	target := newAlertTarget(c, spec)
+	err := Notify(context.TODO(), server.Client(), target, testAlert)
+	c.Assert(err, check.IsNil)
+	body, err := json.Marshal(testAlert)
+	c.Assert(err, check.IsNil)
+	c.Assert(signature, check.Equals, Sign([]byte("secret"), body))
+
+	spec.Webhook.Headers = nil
+	target = newAlertTarget(c, spec)
+	err = Notify(context.TODO(), server.Client(), target, testAlert)
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v", err))
+
+	// email alerts are delivered by Kapacitor
+	target = newAlertTarget(c, storage.AlertTargetSpecV2{Email: "ops@example.com"})
+	err = Notify(context.TODO(), server.Client(), target, testAlert)
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+func (s *NotifySuite) TestRenderFormula(c *check.C) {
+	handler := AlertHandler{
+		Endpoint: AlertHandlerEndpoint,
+		URL:      "https://gravity-site.kube-system.svc.cluster.local:3009/notify",
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+	}
+	rendered, err := RenderFormula(`stream|from().measurement('cpu')|alert().crit(lambda: "value" > 10)
+stream|from().measurement('uptime')|deadman(0.0, 5m)`, handler)
+	c.Assert(err, check.IsNil)
+	c.Assert(rendered, check.Equals, `stream|from().measurement('cpu')|alert().crit(lambda: "value" > 10)`+
+		`.post().endpoint('gravity')
+stream|from().measurement('uptime')|deadman(0.0, 5m)`+
+		`.post().endpoint('gravity')`)
+	// credentials are kept in the endpoint configuration
+	c.Assert(strings.Contains(rendered, "secret"), check.Equals, false)
+	_, err = ParseFormula(rendered)
+	c.Assert(err, check.IsNil)
+
+	// escaped quotes and dbrp statements are kept as is
+	rendered, err = RenderFormula(`dbrp "k8s"."default"
+stream|from().measurement('cpu')|alert().message('\'cpu\' is high').crit(lambda: "value" > 10)`, handler)
+	c.Assert(err, check.IsNil)
+	c.Assert(rendered, check.Equals, `dbrp "k8s"."default"
+stream|from().measurement('cpu')|alert().message('\'cpu\' is high').crit(lambda: "value" > 10)`+
+		`.post().endpoint('gravity')`)
+
+	handler.Endpoint = "o'ps"
+	_, err = RenderFormula(highCPUFormula, handler)
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+// TestAlertDelivery delivers an alert fired by the rendered formula
+// to the alert target through the alert handler
+func (s *NotifySuite) TestAlertDelivery(c *check.C) {
+	var message slackMessage
+	target := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(json.NewDecoder(r.Body).Decode(&message), check.IsNil)
+	}))
+	defer target.Close()
+	alertTarget := newAlertTarget(c, storage.AlertTargetSpecV2{
+		Slack: &storage.AlertSlack{URL: target.URL},
+	})
+
+	// controller receives alerts from Kapacitor and delivers them to the alert target
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var alert Alert
+		c.Assert(json.NewDecoder(r.Body).Decode(&alert), check.IsNil)
+		if err := Notify(r.Context(), target.Client(), alertTarget, alert); err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer controller.Close()
+
+	handler := AlertHandler{
+		Endpoint: AlertHandlerEndpoint,
+		URL:      controller.URL,
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+	}
+	rendered, err := RenderFormula(highCPUFormula, handler)
+	c.Assert(err, check.IsNil)
+
+	// post the alert the way Kapacitor's HTTP post handler does
+	req := kapacitorPostRequest(c, rendered, handler, `{
+  "id": "cpu/node-1",
+  "message": "CRITICAL: node node-1 CPU usage is 95%",
+  "details": "CPU usage is high",
+  "time": "2019-03-01T10:00:00Z",
+  "duration": 300000000000,
+  "level": "CRITICAL",
+  "data": {"series": [{"name": "cpu/usage_rate", "tags": {"nodename": "node-1"}}]},
+  "previousLevel": "OK"
+}`)
+	resp, err := controller.Client().Do(req)
+	c.Assert(err, check.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, check.Equals, http.StatusOK)
+	c.Assert(message.Attachments, check.HasLen, 1)
+	c.Assert(message.Attachments[0].Title, check.Equals, "[CRITICAL] cpu/node-1")
+	c.Assert(message.Attachments[0].Text, check.Equals,
+		"CRITICAL: node node-1 CPU usage is 95%\nCPU usage is high")
+}
+
+// kapacitorPostRequest returns the request the HTTP post handler
+// of the alert node of the specified formula sends for the alert
+// with the endpoint configured by the specified handler
+func kapacitorPostRequest(c *check.C, formula string, handler AlertHandler, alert string) *http.Request {
+	statements, err := parseScript(formula)
+	c.Assert(err, check.IsNil)
+	var req *http.Request
+	for _, stmt := range statements {
+		for _, node := range stmt.Nodes {
+			for _, p := range node.Properties {
+				if p.Name != "endpoint" {
+					continue
+				}
+				c.Assert(p.Args[0].Literal, check.Equals, handler.Endpoint)
+				req, err = http.NewRequest(http.MethodPost, handler.URL, strings.NewReader(alert))
+				c.Assert(err, check.IsNil)
+				for name, value := range handler.Headers {
+					req.Header.Set(name, value)
+				}
+			}
+		}
+	}
+	c.Assert(req, check.NotNil)
+	return req
+}
+
+func newAlertTarget(c *check.C, spec storage.AlertTargetSpecV2) storage.AlertTarget {
+	target := &storage.AlertTargetV2{
+		Kind:    storage.KindAlertTarget,
+		Version: "v2",
+		Spec:    spec,
+	}
+	target.Metadata.Name = "target"
+	c.Assert(target.CheckAndSetDefaults(), check.IsNil)
+	return target
+}
+
+func decodeAlertRequest(c *check.C, target storage.AlertTarget, alert Alert, out interface{}) *http.Request {
+	req, err := NewAlertRequest(target, alert)
+	c.Assert(err, check.IsNil)
+	c.Assert(json.NewDecoder(req.Body).Decode(out), check.IsNil)
+	return req
+}
+
+var testAlert = Alert{
+	ID:       "cpu/node-1",
+	Message:  `CPU usage is "high"`,
+	Details:  "node-1: 95%",
+	Time:     time.Date(2019, time.March, 1, 10, 0, 0, 0, time.UTC),
+	Duration: 5 * time.Minute,
+	Level:    AlertLevelCritical,
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/rigging"
+	"github.com/gravitational/roundtrip"
+	"github.com/gravitational/teleport/lib/httplib"
+	"github.com/gravitational/trace"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PrometheusConfig defines the Prometheus metrics backend configuration
+type PrometheusConfig struct {
+	// URL is the Prometheus HTTP API URL
+	URL string
+	// Namespace is the namespace of the Prometheus statefulset
+	Namespace string
+	// StatefulSet is the name of the Prometheus statefulset
+	// the TSDB retention is configured on
+	StatefulSet string
+	// Client is the Kubernetes client used to update the TSDB retention
+	Client kubernetes.Interface
+}
+
+// CheckAndSetDefaults validates the config and sets default values
+func (c *PrometheusConfig) CheckAndSetDefaults() error {
+	if c.URL == "" {
+		c.URL = fmt.Sprintf("http://%v:%v", defaults.PrometheusServiceAddr, defaults.PrometheusServicePort)
+	}
+	if c.Namespace == "" {
+		c.Namespace = defaults.MonitoringNamespace
+	}
+	if c.StatefulSet == "" {
+		c.StatefulSet = defaults.PrometheusStatefulSet
+	}
+	return nil
+}
+
+type prometheus struct {
+	*roundtrip.Client
+	config PrometheusConfig
+}
+
+// NewPrometheus returns a new Prometheus monitoring provider.
+//
+// Prometheus keeps all metrics in a single TSDB so the only supported
+// retention policy is the default one which maps to the TSDB retention
+func NewPrometheus(config PrometheusConfig) (Monitoring, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	client, err := roundtrip.NewClient(config.URL, "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &prometheus{Client: client, config: config}, nil
+}
+
+// GetRetentionPolicies returns the TSDB retention as the default retention policy
+func (p *prometheus) GetRetentionPolicies() ([]RetentionPolicy, error) {
+	var flags map[string]string
+	if err := p.get(p.Endpoint("api", "v1", "status", "flags"), nil, &flags); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	retention, err := parseRetentionFlags(flags)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []RetentionPolicy{{
+		Name:     retentionPolicyDefault,
+		Duration: retention,
+	}}, nil
+}
+
+// UpdateRetentionPolicy updates the TSDB retention of the Prometheus statefulset.
+// Prometheus is restarted by the statefulset rolling update
+func (p *prometheus) UpdateRetentionPolicy(policy RetentionPolicy) error {
+	if policy.Name != retentionPolicyDefault {
+		return trace.BadParameter("Prometheus only supports %q retention policy, got %q",
+			retentionPolicyDefault, policy.Name)
+	}
+	if p.config.Client == nil {
+		return trace.BadParameter("updating Prometheus retention requires Kubernetes client")
+	}
+	statefulSets := p.config.Client.AppsV1().StatefulSets(p.config.Namespace)
+	statefulSet, err := statefulSets.Get(p.config.StatefulSet, metav1.GetOptions{})
+	if err != nil {
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	containers := statefulSet.Spec.Template.Spec.Containers
+	for i := range containers {
+		if containers[i].Name != prometheusContainer {
+			continue
+		}
+		containers[i].Args = setRetentionArgs(containers[i].Args, policy.Duration)
+		_, err = statefulSets.Update(statefulSet)
+		return trace.Wrap(rigging.ConvertError(err))
+	}
+	return trace.NotFound("statefulset %v/%v has no %q container",
+		p.config.Namespace, p.config.StatefulSet, prometheusContainer)
+}
+
+// GetMeasurements returns the names of the metrics in the TSDB
+func (p *prometheus) GetMeasurements() ([]string, error) {
+	var names []string
+	err := p.get(p.Endpoint("api", "v1", "label", model.MetricNameLabel, "values"), nil, &names)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return names, nil
+}
+
+// Query executes the PromQL query and returns each of the resulting
+// time series as a series with time and value columns.
+// Timestamps are returned in RFC3339 format
+func (p *prometheus) Query(query string) ([]Series, error) {
+	var result prometheusQueryResult
+	err := p.get(p.Endpoint("api", "v1", "query"), url.Values{"query": []string{query}}, &result)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var series []Series
+	switch result.ResultType {
+	case model.ValVector:
+		var vector model.Vector
+		if err := json.Unmarshal(result.Result, &vector); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, sample := range vector {
+			s := newPrometheusSeries(sample.Metric)
+			s.Values = append(s.Values, prometheusPoint(sample.Timestamp, sample.Value))
+			series = append(series, s)
+		}
+	case model.ValMatrix:
+		var matrix model.Matrix
+		if err := json.Unmarshal(result.Result, &matrix); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		for _, stream := range matrix {
+			s := newPrometheusSeries(stream.Metric)
+			for _, pair := range stream.Values {
+				s.Values = append(s.Values, prometheusPoint(pair.Timestamp, pair.Value))
+			}
+			series = append(series, s)
+		}
+	case model.ValScalar:
+		var scalar model.Scalar
+		if err := json.Unmarshal(result.Result, &scalar); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		series = append(series, Series{
+			Columns: []string{"time", "value"},
+			Values:  [][]interface{}{prometheusPoint(scalar.Timestamp, scalar.Value)},
+		})
+	default:
+		return nil, trace.BadParameter("unsupported query result type %v", result.ResultType)
+	}
+	return series, nil
+}
+
+// GetType returns the type of the metrics backend
+func (p *prometheus) GetType() string {
+	return constants.MonitoringBackendPrometheus
+}
+
+// get executes the API request and decodes the data of the response into out
+func (p *prometheus) get(endpoint string, params url.Values, out interface{}) error {
+	response, err := httplib.ConvertResponse(p.Client.Get(context.TODO(), endpoint, params))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var parsed prometheusResponse
+	if err := json.Unmarshal(response.Bytes(), &parsed); err != nil {
+		return trace.Wrap(err)
+	}
+	if parsed.Status != prometheusStatusSuccess {
+		return trace.BadParameter("Prometheus request failed: %v: %v", parsed.ErrorType, parsed.Error)
+	}
+	return trace.Wrap(json.Unmarshal(parsed.Data, out))
+}
+
+// parseRetentionFlags returns the TSDB retention from the Prometheus flags.
+// Older Prometheus versions configure the retention with the deprecated
+// storage.tsdb.retention flag
+func parseRetentionFlags(flags map[string]string) (time.Duration, error) {
+	for _, flag := range []string{retentionTimeFlag, retentionFlagDeprecated} {
+		value, ok := flags[flag]
+		if !ok {
+			continue
+		}
+		retention, err := model.ParseDuration(value)
+		if err != nil {
+			return 0, trace.BadParameter("invalid value %q of flag %v: %v", value, flag, err)
+		}
+		if retention != 0 {
+			return time.Duration(retention), nil
+		}
+	}
+	return defaults.PrometheusRetention, nil
+}
+
+// setRetentionArgs returns the Prometheus command line with the TSDB
+// retention set to the specified duration
+func setRetentionArgs(args []string, retention time.Duration) []string {
+	result := make([]string, 0, len(args)+1)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--"+retentionTimeFlag+"=") ||
+			strings.HasPrefix(arg, "--"+retentionFlagDeprecated+"=") {
+			continue
+		}
+		result = append(result, arg)
+	}
+	return append(result, fmt.Sprintf("--%v=%v", retentionTimeFlag, model.Duration(retention)))
+}
+
+func newPrometheusSeries(metric model.Metric) Series {
+	tags := make(map[string]string, len(metric))
+	for name, value := range metric {
+		if name == model.MetricNameLabel {
+			continue
+		}
+		tags[string(name)] = string(value)
+	}
+	return Series{
+		Name:    string(metric[model.MetricNameLabel]),
+		Tags:    tags,
+		Columns: []string{"time", "value"},
+	}
+}
+
+// prometheusPoint returns the sample as a time and value pair.
+// Values that are not numbers are returned as nil like the missing
+// values in InfluxDB results
+func prometheusPoint(timestamp model.Time, value model.SampleValue) []interface{} {
+	v := float64(value)
+	point := []interface{}{timestamp.Time().UTC().Format(time.RFC3339), v}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		point[1] = nil
+	}
+	return point
+}
+
+// prometheusResponse is the envelope of Prometheus HTTP API responses
+type prometheusResponse struct {
+	// Status is either success or error
+	Status string `json:"status"`
+	// Data is the response data
+	Data json.RawMessage `json:"data"`
+	// ErrorType is the type of the error if the request has failed
+	ErrorType string `json:"errorType,omitempty"`
+	// Error is the error message if the request has failed
+	Error string `json:"error,omitempty"`
+}
+
+// prometheusQueryResult is the result of a Prometheus query
+type prometheusQueryResult struct {
+	// ResultType is the type of the result: vector, matrix or scalar
+	ResultType model.ValueType `json:"resultType"`
+	// Result is the result of the type specified by ResultType
+	Result json.RawMessage `json:"result"`
+}
+
+const (
+	// retentionPolicyDefault is the name of the retention policy for
+	// high-resolution metrics, the only one supported by Prometheus
+	retentionPolicyDefault = "default"
+	// retentionTimeFlag is the Prometheus flag with the TSDB retention
+	retentionTimeFlag = "storage.tsdb.retention.time"
+	// retentionFlagDeprecated is the TSDB retention flag of older Prometheus versions
+	retentionFlagDeprecated = "storage.tsdb.retention"
+	// prometheusContainer is the name of the Prometheus container in the statefulset
+	prometheusContainer = "prometheus"
+	// prometheusStatusSuccess is the status of successful API responses
+	prometheusStatusSuccess = "success"
+)
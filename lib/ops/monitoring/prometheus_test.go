@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type PrometheusSuite struct {
+	server  *httptest.Server
+	backend Monitoring
+}
+
+var _ = check.Suite(&PrometheusSuite{})
+
+func (s *PrometheusSuite) SetUpSuite(c *check.C) {
+	responses := map[string]string{
+		"/api/v1/status/flags": `{"status":"success","data":{
+  "storage.tsdb.path":"/prometheus",
+  "storage.tsdb.retention":"0s",
+  "storage.tsdb.retention.time":"30d"}}`,
+		"/api/v1/label/__name__/values": `{"status":"success","data":["node_load1","up"]}`,
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/query" {
+			switch r.URL.Query().Get("query") {
+			case "up":
+				fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[
+  {"metric":{"__name__":"up","job":"node"},"value":[1551434400,"1"]}]}}`)
+			case "node_load1[2m]":
+				fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[
+  {"metric":{"__name__":"node_load1","instance":"node-1"},
+   "values":[[1551434400,"0.5"],[1551434460,"NaN"]]}]}}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"parse error"}`)
+			}
+			return
+		}
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	var err error
+	s.backend, err = NewPrometheus(PrometheusConfig{URL: s.server.URL})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *PrometheusSuite) TearDownSuite(c *check.C) {
+	s.server.Close()
+}
+
+func (s *PrometheusSuite) TestGetRetentionPolicies(c *check.C) {
+	policies, err := s.backend.GetRetentionPolicies()
+	c.Assert(err, check.IsNil)
+	c.Assert(policies, check.DeepEquals, []RetentionPolicy{
+		{Name: "default", Duration: 30 * 24 * time.Hour},
+	})
+}
+
+func (s *PrometheusSuite) TestUpdateRetentionPolicy(c *check.C) {
+	err := s.backend.UpdateRetentionPolicy(RetentionPolicy{Name: "long", Duration: time.Hour})
+	c.Assert(trace.IsBadParameter(err), check.Equals, true, check.Commentf("%v", err))
+
+	args := setRetentionArgs([]string{
+		"--config.file=/etc/prometheus/prometheus.yml",
+		"--storage.tsdb.retention=15d",
+	}, 7*24*time.Hour)
+	c.Assert(args, check.DeepEquals, []string{
+		"--config.file=/etc/prometheus/prometheus.yml",
+		"--storage.tsdb.retention.time=1w",
+	})
+}
+
+func (s *PrometheusSuite) TestGetMeasurements(c *check.C) {
+	measurements, err := s.backend.GetMeasurements()
+	c.Assert(err, check.IsNil)
+	c.Assert(measurements, check.DeepEquals, []string{"node_load1", "up"})
+}
+
+func (s *PrometheusSuite) TestQuery(c *check.C) {
+	series, err := s.backend.Query("up")
+	c.Assert(err, check.IsNil)
+	c.Assert(series, check.DeepEquals, []Series{{
+		Name:    "up",
+		Tags:    map[string]string{"job": "node"},
+		Columns: []string{"time", "value"},
+		Values:  [][]interface{}{{"2019-03-01T10:00:00Z", 1.0}},
+	}})
+
+	series, err = s.backend.Query("node_load1[2m]")
+	c.Assert(err, check.IsNil)
+	c.Assert(series, check.DeepEquals, []Series{{
+		Name:    "node_load1",
+		Tags:    map[string]string{"instance": "node-1"},
+		Columns: []string{"time", "value"},
+		Values: [][]interface{}{
+			{"2019-03-01T10:00:00Z", 0.5},
+			{"2019-03-01T10:01:00Z", nil},
+		},
+	}})
+
+	_, err = s.backend.Query("up{")
+	c.Assert(err, check.NotNil)
+}
@@ -0,0 +1,590 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gravitational/trace"
+)
+
+// This file implements a parser for the subset of TICKscript used
+// in alert formulas: dbrp statements, variable declarations, pipelines
+// of chained nodes with property methods and lambda expressions.
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenDuration
+	tokenString
+	tokenReference
+	tokenRegex
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	// end is the offset in runes of the end of the token in the script
+	end int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of formula"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// tokenize splits the TICKscript into tokens
+func tokenize(script string) ([]token, error) {
+	var tokens []token
+	line := 1
+	runes := []rune(script)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		n := len(tokens)
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(string(runes[i:]), "'''"):
+			// triple-quoted strings do not support escape sequences
+			start := i + 3
+			end := indexRunes(runes[start:], "'''")
+			if end < 0 {
+				return nil, trace.BadParameter("line %v: unterminated string", line)
+			}
+			value := string(runes[start : start+end])
+			tokens = append(tokens, token{kind: tokenString, value: value, line: line})
+			line += strings.Count(value, "\n")
+			i = start + end + 3
+		case r == '\'':
+			end := indexQuote(runes[i+1:], r)
+			if end < 0 {
+				return nil, trace.BadParameter("line %v: unterminated string", line)
+			}
+			value := unescape(runes[i+1:i+1+end], r)
+			tokens = append(tokens, token{kind: tokenString, value: value, line: line})
+			line += strings.Count(value, "\n")
+			i += end + 2
+		case r == '"':
+			end := indexQuote(runes[i+1:], r)
+			if end < 0 {
+				return nil, trace.BadParameter("line %v: unterminated reference", line)
+			}
+			value := unescape(runes[i+1:i+1+end], r)
+			tokens = append(tokens, token{kind: tokenReference, value: value, line: line})
+			i += end + 2
+		case r == '/' && len(tokens) != 0 && (tokens[len(tokens)-1].value == "=~" ||
+			tokens[len(tokens)-1].value == "!~"):
+			j := i + 1
+			for j < len(runes) && runes[j] != '/' {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, trace.BadParameter("line %v: unterminated regular expression", line)
+			}
+			tokens = append(tokens, token{kind: tokenRegex, value: string(runes[i+1 : j]), line: line})
+			i = j + 1
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			kind := tokenNumber
+			for k := j; k < len(runes) && (unicode.IsLetter(runes[k])); k++ {
+				kind, j = tokenDuration, k+1
+			}
+			tokens = append(tokens, token{kind: kind, value: string(runes[i:j]), line: line})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: string(runes[i:j]), line: line})
+			i = j
+		default:
+			op := string(r)
+			if i+1 < len(runes) {
+				if two := string(runes[i : i+2]); isOperator(two) {
+					op = two
+				}
+			}
+			if !isOperator(op) {
+				return nil, trace.BadParameter("line %v: unexpected character %q", line, r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, value: op, line: line})
+			i += len(op)
+		}
+		if len(tokens) > n {
+			tokens[n].end = i
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, line: line, end: len(runes)}), nil
+}
+
+// indexRunes returns the index of the first occurrence of substr in runes
+// or -1 if substr is not present
+func indexRunes(runes []rune, substr string) int {
+	sub := []rune(substr)
+	for i := 0; i+len(sub) <= len(runes); i++ {
+		if string(runes[i:i+len(sub)]) == substr {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexQuote returns the index of the first occurrence of the quote
+// in runes that is not escaped with a backslash or -1 if there is none
+func indexQuote(runes []rune, quote rune) int {
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape returns the quoted value with the escaped quotes unescaped.
+// Other backslashes are kept as is, e.g. in regular expressions
+func unescape(runes []rune, quote rune) string {
+	return strings.Replace(string(runes), `\`+string(quote), string(quote), -1)
+}
+
+func isOperator(op string) bool {
+	switch op {
+	case "|", ".", "@", "(", ")", ",", ":", "=", "==", "!=", "<", "<=", ">", ">=",
+		"=~", "!~", "+", "-", "*", "/", "%", "!":
+		return true
+	}
+	return false
+}
+
+// pipelineNode is a node of a TICKscript pipeline, for example:
+//
+//	|window()
+//	    .period(5m)
+//	    .every(1m)
+type pipelineNode struct {
+	// Name is the node name
+	Name string
+	// Args is the list of node arguments
+	Args []value
+	// Properties is the list of node property methods
+	Properties []property
+	// Line is the line of the node in the formula
+	Line int
+	// End is the offset in runes of the end of the node
+	// including its properties in the formula
+	End int
+}
+
+// Property returns the arguments of the property with the specified name
+func (n pipelineNode) Property(name string) ([]value, bool) {
+	for _, p := range n.Properties {
+		if p.Name == name {
+			return p.Args, true
+		}
+	}
+	return nil, false
+}
+
+// property is a property method of a pipeline node
+type property struct {
+	// Name is the property name
+	Name string
+	// Args is the list of property arguments
+	Args []value
+}
+
+// value is an argument of a node or a property
+type value struct {
+	// Literal is the literal argument value
+	Literal interface{}
+	// Var is the name of the referenced variable
+	Var string
+	// Lambda is the lambda expression argument
+	Lambda expr
+}
+
+// statement is a top-level TICKscript statement: a dbrp statement,
+// a variable declaration or a pipeline
+type statement struct {
+	// Database is the database of a dbrp statement
+	Database string
+	// RetentionPolicy is the retention policy of a dbrp statement
+	RetentionPolicy string
+	// Var is the name of the declared variable
+	Var string
+	// Source is the pipeline source, stream, batch or a variable
+	// with another pipeline
+	Source string
+	// Value is the literal value of the variable if this is not a pipeline
+	Value *value
+	// Nodes is the list of pipeline nodes
+	Nodes []pipelineNode
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(op string) bool {
+	t := p.peek()
+	return t.kind == tokenOperator && t.value == op
+}
+
+func (p *parser) expectOperator(op string) error {
+	t := p.next()
+	if t.kind != tokenOperator || t.value != op {
+		return trace.BadParameter("line %v: expected %q, got %v", t.line, op, t)
+	}
+	return nil
+}
+
+func (p *parser) expectIdent() (token, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return t, trace.BadParameter("line %v: expected identifier, got %v", t.line, t)
+	}
+	return t, nil
+}
+
+func (p *parser) expectReference() (token, error) {
+	t := p.next()
+	if t.kind != tokenReference {
+		return t, trace.BadParameter("line %v: expected reference, got %v", t.line, t)
+	}
+	return t, nil
+}
+
+// parseScript parses the TICKscript into a list of statements
+func parseScript(script string) ([]statement, error) {
+	tokens, err := tokenize(script)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	p := &parser{tokens: tokens}
+	var statements []statement
+	for p.peek().kind != tokenEOF {
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		statements = append(statements, *stmt)
+	}
+	return statements, nil
+}
+
+func (p *parser) parseStatement() (*statement, error) {
+	var stmt statement
+	if t := p.peek(); t.kind == tokenIdent && t.value == "dbrp" {
+		p.next()
+		database, err := p.expectReference()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if err := p.expectOperator("."); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		policy, err := p.expectReference()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stmt.Database, stmt.RetentionPolicy = database.value, policy.value
+		return &stmt, nil
+	}
+	if t := p.peek(); t.kind == tokenIdent && t.value == "var" {
+		p.next()
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if err := p.expectOperator("="); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stmt.Var = name.value
+	}
+	t := p.peek()
+	if t.kind != tokenIdent || t.value == "lambda" {
+		if stmt.Var == "" {
+			return nil, trace.BadParameter("line %v: unexpected %v", t.line, t)
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stmt.Value = v
+		return &stmt, nil
+	}
+	p.next()
+	stmt.Source = t.value
+	for p.isOperator("|") || p.isOperator(".") || p.isOperator("@") {
+		op := p.next()
+		name, err := p.expectIdent()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		end := p.tokens[p.pos-1].end
+		switch op.value {
+		case "|", "@":
+			stmt.Nodes = append(stmt.Nodes, pipelineNode{Name: name.value, Args: args, Line: name.line, End: end})
+		case ".":
+			if len(stmt.Nodes) == 0 {
+				return nil, trace.BadParameter("line %v: property %q without a node", name.line, name.value)
+			}
+			node := &stmt.Nodes[len(stmt.Nodes)-1]
+			node.Properties = append(node.Properties, property{Name: name.value, Args: args})
+			node.End = end
+		}
+	}
+	return &stmt, nil
+}
+
+func (p *parser) parseArgs() ([]value, error) {
+	if err := p.expectOperator("("); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var args []value
+	for !p.isOperator(")") {
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		args = append(args, *arg)
+		if !p.isOperator(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expectOperator(")"); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return args, nil
+}
+
+func (p *parser) parseValue() (*value, error) {
+	t := p.peek()
+	if t.kind == tokenIdent && t.value == "lambda" {
+		p.next()
+		if err := p.expectOperator(":"); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		e, err := p.parseExpr(0)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &value{Lambda: e}, nil
+	}
+	e, err := p.parseExpr(0)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch e := e.(type) {
+	case literalExpr:
+		return &value{Literal: e.value}, nil
+	case identExpr:
+		return &value{Var: string(e)}, nil
+	case unaryExpr:
+		if lit, ok := e.operand.(literalExpr); ok && e.op == "-" {
+			switch v := lit.value.(type) {
+			case float64:
+				return &value{Literal: -v}, nil
+			case time.Duration:
+				return &value{Literal: -v}, nil
+			}
+		}
+	}
+	return nil, trace.BadParameter("line %v: expressions are only allowed in lambdas", t.line)
+}
+
+// binaryPrecedence defines the precedence of binary operators
+var binaryPrecedence = map[string]int{
+	"OR":  1,
+	"AND": 2,
+	"==":  3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3, "=~": 3, "!~": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+// starLiteral is the TICKscript star literal
+type starLiteral struct{}
+
+func (p *parser) binaryOperator() (string, int, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && !(t.kind == tokenIdent && (t.value == "AND" || t.value == "OR")) {
+		return "", 0, false
+	}
+	precedence, ok := binaryPrecedence[t.value]
+	return t.value, precedence, ok
+}
+
+func (p *parser) parseExpr(minPrecedence int) (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for {
+		op, precedence, ok := p.binaryOperator()
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.isOperator("-") || p.isOperator("!") {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return unaryExpr{op: op.value, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, trace.BadParameter("line %v: invalid number %v", t.line, t)
+		}
+		return literalExpr{value: number}, nil
+	case tokenDuration:
+		duration, err := parseDuration(t.value)
+		if err != nil {
+			return nil, trace.BadParameter("line %v: invalid duration %v", t.line, t)
+		}
+		return literalExpr{value: duration}, nil
+	case tokenString:
+		return literalExpr{value: t.value}, nil
+	case tokenRegex:
+		return literalExpr{value: regexLiteral(t.value)}, nil
+	case tokenReference:
+		return referenceExpr(t.value), nil
+	case tokenIdent:
+		switch t.value {
+		case "TRUE":
+			return literalExpr{value: true}, nil
+		case "FALSE":
+			return literalExpr{value: false}, nil
+		}
+		if p.isOperator("(") {
+			p.next()
+			var args []expr
+			for !p.isOperator(")") {
+				arg, err := p.parseExpr(0)
+				if err != nil {
+					return nil, trace.Wrap(err)
+				}
+				args = append(args, arg)
+				if !p.isOperator(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expectOperator(")"); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return callExpr{name: t.value, args: args}, nil
+		}
+		return identExpr(t.value), nil
+	case tokenOperator:
+		if t.value == "*" {
+			// star selects all tags, e.g. in groupBy(*)
+			return literalExpr{value: starLiteral{}}, nil
+		}
+		if t.value == "(" {
+			e, err := p.parseExpr(0)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if err := p.expectOperator(")"); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return e, nil
+		}
+	}
+	return nil, trace.BadParameter("line %v: unexpected %v", t.line, t)
+}
+
+// parseDuration parses a TICKscript duration literal, which in addition
+// to the Go duration units supports days (d) and weeks (w)
+func parseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil {
+				return 0, trace.Wrap(err)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	duration, err := time.ParseDuration(s)
+	return duration, trace.Wrap(err)
+}
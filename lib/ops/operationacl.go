@@ -0,0 +1,191 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/schema"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// OperationAction checks access to the operation of the specified type
+// in the specified cluster.
+// labels optionally specify the labels of the node the operation is targeting
+func (o *OperatorACL) OperationAction(clusterName, operationType string, labels map[string]string) error {
+	verb, err := OperationVerb(operationType)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operationRule(storage.KindOperation, verb, clusterName, operationType, labels)
+}
+
+// PlanAction checks access to the specified plan action for the operation
+// of the specified type in the specified cluster.
+// labels optionally specify the labels of the node the plan phase is targeting
+func (o *OperatorACL) PlanAction(clusterName, operationType, action string, labels map[string]string) error {
+	return o.operationRule(storage.KindOperationPlan, action, clusterName, operationType, labels)
+}
+
+func (o *OperatorACL) operationRule(kind, verb, clusterName, operationType string, labels map[string]string) error {
+	resource := storage.NewOperationResource(clusterName, operationType, labels)
+	return o.checker.CheckAccessToRule(o.resourceContext(resource), defaults.Namespace,
+		kind, verb, false)
+}
+
+// planChangeAction checks access to the plan action that results in the specified plan change
+func (o *OperatorACL) planChangeAction(key SiteOperationKey, change storage.PlanChange) error {
+	operation, err := o.operator.GetSiteOperation(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var phase *storage.OperationPhase
+	plan, err := o.operator.GetOperationPlan(key)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	if plan != nil {
+		phase = findPhase(plan.Phases, change.PhaseID)
+	}
+	var state string
+	var labels map[string]string
+	if phase != nil {
+		state = phase.GetState()
+		if phase.Data != nil && phase.Data.Server != nil {
+			labels = serverLabels(*phase.Data.Server)
+		}
+	}
+	var errors []error
+	for _, action := range PlanChangeActions(state, change.NewState) {
+		err := o.PlanAction(key.SiteDomain, operation.Type, action, labels)
+		if err == nil {
+			return nil
+		}
+		errors = append(errors, err)
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// expandLabels returns the labels of the node to be added with the specified request
+func expandLabels(req CreateSiteExpandOperationRequest) map[string]string {
+	if len(req.Servers) != 1 {
+		return nil
+	}
+	for role := range req.Servers {
+		return map[string]string{AppRole: role}
+	}
+	return nil
+}
+
+// shrinkLabels returns the labels of the node to be removed with the specified request
+func (o *OperatorACL) shrinkLabels(req CreateSiteShrinkOperationRequest) map[string]string {
+	if len(req.Servers) == 0 {
+		return nil
+	}
+	cluster, err := o.operator.GetSiteByDomain(req.SiteDomain)
+	if err != nil {
+		return nil
+	}
+	server, err := cluster.ClusterState.FindServer(req.Servers[0])
+	if err != nil {
+		return nil
+	}
+	return serverLabels(*server)
+}
+
+// operationLabels returns the labels of the node the specified operation
+// is targeting if the operation has a single node
+func (o *OperatorACL) operationLabels(key SiteOperationKey) map[string]string {
+	operation, err := o.operator.GetSiteOperation(key)
+	if err != nil || len(operation.Servers) != 1 {
+		return nil
+	}
+	return serverLabels(operation.Servers[0])
+}
+
+// OperationVerb returns the verb that controls access to the operation
+// of the specified type
+func OperationVerb(operationType string) (string, error) {
+	switch operationType {
+	case OperationInstall:
+		return storage.VerbInstall, nil
+	case OperationUninstall:
+		return storage.VerbUninstall, nil
+	case OperationExpand:
+		return storage.VerbExpand, nil
+	case OperationShrink:
+		return storage.VerbShrink, nil
+	case OperationUpdate:
+		return storage.VerbUpgrade, nil
+	case OperationGarbageCollect:
+		return storage.VerbGarbageCollect, nil
+	case OperationUpdateConfig, OperationUpdateRuntimeEnviron:
+		return storage.VerbConfigure, nil
+	}
+	return "", trace.BadParameter("unsupported operation type %q", operationType)
+}
+
+// PlanChangeActions returns the plan actions either of which permits
+// the phase to move from the current into the new state.
+//
+// Moving a completed phase into progress starts the rollback while moving
+// an unstarted or rolled back phase into progress starts the execution.
+// A failed or an in-progress phase can be either executed again or rolled back.
+// The final states of the phase conclude the action that has been started before
+func PlanChangeActions(currentState, newState string) []string {
+	switch newState {
+	case storage.OperationPhaseStateRolledBack:
+		return []string{storage.VerbRollback}
+	case storage.OperationPhaseStateInProgress:
+		switch currentState {
+		case storage.OperationPhaseStateCompleted:
+			return []string{storage.VerbRollback}
+		case storage.OperationPhaseStateFailed, storage.OperationPhaseStateInProgress:
+			return []string{storage.VerbExecute, storage.VerbRollback}
+		}
+		return []string{storage.VerbExecute}
+	}
+	return []string{storage.VerbExecute, storage.VerbRollback}
+}
+
+// findPhase returns the phase with the specified ID from the
+// specified list of phases and their subphases
+func findPhase(phases []storage.OperationPhase, phaseID string) *storage.OperationPhase {
+	for i, phase := range phases {
+		if phase.ID == phaseID {
+			return &phases[i]
+		}
+		if found := findPhase(phase.Phases, phaseID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// serverLabels returns the labels of the specified server that can be
+// used to restrict access to the operations targeting the server.
+// The labels are the same as those of the corresponding teleport node
+func serverLabels(server storage.Server) map[string]string {
+	return map[string]string{
+		AdvertiseIP:             server.AdvertiseIP,
+		Hostname:                server.Hostname,
+		AppRole:                 server.Role,
+		InstanceType:            server.InstanceType,
+		schema.ServiceLabelRole: server.ClusterRole,
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ops
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/users"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/trace"
+	check "gopkg.in/check.v1"
+)
+
+type OperationACLSuite struct{}
+
+var _ = check.Suite(&OperationACLSuite{})
+
+func (s *OperationACLSuite) TestOperationAccess(c *check.C) {
+	acl := newTestACL(c, teleservices.Rule{
+		Resources: []string{storage.KindOperation},
+		Verbs:     []string{storage.VerbExpand, storage.VerbShrink},
+		Where:     `equals(resource.metadata.name, "prod") && equals(resource.metadata.labels["app-role"], "worker")`,
+	}, teleservices.Rule{
+		Resources: []string{storage.KindOperationPlan},
+		Verbs:     []string{storage.VerbExecute},
+		Where:     `equals(resource.metadata.name, "prod")`,
+	})
+	worker := map[string]string{AppRole: "worker"}
+	master := map[string]string{AppRole: "master"}
+
+	c.Assert(acl.OperationAction("prod", OperationExpand, worker), check.IsNil)
+	c.Assert(acl.OperationAction("prod", OperationShrink, worker), check.IsNil)
+	assertAccessDenied(c, acl.OperationAction("prod", OperationExpand, master))
+	assertAccessDenied(c, acl.OperationAction("prod", OperationExpand, nil))
+	assertAccessDenied(c, acl.OperationAction("dev", OperationExpand, worker))
+	assertAccessDenied(c, acl.OperationAction("prod", OperationUpdate, worker))
+	assertAccessDenied(c, acl.OperationAction("prod", OperationGarbageCollect, worker))
+
+	c.Assert(acl.PlanAction("prod", OperationUpdate, storage.VerbExecute, nil), check.IsNil)
+	assertAccessDenied(c, acl.PlanAction("prod", OperationUpdate, storage.VerbRollback, nil))
+	assertAccessDenied(c, acl.PlanAction("prod", OperationUpdate, storage.VerbComplete, nil))
+	assertAccessDenied(c, acl.PlanAction("dev", OperationUpdate, storage.VerbExecute, nil))
+}
+
+func (s *OperationACLSuite) TestOperationVerbs(c *check.C) {
+	for operationType, verb := range map[string]string{
+		OperationInstall:              storage.VerbInstall,
+		OperationUninstall:            storage.VerbUninstall,
+		OperationExpand:               storage.VerbExpand,
+		OperationShrink:               storage.VerbShrink,
+		OperationUpdate:               storage.VerbUpgrade,
+		OperationGarbageCollect:       storage.VerbGarbageCollect,
+		OperationUpdateConfig:         storage.VerbConfigure,
+		OperationUpdateRuntimeEnviron: storage.VerbConfigure,
+	} {
+		actual, err := OperationVerb(operationType)
+		c.Assert(err, check.IsNil)
+		c.Assert(actual, check.Equals, verb, check.Commentf(operationType))
+	}
+	_, err := OperationVerb("operation_unknown")
+	c.Assert(trace.IsBadParameter(err), check.Equals, true)
+}
+
+func (s *OperationACLSuite) TestPlanChangeActions(c *check.C) {
+	var testCases = []struct {
+		current, new string
+		actions      []string
+	}{
+		{
+			current: storage.OperationPhaseStateUnstarted,
+			new:     storage.OperationPhaseStateInProgress,
+			actions: []string{storage.VerbExecute},
+		},
+		{
+			current: storage.OperationPhaseStateCompleted,
+			new:     storage.OperationPhaseStateInProgress,
+			actions: []string{storage.VerbRollback},
+		},
+		{
+			current: storage.OperationPhaseStateFailed,
+			new:     storage.OperationPhaseStateInProgress,
+			actions: []string{storage.VerbExecute, storage.VerbRollback},
+		},
+		{
+			current: storage.OperationPhaseStateInProgress,
+			new:     storage.OperationPhaseStateRolledBack,
+			actions: []string{storage.VerbRollback},
+		},
+		{
+			current: storage.OperationPhaseStateInProgress,
+			new:     storage.OperationPhaseStateCompleted,
+			actions: []string{storage.VerbExecute, storage.VerbRollback},
+		},
+	}
+	for _, tc := range testCases {
+		c.Assert(PlanChangeActions(tc.current, tc.new), check.DeepEquals, tc.actions,
+			check.Commentf("%v -> %v", tc.current, tc.new))
+	}
+}
+
+func newTestACL(c *check.C, rules ...teleservices.Rule) *OperatorACL {
+	role, err := users.NewSystemRole("test", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Namespaces: []string{teleservices.Wildcard},
+			Rules:      rules,
+		},
+	})
+	c.Assert(err, check.IsNil)
+	return &OperatorACL{checker: teleservices.NewRoleSet(role)}
+}
+
+func assertAccessDenied(c *check.C, err error) {
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true, check.Commentf("%v", err))
+}
@@ -445,6 +445,9 @@ func (o *OperatorACL) CreateSiteInstallOperation(ctx context.Context, req Create
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.SiteDomain, OperationInstall, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateSiteInstallOperation(ctx, req)
 }
 
@@ -452,6 +455,9 @@ func (o *OperatorACL) ResumeShrink(key SiteKey) (*SiteOperationKey, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(key.SiteDomain, OperationShrink, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.ResumeShrink(key)
 }
 
@@ -459,6 +465,9 @@ func (o *OperatorACL) CreateSiteExpandOperation(ctx context.Context, req CreateS
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.SiteDomain, OperationExpand, expandLabels(req)); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateSiteExpandOperation(ctx, req)
 }
 
@@ -466,6 +475,9 @@ func (o *OperatorACL) CreateSiteShrinkOperation(ctx context.Context, req CreateS
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.SiteDomain, OperationShrink, o.shrinkLabels(req)); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateSiteShrinkOperation(ctx, req)
 }
 
@@ -473,6 +485,9 @@ func (o *OperatorACL) CreateSiteAppUpdateOperation(ctx context.Context, req Crea
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.SiteDomain, OperationUpdate, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateSiteAppUpdateOperation(ctx, req)
 }
 
@@ -487,6 +502,9 @@ func (o *OperatorACL) SiteInstallOperationStart(key SiteOperationKey) error {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := o.OperationAction(key.SiteDomain, OperationInstall, nil); err != nil {
+		return trace.Wrap(err)
+	}
 	return o.operator.SiteInstallOperationStart(key)
 }
 
@@ -494,6 +512,9 @@ func (o *OperatorACL) CreateSiteUninstallOperation(ctx context.Context, req Crea
 	if err := o.ClusterAction(req.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.SiteDomain, OperationUninstall, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateSiteUninstallOperation(ctx, req)
 }
 
@@ -502,6 +523,9 @@ func (o *OperatorACL) CreateClusterGarbageCollectOperation(ctx context.Context,
 	if err := o.ClusterAction(req.ClusterName, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.ClusterName, OperationGarbageCollect, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateClusterGarbageCollectOperation(ctx, req)
 }
 
@@ -510,6 +534,9 @@ func (o *OperatorACL) CreateUpdateEnvarsOperation(ctx context.Context, req Creat
 	if err := o.ClusterAction(req.ClusterKey.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.ClusterKey.SiteDomain, OperationUpdateRuntimeEnviron, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateUpdateEnvarsOperation(ctx, req)
 }
 
@@ -518,6 +545,9 @@ func (o *OperatorACL) CreateUpdateConfigOperation(ctx context.Context, req Creat
 	if err := o.ClusterAction(req.ClusterKey.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := o.OperationAction(req.ClusterKey.SiteDomain, OperationUpdateConfig, nil); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return o.operator.CreateUpdateConfigOperation(ctx, req)
 }
 
@@ -555,6 +585,9 @@ func (o *OperatorACL) SiteExpandOperationStart(key SiteOperationKey) error {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := o.OperationAction(key.SiteDomain, OperationExpand, o.operationLabels(key)); err != nil {
+		return trace.Wrap(err)
+	}
 	return o.operator.SiteExpandOperationStart(key)
 }
 
@@ -630,6 +663,15 @@ func (o *OperatorACL) SetOperationState(key SiteOperationKey, req SetOperationSt
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if req.State == OperationStateCompleted || req.State == OperationStateFailed {
+		operation, err := o.operator.GetSiteOperation(key)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := o.PlanAction(key.SiteDomain, operation.Type, storage.VerbComplete, nil); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return o.operator.SetOperationState(key, req)
 }
 
@@ -646,6 +688,9 @@ func (o *OperatorACL) CreateOperationPlanChange(key SiteOperationKey, change sto
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	if err := o.planChangeAction(key, change); err != nil {
+		return trace.Wrap(err)
+	}
 	return o.operator.CreateOperationPlanChange(key, change)
 }
 
@@ -725,6 +770,32 @@ func (o *OperatorACL) DeleteLogForwarder(ctx context.Context, key SiteKey, forwa
 	return o.operator.DeleteLogForwarder(ctx, key, forwarderName)
 }
 
+// GetAuditSinks returns the configured audit sinks
+func (o *OperatorACL) GetAuditSinks(key SiteKey) ([]storage.AuditSink, error) {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAuditSink, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetAuditSinks(key)
+}
+
+// UpsertAuditSink creates or updates the audit sink
+func (o *OperatorACL) UpsertAuditSink(ctx context.Context, key SiteKey, sink storage.AuditSink) error {
+	for _, verb := range []string{teleservices.VerbCreate, teleservices.VerbUpdate} {
+		if err := o.ClusterAction(key.SiteDomain, storage.KindAuditSink, verb); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return o.operator.UpsertAuditSink(ctx, key, sink)
+}
+
+// DeleteAuditSink deletes the audit sink with the specified name
+func (o *OperatorACL) DeleteAuditSink(ctx context.Context, key SiteKey, name string) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAuditSink, teleservices.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.DeleteAuditSink(ctx, key, name)
+}
+
 func (o *OperatorACL) GetRetentionPolicies(key SiteKey) ([]monitoring.RetentionPolicy, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindCluster, teleservices.VerbRead); err != nil {
 		return nil, trace.Wrap(err)
@@ -802,6 +873,23 @@ func (o *OperatorACL) DeleteAlertTarget(ctx context.Context, key SiteKey) error
 	return o.operator.DeleteAlertTarget(ctx, key)
 }
 
+// NotifyAlertTarget delivers the alert to the cluster monitoring alert target.
+// Alerts are posted by Kapacitor with the API key of the alert notifier
+// which is only allowed to deliver alerts
+func (o *OperatorACL) NotifyAlertTarget(ctx context.Context, key SiteKey, alert monitoring.Alert) error {
+	if err := o.ClusterAction(key.SiteDomain, storage.KindAlertTarget, storage.VerbNotify); err != nil {
+		return trace.Wrap(err)
+	}
+	return o.operator.NotifyAlertTarget(ctx, key, alert)
+}
+
+func (o *OperatorACL) TestAlert(ctx context.Context, req TestAlertRequest) (*monitoring.AlertEvaluation, error) {
+	if err := o.ClusterAction(req.SiteDomain, storage.KindAlert, teleservices.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.TestAlert(ctx, req)
+}
+
 // GetClusterEnvironmentVariables retrieves the cluster runtime environment variables
 func (o *OperatorACL) GetClusterEnvironmentVariables(key SiteKey) (storage.EnvironmentVariables, error) {
 	if err := o.ClusterAction(key.SiteDomain, storage.KindRuntimeEnvironment, teleservices.VerbList); err != nil {
@@ -1072,6 +1160,14 @@ func (o *OperatorACL) EmitAuditEvent(ctx context.Context, req AuditEventRequest)
 	return o.operator.EmitAuditEvent(ctx, req)
 }
 
+// GetAuditEvents returns the audit log events matching the query.
+func (o *OperatorACL) GetAuditEvents(ctx context.Context, query AuditLogQuery) (*AuditLogResult, error) {
+	if err := o.ClusterAction(query.SiteDomain, teleservices.KindEvent, teleservices.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return o.operator.GetAuditEvents(ctx, query)
+}
+
 // CreateUserInvite creates a new invite token for a user.
 func (o *OperatorACL) CreateUserInvite(ctx context.Context, req CreateUserInviteRequest) (*storage.UserToken, error) {
 	if err := o.ClusterAction(req.SiteDomain, storage.KindInvite, teleservices.VerbCreate); err != nil {
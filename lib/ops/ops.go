@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -118,6 +119,7 @@ type Operator interface {
 	Operations
 	Validation
 	LogForwarders
+	AuditSinks
 	Monitoring
 	SMTP
 	Endpoints
@@ -1847,6 +1849,17 @@ type SetOperationStateRequest struct {
 	Progress *ProgressEntry `json:"progress,omitempty"`
 }
 
+// AuditSinks defines the interface to manage the forwarding
+// of audit events to external systems
+type AuditSinks interface {
+	// GetAuditSinks returns the configured audit sinks
+	GetAuditSinks(key SiteKey) ([]storage.AuditSink, error)
+	// UpsertAuditSink creates or updates the audit sink
+	UpsertAuditSink(ctx context.Context, key SiteKey, sink storage.AuditSink) error
+	// DeleteAuditSink deletes the audit sink with the specified name
+	DeleteAuditSink(ctx context.Context, key SiteKey, name string) error
+}
+
 // LogForwarders defines the interface to manage log forwarders
 type LogForwarders interface {
 	// GetLogForwarders retrieves the list of active log forwarders
@@ -1887,6 +1900,42 @@ type Monitoring interface {
 	UpdateAlertTarget(context.Context, SiteKey, storage.AlertTarget) error
 	// DeleteAlertTarget deletes the monitoring alert target
 	DeleteAlertTarget(context.Context, SiteKey) error
+	// NotifyAlertTarget delivers the alert to the webhook, Slack or PagerDuty alert target
+	NotifyAlertTarget(context.Context, SiteKey, monitoring.Alert) error
+	// TestAlert evaluates the monitoring alert against the historical metrics
+	TestAlert(context.Context, TestAlertRequest) (*monitoring.AlertEvaluation, error)
+}
+
+// TestAlertRequest is a request to evaluate a monitoring alert
+// against the historical metrics
+type TestAlertRequest struct {
+	// AccountID is the cluster account ID
+	AccountID string `json:"account_id"`
+	// SiteDomain is the cluster domain name
+	SiteDomain string `json:"site_domain"`
+	// Name is the name of the alert to evaluate
+	Name string `json:"name"`
+	// Since specifies the evaluated time period ending now
+	Since time.Duration `json:"since"`
+}
+
+// Check makes sure the request is correct
+func (r TestAlertRequest) Check() error {
+	if r.Name == "" {
+		return trace.BadParameter("missing alert name")
+	}
+	if r.Since <= 0 {
+		return trace.BadParameter("since must be > 0")
+	}
+	return nil
+}
+
+// SiteKey returns the cluster key from this request
+func (r TestAlertRequest) SiteKey() SiteKey {
+	return SiteKey{
+		AccountID:  r.AccountID,
+		SiteDomain: r.SiteDomain,
+	}
 }
 
 // UpdateRetentionPolicyRequest is a request to update retention policy
@@ -2032,8 +2081,99 @@ func (r AuditEventRequest) String() string {
 	return fmt.Sprintf("AuditEvent(Event=%v, Fields=%v)", r.Event, r.Fields)
 }
 
-// Audit provides interface for emitting audit log events.
+// AuditLogQuery defines the filter for the audit log events.
+type AuditLogQuery struct {
+	// SiteKey is the ID of the cluster the request is for.
+	SiteKey
+	// From is the start of the time range to search.
+	From time.Time `json:"from"`
+	// To is the end of the time range to search.
+	To time.Time `json:"to"`
+	// User optionally limits the events to those triggered by the user.
+	User string `json:"user,omitempty"`
+	// Code optionally limits the events to those with the event code.
+	Code string `json:"code,omitempty"`
+	// Limit is the maximum number of events to return.
+	Limit int `json:"limit,omitempty"`
+}
+
+// AuditLogResult is the result of the audit log query.
+type AuditLogResult struct {
+	// Events lists the matching events, newest first.
+	Events []events.EventFields `json:"events"`
+	// Truncated is set if the audit log capped the number of events
+	// searched, so events in the time range might be missing from
+	// the result. Narrowing the time range does not help if a single
+	// audit log file holds more events than the cap.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Check validates the audit log query.
+func (r *AuditLogQuery) Check() error {
+	if err := r.SiteKey.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	if r.From.IsZero() || r.To.IsZero() {
+		return trace.BadParameter("missing audit log time range")
+	}
+	if r.To.Before(r.From) {
+		return trace.BadParameter("invalid audit log time range: %v - %v", r.From, r.To)
+	}
+	if r.Limit < 0 {
+		return trace.BadParameter("invalid audit log limit: %v", r.Limit)
+	}
+	return nil
+}
+
+// Values returns the query encoded as URL query parameters
+func (r AuditLogQuery) Values() url.Values {
+	values := url.Values{
+		"from": []string{r.From.UTC().Format(time.RFC3339Nano)},
+		"to":   []string{r.To.UTC().Format(time.RFC3339Nano)},
+	}
+	if r.User != "" {
+		values.Set("user", r.User)
+	}
+	if r.Code != "" {
+		values.Set("code", r.Code)
+	}
+	if r.Limit != 0 {
+		values.Set("limit", strconv.Itoa(r.Limit))
+	}
+	return values
+}
+
+// ParseAuditLogQuery returns the audit log query for the specified cluster
+// from the provided URL query parameters
+func ParseAuditLogQuery(key SiteKey, values url.Values) (*AuditLogQuery, error) {
+	query := AuditLogQuery{
+		SiteKey: key,
+		User:    values.Get("user"),
+		Code:    values.Get("code"),
+	}
+	var err error
+	if query.From, err = time.Parse(time.RFC3339Nano, values.Get("from")); err != nil {
+		return nil, trace.BadParameter("invalid start of the time range %q", values.Get("from"))
+	}
+	if query.To, err = time.Parse(time.RFC3339Nano, values.Get("to")); err != nil {
+		return nil, trace.BadParameter("invalid end of the time range %q", values.Get("to"))
+	}
+	if limit := values.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, trace.BadParameter("invalid limit %q", limit)
+		}
+	}
+	if err := query.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &query, nil
+}
+
+// Audit provides interface for emitting and querying audit log events.
 type Audit interface {
 	// EmitAuditEvent saves the provided event in the audit log.
 	EmitAuditEvent(context.Context, AuditEventRequest) error
+	// GetAuditEvents returns the audit log events matching the query,
+	// newest first
+	GetAuditEvents(context.Context, AuditLogQuery) (*AuditLogResult, error)
 }
@@ -988,6 +988,47 @@ func (c *Client) DeleteLogForwarder(ctx context.Context, key ops.SiteKey, forwar
 	return trace.Wrap(err)
 }
 
+// GetAuditSinks returns the configured audit sinks
+func (c *Client) GetAuditSinks(key ops.SiteKey) ([]storage.AuditSink, error) {
+	out, err := c.Get(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "audit", "sinks"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sinks := make([]storage.AuditSink, len(items))
+	for i, raw := range items {
+		sink, err := storage.UnmarshalAuditSink(raw)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sinks[i] = sink
+	}
+	return sinks, nil
+}
+
+// UpsertAuditSink creates or updates the audit sink
+func (c *Client) UpsertAuditSink(ctx context.Context, key ops.SiteKey, sink storage.AuditSink) error {
+	bytes, err := storage.MarshalAuditSink(sink)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(
+		c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "audit", "sinks"),
+		&UpsertResourceRawReq{
+			Resource: bytes,
+		})
+	return trace.Wrap(err)
+}
+
+// DeleteAuditSink deletes the audit sink with the specified name
+func (c *Client) DeleteAuditSink(ctx context.Context, key ops.SiteKey, name string) error {
+	_, err := c.Delete(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "audit", "sinks", name))
+	return trace.Wrap(err)
+}
+
 // GetRetentionPolicies returns a list of retention policies for the site
 func (c *Client) GetRetentionPolicies(key ops.SiteKey) ([]monitoring.RetentionPolicy, error) {
 	response, err := c.Get(c.Endpoint(
@@ -1131,6 +1172,27 @@ func (c *Client) DeleteAlertTarget(ctx context.Context, key ops.SiteKey) error {
 	return trace.Wrap(err)
 }
 
+// TestAlert evaluates the monitoring alert against the historical metrics
+func (c *Client) TestAlert(ctx context.Context, req ops.TestAlertRequest) (*monitoring.AlertEvaluation, error) {
+	response, err := c.PostJSON(c.Endpoint("accounts", req.AccountID, "sites", req.SiteDomain,
+		"monitoring", "alerts", req.Name, "test"), req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var result monitoring.AlertEvaluation
+	if err := json.Unmarshal(response.Bytes(), &result); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &result, nil
+}
+
+// NotifyAlertTarget delivers the alert to the cluster monitoring alert target
+func (c *Client) NotifyAlertTarget(ctx context.Context, key ops.SiteKey, alert monitoring.Alert) error {
+	_, err := c.PostJSON(c.Endpoint("accounts", key.AccountID, "sites", key.SiteDomain, "monitoring", "alert-targets", "notify"),
+		alert)
+	return trace.Wrap(err)
+}
+
 // GetClusterEnvironmentVariables retrieves the cluster runtime environment variables
 func (c *Client) GetClusterEnvironmentVariables(key ops.SiteKey) (storage.EnvironmentVariables, error) {
 	response, err := c.Get(c.Endpoint(
@@ -1563,6 +1625,19 @@ func (c *Client) EmitAuditEvent(ctx context.Context, req ops.AuditEventRequest)
 	return nil
 }
 
+// GetAuditEvents returns the audit log events matching the query.
+func (c *Client) GetAuditEvents(ctx context.Context, query ops.AuditLogQuery) (*ops.AuditLogResult, error) {
+	out, err := c.Get(c.Endpoint("accounts", query.AccountID, "sites", query.SiteDomain, "events"), query.Values())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var result ops.AuditLogResult
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &result, nil
+}
+
 // PostJSON issues HTTP POST request to the server with the provided JSON data
 func (c *Client) PostJSON(endpoint string, data interface{}) (*roundtrip.Response, error) {
 	return telehttplib.ConvertResponse(c.Client.PostJSON(context.TODO(), endpoint, data))
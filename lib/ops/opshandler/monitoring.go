@@ -21,6 +21,7 @@ import (
 	"net/http"
 
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/ops/opsclient"
 	"github.com/gravitational/gravity/lib/storage"
 
@@ -141,6 +142,32 @@ func (h *WebHandler) deleteAlert(w http.ResponseWriter, r *http.Request, p httpr
 	return nil
 }
 
+/* testAlert evaluates the monitoring alert against the historical metrics
+
+     POST /portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alerts/:name/test
+
+   Input: ops.TestAlertRequest
+
+   Success Response:
+
+     monitoring.AlertEvaluation
+*/
+func (h *WebHandler) testAlert(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req ops.TestAlertRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	req.AccountID = p.ByName("account_id")
+	req.SiteDomain = p.ByName("site_domain")
+	req.Name = p.ByName("name")
+	result, err := context.Operator.TestAlert(r.Context(), req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, result)
+	return nil
+}
+
 /* getAlertTargets returns a list of monitoring alert targets for the cluster
 
      GET /portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets
@@ -210,3 +237,28 @@ func (h *WebHandler) deleteAlertTarget(w http.ResponseWriter, r *http.Request, p
 	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("alert target deleted"))
 	return nil
 }
+
+/* notifyAlertTarget delivers the alert to cluster's monitoring alert target
+
+     POST /portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets/notify
+
+   Input: monitoring.Alert
+
+   Success Response:
+
+     {
+       "message": "alert delivered"
+     }
+*/
+func (h *WebHandler) notifyAlertTarget(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var alert monitoring.Alert
+	if err := telehttplib.ReadJSON(r, &alert); err != nil {
+		return trace.Wrap(err)
+	}
+	err := context.Operator.NotifyAlertTarget(r.Context(), siteKey(p), alert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("alert delivered"))
+	return nil
+}
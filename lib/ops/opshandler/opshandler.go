@@ -223,6 +223,11 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/logs/forwarders/:name", h.needsAuth(h.updateLogForwarder))
 	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/logs/forwarders/:name", h.needsAuth(h.deleteLogForwarder))
 
+	// audit sinks
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks", h.needsAuth(h.getAuditSinks))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks", h.needsAuth(h.upsertAuditSink))
+	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks/:name", h.needsAuth(h.deleteAuditSink))
+
 	// smtp
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/smtp", h.needsAuth(h.getSMTPConfig))
 	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/smtp", h.needsAuth(h.updateSMTPConfig))
@@ -237,6 +242,8 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets", h.needsAuth(h.getAlertTargets))
 	h.PUT("/portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets", h.needsAuth(h.updateAlertTarget))
 	h.DELETE("/portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets", h.needsAuth(h.deleteAlertTarget))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alert-targets/notify", h.needsAuth(h.notifyAlertTarget))
+	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/monitoring/alerts/:name/test", h.needsAuth(h.testAlert))
 
 	// environment variables
 	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/envars", h.needsAuth(h.getEnvironmentVariables))
@@ -299,6 +306,8 @@ func NewWebHandler(cfg WebHandlerConfig) (*WebHandler, error) {
 	// audit log events
 	h.POST("/portal/v1/accounts/:account_id/sites/:site_domain/events",
 		h.needsAuth(h.emitAuditEvent))
+	h.GET("/portal/v1/accounts/:account_id/sites/:site_domain/events",
+		h.needsAuth(h.getAuditEvents))
 
 	return h, nil
 }
@@ -1489,6 +1498,11 @@ func (h *WebHandler) createOperationPlan(w http.ResponseWriter, r *http.Request,
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// access is checked against the operation in the URL
+	err = checkOperationKey(siteOperationKey(p), plan.ClusterName, plan.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	err = context.Operator.CreateOperationPlan(siteOperationKey(p), plan)
 	if err != nil {
 		return trace.Wrap(err)
@@ -1509,6 +1523,11 @@ func (h *WebHandler) createOperationPlanChange(w http.ResponseWriter, r *http.Re
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// access is checked against the operation in the URL
+	err = checkOperationKey(siteOperationKey(p), change.ClusterName, change.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
 	err = context.Operator.CreateOperationPlanChange(siteOperationKey(p), change)
 	if err != nil {
 		return trace.Wrap(err)
@@ -2079,6 +2098,68 @@ func (h *WebHandler) deleteLogForwarder(w http.ResponseWriter, r *http.Request,
 	return nil
 }
 
+/* getAuditSinks returns the configured audit sinks
+
+   GET /portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks
+
+Success response:
+
+   []storage.AuditSink
+*/
+func (h *WebHandler) getAuditSinks(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	sinks, err := context.Operator.GetAuditSinks(siteKey(p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	items := make([]json.RawMessage, len(sinks))
+	for i, sink := range sinks {
+		bytes, err := storage.MarshalAuditSink(sink)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		items[i] = bytes
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, items)
+	return nil
+}
+
+/* upsertAuditSink creates or updates the audit sink
+
+   POST /portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks
+*/
+func (h *WebHandler) upsertAuditSink(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	var req opsclient.UpsertResourceRawReq
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	sink, err := storage.UnmarshalAuditSink(req.Resource)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if req.TTL != 0 {
+		sink.SetTTL(clockwork.NewRealClock(), req.TTL)
+	}
+	err = context.Operator.UpsertAuditSink(r.Context(), siteKey(p), sink)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("audit sink saved"))
+	return nil
+}
+
+/* deleteAuditSink deletes the audit sink
+
+   DELETE /portal/v1/accounts/:account_id/sites/:site_domain/audit/sinks/:name
+*/
+func (h *WebHandler) deleteAuditSink(w http.ResponseWriter, r *http.Request, p httprouter.Params, context *HandlerContext) error {
+	err := context.Operator.DeleteAuditSink(r.Context(), siteKey(p), p.ByName("name"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, statusOK("audit sink deleted"))
+	return nil
+}
+
 /* getSMTPConfig returns the cluster SMTP configuration
 
      GET /portal/v1/accounts/:account_id/sites/:site_domain/smtp
@@ -2289,6 +2370,27 @@ func (h *WebHandler) emitAuditEvent(w http.ResponseWriter, r *http.Request, p ht
 	return nil
 }
 
+/* getAuditEvents returns the audit log events matching the query.
+
+     GET /portal/v1/accounts/:account_id/sites/:site_domain/events?from=<time>&to=<time>&user=<user>&code=<code>&limit=<limit>
+
+   Success response:
+
+     {"events": [{"event": "operation.started", "code": "G0001I", ...}, ...], "truncated": false}
+*/
+func (h *WebHandler) getAuditEvents(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *HandlerContext) error {
+	query, err := ops.ParseAuditLogQuery(siteKey(p), r.URL.Query())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	result, err := ctx.Operator.GetAuditEvents(r.Context(), *query)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, result)
+	return nil
+}
+
 func (s *WebHandler) wrap(fn func(w http.ResponseWriter, r *http.Request, p httprouter.Params) error) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		if err := fn(w, r, p); err != nil {
@@ -2469,6 +2571,16 @@ func siteOperationKey(p httprouter.Params) ops.SiteOperationKey {
 	}
 }
 
+// checkOperationKey makes sure that the specified cluster name and operation ID
+// refer to the operation with the specified key
+func checkOperationKey(key ops.SiteOperationKey, clusterName, operationID string) error {
+	if clusterName != key.SiteDomain || operationID != key.OperationID {
+		return trace.BadParameter("operation %v/%v does not match %v/%v",
+			clusterName, operationID, key.SiteDomain, key.OperationID)
+	}
+	return nil
+}
+
 func siteKey(p httprouter.Params) ops.SiteKey {
 	return ops.SiteKey{
 		AccountID:  p[0].Value,
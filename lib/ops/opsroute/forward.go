@@ -576,6 +576,33 @@ func (r *Router) DeleteLogForwarder(ctx context.Context, key ops.SiteKey, forwar
 	return client.DeleteLogForwarder(ctx, key, forwarderName)
 }
 
+// GetAuditSinks returns the configured audit sinks
+func (r *Router) GetAuditSinks(key ops.SiteKey) ([]storage.AuditSink, error) {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.GetAuditSinks(key)
+}
+
+// UpsertAuditSink creates or updates the audit sink
+func (r *Router) UpsertAuditSink(ctx context.Context, key ops.SiteKey, sink storage.AuditSink) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.UpsertAuditSink(ctx, key, sink)
+}
+
+// DeleteAuditSink deletes the audit sink with the specified name
+func (r *Router) DeleteAuditSink(ctx context.Context, key ops.SiteKey, name string) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.DeleteAuditSink(ctx, key, name)
+}
+
 // GetRetentionPolicies returns a list of retention policies for the site
 func (r *Router) GetRetentionPolicies(key ops.SiteKey) ([]monitoring.RetentionPolicy, error) {
 	client, err := r.RemoteClient(key.SiteDomain)
@@ -675,6 +702,24 @@ func (r *Router) DeleteAlertTarget(ctx context.Context, key ops.SiteKey) error {
 	return client.DeleteAlertTarget(ctx, key)
 }
 
+// NotifyAlertTarget delivers the alert to the cluster monitoring alert target
+func (r *Router) NotifyAlertTarget(ctx context.Context, key ops.SiteKey, alert monitoring.Alert) error {
+	client, err := r.RemoteClient(key.SiteDomain)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return client.NotifyAlertTarget(ctx, key, alert)
+}
+
+// TestAlert evaluates the monitoring alert against the historical metrics
+func (r *Router) TestAlert(ctx context.Context, req ops.TestAlertRequest) (*monitoring.AlertEvaluation, error) {
+	client, err := r.RemoteClient(req.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return client.TestAlert(ctx, req)
+}
+
 // GetClusterEnvironmentVariables retrieves the cluster runtime environment variables
 func (r *Router) GetClusterEnvironmentVariables(key ops.SiteKey) (storage.EnvironmentVariables, error) {
 	client, err := r.RemoteClient(key.SiteDomain)
@@ -905,6 +950,11 @@ func (r *Router) EmitAuditEvent(ctx context.Context, req ops.AuditEventRequest)
 	return r.Local.EmitAuditEvent(ctx, req)
 }
 
+// GetAuditEvents returns the audit log events matching the query.
+func (r *Router) GetAuditEvents(ctx context.Context, query ops.AuditLogQuery) (*ops.AuditLogResult, error) {
+	return r.Local.GetAuditEvents(ctx, query)
+}
+
 // CreateUserInvite creates a new invite token for a user.
 func (r *Router) CreateUserInvite(ctx context.Context, req ops.CreateUserInviteRequest) (*storage.UserToken, error) {
 	client, err := r.PickClient(req.SiteDomain)
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
@@ -138,6 +139,19 @@ func (r *AgentService) GetServerInfos(ctx context.Context, key ops.SiteOperation
 
 		infos = append(infos, *info)
 	}
+
+	metrics := make(map[string]*pb.AgentMetrics)
+	for _, peer := range group.GetMetrics(ctx) {
+		if peer.Error != nil {
+			// Metrics are informational and not reported by older agents
+			r.WithError(peer.Error).Warnf("Failed to query agent metrics on %v.", peer.Addr)
+			continue
+		}
+		metrics[peer.Addr] = peer.Metrics
+	}
+	for i, p := range peers {
+		infos[i].Metrics = metrics[p.Addr()]
+	}
 	return infos, nil
 }
 
@@ -330,7 +344,7 @@ func (r *AgentPeerStore) NewPeer(ctx netcontext.Context, req pb.PeerJoinRequest,
 	}
 
 	if req.Config.KeyValues[ops.AgentMode] != ops.AgentModeShrink {
-		errCheck := r.validatePeer(ctx, group, info, req, token.SiteDomain)
+		errCheck := r.validatePeer(ctx, group, info, req, token)
 		if errCheck != nil {
 			return trace.Wrap(errCheck)
 		}
@@ -393,11 +407,16 @@ func (r *AgentPeerStore) authenticatePeer(token string) (*storage.ProvisioningTo
 }
 
 func (r *AgentPeerStore) validatePeer(ctx context.Context, group *agentGroup, info storage.System,
-	req pb.PeerJoinRequest, clusterName string) error {
+	req pb.PeerJoinRequest, token *storage.ProvisioningToken) error {
+	clusterName := token.SiteDomain
 	if err := r.checkHostname(ctx, group, req.Addr, info.GetHostname(), clusterName); err != nil {
 		return trace.Wrap(err)
 	}
 
+	if err := r.checkClusterSpec(req, token); err != nil {
+		return trace.Wrap(err)
+	}
+
 	if err := r.checkLicense(ctx, int(group.NumPeers()), clusterName, info); err != nil {
 		return trace.Wrap(err)
 	}
@@ -434,6 +453,36 @@ func (r *AgentPeerStore) checkHostname(ctx context.Context, group *agentGroup, a
 	return nil
 }
 
+// checkClusterSpec makes sure the peer is listed in the cluster spec
+// of the operation and is configured as the spec requires.
+// Operations without a cluster spec accept any peer
+func (r *AgentPeerStore) checkClusterSpec(req pb.PeerJoinRequest, token *storage.ProvisioningToken) error {
+	if token.OperationID == "" {
+		return nil
+	}
+	operation, err := r.backend.GetSiteOperation(token.SiteDomain, token.OperationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if operation.InstallExpand == nil || len(operation.InstallExpand.Vars.OnPrem.Nodes) == 0 {
+		return nil
+	}
+	addr := req.Config.AdvertiseAddr
+	if addr == "" {
+		addr = req.Addr
+	}
+	node := checks.FindNodeSpec(operation.InstallExpand.Vars.OnPrem.Nodes, addr)
+	if node == nil {
+		return trace.AccessDenied("node %v is not in the cluster spec", utils.ExtractHost(addr))
+	}
+	if diffs := checks.CompareNodeSpec(*node, *req.Config); len(diffs) != 0 {
+		return trace.AccessDenied("node %v does not match the cluster spec: %v",
+			node.AdvertiseAddr, strings.Join(diffs, ", "))
+	}
+	r.Debugf("Verified %v against the cluster spec.", node)
+	return nil
+}
+
 func (r *AgentPeerStore) checkLicense(ctx context.Context, numPeers int, clusterName string, info storage.System) error {
 	cluster, err := r.backend.GetSite(clusterName)
 	if err != nil {
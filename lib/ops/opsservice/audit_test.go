@@ -0,0 +1,218 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	opsevents "github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/check.v1"
+)
+
+type AuditSuite struct {
+	clock    clockwork.FakeClock
+	auditLog *events.FileLog
+	operator *Operator
+}
+
+var _ = check.Suite(&AuditSuite{})
+
+func (s *AuditSuite) SetUpTest(c *check.C) {
+	s.clock = clockwork.NewFakeClockAt(time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC))
+	var err error
+	s.auditLog, err = events.NewFileLog(events.FileLogConfig{
+		Dir:   c.MkDir(),
+		Clock: s.clock,
+	})
+	c.Assert(err, check.IsNil)
+	backend, err := keyval.NewBolt(keyval.BoltConfig{Path: filepath.Join(c.MkDir(), "bolt.db")})
+	c.Assert(err, check.IsNil)
+	_, err = backend.CreateSite(storage.Site{
+		AccountID: defaults.SystemAccountID,
+		Domain:    "example.com",
+		Local:     true,
+		Created:   s.clock.Now(),
+	})
+	c.Assert(err, check.IsNil)
+	s.operator = &Operator{
+		cfg:         Config{AuditLog: s.auditLog, Backend: backend},
+		FieldLogger: logrus.WithField(trace.Component, "audit"),
+	}
+}
+
+func (s *AuditSuite) TearDownTest(c *check.C) {
+	s.auditLog.Close()
+}
+
+func (s *AuditSuite) TestFiltersEvents(c *check.C) {
+	s.emit(c, opsevents.OperationInstallStart, "alice", "example.com")
+	s.emit(c, opsevents.OperationExpandStart, "bob", "example.com")
+	s.emit(c, opsevents.OperationExpandStart, "alice", "")
+	s.emit(c, opsevents.OperationExpandStart, "alice", "other.com")
+
+	var testCases = []struct {
+		comment string
+		query   ops.AuditLogQuery
+		codes   []string
+		users   []string
+	}{
+		{
+			comment: "all events of the cluster, newest first",
+			query:   s.query(),
+			codes: []string{
+				opsevents.OperationExpandStartCode,
+				opsevents.OperationExpandStartCode,
+				opsevents.OperationInstallStartCode,
+			},
+			users: []string{"alice", "bob", "alice"},
+		},
+		{
+			comment: "events of the user",
+			query:   s.query(func(q *ops.AuditLogQuery) { q.User = "bob" }),
+			codes:   []string{opsevents.OperationExpandStartCode},
+			users:   []string{"bob"},
+		},
+		{
+			comment: "events with the code",
+			query:   s.query(func(q *ops.AuditLogQuery) { q.Code = opsevents.OperationInstallStartCode }),
+			codes:   []string{opsevents.OperationInstallStartCode},
+			users:   []string{"alice"},
+		},
+		{
+			comment: "limited number of events",
+			query:   s.query(func(q *ops.AuditLogQuery) { q.Limit = 1 }),
+			codes:   []string{opsevents.OperationExpandStartCode},
+			users:   []string{"alice"},
+		},
+		{
+			comment: "events of a remote cluster",
+			query:   s.query(func(q *ops.AuditLogQuery) { q.SiteDomain = "other.com" }),
+			codes:   []string{opsevents.OperationExpandStartCode},
+			users:   []string{"alice"},
+		},
+		{
+			comment: "events outside the time range",
+			query: s.query(func(q *ops.AuditLogQuery) {
+				q.From = s.clock.Now().Add(time.Hour)
+				q.To = q.From.Add(time.Hour)
+			}),
+		},
+	}
+	for _, tc := range testCases {
+		comment := check.Commentf(tc.comment)
+		result, err := s.operator.GetAuditEvents(context.TODO(), tc.query)
+		c.Assert(err, check.IsNil, comment)
+		c.Assert(result.Truncated, check.Equals, false, comment)
+		var codes, users []string
+		for _, event := range result.Events {
+			codes = append(codes, event.GetString(events.EventCode))
+			users = append(users, event.GetString(events.EventUser))
+		}
+		c.Assert(codes, check.DeepEquals, tc.codes, comment)
+		c.Assert(users, check.DeepEquals, tc.users, comment)
+	}
+}
+
+func (s *AuditSuite) TestReturnsNewestEvents(c *check.C) {
+	from := s.clock.Now()
+	s.emit(c, opsevents.OperationInstallStart, "alice", "example.com")
+	s.clock.Advance(48 * time.Hour)
+	s.emit(c, opsevents.OperationExpandStart, "bob", "example.com")
+	s.emit(c, opsevents.OperationShrinkStart, "bob", "example.com")
+
+	result, err := s.operator.GetAuditEvents(context.TODO(), s.query(func(q *ops.AuditLogQuery) {
+		q.From = from
+		q.Limit = 2
+	}))
+	c.Assert(err, check.IsNil)
+	var codes []string
+	for _, event := range result.Events {
+		codes = append(codes, event.GetString(events.EventCode))
+	}
+	c.Assert(codes, check.DeepEquals, []string{
+		opsevents.OperationShrinkStartCode,
+		opsevents.OperationExpandStartCode,
+	})
+
+	result, err = s.operator.GetAuditEvents(context.TODO(), s.query(func(q *ops.AuditLogQuery) {
+		q.From = from
+	}))
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Events, check.HasLen, 3)
+}
+
+func (s *AuditSuite) TestMarksTruncatedResults(c *check.C) {
+	for i := 0; i < teledefaults.EventsMaxIterationLimit; i++ {
+		c.Assert(s.auditLog.EmitAuditEvent(opsevents.OperationExpandStart, events.EventFields{
+			opsevents.FieldUser:    "alice",
+			opsevents.FieldCluster: "example.com",
+		}), check.IsNil)
+	}
+	s.clock.Advance(time.Minute)
+
+	result, err := s.operator.GetAuditEvents(context.TODO(), s.query())
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Truncated, check.Equals, true)
+	c.Assert(result.Events, check.HasLen, defaults.AuditEventsLimit)
+}
+
+func (s *AuditSuite) TestValidatesQuery(c *check.C) {
+	query := s.query(func(q *ops.AuditLogQuery) { q.From, q.To = q.To, q.From })
+	_, err := s.operator.GetAuditEvents(context.TODO(), query)
+	c.Assert(err, check.NotNil)
+
+	parsed, err := ops.ParseAuditLogQuery(query.SiteKey, s.query().Values())
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed.From.Equal(s.query().From), check.Equals, true)
+	c.Assert(parsed.To.Equal(s.query().To), check.Equals, true)
+}
+
+func (s *AuditSuite) emit(c *check.C, event events.Event, user, cluster string) {
+	fields := events.EventFields{opsevents.FieldUser: user}
+	if cluster != "" {
+		fields[opsevents.FieldCluster] = cluster
+	}
+	c.Assert(s.auditLog.EmitAuditEvent(event, fields), check.IsNil)
+	s.clock.Advance(time.Minute)
+}
+
+func (s *AuditSuite) query(opts ...func(*ops.AuditLogQuery)) ops.AuditLogQuery {
+	query := ops.AuditLogQuery{
+		SiteKey: ops.SiteKey{
+			AccountID:  defaults.SystemAccountID,
+			SiteDomain: "example.com",
+		},
+		From: s.clock.Now().Add(-time.Hour),
+		To:   s.clock.Now(),
+	}
+	for _, opt := range opts {
+		opt(&query)
+	}
+	return query
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/auditsink"
+	"github.com/gravitational/gravity/lib/ops/events"
+	"github.com/gravitational/gravity/lib/storage"
+
+	teleevents "github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+// AuditForwarder forwards audit events to the configured audit sinks
+type AuditForwarder interface {
+	// Emit queues the specified event for delivery to the audit sinks
+	Emit(teleevents.Event, teleevents.EventFields)
+	// Reload reloads the audit sink configuration
+	Reload() error
+}
+
+// GetAuditSinks returns the audit sinks configured for the cluster
+func (o *Operator) GetAuditSinks(key ops.SiteKey) ([]storage.AuditSink, error) {
+	sinks, err := o.backend().GetAuditSinks(key.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sinks, nil
+}
+
+// UpsertAuditSink creates or updates the audit sink of the cluster
+func (o *Operator) UpsertAuditSink(ctx context.Context, key ops.SiteKey, config storage.AuditSink) error {
+	// make sure the sink can be created before saving the configuration
+	sink, err := auditsink.New(config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sink.Close()
+	if err := o.backend().UpsertAuditSink(key.SiteDomain, config); err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.AuditSinkCreated, events.Fields{
+		events.FieldName: config.GetName(),
+	})
+	o.reloadAuditSinks()
+	return nil
+}
+
+// DeleteAuditSink deletes the audit sink of the cluster with the specified name
+func (o *Operator) DeleteAuditSink(ctx context.Context, key ops.SiteKey, name string) error {
+	if err := o.backend().DeleteAuditSink(key.SiteDomain, name); err != nil {
+		return trace.Wrap(err)
+	}
+	events.Emit(ctx, o, events.AuditSinkDeleted, events.Fields{
+		events.FieldName: name,
+	})
+	o.reloadAuditSinks()
+	return nil
+}
+
+// reloadAuditSinks applies the changes to the audit sinks without waiting
+// for the periodic reload.
+// Other controllers pick up the changes during their periodic reload
+func (o *Operator) reloadAuditSinks() {
+	if o.cfg.AuditForwarder == nil {
+		return
+	}
+	if err := o.cfg.AuditForwarder.Reload(); err != nil {
+		o.WithError(err).Warn("Failed to reload audit sinks.")
+	}
+}
@@ -18,9 +18,12 @@ package opsservice
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/events"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
@@ -81,6 +84,9 @@ func (o *Operator) GetAlerts(key ops.SiteKey) (alerts []storage.Alert, err error
 			errors = append(errors, err)
 			continue
 		}
+		if formula, ok := config.Data[constants.AlertFormulaKey]; ok {
+			alert.Spec.Formula = formula
+		}
 		alerts = append(alerts, alert)
 	}
 
@@ -93,21 +99,24 @@ func (o *Operator) GetAlerts(key ops.SiteKey) (alerts []storage.Alert, err error
 
 // UpdateAlert updates the specified monitoring alert
 func (o *Operator) UpdateAlert(ctx context.Context, key ops.SiteKey, alert storage.Alert) error {
+	if err := o.checkAlertsSupported(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := o.checkAlertFormula(alert); err != nil {
+		return trace.Wrap(err)
+	}
+
 	client, err := o.GetKubeClient()
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	data, err := storage.MarshalAlert(alert)
+	handler, err := o.updateAlertHandler(key)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	labels := map[string]string{
-		constants.MonitoringType: constants.MonitoringTypeAlert,
-	}
-	err = updateConfigMap(client.Core().ConfigMaps(defaults.MonitoringNamespace),
-		alert.GetName(), defaults.MonitoringNamespace, string(data), labels)
+	err = upsertAlert(client.Core().ConfigMaps(defaults.MonitoringNamespace), alert, handler)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -159,6 +168,66 @@ func (o *Operator) DeleteAlert(ctx context.Context, key ops.SiteKey, name string
 
 }
 
+// TestAlert evaluates the monitoring alert against the historical metrics
+func (o *Operator) TestAlert(ctx context.Context, req ops.TestAlertRequest) (*monitoring.AlertEvaluation, error) {
+	if err := req.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	alerts, err := o.GetAlerts(req.SiteKey())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, alert := range alerts {
+		if alert.GetName() != req.Name {
+			continue
+		}
+		formula, err := monitoring.ParseFormula(alert.GetFormula())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		now := o.cfg.Clock.UtcNow()
+		return formula.Evaluate(o.cfg.Monitoring, now.Add(-req.Since), now)
+	}
+	return nil, trace.NotFound("alert %q not found", req.Name)
+}
+
+// checkAlertsSupported returns trace.NotImplemented if the metrics backend
+// does not support alerts. Alert formulas are TICKscripts executed by Kapacitor
+// which reads the metrics from InfluxDB
+func (o *Operator) checkAlertsSupported() error {
+	if o.cfg.Monitoring == nil || o.cfg.Monitoring.GetType() != constants.MonitoringBackendPrometheus {
+		return nil
+	}
+	return trace.NotImplemented("alerts are executed by Kapacitor against InfluxDB and "+
+		"are not supported with the %v metrics backend, use Prometheus alerting rules instead",
+		o.cfg.Monitoring.GetType())
+}
+
+// checkAlertFormula validates the formula of the alert and warns if
+// the measurements it references do not exist in the metrics database yet.
+// The measurements are not verified if the metrics database is unavailable
+// or is not InfluxDB which is the database Kapacitor reads the measurements from
+func (o *Operator) checkAlertFormula(alert storage.Alert) error {
+	formula, err := monitoring.ParseFormula(alert.GetFormula())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if o.cfg.Monitoring == nil || o.cfg.Monitoring.GetType() != constants.MonitoringBackendInfluxDB {
+		return nil
+	}
+	measurements, err := o.cfg.Monitoring.GetMeasurements()
+	if err != nil {
+		o.WithError(err).Warn("Failed to retrieve measurements, will not verify alert formula measurements.")
+		return nil
+	}
+	if err := formula.CheckMeasurements(measurements); err != nil {
+		// The measurements might not have been written yet,
+		// e.g. if the alert is created before the first data point
+		o.WithError(err).Warnf("Alert %v might never fire.", alert.GetName())
+	}
+	return nil
+}
+
 // GetAlertTargets returns a list of configured monitoring alert targets
 func (o *Operator) GetAlertTargets(key ops.SiteKey) (targets []storage.AlertTarget, err error) {
 	client, err := o.GetKubeClient()
@@ -204,6 +273,10 @@ func (o *Operator) UpdateAlertTarget(ctx context.Context, key ops.SiteKey, targe
 		return trace.Wrap(err)
 	}
 
+	if err := o.updateAlertHandlers(key); err != nil {
+		return trace.Wrap(err)
+	}
+
 	events.Emit(ctx, o, events.AlertTargetCreated)
 	return nil
 
@@ -224,10 +297,192 @@ func (o *Operator) DeleteAlertTarget(ctx context.Context, key ops.SiteKey) error
 		return trace.Wrap(err)
 	}
 
+	if err := o.updateAlertHandlers(key); err != nil {
+		return trace.Wrap(err)
+	}
+
 	events.Emit(ctx, o, events.AlertTargetDeleted)
 	return nil
 }
 
+// NotifyAlertTarget delivers the alert to the cluster monitoring alert target.
+// Email alert targets are handled by Kapacitor and are not supported
+func (o *Operator) NotifyAlertTarget(ctx context.Context, key ops.SiteKey, alert monitoring.Alert) error {
+	targets, err := o.GetAlertTargets(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, target := range targets {
+		if err := target.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		err := monitoring.Notify(ctx, nil, target, alert)
+		if err != nil {
+			o.WithError(err).Warnf("Failed to deliver alert %v to %v.", alert.ID, target.GetType())
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// getAlertHandler returns the Kapacitor alert handler that forwards alerts
+// to this operator for delivery to the webhook, Slack or PagerDuty alert target.
+// Returns nil if the cluster has no alert target or if the alert target
+// is email which Kapacitor delivers itself
+func (o *Operator) getAlertHandler(key ops.SiteKey) (*monitoring.AlertHandler, error) {
+	targets, err := o.GetAlertTargets(key)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
+	if len(targets) == 0 || targets[0].GetType() == storage.AlertTargetEmail {
+		return nil, nil
+	}
+	// Kapacitor authenticates with the API key of the agent user
+	// that is only allowed to deliver alerts
+	notifier, err := o.cfg.Users.CreateAlertNotifier(key.SiteDomain, storage.NewUser(
+		storage.ClusterAlertNotifier(key.SiteDomain), storage.UserSpecV2{
+			AccountID: key.AccountID,
+		}))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	keys, err := o.cfg.Users.GetAPIKeys(notifier.GetName())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if len(keys) == 0 {
+		return nil, trace.NotFound("no API keys found for user %v", notifier.GetName())
+	}
+	return &monitoring.AlertHandler{
+		Endpoint: monitoring.AlertHandlerEndpoint,
+		URL: fmt.Sprintf("%v/portal/v1/accounts/%v/sites/%v/monitoring/alert-targets/notify",
+			defaults.GravityServiceURL, key.AccountID, key.SiteDomain),
+		Headers: map[string]string{
+			"Authorization": fmt.Sprintf("%v %v", httplib.AuthBearer, keys[0].Token),
+		},
+	}, nil
+}
+
+// updateAlertHandler updates the Kapacitor HTTP post endpoint configuration
+// with the alert handler for the current alert target and returns the handler
+func (o *Operator) updateAlertHandler(key ops.SiteKey) (*monitoring.AlertHandler, error) {
+	handler, err := o.getAlertHandler(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	secrets := client.Core().Secrets(defaults.MonitoringNamespace)
+	if handler == nil {
+		err = rigging.ConvertError(secrets.Delete(constants.AlertHandlerSecret, nil))
+		if err != nil && !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+		return nil, nil
+	}
+	if err := upsertAlertHandler(secrets, *handler); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return handler, nil
+}
+
+// updateAlertHandlers updates all alerts with the alert handler
+// for the current alert target
+func (o *Operator) updateAlertHandlers(key ops.SiteKey) error {
+	handler, err := o.updateAlertHandler(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	alerts, err := o.GetAlerts(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	client, err := o.GetKubeClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, alert := range alerts {
+		err := upsertAlert(client.Core().ConfigMaps(defaults.MonitoringNamespace), alert, handler)
+		if err != nil && trace.IsBadParameter(err) {
+			// do not let an alert that cannot be parsed, e.g. one created
+			// before formulas were validated, block alert target updates
+			o.WithError(err).Warnf("Failed to update alert handler of alert %v.", alert.GetName())
+			continue
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// upsertAlertHandler creates or updates the Secret with the Kapacitor
+// HTTP post endpoint configuration of the specified alert handler.
+// The Secret keeps the credentials out of the alert formulas
+func upsertAlertHandler(client corev1.SecretInterface, handler monitoring.AlertHandler) error {
+	data, err := json.Marshal(handler)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.AlertHandlerSecret,
+			Namespace: defaults.MonitoringNamespace,
+			Labels: map[string]string{
+				constants.MonitoringType: constants.MonitoringTypeAlertHandler,
+			},
+		},
+		Data: map[string][]byte{
+			constants.ResourceSpecKey: data,
+		},
+		Type: v1.SecretTypeOpaque,
+	}
+	_, err = client.Create(secret)
+	err = rigging.ConvertError(err)
+	if err == nil {
+		return nil
+	}
+	if !trace.IsAlreadyExists(err) {
+		return trace.Wrap(err)
+	}
+	_, err = client.Update(secret)
+	return trace.Wrap(rigging.ConvertError(err))
+}
+
+// upsertAlert creates or updates the config map of the specified alert.
+// If handler is not nil, the alert formula that Kapacitor executes
+// posts the alerts to the handler, and the formula as specified by the user
+// is saved separately
+func upsertAlert(client corev1.ConfigMapInterface, alert storage.Alert, handler *monitoring.AlertHandler) error {
+	data := make(map[string]string)
+	if handler != nil {
+		formula, err := monitoring.RenderFormula(alert.GetFormula(), *handler)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		alertV2, ok := alert.(*storage.AlertV2)
+		if !ok {
+			return trace.BadParameter("unsupported alert type %T", alert)
+		}
+		data[constants.AlertFormulaKey] = alert.GetFormula()
+		rendered := *alertV2
+		rendered.Spec.Formula = formula
+		alert = &rendered
+	}
+	spec, err := storage.MarshalAlert(alert)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data[constants.ResourceSpecKey] = string(spec)
+	labels := map[string]string{
+		constants.MonitoringType: constants.MonitoringTypeAlert,
+	}
+	return trace.Wrap(updateConfigMapData(client, alert.GetName(),
+		defaults.MonitoringNamespace, data, labels))
+}
+
 func getConfigMap(client corev1.ConfigMapInterface, name string) (string, error) {
 	config, err := client.Get(name, metav1.GetOptions{})
 	if err != nil {
@@ -243,15 +498,19 @@ func getConfigMap(client corev1.ConfigMapInterface, name string) (string, error)
 }
 
 func updateConfigMap(client corev1.ConfigMapInterface, name, namespace, data string, labels map[string]string) error {
+	return updateConfigMapData(client, name, namespace, map[string]string{
+		constants.ResourceSpecKey: data,
+	}, labels)
+}
+
+func updateConfigMapData(client corev1.ConfigMapInterface, name, namespace string, data, labels map[string]string) error {
 	config := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
 			Labels:    labels,
 		},
-		Data: map[string]string{
-			constants.ResourceSpecKey: data,
-		},
+		Data: data,
 	}
 
 	_, err := client.Create(config)
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsservice
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/monitoring"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type MonitoringSuite struct{}
+
+var _ = check.Suite(&MonitoringSuite{})
+
+func (s *MonitoringSuite) TestRejectsAlertsWithPrometheus(c *check.C) {
+	backend, err := monitoring.NewPrometheus(monitoring.PrometheusConfig{})
+	c.Assert(err, check.IsNil)
+	operator := &Operator{cfg: Config{Monitoring: backend}}
+	alert := &storage.AlertV2{
+		Kind:    storage.KindAlert,
+		Version: "v2",
+		Spec: storage.AlertSpecV2{
+			Formula: `stream|from().measurement('cpu')|alert().crit(lambda: "value" > 90)`,
+		},
+	}
+	alert.Metadata.Name = "cpu"
+	err = operator.UpdateAlert(context.TODO(), ops.SiteKey{
+		AccountID:  defaults.SystemAccountID,
+		SiteDomain: "example.com",
+	}, alert)
+	c.Assert(trace.IsNotImplemented(err), check.Equals, true, check.Commentf("%v", err))
+}
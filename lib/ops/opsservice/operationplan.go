@@ -26,7 +26,11 @@ import (
 
 // CreateOperationPlan saves the provided operation plan
 func (o *Operator) CreateOperationPlan(key ops.SiteOperationKey, plan storage.OperationPlan) error {
-	_, err := o.backend().CreateOperationPlan(plan)
+	err := fsm.CheckPlan(plan)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = o.backend().CreateOperationPlan(plan)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -48,6 +49,7 @@ import (
 	"github.com/docker/docker/pkg/archive"
 	"github.com/gravitational/configure/cstrings"
 	"github.com/gravitational/license/authority"
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
 	teleevents "github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/reversetunnel"
 	teleservices "github.com/gravitational/teleport/lib/services"
@@ -134,6 +136,9 @@ type Config struct {
 	// AuditLog is used to submit events to the audit log
 	AuditLog teleevents.IAuditLog
 
+	// AuditForwarder optionally forwards the audit events to the configured audit sinks
+	AuditForwarder AuditForwarder
+
 	// GetHelmClient is a factory method for creating a Helm client.
 	GetHelmClient helm.GetClientFunc
 }
@@ -1398,12 +1403,104 @@ func (o *Operator) EmitAuditEvent(ctx context.Context, req ops.AuditEventRequest
 	}
 	o.Infof("%s.", req)
 	err = o.cfg.AuditLog.EmitAuditEvent(req.Event, req.Fields)
+	if o.cfg.AuditForwarder != nil {
+		o.cfg.AuditForwarder.Emit(req.Event, req.Fields)
+	}
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// GetAuditEvents returns the audit log events matching the query, newest first.
+// The result is marked truncated if the search of any day reached the limit
+// of the audit log, since the newest events of that day are then missing
+func (o *Operator) GetAuditEvents(ctx context.Context, query ops.AuditLogQuery) (*ops.AuditLogResult, error) {
+	if err := query.Check(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaults.AuditEventsLimit
+	}
+	local, err := o.isLocalCluster(query.SiteDomain)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// the audit log returns the oldest events first and caps the number of
+	// events returned by a single search, so the time range is searched a day
+	// at a time starting with the newest events until enough events are found
+	var result ops.AuditLogResult
+	to := query.To.UTC()
+	for !to.Before(query.From) && len(result.Events) < limit {
+		from := to.Truncate(24 * time.Hour)
+		if from.Before(query.From) {
+			from = query.From.UTC()
+		}
+		found, err := o.cfg.AuditLog.SearchEvents(from, to, "",
+			teledefaults.EventsMaxIterationLimit)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if len(found) >= teledefaults.EventsMaxIterationLimit {
+			o.Warnf("Audit log search between %v and %v returned the maximum of %v events, newer events were skipped.",
+				from, to, len(found))
+			result.Truncated = true
+		}
+		var matched []teleevents.EventFields
+		for _, event := range found {
+			// the audit log selects the events by file so the search
+			// can return events outside of the requested range
+			timestamp := event.GetTimestamp()
+			if timestamp.Before(from) || timestamp.After(to) {
+				continue
+			}
+			if matchesAuditQuery(query, local, event) {
+				matched = append(matched, event)
+			}
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			return matched[i].GetTimestamp().After(matched[j].GetTimestamp())
+		})
+		result.Events = append(result.Events, matched...)
+		to = from.Add(-time.Nanosecond)
+	}
+	if len(result.Events) > limit {
+		result.Events = result.Events[:limit]
+	}
+	return &result, nil
+}
+
+// isLocalCluster returns true if the specified cluster is the cluster
+// this operator is running in
+func (o *Operator) isLocalCluster(clusterName string) (bool, error) {
+	cluster, err := o.backend().GetLocalSite(defaults.SystemAccountID)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return false, nil
+		}
+		return false, trace.Wrap(err)
+	}
+	return cluster.Domain == clusterName, nil
+}
+
+// matchesAuditQuery returns true if the specified event satisfies the query.
+// Events recorded without the cluster name can only have been emitted by
+// the local cluster so they only match queries for the local cluster
+func matchesAuditQuery(query ops.AuditLogQuery, local bool, event teleevents.EventFields) bool {
+	if query.User != "" && event.GetString(events.FieldUser) != query.User {
+		return false
+	}
+	if query.Code != "" && event.GetString(teleevents.EventCode) != query.Code {
+		return false
+	}
+	cluster := event.GetString(events.FieldCluster)
+	if cluster == "" {
+		return local
+	}
+	return cluster == query.SiteDomain
+}
+
 func (o *Operator) openSite(key ops.SiteKey) (*site, error) {
 	site, err := o.backend().GetSite(key.SiteDomain)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
 	"time"
 
@@ -329,6 +330,62 @@ func (c *logForwardersCollection) WriteYAML(w io.Writer) error {
 	return utils.WriteYAML(c, w)
 }
 
+type auditSinkCollection struct {
+	sinks []storage.AuditSink
+}
+
+// Resources returns the resources collection in the generic format
+func (c *auditSinkCollection) Resources() (resources []teleservices.UnknownResource, err error) {
+	for _, item := range c.sinks {
+		resource, err := utils.ToUnknownResource(item)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+// WriteText serializes collection in human-friendly text format
+func (c *auditSinkCollection) WriteText(w io.Writer) error {
+	t := goterm.NewTable(0, 10, 5, ' ', 0)
+	common.PrintTableHeader(t, []string{"Name", "Type", "Destination"})
+	for _, sink := range c.sinks {
+		var sinkType, destination string
+		switch {
+		case sink.GetSyslog() != nil:
+			sinkType = fmt.Sprintf("syslog/%v", sink.GetSyslog().Protocol)
+			destination = sink.GetSyslog().Address
+		case sink.GetFile() != nil:
+			sinkType = "file"
+			destination = sink.GetFile().Path
+		case sink.GetWebhook() != nil:
+			sinkType = "webhook"
+			destination = sink.GetWebhook().URL
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\n", sink.GetName(), sinkType, destination)
+	}
+	_, err := io.WriteString(w, t.String())
+	return trace.Wrap(err)
+}
+
+// WriteJSON serializes collection into JSON format
+func (c *auditSinkCollection) WriteJSON(w io.Writer) error {
+	return utils.WriteJSON(c, w)
+}
+
+func (c *auditSinkCollection) ToMarshal() interface{} {
+	if len(c.sinks) == 1 {
+		return c.sinks[0]
+	}
+	return c.sinks
+}
+
+// WriteYAML serializes collection into YAML format
+func (c *auditSinkCollection) WriteYAML(w io.Writer) error {
+	return utils.WriteYAML(c, w)
+}
+
 type tlsKeyPairCollection struct {
 	keyPairs []storage.TLSKeyPair
 }
@@ -507,9 +564,9 @@ type alertCollection []storage.Alert
 // WriteText serializes collection in human-friendly text format
 func (r alertTargetCollection) WriteText(w io.Writer) error {
 	t := goterm.NewTable(0, 10, 5, ' ', 0)
-	common.PrintTableHeader(t, []string{"Email"})
+	common.PrintTableHeader(t, []string{"Type", "Recipient"})
 	for _, target := range r {
-		fmt.Fprintf(t, "%v\n", target.GetEmail())
+		fmt.Fprintf(t, "%v\t%v\n", target.GetType(), alertTargetRecipient(target))
 	}
 	_, err := io.WriteString(w, t.String())
 	return trace.Wrap(err)
@@ -546,6 +603,33 @@ func (c alertTargetCollection) Resources() (resources []teleservices.UnknownReso
 
 type alertTargetCollection []storage.AlertTarget
 
+// alertTargetRecipient describes the recipient of the alert target
+// without exposing the credentials
+func alertTargetRecipient(target storage.AlertTarget) string {
+	switch target.GetType() {
+	case storage.AlertTargetWebhook:
+		return redactURL(target.GetWebhook().URL)
+	case storage.AlertTargetSlack:
+		if target.GetSlack().Channel != "" {
+			return target.GetSlack().Channel
+		}
+		return redactURL(target.GetSlack().URL)
+	case storage.AlertTargetPagerDuty:
+		return redactURL(target.GetPagerDuty().URL)
+	}
+	return target.GetEmail()
+}
+
+// redactURL returns the URL without the path and query
+// which may contain tokens
+func redactURL(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return addr
+	}
+	return fmt.Sprintf("%v://%v", u.Scheme, u.Host)
+}
+
 type authGatewayCollection struct {
 	item storage.AuthGateway
 }
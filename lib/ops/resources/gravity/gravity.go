@@ -22,6 +22,7 @@ import (
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/ops/resources"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/clusterconfig"
@@ -150,6 +151,16 @@ func (r *Resources) Create(ctx context.Context, req resources.CreateRequest) err
 			}
 		}
 		r.Printf("Created log forwarder %q\n", forwarder.GetName())
+	case storage.KindAuditSink:
+		sink, err := storage.UnmarshalAuditSink(req.Resource.Raw)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		err = r.Operator.UpsertAuditSink(ctx, r.cluster.Key(), sink)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		r.Printf("Created audit sink %q\n", sink.GetName())
 	case storage.KindTLSKeyPair:
 		keyPair, err := storage.UnmarshalTLSKeyPair(req.Resource.Raw)
 		if err != nil {
@@ -207,18 +218,6 @@ func (r *Resources) Create(ctx context.Context, req resources.CreateRequest) err
 		}
 		r.Printf("Updated monitoring alert %q\n", alert.GetName())
 	case storage.KindAlertTarget:
-		// Alert recipient can be created only if SMTP settings
-		// are present, otherwise it will result into invalid
-		// Alertmanager configuration.
-		if _, err := r.Operator.GetSMTPConfig(r.cluster.Key()); err != nil {
-			if trace.IsNotFound(err) {
-				return trace.BadParameter("alert target can only " +
-					"be created when cluster SMTP settings " +
-					"are configured, please create SMTP " +
-					"resource first: https://gravitational.com/gravity/docs/cluster/#configuring-monitoring")
-			}
-			return trace.Wrap(err)
-		}
 		target, err := storage.UnmarshalAlertTarget(req.Resource.Raw)
 		if err != nil {
 			return trace.Wrap(err)
@@ -226,6 +225,20 @@ func (r *Resources) Create(ctx context.Context, req resources.CreateRequest) err
 		if err := target.CheckAndSetDefaults(); err != nil {
 			return trace.Wrap(err)
 		}
+		// Email alert recipient can be created only if SMTP settings
+		// are present, otherwise it will result into invalid
+		// Alertmanager configuration.
+		if target.GetType() == storage.AlertTargetEmail {
+			if _, err := r.Operator.GetSMTPConfig(r.cluster.Key()); err != nil {
+				if trace.IsNotFound(err) {
+					return trace.BadParameter("email alert target can only " +
+						"be created when cluster SMTP settings " +
+						"are configured, please create SMTP " +
+						"resource first: https://gravitational.com/gravity/docs/cluster/#configuring-monitoring")
+				}
+				return trace.Wrap(err)
+			}
+		}
 		err = r.Operator.UpdateAlertTarget(ctx, r.cluster.Key(), target)
 		if err != nil {
 			return trace.Wrap(err)
@@ -326,6 +339,21 @@ func (r *Resources) GetCollection(req resources.ListRequest) (resources.Collecti
 			filtered = forwarders
 		}
 		return &logForwardersCollection{logForwarders: filtered}, nil
+	case storage.KindAuditSink:
+		sinks, err := r.Operator.GetAuditSinks(r.cluster.Key())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var filtered []storage.AuditSink
+		for _, sink := range sinks {
+			if req.Name == "" || sink.GetName() == req.Name {
+				filtered = append(filtered, sink)
+			}
+		}
+		if req.Name != "" && len(filtered) == 0 {
+			return nil, trace.NotFound("audit sink %q is not found", req.Name)
+		}
+		return &auditSinkCollection{sinks: filtered}, nil
 	case storage.KindTLSKeyPair:
 		// always ignore name parameter for tls key pairs, because there is only one
 		cert, err := r.Operator.GetClusterCertificate(r.cluster.Key(), req.WithSecrets)
@@ -447,6 +475,14 @@ func (r *Resources) Remove(ctx context.Context, req resources.RemoveRequest) err
 			return trace.Wrap(err)
 		}
 		r.Printf("Log forwarder %q has been deleted\n", req.Name)
+	case storage.KindAuditSink:
+		if err := r.Operator.DeleteAuditSink(ctx, r.cluster.Key(), req.Name); err != nil {
+			if trace.IsNotFound(err) && req.Force {
+				return nil
+			}
+			return trace.Wrap(err)
+		}
+		r.Printf("Audit sink %q has been deleted\n", req.Name)
 	case storage.KindTLSKeyPair:
 		if err := r.Operator.DeleteClusterCertificate(ctx, r.cluster.Key()); err != nil {
 			if trace.IsNotFound(err) && req.Force {
@@ -463,7 +499,7 @@ func (r *Resources) Remove(ctx context.Context, req resources.RemoveRequest) err
 		if err != nil && !trace.IsNotFound(err) {
 			return trace.Wrap(err)
 		}
-		if len(alertTargets) != 0 {
+		if hasEmailAlertTarget(alertTargets) {
 			return trace.BadParameter("SMTP configuration can " +
 				"only be deleted if there is no alert target, " +
 				"please remove alert target using 'gravity " +
@@ -524,6 +560,8 @@ func Validate(resource storage.UnknownResource) (err error) {
 		_, err = storage.GetTokenMarshaler().UnmarshalToken(resource.Raw)
 	case storage.KindLogForwarder:
 		_, err = storage.GetLogForwarderMarshaler().Unmarshal(resource.Raw)
+	case storage.KindAuditSink:
+		_, err = storage.UnmarshalAuditSink(resource.Raw)
 	case storage.KindTLSKeyPair:
 		_, err = storage.UnmarshalTLSKeyPair(resource.Raw)
 	case teleservices.KindClusterAuthPreference:
@@ -531,7 +569,11 @@ func Validate(resource storage.UnknownResource) (err error) {
 	case storage.KindSMTPConfig:
 		_, err = storage.UnmarshalSMTPConfig(resource.Raw)
 	case storage.KindAlert:
-		_, err = storage.UnmarshalAlert(resource.Raw)
+		var alert storage.Alert
+		alert, err = storage.UnmarshalAlert(resource.Raw)
+		if err == nil {
+			_, err = monitoring.ParseFormula(alert.GetFormula())
+		}
 	case storage.KindAlertTarget:
 		_, err = storage.UnmarshalAlertTarget(resource.Raw)
 	case storage.KindAuthGateway:
@@ -549,3 +591,12 @@ func Validate(resource storage.UnknownResource) (err error) {
 	}
 	return nil
 }
+
+func hasEmailAlertTarget(targets []storage.AlertTarget) bool {
+	for _, target := range targets {
+		if target.GetType() == storage.AlertTargetEmail {
+			return true
+		}
+	}
+	return false
+}
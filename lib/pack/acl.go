@@ -31,6 +31,14 @@ import (
 	"github.com/gravitational/trace"
 )
 
+// CreateChecker is implemented by package services that can check whether
+// a package can be created before its data is available
+type CreateChecker interface {
+	// CheckCreatePackage makes sure the specified package can be created
+	// or, if upsert is set, created or updated
+	CheckCreatePackage(loc loc.Locator, upsert bool) error
+}
+
 func PackagesWithACL(packages PackageService, users users.Users, user storage.User, checker teleservices.AccessChecker) PackageService {
 	return &ACLService{
 		packages: packages,
@@ -126,7 +134,7 @@ func (a *ACLService) GetPackages(repository string) ([]PackageEnvelope, error) {
 
 // CreatePackage creates package and adds it to to the existing repository
 func (a *ACLService) CreatePackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
-	if err := a.repoAction(loc.Repository, teleservices.VerbCreate); err != nil {
+	if err := a.CheckCreatePackage(loc, false); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return a.packages.CreatePackage(loc, data, options...)
@@ -134,15 +142,24 @@ func (a *ACLService) CreatePackage(loc loc.Locator, data io.Reader, options ...P
 
 // UpsertPackage creates package and adds it to to the existing repository
 func (a *ACLService) UpsertPackage(loc loc.Locator, data io.Reader, options ...PackageOption) (*PackageEnvelope, error) {
-	if err := a.repoAction(loc.Repository, teleservices.VerbCreate); err != nil {
-		return nil, trace.Wrap(err)
-	}
-	if err := a.repoAction(loc.Repository, teleservices.VerbUpdate); err != nil {
+	if err := a.CheckCreatePackage(loc, true); err != nil {
 		return nil, trace.Wrap(err)
 	}
 	return a.packages.UpsertPackage(loc, data, options...)
 }
 
+// CheckCreatePackage makes sure the user is allowed to create the specified
+// package or, if upsert is set, to create or update it
+func (a *ACLService) CheckCreatePackage(loc loc.Locator, upsert bool) error {
+	if err := a.repoAction(loc.Repository, teleservices.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if !upsert {
+		return nil
+	}
+	return trace.Wrap(a.repoAction(loc.Repository, teleservices.VerbUpdate))
+}
+
 // DeletePackage deletes package from all repositories
 func (a *ACLService) DeletePackage(loc loc.Locator) error {
 	if err := a.repoAction(loc.Repository, teleservices.VerbDelete); err != nil {
@@ -160,15 +177,58 @@ func (a *ACLService) UpdatePackageLabels(loc loc.Locator, addLabels map[string]s
 
 // Read package opens and returns package contents
 func (a *ACLService) ReadPackage(loc loc.Locator) (*PackageEnvelope, io.ReadCloser, error) {
-	if err := a.repoAction(loc.Repository, teleservices.VerbRead); err != nil {
+	if err := a.readPackageAction(loc); err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
+	return a.packages.ReadPackage(loc)
+}
+
+// GetPackageChunks returns the chunks the data of the specified package consists of
+func (a *ACLService) GetPackageChunks(loc loc.Locator) ([]Chunk, error) {
+	if err := a.readPackageAction(loc); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chunks, ok := a.packages.(ChunkReader)
+	if !ok {
+		return nil, trace.NotImplemented("package service does not store packages as chunks")
+	}
+	return chunks.GetPackageChunks(loc)
+}
+
+// ReadPackageChunk returns the data of the specified chunk of the package
+func (a *ACLService) ReadPackageChunk(loc loc.Locator, hash string) (io.ReadCloser, error) {
+	if err := a.readPackageAction(loc); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	chunks, ok := a.packages.(ChunkReader)
+	if !ok {
+		return nil, trace.NotImplemented("package service does not store packages as chunks")
+	}
+	return chunks.ReadPackageChunk(loc, hash)
+}
+
+// GetDedupStats returns the deduplication statistics
+func (a *ACLService) GetDedupStats() (*DedupStats, error) {
+	if err := a.checker.CheckAccessToRule(a.context(), teledefaults.Namespace, storage.KindRepository, teleservices.VerbList, false); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	reporter, ok := a.packages.(DedupReporter)
+	if !ok {
+		return nil, trace.NotImplemented("package service does not report deduplication statistics")
+	}
+	return reporter.GetDedupStats()
+}
+
+func (a *ACLService) readPackageAction(loc loc.Locator) error {
+	if err := a.repoAction(loc.Repository, teleservices.VerbRead); err != nil {
+		return trace.Wrap(err)
+	}
 	if loc.Name == constants.OpsCenterCAPackage {
 		if err := a.checker.CheckAccessToRule(a.repoContext(loc.Repository), teledefaults.Namespace, storage.KindRepository, storage.VerbReadSecrets, false); err != nil {
-			return nil, nil, trace.Wrap(err)
+			return trace.Wrap(err)
 		}
 	}
-	return a.packages.ReadPackage(loc)
+	return nil
 }
 
 // ReadPackageEnvelope returns package envelope
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"io"
+
+	"github.com/gravitational/gravity/lib/loc"
+)
+
+// Chunk describes a content-defined chunk of package data
+type Chunk struct {
+	// SHA512 is the half SHA512 hash of the chunk data
+	SHA512 string `json:"sha512"`
+	// SizeBytes is the chunk size
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// ChunkReader is implemented by package services that store package
+// data deduplicated as content-defined chunks
+type ChunkReader interface {
+	// GetPackageChunks returns the chunks the data of the specified package
+	// consists of, in order.
+	// Returns trace.NotFound if the package is not stored as chunks
+	GetPackageChunks(loc.Locator) ([]Chunk, error)
+	// ReadPackageChunk returns the data of the chunk with the specified hash
+	// that belongs to the specified package
+	ReadPackageChunk(loc loc.Locator, hash string) (io.ReadCloser, error)
+}
+
+// ChunkWriter is implemented by package services that can assemble
+// packages from content-defined chunks, fetching only the chunks
+// they do not have yet
+type ChunkWriter interface {
+	// CreatePackageFromChunks creates or, if upsert is set, updates the package
+	// described with envelope from the specified chunks.
+	// Missing chunks are retrieved using fetch.
+	// Returns trace.NotImplemented if the service does not store packages as chunks
+	CreatePackageFromChunks(envelope PackageEnvelope, chunks []Chunk, fetch FetchChunkFunc, upsert bool, options ...PackageOption) (*PackageEnvelope, error)
+}
+
+// FetchChunkFunc returns the data of the specified chunk
+type FetchChunkFunc func(Chunk) (io.ReadCloser, error)
+
+// DedupReporter is implemented by package services that report
+// the efficiency of the deduplicating storage mode
+type DedupReporter interface {
+	// GetDedupStats returns the deduplication statistics
+	GetDedupStats() (*DedupStats, error)
+}
+
+// DedupStats describes the efficiency of deduplicating package storage
+type DedupStats struct {
+	// Packages is the number of packages stored as chunks
+	Packages int `json:"packages"`
+	// Chunks is the number of unique chunks
+	Chunks int `json:"chunks"`
+	// LogicalBytes is the total size of packages stored as chunks
+	LogicalBytes int64 `json:"logical_bytes"`
+	// StoredBytes is the total size of unique chunks
+	StoredBytes int64 `json:"stored_bytes"`
+}
+
+// Ratio returns the deduplication ratio, i.e. how many times the total
+// size of the packages exceeds the size of the data actually stored
+func (s DedupStats) Ratio() float64 {
+	if s.StoredBytes == 0 {
+		return 1
+	}
+	return float64(s.LogicalBytes) / float64(s.StoredBytes)
+}
@@ -29,6 +29,12 @@ const (
 	AdvertiseIPLabel = "advertise-ip"
 	// OperationIDLabel contains ID of the operation the package was configured for
 	OperationIDLabel = "operation-id"
+	// ExpiresLabel contains the time the credentials in the package expire
+	// in RFC3339 format
+	ExpiresLabel = "expires"
+	// SignatureLabel contains the base64-encoded signature of the package,
+	// see SignPackage
+	SignatureLabel = "signature"
 
 	// PurposeCA marks the planet certificate authority package
 	PurposeCA = "ca"
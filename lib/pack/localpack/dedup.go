@@ -0,0 +1,555 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localpack
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+
+	"github.com/gravitational/gravity/lib/blob"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// ChunkingConfig defines how package data is split into content-defined
+// chunks in deduplicating storage mode
+type ChunkingConfig struct {
+	// MinSize is the minimum chunk size
+	MinSize int
+	// MaxSize is the maximum chunk size
+	MaxSize int
+	// AverageBits defines the average chunk size as a power of two
+	AverageBits uint
+}
+
+func (c *ChunkingConfig) checkAndSetDefaults() error {
+	if c.MinSize == 0 {
+		c.MinSize = defaults.PackageChunkMinSize
+	}
+	if c.MaxSize == 0 {
+		c.MaxSize = defaults.PackageChunkMaxSize
+	}
+	if c.AverageBits == 0 {
+		c.AverageBits = defaults.PackageChunkAverageBits
+	}
+	if c.MinSize > c.MaxSize {
+		return trace.BadParameter("minimum chunk size %v exceeds maximum chunk size %v",
+			c.MinSize, c.MaxSize)
+	}
+	if c.AverageBits >= 64 {
+		return trace.BadParameter("average chunk size bits should be less than 64, got %v",
+			c.AverageBits)
+	}
+	return nil
+}
+
+// packageData describes package data written to the BLOB storage
+type packageData struct {
+	// sha512 is the hash of the package data
+	sha512 string
+	// sizeBytes is the size of the package data
+	sizeBytes int64
+	// chunks is the hash of the chunk index BLOB if the data
+	// has been stored as chunks
+	chunks string
+	// refs is the set of hashes of the BLOBs referenced by the data
+	refs map[string]struct{}
+}
+
+// writeData writes the package data to the BLOB storage, as a single BLOB
+// or as content-defined chunks in deduplicating mode
+func (p *PackageServer) writeData(data io.Reader) (*packageData, error) {
+	if !p.cfg.Dedup {
+		envelope, err := p.cfg.Objects.WriteBLOB(data)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		refs := map[string]struct{}{envelope.SHA512: {}}
+		if err := p.addRef(envelope.SHA512); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		// the BLOB might have been deleted with the last package
+		// referencing it before the reference was added
+		_, err = p.cfg.Objects.GetBLOBEnvelope(envelope.SHA512)
+		if err != nil {
+			p.releaseRefsOnError(refs)
+			if trace.IsNotFound(err) {
+				return nil, trace.CompareFailed("package data has been deleted concurrently, retry")
+			}
+			return nil, trace.Wrap(err)
+		}
+		return &packageData{
+			sha512:    envelope.SHA512,
+			sizeBytes: envelope.SizeBytes,
+			refs:      refs,
+		}, nil
+	}
+	refs := make(map[string]struct{})
+	pkgData, err := p.writeChunks(data, refs)
+	if err != nil {
+		p.releaseRefsOnError(refs)
+		return nil, trace.Wrap(err)
+	}
+	return pkgData, nil
+}
+
+// writeChunks writes the package data as content-defined chunks
+// adding the hashes of the referenced BLOBs to refs
+func (p *PackageServer) writeChunks(data io.Reader, refs map[string]struct{}) (*packageData, error) {
+	hasher := sha512.New()
+	chunker := newChunker(io.TeeReader(data, hasher), p.cfg.Chunking)
+	var chunks []pack.Chunk
+	var size, written int64
+	for {
+		data, err := chunker.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		chunk := pack.Chunk{
+			SHA512:    hashOf(data),
+			SizeBytes: int64(len(data)),
+		}
+		if err := p.addRefOnce(chunk.SHA512, refs); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stored, err := p.writeChunk(chunk, bytes.NewReader(data))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if stored {
+			written += chunk.SizeBytes
+		}
+		size += chunk.SizeBytes
+		chunks = append(chunks, chunk)
+	}
+	index, err := p.writeIndex(chunks, refs)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	log.WithField("chunks", len(chunks)).Infof("Stored %v new bytes out of %v.", written, size)
+	return &packageData{
+		sha512:    fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2]),
+		sizeBytes: size,
+		chunks:    index,
+		refs:      refs,
+	}, nil
+}
+
+// writeChunk writes the chunk to the BLOB storage unless it is already
+// there and returns true if the chunk has been written.
+// The caller holds a reference to the chunk
+func (p *PackageServer) writeChunk(chunk pack.Chunk, data io.Reader) (stored bool, err error) {
+	_, err = p.cfg.Objects.GetBLOBEnvelope(chunk.SHA512)
+	if err == nil {
+		return false, nil
+	}
+	if !trace.IsNotFound(err) {
+		return false, trace.Wrap(err)
+	}
+	envelope, err := p.cfg.Objects.WriteBLOB(data)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if envelope.SHA512 != chunk.SHA512 || envelope.SizeBytes != chunk.SizeBytes {
+		return false, trace.BadParameter("chunk %v is corrupted: got %v bytes with hash %v, expected %v bytes",
+			chunk.SHA512, envelope.SizeBytes, envelope.SHA512, chunk.SizeBytes)
+	}
+	return true, nil
+}
+
+// writeIndex writes the list of chunks to the BLOB storage
+// adding the reference to it to refs and returns its hash
+func (p *PackageServer) writeIndex(chunks []pack.Chunk, refs map[string]struct{}) (string, error) {
+	data, err := json.Marshal(chunks)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := p.addRefOnce(hashOf(data), refs); err != nil {
+		return "", trace.Wrap(err)
+	}
+	envelope, err := p.cfg.Objects.WriteBLOB(bytes.NewReader(data))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return envelope.SHA512, nil
+}
+
+// readIndex returns the list of chunks from the index BLOB with the specified hash
+func (p *PackageServer) readIndex(hash string) ([]pack.Chunk, error) {
+	f, err := p.cfg.Objects.OpenBLOB(hash)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer f.Close()
+	var chunks []pack.Chunk
+	if err := json.NewDecoder(f).Decode(&chunks); err != nil {
+		return nil, trace.Wrap(err, "failed to read chunk index %v", hash)
+	}
+	return chunks, nil
+}
+
+// GetPackageChunks returns the chunks the data of the specified package consists of
+func (p *PackageServer) GetPackageChunks(loc loc.Locator) ([]pack.Chunk, error) {
+	var err error
+	loc, err = p.processMetadata(loc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	pk, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if pk.Chunks == "" {
+		return nil, trace.NotFound("package %v is not stored as chunks", loc)
+	}
+	return p.readIndex(pk.Chunks)
+}
+
+// ReadPackageChunk returns the data of the chunk with the specified hash
+// that belongs to the specified package
+func (p *PackageServer) ReadPackageChunk(loc loc.Locator, hash string) (io.ReadCloser, error) {
+	chunks, err := p.GetPackageChunks(loc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for _, chunk := range chunks {
+		if chunk.SHA512 == hash {
+			return p.cfg.Objects.OpenBLOB(hash)
+		}
+	}
+	return nil, trace.NotFound("package %v has no chunk %v", loc, hash)
+}
+
+// CreatePackageFromChunks creates or updates the package described with envelope
+// from the specified chunks fetching only the chunks missing from the BLOB storage
+func (p *PackageServer) CreatePackageFromChunks(envelope pack.PackageEnvelope, chunks []pack.Chunk, fetch pack.FetchChunkFunc, upsert bool, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	if !p.cfg.Dedup {
+		return nil, trace.NotImplemented("deduplicating storage mode is disabled")
+	}
+	loc := envelope.Locator
+	if !upsert {
+		_, err := p.backend.GetRepository(loc.Repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	refs := make(map[string]struct{})
+	data, err := p.fetchChunks(envelope, chunks, fetch, refs)
+	if err != nil {
+		p.releaseRefsOnError(refs)
+		return nil, trace.Wrap(err)
+	}
+	return p.createPackage(loc, data, upsert, options...)
+}
+
+// fetchChunks retrieves the chunks missing from the BLOB storage and verifies
+// they make up the package data adding the hashes of the referenced BLOBs to refs
+func (p *PackageServer) fetchChunks(envelope pack.PackageEnvelope, chunks []pack.Chunk, fetch pack.FetchChunkFunc, refs map[string]struct{}) (*packageData, error) {
+	loc := envelope.Locator
+	var written int64
+	for _, chunk := range chunks {
+		if err := p.addRefOnce(chunk.SHA512, refs); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		stored, err := p.fetchChunk(chunk, fetch)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if stored {
+			written += chunk.SizeBytes
+		}
+	}
+	index, err := p.writeIndex(chunks, refs)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// make sure the chunks add up to the expected package data
+	reader := newChunkedReader(p.cfg.Objects, chunks)
+	defer reader.Close()
+	hasher := sha512.New()
+	size, err := io.Copy(hasher, reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hash := fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+	if hash != envelope.SHA512 || size != envelope.SizeBytes {
+		return nil, trace.BadParameter("package %v is corrupted: got %v bytes with hash %v, expected %v bytes with hash %v",
+			loc, size, hash, envelope.SizeBytes, envelope.SHA512)
+	}
+	log.WithFields(log.Fields{
+		"package": loc,
+		"chunks":  len(chunks),
+	}).Infof("Transferred %v bytes out of %v.", written, size)
+	return &packageData{
+		sha512:    hash,
+		sizeBytes: size,
+		chunks:    index,
+		refs:      refs,
+	}, nil
+}
+
+// fetchChunk retrieves the chunk using fetch unless it is already
+// in the BLOB storage and returns true if the chunk has been fetched.
+// The caller holds a reference to the chunk
+func (p *PackageServer) fetchChunk(chunk pack.Chunk, fetch pack.FetchChunkFunc) (stored bool, err error) {
+	_, err = p.cfg.Objects.GetBLOBEnvelope(chunk.SHA512)
+	if err == nil {
+		return false, nil
+	}
+	if !trace.IsNotFound(err) {
+		return false, trace.Wrap(err)
+	}
+	data, err := fetch(chunk)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	defer data.Close()
+	return p.writeChunk(chunk, data)
+}
+
+// GetDedupStats returns the statistics of the packages stored as chunks
+func (p *PackageServer) GetDedupStats() (*pack.DedupStats, error) {
+	var stats pack.DedupStats
+	chunks := make(map[string]struct{})
+	err := p.foreachPackage(func(pkg storage.Package) error {
+		if pkg.Chunks == "" {
+			return nil
+		}
+		index, err := p.readIndex(pkg.Chunks)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		stats.Packages++
+		stats.LogicalBytes += int64(pkg.SizeBytes)
+		for _, chunk := range index {
+			if _, ok := chunks[chunk.SHA512]; ok {
+				continue
+			}
+			chunks[chunk.SHA512] = struct{}{}
+			stats.Chunks++
+			stats.StoredBytes += chunk.SizeBytes
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &stats, nil
+}
+
+// foreachPackage invokes fn for every package in every repository
+func (p *PackageServer) foreachPackage(fn func(storage.Package) error) error {
+	repositories, err := p.backend.GetRepositories()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, repository := range repositories {
+		packages, err := p.backend.GetPackages(repository.GetName())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, pkg := range packages {
+			if err := fn(pkg); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
+	return nil
+}
+
+// chunkedReader reads the package data stored as chunks
+type chunkedReader struct {
+	objects blob.Objects
+	chunks  []pack.Chunk
+	// offsets is the offset of each chunk in the package data
+	offsets []int64
+	size    int64
+	offset  int64
+	// end is the offset at which the open chunk ends
+	end int64
+	// chunk is the open chunk, nil if no chunk is open
+	chunk io.ReadCloser
+}
+
+func newChunkedReader(objects blob.Objects, chunks []pack.Chunk) *chunkedReader {
+	offsets := make([]int64, len(chunks))
+	var size int64
+	for i, chunk := range chunks {
+		offsets[i] = size
+		size += chunk.SizeBytes
+	}
+	return &chunkedReader{
+		objects: objects,
+		chunks:  chunks,
+		offsets: offsets,
+		size:    size,
+	}
+}
+
+// Read reads the package data from the current offset
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.chunk == nil {
+		if err := r.open(); err != nil {
+			return 0, trace.Wrap(err)
+		}
+	}
+	if remaining := r.end - r.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.chunk.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset != r.end {
+		return n, io.ErrUnexpectedEOF
+	}
+	if r.offset == r.end {
+		r.closeChunk()
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset for the next Read
+func (r *chunkedReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, trace.BadParameter("unsupported whence: %v", whence)
+	}
+	if offset < 0 {
+		return 0, trace.BadParameter("negative offset: %v", offset)
+	}
+	if offset != r.offset {
+		r.closeChunk()
+		r.offset = offset
+	}
+	return r.offset, nil
+}
+
+// Close closes the open chunk
+func (r *chunkedReader) Close() error {
+	r.closeChunk()
+	return nil
+}
+
+// open opens the chunk the current offset falls into
+func (r *chunkedReader) open() error {
+	i := sort.Search(len(r.chunks), func(i int) bool {
+		return r.offsets[i]+r.chunks[i].SizeBytes > r.offset
+	})
+	f, err := r.objects.OpenBLOB(r.chunks[i].SHA512)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := f.Seek(r.offset-r.offsets[i], io.SeekStart); err != nil {
+		f.Close()
+		return trace.Wrap(err)
+	}
+	r.chunk = f
+	r.end = r.offsets[i] + r.chunks[i].SizeBytes
+	return nil
+}
+
+func (r *chunkedReader) closeChunk() {
+	if r.chunk != nil {
+		r.chunk.Close()
+		r.chunk = nil
+	}
+}
+
+// chunker splits data into content-defined chunks using the gear
+// rolling hash: a chunk ends where the top bits of the hash of the
+// preceding bytes are all zero, so identical data produces the same chunks
+// regardless of its position in the stream
+type chunker struct {
+	r      *bufio.Reader
+	config ChunkingConfig
+	mask   uint64
+	buf    []byte
+}
+
+func newChunker(r io.Reader, config ChunkingConfig) *chunker {
+	return &chunker{
+		r:      bufio.NewReader(r),
+		config: config,
+		mask:   (1<<config.AverageBits - 1) << (64 - config.AverageBits),
+		buf:    make([]byte, 0, config.MaxSize),
+	}
+}
+
+// next returns the next chunk.
+// The returned slice is only valid until the next call
+func (r *chunker) next() ([]byte, error) {
+	r.buf = r.buf[:0]
+	var hash uint64
+	for {
+		b, err := r.r.ReadByte()
+		if err == io.EOF {
+			if len(r.buf) == 0 {
+				return nil, io.EOF
+			}
+			return r.buf, nil
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		r.buf = append(r.buf, b)
+		hash = hash<<1 + gearTable[b]
+		if len(r.buf) >= r.config.MaxSize ||
+			(len(r.buf) >= r.config.MinSize && hash&r.mask == 0) {
+			return r.buf, nil
+		}
+	}
+}
+
+// gearTable maps bytes to random values for the gear hash.
+// The table is generated from a fixed seed as the chunk boundaries
+// must not change between runs
+var gearTable = func() (table [256]uint64) {
+	rnd := rand.New(rand.NewSource(gearSeed))
+	for i := range table {
+		table[i] = rnd.Uint64()
+	}
+	return table
+}()
+
+const gearSeed = 0x6772617669747900
+
+func hashOf(data []byte) string {
+	hash := sha512.Sum512(data)
+	return fmt.Sprintf("%x", hash[:sha512.Size/2])
+}
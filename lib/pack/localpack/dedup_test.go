@@ -0,0 +1,276 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localpack
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/blob"
+	"github.com/gravitational/gravity/lib/blob/fs"
+	"github.com/gravitational/gravity/lib/compare"
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+	"github.com/gravitational/gravity/lib/pack/suite"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/storage/keyval"
+
+	"github.com/gravitational/trace"
+	"github.com/mailgun/timetools"
+	. "gopkg.in/check.v1"
+)
+
+type DedupSuite struct {
+	server  *PackageServer
+	objects blob.Objects
+	backend storage.Backend
+	suite   suite.PackageSuite
+	clock   *timetools.FreezedTime
+}
+
+var _ = Suite(&DedupSuite{
+	clock: &timetools.FreezedTime{
+		CurrentTime: time.Date(2015, 11, 16, 1, 2, 3, 0, time.UTC),
+	},
+})
+
+func (s *DedupSuite) SetUpTest(c *C) {
+	s.server, s.objects, s.backend = newDedupServer(c, s.clock)
+	s.suite.O = s.objects
+	s.suite.C = s.clock
+	s.suite.S = s.server
+}
+
+func (s *DedupSuite) TearDownTest(c *C) {
+	c.Assert(s.backend.Close(), IsNil)
+}
+
+func (s *DedupSuite) TestPackagesCRUD(c *C) {
+	s.suite.PackagesCRUD(c)
+}
+
+func (s *DedupSuite) TestUpsertPackages(c *C) {
+	s.suite.UpsertPackages(c)
+}
+
+func (s *DedupSuite) TestDeleteRepository(c *C) {
+	s.suite.DeleteRepository(c)
+}
+
+func (s *DedupSuite) TestSharesChunksBetweenVersions(c *C) {
+	layer := randomBytes(1, 64*1024)
+	v1 := append(append([]byte{}, layer...), randomBytes(2, 8*1024)...)
+	// the second version has the shared data shifted by a few bytes
+	v2 := append(append(randomBytes(3, 100), layer...), randomBytes(4, 8*1024)...)
+	loc1 := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	loc2 := loc.MustParseLocator("gravitational.io/app:0.0.2")
+	c.Assert(s.server.UpsertRepository("gravitational.io", time.Time{}), IsNil)
+
+	_, err := s.server.CreatePackage(loc1, bytes.NewReader(v1))
+	c.Assert(err, IsNil)
+	_, err = s.server.CreatePackage(loc2, bytes.NewReader(v2))
+	c.Assert(err, IsNil)
+
+	for loc, data := range map[loc.Locator][]byte{loc1: v1, loc2: v2} {
+		envelope, reader, err := s.server.ReadPackage(loc)
+		c.Assert(err, IsNil)
+		out, err := ioutil.ReadAll(reader)
+		reader.Close()
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(out, data), Equals, true)
+		c.Assert(envelope.SizeBytes, Equals, int64(len(data)))
+	}
+
+	stats, err := s.server.GetDedupStats()
+	c.Assert(err, IsNil)
+	c.Assert(stats.Packages, Equals, 2)
+	c.Assert(stats.LogicalBytes, Equals, int64(len(v1)+len(v2)))
+	c.Assert(stats.Ratio() > 1.5, Equals, true, Commentf("%#v", stats))
+}
+
+func (s *DedupSuite) TestReadsFromOffset(c *C) {
+	data := randomBytes(5, 32*1024)
+	loc := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	_, err := s.server.UpsertPackage(loc, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+
+	_, reader, err := s.server.ReadPackage(loc)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	seeker := reader.(io.ReadSeeker)
+
+	offset, err := seeker.Seek(-10000, io.SeekEnd)
+	c.Assert(err, IsNil)
+	c.Assert(offset, Equals, int64(len(data)-10000))
+	out, err := ioutil.ReadAll(seeker)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(out, data[len(data)-10000:]), Equals, true)
+}
+
+func (s *DedupSuite) TestDeletesUnsharedChunks(c *C) {
+	layer := randomBytes(1, 64*1024)
+	loc1 := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	loc2 := loc.MustParseLocator("gravitational.io/app:0.0.2")
+	_, err := s.server.UpsertPackage(loc1, bytes.NewReader(layer))
+	c.Assert(err, IsNil)
+	blobsBefore, err := s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	_, err = s.server.UpsertPackage(loc2, bytes.NewReader(append(layer, randomBytes(2, 16*1024)...)))
+	c.Assert(err, IsNil)
+
+	c.Assert(s.server.DeletePackage(loc2), IsNil)
+	blobsAfter, err := s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobsAfter, compare.DeepEquals, blobsBefore)
+
+	_, reader, err := s.server.ReadPackage(loc1)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	out, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(out, layer), Equals, true)
+
+	c.Assert(s.server.DeletePackage(loc1), IsNil)
+	blobsAfter, err = s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobsAfter, HasLen, 0)
+}
+
+func (s *DedupSuite) TestReleasesDataOfReplacedPackage(c *C) {
+	loc := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	_, err := s.server.UpsertPackage(loc, bytes.NewReader(randomBytes(1, 32*1024)))
+	c.Assert(err, IsNil)
+	data := randomBytes(2, 32*1024)
+	_, err = s.server.UpsertPackage(loc, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	blobsBefore, err := s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+
+	// replacing the package with the same data keeps the data
+	_, err = s.server.UpsertPackage(loc, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	blobsAfter, err := s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobsAfter, compare.DeepEquals, blobsBefore)
+
+	c.Assert(s.server.DeletePackage(loc), IsNil)
+	blobsAfter, err = s.objects.GetBLOBs()
+	c.Assert(err, IsNil)
+	c.Assert(blobsAfter, HasLen, 0)
+}
+
+func (s *DedupSuite) TestCountsReferencesToExistingBLOBs(c *C) {
+	// packages stored before the references were counted
+	envelope, err := s.objects.WriteBLOB(bytes.NewReader([]byte("hello, world!")))
+	c.Assert(err, IsNil)
+	c.Assert(s.server.UpsertRepository("gravitational.io", time.Time{}), IsNil)
+	loc1 := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	loc2 := loc.MustParseLocator("gravitational.io/app:0.0.2")
+	for _, loc := range []loc.Locator{loc1, loc2} {
+		_, err = s.backend.CreatePackage(storage.Package{
+			Repository: loc.Repository,
+			Name:       loc.Name,
+			Version:    loc.Version,
+			SHA512:     envelope.SHA512,
+			SizeBytes:  int(envelope.SizeBytes),
+		})
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(s.server.DeletePackage(loc1), IsNil)
+	_, err = s.objects.GetBLOBEnvelope(envelope.SHA512)
+	c.Assert(err, IsNil)
+
+	c.Assert(s.server.DeletePackage(loc2), IsNil)
+	_, err = s.objects.GetBLOBEnvelope(envelope.SHA512)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *DedupSuite) TestCreatesPackageFromChunks(c *C) {
+	layer := randomBytes(1, 64*1024)
+	loc1 := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	loc2 := loc.MustParseLocator("gravitational.io/app:0.0.2")
+	data := append(layer, randomBytes(2, 16*1024)...)
+	_, err := s.server.UpsertPackage(loc2, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+
+	dst, _, backend := newDedupServer(c, s.clock)
+	defer backend.Close()
+	_, err = dst.UpsertPackage(loc1, bytes.NewReader(layer))
+	c.Assert(err, IsNil)
+
+	envelope, err := s.server.ReadPackageEnvelope(loc2)
+	c.Assert(err, IsNil)
+	chunks, err := s.server.GetPackageChunks(loc2)
+	c.Assert(err, IsNil)
+	var fetched int64
+	fetch := func(chunk pack.Chunk) (io.ReadCloser, error) {
+		fetched += chunk.SizeBytes
+		return s.server.ReadPackageChunk(loc2, chunk.SHA512)
+	}
+	_, err = dst.CreatePackageFromChunks(*envelope, chunks, fetch, true)
+	c.Assert(err, IsNil)
+	// only the chunks missing from the destination have been transferred
+	c.Assert(fetched < int64(len(data))/2, Equals, true, Commentf("fetched %v bytes", fetched))
+
+	_, reader, err := dst.ReadPackage(loc2)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	out, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(out, data), Equals, true)
+
+	// corrupted chunk list is rejected
+	envelope.SHA512 = "deadbeef"
+	_, err = dst.CreatePackageFromChunks(*envelope, chunks, fetch, true)
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+}
+
+func newDedupServer(c *C, clock timetools.TimeProvider) (*PackageServer, blob.Objects, storage.Backend) {
+	dir := c.MkDir()
+	backend, err := keyval.NewBolt(keyval.BoltConfig{
+		Path: filepath.Join(dir, "storage.db"),
+	})
+	c.Assert(err, IsNil)
+	objects, err := fs.New(dir)
+	c.Assert(err, IsNil)
+	server, err := New(Config{
+		Backend:     backend,
+		UnpackedDir: filepath.Join(dir, defaults.UnpackedDir),
+		Clock:       clock,
+		Objects:     objects,
+		Dedup:       true,
+		Chunking: ChunkingConfig{
+			MinSize:     1024,
+			MaxSize:     16 * 1024,
+			AverageBits: 12,
+		},
+	})
+	c.Assert(err, IsNil)
+	return server, objects, backend
+}
+
+func randomBytes(seed int64, size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
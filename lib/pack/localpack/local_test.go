@@ -18,6 +18,10 @@ package localpack
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"os"
 	"path/filepath"
 	"testing"
@@ -28,10 +32,13 @@ import (
 	"github.com/gravitational/gravity/lib/compare"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/suite"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/storage/keyval"
 
+	"github.com/gravitational/trace"
 	"github.com/mailgun/timetools"
 	log "github.com/sirupsen/logrus"
 	. "gopkg.in/check.v1"
@@ -147,3 +154,51 @@ func (s *LocalSuite) TestDoesnotDeleteCollidingBlobs(c *C) {
 	c.Assert(blobsBefore, compare.DeepEquals, []string{package1.SHA512})
 	c.Assert(blobsAfter, compare.DeepEquals, []string{package1.SHA512})
 }
+
+func (s *LocalSuite) TestSignsPackages(c *C) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	signer, err := signing.NewSigner(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+	c.Assert(err, IsNil)
+	verifier := signer.Verifier()
+
+	app := loc.MustParseLocator("gravitational.io/app:0.0.1")
+	runtime := loc.MustParseLocator("gravitational.io/runtime:0.0.1")
+	c.Assert(s.server.UpsertRepository("gravitational.io", time.Time{}), IsNil)
+	for _, locator := range []loc.Locator{app, runtime} {
+		_, err = s.server.CreatePackage(locator, bytes.NewReader([]byte(locator.String())))
+		c.Assert(err, IsNil)
+	}
+
+	c.Assert(pack.SignPackages(s.server, signer), IsNil)
+	c.Assert(pack.VerifyPackages(s.server, verifier), IsNil)
+
+	// replace the package contents keeping the signature
+	env, err := s.server.ReadPackageEnvelope(app)
+	c.Assert(err, IsNil)
+	_, err = s.server.UpsertPackage(app, bytes.NewReader([]byte("tampered")),
+		pack.WithLabels(env.RuntimeLabels))
+	c.Assert(err, IsNil)
+	err = pack.VerifyPackages(s.server, verifier)
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+
+	// move the signature to another package
+	c.Assert(s.server.DeletePackage(app), IsNil)
+	env, err = s.server.ReadPackageEnvelope(runtime)
+	c.Assert(err, IsNil)
+	_, err = s.server.CreatePackage(app, bytes.NewReader([]byte(runtime.String())),
+		pack.WithLabels(env.RuntimeLabels))
+	c.Assert(err, IsNil)
+	err = pack.VerifyPackages(s.server, verifier)
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+
+	// unsigned packages are rejected
+	c.Assert(s.server.UpdatePackageLabels(app, nil, []string{pack.SignatureLabel}), IsNil)
+	env, err = s.server.ReadPackageEnvelope(app)
+	c.Assert(err, IsNil)
+	err = pack.VerifyPackage(s.server, *env, verifier)
+	c.Assert(err, ErrorMatches, ".*is not signed.*")
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gravitational/gravity/lib/blob"
@@ -52,6 +53,14 @@ type Config struct {
 
 	// UnpackedDir is the path for unpacked packages
 	UnpackedDir string
+
+	// Dedup enables the deduplicating storage mode: package data is
+	// split into content-defined chunks stored as separate BLOBs so
+	// that the data shared between packages is only stored once
+	Dedup bool
+
+	// Chunking optionally overrides the chunk sizes in deduplicating mode
+	Chunking ChunkingConfig
 }
 
 // PackageServer manages BLOBs of data and their metadata as packages
@@ -59,6 +68,11 @@ type PackageServer struct {
 	cfg     Config
 	clock   timetools.TimeProvider
 	backend storage.Backend
+	// refsMu guards refsInitialized
+	refsMu sync.Mutex
+	// refsInitialized is set once the references to the BLOBs
+	// stored before they were counted have been counted
+	refsInitialized bool
 }
 
 func New(cfg Config) (*PackageServer, error) {
@@ -77,6 +91,9 @@ func New(cfg Config) (*PackageServer, error) {
 	if cfg.Clock == nil {
 		cfg.Clock = &timetools.RealTime{}
 	}
+	if err := cfg.Chunking.checkAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	s := &PackageServer{
 		cfg:     cfg,
 		backend: cfg.Backend,
@@ -150,81 +167,61 @@ func (p *PackageServer) CreatePackage(loc loc.Locator, data io.Reader, options .
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	blobEnvelope, err := p.cfg.Objects.WriteBLOB(data)
+	stored, err := p.writeData(data)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-
-	pkg := storage.Package{
-		Repository: loc.Repository,
-		Name:       loc.Name,
-		Version:    loc.Version,
-		SHA512:     blobEnvelope.SHA512,
-		SizeBytes:  int(blobEnvelope.SizeBytes),
-		Created:    p.cfg.Clock.UtcNow(),
-	}
-	for _, option := range options {
-		option(&pkg)
-	}
-
-	envelope := &pack.PackageEnvelope{
-		Locator:       loc,
-		SHA512:        blobEnvelope.SHA512,
-		SizeBytes:     blobEnvelope.SizeBytes,
-		RuntimeLabels: pkg.RuntimeLabels,
-		Hidden:        pkg.Hidden,
-		Encrypted:     pkg.Encrypted,
-		Type:          pkg.Type,
-		Manifest:      pkg.Manifest,
-		Created:       pkg.Created,
-		CreatedBy:     pkg.CreatedBy,
-	}
-
-	// check that the repository exists
-	_, err = p.backend.GetRepository(loc.Repository)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	// create package in repository
-	_, err = p.backend.CreatePackage(pkg)
-	if err != nil {
-		return nil, trace.Wrap(err)
-	}
-
-	return envelope, nil
+	return p.createPackage(loc, stored, false, options...)
 }
 
 // UpsertPackage upserts package and repository
 func (p *PackageServer) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
-	blobEnvelope, err := p.cfg.Objects.WriteBLOB(data)
+	stored, err := p.writeData(data)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	return p.createPackage(loc, stored, true, options...)
+}
 
+// createPackage creates the package with the data already written
+// to the BLOB storage. If upsert is set, the repository is created
+// and the existing package is replaced if necessary.
+// The references to the package data are released if the package
+// cannot be created
+func (p *PackageServer) createPackage(loc loc.Locator, data *packageData, upsert bool, options ...pack.PackageOption) (envelope *pack.PackageEnvelope, err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if errRelease := p.releaseRefs(data.refs); errRelease != nil {
+			log.WithError(errRelease).Warnf("Failed to release data of %v.", loc)
+		}
+	}()
 	pkg := storage.Package{
 		Repository: loc.Repository,
 		Name:       loc.Name,
 		Version:    loc.Version,
-		SHA512:     blobEnvelope.SHA512,
-		SizeBytes:  int(blobEnvelope.SizeBytes),
+		SHA512:     data.sha512,
+		SizeBytes:  int(data.sizeBytes),
+		Chunks:     data.chunks,
 		Created:    p.cfg.Clock.UtcNow(),
 	}
 	for _, option := range options {
 		option(&pkg)
 	}
 
-	envelope := &pack.PackageEnvelope{
-		Locator:       loc,
-		SHA512:        blobEnvelope.SHA512,
-		SizeBytes:     blobEnvelope.SizeBytes,
-		RuntimeLabels: pkg.RuntimeLabels,
-		Hidden:        pkg.Hidden,
-		Encrypted:     pkg.Encrypted,
-		Type:          pkg.Type,
-		Manifest:      pkg.Manifest,
-		Created:       pkg.Created,
-		CreatedBy:     pkg.CreatedBy,
+	if !upsert {
+		// check that the repository exists
+		_, err = p.backend.GetRepository(loc.Repository)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		// create package in repository
+		_, err = p.backend.CreatePackage(pkg)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return newEnvelope(loc, &pkg), nil
 	}
 
 	_, err = p.backend.CreateRepository(storage.NewRepository(loc.Repository))
@@ -233,12 +230,36 @@ func (p *PackageServer) UpsertPackage(loc loc.Locator, data io.Reader, options .
 			return nil, trace.Wrap(err)
 		}
 	}
+	// serialize upserts of the package so that the data of the replaced
+	// package is released exactly once
+	lock := packageLock(loc)
+	if err := p.backend.AcquireLock(lock, defaults.PackageBlobDeleteTimeout); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer func() {
+		if err := p.backend.ReleaseLock(lock); err != nil {
+			log.WithError(err).Warnf("Failed to release lock %v.", lock)
+		}
+	}()
+	existing, err := p.backend.GetPackage(loc.Repository, loc.Name, loc.Version)
+	if err != nil && !trace.IsNotFound(err) {
+		return nil, trace.Wrap(err)
+	}
 	_, err = p.backend.UpsertPackage(pkg)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if existing != nil {
+		if err := p.releaseData(*existing); err != nil {
+			log.WithError(err).Warnf("Failed to release data of replaced %v.", loc)
+		}
+	}
+	return newEnvelope(loc, &pkg), nil
+}
 
-	return envelope, nil
+// packageLock returns the name of the lock serializing updates of the package
+func packageLock(loc loc.Locator) string {
+	return strings.Join([]string{"packages", loc.Repository, loc.Name, loc.Version}, "/")
 }
 
 func (p *PackageServer) processMetadata(locator loc.Locator) (loc.Locator, error) {
@@ -264,6 +285,13 @@ func (p *PackageServer) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
+	if pk.Chunks != "" {
+		chunks, err := p.readIndex(pk.Chunks)
+		if err != nil {
+			return nil, nil, trace.Wrap(err)
+		}
+		return newEnvelope(loc, pk), newChunkedReader(p.cfg.Objects, chunks), nil
+	}
 	f, err := p.cfg.Objects.OpenBLOB(pk.SHA512)
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
@@ -285,12 +313,17 @@ func (p *PackageServer) DeletePackage(loc loc.Locator) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// count the references to the package data before the package
+	// is gone so that its own references are counted
+	if err := p.initRefs(); err != nil {
+		return trace.Wrap(err)
+	}
 	// remove package from all repositories
 	err = p.backend.DeletePackage(loc.Repository, loc.Name, loc.Version)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = p.tryDeleteBlob(*pk)
+	err = p.releaseData(*pk)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -436,30 +469,18 @@ func (p *PackageServer) ConfigurePackage(loc loc.Locator, confLoc loc.Locator, a
 	return pack.ConfigurePackage(p, loc, confLoc, args, nil)
 }
 
-func (p *PackageServer) tryDeleteBlob(toDelete storage.Package) error {
-	log := log.WithFields(log.Fields{
-		"package":  toDelete.Locator(),
-		"checksum": toDelete.SHA512,
-	})
-	repositories, err := p.backend.GetRepositories()
+// releaseData releases the references of the package to its data
+// and deletes the BLOBs no longer used by other packages
+func (p *PackageServer) releaseData(pkg storage.Package) error {
+	hashes, err := p.blobsOf(pkg)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	for _, repository := range repositories {
-		packages, err := p.backend.GetPackages(repository.GetName())
-		if err != nil {
-			return trace.Wrap(err)
-		}
-		for _, pkg := range packages {
-			if pkg.SHA512 == toDelete.SHA512 && !pkg.Locator().IsEqualTo(toDelete.Locator()) {
-				log.WithField("existing", pkg.Locator()).
-					Info("Collision with existing package, will not delete blob.")
-				return nil
-			}
-		}
-	}
-	log.Info("Delete BLOB.")
-	return trace.Wrap(p.cfg.Objects.DeleteBLOB(toDelete.SHA512))
+	log.WithFields(log.Fields{
+		"package":  pkg.Locator(),
+		"checksum": pkg.SHA512,
+	}).Info("Release package data.")
+	return trace.Wrap(p.releaseRefs(hashes))
 }
 
 func newEnvelope(loc loc.Locator, p *storage.Package) *pack.PackageEnvelope {
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localpack
+
+import (
+	"context"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// BLOBs are reference counted so that the data shared between packages
+// is deleted with the last package using it. A reference is added before
+// a BLOB is checked for existence and written, and the last reference is
+// released before the BLOB is deleted. While the BLOB is being deleted,
+// new references to it are refused, so a package can never end up
+// referencing a BLOB that is about to disappear.
+
+// addRef adds a reference to the BLOB with the specified hash,
+// waiting for the BLOB to be deleted if it is being deleted
+func (p *PackageServer) addRef(hash string) error {
+	if err := p.initRefs(); err != nil {
+		return trace.Wrap(err)
+	}
+	// wait long enough for an abandoned deletion to be taken over
+	timeout := defaults.PackageBlobDeleteTimeout + defaults.RetryInterval
+	return utils.RetryFor(context.TODO(), timeout, func() error {
+		err := p.backend.AddBlobRef(hash)
+		if trace.IsCompareFailed(err) {
+			return utils.Continue("BLOB %v is being deleted", hash)
+		}
+		if err != nil {
+			return utils.Abort(err)
+		}
+		return nil
+	})
+}
+
+// addRefOnce adds a reference to the BLOB with the specified hash
+// unless refs already has it and adds the hash to refs
+func (p *PackageServer) addRefOnce(hash string, refs map[string]struct{}) error {
+	if _, ok := refs[hash]; ok {
+		return nil
+	}
+	if err := p.addRef(hash); err != nil {
+		return trace.Wrap(err)
+	}
+	refs[hash] = struct{}{}
+	return nil
+}
+
+// releaseRefsOnError releases the references taken by the operation
+// that has failed
+func (p *PackageServer) releaseRefsOnError(refs map[string]struct{}) {
+	if err := p.releaseRefs(refs); err != nil {
+		log.WithError(err).Warn("Failed to release BLOB references.")
+	}
+}
+
+// releaseRefs releases the references to the BLOBs with the specified
+// hashes and deletes the BLOBs no longer referenced by any package
+func (p *PackageServer) releaseRefs(hashes map[string]struct{}) error {
+	if err := p.initRefs(); err != nil {
+		return trace.Wrap(err)
+	}
+	var deleted int
+	for hash := range hashes {
+		last, err := p.backend.ReleaseBlobRef(hash)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				log.WithError(err).Warn("Failed to release BLOB reference.")
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		if !last {
+			continue
+		}
+		if err := p.cfg.Objects.DeleteBLOB(hash); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if err := p.backend.DeleteBlobRef(hash); err != nil && !trace.IsCompareFailed(err) {
+			return trace.Wrap(err)
+		}
+		deleted++
+	}
+	log.Infof("Deleted %v BLOBs out of %v.", deleted, len(hashes))
+	return nil
+}
+
+// initRefs counts the references to the BLOBs stored before
+// the references were counted
+func (p *PackageServer) initRefs() error {
+	p.refsMu.Lock()
+	defer p.refsMu.Unlock()
+	if p.refsInitialized {
+		return nil
+	}
+	err := p.backend.AcquireLock(blobRefsLock, defaults.PackageBlobDeleteTimeout)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer func() {
+		if err := p.backend.ReleaseLock(blobRefsLock); err != nil {
+			log.WithError(err).Warn("Failed to release lock.")
+		}
+	}()
+	initialized, err := p.backend.BlobRefsInitialized()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if !initialized {
+		refs := make(map[string]int)
+		err := p.foreachPackage(func(pkg storage.Package) error {
+			hashes, err := p.blobsOf(pkg)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			for hash := range hashes {
+				refs[hash]++
+			}
+			return nil
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		log.Infof("Counted references to %v BLOBs.", len(refs))
+		if err := p.backend.InitBlobRefs(refs); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	p.refsInitialized = true
+	return nil
+}
+
+// blobsOf returns the hashes of the BLOBs with the data of the package
+func (p *PackageServer) blobsOf(pkg storage.Package) (map[string]struct{}, error) {
+	if pkg.Chunks == "" {
+		if pkg.SHA512 == "" {
+			// the package has no data
+			return nil, nil
+		}
+		return map[string]struct{}{pkg.SHA512: {}}, nil
+	}
+	chunks, err := p.readIndex(pkg.Chunks)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hashes := map[string]struct{}{pkg.Chunks: {}}
+	for _, chunk := range chunks {
+		hashes[chunk.SHA512] = struct{}{}
+	}
+	return hashes, nil
+}
+
+// blobRefsLock is the name of the lock held while the references
+// to the existing BLOBs are counted
+const blobRefsLock = "blobrefs"
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pack
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/signing"
+
+	"github.com/gravitational/trace"
+)
+
+// SignPackage signs the package with the specified signer and stores
+// the signature in the package labels.
+//
+// The signature covers the package locator and the checksum of the package
+// contents as read from the package service, so packages in encrypted
+// package services are signed in their decrypted form
+func SignPackage(packages PackageService, locator loc.Locator, signer *signing.Signer) error {
+	payload, err := signedPayload(packages, locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	signature, err := signer.Sign(payload)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return packages.UpdatePackageLabels(locator, map[string]string{
+		SignatureLabel: base64.StdEncoding.EncodeToString(signature),
+	}, nil)
+}
+
+// SignPackages signs all packages in the package service
+func SignPackages(packages PackageService, signer *signing.Signer) error {
+	return ForeachPackage(packages, func(env PackageEnvelope) error {
+		return trace.Wrap(SignPackage(packages, env.Locator, signer))
+	})
+}
+
+// VerifyPackage verifies the signature of the package against
+// the package contents. Returns trace.AccessDenied if the package
+// is not signed or the signature is invalid
+func VerifyPackage(packages PackageService, env PackageEnvelope, verifier *signing.Verifier) error {
+	encoded, ok := env.RuntimeLabels[SignatureLabel]
+	if !ok {
+		return trace.AccessDenied("package %v is not signed", env.Locator)
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return trace.AccessDenied("package %v has malformed signature", env.Locator)
+	}
+	payload, err := signedPayload(packages, env.Locator)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := verifier.Verify(payload, signature); err != nil {
+		return trace.AccessDenied("package %v failed signature verification", env.Locator)
+	}
+	return nil
+}
+
+// VerifyPackages verifies the signatures of all packages in the package service
+func VerifyPackages(packages PackageService, verifier *signing.Verifier) error {
+	return ForeachPackage(packages, func(env PackageEnvelope) error {
+		return trace.Wrap(VerifyPackage(packages, env, verifier))
+	})
+}
+
+// signedPayload returns the data signed for the package with the specified locator
+func signedPayload(packages PackageService, locator loc.Locator) ([]byte, error) {
+	_, reader, err := packages.ReadPackage(locator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer reader.Close()
+	hash := sha512.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []byte(fmt.Sprintf("%v\n%x\n", locator, hash.Sum(nil))), nil
+}
@@ -0,0 +1,404 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webpack
+
+import (
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/loc"
+	"github.com/gravitational/gravity/lib/pack"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// UploadRequest starts a resumable package upload
+type UploadRequest struct {
+	// Package is the locator of the package being uploaded
+	Package loc.Locator `json:"package"`
+	// SizeBytes is the package size. The space is reserved in the
+	// upload quota for the lifetime of the session
+	SizeBytes int64 `json:"size_bytes"`
+	// SHA512 is the half SHA512 hash of the package if known in advance.
+	// Uploads with the hash specified can be resumed by a subsequent
+	// request for the same package
+	SHA512 string `json:"sha512,omitempty"`
+	// Upsert specifies whether the package should replace an existing one
+	Upsert bool `json:"upsert"`
+	// Hidden specifies whether the package is hidden
+	Hidden bool `json:"hidden"`
+	// Labels is the package runtime labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Type is the package type
+	Type string `json:"type,omitempty"`
+	// Manifest is the package manifest
+	Manifest []byte `json:"manifest,omitempty"`
+}
+
+// Options returns the package options the upload request specifies
+func (r UploadRequest) Options() []pack.PackageOption {
+	options := []pack.PackageOption{pack.WithLabels(r.Labels), pack.WithHidden(r.Hidden)}
+	if len(r.Manifest) != 0 {
+		options = append(options, pack.WithManifest(r.Type, r.Manifest))
+	}
+	return options
+}
+
+// UploadSession describes the state of a resumable package upload
+type UploadSession struct {
+	// UploadRequest is the request that started the upload
+	UploadRequest
+	// ID is the upload session ID
+	ID string `json:"id"`
+	// Owner is the name of the user who started the upload.
+	// Sessions are only accessible to their owners
+	Owner string `json:"owner"`
+	// Offset is the number of bytes received so far
+	Offset int64 `json:"offset"`
+	// Created is the time the session was started
+	Created time.Time `json:"created"`
+}
+
+// completeUploadRequest finalizes the upload
+type completeUploadRequest struct {
+	// SHA512 is the half SHA512 hash of the uploaded data
+	SHA512 string `json:"sha512"`
+}
+
+// uploadStore keeps resumable upload sessions in a local directory.
+//
+// Each session is stored as a pair of files: <id>.json with the upload
+// request and <id>.data with the data received so far.
+//
+// Sessions are local to the process that created them: with several
+// controllers behind a load balancer, a request may reach a controller
+// that does not have the session, in which case it is reported as not
+// found and clients fall back to a single-request upload
+type uploadStore struct {
+	dir string
+	// quota is the total size of data all active sessions may upload
+	quota int64
+	// createMu serializes session creation so the quota is respected
+	createMu sync.Mutex
+	// mu guards locks
+	mu sync.Mutex
+	// locks serializes access to individual sessions
+	locks map[string]*sync.Mutex
+}
+
+func newUploadStore(dir string, quota int64) (*uploadStore, error) {
+	if err := os.MkdirAll(dir, defaults.SharedDirMask); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &uploadStore{
+		dir:   dir,
+		quota: quota,
+		locks: make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// create starts a new upload session for the specified user.
+// If the request specifies the package hash and the user has an unfinished
+// session for the same package, the existing session is returned instead
+func (r *uploadStore) create(owner string, req UploadRequest) (*UploadSession, error) {
+	if req.SizeBytes <= 0 {
+		return nil, trace.BadParameter("upload of %v is missing package size", req.Package)
+	}
+	r.createMu.Lock()
+	defer r.createMu.Unlock()
+	r.removeExpired()
+	sessions, err := r.list()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var reserved int64
+	for _, session := range sessions {
+		if req.SHA512 != "" && session.Owner == owner && session.Package == req.Package &&
+			session.SHA512 == req.SHA512 && session.SizeBytes == req.SizeBytes {
+			return &session, nil
+		}
+		reserved += session.SizeBytes
+	}
+	if reserved+req.SizeBytes > r.quota {
+		return nil, trace.LimitExceeded("upload of %v bytes exceeds the quota: %v out of %v bytes are reserved by active uploads",
+			req.SizeBytes, reserved, r.quota)
+	}
+	session := UploadSession{
+		UploadRequest: req,
+		ID:            uuid.New(),
+		Owner:         owner,
+		Created:       time.Now().UTC(),
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = ioutil.WriteFile(r.dataPath(session.ID), nil, defaults.SharedReadMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	err = ioutil.WriteFile(r.sessionPath(session.ID), data, defaults.SharedReadMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &session, nil
+}
+
+// get returns the session with the specified key
+func (r *uploadStore) get(key sessionKey) (*UploadSession, error) {
+	unlock := r.lock(key.id)
+	defer unlock()
+	return r.readOwned(key)
+}
+
+// write appends the data for the session with the specified key at offset.
+// The offset must match the amount of data received so far so that
+// clients cannot leave gaps or overwrite data.
+// If the data cannot be fully received, the session is reset to the
+// previous offset
+func (r *uploadStore) write(key sessionKey, offset int64, data io.Reader) (*UploadSession, error) {
+	id := key.id
+	unlock := r.lock(id)
+	defer unlock()
+	session, err := r.readOwned(key)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if session.Offset != offset {
+		return nil, trace.CompareFailed("upload %v is at offset %v, not %v",
+			id, session.Offset, offset)
+	}
+	f, err := os.OpenFile(r.dataPath(id), os.O_WRONLY, defaults.SharedReadMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	// never accept more than the declared size so the quota holds
+	n, err := io.Copy(f, io.LimitReader(data, session.SizeBytes-offset+1))
+	if err == nil && offset+n > session.SizeBytes {
+		err = trace.BadParameter("upload %v exceeds declared size of %v bytes",
+			id, session.SizeBytes)
+	}
+	if err != nil {
+		if errTruncate := f.Truncate(offset); errTruncate != nil {
+			log.WithError(errTruncate).Warnf("Failed to reset upload %v.", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	session.Offset = offset + n
+	return session, nil
+}
+
+// complete verifies the data of the session with the specified key
+// against the expected hash and passes it to fn.
+// The session is removed once fn returns successfully or if the
+// data turns out to be corrupted
+func (r *uploadStore) complete(key sessionKey, hash string, fn func(session UploadSession, data io.Reader) error) error {
+	id := key.id
+	unlock := r.lock(id)
+	defer unlock()
+	session, err := r.readOwned(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if hash == "" {
+		hash = session.SHA512
+	}
+	if hash == "" {
+		return trace.BadParameter("missing hash to verify upload %v", id)
+	}
+	if session.Offset != session.SizeBytes {
+		return trace.BadParameter("upload %v is incomplete: received %v out of %v bytes",
+			id, session.Offset, session.SizeBytes)
+	}
+	f, err := os.Open(r.dataPath(id))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	hasher := sha512.New()
+	_, err = io.Copy(hasher, f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	actual := fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+	if (session.SHA512 != "" && actual != session.SHA512) || actual != hash {
+		r.remove(id)
+		return trace.BadParameter("upload %v is corrupted: expected hash %v, got %v",
+			id, hash, actual)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = fn(*session, f)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.remove(id)
+	return nil
+}
+
+// delete removes the session with the specified key
+func (r *uploadStore) delete(key sessionKey) error {
+	unlock := r.lock(key.id)
+	defer unlock()
+	if _, err := r.readOwned(key); err != nil {
+		return trace.Wrap(err)
+	}
+	r.remove(key.id)
+	return nil
+}
+
+// list returns all unfinished sessions
+func (r *uploadStore) list() ([]UploadSession, error) {
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.json"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var sessions []UploadSession
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		unlock := r.lock(id)
+		session, err := r.read(id)
+		unlock()
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				log.WithError(err).Warnf("Failed to read upload session %v.", path)
+			}
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// removeExpired removes sessions that have not received data
+// for longer than the session TTL
+func (r *uploadStore) removeExpired() {
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*.data"))
+	if err != nil {
+		log.WithError(err).Warn("Failed to list upload sessions.")
+		return
+	}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil || time.Since(fi.ModTime()) < defaults.PackageUploadSessionTTL {
+			continue
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".data")
+		log.Infof("Removing expired upload session %v.", id)
+		unlock := r.lock(id)
+		r.remove(id)
+		unlock()
+	}
+}
+
+// readOwned returns the session with the specified key.
+// Sessions of other users or repositories are reported as not found
+func (r *uploadStore) readOwned(key sessionKey) (*UploadSession, error) {
+	session, err := r.read(key.id)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if session.Owner != key.owner || session.Package.Repository != key.repository {
+		return nil, trace.NotFound("upload session %v not found", key.id)
+	}
+	return session, nil
+}
+
+func (r *uploadStore) read(id string) (*UploadSession, error) {
+	if uuid.Parse(id) == nil {
+		return nil, trace.BadParameter("invalid upload session ID %q", id)
+	}
+	data, err := ioutil.ReadFile(r.sessionPath(id))
+	if err != nil {
+		err = trace.ConvertSystemError(err)
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("upload session %v not found", id)
+		}
+		return nil, trace.Wrap(err)
+	}
+	var session UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	fi, err := os.Stat(r.dataPath(id))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	session.Offset = fi.Size()
+	return &session, nil
+}
+
+func (r *uploadStore) remove(id string) {
+	for _, path := range []string{r.sessionPath(id), r.dataPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warnf("Failed to remove %v.", path)
+		}
+	}
+	r.mu.Lock()
+	delete(r.locks, id)
+	r.mu.Unlock()
+}
+
+// lock locks the session with the specified ID and returns
+// the function to unlock it
+func (r *uploadStore) lock(id string) (unlock func()) {
+	r.mu.Lock()
+	l, ok := r.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		r.locks[id] = l
+	}
+	r.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// sessionKey identifies an upload session of a user in a repository
+type sessionKey struct {
+	// owner is the name of the user accessing the session
+	owner string
+	// repository is the repository the session uploads to
+	repository string
+	// id is the session ID
+	id string
+}
+
+func (r *uploadStore) sessionPath(id string) string {
+	return filepath.Join(r.dir, id+".json")
+}
+
+func (r *uploadStore) dataPath(id string) string {
+	return filepath.Join(r.dir, id+".data")
+}
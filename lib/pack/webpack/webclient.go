@@ -17,21 +17,28 @@ limitations under the License.
 package webpack
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha512"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/roundtrip"
 	telehttplib "github.com/gravitational/teleport/lib/httplib"
 	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
 )
 
 const CurrentVersion = "pack/v1"
@@ -139,7 +146,44 @@ func (c *Client) UpsertPackage(loc loc.Locator, data io.Reader, options ...pack.
 	return c.createOrUpsertPackage(loc, data, true, options...)
 }
 
+// createOrUpsertPackage uploads the package using a resumable upload
+// session if data can be rewound, and in a single request otherwise
 func (c *Client) createOrUpsertPackage(loc loc.Locator, data io.Reader, upsert bool, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
+	seeker, ok := data.(io.ReadSeeker)
+	if !ok {
+		// the size and hash of the data are not known in advance
+		return c.postPackage(loc, data, upsert, options...)
+	}
+	req, err := newUploadRequest(loc, seeker, upsert, options...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	session, err := c.CreateUpload(*req)
+	if trace.IsNotFound(err) || trace.IsNotImplemented(err) {
+		// the server does not support resumable uploads
+		return c.postPackage(loc, seeker, upsert, options...)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	envelope, err := c.upload(*session, seeker)
+	if trace.IsNotFound(err) {
+		// upload sessions are local to the controller that created them
+		// so the session is lost if requests are routed to another one
+		log.WithError(err).Warnf("Upload %v is gone, retrying in a single request.", session.ID)
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return c.postPackage(loc, seeker, upsert, options...)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return envelope, nil
+}
+
+// postPackage uploads the package in a single multipart form request
+func (c *Client) postPackage(loc loc.Locator, data io.Reader, upsert bool, options ...pack.PackageOption) (*pack.PackageEnvelope, error) {
 	file := roundtrip.File{
 		Name:     "package",
 		Filename: loc.String(),
@@ -204,25 +248,19 @@ func (c *Client) ReadPackage(loc loc.Locator) (*pack.PackageEnvelope, io.ReadClo
 	if err != nil {
 		return nil, nil, trace.Wrap(err)
 	}
-	endpoint := c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.Version, "file")
-
-	_, err = telehttplib.ConvertResponse(c.RoundTrip(func() (*http.Response, error) {
-		req, err := http.NewRequest("HEAD", endpoint, nil)
-		if err != nil {
-			return nil, err
-		}
-		c.SetAuthHeader(req.Header)
-		return c.HTTPClient().Do(req)
-	}))
-	if err != nil {
-		return nil, nil, trace.Wrap(err, "failed to read package %s", loc.String())
+	reader := &packageReader{
+		client:   c,
+		endpoint: c.Endpoint("repositories", loc.Repository, "packages", loc.Name, loc.Version, "file"),
+		envelope: *envelope,
+		hasher:   sha512.New(),
 	}
-	re, err := c.Client.GetFile(context.TODO(), endpoint, url.Values{})
+	// open the file right away so that errors are reported here
+	// rather than on the first read
+	err = reader.open()
 	if err != nil {
-		return nil, nil, trace.Wrap(err)
+		return nil, nil, trace.Wrap(err, "failed to read package %s", loc.String())
 	}
-
-	return envelope, re.Body(), nil
+	return envelope, reader, nil
 }
 
 func (c *Client) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, error) {
@@ -239,6 +277,61 @@ func (c *Client) ReadPackageEnvelope(loc loc.Locator) (*pack.PackageEnvelope, er
 	return envelope, nil
 }
 
+// GetPackageChunks returns the chunks of the package stored in deduplicating mode.
+// Returns trace.NotFound if the package is not stored as chunks
+func (c *Client) GetPackageChunks(loc loc.Locator) ([]pack.Chunk, error) {
+	out, err := c.Get(
+		c.Endpoint("repositories", loc.Repository,
+			"packages", loc.Name, loc.Version, "chunks"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var chunks []pack.Chunk
+	if err := json.Unmarshal(out.Bytes(), &chunks); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return chunks, nil
+}
+
+// ReadPackageChunk returns the data of the specified package chunk
+func (c *Client) ReadPackageChunk(loc loc.Locator, hash string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, c.Endpoint("repositories", loc.Repository,
+		"packages", loc.Name, loc.Version, "chunks", hash), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	c.SetAuthHeader(req.Header)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok && uerr.Err != nil {
+			return nil, trace.ConnectionProblem(uerr.Err, "%v", uerr)
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		return nil, trace.ReadError(resp.StatusCode, body)
+	}
+	return resp.Body, nil
+}
+
+// GetDedupStats returns the deduplication statistics of the package storage
+func (c *Client) GetDedupStats() (*pack.DedupStats, error) {
+	out, err := c.Get(c.Endpoint("stats", "dedup"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var stats pack.DedupStats
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &stats, nil
+}
+
 // PostForm is a generic method that issues http POST request to the server
 func (c *Client) PostForm(
 	endpoint string,
@@ -258,3 +351,304 @@ func (c *Client) Get(u string, params url.Values) (*roundtrip.Response, error) {
 func (c *Client) Delete(u string) (*roundtrip.Response, error) {
 	return telehttplib.ConvertResponse(c.Client.Delete(context.TODO(), u))
 }
+
+// CreateUpload starts a resumable package upload
+func (c *Client) CreateUpload(req UploadRequest) (*UploadSession, error) {
+	out, err := telehttplib.ConvertResponse(c.PostJSON(context.TODO(),
+		c.Endpoint("repositories", req.Package.Repository, "uploads"), req))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return unmarshalUploadSession(out.Bytes())
+}
+
+// GetUpload returns the state of the specified upload session
+func (c *Client) GetUpload(repository, id string) (*UploadSession, error) {
+	out, err := c.Get(c.Endpoint("repositories", repository, "uploads", id), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return unmarshalUploadSession(out.Bytes())
+}
+
+// UploadChunk sends the chunk of the package data for the specified
+// upload session at offset
+func (c *Client) UploadChunk(repository, id string, offset int64, chunk []byte) (*UploadSession, error) {
+	endpoint := c.Endpoint("repositories", repository, "uploads", id)
+	out, err := telehttplib.ConvertResponse(c.RoundTrip(func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPut,
+			fmt.Sprintf("%v?offset=%v", endpoint, offset), bytes.NewReader(chunk))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		c.SetAuthHeader(req.Header)
+		return c.HTTPClient().Do(req)
+	}))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return unmarshalUploadSession(out.Bytes())
+}
+
+// CompleteUpload verifies the data of the specified upload session
+// against the hash and creates the package
+func (c *Client) CompleteUpload(repository, id, hash string) (*pack.PackageEnvelope, error) {
+	out, err := telehttplib.ConvertResponse(c.PostJSON(context.TODO(),
+		c.Endpoint("repositories", repository, "uploads", id, "complete"),
+		completeUploadRequest{SHA512: hash}))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var envelope *pack.PackageEnvelope
+	if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return envelope, nil
+}
+
+// DeleteUpload aborts the specified upload session
+func (c *Client) DeleteUpload(repository, id string) error {
+	_, err := c.Delete(c.Endpoint("repositories", repository, "uploads", id))
+	return trace.Wrap(err)
+}
+
+// upload sends data to the server in chunks starting at the session offset
+// and creates the package.
+// Chunks that fail to upload due to transient errors are resent from
+// the offset last acknowledged by the server
+func (c *Client) upload(session UploadSession, data io.ReadSeeker) (*pack.PackageEnvelope, error) {
+	repository := session.Package.Repository
+	if session.Offset != 0 {
+		// the upload with the same contents was interrupted, skip
+		// the data that has already been received
+		_, err := data.Seek(session.Offset, io.SeekStart)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		log.Infof("Resuming upload of %v at offset %v.", session.Package, session.Offset)
+	}
+	offset := session.Offset
+	chunk := make([]byte, defaults.PackageUploadChunkSize)
+	for {
+		n, err := io.ReadFull(data, chunk)
+		if n != 0 {
+			offset, err = c.uploadChunkWithRetries(repository, session.ID, offset, chunk[:n])
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return c.CompleteUpload(repository, session.ID, session.SHA512)
+}
+
+// uploadChunkWithRetries uploads the chunk starting at offset and returns
+// the new session offset
+func (c *Client) uploadChunkWithRetries(repository, id string, offset int64, chunk []byte) (int64, error) {
+	start, end := offset, offset+int64(len(chunk))
+	err := utils.Retry(defaults.PackageTransferRetryPeriod, defaults.PackageTransferAttempts, func() error {
+		session, err := c.UploadChunk(repository, id, offset, chunk[offset-start:])
+		if err == nil {
+			offset = session.Offset
+			return nil
+		}
+		if trace.IsCompareFailed(err) {
+			// the previous attempt might have been received despite
+			// the failure, continue from where the server is at
+			session, err = c.GetUpload(repository, id)
+		}
+		if err != nil {
+			if isTransientTransferError(err) {
+				return utils.Continue("failed to upload chunk at %v: %v", offset, err)
+			}
+			return utils.Abort(err)
+		}
+		if session.Offset < start || session.Offset > end {
+			return utils.Abort(trace.CompareFailed("upload %v is at offset %v, outside of chunk %v-%v",
+				id, session.Offset, start, end))
+		}
+		offset = session.Offset
+		if offset == end {
+			return nil
+		}
+		return utils.Continue("upload %v is at offset %v", id, offset)
+	})
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return offset, nil
+}
+
+// newUploadRequest returns a request to upload the package data.
+// The size and hash of the data are computed in advance so that
+// the upload can be resumed if interrupted
+func newUploadRequest(loc loc.Locator, data io.ReadSeeker, upsert bool, options ...pack.PackageOption) (*UploadRequest, error) {
+	pkg := storage.Package{
+		Repository: loc.Repository,
+		Name:       loc.Name,
+		Version:    loc.Version,
+	}
+	for _, option := range options {
+		option(&pkg)
+	}
+	req := UploadRequest{
+		Package:  loc,
+		Upsert:   upsert,
+		Hidden:   pkg.Hidden,
+		Labels:   pkg.RuntimeLabels,
+		Type:     pkg.Type,
+		Manifest: pkg.Manifest,
+	}
+	hasher := sha512.New()
+	size, err := io.Copy(hasher, data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	_, err = data.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req.SizeBytes = size
+	req.SHA512 = fmt.Sprintf("%x", hasher.Sum(nil)[:sha512.Size/2])
+	return &req, nil
+}
+
+func unmarshalUploadSession(data []byte) (*UploadSession, error) {
+	var session UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &session, nil
+}
+
+// packageReader reads the package file.
+// If the download is interrupted by a transient error, it is resumed
+// with a range request at the current offset
+type packageReader struct {
+	client   *Client
+	endpoint string
+	// envelope describes the package being downloaded
+	envelope pack.PackageEnvelope
+	// hasher verifies the downloaded data against the package hash
+	hasher hash.Hash
+	// etag identifies the package contents when resuming the download
+	etag string
+	// offset is the number of bytes read so far
+	offset int64
+	// attempts is the number of times the download has been resumed
+	attempts int
+	body     io.ReadCloser
+}
+
+// Read reads the package data
+func (r *packageReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.body == nil {
+			err = r.open()
+		}
+		if err == nil {
+			n, err = r.body.Read(p)
+			r.offset += int64(n)
+			r.hasher.Write(p[:n])
+			if err == io.EOF {
+				return n, r.verify()
+			}
+		}
+		if err == nil || !isTransientTransferError(err) {
+			return n, err
+		}
+		r.Close()
+		r.attempts++
+		if r.attempts >= defaults.PackageTransferAttempts {
+			return n, trace.Wrap(err)
+		}
+		log.WithError(err).Warnf("Download of %v interrupted at offset %v, resuming.",
+			r.envelope.Locator, r.offset)
+		if n != 0 {
+			return n, nil
+		}
+		time.Sleep(defaults.PackageTransferRetryPeriod)
+	}
+}
+
+// Close closes the active request
+func (r *packageReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return trace.Wrap(err)
+}
+
+// open requests the package data starting at the current offset
+func (r *packageReader) open() error {
+	req, err := http.NewRequest(http.MethodGet, r.endpoint, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	r.client.SetAuthHeader(req.Header)
+	if r.offset != 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%v-", r.offset))
+		if r.etag != "" {
+			req.Header.Set("If-Range", r.etag)
+		}
+	}
+	resp, err := r.client.HTTPClient().Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok && uerr.Err != nil {
+			return trace.ConnectionProblem(uerr.Err, "%v", uerr)
+		}
+		return trace.ConvertSystemError(err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK && r.offset == 0:
+	case resp.StatusCode == http.StatusPartialContent && r.offset != 0:
+	case resp.StatusCode == http.StatusOK:
+		resp.Body.Close()
+		return trace.CompareFailed("package %v has changed during download", r.envelope.Locator)
+	default:
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		return trace.ReadError(resp.StatusCode, body)
+	}
+	if r.etag == "" {
+		r.etag = resp.Header.Get("ETag")
+	}
+	r.body = resp.Body
+	return nil
+}
+
+// verify makes sure the downloaded data matches the package envelope
+func (r *packageReader) verify() error {
+	if r.offset != r.envelope.SizeBytes {
+		return io.ErrUnexpectedEOF
+	}
+	hash := fmt.Sprintf("%x", r.hasher.Sum(nil)[:sha512.Size/2])
+	if hash != r.envelope.SHA512 {
+		return trace.BadParameter("package %v is corrupted: expected hash %v, got %v",
+			r.envelope.Locator, r.envelope.SHA512, hash)
+	}
+	return io.EOF
+}
+
+// isTransientTransferError returns true if the package transfer
+// failed due to a network issue and can be resumed
+func isTransientTransferError(err error) bool {
+	return trace.IsConnectionProblem(err) || utils.IsNetworkError(err) ||
+		trace.Unwrap(err) == io.ErrUnexpectedEOF
+}
+
+// packageETag returns the entity tag of the package file
+func packageETag(envelope pack.PackageEnvelope) string {
+	return fmt.Sprintf("%q", envelope.SHA512)
+}
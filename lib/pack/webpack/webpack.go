@@ -17,14 +17,18 @@ limitations under the License.
 package webpack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/pack"
@@ -33,6 +37,7 @@ import (
 
 	"github.com/gravitational/form"
 	"github.com/gravitational/roundtrip"
+	telehttplib "github.com/gravitational/teleport/lib/httplib"
 	teleservices "github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/trace"
 	"github.com/julienschmidt/httprouter"
@@ -43,12 +48,21 @@ type Config struct {
 	Packages      pack.PackageService
 	Users         users.Identity
 	Authenticator httplib.Authenticator
+	// UploadDir is the directory with resumable upload sessions.
+	// If unspecified, resumable uploads are not supported.
+	// Sessions are kept on local disk so an upload must be served
+	// by the same process from start to finish
+	UploadDir string
+	// UploadQuota is the total size of data active upload sessions
+	// can reserve in UploadDir. Defaults to defaults.PackageUploadQuota
+	UploadQuota int64
 }
 
 type Server struct {
 	httprouter.Router
 	cfg        Config
 	fileServer http.Handler
+	uploads    *uploadStore
 }
 
 func NewHandler(cfg Config) (*Server, error) {
@@ -61,6 +75,16 @@ func NewHandler(cfg Config) (*Server, error) {
 	h := &Server{
 		cfg: cfg,
 	}
+	if cfg.UploadQuota == 0 {
+		cfg.UploadQuota = defaults.PackageUploadQuota
+	}
+	if cfg.UploadDir != "" {
+		var err error
+		h.uploads, err = newUploadStore(cfg.UploadDir, cfg.UploadQuota)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
 
 	h.POST("/pack/v1/repositories", h.needsAuth(h.createRepository))
 	h.DELETE("/pack/v1/repositories/:repository", h.needsAuth(h.deleteRepository))
@@ -74,6 +98,18 @@ func NewHandler(cfg Config) (*Server, error) {
 	h.POST("/pack/v1/repositories/:repository/packages/:package_name/:package_version", h.needsAuth(h.updatePackageLabels))
 	h.DELETE("/pack/v1/repositories/:repository/packages/:package_name/:package_version", h.needsAuth(h.deletePackage))
 
+	// resumable uploads
+	h.POST("/pack/v1/repositories/:repository/uploads", h.needsAuth(h.createUpload))
+	h.GET("/pack/v1/repositories/:repository/uploads/:session", h.needsAuth(h.getUpload))
+	h.PUT("/pack/v1/repositories/:repository/uploads/:session", h.needsAuth(h.uploadChunk))
+	h.POST("/pack/v1/repositories/:repository/uploads/:session/complete", h.needsAuth(h.completeUpload))
+	h.DELETE("/pack/v1/repositories/:repository/uploads/:session", h.needsAuth(h.deleteUpload))
+
+	// deduplicated package storage
+	h.GET("/pack/v1/repositories/:repository/packages/:package_name/:package_version/chunks", h.needsAuth(h.getPackageChunks))
+	h.GET("/pack/v1/repositories/:repository/packages/:package_name/:package_version/chunks/:chunk", h.needsAuth(h.getPackageChunk))
+	h.GET("/pack/v1/stats/dedup", h.needsAuth(h.getDedupStats))
+
 	return h, nil
 }
 
@@ -169,7 +205,7 @@ func (s *Server) getPackageFile(w http.ResponseWriter, r *http.Request, p httpro
 		return trace.BadParameter(err.Error())
 	}
 
-	_, fileObject, err := service.ReadPackage(*loc)
+	envelope, fileObject, err := service.ReadPackage(*loc)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -180,10 +216,193 @@ func (s *Server) getPackageFile(w http.ResponseWriter, r *http.Request, p httpro
 		return trace.BadParameter("expected read seeker object")
 	}
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename=%v`, loc.String()))
-	http.ServeContent(w, r, loc.String(), time.Now(), readSeeker)
+	// ETag lets clients make sure the package has not changed
+	// when resuming the download with a range request
+	w.Header().Set("ETag", packageETag(*envelope))
+	http.ServeContent(w, r, loc.String(), envelope.Created, readSeeker)
+	return nil
+}
+
+// getPackageChunks returns the chunks of the package stored in deduplicating mode
+//
+// GET /pack/v1/repositories/:repository/packages/:package_name/:package_version/chunks
+func (s *Server) getPackageChunks(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	reader, ok := service.(pack.ChunkReader)
+	if !ok {
+		return trace.NotImplemented("package chunks are not supported")
+	}
+	chunks, err := reader.GetPackageChunks(*loc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, chunks)
+	return nil
+}
+
+// getPackageChunk returns the data of the specified package chunk
+//
+// GET /pack/v1/repositories/:repository/packages/:package_name/:package_version/chunks/:chunk
+func (s *Server) getPackageChunk(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	loc, err := loc.NewLocator(p.ByName("repository"), p.ByName("package_name"), p.ByName("package_version"))
+	if err != nil {
+		return trace.BadParameter("%v", err)
+	}
+	reader, ok := service.(pack.ChunkReader)
+	if !ok {
+		return trace.NotImplemented("package chunks are not supported")
+	}
+	data, err := reader.ReadPackageChunk(*loc, p.ByName("chunk"))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer data.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, err = io.Copy(w, data)
+	return trace.Wrap(err)
+}
+
+// getDedupStats returns the deduplication statistics of the package storage
+//
+// GET /pack/v1/stats/dedup
+func (s *Server) getDedupStats(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	reporter, ok := service.(pack.DedupReporter)
+	if !ok {
+		return trace.NotImplemented("deduplication statistics are not supported")
+	}
+	stats, err := reporter.GetDedupStats()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, stats)
+	return nil
+}
+
+// createUpload starts a new resumable package upload
+//
+// POST /pack/v1/repositories/:repository/uploads
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	if s.uploads == nil {
+		return trace.NotImplemented("resumable uploads are not supported")
+	}
+	var req UploadRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	if req.Package.Repository != p.ByName("repository") {
+		return trace.BadParameter("package %v does not belong to repository %v",
+			req.Package, p.ByName("repository"))
+	}
+	// the session reserves upload space, so fail early rather than
+	// after the package has been uploaded
+	checker, ok := service.(pack.CreateChecker)
+	if !ok {
+		return trace.AccessDenied("cannot check permissions to create package %v", req.Package)
+	}
+	if err := checker.CheckCreatePackage(req.Package, req.Upsert); err != nil {
+		return trace.Wrap(err)
+	}
+	if !req.Upsert {
+		_, err := service.GetRepository(req.Package.Repository)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	session, err := s.uploads.create(storage.UserFromContext(r.Context()), req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, session)
+	return nil
+}
+
+// getUpload returns the state of the upload session
+//
+// GET /pack/v1/repositories/:repository/uploads/:session
+func (s *Server) getUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	if s.uploads == nil {
+		return trace.NotImplemented("resumable uploads are not supported")
+	}
+	session, err := s.uploads.get(uploadSessionKey(r, p))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, session)
+	return nil
+}
+
+// uploadChunk appends the request body to the upload session data
+//
+// PUT /pack/v1/repositories/:repository/uploads/:session?offset=<offset>
+func (s *Server) uploadChunk(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	if s.uploads == nil {
+		return trace.NotImplemented("resumable uploads are not supported")
+	}
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil {
+		return trace.BadParameter("invalid offset %q", r.URL.Query().Get("offset"))
+	}
+	session, err := s.uploads.write(uploadSessionKey(r, p), offset, r.Body)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, session)
+	return nil
+}
+
+// completeUpload verifies the uploaded data and creates the package
+//
+// POST /pack/v1/repositories/:repository/uploads/:session/complete
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	if s.uploads == nil {
+		return trace.NotImplemented("resumable uploads are not supported")
+	}
+	var req completeUploadRequest
+	if err := telehttplib.ReadJSON(r, &req); err != nil {
+		return trace.Wrap(err)
+	}
+	var envelope *pack.PackageEnvelope
+	err := s.uploads.complete(uploadSessionKey(r, p), req.SHA512, func(session UploadSession, data io.Reader) (err error) {
+		if session.Upsert {
+			envelope, err = service.UpsertPackage(session.Package, data, session.Options()...)
+		} else {
+			envelope, err = service.CreatePackage(session.Package, data, session.Options()...)
+		}
+		return trace.Wrap(err)
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	roundtrip.ReplyJSON(w, http.StatusOK, envelope)
 	return nil
 }
 
+// deleteUpload aborts the upload session
+//
+// DELETE /pack/v1/repositories/:repository/uploads/:session
+func (s *Server) deleteUpload(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
+	if s.uploads == nil {
+		return trace.NotImplemented("resumable uploads are not supported")
+	}
+	if err := s.uploads.delete(uploadSessionKey(r, p)); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// uploadSessionKey returns the key of the upload session addressed by
+// the request on behalf of the authenticated user
+func uploadSessionKey(r *http.Request, p httprouter.Params) sessionKey {
+	return sessionKey{
+		owner:      storage.UserFromContext(r.Context()),
+		repository: p.ByName("repository"),
+		id:         p.ByName("session"),
+	}
+}
+
 func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprouter.Params, service pack.PackageService) error {
 	var files form.Files
 	var labelsMap string
@@ -236,7 +455,13 @@ func (s *Server) createPackage(w http.ResponseWriter, r *http.Request, p httprou
 		}
 	}()
 
-	loc, err := loc.ParseLocator(files[0].Name())
+	name := files[0].Name()
+	if !strings.Contains(name, "/") {
+		// mime/multipart strips the directory from file names which
+		// removes the repository from the locator
+		name = fmt.Sprintf("%v/%v", p.ByName("repository"), name)
+	}
+	loc, err := loc.ParseLocator(name)
 	if err != nil {
 		return trace.BadParameter(err.Error())
 	}
@@ -323,7 +548,7 @@ func (s *Server) needsAuth(fn authHandle) httprouter.Handle {
 					w, trace.AccessDenied("bad username or password"))
 				return
 			}
-			user, err := s.cfg.Users.GetTelekubeUser(session.GetUser())
+			user, err = s.cfg.Users.GetTelekubeUser(session.GetUser())
 			if err != nil {
 				log.Debugf("authenticate error: %v", err)
 				// we hide the error from the remote user to avoid giving any hints
@@ -344,6 +569,7 @@ func (s *Server) needsAuth(fn authHandle) httprouter.Handle {
 		// and pass it to the handlers, so every action will be automatically
 		// checked against current user
 		service := pack.PackagesWithACL(s.cfg.Packages, s.cfg.Users, user, checker)
+		r = r.WithContext(context.WithValue(r.Context(), constants.UserContext, user.GetName()))
 		if err := fn(w, r, p, service); err != nil {
 			if trace.IsAccessDenied(err) {
 				log.Debugf("access denied: %v", err)
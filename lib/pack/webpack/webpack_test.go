@@ -18,7 +18,9 @@ package webpack
 
 import (
 	"bytes"
+	"crypto/sha512"
 	"crypto/tls"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -36,6 +38,7 @@ import (
 	"github.com/gravitational/gravity/lib/storage/keyval"
 	"github.com/gravitational/gravity/lib/users"
 	"github.com/gravitational/gravity/lib/users/usersservice"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/roundtrip"
 	teleservices "github.com/gravitational/teleport/lib/services"
@@ -106,8 +109,9 @@ func (s *WebpackSuite) SetUpTest(c *C) {
 	})
 	c.Assert(err, IsNil)
 	webHandler, err := NewHandler(Config{
-		Users:    s.users,
-		Packages: service,
+		Users:     s.users,
+		Packages:  service,
+		UploadDir: filepath.Join(s.dir, defaults.PackageUploadsDir),
 	})
 	c.Assert(err, IsNil)
 	mux := http.NewServeMux()
@@ -293,3 +297,194 @@ func (s *WebpackSuite) TestUpsertPackages(c *C) {
 func (s *WebpackSuite) TestDeleteRepository(c *C) {
 	s.suite.DeleteRepository(c)
 }
+
+func (s *WebpackSuite) TestResumesUpload(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	data := bytes.Repeat([]byte("resumable upload "), 1024)
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+
+	// simulate an upload interrupted after the first chunk
+	req, err := newUploadRequest(locator, bytes.NewReader(data), false)
+	c.Assert(err, IsNil)
+	session, err := client.CreateUpload(*req)
+	c.Assert(err, IsNil)
+	session, err = client.UploadChunk("example.com", session.ID, 0, data[:100])
+	c.Assert(err, IsNil)
+	c.Assert(session.Offset, Equals, int64(100))
+
+	// chunks can only be appended at the current offset
+	_, err = client.UploadChunk("example.com", session.ID, 50, data[50:150])
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+
+	envelope, err := client.CreatePackage(locator, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	c.Assert(envelope.SizeBytes, Equals, int64(len(data)))
+
+	_, err = client.GetUpload("example.com", session.ID)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+
+	_, reader, err := client.ReadPackage(locator)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	out, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(out, DeepEquals, data)
+}
+
+func (s *WebpackSuite) TestRejectsCorruptedUpload(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+
+	session, err := client.CreateUpload(UploadRequest{Package: locator, SizeBytes: 13})
+	c.Assert(err, IsNil)
+	_, err = client.UploadChunk("example.com", session.ID, 0, []byte("hello, world!"))
+	c.Assert(err, IsNil)
+
+	_, err = client.CompleteUpload("example.com", session.ID, utils.MustSHA512Half([]byte("hello, world?")))
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+
+	_, err = client.ReadPackageEnvelope(locator)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestIsolatesUploadSessions(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	c.Assert(client.UpsertRepository("other.example.com", time.Time{}), IsNil)
+	data := []byte("hello, world!")
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+	req, err := newUploadRequest(locator, bytes.NewReader(data), false)
+	c.Assert(err, IsNil)
+	session, err := client.CreateUpload(*req)
+	c.Assert(err, IsNil)
+	c.Assert(session.Owner, Equals, s.adminUser.GetName())
+
+	// the session is not accessible via another repository
+	_, err = client.GetUpload("other.example.com", session.ID)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+	_, err = client.UploadChunk("other.example.com", session.ID, 0, data)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+
+	// nor by another user, even for the same package
+	role, err := users.NewAdminRole()
+	c.Assert(err, IsNil)
+	otherUser := storage.NewUser("other@a.example.com", storage.UserSpecV2{
+		Password: "other-password",
+		Type:     storage.AdminUser,
+		Roles:    []string{role.GetName()},
+	})
+	c.Assert(s.users.UpsertUser(otherUser), IsNil)
+	other, err := NewAuthenticatedClient(s.webServer.URL, otherUser.GetName(), "other-password")
+	c.Assert(err, IsNil)
+	_, err = other.GetUpload("example.com", session.ID)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+	err = other.DeleteUpload("example.com", session.ID)
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+	otherSession, err := other.CreateUpload(*req)
+	c.Assert(err, IsNil)
+	c.Assert(otherSession.ID, Not(Equals), session.ID)
+
+	_, err = client.UploadChunk("example.com", session.ID, 0, data)
+	c.Assert(err, IsNil)
+	_, err = client.CompleteUpload("example.com", session.ID, "")
+	c.Assert(err, IsNil)
+}
+
+func (s *WebpackSuite) TestLimitsUploadSize(c *C) {
+	store, err := newUploadStore(c.MkDir(), 20)
+	c.Assert(err, IsNil)
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+
+	_, err = store.create("alice", UploadRequest{Package: locator})
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+
+	session, err := store.create("alice", UploadRequest{Package: locator, SizeBytes: 15})
+	c.Assert(err, IsNil)
+	_, err = store.create("bob", UploadRequest{Package: locator, SizeBytes: 10})
+	c.Assert(trace.IsLimitExceeded(err), Equals, true, Commentf("%v", err))
+
+	// data beyond the declared size is rejected
+	key := sessionKey{owner: "alice", repository: "example.com", id: session.ID}
+	_, err = store.write(key, 0, bytes.NewReader(bytes.Repeat([]byte("a"), 16)))
+	c.Assert(trace.IsBadParameter(err), Equals, true, Commentf("%v", err))
+	session, err = store.get(key)
+	c.Assert(err, IsNil)
+	c.Assert(session.Offset, Equals, int64(0))
+
+	// the space is released once the session is gone
+	c.Assert(store.delete(key), IsNil)
+	_, err = store.create("bob", UploadRequest{Package: locator, SizeBytes: 10})
+	c.Assert(err, IsNil)
+}
+
+func (s *WebpackSuite) TestResumesDownload(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	data := []byte("hello, resumable download!")
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+	envelope, err := client.CreatePackage(locator, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+
+	// pick up the download after the first 7 bytes have been read
+	reader := &packageReader{
+		client:   client,
+		endpoint: client.Endpoint("repositories", "example.com", "packages", "package", "0.0.1", "file"),
+		envelope: *envelope,
+		hasher:   sha512.New(),
+		etag:     packageETag(*envelope),
+		offset:   7,
+	}
+	reader.hasher.Write(data[:7])
+	defer reader.Close()
+	out, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(out), Equals, string(data[7:]))
+
+	// the download cannot be resumed if the package has changed
+	reader = &packageReader{
+		client:   client,
+		endpoint: reader.endpoint,
+		envelope: *envelope,
+		hasher:   sha512.New(),
+		etag:     `"stale"`,
+		offset:   7,
+	}
+	err = reader.open()
+	c.Assert(trace.IsCompareFailed(err), Equals, true, Commentf("%v", err))
+}
+
+func (s *WebpackSuite) TestRequiresPermissionToCreateUpload(c *C) {
+	client := s.suite.S.(*Client)
+	c.Assert(client.UpsertRepository("example.com", time.Time{}), IsNil)
+	role, err := teleservices.NewRole("reader", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Namespaces: []string{defaults.Namespace},
+			Rules: []teleservices.Rule{
+				{
+					Resources: []string{storage.KindRepository},
+					Verbs:     []string{teleservices.VerbList, teleservices.VerbRead},
+				},
+			},
+		},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(s.users.UpsertRole(role, storage.Forever), IsNil)
+	reader := storage.NewUser("reader@a.example.com", storage.UserSpecV2{
+		Password: "reader-password",
+		Type:     storage.AdminUser,
+		Roles:    []string{role.GetName()},
+	})
+	c.Assert(s.users.UpsertUser(reader), IsNil)
+	readerClient, err := NewAuthenticatedClient(s.webServer.URL, reader.GetName(), "reader-password")
+	c.Assert(err, IsNil)
+
+	locator := loc.MustParseLocator("example.com/package:0.0.1")
+	for _, upsert := range []bool{false, true} {
+		req, err := newUploadRequest(locator, bytes.NewReader([]byte("hello, world!")), upsert)
+		c.Assert(err, IsNil)
+		_, err = readerClient.CreateUpload(*req)
+		c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("upsert=%v: %v", upsert, err))
+	}
+}
@@ -41,6 +41,7 @@ import (
 	blobcluster "github.com/gravitational/gravity/lib/blob/cluster"
 	blobfs "github.com/gravitational/gravity/lib/blob/fs"
 	blobhandler "github.com/gravitational/gravity/lib/blob/handler"
+	blobs3 "github.com/gravitational/gravity/lib/blob/s3"
 	"github.com/gravitational/gravity/lib/clients"
 	cloudaws "github.com/gravitational/gravity/lib/cloudprovider/aws"
 	"github.com/gravitational/gravity/lib/constants"
@@ -51,6 +52,7 @@ import (
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/modules"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/auditsink"
 	"github.com/gravitational/gravity/lib/ops/monitoring"
 	"github.com/gravitational/gravity/lib/ops/opshandler"
 	"github.com/gravitational/gravity/lib/ops/opsroute"
@@ -209,7 +211,13 @@ func New(ctx context.Context, cfg processconfig.Config, tcfg telecfg.FileConfig)
 		return nil, trace.Wrap(err)
 	}
 
-	objects, err := blobfs.New(filepath.Join(cfg.DataDir, defaults.PackagesDir))
+	var objects blob.Objects
+	switch cfg.Pack.BlobBackend {
+	case constants.BlobBackendS3:
+		objects, err = blobs3.New(cfg.Pack.S3)
+	default:
+		objects, err = blobfs.New(filepath.Join(cfg.DataDir, defaults.PackagesDir))
+	}
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -232,16 +240,20 @@ func New(ctx context.Context, cfg processconfig.Config, tcfg telecfg.FileConfig)
 		return nil, trace.Wrap(err)
 	}
 
-	clusterObjects, err := blobcluster.New(blobcluster.Config{
-		Local:         objects,
-		Backend:       backend,
-		GetPeer:       peerPool.GetPeer,
-		ID:            processID,
-		AdvertiseAddr: fmt.Sprintf("https://%v", peerAddr.Addr),
-		// TODO: set WriteFactor to the number of controller instances
-	})
-	if err != nil {
-		return nil, trace.Wrap(err)
+	// S3 bucket is shared by all instances so BLOBs need not be replicated
+	clusterObjects := objects
+	if cfg.Pack.BlobBackend != constants.BlobBackendS3 {
+		clusterObjects, err = blobcluster.New(blobcluster.Config{
+			Local:         objects,
+			Backend:       backend,
+			GetPeer:       peerPool.GetPeer,
+			ID:            processID,
+			AdvertiseAddr: fmt.Sprintf("https://%v", peerAddr.Addr),
+			// TODO: set WriteFactor to the number of controller instances
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
 	}
 
 	packages, err := localpack.New(localpack.Config{
@@ -249,6 +261,7 @@ func New(ctx context.Context, cfg processconfig.Config, tcfg telecfg.FileConfig)
 		DownloadURL: fmt.Sprintf("https://%v", cfg.Pack.GetAddr().Addr),
 		UnpackedDir: filepath.Join(cfg.DataDir, defaults.PackagesDir, defaults.UnpackedDir),
 		Objects:     clusterObjects,
+		Dedup:       cfg.Pack.Dedup,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -924,6 +937,60 @@ func (p *Process) initCertificateAuthority() error {
 	return nil
 }
 
+// newMonitoring returns the monitoring provider for the metrics backend
+// configured in the manifest of the local cluster
+func (p *Process) newMonitoring(client *kubernetes.Clientset) (monitoring.Monitoring, error) {
+	var config monitoring.Config
+	if client != nil {
+		config.Prometheus.Client = client
+	}
+	ext, err := p.getMonitoringExtension()
+	if err != nil {
+		// The metrics backend is not essential for the process to start,
+		// fall back to the default backend
+		p.WithError(err).Warnf("Failed to determine metrics backend, will use %v.",
+			constants.MonitoringBackendInfluxDB)
+	}
+	if ext != nil {
+		config.Backend = ext.Backend
+		if ext.Prometheus != nil {
+			config.Prometheus.URL = ext.Prometheus.URL
+			config.Prometheus.Namespace = ext.Prometheus.Namespace
+			config.Prometheus.StatefulSet = ext.Prometheus.StatefulSet
+		}
+	}
+	mon, err := monitoring.New(config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	p.Infof("Using %v metrics backend.", mon.GetType())
+	return mon, nil
+}
+
+// getMonitoringExtension returns the monitoring extension from the manifest
+// of the local cluster or nil if there is no local cluster yet
+func (p *Process) getMonitoringExtension() (*schema.MonitoringExtension, error) {
+	if p.mode == constants.ComponentInstaller {
+		return nil, nil
+	}
+	cluster, err := p.backend.GetLocalSite(defaults.SystemAccountID)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	application, err := p.applications.GetApp(cluster.App.Locator())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ext := application.Manifest.Extensions
+	if ext == nil {
+		return nil, nil
+	}
+	return ext.Monitoring, nil
+}
+
 func (p *Process) inKubernetes() bool {
 	return os.Getenv(constants.EnvPodIP) != ""
 }
@@ -1095,6 +1162,7 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		Packages:      p.packages,
 		Users:         p.identity,
 		Authenticator: p.handlers.WebProxy.GetHandler().AuthenticateRequest,
+		UploadDir:     filepath.Join(p.cfg.DataDir, defaults.PackagesDir, defaults.PackageUploadsDir),
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -1166,7 +1234,7 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		Backend: p.backend,
 	})
 
-	mon, err := monitoring.NewInfluxDB()
+	mon, err := p.newMonitoring(client)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -1176,6 +1244,23 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		logs = opsservice.NewLogForwardersControl(client)
 	}
 
+	var auditForwarder opsservice.AuditForwarder
+	if p.mode != constants.ComponentInstaller {
+		forwarder, err := auditsink.NewForwarder(auditsink.Config{
+			Backend:     p.backend,
+			FieldLogger: p.WithField(trace.Component, "auditsink"),
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		forwarder.Start()
+		go func() {
+			<-p.context.Done()
+			forwarder.Close()
+		}()
+		auditForwarder = forwarder
+	}
+
 	agentService := opsservice.NewAgentService(p.agentServer, peerStore,
 		p.cfg.Pack.GetAddr().Addr, logrus.StandardLogger())
 	p.agentService = agentService
@@ -1212,6 +1297,7 @@ func (p *Process) initService(ctx context.Context) (err error) {
 		InstallLogFiles: p.cfg.InstallLogFiles,
 		LogForwarders:   logs,
 		AuditLog:        authClient,
+		AuditForwarder:  auditForwarder,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	blobs3 "github.com/gravitational/gravity/lib/blob/s3"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/helm"
@@ -192,6 +193,17 @@ func (cfg *Config) CheckAndSetDefaults() error {
 		return trace.BadParameter("missing pack service advertise address")
 	}
 
+	switch cfg.Pack.BlobBackend {
+	case "", constants.BlobBackendFS:
+		cfg.Pack.BlobBackend = constants.BlobBackendFS
+	case constants.BlobBackendS3:
+		if err := cfg.Pack.S3.Check(); err != nil {
+			return trace.Wrap(err)
+		}
+	default:
+		return trace.BadParameter("unsupported blob backend: %v", cfg.Pack.BlobBackend)
+	}
+
 	if cfg.HealthAddr.IsEmpty() {
 		cfg.HealthAddr = teleutils.NetAddr{
 			AddrNetwork: "tcp",
@@ -308,6 +320,15 @@ type PackageServiceConfig struct {
 
 	// ReadDir is an optional directory with extra packages
 	ReadDir string `yaml:"read_dir"`
+
+	// BlobBackend is a type of storage for package BLOBs, either fs or s3
+	BlobBackend string `yaml:"blob_backend"`
+	// S3 provides S3 BLOB storage config options
+	S3 blobs3.Config `yaml:"s3"`
+
+	// Dedup enables deduplicating package storage: packages are stored
+	// as content-defined chunks shared between packages
+	Dedup bool `yaml:"dedup"`
 }
 
 // PeerAddr returns peer address of the package service instance
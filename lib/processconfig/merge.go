@@ -64,6 +64,13 @@ func MergeConfig(into, from *Config) error {
 	if !from.Pack.PublicAdvertiseAddr.IsEmpty() {
 		into.Pack.PublicAdvertiseAddr = from.Pack.PublicAdvertiseAddr
 	}
+	if from.Pack.BlobBackend != "" {
+		into.Pack.BlobBackend = from.Pack.BlobBackend
+		into.Pack.S3 = from.Pack.S3
+	}
+	if from.Pack.Dedup {
+		into.Pack.Dedup = from.Pack.Dedup
+	}
 	for i := range from.Users {
 		into.Users = append(into.Users, from.Users[i])
 	}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	"github.com/gravitational/trace"
+)
+
+// CheckpointCredentials signs and verifies operation checkpoint bundles
+// with the agent credentials
+type CheckpointCredentials struct {
+	// KeyPair is the key pair used to sign checkpoints.
+	// Only required for signing
+	KeyPair *tls.Certificate
+	// Roots is the pool of certificate authorities trusted to verify
+	// the checkpoint signer
+	Roots *x509.CertPool
+}
+
+// LoadCheckpointCredentials loads the checkpoint credentials from the agent
+// credentials in the specified directory
+func LoadCheckpointCredentials(secretsDir string) (*CheckpointCredentials, error) {
+	keyPair, err := tls.LoadX509KeyPair(
+		filepath.Join(secretsDir, fmt.Sprintf("%s.%s", pb.Client, pb.Cert)),
+		filepath.Join(secretsDir, fmt.Sprintf("%s.%s", pb.Client, pb.Key)))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	caCert, err := ioutil.ReadFile(filepath.Join(secretsDir, fmt.Sprintf("%s.%s", pb.CA, pb.Cert)))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	roots := x509.NewCertPool()
+	if ok := roots.AppendCertsFromPEM(caCert); !ok {
+		return nil, trace.BadParameter("failed to add CA to pool")
+	}
+	return &CheckpointCredentials{
+		KeyPair: &keyPair,
+		Roots:   roots,
+	}, nil
+}
+
+// Sign returns the signature of the SHA-256 digest of the payload
+// and the PEM-encoded certificate of the signer
+func (r CheckpointCredentials) Sign(payload []byte) (signature, certificate []byte, err error) {
+	if r.KeyPair == nil || len(r.KeyPair.Certificate) == 0 {
+		return nil, nil, trace.BadParameter("missing signing key pair")
+	}
+	signer, ok := r.KeyPair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, trace.BadParameter("unsupported private key type %T", r.KeyPair.PrivateKey)
+	}
+	digest := sha256.Sum256(payload)
+	signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	certificate = pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: r.KeyPair.Certificate[0],
+	})
+	return signature, certificate, nil
+}
+
+// Verify makes sure that the payload has been signed by a certificate
+// issued by one of the trusted authorities
+func (r CheckpointCredentials) Verify(payload, signature, certificate []byte) error {
+	block, _ := pem.Decode(certificate)
+	if block == nil {
+		return trace.BadParameter("checkpoint bundle is missing signer certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     r.Roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return trace.AccessDenied("checkpoint signer is not trusted: %v", err)
+	}
+	var algo x509.SignatureAlgorithm
+	switch cert.PublicKeyAlgorithm {
+	case x509.RSA:
+		algo = x509.SHA256WithRSA
+	case x509.ECDSA:
+		algo = x509.ECDSAWithSHA256
+	default:
+		return trace.BadParameter("unsupported signer key algorithm %v", cert.PublicKeyAlgorithm)
+	}
+	err = cert.CheckSignature(algo, payload, signature)
+	if err != nil {
+		return trace.AccessDenied("invalid checkpoint signature: %v", err)
+	}
+	return nil
+}
@@ -60,6 +60,15 @@ func (c *client) Validate(ctx context.Context, req *validationpb.ValidateRequest
 	return nil, trace.Wrap(err)
 }
 
+// GetMetrics returns the health and resource usage metrics of the remote node
+func (c *client) GetMetrics(ctx context.Context) (*pb.AgentMetrics, error) {
+	metrics, err := c.agent.GetMetrics(ctx, &types.Empty{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return metrics, nil
+}
+
 // Shutdown requests remote agent to quit
 func (c *client) Shutdown(ctx context.Context) error {
 	_, err := c.agent.Shutdown(ctx, &types.Empty{})
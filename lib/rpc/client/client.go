@@ -25,6 +25,7 @@ import (
 	validationpb "github.com/gravitational/gravity/lib/network/validation/proto"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/trace"
@@ -53,6 +54,18 @@ type Client interface {
 	CheckPorts(context.Context, *validationpb.CheckPortsRequest) (*validationpb.CheckPortsResponse, error)
 	// CheckBandwidth executes a network bandwidth test
 	CheckBandwidth(context.Context, *validationpb.CheckBandwidthRequest) (*validationpb.CheckBandwidthResponse, error)
+	// StatFile returns information about the file on the remote node
+	StatFile(ctx context.Context, path string) (*pb.FileInfo, error)
+	// UploadFile uploads the local file to the specified path on the remote node
+	UploadFile(ctx context.Context, localPath, remotePath string) (*pb.FileInfo, error)
+	// DownloadFile downloads the file from the remote node to the specified local path
+	DownloadFile(ctx context.Context, remotePath, localPath string) (*pb.FileInfo, error)
+	// Exec runs an interactive session on the remote node and returns the command exit code
+	Exec(ctx context.Context, config ExecConfig) (exitCode int, err error)
+	// RotateCredentials replaces the credentials of the remote agent
+	RotateCredentials(ctx context.Context, archive utils.TLSArchive) error
+	// GetMetrics returns the health and resource usage metrics of the remote node
+	GetMetrics(context.Context) (*pb.AgentMetrics, error)
 	// Shutdown requests remote agent to shut down
 	Shutdown(context.Context) error
 	// Close will close communication with remote agent
@@ -0,0 +1,48 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// RotateCredentials replaces the credentials of the remote agent
+// with the credentials from the specified archive
+func (c *client) RotateCredentials(ctx context.Context, archive utils.TLSArchive) error {
+	var req pb.RotateCredentialsRequest
+	for _, name := range []string{pb.Server, pb.Client, pb.CA} {
+		keyPair, err := archive.GetKeyPair(name)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		switch name {
+		case pb.Server:
+			req.ServerCert, req.ServerKey = keyPair.CertPEM, keyPair.KeyPEM
+		case pb.Client:
+			req.ClientCert, req.ClientKey = keyPair.CertPEM, keyPair.KeyPEM
+		case pb.CA:
+			req.CaCert = keyPair.CertPEM
+		}
+	}
+	_, err := c.agent.RotateCredentials(ctx, &req)
+	return trace.Wrap(err)
+}
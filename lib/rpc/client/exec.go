@@ -0,0 +1,147 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	"github.com/gravitational/trace"
+)
+
+// ExecConfig describes an interactive session
+type ExecConfig struct {
+	// Args specifies the command to run
+	Args []string
+	// Term specifies the terminal type
+	Term string
+	// Size specifies the initial terminal window size
+	Size WindowSize
+	// Stdin is the session input
+	Stdin io.Reader
+	// Stdout receives the session output
+	Stdout io.Writer
+	// Resize receives terminal window size changes
+	Resize <-chan WindowSize
+	// Signals receives names of the signals to forward to the command
+	Signals <-chan string
+}
+
+// WindowSize describes the terminal window size
+type WindowSize struct {
+	// Rows specifies the window height
+	Rows uint32
+	// Cols specifies the window width
+	Cols uint32
+}
+
+// Exec runs an interactive session executing the command specified
+// with config in a pseudo-terminal on the remote node.
+// Once the input has been exhausted, the command receives the end of file
+// and the window size changes and signals are no longer forwarded.
+// Returns the exit code of the command
+func (c *client) Exec(ctx context.Context, config ExecConfig) (exitCode int, err error) {
+	if len(config.Args) == 0 {
+		return 0, trace.BadParameter("at least one argument is required")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.agent.Exec(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	err = stream.Send(&pb.ExecRequest{
+		Args: config.Args,
+		Term: config.Term,
+		Rows: config.Size.Rows,
+		Cols: config.Size.Cols,
+	})
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+
+	var input chan []byte
+	if config.Stdin != nil {
+		input = make(chan []byte)
+		go readInput(ctx, config.Stdin, input)
+	}
+	go func() {
+		for {
+			var req pb.ExecRequest
+			select {
+			case data, ok := <-input:
+				if !ok {
+					// signal the end of input so the command
+					// does not wait for more
+					stream.CloseSend()
+					return
+				}
+				req.Input = data
+			case size := <-config.Resize:
+				req.Rows, req.Cols = size.Rows, size.Cols
+			case signal := <-config.Signals:
+				req.Signal = signal
+			case <-ctx.Done():
+				return
+			}
+			if err := stream.Send(&req); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return 0, trace.ConnectionProblem(nil, "session closed before the command exited")
+		}
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+		if len(resp.Output) != 0 && config.Stdout != nil {
+			if _, err := config.Stdout.Write(resp.Output); err != nil {
+				return 0, trace.ConvertSystemError(err)
+			}
+		}
+		if resp.Exited {
+			return int(resp.ExitCode), nil
+		}
+	}
+}
+
+// readInput reads the session input from r into the specified channel
+// closing the channel once the input has been exhausted
+func readInput(ctx context.Context, r io.Reader, input chan<- []byte) {
+	defer close(input)
+	for {
+		buf := make([]byte, defaults.RPCAgentExecBufferSize)
+		n, err := r.Read(buf)
+		if n > 0 {
+			select {
+			case input <- buf[:n]:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatFile returns information about the file on the remote node
+func (c *client) StatFile(ctx context.Context, path string) (*pb.FileInfo, error) {
+	info, err := c.agent.StatFile(ctx, &pb.StatFileRequest{Path: path})
+	if err != nil {
+		return nil, convertFileError(err)
+	}
+	return info, nil
+}
+
+// UploadFile uploads the local file to the specified path on the remote node.
+// If a previous upload of the file has been interrupted, the upload
+// is resumed. The upload is skipped if the remote node already has
+// the identical file
+func (c *client) UploadFile(ctx context.Context, localPath, remotePath string) (*pb.FileInfo, error) {
+	checksum, err := utils.SHA512HalfFile(localPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	info, err := c.agent.StatFile(ctx, &pb.StatFileRequest{Path: remotePath})
+	if err == nil && info.Sha512 == checksum {
+		return info, nil
+	}
+	var offset int64
+	partial, err := c.agent.StatFile(ctx, &pb.StatFileRequest{Path: remotePath, Partial: true})
+	if err == nil {
+		offset = partial.Size_
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if offset > fi.Size() {
+		// the incomplete upload is of a different file
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+
+	stream, err := c.agent.PutFile(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	req := &pb.PutFileRequest{
+		Path:   remotePath,
+		Mode:   uint32(fi.Mode().Perm()),
+		Offset: offset,
+		Sha512: checksum,
+	}
+	buf := make([]byte, defaults.RPCAgentFileChunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, trace.ConvertSystemError(err)
+		}
+		req.Data = buf[:n]
+		if err := stream.Send(req); err != nil {
+			if err == io.EOF {
+				// the server has aborted the upload,
+				// the error is returned below
+				break
+			}
+			return nil, trace.Wrap(err)
+		}
+		if n < len(buf) {
+			break
+		}
+		req = &pb.PutFileRequest{}
+	}
+	info, err = stream.CloseAndRecv()
+	if err != nil {
+		return nil, convertFileError(err)
+	}
+	return info, nil
+}
+
+// DownloadFile downloads the file from the remote node to the specified local path.
+// If a previous download of the file has been interrupted, the download
+// is resumed
+func (c *client) DownloadFile(ctx context.Context, remotePath, localPath string) (*pb.FileInfo, error) {
+	info, err := c.agent.StatFile(ctx, &pb.StatFileRequest{Path: remotePath})
+	if err != nil {
+		return nil, convertFileError(err)
+	}
+	if info.IsDir {
+		return nil, trace.BadParameter("%v is a directory", remotePath)
+	}
+
+	partialPath := localPath + defaults.PartialFileSuffix
+	f, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY, defaults.PrivateFileMask)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if offset > info.Size_ {
+		// the incomplete download is of a different file
+		if err := f.Truncate(0); err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		if offset, err = f.Seek(0, io.SeekStart); err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+	}
+
+	stream, err := c.agent.GetFile(ctx, &pb.GetFileRequest{Path: remotePath, Offset: offset})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if _, err := f.Write(chunk.Data); err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+
+	checksum, err := utils.SHA512HalfFile(partialPath)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if checksum != info.Sha512 {
+		os.Remove(partialPath)
+		return nil, trace.BadParameter("checksum mismatch for %v: expected %v, got %v",
+			remotePath, info.Sha512, checksum)
+	}
+	if err := os.Chmod(partialPath, os.FileMode(info.Mode)); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(partialPath, localPath); err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return info, nil
+}
+
+// convertFileError converts the gRPC error returned by the file RPCs
+// to the corresponding trace error
+func convertFileError(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return trace.NotFound("%v", status.Convert(err).Message())
+	}
+	if status.Code(err) == codes.PermissionDenied {
+		return trace.AccessDenied("%v", status.Convert(err).Message())
+	}
+	return trace.Wrap(err)
+}
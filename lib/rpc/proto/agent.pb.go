@@ -566,6 +566,459 @@ func (m *PeerLeaveRequest) GetSystemInfo() []byte {
 	return nil
 }
 
+// PutFileRequest is a part of the file upload
+type PutFileRequest struct {
+	// Path specifies the absolute path to the file on the agent's node
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Mode specifies the file permissions
+	Mode uint32 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Offset specifies the offset to resume the incomplete upload from
+	Offset int64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// SHA512 specifies the checksum of the complete file
+	Sha512 string `protobuf:"bytes,4,opt,name=sha512,proto3" json:"sha512,omitempty"`
+	// Data specifies the next part of the file contents
+	Data []byte `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *PutFileRequest) Reset()                    { *m = PutFileRequest{} }
+func (m *PutFileRequest) String() string            { return proto1.CompactTextString(m) }
+func (*PutFileRequest) ProtoMessage()               {}
+func (*PutFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{9} }
+
+func (m *PutFileRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *PutFileRequest) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *PutFileRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *PutFileRequest) GetSha512() string {
+	if m != nil {
+		return m.Sha512
+	}
+	return ""
+}
+
+func (m *PutFileRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// GetFileRequest is a request to download the file
+type GetFileRequest struct {
+	// Path specifies the absolute path to the file on the agent's node
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Offset specifies the offset to resume the download from
+	Offset int64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (m *GetFileRequest) Reset()                    { *m = GetFileRequest{} }
+func (m *GetFileRequest) String() string            { return proto1.CompactTextString(m) }
+func (*GetFileRequest) ProtoMessage()               {}
+func (*GetFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{10} }
+
+func (m *GetFileRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *GetFileRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+// FileChunk is a part of the file download
+type FileChunk struct {
+	// Data specifies the next part of the file contents
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *FileChunk) Reset()                    { *m = FileChunk{} }
+func (m *FileChunk) String() string            { return proto1.CompactTextString(m) }
+func (*FileChunk) ProtoMessage()               {}
+func (*FileChunk) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{11} }
+
+func (m *FileChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// StatFileRequest is a request to query information about the file
+type StatFileRequest struct {
+	// Path specifies the absolute path to the file on the agent's node
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Partial specifies whether to query the incomplete upload of the file
+	Partial bool `protobuf:"varint,2,opt,name=partial,proto3" json:"partial,omitempty"`
+}
+
+func (m *StatFileRequest) Reset()                    { *m = StatFileRequest{} }
+func (m *StatFileRequest) String() string            { return proto1.CompactTextString(m) }
+func (*StatFileRequest) ProtoMessage()               {}
+func (*StatFileRequest) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{12} }
+
+func (m *StatFileRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *StatFileRequest) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+// FileInfo describes the file on the agent's node
+type FileInfo struct {
+	// Path specifies the absolute path to the file
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Size specifies the file size in bytes
+	Size_ int64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Mode specifies the file permissions
+	Mode uint32 `protobuf:"varint,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// SHA512 specifies the file checksum.
+	// Empty for directories
+	Sha512 string `protobuf:"bytes,4,opt,name=sha512,proto3" json:"sha512,omitempty"`
+	// IsDir specifies whether the file is a directory
+	IsDir bool `protobuf:"varint,5,opt,name=is_dir,json=isDir,proto3" json:"is_dir,omitempty"`
+}
+
+func (m *FileInfo) Reset()                    { *m = FileInfo{} }
+func (m *FileInfo) String() string            { return proto1.CompactTextString(m) }
+func (*FileInfo) ProtoMessage()               {}
+func (*FileInfo) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{13} }
+
+func (m *FileInfo) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *FileInfo) GetSize_() int64 {
+	if m != nil {
+		return m.Size_
+	}
+	return 0
+}
+
+func (m *FileInfo) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *FileInfo) GetSha512() string {
+	if m != nil {
+		return m.Sha512
+	}
+	return ""
+}
+
+func (m *FileInfo) GetIsDir() bool {
+	if m != nil {
+		return m.IsDir
+	}
+	return false
+}
+
+// ExecRequest is a part of the interactive session input
+type ExecRequest struct {
+	// Args specifies the command to run.
+	// Only set in the first request of the session
+	Args []string `protobuf:"bytes,1,rep,name=args" json:"args,omitempty"`
+	// Term specifies the terminal type.
+	// Only set in the first request of the session
+	Term string `protobuf:"bytes,2,opt,name=term,proto3" json:"term,omitempty"`
+	// Rows specifies the height of the terminal window
+	Rows uint32 `protobuf:"varint,3,opt,name=rows,proto3" json:"rows,omitempty"`
+	// Cols specifies the width of the terminal window
+	Cols uint32 `protobuf:"varint,4,opt,name=cols,proto3" json:"cols,omitempty"`
+	// Input specifies the next part of the command input
+	Input []byte `protobuf:"bytes,5,opt,name=input,proto3" json:"input,omitempty"`
+	// Signal specifies the name of the signal to send to the command
+	Signal string `protobuf:"bytes,6,opt,name=signal,proto3" json:"signal,omitempty"`
+}
+
+func (m *ExecRequest) Reset()                    { *m = ExecRequest{} }
+func (m *ExecRequest) String() string            { return proto1.CompactTextString(m) }
+func (*ExecRequest) ProtoMessage()               {}
+func (*ExecRequest) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{14} }
+
+func (m *ExecRequest) GetArgs() []string {
+	if m != nil {
+		return m.Args
+	}
+	return nil
+}
+
+func (m *ExecRequest) GetTerm() string {
+	if m != nil {
+		return m.Term
+	}
+	return ""
+}
+
+func (m *ExecRequest) GetRows() uint32 {
+	if m != nil {
+		return m.Rows
+	}
+	return 0
+}
+
+func (m *ExecRequest) GetCols() uint32 {
+	if m != nil {
+		return m.Cols
+	}
+	return 0
+}
+
+func (m *ExecRequest) GetInput() []byte {
+	if m != nil {
+		return m.Input
+	}
+	return nil
+}
+
+func (m *ExecRequest) GetSignal() string {
+	if m != nil {
+		return m.Signal
+	}
+	return ""
+}
+
+// ExecResponse is a part of the interactive session output
+type ExecResponse struct {
+	// Output specifies the next part of the command output
+	Output []byte `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	// Exited specifies whether the command has exited
+	Exited bool `protobuf:"varint,2,opt,name=exited,proto3" json:"exited,omitempty"`
+	// ExitCode specifies the exit code of the command
+	ExitCode int32 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+}
+
+func (m *ExecResponse) Reset()                    { *m = ExecResponse{} }
+func (m *ExecResponse) String() string            { return proto1.CompactTextString(m) }
+func (*ExecResponse) ProtoMessage()               {}
+func (*ExecResponse) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{15} }
+
+func (m *ExecResponse) GetOutput() []byte {
+	if m != nil {
+		return m.Output
+	}
+	return nil
+}
+
+func (m *ExecResponse) GetExited() bool {
+	if m != nil {
+		return m.Exited
+	}
+	return false
+}
+
+func (m *ExecResponse) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+// RotateCredentialsRequest describes the new agent credentials
+type RotateCredentialsRequest struct {
+	// CaCert specifies the certificate authority bundle in PEM format
+	CaCert []byte `protobuf:"bytes,1,opt,name=ca_cert,json=caCert,proto3" json:"ca_cert,omitempty"`
+	// ServerCert specifies the server certificate in PEM format
+	ServerCert []byte `protobuf:"bytes,2,opt,name=server_cert,json=serverCert,proto3" json:"server_cert,omitempty"`
+	// ServerKey specifies the server private key in PEM format
+	ServerKey []byte `protobuf:"bytes,3,opt,name=server_key,json=serverKey,proto3" json:"server_key,omitempty"`
+	// ClientCert specifies the client certificate in PEM format
+	ClientCert []byte `protobuf:"bytes,4,opt,name=client_cert,json=clientCert,proto3" json:"client_cert,omitempty"`
+	// ClientKey specifies the client private key in PEM format
+	ClientKey []byte `protobuf:"bytes,5,opt,name=client_key,json=clientKey,proto3" json:"client_key,omitempty"`
+}
+
+func (m *RotateCredentialsRequest) Reset()                    { *m = RotateCredentialsRequest{} }
+func (m *RotateCredentialsRequest) String() string            { return proto1.CompactTextString(m) }
+func (*RotateCredentialsRequest) ProtoMessage()               {}
+func (*RotateCredentialsRequest) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{16} }
+
+func (m *RotateCredentialsRequest) GetCACert() []byte {
+	if m != nil {
+		return m.CaCert
+	}
+	return nil
+}
+
+func (m *RotateCredentialsRequest) GetServerCert() []byte {
+	if m != nil {
+		return m.ServerCert
+	}
+	return nil
+}
+
+func (m *RotateCredentialsRequest) GetServerKey() []byte {
+	if m != nil {
+		return m.ServerKey
+	}
+	return nil
+}
+
+func (m *RotateCredentialsRequest) GetClientCert() []byte {
+	if m != nil {
+		return m.ClientCert
+	}
+	return nil
+}
+
+func (m *RotateCredentialsRequest) GetClientKey() []byte {
+	if m != nil {
+		return m.ClientKey
+	}
+	return nil
+}
+
+// AgentMetrics describes the health and resource usage of the agent's node
+type AgentMetrics struct {
+	// Version specifies the agent version
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Load1 specifies the system load average over the last minute
+	Load1 float64 `protobuf:"fixed64,2,opt,name=load1,proto3" json:"load1,omitempty"`
+	// Load5 specifies the system load average over the last 5 minutes
+	Load5 float64 `protobuf:"fixed64,3,opt,name=load5,proto3" json:"load5,omitempty"`
+	// Load15 specifies the system load average over the last 15 minutes
+	Load15 float64 `protobuf:"fixed64,4,opt,name=load15,proto3" json:"load15,omitempty"`
+	// MemoryTotal specifies the total amount of memory in bytes
+	MemoryTotal uint64 `protobuf:"varint,5,opt,name=memory_total,json=memoryTotal,proto3" json:"memory_total,omitempty"`
+	// MemoryFree specifies the amount of memory available to processes in bytes
+	MemoryFree uint64 `protobuf:"varint,6,opt,name=memory_free,json=memoryFree,proto3" json:"memory_free,omitempty"`
+	// Disks lists the disk usage of gravity state directories
+	Disks []*DiskUsage `protobuf:"bytes,7,rep,name=disks" json:"disks,omitempty"`
+	// RunningCommands specifies the number of commands the agent is executing
+	RunningCommands int32 `protobuf:"varint,8,opt,name=running_commands,json=runningCommands,proto3" json:"running_commands,omitempty"`
+}
+
+func (m *AgentMetrics) Reset()                    { *m = AgentMetrics{} }
+func (m *AgentMetrics) String() string            { return proto1.CompactTextString(m) }
+func (*AgentMetrics) ProtoMessage()               {}
+func (*AgentMetrics) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{17} }
+
+func (m *AgentMetrics) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *AgentMetrics) GetLoad1() float64 {
+	if m != nil {
+		return m.Load1
+	}
+	return 0
+}
+
+func (m *AgentMetrics) GetLoad5() float64 {
+	if m != nil {
+		return m.Load5
+	}
+	return 0
+}
+
+func (m *AgentMetrics) GetLoad15() float64 {
+	if m != nil {
+		return m.Load15
+	}
+	return 0
+}
+
+func (m *AgentMetrics) GetMemoryTotal() uint64 {
+	if m != nil {
+		return m.MemoryTotal
+	}
+	return 0
+}
+
+func (m *AgentMetrics) GetMemoryFree() uint64 {
+	if m != nil {
+		return m.MemoryFree
+	}
+	return 0
+}
+
+func (m *AgentMetrics) GetDisks() []*DiskUsage {
+	if m != nil {
+		return m.Disks
+	}
+	return nil
+}
+
+func (m *AgentMetrics) GetRunningCommands() int32 {
+	if m != nil {
+		return m.RunningCommands
+	}
+	return 0
+}
+
+// DiskUsage describes the usage of the filesystem of a directory
+type DiskUsage struct {
+	// Path specifies the directory
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Total specifies the size of the filesystem in bytes
+	Total uint64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	// Free specifies the amount of free space in bytes
+	Free uint64 `protobuf:"varint,3,opt,name=free,proto3" json:"free,omitempty"`
+}
+
+func (m *DiskUsage) Reset()                    { *m = DiskUsage{} }
+func (m *DiskUsage) String() string            { return proto1.CompactTextString(m) }
+func (*DiskUsage) ProtoMessage()               {}
+func (*DiskUsage) Descriptor() ([]byte, []int) { return fileDescriptorAgent, []int{18} }
+
+func (m *DiskUsage) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *DiskUsage) GetTotal() uint64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *DiskUsage) GetFree() uint64 {
+	if m != nil {
+		return m.Free
+	}
+	return 0
+}
+
 func init() {
 	proto1.RegisterType((*CommandArgs)(nil), "proto.CommandArgs")
 	proto1.RegisterType((*Message)(nil), "proto.Message")
@@ -576,6 +1029,16 @@ func init() {
 	proto1.RegisterType((*LogEntry)(nil), "proto.LogEntry")
 	proto1.RegisterType((*PeerJoinRequest)(nil), "proto.PeerJoinRequest")
 	proto1.RegisterType((*PeerLeaveRequest)(nil), "proto.PeerLeaveRequest")
+	proto1.RegisterType((*PutFileRequest)(nil), "proto.PutFileRequest")
+	proto1.RegisterType((*GetFileRequest)(nil), "proto.GetFileRequest")
+	proto1.RegisterType((*FileChunk)(nil), "proto.FileChunk")
+	proto1.RegisterType((*StatFileRequest)(nil), "proto.StatFileRequest")
+	proto1.RegisterType((*FileInfo)(nil), "proto.FileInfo")
+	proto1.RegisterType((*ExecRequest)(nil), "proto.ExecRequest")
+	proto1.RegisterType((*ExecResponse)(nil), "proto.ExecResponse")
+	proto1.RegisterType((*RotateCredentialsRequest)(nil), "proto.RotateCredentialsRequest")
+	proto1.RegisterType((*AgentMetrics)(nil), "proto.AgentMetrics")
+	proto1.RegisterType((*DiskUsage)(nil), "proto.DiskUsage")
 	proto1.RegisterEnum("proto.ExecOutput_FD", ExecOutput_FD_name, ExecOutput_FD_value)
 	proto1.RegisterEnum("proto.LogEntry_Level", LogEntry_Level_name, LogEntry_Level_value)
 }
@@ -602,6 +1065,25 @@ type AgentClient interface {
 	PeerJoin(ctx context.Context, in *PeerJoinRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 	// PeerLeave receives a "leave" request from a peer and initiates its shutdown
 	PeerLeave(ctx context.Context, in *PeerLeaveRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// PutFile uploads a file to the agent's node.
+	// The first request of the stream specifies the file to write
+	// and the following requests carry the file contents
+	PutFile(ctx context.Context, opts ...grpc.CallOption) (Agent_PutFileClient, error)
+	// GetFile streams the contents of the file on the agent's node
+	GetFile(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (Agent_GetFileClient, error)
+	// StatFile returns information about the file on the agent's node
+	StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*FileInfo, error)
+	// Exec starts an interactive session executing the command specified
+	// with the first request of the stream in a pseudo-terminal.
+	// The following requests carry the input, window size changes
+	// and signals and the responses carry the command output
+	Exec(ctx context.Context, opts ...grpc.CallOption) (Agent_ExecClient, error)
+	// RotateCredentials replaces the agent's credentials.
+	// The new credentials are used for the new connections while
+	// the established connections are kept intact
+	RotateCredentials(ctx context.Context, in *RotateCredentialsRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// GetMetrics returns the health and resource usage metrics of the agent's node
+	GetMetrics(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AgentMetrics, error)
 }
 
 type agentClient struct {
@@ -671,33 +1153,176 @@ func (c *agentClient) PeerLeave(ctx context.Context, in *PeerLeaveRequest, opts
 	return out, nil
 }
 
-// Server API for Agent service
+func (c *agentClient) PutFile(ctx context.Context, opts ...grpc.CallOption) (Agent_PutFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Agent_serviceDesc.Streams[1], c.cc, "/proto.Agent/PutFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentPutFileClient{stream}
+	return x, nil
+}
 
-type AgentServer interface {
-	// Shutdown requests the agent to shut down
-	Shutdown(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
-	// Command executes a command specified with CommandArgs.
-	// The output of the command is streamed as a result.
-	Command(*CommandArgs, Agent_CommandServer) error
-	// PeerJoin receives a connection from a peer.
-	// The peer configuration allows this agent to establish a reverse
-	// connection to the remote peer to execute remote commands
-	PeerJoin(context.Context, *PeerJoinRequest) (*google_protobuf.Empty, error)
-	// PeerLeave receives a "leave" request from a peer and initiates its shutdown
-	PeerLeave(context.Context, *PeerLeaveRequest) (*google_protobuf.Empty, error)
+type Agent_PutFileClient interface {
+	Send(*PutFileRequest) error
+	CloseAndRecv() (*FileInfo, error)
+	grpc.ClientStream
 }
 
-func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
-	s.RegisterService(&_Agent_serviceDesc, srv)
+type agentPutFileClient struct {
+	grpc.ClientStream
 }
 
-func _Agent_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(google_protobuf.Empty)
-	if err := dec(in); err != nil {
+func (x *agentPutFileClient) Send(m *PutFileRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentPutFileClient) CloseAndRecv() (*FileInfo, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	if interceptor == nil {
-		return srv.(AgentServer).Shutdown(ctx, in)
+	m := new(FileInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) GetFile(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (Agent_GetFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Agent_serviceDesc.Streams[2], c.cc, "/proto.Agent/GetFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentGetFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Agent_GetFileClient interface {
+	Recv() (*FileChunk, error)
+	grpc.ClientStream
+}
+
+type agentGetFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentGetFileClient) Recv() (*FileChunk, error) {
+	m := new(FileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) StatFile(ctx context.Context, in *StatFileRequest, opts ...grpc.CallOption) (*FileInfo, error) {
+	out := new(FileInfo)
+	err := grpc.Invoke(ctx, "/proto.Agent/StatFile", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) Exec(ctx context.Context, opts ...grpc.CallOption) (Agent_ExecClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Agent_serviceDesc.Streams[3], c.cc, "/proto.Agent/Exec", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentExecClient{stream}
+	return x, nil
+}
+
+type Agent_ExecClient interface {
+	Send(*ExecRequest) error
+	Recv() (*ExecResponse, error)
+	grpc.ClientStream
+}
+
+type agentExecClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentExecClient) Send(m *ExecRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *agentExecClient) Recv() (*ExecResponse, error) {
+	m := new(ExecResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *agentClient) RotateCredentials(ctx context.Context, in *RotateCredentialsRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/proto.Agent/RotateCredentials", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentClient) GetMetrics(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AgentMetrics, error) {
+	out := new(AgentMetrics)
+	err := grpc.Invoke(ctx, "/proto.Agent/GetMetrics", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Agent service
+
+type AgentServer interface {
+	// Shutdown requests the agent to shut down
+	Shutdown(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
+	// Command executes a command specified with CommandArgs.
+	// The output of the command is streamed as a result.
+	Command(*CommandArgs, Agent_CommandServer) error
+	// PeerJoin receives a connection from a peer.
+	// The peer configuration allows this agent to establish a reverse
+	// connection to the remote peer to execute remote commands
+	PeerJoin(context.Context, *PeerJoinRequest) (*google_protobuf.Empty, error)
+	// PeerLeave receives a "leave" request from a peer and initiates its shutdown
+	PeerLeave(context.Context, *PeerLeaveRequest) (*google_protobuf.Empty, error)
+	// PutFile uploads a file to the agent's node.
+	// The first request of the stream specifies the file to write
+	// and the following requests carry the file contents
+	PutFile(Agent_PutFileServer) error
+	// GetFile streams the contents of the file on the agent's node
+	GetFile(*GetFileRequest, Agent_GetFileServer) error
+	// StatFile returns information about the file on the agent's node
+	StatFile(context.Context, *StatFileRequest) (*FileInfo, error)
+	// Exec starts an interactive session executing the command specified
+	// with the first request of the stream in a pseudo-terminal.
+	// The following requests carry the input, window size changes
+	// and signals and the responses carry the command output
+	Exec(Agent_ExecServer) error
+	// RotateCredentials replaces the agent's credentials.
+	// The new credentials are used for the new connections while
+	// the established connections are kept intact
+	RotateCredentials(context.Context, *RotateCredentialsRequest) (*google_protobuf.Empty, error)
+	// GetMetrics returns the health and resource usage metrics of the agent's node
+	GetMetrics(context.Context, *google_protobuf.Empty) (*AgentMetrics, error)
+}
+
+func RegisterAgentServer(s *grpc.Server, srv AgentServer) {
+	s.RegisterService(&_Agent_serviceDesc, srv)
+}
+
+func _Agent_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).Shutdown(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
@@ -766,6 +1391,133 @@ func _Agent_PeerLeave_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Agent_PutFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServer).PutFile(&agentPutFileServer{stream})
+}
+
+type Agent_PutFileServer interface {
+	SendAndClose(*FileInfo) error
+	Recv() (*PutFileRequest, error)
+	grpc.ServerStream
+}
+
+type agentPutFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentPutFileServer) SendAndClose(m *FileInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentPutFileServer) Recv() (*PutFileRequest, error) {
+	m := new(PutFileRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Agent_GetFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetFileRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServer).GetFile(m, &agentGetFileServer{stream})
+}
+
+type Agent_GetFileServer interface {
+	Send(*FileChunk) error
+	grpc.ServerStream
+}
+
+type agentGetFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentGetFileServer) Send(m *FileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Agent_StatFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).StatFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Agent/StatFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).StatFile(ctx, req.(*StatFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentServer).Exec(&agentExecServer{stream})
+}
+
+type Agent_ExecServer interface {
+	Send(*ExecResponse) error
+	Recv() (*ExecRequest, error)
+	grpc.ServerStream
+}
+
+type agentExecServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentExecServer) Send(m *ExecResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *agentExecServer) Recv() (*ExecRequest, error) {
+	m := new(ExecRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Agent_RotateCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).RotateCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Agent/RotateCredentials",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).RotateCredentials(ctx, req.(*RotateCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Agent_GetMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).GetMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Agent/GetMetrics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).GetMetrics(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Agent_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.Agent",
 	HandlerType: (*AgentServer)(nil),
@@ -782,6 +1534,18 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PeerLeave",
 			Handler:    _Agent_PeerLeave_Handler,
 		},
+		{
+			MethodName: "StatFile",
+			Handler:    _Agent_StatFile_Handler,
+		},
+		{
+			MethodName: "RotateCredentials",
+			Handler:    _Agent_RotateCredentials_Handler,
+		},
+		{
+			MethodName: "GetMetrics",
+			Handler:    _Agent_GetMetrics_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -789,6 +1553,22 @@ var _Agent_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Agent_Command_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "PutFile",
+			Handler:       _Agent_PutFile_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetFile",
+			Handler:       _Agent_GetFile_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Exec",
+			Handler:       _Agent_Exec_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "agent.proto",
 }
@@ -1253,256 +2033,2313 @@ func (m *PeerLeaveRequest) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
-func encodeFixed64Agent(dAtA []byte, offset int, v uint64) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	dAtA[offset+4] = uint8(v >> 32)
-	dAtA[offset+5] = uint8(v >> 40)
-	dAtA[offset+6] = uint8(v >> 48)
-	dAtA[offset+7] = uint8(v >> 56)
-	return offset + 8
-}
-func encodeFixed32Agent(dAtA []byte, offset int, v uint32) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	return offset + 4
-}
-func encodeVarintAgent(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *PutFileRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+	return dAtA[:n], nil
 }
-func (m *CommandArgs) Size() (n int) {
+
+func (m *PutFileRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if len(m.Args) > 0 {
-		for _, s := range m.Args {
-			l = len(s)
-			n += 1 + l + sovAgent(uint64(l))
-		}
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
-	if m.SelfCommand {
-		n += 2
+	if m.Mode != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Mode))
 	}
-	if len(m.Env) > 0 {
-		for k, v := range m.Env {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovAgent(uint64(len(k))) + 1 + len(v) + sovAgent(uint64(len(v)))
-			n += mapEntrySize + 1 + sovAgent(uint64(mapEntrySize))
-		}
+	if m.Offset != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Offset))
 	}
-	return n
-}
-
-func (m *Message) Size() (n int) {
-	var l int
-	_ = l
-	if m.Element != nil {
-		n += m.Element.Size()
+	if len(m.Sha512) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Sha512)))
+		i += copy(dAtA[i:], m.Sha512)
 	}
-	return n
+	if len(m.Data) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
+	}
+	return i, nil
 }
 
-func (m *Message_ExecStarted) Size() (n int) {
-	var l int
-	_ = l
-	if m.ExecStarted != nil {
-		l = m.ExecStarted.Size()
-		n += 1 + l + sovAgent(uint64(l))
+func (m *GetFileRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
-func (m *Message_ExecCompleted) Size() (n int) {
+
+func (m *GetFileRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.ExecCompleted != nil {
-		l = m.ExecCompleted.Size()
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
-	return n
+	if m.Offset != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Offset))
+	}
+	return i, nil
 }
-func (m *Message_ExecOutput) Size() (n int) {
-	var l int
-	_ = l
-	if m.ExecOutput != nil {
-		l = m.ExecOutput.Size()
-		n += 1 + l + sovAgent(uint64(l))
+
+func (m *FileChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
-func (m *Message_LogEntry) Size() (n int) {
+
+func (m *FileChunk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.LogEntry != nil {
-		l = m.LogEntry.Size()
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.Data) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
 	}
-	return n
+	return i, nil
 }
-func (m *Message_Error) Size() (n int) {
-	var l int
-	_ = l
-	if m.Error != nil {
-		l = m.Error.Size()
-		n += 1 + l + sovAgent(uint64(l))
+
+func (m *StatFileRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
-func (m *ExecStarted) Size() (n int) {
+
+func (m *StatFileRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Seq != 0 {
-		n += 1 + sovAgent(uint64(m.Seq))
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
-	if len(m.Args) > 0 {
-		for _, s := range m.Args {
-			l = len(s)
-			n += 1 + l + sovAgent(uint64(l))
+	if m.Partial {
+		dAtA[i] = 0x10
+		i++
+		if m.Partial {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
-	if len(m.Env) > 0 {
-		for _, s := range m.Env {
-			l = len(s)
-			n += 1 + l + sovAgent(uint64(l))
-		}
+	return i, nil
+}
+
+func (m *FileInfo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *ExecCompleted) Size() (n int) {
+func (m *FileInfo) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Seq != 0 {
-		n += 1 + sovAgent(uint64(m.Seq))
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
 	}
-	if m.ExitCode != 0 {
-		n += 1 + sovAgent(uint64(m.ExitCode))
+	if m.Size_ != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Size_))
 	}
-	if m.Error != nil {
-		l = m.Error.Size()
-		n += 1 + l + sovAgent(uint64(l))
+	if m.Mode != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Mode))
 	}
-	return n
-}
-
-func (m *Error) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Message)
-	if l > 0 {
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.Sha512) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Sha512)))
+		i += copy(dAtA[i:], m.Sha512)
 	}
-	if len(m.Traces) > 0 {
-		for _, s := range m.Traces {
-			l = len(s)
-			n += 1 + l + sovAgent(uint64(l))
+	if m.IsDir {
+		dAtA[i] = 0x28
+		i++
+		if m.IsDir {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
-	return n
+	return i, nil
+}
+func (m *ExecRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
 
-func (m *ExecOutput) Size() (n int) {
+func (m *ExecRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	if m.Seq != 0 {
-		n += 1 + sovAgent(uint64(m.Seq))
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
 	}
-	if m.Fd != 0 {
-		n += 1 + sovAgent(uint64(m.Fd))
+	if len(m.Term) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Term)))
+		i += copy(dAtA[i:], m.Term)
 	}
-	l = len(m.Data)
-	if l > 0 {
-		n += 1 + l + sovAgent(uint64(l))
+	if m.Rows != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Rows))
 	}
-	return n
+	if m.Cols != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Cols))
+	}
+	if len(m.Input) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Input)))
+		i += copy(dAtA[i:], m.Input)
+	}
+	if len(m.Signal) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Signal)))
+		i += copy(dAtA[i:], m.Signal)
+	}
+	return i, nil
 }
 
-func (m *LogEntry) Size() (n int) {
+func (m *ExecResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExecResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Message)
-	if l > 0 {
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.Output) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Output)))
+		i += copy(dAtA[i:], m.Output)
 	}
-	if len(m.Fields) > 0 {
-		for k, v := range m.Fields {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovAgent(uint64(len(k))) + 1 + len(v) + sovAgent(uint64(len(v)))
-			n += mapEntrySize + 1 + sovAgent(uint64(mapEntrySize))
+	if m.Exited {
+		dAtA[i] = 0x10
+		i++
+		if m.Exited {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
 	}
-	if m.Level != 0 {
-		n += 1 + sovAgent(uint64(m.Level))
+	if m.ExitCode != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.ExitCode))
 	}
-	if len(m.Traces) > 0 {
-		for _, s := range m.Traces {
-			l = len(s)
-			n += 1 + l + sovAgent(uint64(l))
-		}
+	return i, nil
+}
+func (m *RotateCredentialsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *PeerJoinRequest) Size() (n int) {
+func (m *RotateCredentialsRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Addr)
-	if l > 0 {
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.CaCert) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.CaCert)))
+		i += copy(dAtA[i:], m.CaCert)
 	}
-	if m.Config != nil {
-		l = m.Config.Size()
+	if len(m.ServerCert) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.ServerCert)))
+		i += copy(dAtA[i:], m.ServerCert)
+	}
+	if len(m.ServerKey) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.ServerKey)))
+		i += copy(dAtA[i:], m.ServerKey)
+	}
+	if len(m.ClientCert) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.ClientCert)))
+		i += copy(dAtA[i:], m.ClientCert)
+	}
+	if len(m.ClientKey) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.ClientKey)))
+		i += copy(dAtA[i:], m.ClientKey)
+	}
+	return i, nil
+}
+func (m *AgentMetrics) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AgentMetrics) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Version) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
+	}
+	if m.Load1 != 0 {
+		dAtA[i] = 0x11
+		i++
+		i = encodeFixed64Agent(dAtA, i, uint64(math.Float64bits(float64(m.Load1))))
+	}
+	if m.Load5 != 0 {
+		dAtA[i] = 0x19
+		i++
+		i = encodeFixed64Agent(dAtA, i, uint64(math.Float64bits(float64(m.Load5))))
+	}
+	if m.Load15 != 0 {
+		dAtA[i] = 0x21
+		i++
+		i = encodeFixed64Agent(dAtA, i, uint64(math.Float64bits(float64(m.Load15))))
+	}
+	if m.MemoryTotal != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.MemoryTotal))
+	}
+	if m.MemoryFree != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.MemoryFree))
+	}
+	if len(m.Disks) > 0 {
+		for _, msg := range m.Disks {
+			dAtA[i] = 0x3a
+			i++
+			i = encodeVarintAgent(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.RunningCommands != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.RunningCommands))
+	}
+	return i, nil
+}
+
+func (m *DiskUsage) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DiskUsage) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Path) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(len(m.Path)))
+		i += copy(dAtA[i:], m.Path)
+	}
+	if m.Total != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Total))
+	}
+	if m.Free != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintAgent(dAtA, i, uint64(m.Free))
+	}
+	return i, nil
+}
+func encodeFixed64Agent(dAtA []byte, offset int, v uint64) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	dAtA[offset+4] = uint8(v >> 32)
+	dAtA[offset+5] = uint8(v >> 40)
+	dAtA[offset+6] = uint8(v >> 48)
+	dAtA[offset+7] = uint8(v >> 56)
+	return offset + 8
+}
+func encodeFixed32Agent(dAtA []byte, offset int, v uint32) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	return offset + 4
+}
+func encodeVarintAgent(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *CommandArgs) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	if m.SelfCommand {
+		n += 2
+	}
+	if len(m.Env) > 0 {
+		for k, v := range m.Env {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovAgent(uint64(len(k))) + 1 + len(v) + sovAgent(uint64(len(v)))
+			n += mapEntrySize + 1 + sovAgent(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *Message) Size() (n int) {
+	var l int
+	_ = l
+	if m.Element != nil {
+		n += m.Element.Size()
+	}
+	return n
+}
+
+func (m *Message_ExecStarted) Size() (n int) {
+	var l int
+	_ = l
+	if m.ExecStarted != nil {
+		l = m.ExecStarted.Size()
 		n += 1 + l + sovAgent(uint64(l))
 	}
-	l = len(m.SystemInfo)
-	if l > 0 {
+	return n
+}
+func (m *Message_ExecCompleted) Size() (n int) {
+	var l int
+	_ = l
+	if m.ExecCompleted != nil {
+		l = m.ExecCompleted.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+func (m *Message_ExecOutput) Size() (n int) {
+	var l int
+	_ = l
+	if m.ExecOutput != nil {
+		l = m.ExecOutput.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+func (m *Message_LogEntry) Size() (n int) {
+	var l int
+	_ = l
+	if m.LogEntry != nil {
+		l = m.LogEntry.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+func (m *Message_Error) Size() (n int) {
+	var l int
+	_ = l
+	if m.Error != nil {
+		l = m.Error.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+func (m *ExecStarted) Size() (n int) {
+	var l int
+	_ = l
+	if m.Seq != 0 {
+		n += 1 + sovAgent(uint64(m.Seq))
+	}
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	if len(m.Env) > 0 {
+		for _, s := range m.Env {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExecCompleted) Size() (n int) {
+	var l int
+	_ = l
+	if m.Seq != 0 {
+		n += 1 + sovAgent(uint64(m.Seq))
+	}
+	if m.ExitCode != 0 {
+		n += 1 + sovAgent(uint64(m.ExitCode))
+	}
+	if m.Error != nil {
+		l = m.Error.Size()
 		n += 1 + l + sovAgent(uint64(l))
 	}
 	return n
 }
 
-func (m *PeerLeaveRequest) Size() (n int) {
+func (m *Error) Size() (n int) {
 	var l int
 	_ = l
-	l = len(m.Addr)
+	l = len(m.Message)
 	if l > 0 {
 		n += 1 + l + sovAgent(uint64(l))
 	}
-	if m.Config != nil {
-		l = m.Config.Size()
-		n += 1 + l + sovAgent(uint64(l))
+	if len(m.Traces) > 0 {
+		for _, s := range m.Traces {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
 	}
-	l = len(m.SystemInfo)
+	return n
+}
+
+func (m *ExecOutput) Size() (n int) {
+	var l int
+	_ = l
+	if m.Seq != 0 {
+		n += 1 + sovAgent(uint64(m.Seq))
+	}
+	if m.Fd != 0 {
+		n += 1 + sovAgent(uint64(m.Fd))
+	}
+	l = len(m.Data)
 	if l > 0 {
 		n += 1 + l + sovAgent(uint64(l))
 	}
 	return n
 }
 
-func sovAgent(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *LogEntry) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Message)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if len(m.Fields) > 0 {
+		for k, v := range m.Fields {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovAgent(uint64(len(k))) + 1 + len(v) + sovAgent(uint64(len(v)))
+			n += mapEntrySize + 1 + sovAgent(uint64(mapEntrySize))
+		}
+	}
+	if m.Level != 0 {
+		n += 1 + sovAgent(uint64(m.Level))
+	}
+	if len(m.Traces) > 0 {
+		for _, s := range m.Traces {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *PeerJoinRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Addr)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Config != nil {
+		l = m.Config.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.SystemInfo)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+
+func (m *PeerLeaveRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Addr)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Config != nil {
+		l = m.Config.Size()
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.SystemInfo)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+
+func (m *PutFileRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Mode != 0 {
+		n += 1 + sovAgent(uint64(m.Mode))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovAgent(uint64(m.Offset))
+	}
+	l = len(m.Sha512)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+
+func (m *GetFileRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovAgent(uint64(m.Offset))
+	}
+	return n
+}
+
+func (m *FileChunk) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+
+func (m *StatFileRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Partial {
+		n += 2
+	}
+	return n
+}
+
+func (m *FileInfo) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Size_ != 0 {
+		n += 1 + sovAgent(uint64(m.Size_))
+	}
+	if m.Mode != 0 {
+		n += 1 + sovAgent(uint64(m.Mode))
+	}
+	l = len(m.Sha512)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.IsDir {
+		n += 2
+	}
+	return n
+}
+func (m *ExecRequest) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Args) > 0 {
+		for _, s := range m.Args {
+			l = len(s)
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	l = len(m.Term)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Rows != 0 {
+		n += 1 + sovAgent(uint64(m.Rows))
+	}
+	if m.Cols != 0 {
+		n += 1 + sovAgent(uint64(m.Cols))
+	}
+	l = len(m.Input)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.Signal)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+
+func (m *ExecResponse) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Output)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Exited {
+		n += 2
+	}
+	if m.ExitCode != 0 {
+		n += 1 + sovAgent(uint64(m.ExitCode))
+	}
+	return n
+}
+func (m *RotateCredentialsRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.CaCert)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.ServerCert)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.ServerKey)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.ClientCert)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	l = len(m.ClientKey)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	return n
+}
+func (m *AgentMetrics) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Load1 != 0 {
+		n += 9
+	}
+	if m.Load5 != 0 {
+		n += 9
+	}
+	if m.Load15 != 0 {
+		n += 9
+	}
+	if m.MemoryTotal != 0 {
+		n += 1 + sovAgent(uint64(m.MemoryTotal))
+	}
+	if m.MemoryFree != 0 {
+		n += 1 + sovAgent(uint64(m.MemoryFree))
+	}
+	if len(m.Disks) > 0 {
+		for _, e := range m.Disks {
+			l = e.Size()
+			n += 1 + l + sovAgent(uint64(l))
+		}
+	}
+	if m.RunningCommands != 0 {
+		n += 1 + sovAgent(uint64(m.RunningCommands))
+	}
+	return n
+}
+
+func (m *DiskUsage) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Path)
+	if l > 0 {
+		n += 1 + l + sovAgent(uint64(l))
+	}
+	if m.Total != 0 {
+		n += 1 + sovAgent(uint64(m.Total))
+	}
+	if m.Free != 0 {
+		n += 1 + sovAgent(uint64(m.Free))
+	}
+	return n
+}
+func sovAgent(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozAgent(x uint64) (n int) {
+	return sovAgent(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *CommandArgs) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CommandArgs: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CommandArgs: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SelfCommand", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SelfCommand = bool(v != 0)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Env", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Env == nil {
+				m.Env = make(map[string]string)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAgent
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var stringLenmapvalue uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAgent
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				intStringLenmapvalue := int(stringLenmapvalue)
+				if intStringLenmapvalue < 0 {
+					return ErrInvalidLengthAgent
+				}
+				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+				if postStringIndexmapvalue > l {
+					return io.ErrUnexpectedEOF
+				}
+				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
+				iNdEx = postStringIndexmapvalue
+				m.Env[mapkey] = mapvalue
+			} else {
+				var mapvalue string
+				m.Env[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Message) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Message: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Message: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecStarted", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExecStarted{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Element = &Message_ExecStarted{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecCompleted", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExecCompleted{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Element = &Message_ExecCompleted{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExecOutput", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExecOutput{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Element = &Message_ExecOutput{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LogEntry", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &LogEntry{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Element = &Message_LogEntry{v}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Error{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Element = &Message_Error{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExecStarted) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExecStarted: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExecStarted: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Env", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Env = append(m.Env, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExecCompleted: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExecCompleted: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExitCode", wireType)
+			}
+			m.ExitCode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExitCode |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Error == nil {
+				m.Error = &Error{}
+			}
+			if err := m.Error.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Error) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Error: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Error: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Traces", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Traces = append(m.Traces, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExecOutput) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExecOutput: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExecOutput: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			}
+			m.Seq = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Seq |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fd", wireType)
+			}
+			m.Fd = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Fd |= (ExecOutput_FD(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LogEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Fields == nil {
+				m.Fields = make(map[string]string)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAgent
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var stringLenmapvalue uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAgent
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				intStringLenmapvalue := int(stringLenmapvalue)
+				if intStringLenmapvalue < 0 {
+					return ErrInvalidLengthAgent
+				}
+				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+				if postStringIndexmapvalue > l {
+					return io.ErrUnexpectedEOF
+				}
+				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
+				iNdEx = postStringIndexmapvalue
+				m.Fields[mapkey] = mapvalue
+			} else {
+				var mapvalue string
+				m.Fields[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Level", wireType)
+			}
+			m.Level = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Level |= (LogEntry_Level(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Traces", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Traces = append(m.Traces, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PeerJoinRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PeerJoinRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Addr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Config == nil {
+				m.Config = &RuntimeConfig{}
+			}
+			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SystemInfo", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SystemInfo = append(m.SystemInfo[:0], dAtA[iNdEx:postIndex]...)
+			if m.SystemInfo == nil {
+				m.SystemInfo = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PeerLeaveRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PeerLeaveRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Addr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Config == nil {
+				m.Config = &RuntimeConfig{}
+			}
+			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SystemInfo", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SystemInfo = append(m.SystemInfo[:0], dAtA[iNdEx:postIndex]...)
+			if m.SystemInfo == nil {
+				m.SystemInfo = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthAgent
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
-func sozAgent(x uint64) (n int) {
-	return sovAgent(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *CommandArgs) Unmarshal(dAtA []byte) error {
+func (m *PutFileRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1525,15 +4362,15 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: CommandArgs: wiretype end group for non-group")
+			return fmt.Errorf("proto: PutFileRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CommandArgs: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PutFileRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1558,13 +4395,13 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
+			m.Path = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SelfCommand", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
 			}
-			var v int
+			m.Mode = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1574,17 +4411,16 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.Mode |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.SelfCommand = bool(v != 0)
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Env", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			var msglen int
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1594,19 +4430,16 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Offset |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sha512", wireType)
 			}
-			var keykey uint64
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1616,12 +4449,26 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				keykey |= (uint64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			var stringLenmapkey uint64
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sha512 = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1631,69 +4478,21 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLenmapkey := int(stringLenmapkey)
-			if intStringLenmapkey < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postStringIndexmapkey := iNdEx + intStringLenmapkey
-			if postStringIndexmapkey > l {
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
-			iNdEx = postStringIndexmapkey
-			if m.Env == nil {
-				m.Env = make(map[string]string)
-			}
-			if iNdEx < postIndex {
-				var valuekey uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAgent
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					valuekey |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				var stringLenmapvalue uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAgent
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				intStringLenmapvalue := int(stringLenmapvalue)
-				if intStringLenmapvalue < 0 {
-					return ErrInvalidLengthAgent
-				}
-				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
-				if postStringIndexmapvalue > l {
-					return io.ErrUnexpectedEOF
-				}
-				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
-				iNdEx = postStringIndexmapvalue
-				m.Env[mapkey] = mapvalue
-			} else {
-				var mapvalue string
-				m.Env[mapkey] = mapvalue
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
 			}
 			iNdEx = postIndex
 		default:
@@ -1717,7 +4516,7 @@ func (m *CommandArgs) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Message) Unmarshal(dAtA []byte) error {
+func (m *GetFileRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1740,17 +4539,17 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Message: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetFileRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Message: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetFileRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExecStarted", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1760,29 +4559,26 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &ExecStarted{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Element = &Message_ExecStarted{v}
+			m.Path = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExecCompleted", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			var msglen int
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1792,93 +4588,66 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Offset |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			v := &ExecCompleted{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAgent(dAtA[iNdEx:])
+			if err != nil {
 				return err
 			}
-			m.Element = &Message_ExecCompleted{v}
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExecOutput", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAgent
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
+			if skippy < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &ExecOutput{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Element = &Message_ExecOutput{v}
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field LogEntry", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAgent
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthAgent
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FileChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAgent
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
+			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &LogEntry{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
 			}
-			m.Element = &Message_LogEntry{v}
-			iNdEx = postIndex
-		case 5:
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FileChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FileChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -1888,23 +4657,22 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &Error{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
 			}
-			m.Element = &Message_Error{v}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1927,7 +4695,7 @@ func (m *Message) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExecStarted) Unmarshal(dAtA []byte) error {
+func (m *StatFileRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1950,34 +4718,15 @@ func (m *ExecStarted) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExecStarted: wiretype end group for non-group")
+			return fmt.Errorf("proto: StatFileRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExecStarted: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: StatFileRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
-			}
-			m.Seq = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAgent
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Seq |= (int32(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2002,13 +4751,13 @@ func (m *ExecStarted) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
+			m.Path = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Env", wireType)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Partial", wireType)
 			}
-			var stringLen uint64
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2018,21 +4767,12 @@ func (m *ExecStarted) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Env = append(m.Env, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
+			m.Partial = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAgent(dAtA[iNdEx:])
@@ -2054,7 +4794,7 @@ func (m *ExecStarted) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
+func (m *FileInfo) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2077,17 +4817,17 @@ func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExecCompleted: wiretype end group for non-group")
+			return fmt.Errorf("proto: FileInfo: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExecCompleted: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FileInfo: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
-			m.Seq = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2097,16 +4837,26 @@ func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Seq |= (int32(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Path = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExitCode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Size", wireType)
 			}
-			m.ExitCode = 0
+			m.Size_ = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2116,16 +4866,35 @@ func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ExitCode |= (int32(b) & 0x7F) << shift
+				m.Size_ |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Sha512", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2135,25 +4904,41 @@ func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Error == nil {
-				m.Error = &Error{}
+			m.Sha512 = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsDir", wireType)
 			}
-			if err := m.Error.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
+			m.IsDir = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAgent(dAtA[iNdEx:])
@@ -2175,7 +4960,7 @@ func (m *ExecCompleted) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Error) Unmarshal(dAtA []byte) error {
+func (m *ExecRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2198,15 +4983,15 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Error: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExecRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Error: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExecRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Args", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2231,11 +5016,11 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Message = string(dAtA[iNdEx:postIndex])
+			m.Args = append(m.Args, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Traces", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Term", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2260,7 +5045,105 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Traces = append(m.Traces, string(dAtA[iNdEx:postIndex]))
+			m.Term = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rows", wireType)
+			}
+			m.Rows = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Rows |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cols", wireType)
+			}
+			m.Cols = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Cols |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Input", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Input = append(m.Input[:0], dAtA[iNdEx:postIndex]...)
+			if m.Input == nil {
+				m.Input = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signal", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signal = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2283,7 +5166,7 @@ func (m *Error) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExecOutput) Unmarshal(dAtA []byte) error {
+func (m *ExecResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2306,17 +5189,17 @@ func (m *ExecOutput) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExecOutput: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExecResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExecOutput: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExecResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Seq", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Output", wireType)
 			}
-			m.Seq = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2326,16 +5209,28 @@ func (m *ExecOutput) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Seq |= (int32(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Output = append(m.Output[:0], dAtA[iNdEx:postIndex]...)
+			if m.Output == nil {
+				m.Output = []byte{}
+			}
+			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Fd", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Exited", wireType)
 			}
-			m.Fd = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2345,16 +5240,17 @@ func (m *ExecOutput) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Fd |= (ExecOutput_FD(b) & 0x7F) << shift
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Exited = bool(v != 0)
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExitCode", wireType)
 			}
-			var byteLen int
+			m.ExitCode = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2362,25 +5258,13 @@ func (m *ExecOutput) Unmarshal(dAtA []byte) error {
 				if iNdEx >= l {
 					return io.ErrUnexpectedEOF
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if byteLen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExitCode |= (int32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAgent(dAtA[iNdEx:])
@@ -2402,7 +5286,7 @@ func (m *ExecOutput) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LogEntry) Unmarshal(dAtA []byte) error {
+func (m *RotateCredentialsRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2425,17 +5309,17 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LogEntry: wiretype end group for non-group")
+			return fmt.Errorf("proto: RotateCredentialsRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LogEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RotateCredentialsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field CaCert", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2445,26 +5329,28 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Message = string(dAtA[iNdEx:postIndex])
+			m.CaCert = append(m.CaCert[:0], dAtA[iNdEx:postIndex]...)
+			if m.CaCert == nil {
+				m.CaCert = []byte{}
+			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ServerCert", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2474,34 +5360,28 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			var keykey uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAgent
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				keykey |= (uint64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			m.ServerCert = append(m.ServerCert[:0], dAtA[iNdEx:postIndex]...)
+			if m.ServerCert == nil {
+				m.ServerCert = []byte{}
 			}
-			var stringLenmapkey uint64
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServerKey", wireType)
+			}
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2511,76 +5391,28 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLenmapkey := int(stringLenmapkey)
-			if intStringLenmapkey < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postStringIndexmapkey := iNdEx + intStringLenmapkey
-			if postStringIndexmapkey > l {
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
-			iNdEx = postStringIndexmapkey
-			if m.Fields == nil {
-				m.Fields = make(map[string]string)
-			}
-			if iNdEx < postIndex {
-				var valuekey uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAgent
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					valuekey |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				var stringLenmapvalue uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAgent
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				intStringLenmapvalue := int(stringLenmapvalue)
-				if intStringLenmapvalue < 0 {
-					return ErrInvalidLengthAgent
-				}
-				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
-				if postStringIndexmapvalue > l {
-					return io.ErrUnexpectedEOF
-				}
-				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
-				iNdEx = postStringIndexmapvalue
-				m.Fields[mapkey] = mapvalue
-			} else {
-				var mapvalue string
-				m.Fields[mapkey] = mapvalue
+			m.ServerKey = append(m.ServerKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.ServerKey == nil {
+				m.ServerKey = []byte{}
 			}
 			iNdEx = postIndex
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Level", wireType)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientCert", wireType)
 			}
-			m.Level = 0
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2590,16 +5422,28 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Level |= (LogEntry_Level(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 4:
+			if byteLen < 0 {
+				return ErrInvalidLengthAgent
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ClientCert = append(m.ClientCert[:0], dAtA[iNdEx:postIndex]...)
+			if m.ClientCert == nil {
+				m.ClientCert = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Traces", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ClientKey", wireType)
 			}
-			var stringLen uint64
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2609,20 +5453,22 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthAgent
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + byteLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Traces = append(m.Traces, string(dAtA[iNdEx:postIndex]))
+			m.ClientKey = append(m.ClientKey[:0], dAtA[iNdEx:postIndex]...)
+			if m.ClientKey == nil {
+				m.ClientKey = []byte{}
+			}
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2645,7 +5491,7 @@ func (m *LogEntry) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
+func (m *AgentMetrics) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2668,15 +5514,15 @@ func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PeerJoinRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: AgentMetrics: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PeerJoinRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: AgentMetrics: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2701,11 +5547,103 @@ func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Addr = string(dAtA[iNdEx:postIndex])
+			m.Version = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Load1", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			v = uint64(dAtA[iNdEx-8])
+			v |= uint64(dAtA[iNdEx-7]) << 8
+			v |= uint64(dAtA[iNdEx-6]) << 16
+			v |= uint64(dAtA[iNdEx-5]) << 24
+			v |= uint64(dAtA[iNdEx-4]) << 32
+			v |= uint64(dAtA[iNdEx-3]) << 40
+			v |= uint64(dAtA[iNdEx-2]) << 48
+			v |= uint64(dAtA[iNdEx-1]) << 56
+			m.Load1 = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Load5", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			v = uint64(dAtA[iNdEx-8])
+			v |= uint64(dAtA[iNdEx-7]) << 8
+			v |= uint64(dAtA[iNdEx-6]) << 16
+			v |= uint64(dAtA[iNdEx-5]) << 24
+			v |= uint64(dAtA[iNdEx-4]) << 32
+			v |= uint64(dAtA[iNdEx-3]) << 40
+			v |= uint64(dAtA[iNdEx-2]) << 48
+			v |= uint64(dAtA[iNdEx-1]) << 56
+			m.Load5 = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Load15", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			v = uint64(dAtA[iNdEx-8])
+			v |= uint64(dAtA[iNdEx-7]) << 8
+			v |= uint64(dAtA[iNdEx-6]) << 16
+			v |= uint64(dAtA[iNdEx-5]) << 24
+			v |= uint64(dAtA[iNdEx-4]) << 32
+			v |= uint64(dAtA[iNdEx-3]) << 40
+			v |= uint64(dAtA[iNdEx-2]) << 48
+			v |= uint64(dAtA[iNdEx-1]) << 56
+			m.Load15 = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryTotal", wireType)
+			}
+			m.MemoryTotal = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MemoryTotal |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryFree", wireType)
+			}
+			m.MemoryFree = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAgent
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MemoryFree |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Disks", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2729,18 +5667,16 @@ func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Config == nil {
-				m.Config = &RuntimeConfig{}
-			}
-			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Disks = append(m.Disks, &DiskUsage{})
+			if err := m.Disks[len(m.Disks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SystemInfo", wireType)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RunningCommands", wireType)
 			}
-			var byteLen int
+			m.RunningCommands = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2750,23 +5686,11 @@ func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				m.RunningCommands |= (int32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.SystemInfo = append(m.SystemInfo[:0], dAtA[iNdEx:postIndex]...)
-			if m.SystemInfo == nil {
-				m.SystemInfo = []byte{}
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAgent(dAtA[iNdEx:])
@@ -2788,7 +5712,7 @@ func (m *PeerJoinRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
+func (m *DiskUsage) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2811,15 +5735,15 @@ func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: PeerLeaveRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: DiskUsage: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: PeerLeaveRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DiskUsage: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Addr", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Path", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2844,13 +5768,13 @@ func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Addr = string(dAtA[iNdEx:postIndex])
+			m.Path = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Config", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
 			}
-			var msglen int
+			m.Total = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2860,30 +5784,16 @@ func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.Total |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Config == nil {
-				m.Config = &RuntimeConfig{}
-			}
-			if err := m.Config.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SystemInfo", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Free", wireType)
 			}
-			var byteLen int
+			m.Free = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAgent
@@ -2893,23 +5803,11 @@ func (m *PeerLeaveRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				m.Free |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthAgent
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.SystemInfo = append(m.SystemInfo[:0], dAtA[iNdEx:postIndex]...)
-			if m.SystemInfo == nil {
-				m.SystemInfo = []byte{}
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAgent(dAtA[iNdEx:])
@@ -3039,54 +5937,89 @@ var (
 func init() { proto1.RegisterFile("agent.proto", fileDescriptorAgent) }
 
 var fileDescriptorAgent = []byte{
-	// 773 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x54, 0xdd, 0x6e, 0xe3, 0x44,
-	0x14, 0xf6, 0x4f, 0x9d, 0xc6, 0xc7, 0xdd, 0xd6, 0x8c, 0x96, 0x62, 0xa5, 0xa8, 0x04, 0x6b, 0x2f,
-	0x22, 0x01, 0x2e, 0x64, 0x11, 0xb0, 0x2b, 0xb8, 0xd8, 0x4d, 0x52, 0x05, 0x54, 0xb4, 0x68, 0xba,
-	0x88, 0xcb, 0xc8, 0xb1, 0x8f, 0x4d, 0x84, 0xed, 0xc9, 0xda, 0xe3, 0xd0, 0xbc, 0x03, 0x0f, 0xb0,
-	0xef, 0xc0, 0x8b, 0x70, 0xc9, 0x23, 0xa0, 0x72, 0xcf, 0x33, 0xa0, 0x19, 0x8f, 0x1b, 0x6f, 0x77,
-	0x8b, 0xc4, 0xcd, 0x5e, 0xe5, 0xfc, 0x7d, 0xe7, 0x1c, 0x7f, 0x5f, 0xe6, 0x80, 0x13, 0xa6, 0x58,
-	0xf0, 0x60, 0x5d, 0x32, 0xce, 0x88, 0x25, 0x7f, 0x06, 0x27, 0x29, 0x63, 0x69, 0x86, 0x67, 0xd2,
-	0x5b, 0xd6, 0xc9, 0x19, 0xe6, 0x6b, 0xbe, 0x6d, 0x6a, 0x06, 0x47, 0xf1, 0xaa, 0x8a, 0xd8, 0x06,
-	0x4b, 0x15, 0xf0, 0x7f, 0xd7, 0xc1, 0x99, 0xb0, 0x3c, 0x0f, 0x8b, 0xf8, 0x49, 0x99, 0x56, 0x84,
-	0xc0, 0x5e, 0x58, 0xa6, 0x95, 0xa7, 0x0f, 0xcd, 0x91, 0x4d, 0xa5, 0x4d, 0x3e, 0x84, 0x83, 0x0a,
-	0xb3, 0x64, 0x11, 0x35, 0x75, 0x9e, 0x31, 0xd4, 0x47, 0x7d, 0xea, 0x88, 0x98, 0x82, 0x92, 0x4f,
-	0xc0, 0xc4, 0x62, 0xe3, 0x99, 0x43, 0x73, 0xe4, 0x8c, 0x4f, 0x9a, 0xde, 0x41, 0xa7, 0x6f, 0x30,
-	0x2b, 0x36, 0xb3, 0x82, 0x97, 0x5b, 0x2a, 0xea, 0x06, 0x5f, 0x40, 0xbf, 0x0d, 0x10, 0x17, 0xcc,
-	0x5f, 0x70, 0xeb, 0xe9, 0x43, 0x7d, 0x64, 0x53, 0x61, 0x92, 0xfb, 0x60, 0x6d, 0xc2, 0xac, 0x46,
-	0x39, 0xc8, 0xa6, 0x8d, 0xf3, 0xd8, 0xf8, 0x4a, 0xf7, 0x5f, 0x1a, 0xb0, 0xff, 0x3d, 0x56, 0x55,
-	0x98, 0x22, 0xf9, 0x12, 0x0e, 0xf0, 0x0a, 0xa3, 0x45, 0xc5, 0xc3, 0x92, 0x63, 0x2c, 0x1b, 0x38,
-	0x63, 0xa2, 0x66, 0xcf, 0xae, 0x30, 0xba, 0x6c, 0x32, 0x73, 0x8d, 0x3a, 0xb8, 0x73, 0xc9, 0x37,
-	0x70, 0x28, 0x81, 0x11, 0xcb, 0xd7, 0x19, 0x0a, 0xa8, 0x21, 0xa1, 0xf7, 0x3b, 0xd0, 0x49, 0x9b,
-	0x9b, 0x6b, 0xf4, 0x1e, 0x76, 0x03, 0xe4, 0x73, 0x90, 0xdd, 0x16, 0xac, 0xe6, 0xeb, 0x9a, 0x7b,
-	0xa6, 0xc4, 0xbe, 0xd3, 0xc1, 0x3e, 0x93, 0x89, 0xb9, 0x46, 0x01, 0x6f, 0x3c, 0x12, 0x80, 0x9d,
-	0xb1, 0x74, 0x81, 0xe2, 0x93, 0xbd, 0x3d, 0x89, 0x39, 0x52, 0x98, 0x0b, 0x96, 0x4a, 0x26, 0xe6,
-	0x1a, 0xed, 0x67, 0xca, 0x26, 0x0f, 0xc0, 0xc2, 0xb2, 0x64, 0xa5, 0x67, 0xc9, 0xda, 0x83, 0xb6,
-	0xbf, 0x88, 0xcd, 0x35, 0xda, 0x24, 0x9f, 0xda, 0xb0, 0x8f, 0x19, 0xe6, 0x58, 0x70, 0x7f, 0x06,
-	0x4e, 0xe7, 0x9b, 0x05, 0xab, 0x15, 0xbe, 0x90, 0xa4, 0x58, 0x54, 0x98, 0x37, 0xca, 0x1a, 0x1d,
-	0x65, 0xdd, 0x9d, 0x6c, 0xb6, 0x54, 0xc6, 0x5f, 0xc2, 0xbd, 0x57, 0xbe, 0xff, 0x0d, 0x8d, 0x4e,
-	0xc0, 0xc6, 0xab, 0x15, 0x5f, 0x44, 0x2c, 0x6e, 0x24, 0xb2, 0x68, 0x5f, 0x04, 0x26, 0x2c, 0x46,
-	0xe2, 0xb7, 0x7b, 0x9b, 0xaf, 0xef, 0xad, 0xb6, 0xf6, 0x1f, 0x81, 0x25, 0x7d, 0xe2, 0xc1, 0x7e,
-	0xde, 0xa8, 0xa9, 0xe4, 0x6f, 0x5d, 0x72, 0x0c, 0x3d, 0x5e, 0x86, 0x11, 0xb6, 0xeb, 0x2a, 0xcf,
-	0xdf, 0x00, 0xec, 0x28, 0x7e, 0xc3, 0x6e, 0x0f, 0xc0, 0x48, 0x1a, 0x3d, 0x0f, 0x5f, 0xd1, 0xb3,
-	0x01, 0x04, 0xe7, 0x53, 0x6a, 0x24, 0xb1, 0xa0, 0x22, 0x0e, 0x79, 0x28, 0x77, 0x3c, 0xa0, 0xd2,
-	0xf6, 0xdf, 0x07, 0xe3, 0x7c, 0x4a, 0x00, 0x7a, 0x97, 0xcf, 0xa7, 0xcf, 0x7e, 0x7c, 0xee, 0x6a,
-	0xca, 0x9e, 0x51, 0xea, 0xea, 0xfe, 0x6f, 0x06, 0xf4, 0x5b, 0x9d, 0xfe, 0x63, 0xed, 0x87, 0xd0,
-	0x4b, 0x56, 0x98, 0xc5, 0xcd, 0xda, 0xbb, 0x97, 0xd0, 0x42, 0x83, 0x73, 0x99, 0x6d, 0x5e, 0x82,
-	0x2a, 0x25, 0x1f, 0x81, 0x95, 0xe1, 0x06, 0x33, 0xb9, 0xce, 0xe1, 0xf8, 0xdd, 0xdb, 0x98, 0x0b,
-	0x91, 0xa4, 0x4d, 0x4d, 0x87, 0x98, 0xbd, 0x2e, 0x31, 0x83, 0x47, 0xe0, 0x74, 0x7a, 0xff, 0xaf,
-	0x47, 0xf5, 0x19, 0x58, 0x72, 0x04, 0xb1, 0xc1, 0x9a, 0xe2, 0xb2, 0x4e, 0x5d, 0x8d, 0xf4, 0x61,
-	0xef, 0xdb, 0x22, 0x61, 0xae, 0x2e, 0xac, 0x9f, 0xc2, 0xb2, 0x70, 0x0d, 0x91, 0x96, 0xb2, 0xb9,
-	0xa6, 0xcf, 0xe1, 0xe8, 0x07, 0xc4, 0xf2, 0x3b, 0xb6, 0x2a, 0x28, 0xbe, 0xa8, 0xb1, 0xe2, 0xf2,
-	0xef, 0x15, 0xc7, 0xa5, 0x1a, 0x29, 0x6d, 0xf2, 0x31, 0xf4, 0x22, 0x56, 0x24, 0xab, 0xf4, 0xd6,
-	0x0b, 0xa3, 0x75, 0xc1, 0x57, 0x39, 0x4e, 0x64, 0x8e, 0xaa, 0x1a, 0xf2, 0x01, 0x38, 0xd5, 0xb6,
-	0xe2, 0x98, 0x2f, 0x56, 0x45, 0xc2, 0x94, 0x38, 0xd0, 0x84, 0xc4, 0x32, 0x7e, 0x0d, 0xae, 0x98,
-	0x7a, 0x81, 0xe1, 0x06, 0xdf, 0xde, 0xd8, 0xf1, 0x3f, 0x3a, 0x58, 0x4f, 0xc4, 0x9d, 0x25, 0x8f,
-	0xa1, 0x7f, 0xf9, 0x73, 0xcd, 0x63, 0xf6, 0x6b, 0x41, 0x8e, 0x83, 0xe6, 0xce, 0x06, 0xed, 0x9d,
-	0x0d, 0x66, 0xe2, 0xce, 0x0e, 0xee, 0x88, 0x93, 0x33, 0xd8, 0x6f, 0x8f, 0x25, 0x79, 0xfd, 0x3e,
-	0x0e, 0x0e, 0x55, 0x4c, 0x5d, 0xb7, 0x4f, 0x75, 0x31, 0xac, 0xe5, 0x98, 0x1c, 0xab, 0xec, 0x2d,
-	0xd2, 0xef, 0x1c, 0xf6, 0x35, 0xd8, 0x37, 0x4c, 0x91, 0xf7, 0x3a, 0xe0, 0x2e, 0x77, 0x77, 0xa1,
-	0x9f, 0xba, 0x7f, 0x5c, 0x9f, 0xea, 0x7f, 0x5e, 0x9f, 0xea, 0x7f, 0x5d, 0x9f, 0xea, 0x2f, 0xff,
-	0x3e, 0xd5, 0x96, 0x3d, 0x59, 0xf1, 0xf0, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x8d, 0x75, 0x5e,
-	0x80, 0x70, 0x06, 0x00, 0x00,
+	// 1333 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x56, 0xcb, 0x6e, 0x1b, 0xc7,
+	0x12, 0xe5, 0x0c, 0x39, 0x22, 0xa7, 0xa8, 0x07, 0xdd, 0xd7, 0x8f, 0x01, 0x7d, 0xaf, 0xac, 0x3b,
+	0x30, 0x2e, 0x74, 0x91, 0x84, 0xb6, 0x25, 0x2b, 0x89, 0x0d, 0x07, 0x81, 0xad, 0x87, 0xe5, 0x58,
+	0x86, 0x8d, 0x96, 0x8d, 0x2c, 0xb2, 0x20, 0x46, 0x33, 0x45, 0x6a, 0xa0, 0xe1, 0x0c, 0xdd, 0xdd,
+	0xa4, 0xcd, 0x20, 0xbb, 0x2c, 0x93, 0x0f, 0xf0, 0x3f, 0x64, 0x99, 0x9f, 0xc8, 0x22, 0x8b, 0x7c,
+	0x42, 0xe0, 0xfc, 0x48, 0xd0, 0x2f, 0x6a, 0x28, 0x89, 0x31, 0xb2, 0xc9, 0x8a, 0x55, 0xa7, 0xeb,
+	0x74, 0xd5, 0x54, 0x75, 0x1f, 0x36, 0x34, 0xa3, 0x3e, 0xe6, 0xa2, 0x33, 0x64, 0x85, 0x28, 0x88,
+	0xa7, 0x7e, 0xda, 0xd7, 0xfb, 0x45, 0xd1, 0xcf, 0xf0, 0x96, 0xf2, 0x8e, 0x46, 0xbd, 0x5b, 0x38,
+	0x18, 0x8a, 0x89, 0x8e, 0x69, 0xaf, 0x24, 0x29, 0x8f, 0x8b, 0x31, 0x32, 0x03, 0x84, 0x3f, 0x39,
+	0xd0, 0xdc, 0x2e, 0x06, 0x83, 0x28, 0x4f, 0x1e, 0xb2, 0x3e, 0x27, 0x04, 0x6a, 0x11, 0xeb, 0xf3,
+	0xc0, 0x59, 0xab, 0xae, 0xfb, 0x54, 0xd9, 0xe4, 0xbf, 0xb0, 0xc8, 0x31, 0xeb, 0x75, 0x63, 0x1d,
+	0x17, 0xb8, 0x6b, 0xce, 0x7a, 0x83, 0x36, 0x25, 0x66, 0xa8, 0xe4, 0x13, 0xa8, 0x62, 0x3e, 0x0e,
+	0xaa, 0x6b, 0xd5, 0xf5, 0xe6, 0xc6, 0x75, 0xbd, 0x77, 0xa7, 0xb4, 0x6f, 0x67, 0x37, 0x1f, 0xef,
+	0xe6, 0x82, 0x4d, 0xa8, 0x8c, 0x6b, 0x7f, 0x0a, 0x0d, 0x0b, 0x90, 0x16, 0x54, 0x4f, 0x70, 0x12,
+	0x38, 0x6b, 0xce, 0xba, 0x4f, 0xa5, 0x49, 0x2e, 0x83, 0x37, 0x8e, 0xb2, 0x11, 0xaa, 0x44, 0x3e,
+	0xd5, 0xce, 0x7d, 0xf7, 0x73, 0x27, 0x7c, 0xe7, 0x42, 0xfd, 0x19, 0x72, 0x1e, 0xf5, 0x91, 0x7c,
+	0x06, 0x8b, 0xf8, 0x16, 0xe3, 0x2e, 0x17, 0x11, 0x13, 0x98, 0xa8, 0x0d, 0x9a, 0x1b, 0xc4, 0xe4,
+	0xde, 0x7d, 0x8b, 0xf1, 0xa1, 0x5e, 0xd9, 0xaf, 0xd0, 0x26, 0x9e, 0xba, 0xe4, 0x0b, 0x58, 0x56,
+	0xc4, 0xb8, 0x18, 0x0c, 0x33, 0x94, 0x54, 0x57, 0x51, 0x2f, 0x97, 0xa8, 0xdb, 0x76, 0x6d, 0xbf,
+	0x42, 0x97, 0xb0, 0x0c, 0x90, 0xbb, 0xa0, 0x76, 0xeb, 0x16, 0x23, 0x31, 0x1c, 0x89, 0xa0, 0xaa,
+	0xb8, 0x97, 0x4a, 0xdc, 0xe7, 0x6a, 0x61, 0xbf, 0x42, 0x01, 0xa7, 0x1e, 0xe9, 0x80, 0x9f, 0x15,
+	0xfd, 0x2e, 0xca, 0x4f, 0x0e, 0x6a, 0x8a, 0xb3, 0x62, 0x38, 0x07, 0x45, 0x5f, 0x75, 0x62, 0xbf,
+	0x42, 0x1b, 0x99, 0xb1, 0xc9, 0x4d, 0xf0, 0x90, 0xb1, 0x82, 0x05, 0x9e, 0x8a, 0x5d, 0xb4, 0xfb,
+	0x4b, 0x6c, 0xbf, 0x42, 0xf5, 0xe2, 0x23, 0x1f, 0xea, 0x98, 0xe1, 0x00, 0x73, 0x11, 0xee, 0x42,
+	0xb3, 0xf4, 0xcd, 0xb2, 0xab, 0x1c, 0x5f, 0xab, 0xa6, 0x78, 0x54, 0x9a, 0xd3, 0xc9, 0xba, 0xa5,
+	0xc9, 0xb6, 0x4e, 0xc7, 0xe6, 0xab, 0xc9, 0x84, 0x47, 0xb0, 0x34, 0xf3, 0xfd, 0x17, 0x6c, 0x74,
+	0x1d, 0x7c, 0x7c, 0x9b, 0x8a, 0x6e, 0x5c, 0x24, 0x7a, 0x44, 0x1e, 0x6d, 0x48, 0x60, 0xbb, 0x48,
+	0x90, 0x84, 0xb6, 0xee, 0xea, 0xf9, 0xba, 0x4d, 0xd5, 0xe1, 0x3d, 0xf0, 0x94, 0x4f, 0x02, 0xa8,
+	0x0f, 0xf4, 0x34, 0xcd, 0xf8, 0xad, 0x4b, 0xae, 0xc2, 0x82, 0x60, 0x51, 0x8c, 0xb6, 0x5c, 0xe3,
+	0x85, 0x63, 0x80, 0xd3, 0x16, 0x5f, 0x50, 0xdb, 0x4d, 0x70, 0x7b, 0x7a, 0x9e, 0xcb, 0x33, 0xf3,
+	0xd4, 0x84, 0xce, 0xde, 0x0e, 0x75, 0x7b, 0x89, 0x6c, 0x45, 0x12, 0x89, 0x48, 0xd5, 0xb8, 0x48,
+	0x95, 0x1d, 0xfe, 0x1b, 0xdc, 0xbd, 0x1d, 0x02, 0xb0, 0x70, 0xf8, 0x72, 0xe7, 0xf9, 0xab, 0x97,
+	0xad, 0x8a, 0xb1, 0x77, 0x29, 0x6d, 0x39, 0xe1, 0x8f, 0x2e, 0x34, 0xec, 0x9c, 0xfe, 0xa2, 0xec,
+	0x4d, 0x58, 0xe8, 0xa5, 0x98, 0x25, 0xba, 0xec, 0xd3, 0x9b, 0x60, 0xa9, 0x9d, 0x3d, 0xb5, 0xaa,
+	0x6c, 0x6a, 0x42, 0xc9, 0x47, 0xe0, 0x65, 0x38, 0xc6, 0x4c, 0x95, 0xb3, 0xbc, 0x71, 0xe5, 0x2c,
+	0xe7, 0x40, 0x2e, 0x52, 0x1d, 0x53, 0x6a, 0x4c, 0xad, 0xdc, 0x98, 0xf6, 0x3d, 0x68, 0x96, 0xf6,
+	0xfe, 0x5b, 0x97, 0xea, 0x0e, 0x78, 0x2a, 0x05, 0xf1, 0xc1, 0xdb, 0xc1, 0xa3, 0x51, 0xbf, 0x55,
+	0x21, 0x0d, 0xa8, 0x3d, 0xc9, 0x7b, 0x45, 0xcb, 0x91, 0xd6, 0xd7, 0x11, 0xcb, 0x5b, 0x2e, 0xf1,
+	0xcd, 0xd8, 0x5a, 0xd5, 0x50, 0xc0, 0xca, 0x0b, 0x44, 0xf6, 0x55, 0x91, 0xe6, 0x14, 0x5f, 0x8f,
+	0x90, 0x0b, 0x75, 0xbc, 0x92, 0x84, 0x99, 0x94, 0xca, 0x26, 0x1f, 0xc3, 0x42, 0x5c, 0xe4, 0xbd,
+	0xb4, 0x7f, 0xe6, 0x86, 0xd1, 0x51, 0x2e, 0xd2, 0x01, 0x6e, 0xab, 0x35, 0x6a, 0x62, 0xc8, 0x0d,
+	0x68, 0xf2, 0x09, 0x17, 0x38, 0xe8, 0xa6, 0x79, 0xaf, 0x30, 0xc3, 0x01, 0x0d, 0xc9, 0x62, 0xc2,
+	0x11, 0xb4, 0x64, 0xd6, 0x03, 0x8c, 0xc6, 0xf8, 0x0f, 0xa6, 0xfd, 0x0e, 0x96, 0x5f, 0x8c, 0xc4,
+	0x5e, 0x9a, 0x95, 0x93, 0x0e, 0x23, 0x71, 0x6c, 0x93, 0x4a, 0x5b, 0x62, 0x03, 0x7b, 0x21, 0x96,
+	0xa8, 0xb2, 0xe5, 0xb0, 0x8a, 0x5e, 0x8f, 0xa3, 0x56, 0x89, 0x2a, 0x35, 0x9e, 0xc4, 0xf9, 0x71,
+	0xb4, 0x75, 0x67, 0x43, 0x29, 0x81, 0x4f, 0x8d, 0x37, 0x3d, 0x97, 0x5e, 0xe9, 0x5c, 0x3e, 0x80,
+	0xe5, 0xc7, 0xf8, 0xc1, 0xec, 0xa7, 0x99, 0xdc, 0x72, 0xa6, 0xf0, 0x06, 0xf8, 0x92, 0xba, 0x7d,
+	0x3c, 0xca, 0x4f, 0xa6, 0xdb, 0x3b, 0xa5, 0xed, 0xbf, 0x84, 0x95, 0x43, 0x11, 0x7d, 0x70, 0xff,
+	0x00, 0xea, 0xc3, 0x88, 0x89, 0x34, 0xca, 0x8c, 0xfa, 0x5b, 0x37, 0x1c, 0x41, 0x43, 0x92, 0x65,
+	0xa7, 0xe6, 0xf5, 0x85, 0xa7, 0xdf, 0xa2, 0xa9, 0x4b, 0xd9, 0xd3, 0x5e, 0x55, 0x67, 0x7b, 0x75,
+	0x61, 0x4f, 0xae, 0xc0, 0x42, 0xca, 0xbb, 0x49, 0xaa, 0x95, 0xb0, 0x41, 0xbd, 0x94, 0xef, 0xa4,
+	0x2c, 0xfc, 0xc1, 0xd1, 0x7a, 0x57, 0x3e, 0x07, 0x67, 0xff, 0xb7, 0x08, 0xd4, 0x04, 0xb2, 0x81,
+	0x39, 0xf1, 0xca, 0x96, 0x18, 0x2b, 0xde, 0x70, 0x9b, 0x5a, 0xda, 0x12, 0x8b, 0x8b, 0x8c, 0xab,
+	0xc4, 0x4b, 0x54, 0xd9, 0xf2, 0xba, 0xa4, 0xb9, 0xd4, 0x77, 0x3d, 0x0b, 0xed, 0xa8, 0x22, 0xd3,
+	0x7e, 0x1e, 0x65, 0xc1, 0x82, 0x29, 0x52, 0x79, 0xe1, 0x37, 0xb0, 0xa8, 0x8b, 0xe1, 0xc3, 0x22,
+	0xe7, 0x7a, 0xf0, 0xfa, 0xef, 0x41, 0xf7, 0xda, 0x78, 0x12, 0x97, 0x4a, 0x89, 0xf6, 0x3f, 0xd4,
+	0x78, 0xb3, 0x92, 0x5a, 0x9d, 0x95, 0xd4, 0xf0, 0x67, 0x07, 0x02, 0x5a, 0x88, 0x48, 0xe0, 0x36,
+	0xc3, 0x04, 0x73, 0xd9, 0x76, 0x6e, 0xbf, 0xfb, 0x1a, 0xd4, 0xe3, 0xa8, 0x1b, 0x23, 0x9b, 0xa6,
+	0x8a, 0xa3, 0x6d, 0x64, 0x42, 0x1d, 0x6b, 0x64, 0x63, 0x64, 0x7a, 0xd1, 0x35, 0xc7, 0x5a, 0x41,
+	0x2a, 0xe0, 0x3f, 0x60, 0xbc, 0xae, 0x54, 0x0a, 0x7d, 0xec, 0x7d, 0x8d, 0x3c, 0xc5, 0x89, 0xe4,
+	0xc7, 0x59, 0x8a, 0xb9, 0xd0, 0xfc, 0x9a, 0xe6, 0x6b, 0xc8, 0xf2, 0x4d, 0x80, 0xe4, 0xeb, 0x36,
+	0xf9, 0x1a, 0x79, 0x8a, 0x93, 0xf0, 0x7b, 0x17, 0x16, 0x1f, 0xca, 0xd7, 0xc9, 0x33, 0x14, 0x2c,
+	0x8d, 0xb9, 0x3c, 0x42, 0x63, 0x64, 0x3c, 0x2d, 0x72, 0xab, 0x9a, 0xc6, 0x95, 0xbd, 0xce, 0x8a,
+	0x28, 0xb9, 0xa3, 0x8a, 0x74, 0xa8, 0x76, 0x2c, 0xba, 0x15, 0x54, 0x4f, 0xd1, 0x2d, 0xd9, 0x41,
+	0xb5, 0xbc, 0xa5, 0x2a, 0x72, 0xa8, 0xf1, 0xe4, 0x1b, 0x65, 0x80, 0x83, 0x82, 0x4d, 0xba, 0xa2,
+	0x10, 0x51, 0xa6, 0xea, 0xa9, 0xd1, 0xa6, 0xc6, 0x5e, 0x4a, 0x48, 0x7e, 0x91, 0x09, 0xe9, 0x31,
+	0x44, 0x35, 0xc1, 0x1a, 0x05, 0x0d, 0xed, 0x31, 0x44, 0xf2, 0x3f, 0xf0, 0x92, 0x94, 0x9f, 0xf0,
+	0xa0, 0xae, 0xc4, 0xbb, 0x65, 0x64, 0x63, 0x27, 0xe5, 0x27, 0xaf, 0xa4, 0xbc, 0x53, 0xbd, 0x4c,
+	0xfe, 0x0f, 0x2d, 0x36, 0xca, 0xf3, 0x34, 0xef, 0xdb, 0x27, 0x11, 0x0f, 0x1a, 0x6a, 0x68, 0x2b,
+	0x06, 0x37, 0x2f, 0x1f, 0x1e, 0x3e, 0x01, 0x7f, 0x4a, 0xbf, 0xf0, 0x7a, 0x5c, 0x06, 0x4f, 0x17,
+	0xec, 0xaa, 0x72, 0xb4, 0x23, 0x23, 0x55, 0x8d, 0x55, 0x05, 0x2a, 0x7b, 0xe3, 0xd7, 0x1a, 0x78,
+	0xaa, 0xa1, 0xe4, 0x3e, 0x34, 0x0e, 0x8f, 0x47, 0x22, 0x29, 0xde, 0xe4, 0xe4, 0x6a, 0x47, 0x3f,
+	0xf7, 0x3a, 0xf6, 0xb9, 0xd7, 0xd9, 0x95, 0xcf, 0xbd, 0xf6, 0x1c, 0x9c, 0xdc, 0x82, 0xba, 0x7d,
+	0xb3, 0x91, 0xf3, 0xcf, 0xb4, 0xf6, 0xb2, 0xc1, 0xcc, 0x23, 0xeb, 0xb6, 0x23, 0x93, 0x59, 0xa9,
+	0x27, 0x57, 0xcd, 0xea, 0x19, 0xed, 0x9f, 0x9b, 0xec, 0x01, 0xf8, 0x53, 0xc1, 0x26, 0xd7, 0x4a,
+	0xe4, 0xb2, 0x84, 0xcf, 0x65, 0x6f, 0x42, 0xdd, 0xe8, 0x2e, 0xb1, 0xff, 0x89, 0xb3, 0x3a, 0xdc,
+	0xb6, 0x2f, 0x28, 0x2b, 0x40, 0xeb, 0x0e, 0xb9, 0x0b, 0xf5, 0xc7, 0x38, 0x4b, 0x9a, 0x95, 0xcf,
+	0x76, 0xab, 0x44, 0x52, 0xba, 0x78, 0xdb, 0x21, 0x9b, 0xd0, 0xb0, 0x2a, 0x38, 0xfd, 0xc8, 0x33,
+	0xb2, 0x78, 0x2e, 0x19, 0xd9, 0x84, 0x9a, 0xbc, 0xf4, 0xa4, 0xfc, 0xe4, 0xb4, 0xc1, 0xff, 0x9a,
+	0xc1, 0xb4, 0x2a, 0xac, 0x3b, 0xb7, 0x1d, 0x72, 0x00, 0x97, 0xce, 0xdd, 0x65, 0x72, 0xc3, 0x44,
+	0xcf, 0xbb, 0xe5, 0x73, 0x5b, 0x74, 0x0f, 0xe0, 0x31, 0x4e, 0x6f, 0xd8, 0xbc, 0xb3, 0x60, 0x8b,
+	0x29, 0x5f, 0xc7, 0x47, 0xad, 0x5f, 0xde, 0xaf, 0x3a, 0xbf, 0xbd, 0x5f, 0x75, 0x7e, 0x7f, 0xbf,
+	0xea, 0xbc, 0xfb, 0x63, 0xb5, 0x72, 0xb4, 0xa0, 0xa2, 0x36, 0xff, 0x1c, 0x00, 0x9c, 0x43, 0xa6,
+	0xa6, 0x55, 0x0c, 0x00, 0x00,
 }
@@ -0,0 +1,298 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/gravitational/license/authority"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+)
+
+// RotateAgentCredentials generates new agent credentials to replace the specified
+// credentials. The new credentials are issued by a new certificate authority
+// for the same set of hosts and with the same client certificate lifetime.
+//
+// The certificate authority bundle of the new credentials also includes the
+// authority that issued the current credentials so that the agents with
+// the current credentials are trusted while the credentials are being rotated
+func RotateAgentCredentials(current utils.TLSArchive) (rotated utils.TLSArchive, err error) {
+	if err := checkCredentials(current); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	caCerts, err := helpers.ParseCertificatesPEM(current[pb.CA].CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	serverCert, err := helpers.ParseCertificatePEM(current[pb.Server].CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	clientCert, err := helpers.ParseCertificatePEM(current[pb.Client].CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	issuer, err := findIssuer(serverCert, caCerts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// Certificates are issued with the validity period starting an hour in the past
+	clientTTL := clientCert.NotAfter.Sub(clientCert.NotBefore) - time.Hour
+	rotated, err = generateAgentCredentials(serverHosts(serverCert), issuer.Subject.CommonName, clientTTL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	rotated[pb.CA].CertPEM = append(rotated[pb.CA].CertPEM, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: issuer.Raw,
+	})...)
+	return rotated, nil
+}
+
+// RotateCredentials replaces the credentials of the agents running on the specified
+// servers with the rotated credentials. The established connections are not interrupted.
+//
+// The credentials are replaced in three steps so that the agents can connect to
+// each other at any point during the rotation: first, all agents are configured to
+// trust the new certificate authority, then the new certificates are installed and
+// finally the agents stop trusting the previous certificate authority.
+// If any agent fails to complete one of the first two steps, all agents are
+// rolled back to the current credentials.
+//
+// newAgents returns the agent repository connecting with the specified client credentials.
+// Returns the credentials the agents have after the rotation
+func RotateCredentials(ctx context.Context, servers []string, current, rotated utils.TLSArchive,
+	logger log.FieldLogger, newAgents func(credentials.TransportCredentials) AgentRepository) (utils.TLSArchive, error) {
+	final, err := finalCredentials(rotated)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	trusted := utils.TLSArchive{
+		pb.Server: current[pb.Server],
+		pb.Client: current[pb.Client],
+		pb.CA:     rotated[pb.CA],
+	}
+	r := rotation{
+		FieldLogger: logger,
+		newAgents:   newAgents,
+	}
+	logger.Info("Distribute the new certificate authority.")
+	updated, err := r.update(ctx, servers, trusted, current)
+	if err != nil {
+		r.rollback(ctx, updated, current, current)
+		return nil, trace.Wrap(err, "failed to distribute the new certificate authority")
+	}
+	logger.Info("Distribute the new certificates.")
+	// agents are reached with the current client certificate as they all
+	// trust the current authority but some might already have the new certificates
+	updated, err = r.update(ctx, servers, rotated, trusted)
+	if err != nil {
+		r.rollback(ctx, updated, trusted, trusted)
+		r.rollback(ctx, servers, current, current)
+		return nil, trace.Wrap(err, "failed to distribute the new certificates")
+	}
+	logger.Info("Revoke trust in the previous certificate authority.")
+	_, err = r.update(ctx, servers, final, rotated)
+	if err != nil {
+		// the agents can connect to each other with the new certificates,
+		// the previous authority is trusted until the next rotation
+		return rotated, trace.Wrap(err, "failed to revoke trust in the previous certificate authority, "+
+			"rotate the credentials again to revoke it")
+	}
+	return final, nil
+}
+
+// WatchCredentialsExpiry periodically checks the agent credentials in the specified
+// secrets dir and warns when they are about to expire.
+// Blocks until the specified context is canceled
+func WatchCredentialsExpiry(ctx context.Context, secretsDir string, logger log.FieldLogger) {
+	ticker := time.NewTicker(defaults.RPCAgentCredentialsCheckInterval)
+	defer ticker.Stop()
+	for {
+		checkCredentialsExpiry(secretsDir, logger)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func checkCredentialsExpiry(secretsDir string, logger log.FieldLogger) {
+	archive, err := ReadCredentials(secretsDir)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read agent credentials.")
+		return
+	}
+	expires, err := CredentialsExpiry(archive)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to determine agent credentials expiration.")
+		return
+	}
+	logger = logger.WithField("expires", expires.UTC().Format(constants.HumanDateFormat))
+	remaining := time.Until(expires)
+	switch {
+	case remaining <= 0:
+		logger.Error("Agent credentials have expired, rotate them with 'gravity agent rotate-credentials'.")
+	case remaining < defaults.RPCAgentCredentialsExpiryThreshold:
+		logger.Warn("Agent credentials are about to expire, rotate them with 'gravity agent rotate-credentials'.")
+	default:
+		logger.Debug("Agent credentials are valid.")
+	}
+}
+
+// rotation distributes credentials to agents
+type rotation struct {
+	log.FieldLogger
+	// newAgents returns the agent repository connecting
+	// with the specified client credentials
+	newAgents func(credentials.TransportCredentials) AgentRepository
+}
+
+// update replaces the credentials of the agents on the specified servers with archive
+// connecting with the client credentials from clientArchive.
+// Returns the servers updated successfully
+func (r rotation) update(ctx context.Context, servers []string, archive, clientArchive utils.TLSArchive) (updated []string, err error) {
+	_, clientCreds, err := CredentialsFromArchive(clientArchive)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	agents := r.newAgents(clientCreds)
+	type result struct {
+		host string
+		err  error
+	}
+	resultCh := make(chan result, len(servers))
+	for _, srv := range servers {
+		go func(host string) {
+			err := utils.RetryWithInterval(ctx, utils.NewExponentialBackOff(defaults.RPCAgentCredentialsUpdateTimeout),
+				func() error {
+					return trace.Wrap(updateAgentCredentials(ctx, host, archive, agents))
+				})
+			if err != nil {
+				r.WithError(err).Errorf("Failed to update credentials of agent on %s.", host)
+			} else {
+				r.Infof("Updated credentials of agent on %s.", host)
+			}
+			resultCh <- result{host: host, err: err}
+		}(srv)
+	}
+	var errors []error
+	for range servers {
+		result := <-resultCh
+		if result.err != nil {
+			errors = append(errors, result.err)
+			continue
+		}
+		updated = append(updated, result.host)
+	}
+	return updated, trace.NewAggregate(errors...)
+}
+
+// rollback restores the credentials of the agents on the specified servers
+// to archive connecting with the client credentials from clientArchive
+func (r rotation) rollback(ctx context.Context, servers []string, archive, clientArchive utils.TLSArchive) {
+	if len(servers) == 0 {
+		return
+	}
+	r.Warnf("Roll back credentials of agents on %v.", strings.Join(servers, ", "))
+	_, err := r.update(ctx, servers, archive, clientArchive)
+	if err != nil {
+		r.WithError(err).Error("Failed to roll back agent credentials, " +
+			"restart the agents with 'gravity agent deploy'.")
+	}
+}
+
+func updateAgentCredentials(ctx context.Context, addr string, archive utils.TLSArchive, rpc AgentRepository) error {
+	ctx, cancel := context.WithTimeout(ctx, defaults.DialTimeout)
+	defer cancel()
+
+	clt, err := rpc.GetClient(ctx, addr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return trace.Wrap(clt.RotateCredentials(ctx, archive))
+}
+
+// finalCredentials returns the rotated credentials with the certificate
+// authority bundle reduced to the authority that issued the rotated certificates
+func finalCredentials(rotated utils.TLSArchive) (utils.TLSArchive, error) {
+	if err := checkCredentials(rotated); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	caCerts, err := helpers.ParseCertificatesPEM(rotated[pb.CA].CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	serverCert, err := helpers.ParseCertificatePEM(rotated[pb.Server].CertPEM)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	issuer, err := findIssuer(serverCert, caCerts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return utils.TLSArchive{
+		pb.Server: rotated[pb.Server],
+		pb.Client: rotated[pb.Client],
+		pb.CA: &authority.TLSKeyPair{
+			CertPEM: pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: issuer.Raw,
+			}),
+			KeyPEM: rotated[pb.CA].KeyPEM,
+		},
+	}, nil
+}
+
+// findIssuer returns the certificate authority from the specified list
+// that issued cert
+func findIssuer(cert *x509.Certificate, caCerts []*x509.Certificate) (*x509.Certificate, error) {
+	for _, caCert := range caCerts {
+		if cert.CheckSignatureFrom(caCert) == nil {
+			return caCert, nil
+		}
+	}
+	return nil, trace.NotFound("no certificate authority for %v", cert.Subject.CommonName)
+}
+
+// serverHosts returns the additional hosts the specified server certificate
+// has been issued for
+func serverHosts(cert *x509.Certificate) (hosts []string) {
+	for _, name := range cert.DNSNames {
+		if name != pb.ServerName {
+			hosts = append(hosts, name)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		hosts = append(hosts, ip.String())
+	}
+	return hosts
+}
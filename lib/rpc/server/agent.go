@@ -18,6 +18,7 @@ package server
 
 import (
 	"os"
+	"sync/atomic"
 	"time"
 
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
@@ -42,6 +43,9 @@ func (srv *agentServer) Command(req *pb.CommandArgs, stream pb.Agent_CommandServ
 		"args":    req.Args})
 	log.Debug("request received")
 
+	atomic.AddInt32(&srv.runningCommands, 1)
+	defer atomic.AddInt32(&srv.runningCommands, -1)
+
 	if req.SelfCommand {
 		gravityPath, err := os.Executable()
 		if err != nil {
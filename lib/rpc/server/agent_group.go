@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gravitational/gravity/lib/defaults"
@@ -27,6 +29,7 @@ import (
 	"github.com/gravitational/gravity/lib/rpc/client"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/storage"
+	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/trace"
@@ -175,6 +178,41 @@ func (r *AgentGroup) Shutdown(ctx context.Context) error {
 	return trace.Wrap(err)
 }
 
+// GetMetrics queries the health and resource usage metrics of all peers
+// concurrently. Peers that cannot be queried are reported with the
+// corresponding error
+func (r *AgentGroup) GetMetrics(ctx context.Context) []PeerMetrics {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var result []PeerMetrics
+	r.peers.iterate(func(p peer) error {
+		wg.Add(1)
+		go func(p peer) {
+			defer wg.Done()
+			metrics, err := p.GetMetrics(ctx)
+			mu.Lock()
+			result = append(result, PeerMetrics{Addr: p.Addr(), Metrics: metrics, Error: err})
+			mu.Unlock()
+		}(p)
+		return nil
+	})
+	wg.Wait()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Addr < result[j].Addr
+	})
+	return result
+}
+
+// PeerMetrics describes the result of querying metrics of a single peer
+type PeerMetrics struct {
+	// Addr is the address of the peer
+	Addr string
+	// Metrics is the health and resource usage metrics of the peer
+	Metrics *pb.AgentMetrics
+	// Error is the error querying the peer, if any
+	Error error
+}
+
 // Start starts this group's internal goroutines
 func (r *AgentGroup) Start() {
 	go r.updateLoop()
@@ -264,6 +302,30 @@ func (r errorPeer) CheckBandwidth(context.Context, *validationpb.CheckBandwidthR
 	return nil, trace.Wrap(r.error)
 }
 
+func (r errorPeer) StatFile(context.Context, string) (*pb.FileInfo, error) {
+	return nil, trace.Wrap(r.error)
+}
+
+func (r errorPeer) UploadFile(context.Context, string, string) (*pb.FileInfo, error) {
+	return nil, trace.Wrap(r.error)
+}
+
+func (r errorPeer) DownloadFile(context.Context, string, string) (*pb.FileInfo, error) {
+	return nil, trace.Wrap(r.error)
+}
+
+func (r errorPeer) Exec(context.Context, client.ExecConfig) (int, error) {
+	return 0, trace.Wrap(r.error)
+}
+
+func (r errorPeer) GetMetrics(context.Context) (*pb.AgentMetrics, error) {
+	return nil, trace.Wrap(r.error)
+}
+
+func (r errorPeer) RotateCredentials(context.Context, utils.TLSArchive) error {
+	return trace.Wrap(r.error)
+}
+
 func (r errorPeer) Shutdown(context.Context) error {
 	return trace.Wrap(r.error)
 }
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net"
+	"sync"
+
+	"github.com/gravitational/gravity/lib/rpc"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/gravitational/license/authority"
+	"github.com/gravitational/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+)
+
+// RotateCredentials replaces the credentials of this agent.
+// The new credentials are used for the new connections while
+// the established connections are kept intact
+func (srv *agentServer) RotateCredentials(ctx context.Context, req *pb.RotateCredentialsRequest) (*types.Empty, error) {
+	archive := utils.TLSArchive{
+		pb.Server: &authority.TLSKeyPair{CertPEM: req.ServerCert, KeyPEM: req.ServerKey},
+		pb.Client: &authority.TLSKeyPair{CertPEM: req.ClientCert, KeyPEM: req.ClientKey},
+		pb.CA:     &authority.TLSKeyPair{CertPEM: req.CaCert},
+	}
+	serverCreds, clientCreds, err := rpc.CredentialsFromArchive(archive)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	server, okServer := srv.Credentials.Server.(*rotatingCredentials)
+	client, okClient := srv.Credentials.Client.(*rotatingCredentials)
+	if !okServer || !okClient {
+		return nil, trace.BadParameter("agent does not support credentials rotation")
+	}
+	if srv.SecretsDir != "" {
+		if err := rpc.WriteCredentials(srv.SecretsDir, archive); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	server.rotate(serverCreds)
+	client.rotate(clientCreds)
+	expires, err := rpc.CredentialsExpiry(archive)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	srv.WithField("expires", expires).Info("Rotated credentials.")
+	return &types.Empty{}, nil
+}
+
+// NewRotatingCredentials returns the specified credentials
+// so that they can be rotated at runtime.
+// Agents configured with other credentials reject the rotation
+func NewRotatingCredentials(creds Credentials) Credentials {
+	return Credentials{
+		Server: newRotatingCredentials(creds.Server),
+		Client: newRotatingCredentials(creds.Client),
+	}
+}
+
+// newRotatingCredentials returns a new instance of credentials
+// that can be replaced at runtime.
+// If creds are already rotating credentials, they are returned unaltered
+func newRotatingCredentials(creds credentials.TransportCredentials) credentials.TransportCredentials {
+	if creds == nil {
+		return nil
+	}
+	if _, ok := creds.(*rotatingCredentials); ok {
+		return creds
+	}
+	return &rotatingCredentials{creds: creds}
+}
+
+// rotatingCredentials implements credentials.TransportCredentials
+// by delegating to the credentials that can be replaced at runtime.
+// Replacing the credentials only affects the future handshakes
+type rotatingCredentials struct {
+	sync.RWMutex
+	creds credentials.TransportCredentials
+}
+
+// ClientHandshake performs the client side of the handshake with the current credentials
+func (r *rotatingCredentials) ClientHandshake(ctx context.Context, addr string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return r.current().ClientHandshake(ctx, addr, conn)
+}
+
+// ServerHandshake performs the server side of the handshake with the current credentials
+func (r *rotatingCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return r.current().ServerHandshake(conn)
+}
+
+// Info returns the protocol information of the current credentials
+func (r *rotatingCredentials) Info() credentials.ProtocolInfo {
+	return r.current().Info()
+}
+
+// Clone returns these credentials.
+// The credentials are shared so that the clones observe the rotation
+func (r *rotatingCredentials) Clone() credentials.TransportCredentials {
+	return r
+}
+
+// OverrideServerName overrides the server name of the current credentials
+func (r *rotatingCredentials) OverrideServerName(name string) error {
+	return r.current().OverrideServerName(name)
+}
+
+func (r *rotatingCredentials) rotate(creds credentials.TransportCredentials) {
+	r.Lock()
+	defer r.Unlock()
+	r.creds = creds
+}
+
+func (r *rotatingCredentials) current() credentials.TransportCredentials {
+	r.RLock()
+	defer r.RUnlock()
+	return r.creds
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/rpc/client"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/cloudflare/cfssl/helpers"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	. "gopkg.in/check.v1"
+)
+
+func (r *S) TestRotatesCredentials(c *C) {
+	current, err := rpc.GenerateAgentCredentials([]string{"127.0.0.1"}, "test", false)
+	c.Assert(err, IsNil)
+	serverCreds, clientCreds, err := rpc.CredentialsFromArchive(current)
+	c.Assert(err, IsNil)
+
+	secretsDir := c.MkDir()
+	listener := listen(c)
+	srv, err := New(Config{
+		Listener:    listener,
+		Credentials: NewRotatingCredentials(Credentials{Server: serverCreds, Client: clientCreds}),
+		SecretsDir:  secretsDir,
+	}, r.WithField("test", "RotatesCredentials"))
+	c.Assert(err, IsNil)
+	go srv.Serve()
+	defer srv.Stop(context.TODO())
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	clt := r.connect(ctx, c, srv.Addr().String(), clientCreds)
+
+	rotated, err := rpc.RotateAgentCredentials(current)
+	c.Assert(err, IsNil)
+	c.Assert(clt.RotateCredentials(ctx, rotated), IsNil)
+
+	// established connection is intact
+	_, err = clt.GetRuntimeConfig(ctx)
+	c.Assert(err, IsNil)
+
+	// new connections require the rotated credentials
+	_, rotatedClientCreds, err := rpc.CredentialsFromArchive(rotated)
+	c.Assert(err, IsNil)
+	clt = r.connect(ctx, c, srv.Addr().String(), rotatedClientCreds)
+	_, err = clt.GetRuntimeConfig(ctx)
+	c.Assert(err, IsNil)
+
+	// new connections with the previous credentials are rejected
+	dialCtx, dialCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer dialCancel()
+	_, err = client.New(dialCtx, client.Config{
+		ServerAddr:  srv.Addr().String(),
+		Credentials: clientCreds,
+	})
+	c.Assert(err, NotNil)
+
+	// rotated credentials are persisted
+	persisted, err := rpc.ReadCredentials(secretsDir)
+	c.Assert(err, IsNil)
+	for _, name := range []string{pb.Server, pb.Client, pb.CA} {
+		c.Assert(persisted[name].CertPEM, DeepEquals, rotated[name].CertPEM)
+	}
+	expires, err := rpc.CredentialsExpiry(persisted)
+	c.Assert(err, IsNil)
+	c.Assert(expires.After(time.Now()), Equals, true)
+}
+
+func (r *S) TestRotatesCredentialsOfAgents(c *C) {
+	current, err := rpc.GenerateAgentCredentials([]string{"127.0.0.1"}, "test", false)
+	c.Assert(err, IsNil)
+	serverCreds, clientCreds, err := rpc.CredentialsFromArchive(current)
+	c.Assert(err, IsNil)
+
+	var servers []string
+	for i := 0; i < 2; i++ {
+		listener := listen(c)
+		srv, err := New(Config{
+			Listener:    listener,
+			Credentials: NewRotatingCredentials(Credentials{Server: serverCreds, Client: clientCreds}),
+		}, r.WithField("test", "RotatesCredentialsOfAgents"))
+		c.Assert(err, IsNil)
+		go srv.Serve()
+		defer srv.Stop(context.TODO())
+		servers = append(servers, srv.Addr().String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	rotated, err := rpc.RotateAgentCredentials(current)
+	c.Assert(err, IsNil)
+	final, err := rpc.RotateCredentials(ctx, servers, current, rotated, r.WithField("test", "RotatesCredentialsOfAgents"),
+		func(creds credentials.TransportCredentials) rpc.AgentRepository {
+			return agentRepository{creds: creds}
+		})
+	c.Assert(err, IsNil)
+
+	// the previous certificate authority is no longer trusted
+	caCerts, err := helpers.ParseCertificatesPEM(final[pb.CA].CertPEM)
+	c.Assert(err, IsNil)
+	c.Assert(caCerts, HasLen, 1)
+	_, finalClientCreds, err := rpc.CredentialsFromArchive(final)
+	c.Assert(err, IsNil)
+	// certificates issued by the previous authority are rejected
+	previous := utils.TLSArchive{
+		pb.Server: current[pb.Server],
+		pb.Client: current[pb.Client],
+		pb.CA:     final[pb.CA],
+	}
+	_, previousClientCreds, err := rpc.CredentialsFromArchive(previous)
+	c.Assert(err, IsNil)
+	for _, addr := range servers {
+		clt := r.connect(ctx, c, addr, finalClientCreds)
+		_, err = clt.GetRuntimeConfig(ctx)
+		c.Assert(err, IsNil)
+
+		dialCtx, dialCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		clt, err = client.New(dialCtx, client.Config{
+			ServerAddr:  addr,
+			Credentials: previousClientCreds,
+		})
+		if err == nil {
+			_, err = clt.GetRuntimeConfig(dialCtx)
+		}
+		dialCancel()
+		c.Assert(err, NotNil)
+	}
+}
+
+func (r *S) connect(ctx context.Context, c *C, addr string, creds credentials.TransportCredentials) client.Client {
+	clt, err := client.New(ctx, client.Config{
+		ServerAddr:  addr,
+		Credentials: creds,
+	})
+	c.Assert(err, IsNil)
+	return clt
+}
+
+type agentRepository struct {
+	creds credentials.TransportCredentials
+}
+
+func (r agentRepository) GetClient(ctx context.Context, addr string) (client.Client, error) {
+	return client.New(ctx, client.Config{
+		ServerAddr:  addr,
+		Credentials: r.creds,
+	})
+}
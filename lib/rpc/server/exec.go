@@ -0,0 +1,179 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/gravitational/trace"
+	"github.com/kr/pty"
+	log "github.com/sirupsen/logrus"
+)
+
+// Exec executes the command given with the first request of the stream
+// in a pseudo-terminal forwarding the input, window size changes and signals
+// from the stream to the command and streaming the output of the command
+// as a result
+func (srv *agentServer) Exec(stream pb.Agent_ExecServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(req.Args) == 0 {
+		return trace.BadParameter("at least one argument is required")
+	}
+
+	log := srv.WithFields(log.Fields{
+		"request": "Exec",
+		"args":    req.Args})
+	log.Info("Start interactive session.")
+
+	atomic.AddInt32(&srv.runningCommands, 1)
+	defer atomic.AddInt32(&srv.runningCommands, -1)
+
+	cmd := exec.CommandContext(stream.Context(), req.Args[0], req.Args[1:]...)
+	cmd.Env = os.Environ()
+	if req.Term != "" {
+		cmd.Env = append(cmd.Env, "TERM="+req.Term)
+	}
+	tty, err := pty.Start(cmd)
+	if err != nil {
+		return trace.Wrap(err, "failed to start %v", cmd.Path)
+	}
+	defer tty.Close()
+	if err := resizeTerminal(tty, req); err != nil {
+		log.WithError(err).Warn("Failed to set terminal window size.")
+	}
+
+	go func() {
+		var lastInput byte = '\n'
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				if err := sendEOF(tty, lastInput); err != nil {
+					log.WithError(err).Warn("Failed to close session input.")
+				}
+				return
+			}
+			if err != nil {
+				return
+			}
+			if len(req.Input) != 0 {
+				lastInput = req.Input[len(req.Input)-1]
+			}
+			if err := handleExecRequest(tty, cmd.Process, req); err != nil {
+				log.WithError(err).Warn("Failed to handle session input.")
+			}
+		}
+	}()
+
+	buf := make([]byte, defaults.RPCAgentExecBufferSize)
+	for {
+		n, err := tty.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.ExecResponse{Output: buf[:n]}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if err != nil {
+			// reading from the terminal fails once the command has exited
+			break
+		}
+	}
+
+	exitCode := 0
+	if err := cmd.Wait(); err != nil {
+		exitCode = ExitCodeUndefined
+		if errExit, ok := err.(*exec.ExitError); ok {
+			if status, ok := errExit.Sys().(syscall.WaitStatus); ok {
+				exitCode = status.ExitStatus()
+			}
+		}
+	}
+	log.WithField("exit", exitCode).Info("Interactive session completed.")
+	return trace.Wrap(stream.Send(&pb.ExecResponse{
+		Exited:   true,
+		ExitCode: int32(exitCode),
+	}))
+}
+
+func handleExecRequest(tty *os.File, process *os.Process, req *pb.ExecRequest) error {
+	if len(req.Input) != 0 {
+		if _, err := tty.Write(req.Input); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if err := resizeTerminal(tty, req); err != nil {
+		return trace.Wrap(err)
+	}
+	if req.Signal != "" {
+		signal, ok := execSignals[req.Signal]
+		if !ok {
+			return trace.BadParameter("unsupported signal %q", req.Signal)
+		}
+		if err := process.Signal(signal); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// sendEOF signals the end of input to the command by writing the
+// end-of-transmission character to the terminal. In canonical mode it only
+// ends the input at the start of a line, otherwise it submits the pending line,
+// so it is written twice unless the input ended with a newline
+func sendEOF(tty *os.File, lastInput byte) error {
+	eof := []byte{eot}
+	if lastInput != '\n' {
+		eof = append(eof, eot)
+	}
+	_, err := tty.Write(eof)
+	return trace.ConvertSystemError(err)
+}
+
+// eot is the end-of-transmission character (Ctrl-D)
+const eot = 0x04
+
+// resizeTerminal sets the window size of the terminal if the request specifies one
+func resizeTerminal(tty *os.File, req *pb.ExecRequest) error {
+	if req.Rows == 0 || req.Cols == 0 {
+		return nil
+	}
+	err := term.SetWinsize(tty.Fd(), &term.Winsize{
+		Height: uint16(req.Rows),
+		Width:  uint16(req.Cols),
+	})
+	return trace.Wrap(err)
+}
+
+// execSignals lists signals that can be forwarded to the command
+// of the interactive session
+var execSignals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/rpc/client"
+
+	"golang.org/x/net/context"
+	. "gopkg.in/check.v1"
+)
+
+func (r *S) TestExecutesInteractiveSession(c *C) {
+	clt := r.newFileClient(c, "ExecutesInteractiveSession")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	exitCode, err := clt.Exec(ctx, client.ExecConfig{
+		Args:   []string{"/bin/sh", "-c", `read line; echo "got $line"; stty size; exit 3`},
+		Term:   "xterm",
+		Size:   client.WindowSize{Rows: 24, Cols: 100},
+		Stdin:  strings.NewReader("hello\n"),
+		Stdout: &out,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(exitCode, Equals, 3)
+	c.Assert(out.String(), Matches, "(?s).*got hello.*24 100.*")
+}
+
+func (r *S) TestForwardsSignals(c *C) {
+	clt := r.newFileClient(c, "ForwardsSignals")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	signals := make(chan string, 1)
+	signals <- "SIGTERM"
+	exitCode, err := clt.Exec(ctx, client.ExecConfig{
+		Args:    []string{"/bin/sleep", "10"},
+		Signals: signals,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(exitCode, Not(Equals), 0)
+}
+
+func (r *S) TestClosesInputAtEOF(c *C) {
+	clt := r.newFileClient(c, "ClosesInputAtEOF")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	var comment CommentInterface
+	for _, input := range []string{"hello\nworld\n", "hello\nworld"} {
+		comment = Commentf("input %q", input)
+		var out bytes.Buffer
+		exitCode, err := clt.Exec(ctx, client.ExecConfig{
+			Args:   []string{"/bin/sh", "-c", `stty -echo; cat; echo done`},
+			Stdin:  strings.NewReader(input),
+			Stdout: &out,
+		})
+		c.Assert(err, IsNil, comment)
+		c.Assert(exitCode, Equals, 0, comment)
+		c.Assert(out.String(), Matches, "(?s).*hello.*world.*done.*", comment)
+	}
+}
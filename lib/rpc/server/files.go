@@ -0,0 +1,330 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PutFile writes the file uploaded by the client.
+// Every upload writes into its own temporary file next to the destination
+// which replaces the destination once the upload is complete and
+// the checksum matches. An interrupted upload is kept as the partial file
+// and can be resumed by specifying the offset to continue from
+func (srv *agentServer) PutFile(stream pb.Agent_PutFileServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	path, err := srv.checkFilePath(req.Path)
+	if err != nil {
+		return convertFileError(err)
+	}
+	if req.Sha512 == "" {
+		return trace.BadParameter("missing file checksum")
+	}
+	log := srv.WithFields(log.Fields{
+		"request": "PutFile",
+		"path":    path,
+		"offset":  req.Offset,
+	})
+	log.Debug("Request received.")
+
+	partialPath := partialFilePath(path)
+	f, err := createUploadFile(path, partialPath, req.Offset)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	uploadPath := f.Name()
+	interrupted := true
+	defer func() {
+		f.Close()
+		if interrupted {
+			keepPartialFile(uploadPath, partialPath, log)
+		}
+	}()
+	if _, err := f.Write(req.Data); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := f.Write(chunk.Data); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	interrupted = false
+
+	checksum, err := utils.SHA512HalfFile(uploadPath)
+	if err != nil {
+		os.Remove(uploadPath)
+		return trace.Wrap(err)
+	}
+	if checksum != req.Sha512 {
+		os.Remove(uploadPath)
+		os.Remove(partialPath)
+		return trace.BadParameter("checksum mismatch for %v: expected %v, got %v",
+			path, req.Sha512, checksum)
+	}
+	mode := os.FileMode(req.Mode)
+	if mode == 0 {
+		mode = defaults.SharedReadMask
+	}
+	if err := os.Chmod(uploadPath, mode); err != nil {
+		os.Remove(uploadPath)
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Rename(uploadPath, path); err != nil {
+		os.Remove(uploadPath)
+		return trace.ConvertSystemError(err)
+	}
+	if err := os.Remove(partialPath); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warn("Failed to remove partial file.")
+	}
+	info, err := statFile(path)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	info.Path = req.Path
+	log.Debug("Upload completed.")
+	return trace.Wrap(stream.SendAndClose(info))
+}
+
+// GetFile streams the contents of the requested file starting at the specified offset
+func (srv *agentServer) GetFile(req *pb.GetFileRequest, stream pb.Agent_GetFileServer) error {
+	path, err := srv.checkFilePath(req.Path)
+	if err != nil {
+		return convertFileError(err)
+	}
+	srv.WithFields(log.Fields{
+		"request": "GetFile",
+		"path":    path,
+		"offset":  req.Offset,
+	}).Debug("Request received.")
+
+	f, err := os.Open(path)
+	if err != nil {
+		return convertFileError(trace.ConvertSystemError(err))
+	}
+	defer f.Close()
+	if _, err := f.Seek(req.Offset, io.SeekStart); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	buf := make([]byte, defaults.RPCAgentFileChunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&pb.FileChunk{Data: buf[:n]}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+}
+
+// StatFile returns information about the requested file
+// or the incomplete upload of the file
+func (srv *agentServer) StatFile(ctx context.Context, req *pb.StatFileRequest) (*pb.FileInfo, error) {
+	path, err := srv.checkFilePath(req.Path)
+	if err != nil {
+		return nil, convertFileError(err)
+	}
+	if !req.Partial {
+		info, err := statFile(path)
+		if err != nil {
+			return nil, convertFileError(err)
+		}
+		info.Path = req.Path
+		return info, nil
+	}
+	fi, err := os.Stat(partialFilePath(path))
+	if err != nil {
+		return nil, convertFileError(trace.ConvertSystemError(err))
+	}
+	// the checksum of the incomplete upload is of no use
+	return &pb.FileInfo{
+		Path:  req.Path,
+		Size_: fi.Size(),
+		Mode:  uint32(fi.Mode().Perm()),
+	}, nil
+}
+
+func statFile(path string) (*pb.FileInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	info := &pb.FileInfo{
+		Path:  path,
+		Size_: fi.Size(),
+		Mode:  uint32(fi.Mode().Perm()),
+		IsDir: fi.IsDir(),
+	}
+	if !fi.IsDir() {
+		info.Sha512, err = utils.SHA512HalfFile(path)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	return info, nil
+}
+
+// createUploadFile creates a new temporary file for the upload of the file
+// at path. If the upload is resumed at a non-zero offset, the temporary file
+// starts with the data of the partial file up to the offset
+func createUploadFile(path, partialPath string, offset int64) (*os.File, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(partialPath)+"-")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if offset == 0 {
+		return f, nil
+	}
+	if err := copyPartialFile(f, partialPath, offset); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, trace.Wrap(err)
+	}
+	return f, nil
+}
+
+// copyPartialFile copies the data of the partial file up to the offset to w
+func copyPartialFile(w io.Writer, partialPath string, offset int64) error {
+	partial, err := os.Open(partialPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return trace.BadParameter("cannot resume upload of %v at offset %v: there is no partial upload",
+				partialPath, offset)
+		}
+		return trace.ConvertSystemError(err)
+	}
+	defer partial.Close()
+	n, err := io.CopyN(w, partial, offset)
+	if err == io.EOF {
+		return trace.BadParameter("cannot resume upload of %v at offset %v: only %v bytes have been uploaded",
+			partialPath, offset, n)
+	}
+	return trace.ConvertSystemError(err)
+}
+
+// keepPartialFile makes the data of the interrupted upload
+// the partial file so that the upload can be resumed
+func keepPartialFile(uploadPath, partialPath string, log log.FieldLogger) {
+	if err := os.Rename(uploadPath, partialPath); err != nil {
+		log.WithError(err).Warn("Failed to keep partial file.")
+		os.Remove(uploadPath)
+	}
+}
+
+// checkFilePath validates the path of the transferred file and returns
+// it with symlinks resolved. Only the files in the directories
+// from the FileDirs configuration can be transferred
+func (srv *agentServer) checkFilePath(path string) (string, error) {
+	if path == "" {
+		return "", trace.BadParameter("missing file path")
+	}
+	if !filepath.IsAbs(path) {
+		return "", trace.BadParameter("file path %v is not absolute", path)
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	for _, dir := range srv.FileDirs {
+		dir, err := resolvePath(dir)
+		if err != nil {
+			return "", trace.Wrap(err)
+		}
+		if isWithinDir(dir, resolved) {
+			return resolved, nil
+		}
+	}
+	return "", trace.AccessDenied("file %v is outside of the directories %v",
+		path, strings.Join(srv.FileDirs, ", "))
+}
+
+// resolvePath returns the path with symlinks resolved.
+// The path does not have to exist
+func resolvePath(path string) (string, error) {
+	path = filepath.Clean(path)
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", trace.ConvertSystemError(err)
+	}
+	dir, err := resolvePath(filepath.Dir(path))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+// isWithinDir returns true if path is the directory dir or is inside of it
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// partialFilePath returns the path to the incomplete upload of the specified file
+func partialFilePath(path string) string {
+	return path + defaults.PartialFileSuffix
+}
+
+// convertFileError converts the specified error to the gRPC error
+// with the corresponding code so clients can tell missing
+// and inaccessible files
+func convertFileError(err error) error {
+	if trace.IsNotFound(err) {
+		return status.Error(codes.NotFound, trace.UserMessage(err))
+	}
+	if trace.IsAccessDenied(err) {
+		return status.Error(codes.PermissionDenied, trace.UserMessage(err))
+	}
+	return trace.Wrap(err)
+}
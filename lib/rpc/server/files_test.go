@@ -0,0 +1,179 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/rpc/client"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/net/context"
+	. "gopkg.in/check.v1"
+)
+
+func (r *S) TestTransfersFiles(c *C) {
+	clt := r.newFileClient(c, "TransfersFiles")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	dir := c.MkDir()
+	data := strings.Repeat("data", defaults.RPCAgentFileChunkSize/2)
+	localPath := filepath.Join(dir, "local")
+	c.Assert(ioutil.WriteFile(localPath, []byte(data), 0640), IsNil)
+
+	remotePath := filepath.Join(dir, "remote")
+	info, err := clt.UploadFile(ctx, localPath, remotePath)
+	c.Assert(err, IsNil)
+	c.Assert(info.Size_, Equals, int64(len(data)))
+	c.Assert(os.FileMode(info.Mode), Equals, os.FileMode(0640))
+	assertFile(c, remotePath, data)
+
+	downloadPath := filepath.Join(dir, "download")
+	_, err = clt.DownloadFile(ctx, remotePath, downloadPath)
+	c.Assert(err, IsNil)
+	assertFile(c, downloadPath, data)
+
+	_, err = clt.StatFile(ctx, filepath.Join(dir, "missing"))
+	c.Assert(trace.IsNotFound(err), Equals, true, Commentf("%v", err))
+}
+
+func (r *S) TestResumesFileTransfers(c *C) {
+	clt := r.newFileClient(c, "ResumesFileTransfers")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	dir := c.MkDir()
+	data := strings.Repeat("data", defaults.RPCAgentFileChunkSize/2)
+	localPath := filepath.Join(dir, "local")
+	c.Assert(ioutil.WriteFile(localPath, []byte(data), defaults.SharedReadMask), IsNil)
+
+	// simulate interrupted transfers in both directions
+	remotePath := filepath.Join(dir, "remote")
+	err := ioutil.WriteFile(remotePath+defaults.PartialFileSuffix, []byte(data[:1000]), defaults.SharedReadMask)
+	c.Assert(err, IsNil)
+	_, err = clt.UploadFile(ctx, localPath, remotePath)
+	c.Assert(err, IsNil)
+	assertFile(c, remotePath, data)
+
+	downloadPath := filepath.Join(dir, "download")
+	err = ioutil.WriteFile(downloadPath+defaults.PartialFileSuffix, []byte(data[:2000]), defaults.SharedReadMask)
+	c.Assert(err, IsNil)
+	_, err = clt.DownloadFile(ctx, remotePath, downloadPath)
+	c.Assert(err, IsNil)
+	assertFile(c, downloadPath, data)
+
+	// the partial data of a different file is discarded
+	err = ioutil.WriteFile(remotePath+defaults.PartialFileSuffix, []byte("corrupted"), defaults.SharedReadMask)
+	c.Assert(err, IsNil)
+	c.Assert(os.Remove(remotePath), IsNil)
+	_, err = clt.UploadFile(ctx, localPath, remotePath)
+	c.Assert(err, NotNil)
+	_, err = os.Stat(remotePath + defaults.PartialFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = clt.UploadFile(ctx, localPath, remotePath)
+	c.Assert(err, IsNil)
+	assertFile(c, remotePath, data)
+}
+
+func (r *S) TestRestrictsFileTransfers(c *C) {
+	clt := r.newFileClient(c, "RestrictsFileTransfers")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	dir := c.MkDir()
+	localPath := filepath.Join(dir, "local")
+	c.Assert(ioutil.WriteFile(localPath, []byte("data"), defaults.SharedReadMask), IsNil)
+	c.Assert(os.Symlink("/etc", filepath.Join(dir, "etc")), IsNil)
+
+	for _, path := range []string{
+		"/etc/hostname",
+		filepath.Join(dir, strings.Repeat("../", 10), "etc", "hostname"),
+		filepath.Join(dir, "etc", "hostname"),
+	} {
+		_, err := clt.StatFile(ctx, path)
+		c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v: %v", path, err))
+		_, err = clt.DownloadFile(ctx, path, filepath.Join(dir, "download"))
+		c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v: %v", path, err))
+		_, err = clt.UploadFile(ctx, localPath, path)
+		c.Assert(trace.IsAccessDenied(err), Equals, true, Commentf("%v: %v", path, err))
+	}
+}
+
+func (r *S) TestIsolatesConcurrentUploads(c *C) {
+	clt := r.newFileClient(c, "IsolatesConcurrentUploads")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	dir := c.MkDir()
+	remotePath := filepath.Join(dir, "remote")
+	contents := make(map[string]struct{})
+	errCh := make(chan error, 2)
+	for _, s := range []string{"first", "second"} {
+		data := strings.Repeat(s, defaults.RPCAgentFileChunkSize)
+		contents[data] = struct{}{}
+		localPath := filepath.Join(dir, s)
+		c.Assert(ioutil.WriteFile(localPath, []byte(data), defaults.SharedReadMask), IsNil)
+		go func() {
+			_, err := clt.UploadFile(ctx, localPath, remotePath)
+			errCh <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		c.Assert(<-errCh, IsNil)
+	}
+	data, err := ioutil.ReadFile(remotePath)
+	c.Assert(err, IsNil)
+	_, ok := contents[string(data)]
+	c.Assert(ok, Equals, true, Commentf("uploads have been mixed up"))
+}
+
+func (r *S) newFileClient(c *C, test string) client.Client {
+	creds := TestCredentials(c)
+	log := r.WithField("test", test)
+	listener := listen(c)
+	srv, err := New(Config{
+		Listener:    listener,
+		Credentials: creds,
+		// tests transfer files in temporary directories
+		FileDirs: []string{os.TempDir()},
+	}, log.WithField("server", listener.Addr()))
+	c.Assert(err, IsNil)
+	go srv.Serve()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 1*time.Second)
+	defer cancel()
+	clt, err := client.New(ctx, client.Config{
+		ServerAddr:  srv.Addr().String(),
+		Credentials: creds.Client,
+	})
+	c.Assert(err, IsNil)
+	return clt
+}
+
+func assertFile(c *C, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, expected)
+	_, err = os.Stat(path + defaults.PartialFileSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/state"
+
+	sigar "github.com/cloudfoundry/gosigar"
+	"github.com/gogo/protobuf/types"
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+	"golang.org/x/net/context"
+)
+
+// GetMetrics returns the health and resource usage metrics of the agent's node
+func (srv *agentServer) GetMetrics(ctx context.Context, _ *types.Empty) (*pb.AgentMetrics, error) {
+	var load sigar.LoadAverage
+	if err := load.Get(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var memory sigar.Mem
+	if err := memory.Get(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	disks, err := srv.getDiskUsage()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &pb.AgentMetrics{
+		Version:         version.Get().Version,
+		Load1:           load.One,
+		Load5:           load.Five,
+		Load15:          load.Fifteen,
+		MemoryTotal:     memory.Total,
+		MemoryFree:      memory.ActualFree,
+		Disks:           disks,
+		RunningCommands: atomic.LoadInt32(&srv.runningCommands),
+	}, nil
+}
+
+// getDiskUsage returns the usage of the filesystems of gravity state directories
+func (srv *agentServer) getDiskUsage() (disks []*pb.DiskUsage, err error) {
+	stateDir := srv.StateDir
+	if stateDir == "" {
+		stateDir, err = state.GetStateDir()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	dirs := []string{stateDir, defaults.GravityEphemeralDir}
+	if srv.TempDir != "" && srv.TempDir != stateDir {
+		dirs = append(dirs, srv.TempDir)
+	}
+	for _, dir := range dirs {
+		var usage sigar.FileSystemUsage
+		if err := usage.Get(dir); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, trace.ConvertSystemError(err)
+		}
+		// usage is reported in kilobytes
+		disks = append(disks, &pb.DiskUsage{
+			Path:  dir,
+			Total: usage.Total * 1024,
+			Free:  usage.Avail * 1024,
+		})
+	}
+	return disks, nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"time"
+
+	"github.com/gravitational/version"
+	"golang.org/x/net/context"
+	. "gopkg.in/check.v1"
+)
+
+func (r *S) TestReportsMetrics(c *C) {
+	clt := r.newFileClient(c, "ReportsMetrics")
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+
+	metrics, err := clt.GetMetrics(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(metrics.Version, Equals, version.Get().Version)
+	c.Assert(metrics.MemoryTotal > 0, Equals, true)
+	c.Assert(metrics.MemoryFree <= metrics.MemoryTotal, Equals, true)
+	c.Assert(metrics.RunningCommands, Equals, int32(0))
+	for _, disk := range metrics.Disks {
+		c.Assert(disk.Free <= disk.Total, Equals, true)
+	}
+}
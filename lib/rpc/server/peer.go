@@ -426,3 +426,15 @@ type peer struct {
 	// doneCh is the channel that is closed when this peer shuts down
 	doneCh chan struct{}
 }
+
+// GetMetrics returns the health and resource usage metrics of this peer
+func (r *peer) GetMetrics(ctx context.Context) (*pb.AgentMetrics, error) {
+	if r.Client == nil {
+		return nil, trace.ConnectionProblem(nil, "%v not connected", r.Addr())
+	}
+	metrics, err := r.Client.Client().GetMetrics(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return metrics, nil
+}
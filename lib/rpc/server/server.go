@@ -26,6 +26,7 @@ import (
 	"github.com/gravitational/gravity/lib/network/validation"
 	validationpb "github.com/gravitational/gravity/lib/network/validation/proto"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
+	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
 	"github.com/gravitational/gravity/lib/utils"
@@ -144,6 +145,12 @@ type Config struct {
 	// ReconnectTimeout specifies the maximum timeout used to reconnect to a peer.
 	// Defaults to defaults.RPCAgentBackoffThreshold
 	ReconnectTimeout time.Duration
+	// SecretsDir specifies the optional directory with the agent credentials.
+	// If specified, the rotated credentials are persisted in this directory
+	SecretsDir string
+	// FileDirs lists the directories the files can be transferred to and from.
+	// Defaults to the state directory
+	FileDirs []string
 	// systemInfo queries system information
 	systemInfo
 	// commandExecutor is a system command executor.
@@ -169,6 +176,18 @@ func (r *Config) CheckAndSetDefaults() error {
 		r.commandExecutor = execFunc(osExec)
 	}
 
+	if len(r.FileDirs) == 0 {
+		stateDir := r.StateDir
+		if stateDir == "" {
+			var err error
+			stateDir, err = state.GetStateDir()
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		r.FileDirs = []string{stateDir}
+	}
+
 	return nil
 }
 
@@ -201,6 +220,8 @@ type agentServer struct {
 	grpcServer *grpc.Server
 	// listener is the server's listener
 	listener net.Listener
-	ctx      context.Context
-	cancel   context.CancelFunc
+	// runningCommands is the number of commands being executed
+	runningCommands int32
+	ctx             context.Context
+	cancel          context.CancelFunc
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package rpc
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -32,6 +33,7 @@ import (
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/cloudflare/cfssl/csr"
+	"github.com/cloudflare/cfssl/helpers"
 	"github.com/gravitational/license/authority"
 	"github.com/gravitational/trace"
 	"google.golang.org/grpc/credentials"
@@ -59,6 +61,14 @@ func GenerateAgentCredentialsPackage(packages pack.PackageService, pkgTemplate l
 // GenerateAgentCredentials creates client/server credentials archive.
 // hosts lists additional hosts to add to the generated certificates.
 func GenerateAgentCredentials(hosts []string, commonName string, longLivedClient bool) (archive utils.TLSArchive, err error) {
+	var clientTTL time.Duration
+	if longLivedClient {
+		clientTTL = defaults.CertificateExpiry
+	}
+	return generateAgentCredentials(hosts, commonName, clientTTL)
+}
+
+func generateAgentCredentials(hosts []string, commonName string, clientTTL time.Duration) (archive utils.TLSArchive, err error) {
 	ca, err := authority.GenerateSelfSignedCA(csr.CertificateRequest{
 		CN: commonName,
 	})
@@ -82,10 +92,6 @@ func GenerateAgentCredentials(hosts []string, commonName string, longLivedClient
 		return nil, trace.Wrap(err)
 	}
 
-	var clientTTL time.Duration
-	if longLivedClient {
-		clientTTL = defaults.CertificateExpiry
-	}
 	clientKeyPair, err := authority.GenerateCertificate(csr.CertificateRequest{
 		CN: "leadagent",
 		Names: []csr.Name{
@@ -248,6 +254,98 @@ func Credentials(secretsDir string) (server credentials.TransportCredentials, cl
 	return server, client, nil
 }
 
+// ReadCredentials reads the agent credentials from the specified secrets dir
+func ReadCredentials(secretsDir string) (utils.TLSArchive, error) {
+	archive := make(utils.TLSArchive)
+	for _, name := range []string{pb.Server, pb.Client, pb.CA} {
+		var keyPair authority.TLSKeyPair
+		certPEM, err := ioutil.ReadFile(credentialsPath(secretsDir, name, pb.Cert))
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		keyPair.CertPEM = certPEM
+		if name != pb.CA {
+			keyPEM, err := ioutil.ReadFile(credentialsPath(secretsDir, name, pb.Key))
+			if err != nil {
+				return nil, trace.ConvertSystemError(err)
+			}
+			keyPair.KeyPEM = keyPEM
+		}
+		archive[name] = &keyPair
+	}
+	return archive, nil
+}
+
+// WriteCredentials replaces the agent credentials in the specified secrets dir
+// with the credentials from archive.
+// Each file is replaced atomically
+func WriteCredentials(secretsDir string, archive utils.TLSArchive) error {
+	for _, name := range []string{pb.Server, pb.Client, pb.CA} {
+		keyPair, err := archive.GetKeyPair(name)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(keyPair.KeyPEM) != 0 {
+			err = utils.CopyReaderWithPerms(credentialsPath(secretsDir, name, pb.Key),
+				bytes.NewReader(keyPair.KeyPEM), defaults.GroupReadMask)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		err = utils.CopyReaderWithPerms(credentialsPath(secretsDir, name, pb.Cert),
+			bytes.NewReader(keyPair.CertPEM), defaults.SharedReadMask)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// CredentialsFromArchive returns both server and client credentials
+// from the specified archive
+func CredentialsFromArchive(archive utils.TLSArchive) (server credentials.TransportCredentials, client credentials.TransportCredentials, err error) {
+	if err := checkCredentials(archive); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	serverCert, err := tls.X509KeyPair(archive[pb.Server].CertPEM, archive[pb.Server].KeyPEM)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	certPool := x509.NewCertPool()
+	if ok := certPool.AppendCertsFromPEM(archive[pb.CA].CertPEM); !ok {
+		return nil, nil, trace.BadParameter("failed to append CA to cert pool")
+	}
+	server = credentials.NewTLS(&tls.Config{
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    certPool,
+	})
+	client, err = ClientCredentialsFromKeyPairs(*archive[pb.Client], *archive[pb.CA])
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return server, client, nil
+}
+
+// CredentialsExpiry returns the time the earliest of the server and
+// client certificates from the specified archive expires
+func CredentialsExpiry(archive utils.TLSArchive) (time.Time, error) {
+	if err := checkCredentials(archive); err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	var expires time.Time
+	for _, name := range []string{pb.Server, pb.Client} {
+		cert, err := helpers.ParseCertificatePEM(archive[name].CertPEM)
+		if err != nil {
+			return time.Time{}, trace.Wrap(err)
+		}
+		if expires.IsZero() || cert.NotAfter.Before(expires) {
+			expires = cert.NotAfter
+		}
+	}
+	return expires, nil
+}
+
 // AgentAddr returns a complete agent address for specified address addr.
 // If addr already contains a port, the address is returned unaltered,
 // otherwise, a default RPC agent port is added
@@ -269,8 +367,9 @@ func createPackage(packages pack.PackageService, pkg loc.Locator, archive utils.
 		return trace.Wrap(err)
 	}
 
-	labels := map[string]string{
-		pack.PurposeLabel: pack.PurposeRPCCredentials,
+	labels, err := credentialsLabels(archive)
+	if err != nil {
+		return trace.Wrap(err)
 	}
 	_, err = packages.CreatePackage(pkg, reader, pack.WithLabels(labels))
 	return trace.Wrap(err)
@@ -289,9 +388,43 @@ func upsertPackage(packages pack.PackageService, pkg loc.Locator, archive utils.
 		return trace.Wrap(err)
 	}
 
-	labels := map[string]string{
-		pack.PurposeLabel: pack.PurposeRPCCredentials,
+	labels, err := credentialsLabels(archive)
+	if err != nil {
+		return trace.Wrap(err)
 	}
 	_, err = packages.UpsertPackage(pkg, reader, pack.WithLabels(labels))
 	return trace.Wrap(err)
 }
+
+// credentialsLabels returns the labels for the secrets package with the specified archive
+func credentialsLabels(archive utils.TLSArchive) (map[string]string, error) {
+	expires, err := CredentialsExpiry(archive)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return map[string]string{
+		pack.PurposeLabel: pack.PurposeRPCCredentials,
+		pack.ExpiresLabel: expires.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// checkCredentials validates that archive has all the agent credentials
+func checkCredentials(archive utils.TLSArchive) error {
+	for _, name := range []string{pb.Server, pb.Client, pb.CA} {
+		keyPair, err := archive.GetKeyPair(name)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(keyPair.CertPEM) == 0 {
+			return trace.BadParameter("missing %v certificate", name)
+		}
+		if name != pb.CA && len(keyPair.KeyPEM) == 0 {
+			return trace.BadParameter("missing %v private key", name)
+		}
+	}
+	return nil
+}
+
+func credentialsPath(secretsDir, name, ext string) string {
+	return filepath.Join(secretsDir, fmt.Sprintf("%s.%s", name, ext))
+}
@@ -263,7 +263,7 @@ func (in *Extensions) DeepCopyInto(out *Extensions) {
 			*out = nil
 		} else {
 			*out = new(MonitoringExtension)
-			**out = **in
+			(*in).DeepCopyInto(*out)
 		}
 	}
 	if in.Kubernetes != nil {
@@ -904,6 +904,15 @@ func (in *Metadata) DeepCopy() *Metadata {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitoringExtension) DeepCopyInto(out *MonitoringExtension) {
 	*out = *in
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		if *in == nil {
+			*out = nil
+		} else {
+			*out = new(PrometheusBackend)
+			**out = **in
+		}
+	}
 	return
 }
 
@@ -1068,6 +1077,22 @@ func (in *Port) DeepCopy() *Port {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusBackend) DeepCopyInto(out *PrometheusBackend) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusBackend.
+func (in *PrometheusBackend) DeepCopy() *PrometheusBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Providers) DeepCopyInto(out *Providers) {
 	*out = *in
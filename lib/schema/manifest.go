@@ -1101,6 +1101,20 @@ type LogsExtension struct {
 type MonitoringExtension struct {
 	// Disabled allows to disable Monitoring tab
 	Disabled bool `json:"disabled,omitempty"`
+	// Backend is the metrics backend, influxdb (default) or prometheus
+	Backend string `json:"backend,omitempty"`
+	// Prometheus configures the Prometheus metrics backend
+	Prometheus *PrometheusBackend `json:"prometheus,omitempty"`
+}
+
+// PrometheusBackend configures the Prometheus metrics backend
+type PrometheusBackend struct {
+	// URL is the Prometheus HTTP API URL
+	URL string `json:"url,omitempty"`
+	// Namespace is the namespace of the Prometheus statefulset
+	Namespace string `json:"namespace,omitempty"`
+	// StatefulSet is the name of the Prometheus statefulset
+	StatefulSet string `json:"statefulSet,omitempty"`
 }
 
 // CatalogExtension allows to customize application catalog feature
@@ -552,6 +552,40 @@ systemOptions:
 	c.Assert(err, NotNil)
 }
 
+func (s *ManifestSuite) TestMonitoringBackend(c *C) {
+	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+extensions:
+  monitoring:
+    backend: prometheus
+    prometheus:
+      url: http://prometheus-k8s.monitoring.svc.cluster.local:9090
+      statefulSet: prometheus-k8s`)
+	m, err := ParseManifestYAML(bytes)
+	c.Assert(err, IsNil)
+	compare.DeepCompare(c, m.Extensions.Monitoring, &MonitoringExtension{
+		Backend: constants.MonitoringBackendPrometheus,
+		Prometheus: &PrometheusBackend{
+			URL:         "http://prometheus-k8s.monitoring.svc.cluster.local:9090",
+			StatefulSet: "prometheus-k8s",
+		},
+	})
+
+	bytes = []byte(`apiVersion: bundle.gravitational.io/v2
+kind: Bundle
+metadata:
+  name: myapp
+  resourceVersion: 0.0.1
+extensions:
+  monitoring:
+    backend: graphite`)
+	_, err = ParseManifestYAML(bytes)
+	c.Assert(err, NotNil)
+}
+
 func (s *ManifestSuite) TestCanOverrideBooleans(c *C) {
 	bytes := []byte(`apiVersion: bundle.gravitational.io/v2
 kind: Bundle
@@ -572,7 +572,23 @@ const manifestSchema = `
               }
             },
             "logs": {"$ref": "#/definitions/onOff"},
-            "monitoring": {"$ref": "#/definitions/onOff"},
+            "monitoring": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "disabled": {"type": "boolean"},
+                "backend": {"type": "string", "enum": ["influxdb", "prometheus"]},
+                "prometheus": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "properties": {
+                    "url": {"type": "string"},
+                    "namespace": {"type": "string"},
+                    "statefulSet": {"type": "string"}
+                  }
+                }
+              }
+            },
             "catalog": {"$ref": "#/definitions/onOff"},
             "kubernetes": {"$ref": "#/definitions/onOff"},
             "configuration": {"$ref": "#/definitions/onOff"}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ed25519"
+)
+
+// parseEd25519PrivateKey returns the Ed25519 private key from the PKCS #8
+// encoded key as described in RFC 8410.
+// Returns false if the key is not an Ed25519 key
+func parseEd25519PrivateKey(der []byte) (ed25519.PrivateKey, bool, error) {
+	var key pkcs8
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, false, trace.Wrap(err)
+	}
+	if !key.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, false, nil
+	}
+	var seed []byte
+	if _, err := asn1.Unmarshal(key.PrivateKey, &seed); err != nil {
+		return nil, true, trace.Wrap(err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, true, trace.BadParameter("invalid Ed25519 private key length %v", len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), true, nil
+}
+
+// parseEd25519PublicKey returns the Ed25519 public key from the PKIX
+// encoded key as described in RFC 8410.
+// Returns false if the key is not an Ed25519 key
+func parseEd25519PublicKey(der []byte) (ed25519.PublicKey, bool, error) {
+	var key publicKeyInfo
+	if _, err := asn1.Unmarshal(der, &key); err != nil {
+		return nil, false, trace.Wrap(err)
+	}
+	if !key.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, false, nil
+	}
+	if len(key.PublicKey.Bytes) != ed25519.PublicKeySize {
+		return nil, true, trace.BadParameter("invalid Ed25519 public key length %v", len(key.PublicKey.Bytes))
+	}
+	return ed25519.PublicKey(key.PublicKey.Bytes), true, nil
+}
+
+// pkcs8 is the ASN.1 encoding of the PKCS #8 private key.
+// The optional attributes are ignored
+type pkcs8 struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is the ASN.1 encoding of the PKIX public key
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// oidEd25519 identifies the Ed25519 keys
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
@@ -0,0 +1,236 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package signing implements detached signatures of installer tarballs
+// and packages.
+//
+// Signatures are computed over the SHA-256 digest of the data with an RSA
+// (PKCS #1 v1.5) or an Ed25519 key. RSA signatures are in the format produced
+// and verified by 'openssl dgst -sha256 -sign/-verify' and Ed25519 signatures
+// sign the digest itself, so installer signatures can be checked without gravity.
+//
+// Both signature schemes are deterministic, so signing the same data
+// with the same key always yields the same signature.
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ed25519"
+)
+
+// Signer signs data with a private key
+type Signer struct {
+	key crypto.Signer
+}
+
+// ReadSigner returns a signer with the PEM-encoded private key from the file at path
+func ReadSigner(path string) (*Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	signer, err := NewSigner(data)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read signing key from %v", path)
+	}
+	return signer, nil
+}
+
+// NewSigner returns a signer with the PEM-encoded RSA or Ed25519 private key
+func NewSigner(keyPEM []byte) (*Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, trace.BadParameter("expected PEM-encoded private key")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		var ok bool
+		key, ok, err = parseEd25519PrivateKey(block.Bytes)
+		if err == nil && !ok {
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+	default:
+		return nil, trace.BadParameter("unsupported private key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &Signer{key: key}, nil
+	case ed25519.PrivateKey:
+		return &Signer{key: key}, nil
+	}
+	return nil, trace.BadParameter("unsupported private key %T, only RSA and Ed25519 keys are supported", key)
+}
+
+// Sign returns the signature of the data
+func (r *Signer) Sign(data []byte) ([]byte, error) {
+	return r.SignReader(bytes.NewReader(data))
+}
+
+// SignReader returns the signature of the data read from the reader
+func (r *Signer) SignReader(reader io.Reader) ([]byte, error) {
+	digest, err := digestOf(reader)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if key, ok := r.key.(ed25519.PrivateKey); ok {
+		return ed25519.Sign(key, digest), nil
+	}
+	signature, err := r.key.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return signature, nil
+}
+
+// Verifier returns the verifier for the signatures made by this signer
+func (r *Signer) Verifier() *Verifier {
+	return &Verifier{key: r.key.Public()}
+}
+
+// Verifier verifies signatures with a public key
+type Verifier struct {
+	key crypto.PublicKey
+}
+
+// ReadVerifier returns a verifier with the PEM-encoded public key
+// or certificate from the file at path
+func ReadVerifier(path string) (*Verifier, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	verifier, err := NewVerifier(data)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read verification key from %v", path)
+	}
+	return verifier, nil
+}
+
+// NewVerifier returns a verifier with the PEM-encoded RSA or Ed25519
+// public key or the certificate with such a key
+func NewVerifier(keyPEM []byte) (*Verifier, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, trace.BadParameter("expected PEM-encoded public key or certificate")
+	}
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		parsed, err := parsePublicKey(block.Bytes)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		key = parsed
+	case "RSA PUBLIC KEY":
+		parsed, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		key = parsed
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		parsed, err := parsePublicKey(cert.RawSubjectPublicKeyInfo)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		key = parsed
+	default:
+		return nil, trace.BadParameter("unsupported public key type %q", block.Type)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return &Verifier{key: key}, nil
+	}
+	return nil, trace.BadParameter("unsupported public key %T, only RSA and Ed25519 keys are supported", key)
+}
+
+// Verify verifies the signature of the data
+func (r *Verifier) Verify(data, signature []byte) error {
+	return r.VerifyReader(bytes.NewReader(data), signature)
+}
+
+// VerifyReader verifies the signature of the data read from the reader
+func (r *Verifier) VerifyReader(reader io.Reader, signature []byte) error {
+	digest, err := digestOf(reader)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch key := r.key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature); err != nil {
+			return trace.AccessDenied("invalid signature")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest, signature) {
+			return trace.AccessDenied("invalid signature")
+		}
+		return nil
+	}
+	return trace.BadParameter("unsupported public key %T", r.key)
+}
+
+// SignatureFile returns the path to the detached signature of the file at path
+func SignatureFile(path string) string {
+	return path + SignatureFileExt
+}
+
+// parsePublicKey returns the PKIX-encoded Ed25519 or other public key
+func parsePublicKey(der []byte) (interface{}, error) {
+	key, ok, err := parseEd25519PublicKey(der)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if ok {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return parsed, nil
+}
+
+func digestOf(reader io.Reader) ([]byte, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// SignatureFileExt is the extension of detached signature files
+const SignatureFileExt = ".sig"
@@ -0,0 +1,170 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signing
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ed25519"
+	. "gopkg.in/check.v1"
+)
+
+func TestSigning(t *testing.T) { TestingT(t) }
+
+type SigningSuite struct{}
+
+var _ = Suite(&SigningSuite{})
+
+func (s *SigningSuite) TestSignsAndVerifies(c *C) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	edPublicKey, edKey, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	pkcs8Bytes, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	c.Assert(err, IsNil)
+
+	var testCases = []struct {
+		comment    string
+		privateKey []byte
+		publicKey  []byte
+	}{
+		{
+			comment:    "RSA key",
+			privateKey: encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			publicKey:  marshalPublicKey(c, rsaKey.Public()),
+		},
+		{
+			comment:    "PKCS #8 key",
+			privateKey: encodePEM("PRIVATE KEY", pkcs8Bytes),
+			publicKey:  marshalPublicKey(c, rsaKey.Public()),
+		},
+		{
+			comment:    "Ed25519 key",
+			privateKey: encodePEM("PRIVATE KEY", marshalEd25519PrivateKey(c, edKey)),
+			publicKey:  marshalEd25519PublicKey(c, edPublicKey),
+		},
+	}
+	for _, testCase := range testCases {
+		comment := Commentf(testCase.comment)
+		signer, err := NewSigner(testCase.privateKey)
+		c.Assert(err, IsNil, comment)
+		verifier, err := NewVerifier(encodePEM("PUBLIC KEY", testCase.publicKey))
+		c.Assert(err, IsNil, comment)
+
+		data := []byte("installer data")
+		signature, err := signer.Sign(data)
+		c.Assert(err, IsNil, comment)
+		c.Assert(verifier.Verify(data, signature), IsNil, comment)
+		c.Assert(signer.Verifier().Verify(data, signature), IsNil, comment)
+
+		err = verifier.Verify([]byte("tampered data"), signature)
+		c.Assert(trace.IsAccessDenied(err), Equals, true, comment)
+		err = verifier.Verify(data, signature[1:])
+		c.Assert(trace.IsAccessDenied(err), Equals, true, comment)
+	}
+}
+
+func (s *SigningSuite) TestSignaturesAreDeterministic(c *C) {
+	// key from RFC 8032, section 7.1, test 1
+	seed, err := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	c.Assert(err, IsNil)
+	edKey := ed25519.NewKeyFromSeed(seed)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+
+	for _, keyPEM := range [][]byte{
+		encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		encodePEM("PRIVATE KEY", marshalEd25519PrivateKey(c, edKey)),
+	} {
+		signer, err := NewSigner(keyPEM)
+		c.Assert(err, IsNil)
+		first, err := signer.Sign([]byte("installer data"))
+		c.Assert(err, IsNil)
+		second, err := signer.Sign([]byte("installer data"))
+		c.Assert(err, IsNil)
+		c.Assert(first, DeepEquals, second)
+		c.Assert(signer.Verifier().Verify([]byte("installer data"), first), IsNil)
+	}
+}
+
+func (s *SigningSuite) TestRejectsSignaturesWithOtherKey(c *C) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	signer, err := NewSigner(encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+	c.Assert(err, IsNil)
+	verifier, err := NewVerifier(encodePEM("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&otherKey.PublicKey)))
+	c.Assert(err, IsNil)
+
+	signature, err := signer.Sign([]byte("data"))
+	c.Assert(err, IsNil)
+	err = verifier.Verify([]byte("data"), signature)
+	c.Assert(trace.IsAccessDenied(err), Equals, true)
+}
+
+func (s *SigningSuite) TestRejectsInvalidKeys(c *C) {
+	_, err := NewSigner([]byte("not a key"))
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+	_, err = NewVerifier(encodePEM("RSA PRIVATE KEY", []byte("private")))
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+	_, err = NewSigner(encodePEM("PRIVATE KEY", marshalPKCS8(c, oidEd25519, []byte("short seed"))))
+	c.Assert(trace.IsBadParameter(err), Equals, true)
+}
+
+func encodePEM(blockType string, data []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+}
+
+func marshalPublicKey(c *C, key crypto.PublicKey) []byte {
+	data, err := x509.MarshalPKIXPublicKey(key)
+	c.Assert(err, IsNil)
+	return data
+}
+
+func marshalEd25519PublicKey(c *C, key ed25519.PublicKey) []byte {
+	data, err := asn1.Marshal(publicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+		PublicKey: asn1.BitString{Bytes: key, BitLength: 8 * len(key)},
+	})
+	c.Assert(err, IsNil)
+	return data
+}
+
+func marshalEd25519PrivateKey(c *C, key ed25519.PrivateKey) []byte {
+	return marshalPKCS8(c, oidEd25519, key.Seed())
+}
+
+func marshalPKCS8(c *C, algorithm asn1.ObjectIdentifier, seed []byte) []byte {
+	privateKey, err := asn1.Marshal(seed)
+	c.Assert(err, IsNil)
+	data, err := asn1.Marshal(pkcs8{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: algorithm},
+		PrivateKey: privateKey,
+	})
+	c.Assert(err, IsNil)
+	return data
+}
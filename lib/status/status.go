@@ -22,13 +22,17 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"sync"
 	"time"
 
+	"github.com/gravitational/gravity/lib/checks"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/httplib"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/rpc"
+	rpcpb "github.com/gravitational/gravity/lib/rpc/proto"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/utils"
@@ -349,6 +353,55 @@ type ClusterServer struct {
 	Status string `json:"status"`
 	// FailedProbes lists all failed probes if the node is not healthy
 	FailedProbes []string `json:"failed_probes,omitempty"`
+	// Agent describes the health of the node as reported by the
+	// operation agent if the agent is running on the node
+	Agent *AgentMetrics `json:"agent,omitempty"`
+}
+
+// AgentMetrics describes the health and resource usage of a node
+// as reported by the operation agent
+type AgentMetrics struct {
+	// Metrics specifies the metrics reported by the agent
+	Metrics *rpcpb.AgentMetrics `json:"metrics,omitempty"`
+	// Warnings lists resource shortages detected on the node
+	Warnings []string `json:"warnings,omitempty"`
+	// Error describes the error querying the agent
+	Error string `json:"error,omitempty"`
+}
+
+// CollectAgentMetrics queries the operation agents running on the nodes
+// for health and resource usage metrics
+func (r *Agent) CollectAgentMetrics(ctx context.Context, agents rpc.AgentRepository) {
+	ctx, cancel := context.WithTimeout(ctx, defaults.AgentMetricsTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := range r.Nodes {
+		wg.Add(1)
+		go func(node *ClusterServer) {
+			defer wg.Done()
+			node.Agent = agentMetrics(ctx, node.AdvertiseIP, agents)
+		}(&r.Nodes[i])
+	}
+	wg.Wait()
+}
+
+func agentMetrics(ctx context.Context, addr string, agents rpc.AgentRepository) *AgentMetrics {
+	clt, err := agents.GetClient(ctx, addr)
+	if err != nil {
+		return &AgentMetrics{Error: trace.UserMessage(err)}
+	}
+	metrics, err := clt.GetMetrics(ctx)
+	if err != nil {
+		return &AgentMetrics{Error: trace.UserMessage(err)}
+	}
+	var numCPU uint
+	if info, err := clt.GetSystemInfo(ctx); err == nil {
+		numCPU = info.GetNumCPU()
+	}
+	return &AgentMetrics{
+		Metrics:  metrics,
+		Warnings: checks.MetricsWarnings(*metrics, numCPU),
+	}
 }
 
 func (r ClusterOperation) isFailed() bool {
@@ -0,0 +1,322 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	teleservices "github.com/gravitational/teleport/lib/services"
+	teleutils "github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// AuditSink describes a resource that configures forwarding
+// of the cluster audit events to an external system
+type AuditSink interface {
+	teleservices.Resource
+	// GetSyslog returns the syslog sink configuration
+	GetSyslog() *AuditSinkSyslog
+	// GetFile returns the file sink configuration
+	GetFile() *AuditSinkFile
+	// GetWebhook returns the webhook sink configuration
+	GetWebhook() *AuditSinkWebhook
+	// CheckAndSetDefaults validates the audit sink configuration
+	CheckAndSetDefaults() error
+}
+
+// NewAuditSink creates a new audit sink resource
+func NewAuditSink(name string, spec AuditSinkSpecV2) AuditSink {
+	return &AuditSinkV2{
+		Kind:    KindAuditSink,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// AuditSinkV2 represents the audit sink resource
+type AuditSinkV2 struct {
+	// Kind is the resource kind, "auditsink"
+	Kind string `json:"kind"`
+	// Version is the resource version, "v2"
+	Version string `json:"version"`
+	// Metadata contains the audit sink metadata
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the audit sink spec
+	Spec AuditSinkSpecV2 `json:"spec"`
+}
+
+// AuditSinkSpecV2 is the audit sink spec.
+// Exactly one of the sink configurations must be set
+type AuditSinkSpecV2 struct {
+	// Syslog configures forwarding to a syslog server
+	Syslog *AuditSinkSyslog `json:"syslog,omitempty"`
+	// File configures writing to a local file
+	File *AuditSinkFile `json:"file,omitempty"`
+	// Webhook configures posting to an HTTPS endpoint
+	Webhook *AuditSinkWebhook `json:"webhook,omitempty"`
+}
+
+// AuditSinkSyslog configures forwarding of audit events to a syslog
+// server in RFC5424 format
+type AuditSinkSyslog struct {
+	// Address is the syslog server address as host:port
+	Address string `json:"address"`
+	// Protocol is the transport protocol, tcp or tls
+	Protocol string `json:"protocol,omitempty"`
+	// CACert is the optional PEM-encoded certificate authority
+	// to verify the server certificate with
+	CACert string `json:"ca_cert,omitempty"`
+}
+
+// AuditSinkFile configures writing audit events to a local file
+// as JSON lines
+type AuditSinkFile struct {
+	// Path is the absolute path to the file
+	Path string `json:"path"`
+	// MaxSizeMB is the file size in megabytes after which the file is rotated
+	MaxSizeMB int `json:"max_size_mb,omitempty"`
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int `json:"max_backups,omitempty"`
+}
+
+// AuditSinkWebhook configures posting audit events to an HTTPS endpoint
+type AuditSinkWebhook struct {
+	// URL is the webhook URL
+	URL string `json:"url"`
+	// Headers specifies additional request headers
+	Headers map[string]string `json:"headers,omitempty"`
+	// CACert is the optional PEM-encoded certificate authority
+	// to verify the server certificate with
+	CACert string `json:"ca_cert,omitempty"`
+}
+
+// GetName returns the audit sink name
+func (s *AuditSinkV2) GetName() string {
+	return s.Metadata.Name
+}
+
+// SetName sets the audit sink name
+func (s *AuditSinkV2) SetName(name string) {
+	s.Metadata.Name = name
+}
+
+// GetMetadata returns the audit sink metadata
+func (s *AuditSinkV2) GetMetadata() teleservices.Metadata {
+	return s.Metadata
+}
+
+// SetExpiry sets the audit sink expiration time
+func (s *AuditSinkV2) SetExpiry(expires time.Time) {
+	s.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns the audit sink expiration time
+func (s *AuditSinkV2) Expiry() time.Time {
+	return s.Metadata.Expiry()
+}
+
+// SetTTL sets the audit sink TTL
+func (s *AuditSinkV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	s.Metadata.SetTTL(clock, ttl)
+}
+
+// GetSyslog returns the syslog sink configuration
+func (s *AuditSinkV2) GetSyslog() *AuditSinkSyslog {
+	return s.Spec.Syslog
+}
+
+// GetFile returns the file sink configuration
+func (s *AuditSinkV2) GetFile() *AuditSinkFile {
+	return s.Spec.File
+}
+
+// GetWebhook returns the webhook sink configuration
+func (s *AuditSinkV2) GetWebhook() *AuditSinkWebhook {
+	return s.Spec.Webhook
+}
+
+// CheckAndSetDefaults validates the audit sink configuration
+func (s *AuditSinkV2) CheckAndSetDefaults() error {
+	if s.Metadata.Name == "" {
+		return trace.BadParameter("missing audit sink name")
+	}
+	var configured int
+	if s.Spec.Syslog != nil {
+		configured++
+		if err := s.Spec.Syslog.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if s.Spec.File != nil {
+		configured++
+		if err := s.Spec.File.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if s.Spec.Webhook != nil {
+		configured++
+		if err := s.Spec.Webhook.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if configured != 1 {
+		return trace.BadParameter("audit sink %q must specify exactly one of syslog, file or webhook",
+			s.Metadata.Name)
+	}
+	return nil
+}
+
+func (s *AuditSinkSyslog) checkAndSetDefaults() error {
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return trace.BadParameter("invalid syslog address %q, expected host:port", s.Address)
+	}
+	switch s.Protocol {
+	case "":
+		s.Protocol = AuditSinkProtocolTLS
+	case AuditSinkProtocolTCP, AuditSinkProtocolTLS:
+	default:
+		return trace.BadParameter("unsupported syslog protocol %q, must be one of: %v, %v",
+			s.Protocol, AuditSinkProtocolTCP, AuditSinkProtocolTLS)
+	}
+	return nil
+}
+
+func (s *AuditSinkFile) checkAndSetDefaults() error {
+	if !filepath.IsAbs(s.Path) {
+		return trace.BadParameter("audit file path %q must be absolute", s.Path)
+	}
+	if s.MaxSizeMB < 0 || s.MaxBackups < 0 {
+		return trace.BadParameter("audit file rotation settings must not be negative")
+	}
+	if s.MaxSizeMB == 0 {
+		s.MaxSizeMB = defaults.AuditFileMaxSizeMB
+	}
+	if s.MaxBackups == 0 {
+		s.MaxBackups = defaults.AuditFileMaxBackups
+	}
+	return nil
+}
+
+func (s *AuditSinkWebhook) checkAndSetDefaults() error {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return trace.BadParameter("invalid webhook URL %q: %v", s.URL, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return trace.BadParameter("webhook URL %q must be an https URL", s.URL)
+	}
+	return nil
+}
+
+// UnmarshalAuditSink unmarshals the audit sink resource from the provided data
+func UnmarshalAuditSink(data []byte) (AuditSink, error) {
+	if len(data) == 0 {
+		return nil, trace.BadParameter("missing audit sink data")
+	}
+	jsonData, err := teleutils.ToJSON(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var header teleservices.ResourceHeader
+	err = json.Unmarshal(jsonData, &header)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch header.Version {
+	case teleservices.V2:
+		var sink AuditSinkV2
+		err := teleutils.UnmarshalWithSchema(GetAuditSinkSchema(), &sink, jsonData)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		sink.Metadata.CheckAndSetDefaults()
+		if err := sink.CheckAndSetDefaults(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &sink, nil
+	}
+	return nil, trace.BadParameter("%v resource version %q is not supported",
+		KindAuditSink, header.Version)
+}
+
+// MarshalAuditSink marshals the provided audit sink resource to JSON
+func MarshalAuditSink(sink AuditSink, opts ...teleservices.MarshalOption) ([]byte, error) {
+	return json.Marshal(sink)
+}
+
+// GetAuditSinkSchema returns the full audit sink resource schema
+func GetAuditSinkSchema() string {
+	return fmt.Sprintf(teleservices.V2SchemaTemplate, MetadataSchema,
+		AuditSinkSpecV2Schema, "")
+}
+
+// AuditSinkSpecV2Schema defines the audit sink spec schema
+const AuditSinkSpecV2Schema = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "syslog": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["address"],
+      "properties": {
+        "address": {"type": "string"},
+        "protocol": {"type": "string"},
+        "ca_cert": {"type": "string"}
+      }
+    },
+    "file": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["path"],
+      "properties": {
+        "path": {"type": "string"},
+        "max_size_mb": {"type": "integer"},
+        "max_backups": {"type": "integer"}
+      }
+    },
+    "webhook": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url"],
+      "properties": {
+        "url": {"type": "string"},
+        "headers": {"type": "object", "additionalProperties": {"type": "string"}},
+        "ca_cert": {"type": "string"}
+      }
+    }
+  }
+}`
+
+const (
+	// AuditSinkProtocolTCP forwards audit events to syslog over plain TCP
+	AuditSinkProtocolTCP = "tcp"
+	// AuditSinkProtocolTLS forwards audit events to syslog over TLS
+	AuditSinkProtocolTLS = "tls"
+)
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"gopkg.in/check.v1"
+)
+
+type AuditSinkSuite struct{}
+
+var _ = check.Suite(&AuditSinkSuite{})
+
+func (s *AuditSinkSuite) TestUnmarshal(c *check.C) {
+	tcs := []struct {
+		comment  string
+		spec     string
+		expected AuditSink
+		err      bool
+	}{
+		{
+			comment: "syslog sink defaults to tls",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: siem
+spec:
+  syslog:
+    address: siem.example.com:6514`,
+			expected: NewAuditSink("siem", AuditSinkSpecV2{
+				Syslog: &AuditSinkSyslog{
+					Address:  "siem.example.com:6514",
+					Protocol: AuditSinkProtocolTLS,
+				},
+			}),
+		},
+		{
+			comment: "file sink with rotation defaults",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: local
+spec:
+  file:
+    path: /var/log/gravity-audit.log`,
+			expected: NewAuditSink("local", AuditSinkSpecV2{
+				File: &AuditSinkFile{
+					Path:       "/var/log/gravity-audit.log",
+					MaxSizeMB:  defaults.AuditFileMaxSizeMB,
+					MaxBackups: defaults.AuditFileMaxBackups,
+				},
+			}),
+		},
+		{
+			comment: "webhook sink",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: hook
+spec:
+  webhook:
+    url: https://hooks.example.com/audit
+    headers:
+      Authorization: Bearer token`,
+			expected: NewAuditSink("hook", AuditSinkSpecV2{
+				Webhook: &AuditSinkWebhook{
+					URL:     "https://hooks.example.com/audit",
+					Headers: map[string]string{"Authorization": "Bearer token"},
+				},
+			}),
+		},
+		{
+			comment: "webhook must use https",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: hook
+spec:
+  webhook:
+    url: http://hooks.example.com/audit`,
+			err: true,
+		},
+		{
+			comment: "relative file path",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: local
+spec:
+  file:
+    path: audit.log`,
+			err: true,
+		},
+		{
+			comment: "unsupported syslog protocol",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: siem
+spec:
+  syslog:
+    address: siem.example.com:514
+    protocol: udp`,
+			err: true,
+		},
+		{
+			comment: "more than one destination",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: both
+spec:
+  syslog:
+    address: siem.example.com:6514
+  file:
+    path: /var/log/gravity-audit.log`,
+			err: true,
+		},
+		{
+			comment: "no destination",
+			spec: `kind: auditsink
+version: v2
+metadata:
+  name: none
+spec: {}`,
+			err: true,
+		},
+	}
+	for _, tc := range tcs {
+		comment := check.Commentf(tc.comment)
+		sink, err := UnmarshalAuditSink([]byte(tc.spec))
+		if tc.err {
+			c.Assert(err, check.NotNil, comment)
+			continue
+		}
+		c.Assert(err, check.IsNil, comment)
+		expected := tc.expected.(*AuditSinkV2)
+		expected.Metadata.CheckAndSetDefaults()
+		c.Assert(sink, check.DeepEquals, tc.expected, comment)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyval
+
+import (
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// UpsertAuditSink creates or updates the audit sink of the specified cluster
+func (b *backend) UpsertAuditSink(clusterName string, sink storage.AuditSink) error {
+	data, err := storage.MarshalAuditSink(sink)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	err = b.upsertValBytes(b.key(sitesP, clusterName, auditSinksP, sink.GetName()), data, b.ttl(sink.Expiry()))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// GetAuditSink returns the audit sink of the specified cluster by name
+func (b *backend) GetAuditSink(clusterName, name string) (storage.AuditSink, error) {
+	data, err := b.getValBytes(b.key(sitesP, clusterName, auditSinksP, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("audit sink %q not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	sink, err := storage.UnmarshalAuditSink(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sink, nil
+}
+
+// GetAuditSinks returns all audit sinks of the specified cluster
+func (b *backend) GetAuditSinks(clusterName string) ([]storage.AuditSink, error) {
+	names, err := b.getKeys(b.key(sitesP, clusterName, auditSinksP))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var sinks []storage.AuditSink
+	for _, name := range names {
+		sink, err := b.GetAuditSink(clusterName, name)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				continue
+			}
+			return nil, trace.Wrap(err)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// DeleteAuditSink deletes the audit sink of the specified cluster by name
+func (b *backend) DeleteAuditSink(clusterName, name string) error {
+	err := b.deleteKey(b.key(sitesP, clusterName, auditSinksP, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("audit sink %q not found", name)
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyval
+
+import (
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// blobRef is the reference count of a BLOB
+type blobRef struct {
+	// Count is the number of packages referencing the BLOB
+	Count int `json:"count"`
+	// Deleting is the time the BLOB started being deleted
+	// after the last reference to it was released
+	Deleting time.Time `json:"deleting,omitempty"`
+}
+
+// BlobRefsInitialized returns true if the references have been counted
+func (b *backend) BlobRefsInitialized() (bool, error) {
+	var initialized bool
+	err := b.getVal(b.key(blobRefsP, initializedP), &initialized)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return false, nil
+		}
+		return false, trace.Wrap(err)
+	}
+	return initialized, nil
+}
+
+// InitBlobRefs sets the reference counts of the BLOBs stored before the
+// references were counted and marks the references as counted
+func (b *backend) InitBlobRefs(refs map[string]int) error {
+	for hash, count := range refs {
+		err := b.upsertVal(b.key(blobRefsP, countsP, hash), blobRef{Count: count}, forever)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return trace.Wrap(b.upsertVal(b.key(blobRefsP, initializedP), true, forever))
+}
+
+// AddBlobRef adds a reference to the BLOB with the specified hash.
+// Returns trace.CompareFailed if the BLOB is being deleted
+func (b *backend) AddBlobRef(hash string) error {
+	key := b.key(blobRefsP, countsP, hash)
+	for {
+		var ref blobRef
+		err := b.getVal(key, &ref)
+		if err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+		if trace.IsNotFound(err) {
+			err = b.createVal(key, blobRef{Count: 1}, forever)
+			if trace.IsAlreadyExists(err) {
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		next := blobRef{Count: ref.Count + 1}
+		if !ref.Deleting.IsZero() {
+			if b.Now().UTC().Sub(ref.Deleting) < defaults.PackageBlobDeleteTimeout {
+				return trace.CompareFailed("BLOB %v is being deleted", hash)
+			}
+			// the deletion has been abandoned, the BLOB might be
+			// partially deleted so callers check it anyway
+			next.Count = 1
+		}
+		var out blobRef
+		err = b.compareAndSwap(key, next, ref, &out, forever)
+		if trace.IsCompareFailed(err) || trace.IsNotFound(err) {
+			continue
+		}
+		return trace.Wrap(err)
+	}
+}
+
+// ReleaseBlobRef releases a reference to the BLOB with the specified hash
+// and returns true if it was the last one. The BLOB is then marked as
+// being deleted, which the caller does before calling DeleteBlobRef
+func (b *backend) ReleaseBlobRef(hash string) (last bool, err error) {
+	key := b.key(blobRefsP, countsP, hash)
+	for {
+		var ref blobRef
+		err := b.getVal(key, &ref)
+		if err != nil {
+			if trace.IsNotFound(err) {
+				return false, trace.NotFound("BLOB %v is not referenced", hash)
+			}
+			return false, trace.Wrap(err)
+		}
+		if !ref.Deleting.IsZero() {
+			return false, trace.NotFound("BLOB %v is not referenced", hash)
+		}
+		next := blobRef{Count: ref.Count - 1}
+		if next.Count <= 0 {
+			next = blobRef{Deleting: b.Now().UTC()}
+		}
+		var out blobRef
+		err = b.compareAndSwap(key, next, ref, &out, forever)
+		if trace.IsCompareFailed(err) || trace.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, trace.Wrap(err)
+		}
+		return !next.Deleting.IsZero(), nil
+	}
+}
+
+// DeleteBlobRef removes the reference count of the deleted BLOB
+func (b *backend) DeleteBlobRef(hash string) error {
+	key := b.key(blobRefsP, countsP, hash)
+	var ref blobRef
+	err := b.getVal(key, &ref)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if ref.Deleting.IsZero() {
+		// the deletion has been abandoned and the BLOB is referenced again
+		return trace.CompareFailed("BLOB %v is not being deleted", hash)
+	}
+	return trace.Wrap(b.deleteKey(key))
+}
@@ -410,7 +410,9 @@ func (b *blt) compareAndSwapBytes(k key, val, prevVal []byte, outVal *[]byte, tt
 			if err != nil {
 				return trace.Wrap(err)
 			}
-			*outVal = currentVal
+			// the value is only valid for the life of the transaction
+			*outVal = make([]byte, len(currentVal))
+			copy(*outVal, currentVal)
 			return nil
 		}
 	})
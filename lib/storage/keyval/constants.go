@@ -62,7 +62,11 @@ const (
 	tunnelsP                    = "tunnels"
 	peersP                      = "peers"
 	objectsP                    = "objects"
+	blobRefsP                   = "blobrefs"
+	countsP                     = "counts"
+	initializedP                = "initialized"
 	logForwardersP              = "logforwarders"
+	auditSinksP                 = "auditsinks"
 	linksP                      = "links"
 	remoteAccessP               = "remoteaccess"
 	rolesP                      = "roles"
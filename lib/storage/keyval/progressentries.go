@@ -17,6 +17,8 @@ limitations under the License.
 package keyval
 
 import (
+	"sort"
+
 	"github.com/gravitational/gravity/lib/storage"
 
 	"github.com/gravitational/trace"
@@ -75,6 +77,35 @@ func (b *backend) GetLastProgressEntry(siteDomain, operationID string) (*storage
 	return p, nil
 }
 
+func (b *backend) GetProgressEntries(siteDomain, operationID string) ([]storage.ProgressEntry, error) {
+	if siteDomain == "" {
+		return nil, trace.BadParameter("missing site domain")
+	}
+	if operationID == "" {
+		return nil, trace.BadParameter("missing operation id")
+	}
+	ids, err := b.getKeys(b.key(sitesP, siteDomain, operationsP, operationID, progressP))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	entries := make([]storage.ProgressEntry, 0, len(ids))
+	for _, id := range ids {
+		var e storage.ProgressEntry
+		err := b.getVal(b.key(sitesP, siteDomain, operationsP, operationID, progressP, id), &e)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.Before(entries[j].Created)
+	})
+	return entries, nil
+}
+
 func (b *backend) CreateAppProgressEntry(p storage.AppProgressEntry) (*storage.AppProgressEntry, error) {
 	err := p.Check()
 	if err != nil {
@@ -19,8 +19,12 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"text/template"
 	"time"
 
+	"github.com/gravitational/gravity/lib/defaults"
+
 	teleservices "github.com/gravitational/teleport/lib/services"
 	teleutils "github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/trace"
@@ -188,6 +192,14 @@ type AlertTarget interface {
 	CheckAndSetDefaults() error
 	// GetEmail returns the recipient's email
 	GetEmail() string
+	// GetWebhook returns the generic webhook configuration
+	GetWebhook() *AlertWebhook
+	// GetSlack returns the Slack-compatible webhook configuration
+	GetSlack() *AlertSlack
+	// GetPagerDuty returns the PagerDuty configuration
+	GetPagerDuty() *AlertPagerDuty
+	// GetType returns the type of the alert target
+	GetType() string
 }
 
 // AlertTargetV2 defines a monitoring alert target
@@ -207,12 +219,155 @@ func (r *AlertTargetV2) GetEmail() string {
 	return r.Spec.Email
 }
 
+// GetWebhook returns the generic webhook configuration
+func (r *AlertTargetV2) GetWebhook() *AlertWebhook {
+	return r.Spec.Webhook
+}
+
+// GetSlack returns the Slack-compatible webhook configuration
+func (r *AlertTargetV2) GetSlack() *AlertSlack {
+	return r.Spec.Slack
+}
+
+// GetPagerDuty returns the PagerDuty configuration
+func (r *AlertTargetV2) GetPagerDuty() *AlertPagerDuty {
+	return r.Spec.PagerDuty
+}
+
+// GetType returns the type of the alert target
+func (r *AlertTargetV2) GetType() string {
+	switch {
+	case r.Spec.Webhook != nil:
+		return AlertTargetWebhook
+	case r.Spec.Slack != nil:
+		return AlertTargetSlack
+	case r.Spec.PagerDuty != nil:
+		return AlertTargetPagerDuty
+	}
+	return AlertTargetEmail
+}
+
 // CheckAndSetDefaults checks validity of all parameters and sets defaults
 func (r *AlertTargetV2) CheckAndSetDefaults() error {
-	if r.Spec.Email == "" {
+	var configured int
+	if r.Spec.Email != "" {
+		configured++
+	}
+	if r.Spec.Webhook != nil {
+		configured++
+		if err := r.Spec.Webhook.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if r.Spec.Slack != nil {
+		configured++
+		if err := r.Spec.Slack.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if r.Spec.PagerDuty != nil {
+		configured++
+		if err := r.Spec.PagerDuty.checkAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if configured == 0 {
 		return trace.BadParameter("missing parameter Email")
 	}
+	if configured != 1 {
+		return trace.BadParameter("alert target must specify exactly one of email, webhook, slack or pagerduty")
+	}
+	return nil
+}
+
+// AlertWebhook configures delivery of alerts to a generic HTTP webhook
+type AlertWebhook struct {
+	// URL is the webhook URL
+	URL string `json:"url"`
+	// Headers specifies additional request headers
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is an optional Go template of the request body.
+	//
+	// The template is executed with the alert as data.
+	// If not specified, the alert is posted as JSON.
+	Body string `json:"body,omitempty"`
+	// SigningSecret is an optional secret to sign the request body
+	// with HMAC-SHA256. The signature is sent in the X-Gravity-Signature header
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+func (r *AlertWebhook) checkAndSetDefaults() error {
+	if err := checkAlertTargetURL(r.URL); err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := r.ParseBody(); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// ParseBody parses the request body template.
+// Returns nil if no template has been configured.
+//
+// Besides the standard functions, the template can use the json
+// function to format a value as JSON
+func (r *AlertWebhook) ParseBody() (*template.Template, error) {
+	if r.Body == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("body").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(r.Body)
+	if err != nil {
+		return nil, trace.BadParameter("invalid webhook body template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// AlertSlack configures delivery of alerts to a Slack-compatible
+// incoming webhook
+type AlertSlack struct {
+	// URL is the incoming webhook URL
+	URL string `json:"url"`
+	// Channel optionally overrides the default channel of the webhook
+	Channel string `json:"channel,omitempty"`
+	// Username optionally overrides the default username of the webhook
+	Username string `json:"username,omitempty"`
+}
+
+func (r *AlertSlack) checkAndSetDefaults() error {
+	return trace.Wrap(checkAlertTargetURL(r.URL))
+}
+
+// AlertPagerDuty configures delivery of alerts as PagerDuty events
+type AlertPagerDuty struct {
+	// RoutingKey is the integration key of the PagerDuty service
+	RoutingKey string `json:"routing_key"`
+	// URL is the events API endpoint, defaults to the PagerDuty Events API v2
+	URL string `json:"url,omitempty"`
+}
+
+func (r *AlertPagerDuty) checkAndSetDefaults() error {
+	if r.RoutingKey == "" {
+		return trace.BadParameter("missing parameter RoutingKey")
+	}
+	if r.URL == "" {
+		r.URL = defaults.PagerDutyEventsURL
+	}
+	return trace.Wrap(checkAlertTargetURL(r.URL))
+}
 
+func checkAlertTargetURL(addr string) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return trace.BadParameter("invalid URL %q: %v", addr, err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return trace.BadParameter("URL %q must be an https URL", addr)
+	}
 	return nil
 }
 
@@ -252,22 +407,69 @@ func MarshalAlertTarget(target AlertTarget, opts ...teleservices.MarshalOption)
 	return json.Marshal(target)
 }
 
-// AlertTargetSpecV2 defines a monitoring alert target
+// AlertTargetSpecV2 defines a monitoring alert target.
+// Exactly one of the targets must be specified
 type AlertTargetSpecV2 struct {
 	// Email specifies recipient's email
-	Email string `json:"email"`
+	Email string `json:"email,omitempty"`
+	// Webhook specifies the generic webhook
+	Webhook *AlertWebhook `json:"webhook,omitempty"`
+	// Slack specifies the Slack-compatible incoming webhook
+	Slack *AlertSlack `json:"slack,omitempty"`
+	// PagerDuty specifies the PagerDuty service
+	PagerDuty *AlertPagerDuty `json:"pagerduty,omitempty"`
 }
 
 // AlertTargetSpecV2Schema is JSON schema for a monitoring alert target
 const AlertTargetSpecV2Schema = `{
   "type": "object",
   "additionalProperties": false,
-  "required": ["email"],
   "properties": {
-    "email": {"type": "string"}
+    "email": {"type": "string"},
+    "webhook": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url"],
+      "properties": {
+        "url": {"type": "string"},
+        "headers": {"type": "object", "additionalProperties": {"type": "string"}},
+        "body": {"type": "string"},
+        "signing_secret": {"type": "string"}
+      }
+    },
+    "slack": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url"],
+      "properties": {
+        "url": {"type": "string"},
+        "channel": {"type": "string"},
+        "username": {"type": "string"}
+      }
+    },
+    "pagerduty": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["routing_key"],
+      "properties": {
+        "routing_key": {"type": "string"},
+        "url": {"type": "string"}
+      }
+    }
   }
 }`
 
+const (
+	// AlertTargetEmail is the email alert target type
+	AlertTargetEmail = "email"
+	// AlertTargetWebhook is the generic webhook alert target type
+	AlertTargetWebhook = "webhook"
+	// AlertTargetSlack is the Slack-compatible webhook alert target type
+	AlertTargetSlack = "slack"
+	// AlertTargetPagerDuty is the PagerDuty alert target type
+	AlertTargetPagerDuty = "pagerduty"
+)
+
 // GetAlertTargetSchema returns alert target schema for version V2
 func GetAlertTargetSchema() string {
 	return fmt.Sprintf(teleservices.V2SchemaTemplate, teleservices.MetadataSchema,
@@ -0,0 +1,113 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"github.com/gravitational/gravity/lib/defaults"
+
+	"gopkg.in/check.v1"
+)
+
+type AlertTargetSuite struct{}
+
+var _ = check.Suite(&AlertTargetSuite{})
+
+func (s *AlertTargetSuite) TestAlertTargetTypes(c *check.C) {
+	tcs := []struct {
+		comment  string
+		spec     string
+		typ      string
+		validate func(AlertTarget)
+		err      bool
+	}{
+		{
+			comment: "email",
+			spec:    `email: ops@example.com`,
+			typ:     AlertTargetEmail,
+		},
+		{
+			comment: "webhook with template and signing secret",
+			spec: `webhook:
+    url: https://hooks.example.com/alerts
+    body: '{"text": {{json .Message}}}'
+    signing_secret: secret`,
+			typ: AlertTargetWebhook,
+		},
+		{
+			comment: "slack",
+			spec: `slack:
+    url: https://hooks.slack.com/services/T000/B000/XXX
+    channel: "#ops"`,
+			typ: AlertTargetSlack,
+		},
+		{
+			comment: "pagerduty with the default URL",
+			spec: `pagerduty:
+    routing_key: key`,
+			typ: AlertTargetPagerDuty,
+			validate: func(target AlertTarget) {
+				c.Assert(target.GetPagerDuty().URL, check.Equals, defaults.PagerDutyEventsURL)
+			},
+		},
+		{
+			comment: "invalid webhook body template",
+			spec: `webhook:
+    url: https://hooks.example.com/alerts
+    body: '{{.Message'`,
+			err: true,
+		},
+		{
+			comment: "webhook must use https",
+			spec: `webhook:
+    url: http://hooks.example.com/alerts`,
+			err: true,
+		},
+		{
+			comment: "more than one target",
+			spec: `email: ops@example.com
+  slack:
+    url: https://hooks.slack.com/services/T000/B000/XXX`,
+			err: true,
+		},
+		{
+			comment: "no target",
+			spec:    `{}`,
+			err:     true,
+		},
+	}
+	for _, tc := range tcs {
+		comment := check.Commentf(tc.comment)
+		target, err := UnmarshalAlertTarget([]byte(`kind: alerttarget
+version: v2
+metadata:
+  name: target
+spec:
+  ` + tc.spec))
+		if err == nil {
+			err = target.CheckAndSetDefaults()
+		}
+		if tc.err {
+			c.Assert(err, check.NotNil, comment)
+			continue
+		}
+		c.Assert(err, check.IsNil, comment)
+		c.Assert(target.GetType(), check.Equals, tc.typ, comment)
+		if tc.validate != nil {
+			tc.validate(target)
+		}
+	}
+}
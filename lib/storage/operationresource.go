@@ -0,0 +1,96 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"time"
+
+	teledefaults "github.com/gravitational/teleport/lib/defaults"
+	teleservices "github.com/gravitational/teleport/lib/services"
+	"github.com/jonboulle/clockwork"
+)
+
+// NewOperationResource returns a new resource that describes an operation
+// of the specified type for access checks.
+//
+// The resource is named after the cluster the operation belongs to
+// so that the rules can restrict access to operations in specific clusters
+// the same way as the access to clusters themselves.
+// labels specify the labels of the node the operation or the plan phase
+// is targeting, if any
+func NewOperationResource(clusterName, operationType string, labels map[string]string) *OperationResourceV2 {
+	return &OperationResourceV2{
+		Kind:    KindOperation,
+		Version: teleservices.V2,
+		Metadata: teleservices.Metadata{
+			Name:      clusterName,
+			Namespace: teledefaults.Namespace,
+			Labels:    labels,
+		},
+		Spec: OperationResourceSpecV2{
+			Type: operationType,
+		},
+	}
+}
+
+// OperationResourceV2 describes an operation for access checks
+type OperationResourceV2 struct {
+	// Kind is a resource kind - always operation
+	Kind string `json:"kind"`
+	// Version is a resource version
+	Version string `json:"version"`
+	// Metadata is the operation metadata
+	Metadata teleservices.Metadata `json:"metadata"`
+	// Spec is the operation specification
+	Spec OperationResourceSpecV2 `json:"spec"`
+}
+
+// OperationResourceSpecV2 is the operation specification
+type OperationResourceSpecV2 struct {
+	// Type is the operation type
+	Type string `json:"type"`
+}
+
+// GetName returns the name of the cluster the operation belongs to
+func (r *OperationResourceV2) GetName() string {
+	return r.Metadata.Name
+}
+
+// SetName sets the name of the cluster the operation belongs to
+func (r *OperationResourceV2) SetName(name string) {
+	r.Metadata.Name = name
+}
+
+// GetMetadata returns the operation metadata
+func (r *OperationResourceV2) GetMetadata() teleservices.Metadata {
+	return r.Metadata
+}
+
+// SetExpiry sets the operation expiration time
+func (r *OperationResourceV2) SetExpiry(expires time.Time) {
+	r.Metadata.SetExpiry(expires)
+}
+
+// Expiry returns the operation expiration time
+func (r *OperationResourceV2) Expiry() time.Time {
+	return r.Metadata.Expiry()
+}
+
+// SetTTL sets Expires header using realtime clock
+func (r *OperationResourceV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	r.Metadata.SetTTL(clock, ttl)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package storage
 
 import (
+	"sort"
 	"time"
 
 	"github.com/gravitational/gravity/lib/loc"
@@ -83,6 +84,23 @@ type OperationPhase struct {
 	Parallel bool `json:"parallel"`
 	// Updated is the last phase update time
 	Updated time.Time `json:"updated,omitempty" yaml:"updated,omitempty"`
+	// Started is the time the phase execution has started
+	Started time.Time `json:"started,omitempty" yaml:"started,omitempty"`
+	// Finished is the time the phase execution has finished
+	Finished time.Time `json:"finished,omitempty" yaml:"finished,omitempty"`
+	// Timeout optionally limits the duration of a single attempt
+	// to execute or rollback the phase
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Retries is the optional number of times the phase is retried
+	// after a failed attempt
+	Retries int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// RetryBackoff is the optional delay before the first retry.
+	// The delay is doubled after each subsequent attempt
+	RetryBackoff time.Duration `json:"retry_backoff,omitempty" yaml:"retry_backoff,omitempty"`
+	// Attempt is the number of the latest attempt to execute or rollback the phase
+	Attempt int `json:"attempt,omitempty" yaml:"attempt,omitempty"`
+	// FailedAttempts lists the failed attempts preceding the latest attempt
+	FailedAttempts []PhaseAttempt `json:"failed_attempts,omitempty" yaml:"failed_attempts,omitempty"`
 	// Data is optional phase-specific data attached to the phase
 	Data *OperationPhaseData `json:"data,omitempty" yaml:"data,omitempty"`
 	// Error is the error that happened during phase execution
@@ -232,6 +250,19 @@ type PlanChange struct {
 	Created time.Time `json:"created"`
 	// Error is the error that happened during phase execution
 	Error *trace.RawTrace `json:"error"`
+	// Attempt is the number of the attempt to execute or rollback
+	// the phase this change belongs to
+	Attempt int `json:"attempt,omitempty"`
+}
+
+// PhaseAttempt describes a failed attempt to execute or rollback a phase
+type PhaseAttempt struct {
+	// Attempt is the number of the attempt
+	Attempt int `json:"attempt" yaml:"attempt"`
+	// Failed is the time the attempt failed
+	Failed time.Time `json:"failed" yaml:"failed"`
+	// Error is the error the attempt failed with
+	Error *trace.RawTrace `json:"error,omitempty" yaml:"error,omitempty"`
 }
 
 // PlanChangelog is a list of plan state changes
@@ -251,6 +282,47 @@ func (c PlanChangelog) Latest(phaseID string) *PlanChange {
 	return latest
 }
 
+// FailedAttempts returns the failed attempts to execute or rollback the
+// specified phase that precede the latest attempt in chronological order
+func (c PlanChangelog) FailedAttempts(phaseID string) (attempts []PhaseAttempt) {
+	latest := c.Latest(phaseID)
+	if latest == nil {
+		return nil
+	}
+	for _, change := range c {
+		if change.PhaseID != phaseID || change.NewState != OperationPhaseStateFailed {
+			continue
+		}
+		if change.Attempt == 0 || change.Attempt >= latest.Attempt {
+			continue
+		}
+		attempts = append(attempts, PhaseAttempt{
+			Attempt: change.Attempt,
+			Failed:  change.Created,
+			Error:   change.Error,
+		})
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].Failed.Before(attempts[j].Failed)
+	})
+	return attempts
+}
+
+// LatestWithState returns the most recent plan change entry for the specified phase
+// that moved the phase into the given state
+func (c PlanChangelog) LatestWithState(phaseID, state string) *PlanChange {
+	var latest *PlanChange
+	for i, change := range c {
+		if change.PhaseID != phaseID || change.NewState != state {
+			continue
+		}
+		if latest == nil || change.Created.After(latest.Created) {
+			latest = &(c[i])
+		}
+	}
+	return latest
+}
+
 // HasSubphases returns true if the phase has 1 or more subphases
 func (p OperationPhase) HasSubphases() bool {
 	return len(p.Phases) > 0
@@ -295,6 +367,42 @@ func (p OperationPhase) GetLastUpdateTime() time.Time {
 	return last
 }
 
+// GetStartTime returns the time the phase execution has started.
+// For a phase with subphases, this is the earliest start time of all its subphases
+func (p OperationPhase) GetStartTime() time.Time {
+	if len(p.Phases) == 0 {
+		return p.Started
+	}
+	var started time.Time
+	for _, phase := range p.Phases {
+		t := phase.GetStartTime()
+		if !t.IsZero() && (started.IsZero() || t.Before(started)) {
+			started = t
+		}
+	}
+	return started
+}
+
+// GetFinishTime returns the time the phase execution has finished.
+// For a phase with subphases, this is the latest finish time of all its subphases
+// or zero time if any of the subphases has not finished yet
+func (p OperationPhase) GetFinishTime() time.Time {
+	if len(p.Phases) == 0 {
+		return p.Finished
+	}
+	var finished time.Time
+	for _, phase := range p.Phases {
+		t := phase.GetFinishTime()
+		if t.IsZero() {
+			return time.Time{}
+		}
+		if t.After(finished) {
+			finished = t
+		}
+	}
+	return finished
+}
+
 // GetState returns the phase state based on the states of all its subphases
 func (p OperationPhase) GetState() string {
 	// if the phase doesn't have subphases, then just return its state from property
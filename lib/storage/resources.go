@@ -51,6 +51,8 @@ const (
 	VerbReadSecrets = "readsecrets"
 	// KindLogForwarder is log forwarder resource kind
 	KindLogForwarder = "logforwarder"
+	// KindAuditSink is the audit events forwarding resource kind
+	KindAuditSink = "auditsink"
 	// KindTLSKeyPair is a TLS key pair
 	KindTLSKeyPair = "tlskeypair"
 	// KindSMTPConfig defines the monitoring SMTP configuration resource type
@@ -73,6 +75,35 @@ const (
 	KindRelease = "release"
 	// KindInvite defines the user invite token.
 	KindInvite = "invite"
+	// KindOperation defines the cluster operation resource type.
+	// The verbs for this resource are the operation types
+	KindOperation = "operation"
+	// KindOperationPlan defines the operation plan resource type.
+	// The verbs for this resource are the plan actions
+	KindOperationPlan = "operationplan"
+	// VerbInstall is used to allow installing clusters
+	VerbInstall = "install"
+	// VerbUninstall is used to allow uninstalling clusters
+	VerbUninstall = "uninstall"
+	// VerbExpand is used to allow adding nodes to clusters
+	VerbExpand = "expand"
+	// VerbShrink is used to allow removing nodes from clusters
+	VerbShrink = "shrink"
+	// VerbUpgrade is used to allow updating cluster applications
+	VerbUpgrade = "update"
+	// VerbGarbageCollect is used to allow garbage collection in clusters
+	VerbGarbageCollect = "gc"
+	// VerbConfigure is used to allow updating cluster configuration
+	// and runtime environment
+	VerbConfigure = "config"
+	// VerbExecute is used to allow executing operation plan phases
+	VerbExecute = "execute"
+	// VerbRollback is used to allow rolling back operation plan phases
+	VerbRollback = "rollback"
+	// VerbComplete is used to allow completing operations
+	VerbComplete = "complete"
+	// VerbNotify is used to allow delivering alerts to the alert target
+	VerbNotify = "notify"
 )
 
 // CanonicalKind translates the specified kind to canonical form.
@@ -89,6 +120,8 @@ func CanonicalKind(kind string) string {
 		return KindToken
 	case KindLogForwarder, "logforwarders":
 		return KindLogForwarder
+	case KindAuditSink, "auditsinks":
+		return KindAuditSink
 	case KindTLSKeyPair, "tlskeypairs", "tls":
 		return KindTLSKeyPair
 	case teleservices.KindClusterAuthPreference, "authpreference", "cap":
@@ -164,6 +197,7 @@ var SupportedGravityResources = []string{
 	teleservices.KindUser,
 	KindToken,
 	KindLogForwarder,
+	KindAuditSink,
 	KindSMTPConfig,
 	KindAlert,
 	KindAlertTarget,
@@ -180,6 +214,7 @@ var SupportedGravityResourcesToRemove = []string{
 	teleservices.KindUser,
 	KindToken,
 	KindLogForwarder,
+	KindAuditSink,
 	KindSMTPConfig,
 	KindAlert,
 	KindAlertTarget,
@@ -184,6 +184,18 @@ func (r *RoleV2) V3() *teleservices.RoleV3 {
 			}
 			// cluster also grants access to log forwarder configuation
 			rules = append(rules, teleservices.NewRule(KindLogForwarder, teleutils.CopyStrings(verbs)))
+			// and to the audit sinks of the same clusters
+			auditSinkRule := teleservices.NewRule(KindAuditSink, teleutils.CopyStrings(verbs))
+			auditSinkRule.Where = rule.Where
+			rules = append(rules, auditSinkRule)
+			// and write access to cluster grants access to all operations
+			// and plan actions in the same clusters
+			if containsWrite {
+				operationRule := teleservices.NewRule(KindOperation, []string{teleservices.Wildcard})
+				operationRule.Resources = append(operationRule.Resources, KindOperationPlan)
+				operationRule.Where = rule.Where
+				rules = append(rules, operationRule)
+			}
 		case KindApp:
 			// Add additional rule that limits access to specific repositories
 			// Note that our apps are all residing in one default repository
@@ -848,6 +848,9 @@ type ProgressEntries interface {
 	CreateProgressEntry(p ProgressEntry) (*ProgressEntry, error)
 	// GetLastProgressEntry gets a progress entry for this site
 	GetLastProgressEntry(siteDomain, operationID string) (*ProgressEntry, error)
+	// GetProgressEntries returns all progress entries for the specified operation
+	// sorted by creation time
+	GetProgressEntries(siteDomain, operationID string) ([]ProgressEntry, error)
 }
 
 // Package is any named and versioned blob with an optional manifest
@@ -878,6 +881,10 @@ type Package struct {
 	Hidden bool `json:"hidden"`
 	// Encrypted indicates whether the package data is encrypted
 	Encrypted bool `json:"encrypted"`
+	// Chunks is the hash of the BLOB with the list of content-defined
+	// chunks the package data is stored as in deduplicating mode.
+	// Empty if the package data is stored as a single BLOB
+	Chunks string `json:"chunks,omitempty"`
 	// Manifest defines the application manifest for an application package
 	Manifest []byte `json:"manifest"`
 	// Base refers to the package this application is based on
@@ -1173,6 +1180,25 @@ func (u *PackageUpdate) String() string {
 		format(u), format(u.ConfigPackage))
 }
 
+// BlobRefs counts the packages referencing the BLOBs of the package storage
+// so that BLOBs shared by several packages are deleted with the last one
+type BlobRefs interface {
+	// BlobRefsInitialized returns true if the references have been counted
+	BlobRefsInitialized() (bool, error)
+	// InitBlobRefs sets the reference counts of the BLOBs stored before the
+	// references were counted and marks the references as counted
+	InitBlobRefs(refs map[string]int) error
+	// AddBlobRef adds a reference to the BLOB with the specified hash.
+	// Returns trace.CompareFailed if the BLOB is being deleted
+	AddBlobRef(hash string) error
+	// ReleaseBlobRef releases a reference to the BLOB with the specified hash
+	// and returns true if it was the last one. The BLOB is then marked as
+	// being deleted, which the caller does before calling DeleteBlobRef
+	ReleaseBlobRef(hash string) (last bool, err error)
+	// DeleteBlobRef removes the reference count of the deleted BLOB
+	DeleteBlobRef(hash string) error
+}
+
 // PackageChangesets tracks server local package changes - updates and downgrades
 type PackageChangesets interface {
 	// CreatePackageChangeset creates new changeset
@@ -1474,12 +1500,14 @@ type Backend interface {
 	Migrations
 	Peers
 	Objects
+	BlobRefs
 	PackageChangesets
 	Links
 	ClusterImport
 	LegacyRoles
 	SystemMetadata
 	Charts
+	AuditSinks
 }
 
 const (
@@ -1847,6 +1875,28 @@ type OnPremVariables struct {
 	ServiceCIDR string `json:"service_cidr"`
 	// VxlanPort is the overlay network port
 	VxlanPort int `json:"vxlan_port"`
+	// Nodes lists the nodes of the cluster installed from a cluster spec.
+	// If set, nodes not on the list are not allowed to join the operation
+	Nodes []NodeSpec `json:"nodes,omitempty"`
+}
+
+// NodeSpec describes a node of the cluster installed from a cluster spec
+type NodeSpec struct {
+	// AdvertiseAddr is the advertise IP address of the node
+	AdvertiseAddr string `json:"advertise_addr"`
+	// Profile is the node profile from the application manifest
+	Profile string `json:"profile"`
+	// DockerDevice is the optional block device for Docker data
+	DockerDevice string `json:"docker_device,omitempty"`
+	// SystemDevice is the optional block device for gravity data
+	SystemDevice string `json:"system_device,omitempty"`
+	// Mounts maps the names of the profile volumes to the host directories
+	Mounts map[string]string `json:"mounts,omitempty"`
+}
+
+// String returns the node description
+func (n NodeSpec) String() string {
+	return fmt.Sprintf("node(addr=%v, profile=%v)", n.AdvertiseAddr, n.Profile)
 }
 
 // AWSVariables is a set of operation variables specific to AWS provider
@@ -2121,6 +2171,19 @@ type CloudConfig struct {
 	GCENodeTags []string `json:"gce_node_tags,omitempty"`
 }
 
+// AuditSinks manages the audit events forwarding configuration.
+// Audit sinks are configured per cluster
+type AuditSinks interface {
+	// UpsertAuditSink creates or updates the audit sink of the specified cluster
+	UpsertAuditSink(clusterName string, sink AuditSink) error
+	// GetAuditSink returns the audit sink of the specified cluster by name
+	GetAuditSink(clusterName, name string) (AuditSink, error)
+	// GetAuditSinks returns all audit sinks of the specified cluster
+	GetAuditSinks(clusterName string) ([]AuditSink, error)
+	// DeleteAuditSink deletes the audit sink of the specified cluster by name
+	DeleteAuditSink(clusterName, name string) error
+}
+
 // Charts defines methods related to Helm chart repository functionality.
 type Charts interface {
 	// GetIndexFile returns the chart repository index file.
@@ -897,6 +897,10 @@ func (s *StorageSuite) ProgressEntriesCRUD(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(*ope2, DeepEquals, pe2)
 
+	entries, err := s.Backend.GetProgressEntries(sa.Domain, op.ID)
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []storage.ProgressEntry{pe1, pe2})
+
 	// Create for non existent site should fail
 	_, err = s.Backend.CreateProgressEntry(storage.ProgressEntry{
 		SiteDomain:  "nothere.com",
@@ -1832,10 +1836,20 @@ func (s *StorageSuite) LoginAttempts(c *C) {
 func (s *StorageSuite) ClusterAgentCreds(c *C) {
 	clusterName := "t1"
 
+	// the alert notifier is an unprivileged agent that only delivers alerts
+	notifier := storage.NewUser(storage.ClusterAlertNotifier(clusterName), storage.UserSpecV2{})
+	notifier.SetClusterName(clusterName)
+	notifier.SetType(storage.AgentUser)
+	_, err := s.Backend.CreateUser(notifier)
+	c.Assert(err, IsNil)
+
+	_, err = s.Backend.CreateAPIKey(storage.APIKey{Token: "notifier", UserEmail: notifier.GetName()})
+	c.Assert(err, IsNil)
+
 	regularAgent := storage.NewUser(fmt.Sprintf("regular@%v", clusterName), storage.UserSpecV2{})
 	regularAgent.SetClusterName(clusterName)
 	regularAgent.SetType(storage.AgentUser)
-	_, err := s.Backend.CreateUser(regularAgent)
+	_, err = s.Backend.CreateUser(regularAgent)
 	c.Assert(err, IsNil)
 
 	regularKey := storage.APIKey{Token: "regular", UserEmail: regularAgent.GetName()}
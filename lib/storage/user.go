@@ -784,6 +784,12 @@ func ClusterAdminAgent(clusterName string) string {
 	return fmt.Sprintf("adminagent@%v", clusterName)
 }
 
+// ClusterAlertNotifier generates the name of the agent user that delivers
+// monitoring alerts to the alert target of the specified cluster
+func ClusterAlertNotifier(clusterName string) string {
+	return fmt.Sprintf("alerts@%v", clusterName)
+}
+
 // UserFromContext extracts name of the user attached to the provided context.
 //
 // Returns an empty string if no user is attached.
@@ -43,7 +43,7 @@ func GetClusterAgentCreds(backend Backend, clusterName string, needAdmin bool) (
 
 	var user User
 	for i := range users {
-		if users[i].GetType() == AgentUser {
+		if users[i].GetType() == AgentUser && users[i].GetName() != ClusterAlertNotifier(clusterName) {
 			hasAdminRole := utils.StringInSlice(users[i].GetRoles(), constants.RoleAdmin)
 			if (needAdmin && hasAdminRole) || (!needAdmin && !hasAdminRole) {
 				user = users[i]
@@ -19,6 +19,7 @@ package update
 
 import (
 	"path"
+	"time"
 
 	"github.com/gravitational/gravity/lib/storage"
 )
@@ -78,6 +79,20 @@ func (p *Phase) RequireLiteral(ids ...string) *Phase {
 	return p
 }
 
+// WithTimeout limits the duration of a single attempt to execute this phase
+func (p *Phase) WithTimeout(timeout time.Duration) *Phase {
+	p.Timeout = timeout
+	return p
+}
+
+// WithRetries sets the number of times this phase is retried after a failed
+// attempt and the initial delay between retries
+func (p *Phase) WithRetries(retries int, backoff time.Duration) *Phase {
+	p.Retries = retries
+	p.RetryBackoff = backoff
+	return p
+}
+
 // GetID returns this phase's ID.
 // implements PhaseDependency
 func (p Phase) GetID() string {
@@ -418,13 +418,13 @@ func (r phaseBuilder) etcdPlan(
 			Server: &leadMaster,
 		},
 	})
-	root.Add(restartMasters)
+	root.AddSequential(restartMasters)
 
 	return &root
 }
 
 func (r phaseBuilder) etcdBackupNode(server storage.Server, parent update.Phase) update.Phase {
-	return update.Phase{
+	phase := update.Phase{
 		ID:          parent.ChildLiteral(server.Hostname),
 		Description: fmt.Sprintf("Backup etcd on node %q", server.Hostname),
 		Executor:    updateEtcdBackup,
@@ -432,6 +432,8 @@ func (r phaseBuilder) etcdBackupNode(server storage.Server, parent update.Phase)
 			Server: &server,
 		},
 	}
+	phase.WithRetries(defaults.EtcdPhaseRetries, defaults.EtcdPhaseRetryBackoff)
+	return phase
 }
 
 func (r phaseBuilder) etcdShutdownNode(server storage.Server, parent update.Phase, isLeader bool) update.Phase {
@@ -458,7 +460,7 @@ func (r phaseBuilder) etcdUpgrade(server storage.Server, parent update.Phase) up
 }
 
 func (r phaseBuilder) etcdRestart(server storage.Server, parent update.Phase) update.Phase {
-	return update.Phase{
+	phase := update.Phase{
 		ID:          parent.ChildLiteral(server.Hostname),
 		Description: fmt.Sprintf("Restart etcd on node %q", server.Hostname),
 		Executor:    updateEtcdRestart,
@@ -466,6 +468,8 @@ func (r phaseBuilder) etcdRestart(server storage.Server, parent update.Phase) up
 			Server: &server,
 		},
 	}
+	phase.WithRetries(defaults.EtcdPhaseRetries, defaults.EtcdPhaseRetryBackoff)
+	return phase
 }
 
 func (r phaseBuilder) node(server storage.Server, parent update.ParentPhase, format string) update.Phase {
@@ -536,6 +540,12 @@ func (r phaseBuilder) commonNode(server, leadMaster storage.UpdateServer, suppor
 				ExecServer: &leadMaster.Server,
 			}})
 	}
+	for i := range phases {
+		// the phases executed via the Kubernetes API are safe to repeat
+		if phases[i].Executor != updateSystem {
+			phases[i].WithRetries(defaults.NodePhaseRetries, defaults.NodePhaseRetryBackoff)
+		}
+	}
 	return phases
 }
 
@@ -79,14 +79,14 @@ func (s *PlanSuite) TestPlanWithRuntimeUpdate(c *check.C) {
 	etcd := *builder.etcdPlan(leadMaster.Server, plan.Servers[1:2], plan.Servers[2:], "1.0.0", "2.0.0")
 	migration := builder.migration(leadMaster.Server).Require(etcd)
 	c.Assert(migration, check.NotNil)
-	config := *builder.config(servers(params.servers[:2]...)).Require(masters)
+	config := *builder.config(servers(params.servers[:2]...)).Require(masters, migration)
 
 	runtimeLocs := []loc.Locator{
 		loc.MustParseLocator("gravitational.io/runtime-dep-2:2.0.0"),
 		loc.MustParseLocator("gravitational.io/rbac-app:2.0.0"),
 		runtimeLoc2,
 	}
-	runtime := *builder.runtime(runtimeLocs).Require(masters)
+	runtime := *builder.runtime(runtimeLocs).Require(masters, config)
 
 	appLocs := []loc.Locator{loc.MustParseLocator("gravitational.io/app-dep-2:2.0.0"), appLoc2}
 	app := *builder.app(appLocs).Require(runtime)
@@ -19,6 +19,8 @@ package cluster
 import (
 	"bytes"
 	"context"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gravitational/gravity/lib/app"
@@ -51,6 +53,44 @@ func New(ctx context.Context, config Config) (*update.Updater, error) {
 	return updater, nil
 }
 
+// Simulate describes all phases of the specified plan without executing them.
+// The plan is not loaded from the backend, so the update can be simulated
+// before the operation is created
+func Simulate(ctx context.Context, config Config, plan storage.OperationPlan) (*fsm.Simulation, error) {
+	config.Operator = simulatedOperator{Operator: config.Operator}
+	err := config.checkAndSetDefaults()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	logger := logrus.WithFields(logrus.Fields{
+		trace.Component: "fsm:update",
+	})
+	machine, err := fsm.New(fsm.Config{
+		Engine: &engine{
+			Config:      config,
+			FieldLogger: logger,
+			plan:        plan,
+		},
+		Logger: logger,
+		Runner: config.Runner,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return machine.SimulatePlan(ctx, false)
+}
+
+// simulatedOperator discards the log entries of the simulated operation
+// which does not exist in the cluster
+type simulatedOperator struct {
+	ops.Operator
+}
+
+// CreateLogEntry discards the log entry
+func (simulatedOperator) CreateLogEntry(ops.SiteOperationKey, ops.LogEntry) error {
+	return nil
+}
+
 // checkAndSetDefaults validates FSM config and sets defaults
 func (c *Config) checkAndSetDefaults() error {
 	if err := c.Config.CheckAndSetDefaults(); err != nil {
@@ -100,9 +140,10 @@ func newMachine(ctx context.Context, c Config) (*fsm.FSM, error) {
 		return nil, trace.Wrap(err)
 	}
 	fsm, err := fsm.New(fsm.Config{
-		Engine: engine,
-		Logger: logger,
-		Runner: c.Runner,
+		Engine:      engine,
+		Logger:      logger,
+		Runner:      c.Runner,
+		MaxParallel: c.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -116,6 +157,9 @@ type engine struct {
 	Config
 	// FieldLogger is used for logging
 	logrus.FieldLogger
+	// mu guards plan which is updated as phases executing
+	// concurrently change their state
+	mu sync.Mutex
 	// plan is the update operation plan
 	plan       storage.OperationPlan
 	reconciler update.Reconciler
@@ -159,13 +203,24 @@ func (f *engine) GetExecutor(p fsm.ExecutorParams, remote fsm.Remote) (fsm.Phase
 // RunCommand executes the phase specified by params on the specified server
 // using the provided runner
 func (f *engine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, p fsm.Params) error {
-	args := []string{"plan", "execute",
+	command := "execute"
+	if p.Rollback {
+		command = "rollback"
+	}
+	plan, err := f.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	args := []string{"plan", command,
 		"--phase", p.PhaseID,
-		"--operation-id", f.plan.OperationID,
+		"--operation-id", plan.OperationID,
 	}
 	if p.Force {
 		args = append(args, "--force")
 	}
+	if p.Attempt != 0 {
+		args = append(args, "--attempt", strconv.Itoa(p.Attempt))
+	}
 	return runner.Run(ctx, server, args...)
 }
 
@@ -229,7 +284,10 @@ func (f *engine) Complete(fsmErr error) error {
 
 // GetPlan returns an up-to-date plan
 func (f *engine) GetPlan() (*storage.OperationPlan, error) {
-	return &f.plan, nil
+	f.mu.Lock()
+	plan := f.plan
+	f.mu.Unlock()
+	return &plan, nil
 }
 
 func (f *engine) commitClusterChanges(cluster *storage.Site, op ops.SiteOperation) error {
@@ -279,13 +337,18 @@ func (f *engine) activateCluster(cluster storage.Site) error {
 func (f *engine) ChangePhaseState(ctx context.Context, change fsm.StateChange) error {
 	f.WithField("change", change).Debug("Apply.")
 
-	_, err := f.LocalBackend.CreateOperationPlanChange(storage.PlanChange{
+	plan, err := f.GetPlan()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = f.LocalBackend.CreateOperationPlanChange(storage.PlanChange{
 		ID:          uuid.New(),
-		ClusterName: f.plan.ClusterName,
-		OperationID: f.plan.OperationID,
+		ClusterName: plan.ClusterName,
+		OperationID: plan.OperationID,
 		PhaseID:     change.Phase,
 		NewState:    change.State,
 		Error:       utils.ToRawTrace(change.Error),
+		Attempt:     change.Attempt,
 		Created:     time.Now().UTC(),
 	})
 	if err != nil {
@@ -301,7 +364,12 @@ func (f *engine) ChangePhaseState(ctx context.Context, change fsm.StateChange) e
 	return nil
 }
 
+// reconcilePlan updates the plan with the latest state changes.
+// State changes are reconciled one at a time so that a plan reconciled
+// from an older changelog never replaces a more recent one
 func (f *engine) reconcilePlan(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	plan, err := f.reconciler.ReconcilePlan(ctx, f.plan)
 	if err != nil {
 		return trace.Wrap(err)
@@ -18,10 +18,15 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/opsservice"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/update"
@@ -59,7 +64,7 @@ func (s *FSMSuite) SetUpTest(c *check.C) {
 			Spec:     getTestExecutor(),
 		},
 		FieldLogger: logger,
-		reconciler:  &testReconciler{},
+		reconciler:  &testReconciler{backend: services.Backend},
 	}
 	s.fsm = &fsm.FSM{
 		Config:      fsm.Config{Engine: s.engine},
@@ -167,6 +172,460 @@ func (s *FSMSuite) TestFSMExecutePhaseWithSubphases(c *check.C) {
 	})
 }
 
+func (s *FSMSuite) TestFSMSimulatePlan(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1", Phases: []storage.OperationPhase{
+				{ID: "/phase1/sub1"},
+				{ID: "/phase1/sub2"},
+			}},
+			{ID: "/phase2", Requires: []string{"/phase1"}},
+		},
+	}
+
+	s.engine.plan = plan
+
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/phase1/sub1",
+	})
+	c.Assert(err, check.IsNil)
+	s.engine.plan = *s.resolvePlan(c, plan)
+
+	sim, err := s.fsm.SimulatePlan(context.TODO(), false)
+	c.Assert(err, check.IsNil)
+	c.Assert(sim.Phases, check.DeepEquals, []fsm.PhaseSimulation{
+		{PhaseID: "/phase1/sub1", Skipped: true, Reason: "phase is already completed"},
+		{PhaseID: "/phase1/sub2", Actions: []fsm.Action{
+			{Kind: fsm.ActionPhase, Description: "Execute phase /phase1/sub2"},
+		}},
+		{PhaseID: "/phase2", Described: true, Actions: []fsm.Action{
+			fsm.CommandAction("run test command", "test", "--phase", "2"),
+		}},
+	})
+
+	// simulation does not change the state of the plan
+	checkStates(c, s.resolvePlan(c, plan), map[string]string{
+		"/phase1/sub1": storage.OperationPhaseStateCompleted,
+		"/phase1/sub2": storage.OperationPhaseStateUnstarted,
+		"/phase2":      storage.OperationPhaseStateUnstarted,
+	})
+}
+
+func (s *FSMSuite) TestSimulatesPlanOfOperationNotCreated(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   "simulated",
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1"},
+			{ID: "/phase2", Requires: []string{"/phase1"}},
+		},
+	}
+	config := s.engine.Config
+	config.Backend = config.LocalBackend
+	config.Operation = &ops.SiteOperation{
+		ID:         plan.OperationID,
+		SiteDomain: clusterName,
+		Type:       ops.OperationUpdate,
+	}
+
+	sim, err := Simulate(context.TODO(), config, plan)
+	c.Assert(err, check.IsNil)
+	c.Assert(sim.Phases, check.DeepEquals, []fsm.PhaseSimulation{
+		{PhaseID: "/phase1", Actions: []fsm.Action{
+			{Kind: fsm.ActionPhase, Description: "Execute phase /phase1"},
+		}},
+		{PhaseID: "/phase2", Described: true, Actions: []fsm.Action{
+			fsm.CommandAction("run test command", "test", "--phase", "2"),
+		}},
+	})
+
+	// the plan is not stored
+	_, err = config.LocalBackend.GetOperationPlan(clusterName, plan.OperationID)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+}
+
+func (s *FSMSuite) TestFSMExecutesParallelPhasesInDependencyOrder(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1", Parallel: true, Phases: []storage.OperationPhase{
+				{ID: "/phase1/sub1", Requires: []string{"/phase1/sub2"}},
+				{ID: "/phase1/sub2"},
+				{ID: "/phase1/sub3"},
+			}},
+		},
+	}
+
+	s.engine.plan = plan
+
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/phase1",
+	})
+	c.Assert(err, check.IsNil)
+
+	resolved := s.resolvePlan(c, plan)
+	checkStates(c, resolved, map[string]string{
+		"/phase1":      storage.OperationPhaseStateCompleted,
+		"/phase1/sub1": storage.OperationPhaseStateCompleted,
+		"/phase1/sub2": storage.OperationPhaseStateCompleted,
+		"/phase1/sub3": storage.OperationPhaseStateCompleted,
+	})
+
+	sub1, err := fsm.FindPhase(resolved, "/phase1/sub1")
+	c.Assert(err, check.IsNil)
+	sub2, err := fsm.FindPhase(resolved, "/phase1/sub2")
+	c.Assert(err, check.IsNil)
+	c.Assert(sub2.Started.IsZero(), check.Equals, false)
+	c.Assert(sub2.Finished.IsZero(), check.Equals, false)
+	c.Assert(sub1.Started.Before(sub2.Finished), check.Equals, false,
+		check.Commentf("%v started before %v finished", sub1.ID, sub2.ID))
+}
+
+func (s *FSMSuite) TestFSMExecutesIndependentPhasesConcurrently(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1", Phases: []storage.OperationPhase{
+				{ID: "/phase1/init"},
+				{ID: "/phase1/sub1", Requires: []string{"/phase1/init"}},
+				{ID: "/phase1/sub2", Requires: []string{"/phase1/init"}},
+			}},
+		},
+	}
+
+	s.engine.plan = plan
+	// both subphases only complete once the other one has started
+	var started sync.WaitGroup
+	started.Add(2)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+	s.engine.Spec = func(p fsm.ExecutorParams, _ fsm.Remote) (fsm.PhaseExecutor, error) {
+		return &testFuncPhase{
+			FieldLogger: logrus.NewEntry(logrus.New()),
+			execute: func(ctx context.Context) error {
+				if p.Phase.ID == "/phase1/init" {
+					return nil
+				}
+				started.Done()
+				select {
+				case <-allStarted:
+					return nil
+				case <-time.After(5 * time.Second):
+					return trace.LimitExceeded("%v did not overlap with its sibling", p.Phase.ID)
+				}
+			},
+		}, nil
+	}
+
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/phase1",
+	})
+	c.Assert(err, check.IsNil)
+
+	checkStates(c, s.resolvePlan(c, plan), map[string]string{
+		"/phase1/init": storage.OperationPhaseStateCompleted,
+		"/phase1/sub1": storage.OperationPhaseStateCompleted,
+		"/phase1/sub2": storage.OperationPhaseStateCompleted,
+	})
+}
+
+func (s *FSMSuite) TestFSMLimitsConcurrentPhases(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1", Parallel: true, Phases: []storage.OperationPhase{
+				{ID: "/phase1/sub1"},
+				{ID: "/phase1/sub2"},
+				{ID: "/phase1/sub3"},
+				{ID: "/phase1/sub4"},
+			}},
+		},
+	}
+
+	s.engine.plan = plan
+	var mu sync.Mutex
+	var running, maxRunning int
+	s.engine.Spec = func(p fsm.ExecutorParams, _ fsm.Remote) (fsm.PhaseExecutor, error) {
+		return &testFuncPhase{
+			FieldLogger: logrus.NewEntry(logrus.New()),
+			execute: func(context.Context) error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(50 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			},
+		}, nil
+	}
+	machine, err := fsm.New(fsm.Config{
+		Engine:      s.engine,
+		Logger:      s.fsm.FieldLogger,
+		MaxParallel: 2,
+	})
+	c.Assert(err, check.IsNil)
+
+	err = machine.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/phase1",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(maxRunning, check.Equals, 2)
+
+	checkStates(c, s.resolvePlan(c, plan), map[string]string{
+		"/phase1/sub1": storage.OperationPhaseStateCompleted,
+		"/phase1/sub2": storage.OperationPhaseStateCompleted,
+		"/phase1/sub3": storage.OperationPhaseStateCompleted,
+		"/phase1/sub4": storage.OperationPhaseStateCompleted,
+	})
+}
+
+func (s *FSMSuite) TestFSMRetriesFailedPhase(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/flaky", Retries: 2},
+		},
+	}
+
+	s.engine.plan = plan
+	flaky := &testFlakyPhase{
+		FieldLogger: logrus.NewEntry(logrus.New()),
+		failures:    2,
+	}
+	s.engine.Spec = func(fsm.ExecutorParams, fsm.Remote) (fsm.PhaseExecutor, error) {
+		return flaky, nil
+	}
+
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/flaky",
+	})
+	c.Assert(err, check.IsNil)
+
+	resolved := s.resolvePlan(c, plan)
+	checkStates(c, resolved, map[string]string{
+		"/flaky": storage.OperationPhaseStateCompleted,
+	})
+	c.Assert(resolved.Phases[0].Attempt, check.Equals, 3)
+
+	changelog, err := s.engine.LocalBackend.GetOperationPlanChangelog(clusterName, operationID)
+	c.Assert(err, check.IsNil)
+	sort.Slice(changelog, func(i, j int) bool {
+		return changelog[i].Created.Before(changelog[j].Created)
+	})
+	var attempts []string
+	for _, change := range changelog {
+		attempts = append(attempts, fmt.Sprintf("%v:%v", change.Attempt, change.NewState))
+	}
+	c.Assert(attempts, check.DeepEquals, []string{
+		"1:in_progress", "1:failed",
+		"2:in_progress", "2:failed",
+		"3:in_progress", "3:completed",
+	})
+
+	var failed []int
+	for _, attempt := range resolved.Phases[0].FailedAttempts {
+		c.Assert(attempt.Error, check.NotNil)
+		failed = append(failed, attempt.Attempt)
+	}
+	c.Assert(failed, check.DeepEquals, []int{1, 2})
+}
+
+func (s *FSMSuite) TestFSMAttemptsDispatchedPhaseOnce(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/flaky", Retries: 2},
+		},
+	}
+
+	s.engine.plan = plan
+	s.engine.Spec = func(fsm.ExecutorParams, fsm.Remote) (fsm.PhaseExecutor, error) {
+		return &testFlakyPhase{
+			FieldLogger: logrus.NewEntry(logrus.New()),
+			failures:    1,
+		}, nil
+	}
+
+	// the node that dispatched the phase is responsible for retries
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/flaky",
+		Attempt: 3,
+	})
+	c.Assert(err, check.NotNil)
+
+	resolved := s.resolvePlan(c, plan)
+	checkStates(c, resolved, map[string]string{
+		"/flaky": storage.OperationPhaseStateFailed,
+	})
+	c.Assert(resolved.Phases[0].Attempt, check.Equals, 3)
+}
+
+func (s *FSMSuite) TestFSMFailsPhaseAfterRetriesAndTimeouts(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/flaky", Retries: 1, Timeout: 10 * time.Millisecond},
+		},
+	}
+
+	s.engine.plan = plan
+	s.engine.Spec = func(fsm.ExecutorParams, fsm.Remote) (fsm.PhaseExecutor, error) {
+		return &testFlakyPhase{
+			FieldLogger: logrus.NewEntry(logrus.New()),
+			hang:        true,
+		}, nil
+	}
+
+	err := s.fsm.ExecutePhase(context.TODO(), fsm.Params{
+		PhaseID: "/flaky",
+	})
+	c.Assert(trace.IsLimitExceeded(err), check.Equals, true, check.Commentf("%v", err))
+
+	resolved := s.resolvePlan(c, plan)
+	checkStates(c, resolved, map[string]string{
+		"/flaky": storage.OperationPhaseStateFailed,
+	})
+	c.Assert(resolved.Phases[0].Attempt, check.Equals, 2)
+}
+
+func (s *FSMSuite) TestFSMRollsBackPlanInReverseOrder(c *check.C) {
+	plan := storage.OperationPlan{
+		OperationID:   operationID,
+		OperationType: "test_operation",
+		ClusterName:   clusterName,
+		Phases: []storage.OperationPhase{
+			{ID: "/phase1", Parallel: true, Phases: []storage.OperationPhase{
+				{ID: "/phase1/sub1", Requires: []string{"/phase1/sub2"}},
+				{ID: "/phase1/sub2"},
+			}},
+			{ID: "/phase2", Requires: []string{"/phase1"}},
+			{ID: "/phase3", Requires: []string{"/phase2"}},
+		},
+	}
+
+	s.engine.plan = plan
+	var rolledBack []string
+	s.engine.Spec = func(p fsm.ExecutorParams, _ fsm.Remote) (fsm.PhaseExecutor, error) {
+		return &testRecordingPhase{
+			FieldLogger: logrus.NewEntry(logrus.New()),
+			id:          p.Phase.ID,
+			rolledBack:  &rolledBack,
+		}, nil
+	}
+
+	ctx := context.TODO()
+	for _, phaseID := range []string{"/phase1", "/phase2"} {
+		err := s.fsm.ExecutePhase(ctx, fsm.Params{PhaseID: phaseID})
+		c.Assert(err, check.IsNil)
+	}
+
+	err := s.fsm.RollbackPlan(ctx, nil, false)
+	c.Assert(err, check.IsNil)
+	c.Assert(rolledBack, check.DeepEquals, []string{"/phase2", "/phase1/sub1", "/phase1/sub2"})
+
+	checkStates(c, s.resolvePlan(c, plan), map[string]string{
+		"/phase1/sub1": storage.OperationPhaseStateRolledBack,
+		"/phase1/sub2": storage.OperationPhaseStateRolledBack,
+		"/phase2":      storage.OperationPhaseStateRolledBack,
+		"/phase3":      storage.OperationPhaseStateUnstarted,
+	})
+
+	// nothing left to roll back
+	rolledBack = nil
+	err = s.fsm.RollbackPlan(ctx, nil, false)
+	c.Assert(err, check.IsNil)
+	c.Assert(rolledBack, check.IsNil)
+}
+
+func (s *FSMSuite) TestCheckPlanDetectsCycles(c *check.C) {
+	var testCases = []struct {
+		phases  []storage.OperationPhase
+		isValid bool
+		comment string
+	}{
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1"},
+				{ID: "/phase2", Requires: []string{"/phase1"}},
+			},
+			isValid: true,
+			comment: "requirements follow the order of phases",
+		},
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1", Requires: []string{"/phase2"}},
+				{ID: "/phase2"},
+			},
+			comment: "sequential phase requires a subsequent phase",
+		},
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1", Parallel: true, Phases: []storage.OperationPhase{
+					{ID: "/phase1/sub1", Requires: []string{"/phase1/sub2"}},
+					{ID: "/phase1/sub2"},
+				}},
+			},
+			isValid: true,
+			comment: "parallel phase requires a sibling",
+		},
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1", Parallel: true, Phases: []storage.OperationPhase{
+					{ID: "/phase1/sub1", Requires: []string{"/phase1/sub2"}},
+					{ID: "/phase1/sub2", Requires: []string{"/phase1/sub1"}},
+				}},
+			},
+			comment: "parallel phases require each other",
+		},
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1", Retries: -1},
+			},
+			comment: "negative number of retries",
+		},
+		{
+			phases: []storage.OperationPhase{
+				{ID: "/phase1", Retries: 1, Phases: []storage.OperationPhase{
+					{ID: "/phase1/sub1"},
+				}},
+			},
+			comment: "retry policy on a phase with subphases",
+		},
+	}
+	for _, tc := range testCases {
+		err := fsm.CheckPlan(storage.OperationPlan{Phases: tc.phases})
+		if tc.isValid {
+			c.Assert(err, check.IsNil, check.Commentf(tc.comment))
+		} else {
+			c.Assert(err, check.NotNil, check.Commentf(tc.comment))
+		}
+	}
+}
+
 func (s *FSMSuite) resolvePlan(c *check.C, plan storage.OperationPlan) *storage.OperationPlan {
 	changelog, err := s.engine.LocalBackend.GetOperationPlanChangelog(plan.ClusterName, plan.OperationID)
 	c.Assert(err, check.IsNil)
@@ -230,9 +689,89 @@ func (p *testPhase2) Execute(context.Context) error {
 func (p *testPhase2) Rollback(context.Context) error {
 	return nil
 }
+func (p *testPhase2) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.CommandAction("run test command", "test", "--phase", "2"),
+	}, nil
+}
+
+// testFlakyPhase fails the specified number of times before succeeding.
+// If hang is set, it blocks until the context is done instead
+type testFlakyPhase struct {
+	logrus.FieldLogger
+	failures int
+	hang     bool
+}
+
+func (p *testFlakyPhase) PreCheck(context.Context) error {
+	return nil
+}
+func (p *testFlakyPhase) PostCheck(context.Context) error {
+	return nil
+}
+func (p *testFlakyPhase) Execute(ctx context.Context) error {
+	if p.hang {
+		<-ctx.Done()
+		return trace.Wrap(ctx.Err())
+	}
+	if p.failures > 0 {
+		p.failures--
+		return trace.ConnectionProblem(nil, "temporary failure")
+	}
+	return nil
+}
+func (p *testFlakyPhase) Rollback(context.Context) error {
+	return nil
+}
+
+// testFuncPhase executes the specified function
+type testFuncPhase struct {
+	logrus.FieldLogger
+	execute func(context.Context) error
+}
+
+func (p *testFuncPhase) PreCheck(context.Context) error {
+	return nil
+}
+func (p *testFuncPhase) PostCheck(context.Context) error {
+	return nil
+}
+func (p *testFuncPhase) Execute(ctx context.Context) error {
+	return p.execute(ctx)
+}
+func (p *testFuncPhase) Rollback(context.Context) error {
+	return nil
+}
+
+// testRecordingPhase records the order in which phases are rolled back
+type testRecordingPhase struct {
+	logrus.FieldLogger
+	id         string
+	rolledBack *[]string
+}
+
+func (p *testRecordingPhase) PreCheck(context.Context) error {
+	return nil
+}
+func (p *testRecordingPhase) PostCheck(context.Context) error {
+	return nil
+}
+func (p *testRecordingPhase) Execute(context.Context) error {
+	return nil
+}
+func (p *testRecordingPhase) Rollback(context.Context) error {
+	*p.rolledBack = append(*p.rolledBack, p.id)
+	return nil
+}
 
 func (r *testReconciler) ReconcilePlan(ctx context.Context, plan storage.OperationPlan) (*storage.OperationPlan, error) {
-	return &plan, nil
+	changelog, err := r.backend.GetOperationPlanChangelog(plan.ClusterName, plan.OperationID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return fsm.ResolvePlan(plan, changelog), nil
 }
 
-type testReconciler struct{}
+type testReconciler struct {
+	backend storage.Backend
+}
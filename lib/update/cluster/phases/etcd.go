@@ -18,6 +18,7 @@ package phases
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 
 	"github.com/gravitational/gravity/lib/constants"
@@ -99,6 +100,17 @@ func (p *PhaseUpgradeEtcdBackup) Rollback(context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeEtcdBackup) Describe(context.Context) ([]fsm.Action, error) {
+	backupFile, err := backupFile()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []fsm.Action{
+		planetAction("backup etcd data", "etcd", "backup", backupFile),
+	}, nil
+}
+
 func (*PhaseUpgradeEtcdBackup) PreCheck(context.Context) error {
 	// TODO(knisbet) should we check that there is enough free space available to hold the backup?
 	return nil
@@ -150,6 +162,13 @@ func (p *PhaseUpgradeEtcdShutdown) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeEtcdShutdown) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		planetAction("shutdown etcd", "etcd", "disable"),
+	}, nil
+}
+
 func (p *PhaseUpgradeEtcdShutdown) PreCheck(ctx context.Context) error {
 	return nil
 }
@@ -209,6 +228,14 @@ func (p *PhaseUpgradeEtcd) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeEtcd) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		planetAction("upgrade etcd", "etcd", "upgrade"),
+		planetAction("start temporary etcd cluster", "etcd", "enable", "--upgrade"),
+	}, nil
+}
+
 func (*PhaseUpgradeEtcd) PreCheck(context.Context) error {
 	return nil
 }
@@ -251,6 +278,17 @@ func (p *PhaseUpgradeEtcdRestore) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeEtcdRestore) Describe(context.Context) ([]fsm.Action, error) {
+	backupFile, err := backupFile()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []fsm.Action{
+		planetAction("restore etcd data from backup", "etcd", "restore", backupFile),
+	}, nil
+}
+
 func (p *PhaseUpgradeEtcdRestore) PreCheck(ctx context.Context) error {
 	// wait for etcd to form a cluster
 	out, err := utils.RunCommand(ctx, p.FieldLogger,
@@ -311,6 +349,14 @@ func (p *PhaseUpgradeEtcdRestart) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeEtcdRestart) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		planetAction("stop temporary etcd cluster", "etcd", "disable", "--upgrade"),
+		planetAction("start etcd", "etcd", "enable"),
+	}, nil
+}
+
 func (*PhaseUpgradeEtcdRestart) PreCheck(context.Context) error {
 	return nil
 }
@@ -345,6 +391,15 @@ func (p *PhaseUpgradeGravitySiteRestart) Rollback(context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *PhaseUpgradeGravitySiteRestart) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.CommandAction("wait for etcd cluster", utils.PlanetCommandArgs(defaults.WaitForEtcdScript)...),
+		fsm.KubernetesAction("delete cluster controller pods",
+			fmt.Sprintf("pods/%v (app=%v)", constants.KubeSystemNamespace, constants.GravityServiceName)),
+	}, nil
+}
+
 func (*PhaseUpgradeGravitySiteRestart) PreCheck(context.Context) error {
 	return nil
 }
@@ -371,3 +426,9 @@ func restartGravitySite(ctx context.Context, client *kubeapi.Clientset, l log.Fi
 	}, defaults.DrainErrorTimeout)
 	return trace.Wrap(err)
 }
+
+// planetAction returns an action describing the planet command specified with args
+func planetAction(description string, args ...string) fsm.Action {
+	return fsm.CommandAction(description,
+		utils.PlanetCommandArgs(append([]string{defaults.PlanetBin}, args...)...)...)
+}
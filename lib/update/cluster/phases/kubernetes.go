@@ -18,6 +18,7 @@ package phases
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -70,6 +71,13 @@ func (p *phaseTaint) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseTaint) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("add taint "+runLevelTaintString(), nodeObject(p.Server)),
+	}, nil
+}
+
 // phaseUntaint defines the operation of removing a taint from the node
 type phaseUntaint struct {
 	kubernetesOperation
@@ -102,6 +110,13 @@ func (p *phaseUntaint) Rollback(context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseUntaint) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("remove taint "+runLevelTaintString(), nodeObject(p.Server)),
+	}, nil
+}
+
 // phaseDrain defines the operation of draining a node
 type phaseDrain struct {
 	kubernetesOperation
@@ -136,6 +151,13 @@ func (p *phaseDrain) Rollback(ctx context.Context) error {
 	return trace.Wrap(err)
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseDrain) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("drain node", nodeObject(p.Server)),
+	}, nil
+}
+
 // phaseKubeletPermissions defines the operation to bootstrap additional permissions for kubelet.
 // This is necessary for a master node that is upgraded first and needs to update node status (via patch)
 // on an older api server.
@@ -166,6 +188,16 @@ func (p *phaseKubeletPermissions) Rollback(context.Context) error {
 	return trace.Wrap(removeKubeletPermissions(p.Client))
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseKubeletPermissions) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("create cluster role",
+			fmt.Sprintf("clusterrole/%v", defaults.KubeletUpdatePermissionsRole)),
+		fsm.KubernetesAction("create cluster role binding",
+			fmt.Sprintf("clusterrolebinding/%v", defaults.KubeletUpdatePermissionsRole)),
+	}, nil
+}
+
 // phaseUncordon defines the operation of uncordoning a node
 type phaseUncordon struct {
 	kubernetesOperation
@@ -195,6 +227,13 @@ func (p *phaseUncordon) Rollback(context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseUncordon) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("uncordon node", nodeObject(p.Server)),
+	}, nil
+}
+
 // phaseEndpoints defines the operation waiting for DNS/cluster endpoints after
 // a node has been drained
 type phaseEndpoints struct {
@@ -224,6 +263,13 @@ func (p *phaseEndpoints) Rollback(context.Context) error {
 	return nil
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *phaseEndpoints) Describe(context.Context) ([]fsm.Action, error) {
+	return []fsm.Action{
+		fsm.KubernetesAction("wait for DNS and cluster controller endpoints", nodeObject(p.Server)),
+	}, nil
+}
+
 func newKubernetesOperation(p fsm.ExecutorParams, client *kubeapi.Clientset, logger log.FieldLogger) (*kubernetesOperation, error) {
 	if p.Phase.Data == nil || p.Phase.Data.Server == nil {
 		return nil, trace.NotFound("no server specified for phase %q", p.Phase.ID)
@@ -378,4 +424,12 @@ func removeKubeletPermissions(client *kubeapi.Clientset) error {
 	return nil
 }
 
+func nodeObject(server storage.Server) string {
+	return fmt.Sprintf("node/%v", server.KubeNodeID())
+}
+
+func runLevelTaintString() string {
+	return fmt.Sprintf("%v=%v:%v", defaults.RunLevelLabel, defaults.RunLevelSystem, v1.TaintEffectNoExecute)
+}
+
 type addTaint bool
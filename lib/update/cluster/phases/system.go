@@ -152,6 +152,28 @@ func (p *updatePhaseSystem) Rollback(ctx context.Context) error {
 	return trace.Wrap(err)
 }
 
+// Describe returns the list of actions this phase would perform
+func (p *updatePhaseSystem) Describe(context.Context) ([]fsm.Action, error) {
+	actions := []fsm.Action{
+		fsm.PackageAction("update gravity", p.GravityPackage),
+	}
+	if p.Server.Runtime.Update != nil {
+		actions = append(actions,
+			fsm.PackageAction("update runtime", p.Server.Runtime.Update.Package),
+			fsm.PackageAction("update runtime configuration", p.Server.Runtime.Update.ConfigPackage))
+	}
+	if p.Server.Teleport.Update != nil {
+		actions = append(actions,
+			fsm.PackageAction("update teleport", p.Server.Teleport.Update.Package),
+			fsm.PackageAction("update teleport configuration", p.Server.Teleport.Update.NodeConfigPackage))
+	}
+	if p.Server.Runtime.SecretsPackage != nil {
+		actions = append(actions,
+			fsm.PackageAction("update runtime secrets", *p.Server.Runtime.SecretsPackage))
+	}
+	return actions, nil
+}
+
 type updatePhaseConfig struct {
 	// Packages is the cluster package service
 	Packages pack.PackageService
@@ -20,17 +20,21 @@ import (
 	"archive/tar"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"strconv"
+	"time"
 
 	"github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/opsservice"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/schema"
 	"github.com/gravitational/gravity/lib/storage"
@@ -42,6 +46,7 @@ import (
 
 	teleservices "github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -77,6 +82,57 @@ func InitOperationPlan(
 		return nil, trace.Wrap(err)
 	}
 
+	plan, err = newPlan(localEnv, clusterEnv, *cluster, operation, clusterEnv.Operator)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	_, err = clusterEnv.Backend.CreateOperationPlan(*plan)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return plan, nil
+}
+
+// NewSimulatedOperationPlan returns the plan to update the cluster to the
+// specified application package without creating the update operation.
+// The returned operation only exists in memory so the plan can only
+// be simulated with Simulate
+func NewSimulatedOperationPlan(
+	localEnv *localenv.LocalEnvironment,
+	clusterEnv *localenv.ClusterEnvironment,
+	cluster ops.Site,
+	updatePackage loc.Locator,
+) (*storage.OperationPlan, *ops.SiteOperation, error) {
+	now := time.Now().UTC()
+	operation := ops.SiteOperation{
+		ID:         uuid.New(),
+		AccountID:  cluster.AccountID,
+		SiteDomain: cluster.Domain,
+		Type:       ops.OperationUpdate,
+		Created:    now,
+		Updated:    now,
+		State:      ops.OperationStateUpdateInProgress,
+		Update: &storage.UpdateOperationState{
+			UpdatePackage: updatePackage.String(),
+		},
+	}
+	plan, err := newPlan(localEnv, clusterEnv, cluster, (*storage.SiteOperation)(&operation),
+		simulatedRotator{packageRotator: clusterEnv.Operator})
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return plan, &operation, nil
+}
+
+func newPlan(
+	localEnv *localenv.LocalEnvironment,
+	clusterEnv *localenv.ClusterEnvironment,
+	cluster ops.Site,
+	operation *storage.SiteOperation,
+	rotator packageRotator,
+) (*storage.OperationPlan, error) {
 	dnsConfig := cluster.DNSConfig
 	if dnsConfig.IsEmpty() {
 		log.Info("Detecting DNS configuration.")
@@ -87,7 +143,7 @@ func InitOperationPlan(
 		dnsConfig = *existingDNS
 	}
 
-	plan, err = NewOperationPlan(PlanConfig{
+	plan, err := NewOperationPlan(PlanConfig{
 		Backend:   clusterEnv.Backend,
 		Apps:      clusterEnv.Apps,
 		Packages:  clusterEnv.ClusterPackages,
@@ -95,12 +151,13 @@ func InitOperationPlan(
 		DNSConfig: dnsConfig,
 		Operator:  clusterEnv.Operator,
 		Operation: operation,
+		rotator:   rotator,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 
-	_, err = clusterEnv.Backend.CreateOperationPlan(*plan)
+	err = fsm.CheckPlan(*plan)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -176,7 +233,7 @@ func NewOperationPlan(config PlanConfig) (*storage.OperationPlan, error) {
 
 	updates, err := configUpdates(
 		installedApp.Manifest, updateApp.Manifest,
-		config.Operator, (*ops.SiteOperation)(config.Operation).Key(), servers)
+		config.rotator, (*ops.SiteOperation)(config.Operation).Key(), servers)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -196,7 +253,7 @@ func NewOperationPlan(config PlanConfig) (*storage.OperationPlan, error) {
 			DNSConfig:      config.DNSConfig,
 			GravityPackage: *gravityPackage,
 		},
-		operator:          config.Operator,
+		operator:          config.rotator,
 		operation:         *config.Operation,
 		servers:           updates,
 		installedRuntime:  *installedRuntime,
@@ -238,6 +295,9 @@ func (r *PlanConfig) checkAndSetDefaults() error {
 	if r.Operation == nil {
 		return trace.BadParameter("cluster operation is required")
 	}
+	if r.rotator == nil {
+		r.rotator = r.Operator
+	}
 	return nil
 }
 
@@ -250,6 +310,8 @@ type PlanConfig struct {
 	Operator  ops.Operator
 	Operation *storage.SiteOperation
 	Client    *kubernetes.Clientset
+	// rotator generates the configuration packages, defaults to Operator
+	rotator packageRotator
 }
 
 // planConfig collects parameters needed to generate an update operation plan
@@ -389,7 +451,7 @@ func newOperationPlan(p planConfig) (*storage.OperationPlan, error) {
 		// phase so new gravity-sites can find it after they start
 		configPhase := *builder.config(servers(masters...)).Require(mastersPhase)
 		runtimePhase := *builder.runtime(runtimeUpdates).Require(mastersPhase)
-		root.Add(configPhase, runtimePhase)
+		root.AddSequential(configPhase, runtimePhase)
 	}
 
 	root.AddSequential(*builder.app(appUpdates), *builder.cleanup())
@@ -680,3 +742,27 @@ type packageRotator interface {
 	RotatePlanetConfig(ops.RotatePlanetConfigRequest) (*ops.RotatePackageResponse, error)
 	RotateTeleportConfig(ops.RotateTeleportConfigRequest) (*ops.RotatePackageResponse, *ops.RotatePackageResponse, error)
 }
+
+// simulatedRotator generates the configuration packages for the plan
+// of an update operation that has not been created.
+// The cluster can only generate the teleport configuration for an existing
+// operation, so the simulated rotator names the package the same way instead
+type simulatedRotator struct {
+	packageRotator
+}
+
+// RotateTeleportConfig returns the locator of the teleport node configuration
+// package for the server specified in the request
+func (r simulatedRotator) RotateTeleportConfig(req ops.RotateTeleportConfigRequest) (*ops.RotatePackageResponse, *ops.RotatePackageResponse, error) {
+	if !req.DryRun {
+		return nil, nil, trace.BadParameter("simulated operation cannot rotate teleport configuration")
+	}
+	domain := req.Key.SiteDomain
+	nodeConfig, err := loc.ParseLocator(fmt.Sprintf("%v/%v:0.0.%v-%v", domain,
+		constants.TeleportNodeConfigPackage, time.Now().UTC().Unix(),
+		opsservice.PackageSuffix(&opsservice.ProvisionedServer{Server: req.Server}, domain)))
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return nil, &ops.RotatePackageResponse{Locator: *nodeConfig}, nil
+}
@@ -19,6 +19,8 @@ package update
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gravitational/gravity/lib/fsm"
@@ -39,13 +41,15 @@ func NewMachine(ctx context.Context, config Config, engine *Engine) (*fsm.FSM, e
 		return nil, trace.Wrap(err)
 	}
 	machine, err := fsm.New(fsm.Config{
-		Engine: engine,
-		Runner: config.Runner,
+		Engine:      engine,
+		Runner:      config.Runner,
+		MaxParallel: config.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
 	machine.SetPreExec(engine.UpdateProgress)
+	machine.SetPreRollback(engine.UpdateProgress)
 	return machine, nil
 }
 
@@ -94,7 +98,7 @@ func (r *Engine) UpdateProgress(ctx context.Context, params fsm.Params) error {
 		Completion:  100 / utils.Max(len(plan.Phases), 1) * phase.Step,
 		Step:        phase.Step,
 		State:       ops.ProgressStateInProgress,
-		Message:     phase.Description,
+		Message:     fsm.ProgressMessage(*phase, params),
 		Created:     time.Now().UTC(),
 	}
 	err = r.operator.CreateProgressEntry(key, entry)
@@ -147,11 +151,16 @@ func (r *Engine) ChangePhaseState(ctx context.Context, change fsm.StateChange) e
 		PhaseID:     change.Phase,
 		NewState:    change.State,
 		Error:       utils.ToRawTrace(change.Error),
+		Attempt:     change.Attempt,
 		Created:     time.Now().UTC(),
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// State changes are reconciled one at a time so that a plan reconciled
+	// from an older changelog never replaces a more recent one
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	plan, err := r.reconciler.ReconcilePlan(ctx, r.plan)
 	if err != nil {
 		return trace.Wrap(err)
@@ -163,19 +172,29 @@ func (r *Engine) ChangePhaseState(ctx context.Context, change fsm.StateChange) e
 // RunCommand executes the phase specified by params on the specified server
 // using the provided runner
 func (r *Engine) RunCommand(ctx context.Context, runner fsm.RemoteRunner, server storage.Server, params fsm.Params) error {
-	args := []string{"plan", "execute",
+	command := "execute"
+	if params.Rollback {
+		command = "rollback"
+	}
+	args := []string{"plan", command,
 		"--phase", params.PhaseID,
 		"--operation-id", r.Operation.ID,
 	}
 	if params.Force {
 		args = append(args, "--force")
 	}
+	if params.Attempt != 0 {
+		args = append(args, "--attempt", strconv.Itoa(params.Attempt))
+	}
 	return runner.Run(ctx, server, args...)
 }
 
 // GetPlan returns the most up-to-date operation plan
 func (r *Engine) GetPlan() (*storage.OperationPlan, error) {
-	return &r.plan, nil
+	r.mu.Lock()
+	plan := r.plan
+	r.mu.Unlock()
+	return &plan, nil
 }
 
 // GetExecutor returns a new executor based on the provided parameters
@@ -191,6 +210,9 @@ type Engine struct {
 	localenv.Silent
 	reconciler Reconciler
 	operator
+	// mu guards plan which is updated as phases executing
+	// concurrently change their state
+	mu         sync.Mutex
 	plan       storage.OperationPlan
 	dispatcher Dispatcher
 }
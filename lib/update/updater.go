@@ -81,7 +81,9 @@ L:
 }
 
 // RunPhase runs the specified phase.
-func (r *Updater) RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
+// A non-zero attempt is the number of the attempt assigned by the node
+// that dispatched the phase to this node
+func (r *Updater) RunPhase(ctx context.Context, phase string, attempt int, phaseTimeout time.Duration, force bool) error {
 	if phase == fsm.RootPhase {
 		return trace.Wrap(r.Run(ctx, force))
 	}
@@ -96,11 +98,14 @@ func (r *Updater) RunPhase(ctx context.Context, phase string, phaseTimeout time.
 		PhaseID:  phase,
 		Progress: progress,
 		Force:    force,
+		Attempt:  attempt,
 	}))
 }
 
 // RollbackPhase rolls back the specified phase.
-func (r *Updater) RollbackPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
+// A non-zero attempt is the number of the attempt assigned by the node
+// that dispatched the phase to this node
+func (r *Updater) RollbackPhase(ctx context.Context, phase string, attempt int, phaseTimeout time.Duration, force bool) error {
 	ctx, cancel := context.WithTimeout(ctx, phaseTimeout)
 	defer cancel()
 
@@ -111,9 +116,34 @@ func (r *Updater) RollbackPhase(ctx context.Context, phase string, phaseTimeout
 		PhaseID:  phase,
 		Progress: progress,
 		Force:    force,
+		Attempt:  attempt,
 	}))
 }
 
+// RollbackPlan rolls back all phases of the operation plan that have been
+// executed in the reverse order of their dependencies.
+func (r *Updater) RollbackPlan(ctx context.Context, phaseTimeout time.Duration, force bool) error {
+	ctx, cancel := context.WithTimeout(ctx, phaseTimeout)
+	defer cancel()
+
+	progress := utils.NewProgress(ctx, fmt.Sprintf("Rolling back %v", formatOperation(*r.Operation)), -1, false)
+	defer progress.Stop()
+
+	return trace.Wrap(r.machine.RollbackPlan(ctx, progress, force))
+}
+
+// SimulatePhase describes the specified phase without executing it.
+func (r *Updater) SimulatePhase(ctx context.Context, phase string, force bool) (*fsm.Simulation, error) {
+	sim, err := r.machine.SimulatePhase(ctx, fsm.Params{
+		PhaseID: phase,
+		Force:   force,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sim, nil
+}
+
 // Complete completes the active operation
 func (r *Updater) Complete(fsmErr error) error {
 	if fsmErr == nil {
@@ -147,6 +177,13 @@ func (r *Updater) executePlan(ctx context.Context, force bool) error {
 	planErr := r.machine.ExecutePlan(ctx, progress, force)
 	if planErr != nil {
 		r.Warnf("Failed to execute plan: %v.", trace.DebugReport(planErr))
+		if r.AutoRollback {
+			r.Silent.Println("Operation failed, rolling back.")
+			if err := r.machine.RollbackPlan(ctx, progress, false); err != nil {
+				r.WithError(err).Warn("Failed to roll back operation.")
+				planErr = trace.NewAggregate(planErr, err)
+			}
+		}
 	}
 
 	err := r.machine.Complete(planErr)
@@ -217,6 +254,11 @@ type Config struct {
 	LocalBackend storage.Backend
 	// Runner specifies the runner for remote commands
 	Runner fsm.AgentRepository
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
+	// AutoRollback specifies whether the operation is rolled back
+	// automatically if the plan fails to execute
+	AutoRollback bool
 	// FieldLogger is the logger to use
 	log.FieldLogger
 	// Silent controls whether the process outputs messages to stdout
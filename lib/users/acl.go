@@ -521,6 +521,15 @@ func (i *IdentityACL) CreateClusterAgent(clusterName string, agent storage.User)
 	return i.identity.CreateClusterAgent(clusterName, agent)
 }
 
+// CreateAlertNotifier creates a new agent user that is only allowed
+// to deliver monitoring alerts to the alert target of the cluster
+func (i *IdentityACL) CreateAlertNotifier(clusterName string, notifier storage.User) (storage.User, error) {
+	if err := i.usersAction(teleservices.VerbCreate); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return i.identity.CreateAlertNotifier(clusterName, notifier)
+}
+
 // CreateClusterAdminAgent creates a new privileged cluster agent user used during operations
 // like install/expand on master nodes, and has advanced administrative operations
 // e.g. create and delete roles, set up OIDC connectors
@@ -144,6 +144,7 @@ func NewInstallTokenRole(name string, clusterName, repoName string) (teleservice
 						Right: storage.StringExpr(clusterName),
 					}.String(),
 				},
+				NewOperationsRule(clusterName),
 				{
 					Resources: []string{storage.KindApp},
 					Verbs:     []string{teleservices.VerbList, teleservices.VerbRead},
@@ -278,6 +279,7 @@ func NewClusterAgentRole(name string, clusterName string) (teleservices.Role, er
 						Right: storage.StringExpr(clusterName),
 					}.String(),
 				},
+				NewOperationsRule(clusterName),
 				{
 					Resources: []string{storage.KindApp},
 					Verbs: []string{
@@ -318,6 +320,27 @@ func NewClusterAgentRole(name string, clusterName string) (teleservices.Role, er
 	})
 }
 
+// NewAlertNotifierRole returns new role that only allows delivering
+// monitoring alerts to the alert target of the specified cluster
+func NewAlertNotifierRole(name string, clusterName string) (teleservices.Role, error) {
+	return NewSystemRole(name, teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Namespaces: []string{defaults.Namespace},
+			Logins:     noLogins(),
+			Rules: []teleservices.Rule{
+				{
+					Resources: []string{storage.KindAlertTarget},
+					Verbs:     []string{storage.VerbNotify},
+					Where: storage.EqualsExpr{
+						Left:  storage.ResourceNameExpr,
+						Right: storage.StringExpr(clusterName),
+					}.String(),
+				},
+			},
+		},
+	})
+}
+
 // NewObjectStorageRole specifies role for the object storage
 func NewObjectStorageRole(name string) (teleservices.Role, error) {
 	return NewSystemRole(name, teleservices.RoleSpecV3{
@@ -333,6 +356,19 @@ func NewObjectStorageRole(name string) (teleservices.Role, error) {
 	})
 }
 
+// NewOperationsRule returns a rule that grants access to all operations
+// and plan actions in the specified cluster
+func NewOperationsRule(clusterName string) teleservices.Rule {
+	return teleservices.Rule{
+		Resources: []string{storage.KindOperation, storage.KindOperationPlan},
+		Verbs:     []string{teleservices.Wildcard},
+		Where: storage.EqualsExpr{
+			Left:  storage.ResourceNameExpr,
+			Right: storage.StringExpr(clusterName),
+		}.String(),
+	}
+}
+
 func noLogins() []string {
 	// do not allow any valid logins but the login list should not be empty,
 	// otherwise teleport will reject the web session
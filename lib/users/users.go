@@ -131,6 +131,10 @@ type Users interface {
 	// e.g. create and delete roles, set up OIDC connectors
 	CreateClusterAdminAgent(cluster string, agent storage.User) (storage.User, error)
 
+	// CreateAlertNotifier creates a new agent user that is only allowed
+	// to deliver monitoring alerts to the alert target of the cluster
+	CreateAlertNotifier(cluster string, notifier storage.User) (storage.User, error)
+
 	// CreateLocalAdmin creates a new admin user for the locally running site
 	CreateAdmin(email, password string) error
 
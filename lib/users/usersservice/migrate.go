@@ -65,9 +65,56 @@ func (u *UsersService) Migrate() error {
 			}
 		}
 	}
+	roles, err = u.backend.GetRoles()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, role := range roles {
+		if !addOperationRules(role) {
+			continue
+		}
+		m := log.WithFields(log.Fields{"role": role.GetName(), "module": "migrate"})
+		m.Debugf("adding operation rules to role")
+		err := u.backend.UpsertRole(role, storage.Forever)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return nil
 }
 
+// addOperationRules grants the role that can update clusters access to
+// operations and plan actions in the same clusters.
+// Roles created before operations had their own access rules relied on
+// the cluster update access instead. Roles that already mention operations
+// in either allow or deny rules are left intact.
+// Returns true if the role has been updated
+func addOperationRules(role teleservices.Role) bool {
+	for _, condition := range []teleservices.RoleConditionType{teleservices.Allow, teleservices.Deny} {
+		for _, rule := range role.GetRules(condition) {
+			if rule.HasResource(storage.KindOperation) || rule.HasResource(storage.KindOperationPlan) {
+				return false
+			}
+		}
+	}
+	rules := role.GetRules(teleservices.Allow)
+	var added []teleservices.Rule
+	for _, rule := range rules {
+		if rule.HasResource(storage.KindCluster) && (rule.HasVerb(teleservices.VerbUpdate) || rule.HasVerb(teleservices.Wildcard)) {
+			added = append(added, teleservices.Rule{
+				Resources: []string{storage.KindOperation, storage.KindOperationPlan},
+				Verbs:     []string{teleservices.Wildcard},
+				Where:     rule.Where,
+			})
+		}
+	}
+	if len(added) == 0 {
+		return false
+	}
+	role.SetRules(teleservices.Allow, append(rules, added...))
+	return true
+}
+
 func (u *UsersService) updateUserWithRoles(user storage.User, roles ...teleservices.Role) error {
 	for i := range roles {
 		if err := u.backend.UpsertRole(roles[i], storage.Forever); err != nil {
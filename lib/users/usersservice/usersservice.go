@@ -546,6 +546,17 @@ func (c *UsersService) createClusterAgent(agent storage.User, clusterName string
 	return c.createUserWithRoles(agent, roles, key)
 }
 
+// CreateAlertNotifier creates unprivileged agent user that delivers monitoring alerts
+func (c *UsersService) CreateAlertNotifier(clusterName string, notifier storage.User) (storage.User, error) {
+	notifier.SetClusterName(clusterName)
+	notifier.SetType(storage.AgentUser)
+	role, err := users.NewAlertNotifierRole(notifier.GetName(), clusterName)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return c.createUserWithRoles(notifier, []teleservices.Role{role}, nil)
+}
+
 // CreateAdmin creates a new admin user for the locally running site.
 func (c *UsersService) CreateAdmin(email, password string) error {
 	err := teleservices.VerifyPassword([]byte(password))
@@ -230,6 +230,70 @@ func (s *UsersSuite) TestMigrateClusterAgents(c *C) {
 	})
 }
 
+// TestMigrateOperationRules tests that custom roles with update access
+// to clusters are granted access to operations in the same clusters
+func (s *UsersSuite) TestMigrateOperationRules(c *C) {
+	identity := s.suite.Users
+	where := storage.EqualsExpr{
+		Left:  storage.ResourceNameExpr,
+		Right: storage.StringExpr("example.com"),
+	}.String()
+	role, err := teleservices.NewRole("operator", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Rules: []teleservices.Rule{{
+				Resources: []string{storage.KindCluster},
+				Verbs:     []string{teleservices.VerbRead, teleservices.VerbUpdate},
+				Where:     where,
+			}},
+		},
+	})
+	c.Assert(err, IsNil)
+	err = s.backend.UpsertRole(role, storage.Forever)
+	c.Assert(err, IsNil)
+
+	// role that explicitly denies plan actions is left intact
+	restricted, err := teleservices.NewRole("restricted", teleservices.RoleSpecV3{
+		Allow: teleservices.RoleConditions{
+			Rules: []teleservices.Rule{{
+				Resources: []string{storage.KindCluster},
+				Verbs:     []string{teleservices.Wildcard},
+			}},
+		},
+		Deny: teleservices.RoleConditions{
+			Rules: []teleservices.Rule{{
+				Resources: []string{storage.KindOperationPlan},
+				Verbs:     []string{teleservices.Wildcard},
+			}},
+		},
+	})
+	c.Assert(err, IsNil)
+	err = s.backend.UpsertRole(restricted, storage.Forever)
+	c.Assert(err, IsNil)
+
+	err = identity.Migrate()
+	c.Assert(err, IsNil)
+
+	role, err = s.backend.GetRole("operator")
+	c.Assert(err, IsNil)
+	rule := findRule(c, storage.KindOperation, role.GetRules(teleservices.Allow))
+	compare.DeepCompare(c, rule, &teleservices.Rule{
+		Resources: []string{storage.KindOperation, storage.KindOperationPlan},
+		Verbs:     []string{teleservices.Wildcard},
+		Where:     where,
+	})
+
+	restricted, err = s.backend.GetRole("restricted")
+	c.Assert(err, IsNil)
+	c.Assert(restricted.GetRules(teleservices.Allow), HasLen, 1)
+
+	// migration is idempotent
+	err = identity.Migrate()
+	c.Assert(err, IsNil)
+	role, err = s.backend.GetRole("operator")
+	c.Assert(err, IsNil)
+	c.Assert(role.GetRules(teleservices.Allow), HasLen, 2)
+}
+
 // TestPasswordRecovery verifies that:
 //   * User can reset its password using generated recovery token.
 //   * Recovery token cannot be reused.
@@ -439,6 +503,49 @@ func (s *UsersSuite) TestBuiltinRoles(c *C) {
 				},
 			},
 		},
+		{
+			name: "2 - alert notifier can only deliver alerts of the cluster",
+			roles: []teleservices.Role{
+				MustCreateAlertNotifier("alerts", "example.com"),
+			},
+			checks: []check{
+				{
+					context:   clusterContext("example.com"),
+					rule:      storage.KindAlertTarget,
+					verb:      storage.VerbNotify,
+					namespace: teledefaults.Namespace,
+					hasAccess: true,
+				},
+				{
+					context:   clusterContext("example2.com"),
+					rule:      storage.KindAlertTarget,
+					verb:      storage.VerbNotify,
+					namespace: teledefaults.Namespace,
+					hasAccess: false,
+				},
+				{
+					context:   clusterContext("example.com"),
+					rule:      storage.KindAlertTarget,
+					verb:      teleservices.VerbUpdate,
+					namespace: teledefaults.Namespace,
+					hasAccess: false,
+				},
+				{
+					context:   clusterContext("example.com"),
+					rule:      storage.KindCluster,
+					verb:      teleservices.VerbUpdate,
+					namespace: teledefaults.Namespace,
+					hasAccess: false,
+				},
+				{
+					context:   clusterContext("example.com"),
+					rule:      storage.KindOperation,
+					verb:      storage.VerbUpgrade,
+					namespace: teledefaults.Namespace,
+					hasAccess: false,
+				},
+			},
+		},
 	}
 	for i, tc := range testCases {
 		var set teleservices.RoleSet
@@ -465,6 +572,30 @@ func MustCreateClusterAgent(name string, clusterName string) teleservices.Role {
 	return role
 }
 
+func MustCreateAlertNotifier(name string, clusterName string) teleservices.Role {
+	role, err := users.NewAlertNotifierRole(name, clusterName)
+	if err != nil {
+		panic(err)
+	}
+	return role
+}
+
+// clusterContext returns the access check context for the specified cluster
+func clusterContext(clusterName string) *users.Context {
+	return &users.Context{
+		Context: teleservices.Context{
+			Resource: &storage.ClusterV2{
+				Kind:    storage.KindCluster,
+				Version: teleservices.V2,
+				Metadata: teleservices.Metadata{
+					Namespace: teledefaults.Namespace,
+					Name:      clusterName,
+				},
+			},
+		},
+	}
+}
+
 func findRule(c *C, resource string, rules []teleservices.Rule, verbs ...string) *teleservices.Rule {
 	for _, rule := range rules {
 		if teleutils.SliceContainsStr(rule.Resources, resource) {
@@ -269,10 +269,17 @@ func ConvertS3Error(err error) error {
 	switch awsErr.Code() {
 	case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket:
 		return trace.NotFound(awsErr.Message())
+	case s3ErrCodeNotFound:
+		// HEAD responses have no body so the error carries no message
+		return trace.NotFound("object not found")
 	}
 	return err
 }
 
+// s3ErrCodeNotFound is the error code S3 API returns for HEAD requests
+// on missing objects
+const s3ErrCodeNotFound = "NotFound"
+
 // UnsupportedFilesystemError represents a condition when an action is being
 // performed on an unsupported filesystem, for example an attempt to create
 // a bolt database file on filesystem that does not support mmap
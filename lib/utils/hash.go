@@ -20,8 +20,11 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
 
 	"crypto/sha512"
+
+	"github.com/gravitational/trace"
 )
 
 // SHA512 half is a first half of SHA512 hash of the byte string
@@ -43,3 +46,17 @@ func MustSHA512Half(v []byte) string {
 	}
 	return h
 }
+
+// SHA512HalfFile returns the first half of SHA512 hash of the specified file contents
+func SHA512HalfFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	h := sha512.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:sha512.Size/2]), nil
+}
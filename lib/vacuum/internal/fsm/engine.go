@@ -18,6 +18,7 @@ package fsm
 
 import (
 	"context"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,8 +48,9 @@ func New(config Config) (*libfsm.FSM, error) {
 		Config: config,
 	}
 	machine, err := libfsm.New(libfsm.Config{
-		Engine: engine,
-		Runner: config.Runner,
+		Engine:      engine,
+		Runner:      config.Runner,
+		MaxParallel: config.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -114,6 +116,8 @@ type Config struct {
 	Runner libfsm.RemoteRunner
 	// Silent controls whether the process outputs messages to stdout
 	localenv.Silent
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
 }
 
 // UpdateProgress creates an appropriate progress entry in the operator
@@ -181,6 +185,7 @@ func (r *engine) ChangePhaseState(ctx context.Context, change libfsm.StateChange
 			PhaseID:     change.Phase,
 			NewState:    change.State,
 			Error:       utils.ToRawTrace(change.Error),
+			Attempt:     change.Attempt,
 			Created:     time.Now().UTC(),
 		})
 	if err != nil {
@@ -200,10 +205,17 @@ func (r *engine) GetExecutor(params libfsm.ExecutorParams, remote libfsm.Remote)
 // RunCommand executes the phase specified by params on the specified server
 // using the provided runner
 func (r *engine) RunCommand(ctx context.Context, runner libfsm.RemoteRunner, server storage.Server, params libfsm.Params) error {
-	args := []string{"plan", "execute", "--phase", params.PhaseID}
+	command := "execute"
+	if params.Rollback {
+		command = "rollback"
+	}
+	args := []string{"plan", command, "--phase", params.PhaseID}
 	if params.Force {
 		args = append(args, "--force")
 	}
+	if params.Attempt != 0 {
+		args = append(args, "--attempt", strconv.Itoa(params.Attempt))
+	}
 	return runner.Run(ctx, server, args...)
 }
 
@@ -80,7 +80,9 @@ L:
 }
 
 // RunPhase runs the specified garbage collection phase.
-func (r *Collector) RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error {
+// A non-zero attempt is the number of the attempt assigned by the node
+// that dispatched the phase to this node
+func (r *Collector) RunPhase(ctx context.Context, phase string, attempt int, phaseTimeout time.Duration, force bool) error {
 	if phase == libfsm.RootPhase {
 		return trace.Wrap(r.Run(ctx, force))
 	}
@@ -100,9 +102,27 @@ func (r *Collector) RunPhase(ctx context.Context, phase string, phaseTimeout tim
 		PhaseID:  phase,
 		Progress: progress,
 		Force:    force,
+		Attempt:  attempt,
 	}))
 }
 
+// SimulatePhase describes the specified garbage collection phase without executing it.
+func (r *Collector) SimulatePhase(ctx context.Context, phase string, force bool) (*libfsm.Simulation, error) {
+	machine, err := r.init()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	sim, err := machine.SimulatePhase(ctx, libfsm.Params{
+		PhaseID: phase,
+		Force:   force,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return sim, nil
+}
+
 // Create creates the garbage collection operation but does not start it.
 func (r *Collector) Create(ctx context.Context) error {
 	_, err := r.init()
@@ -130,6 +150,7 @@ func (r *Collector) init() (*libfsm.FSM, error) {
 		RuntimePath:   r.RuntimePath,
 		Runner:        r.Runner,
 		Silent:        r.Silent,
+		MaxParallel:   r.MaxParallel,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -232,6 +253,8 @@ type Config struct {
 	log.FieldLogger
 	// Silent controls whether the process outputs messages to stdout
 	localenv.Silent
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
 }
 
 type Collector struct {
@@ -0,0 +1,414 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	rpcclient "github.com/gravitational/gravity/lib/rpc/client"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/docker/docker/pkg/term"
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// rpcAgentShell opens an interactive session on the specified node
+// using the agent running on the node.
+// The session is recorded to the system log and to the log
+// of the operation in progress. The input is only recorded if recordInput
+// is set since it can contain secrets typed at prompts
+func rpcAgentShell(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, node, operationID string, args []string, recordInput bool) error {
+	if len(args) == 0 {
+		args = []string{"/bin/bash", "-l"}
+	}
+	op, err := getLastOperation(localEnv, updateEnv, joinEnv, operationID)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	server, err := findShellServer(op, node)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	creds, err := fsm.GetClientCredentials()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clt, err := fsm.NewAgentRunner(creds).GetClient(ctx, server.AdvertiseIP)
+	if err != nil {
+		return trace.Wrap(err, "failed to connect to the agent on %v, "+
+			"make sure the agent is running on the node", node)
+	}
+
+	var operationLog *fsm.Logger
+	if op != nil {
+		operationLog, err = newOperationLogger(localEnv, *op, server)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to set up operation log recording.")
+			localEnv.Println("Warning: the session will only be recorded to the system log.")
+		}
+	}
+	recorder := newSessionRecorder(logrus.WithFields(logrus.Fields{
+		"node":    server.AdvertiseIP,
+		"session": args,
+	}), operationLog)
+	defer recorder.Close()
+	recorder.record(fmt.Sprintf("Interactive session %q started on %v.", args, server.AdvertiseIP))
+	defer recorder.record("Interactive session completed.")
+	stdout := recorder.newStream(sessionOutputPrefix, outputLineSeparators)
+	defer stdout.Close()
+
+	config := rpcclient.ExecConfig{
+		Args:    args,
+		Term:    os.Getenv("TERM"),
+		Stdin:   os.Stdin,
+		Stdout:  io.MultiWriter(os.Stdout, stdout),
+		Signals: forwardSignals(ctx),
+	}
+	if recordInput {
+		stdin := recorder.newStream(sessionInputPrefix, inputLineSeparators)
+		defer stdin.Close()
+		config.Stdin = io.TeeReader(os.Stdin, stdin)
+	} else {
+		recorder.record("Session input is not recorded.")
+	}
+	if term.IsTerminal(os.Stdin.Fd()) {
+		state, err := term.SetRawTerminal(os.Stdin.Fd())
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer term.RestoreTerminal(os.Stdin.Fd(), state)
+		if size, err := term.GetWinsize(os.Stdout.Fd()); err == nil {
+			config.Size = rpcclient.WindowSize{Rows: uint32(size.Height), Cols: uint32(size.Width)}
+		}
+		config.Resize = watchWindowSize(ctx)
+	}
+	exitCode, err := clt.Exec(ctx, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if exitCode != 0 {
+		return trace.BadParameter("session exited with code %v", exitCode)
+	}
+	return nil
+}
+
+// findShellServer returns the server of the operation with the specified
+// hostname or address. Without the operation, the node is treated as the address
+func findShellServer(op *ops.SiteOperation, node string) (*storage.Server, error) {
+	if op != nil {
+		for _, server := range op.Servers {
+			if server.Hostname == node || server.AdvertiseIP == node {
+				return &server, nil
+			}
+		}
+	}
+	if net.ParseIP(node) == nil {
+		return nil, trace.NotFound("node %q not found, specify the node address", node)
+	}
+	return &storage.Server{AdvertiseIP: node}, nil
+}
+
+// newOperationLogger returns a new logger that writes to the log
+// of the specified operation on behalf of the specified server
+func newOperationLogger(localEnv *localenv.LocalEnvironment, op ops.SiteOperation, server *storage.Server) (*fsm.Logger, error) {
+	var operator ops.Operator
+	if op.Type == ops.OperationInstall && !op.IsCompleted() {
+		wizardEnv, err := localenv.NewRemoteEnvironment()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if wizardEnv.Operator == nil {
+			return nil, trace.NotFound("no installer is running")
+		}
+		operator = wizardEnv.Operator
+	} else {
+		clusterOperator, err := localEnv.SiteOperator()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		operator = clusterOperator
+	}
+	// only submit the session to the operation log
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	return &fsm.Logger{
+		FieldLogger: logger,
+		Key:         op.Key(),
+		Operator:    operator,
+		Server:      server,
+	}, nil
+}
+
+// newSessionRecorder returns a new recorder that records the session
+// to the system log with the specified logger and, optionally,
+// to the operation log
+func newSessionRecorder(log logrus.FieldLogger, operationLog *fsm.Logger) *sessionRecorder {
+	r := &sessionRecorder{
+		log:          log,
+		operationLog: operationLog,
+		lines:        make(chan string, defaults.RPCAgentSessionRecordBacklog),
+		done:         make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// sessionRecorder records the session line by line.
+// The lines are submitted to the operation log in batches in the background
+// so that the session is not slowed down by the recording
+type sessionRecorder struct {
+	log          logrus.FieldLogger
+	operationLog *fsm.Logger
+	done         chan struct{}
+	// mu guards lines which are closed when the recorder is closed.
+	// The input might still be read after the session has completed
+	mu     sync.Mutex
+	lines  chan string
+	closed bool
+	// dropped counts the lines dropped because the recording
+	// could not keep up with the session
+	dropped int64
+}
+
+// newStream returns a new writer that records the lines of a session stream
+// prefixing them with prefix. Any of separators ends a line
+func (r *sessionRecorder) newStream(prefix, separators string) *sessionStream {
+	return &sessionStream{
+		recorder:   r,
+		prefix:     prefix,
+		separators: separators,
+	}
+}
+
+// record queues the line for recording without blocking
+func (r *sessionRecorder) record(line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.lines <- line:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// Close records the queued lines and stops the recorder
+func (r *sessionRecorder) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.lines)
+	}
+	r.mu.Unlock()
+	<-r.done
+	if dropped := atomic.LoadInt64(&r.dropped); dropped != 0 {
+		r.log.Warnf("Dropped %v lines of the session.", dropped)
+	}
+	return nil
+}
+
+func (r *sessionRecorder) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(defaults.RPCAgentSessionRecordInterval)
+	defer ticker.Stop()
+	var batch []string
+	for {
+		select {
+		case line, ok := <-r.lines:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			r.log.Info(line)
+			batch = append(batch, line)
+			if len(batch) >= defaults.RPCAgentSessionRecordBatchSize {
+				r.flush(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = nil
+		}
+	}
+}
+
+// flush submits the batch of lines to the operation log as a single entry
+func (r *sessionRecorder) flush(batch []string) {
+	if len(batch) == 0 || r.operationLog == nil {
+		return
+	}
+	r.operationLog.Info(strings.Join(batch, "\n"))
+}
+
+// sessionStream records the lines of a session stream
+// with the terminal control sequences removed
+type sessionStream struct {
+	recorder   *sessionRecorder
+	prefix     string
+	separators string
+	// mu guards buf which is written from the session input goroutine
+	// and flushed when the session completes
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write records the complete lines of the stream
+func (r *sessionStream) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf.Write(p)
+	for {
+		i := bytes.IndexAny(r.buf.Bytes(), r.separators)
+		if i < 0 {
+			break
+		}
+		line := r.buf.Next(i + 1)
+		r.recordLine(line[:i])
+	}
+	return len(p), nil
+}
+
+// Close records the incomplete line
+func (r *sessionStream) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.buf.Len() != 0 {
+		r.recordLine(r.buf.Bytes())
+		r.buf.Reset()
+	}
+	return nil
+}
+
+func (r *sessionStream) recordLine(line []byte) {
+	line = stripControlSequences(line)
+	if len(line) == 0 {
+		return
+	}
+	r.recorder.record(r.prefix + string(line))
+}
+
+// stripControlSequences removes the terminal escape sequences and
+// control characters from the line applying backspaces
+func stripControlSequences(line []byte) []byte {
+	line = escapeSequences.ReplaceAll(line, nil)
+	out := make([]byte, 0, len(line))
+	for _, b := range line {
+		switch {
+		case b == '\b' || b == 0x7f:
+			if len(out) != 0 {
+				out = out[:len(out)-1]
+			}
+		case b == '\t' || b >= 0x20:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// escapeSequences matches the CSI and OSC terminal sequences
+// and the other escape sequences
+var escapeSequences = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)|\x1b[ -/]*[0-~]`)
+
+const (
+	// sessionInputPrefix prefixes the recorded session input
+	sessionInputPrefix = "> "
+	// sessionOutputPrefix prefixes the recorded session output
+	sessionOutputPrefix = ""
+	// inputLineSeparators end the lines of the session input.
+	// Terminals in raw mode send a carriage return on enter
+	inputLineSeparators = "\r\n"
+	// outputLineSeparators end the lines of the session output
+	outputLineSeparators = "\n"
+)
+
+// watchWindowSize returns a channel that receives the terminal window
+// size changes
+func watchWindowSize(ctx context.Context) <-chan rpcclient.WindowSize {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	resize := make(chan rpcclient.WindowSize)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-signals:
+				size, err := term.GetWinsize(os.Stdout.Fd())
+				if err != nil {
+					continue
+				}
+				select {
+				case resize <- rpcclient.WindowSize{Rows: uint32(size.Height), Cols: uint32(size.Width)}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return resize
+}
+
+// forwardSignals returns a channel that receives the names of the
+// termination signals for the remote command
+func forwardSignals(ctx context.Context) <-chan string {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGTERM)
+	names := make(chan string)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case sig := <-signals:
+				name := "SIGTERM"
+				if sig == syscall.SIGHUP {
+					name = "SIGHUP"
+				}
+				select {
+				case names <- name:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return names
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/ops/monitoring"
+
+	"github.com/gravitational/trace"
+)
+
+// testAlert evaluates the specified alert against the metrics
+// collected during the specified time period
+func testAlert(env *localenv.LocalEnvironment, name string, since time.Duration, format constants.Format) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	result, err := operator.TestAlert(context.TODO(), ops.TestAlertRequest{
+		AccountID:  cluster.AccountID,
+		SiteDomain: cluster.Domain,
+		Name:       name,
+		Since:      since,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch format {
+	case constants.EncodingText:
+		printAlertEvaluation(os.Stdout, name, *result)
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	default:
+		return trace.BadParameter("unknown output format: %s", format)
+	}
+	return nil
+}
+
+func printAlertEvaluation(out io.Writer, name string, result monitoring.AlertEvaluation) {
+	if len(result.Transitions) == 0 {
+		fmt.Fprintf(out, "Alert %v would not have fired between %v and %v (%v points evaluated).\n",
+			name, result.From.Format(constants.HumanDateFormat),
+			result.To.Format(constants.HumanDateFormat), result.Points)
+	} else {
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Time\tLevel\tTags\tValues\n")
+		fmt.Fprintf(w, "----\t-----\t----\t------\n")
+		for _, transition := range result.Transitions {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n",
+				transition.Time.Format(constants.HumanDateFormat),
+				transition.Level,
+				formatKeyValues(transition.Tags),
+				formatKeyValues(transition.Fields))
+		}
+		w.Flush()
+		fmt.Fprintf(out, "\nAlert %v would have changed level %v times between %v and %v (%v points evaluated).\n",
+			name, len(result.Transitions), result.From.Format(constants.HumanDateFormat),
+			result.To.Format(constants.HumanDateFormat), result.Points)
+	}
+	for _, note := range result.Notes {
+		fmt.Fprintf(out, "Note: %v\n", note)
+	}
+}
+
+// formatKeyValues returns the map as a sorted list of key=value pairs
+func formatKeyValues(values interface{}) string {
+	var pairs []string
+	switch values := values.(type) {
+	case map[string]string:
+		for key, value := range values {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", key, value))
+		}
+	case map[string]interface{}:
+		for key, value := range values {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", key, value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
@@ -28,6 +28,7 @@ import (
 
 	appservice "github.com/gravitational/gravity/lib/app"
 	"github.com/gravitational/gravity/lib/app/resources"
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/archive"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -90,6 +91,24 @@ func statusApp(env *localenv.LocalEnvironment, appPackage loc.Locator, portalURL
 	return nil
 }
 
+// appSBOM outputs the software bill of materials of the specified application
+func appSBOM(env *localenv.LocalEnvironment, appPackage loc.Locator, format, opsCenterURL string) error {
+	apps, err := env.AppService(opsCenterURL, localenv.AppConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	doc, err := apps.GetAppSBOM(appPackage)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := sbom.Encode(*doc, format)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = os.Stdout.Write(data)
+	return trace.Wrap(err)
+}
+
 // listApps lists installed applications
 func listApps(env *localenv.LocalEnvironment, repository, appType string, showHidden bool, opsCenterURL string) error {
 	apps, err := env.AppService(opsCenterURL, localenv.AppConfig{})
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitational/gravity/lib/constants"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/trace"
+)
+
+// auditListConfig defines the audit log query of 'gravity audit ls'
+type auditListConfig struct {
+	// since limits the events to the specified time period
+	since time.Duration
+	// user limits the events to those triggered by the user
+	user string
+	// code limits the events to those with the event code
+	code string
+	// limit is the maximum number of events to display
+	limit int
+	// format is the output format
+	format constants.Format
+}
+
+func listAuditEvents(env *localenv.LocalEnvironment, config auditListConfig) error {
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	now := time.Now().UTC()
+	result, err := operator.GetAuditEvents(context.TODO(), ops.AuditLogQuery{
+		SiteKey: cluster.Key(),
+		From:    now.Add(-config.since),
+		To:      now,
+		User:    config.user,
+		Code:    config.code,
+		Limit:   config.limit,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch config.format {
+	case constants.EncodingText:
+		printAuditEvents(os.Stdout, result.Events)
+		if result.Truncated {
+			env.Println("Warning: the audit log holds more events than can be searched at once, " +
+				"some events in the time range might be missing.")
+		}
+	case constants.EncodingJSON:
+		bytes, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(bytes))
+	default:
+		return trace.BadParameter("unknown output format: %s", config.format)
+	}
+	return nil
+}
+
+func printAuditEvents(out io.Writer, result []events.EventFields) {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 1, '\t', 0)
+	fmt.Fprintf(w, "Time\tCode\tEvent\tUser\tDetails\n")
+	fmt.Fprintf(w, "----\t----\t-----\t----\t-------\n")
+	for _, event := range result {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n",
+			event.GetTimestamp().Format(constants.HumanDateFormat),
+			event.GetString(events.EventCode),
+			event.GetString(events.EventType),
+			event.GetString(events.EventUser),
+			formatAuditDetails(event))
+	}
+	w.Flush()
+}
+
+// formatAuditDetails returns the event fields not displayed
+// in the separate columns as a sorted list of key=value pairs
+func formatAuditDetails(event events.EventFields) string {
+	var details []string
+	for key, value := range event {
+		switch key {
+		case events.EventTime, events.EventCode, events.EventType, events.EventUser:
+			continue
+		}
+		details = append(details, fmt.Sprintf("%v=%v", key, value))
+	}
+	sort.Strings(details)
+	return strings.Join(details, " ")
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
+	"github.com/gravitational/gravity/lib/localenv"
+	"github.com/gravitational/gravity/lib/ops"
+	"github.com/gravitational/gravity/lib/rpc"
+	"github.com/gravitational/gravity/lib/storage"
+
+	"github.com/gravitational/trace"
+)
+
+// exportOperationPlan saves the checkpoint of the specified operation
+// as a signed bundle at path.
+// If path is "-", the bundle is written to stdout
+func exportOperationPlan(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, operationID, path string) error {
+	op, err := getLastOperation(localEnv, updateEnv, joinEnv, operationID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	backend, err := getCheckpointSourceBackend(localEnv, updateEnv, joinEnv, *op)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	checkpoint, err := fsm.NewCheckpoint(backend, op.SiteDomain, op.ID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(checkpoint.Progress) == 0 {
+		// Progress entries are only recorded in the cluster state
+		checkpoint.Progress, err = getClusterProgressEntries(localEnv, *op)
+		if err != nil {
+			log.WithError(err).Warn("Failed to query operation progress.")
+		}
+	}
+	creds, err := getCheckpointCredentials()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, defaults.PrivateFileMask)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	err = fsm.WriteCheckpoint(w, *checkpoint, creds)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if path != "-" {
+		localEnv.Printf("Operation %v exported to %v.\n", op.ID, path)
+	}
+	return nil
+}
+
+// importOperationPlan restores the operation from the checkpoint bundle at path
+// so it can be resumed on this node.
+// If path is "-", the bundle is read from stdin
+func importOperationPlan(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, path string, skipVerify bool) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	var verifier fsm.CheckpointVerifier
+	if !skipVerify {
+		creds, err := getCheckpointCredentials()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		verifier = creds
+	}
+	checkpoint, err := fsm.ReadCheckpoint(r, verifier)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	op := checkpoint.Operation
+	switch op.Type {
+	case ops.OperationInstall:
+		return trace.BadParameter("install operation plan cannot be imported")
+	case ops.OperationExpand:
+		if joinEnv == nil {
+			return trace.BadParameter("join environment is not available")
+		}
+		err = fsm.ImportCheckpoint(joinEnv.Backend, *checkpoint)
+	case ops.OperationUpdate, ops.OperationUpdateRuntimeEnviron, ops.OperationUpdateConfig:
+		if updateEnv == nil {
+			return trace.BadParameter("update environment is not available")
+		}
+		err = fsm.ImportCheckpoint(updateEnv.Backend, *checkpoint)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// The cluster state will be reconciled with the local plan once
+		// the operation is resumed so only attempt to update it here
+		err = importCheckpointToCluster(localEnv, *checkpoint)
+		if err != nil {
+			log.WithError(err).Warn("Failed to import operation plan into cluster state.")
+			err = nil
+		}
+	default:
+		err = importCheckpointToCluster(localEnv, *checkpoint)
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	localEnv.Printf("Operation %v imported, use 'gravity plan resume --operation-id=%v' to resume.\n",
+		op.ID, op.ID)
+	return nil
+}
+
+func importCheckpointToCluster(localEnv *localenv.LocalEnvironment, checkpoint fsm.Checkpoint) error {
+	clusterEnv, err := localEnv.NewClusterEnvironment(localenv.WithEtcdTimeout(1 * time.Second))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(fsm.ImportCheckpoint(clusterEnv.Backend, checkpoint))
+}
+
+// getCheckpointSourceBackend returns the backend with the most recent state
+// of the specified operation
+func getCheckpointSourceBackend(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, op ops.SiteOperation) (storage.Backend, error) {
+	var localBackend storage.Backend
+	switch op.Type {
+	case ops.OperationInstall:
+		return nil, trace.BadParameter("install operation plan cannot be exported")
+	case ops.OperationExpand:
+		if joinEnv != nil {
+			localBackend = joinEnv.Backend
+		}
+	case ops.OperationUpdate, ops.OperationUpdateRuntimeEnviron, ops.OperationUpdateConfig:
+		if updateEnv != nil {
+			localBackend = updateEnv.Backend
+		}
+	}
+	if localBackend != nil {
+		_, err := localBackend.GetOperationPlan(op.SiteDomain, op.ID)
+		if err == nil {
+			return localBackend, nil
+		}
+		if !trace.IsNotFound(err) {
+			return nil, trace.Wrap(err)
+		}
+	}
+	clusterEnv, err := localEnv.NewClusterEnvironment()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return clusterEnv.Backend, nil
+}
+
+func getClusterProgressEntries(localEnv *localenv.LocalEnvironment, op ops.SiteOperation) ([]storage.ProgressEntry, error) {
+	clusterEnv, err := localEnv.NewClusterEnvironment(localenv.WithEtcdTimeout(1 * time.Second))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	entries, err := clusterEnv.Backend.GetProgressEntries(op.SiteDomain, op.ID)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return entries, nil
+}
+
+// getCheckpointCredentials returns the credentials to sign and verify
+// checkpoint bundles with
+func getCheckpointCredentials() (*rpc.CheckpointCredentials, error) {
+	secretsDir, err := fsm.AgentSecretsDir()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	creds, err := rpc.LoadCheckpointCredentials(secretsDir)
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("agent credentials not found in %v. "+
+				"Operation plan can only be exported or imported on a cluster node with "+
+				"update agent credentials", secretsDir)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return creds, nil
+}
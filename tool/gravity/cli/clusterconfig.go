@@ -83,27 +83,34 @@ func newConfigUpdater(ctx context.Context, localEnv, updateEnv *localenv.LocalEn
 }
 
 func executeConfigPhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getConfigUpdater(env, updateEnv, operation)
+	updater, err := getConfigUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.DryRun {
+		return trace.Wrap(simulateUpdatePhase(updater, params))
+	}
+	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func rollbackConfigPhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getConfigUpdater(env, updateEnv, operation)
+	updater, err := getConfigUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.PhaseID == libfsm.RootPhase {
+		err = updater.RollbackPlan(context.TODO(), params.Timeout, params.Force)
+		return trace.Wrap(err)
+	}
+	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func completeConfigPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
-	updater, err := getConfigUpdater(env, updateEnv, operation)
+	updater, err := getConfigUpdater(env, updateEnv, operation, PhaseParams{})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -111,7 +118,7 @@ func completeConfigPlan(env, updateEnv *localenv.LocalEnvironment, operation ops
 	return trace.Wrap(updater.Complete(nil))
 }
 
-func getConfigUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) (*update.Updater, error) {
+func getConfigUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, params PhaseParams) (*update.Updater, error) {
 	clusterEnv, err := localEnv.NewClusterEnvironment()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -132,6 +139,8 @@ func getConfigUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation
 			LocalBackend: updateEnv.Backend,
 			Runner:       runner,
 			Silent:       localEnv.Silent,
+			MaxParallel:  params.MaxParallel,
+			AutoRollback: params.AutoRollback,
 			FieldLogger: logrus.WithFields(logrus.Fields{
 				trace.Component: "update:clusterconfig",
 				"operation":     operation,
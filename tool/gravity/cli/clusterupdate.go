@@ -58,10 +58,10 @@ func updateTrigger(
 	localEnv *localenv.LocalEnvironment,
 	updateEnv *localenv.LocalEnvironment,
 	updatePackage string,
-	manual, block, noValidateVersion bool,
+	manual, block, noValidateVersion, autoRollback bool,
 ) error {
 	ctx := context.TODO()
-	updater, err := newClusterUpdater(ctx, localEnv, updateEnv, updatePackage, manual, block, noValidateVersion)
+	updater, err := newClusterUpdater(ctx, localEnv, updateEnv, updatePackage, manual, block, noValidateVersion, autoRollback)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -82,12 +82,13 @@ func newClusterUpdater(
 	ctx context.Context,
 	localEnv, updateEnv *localenv.LocalEnvironment,
 	updatePackage string,
-	manual, block, noValidateVersion bool,
+	manual, block, noValidateVersion, autoRollback bool,
 ) (updater, error) {
 	unattended := !manual && !block
 	init := &clusterInitializer{
 		updatePackage: updatePackage,
 		unattended:    unattended,
+		autoRollback:  autoRollback,
 	}
 	updater, err := newUpdater(ctx, localEnv, updateEnv, init)
 	if err != nil {
@@ -102,28 +103,82 @@ func newClusterUpdater(
 	return updater, nil
 }
 
+// simulateClusterUpdate describes the update of the cluster to the specified
+// application package without creating the update operation
+func simulateClusterUpdate(localEnv, updateEnv *localenv.LocalEnvironment, updatePackage string, format constants.Format) error {
+	ctx := context.TODO()
+	clusterEnv, err := localEnv.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if clusterEnv.Client == nil {
+		return trace.BadParameter("this operation can only be executed on one of the master nodes")
+	}
+	operator := clusterEnv.Operator
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	init := &clusterInitializer{updatePackage: updatePackage}
+	err = init.validatePreconditions(localEnv, operator, *cluster)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	plan, operation, err := clusterupdate.NewSimulatedOperationPlan(localEnv, clusterEnv, *cluster, init.updateLoc)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	sim, err := clusterupdate.Simulate(ctx, clusterupdate.Config{
+		Config: update.Config{
+			Operation:    operation,
+			Operator:     operator,
+			Backend:      clusterEnv.Backend,
+			LocalBackend: updateEnv.Backend,
+			Silent:       localEnv.Silent,
+		},
+		Apps:              clusterEnv.Apps,
+		Client:            clusterEnv.Client,
+		Packages:          clusterEnv.Packages,
+		ClusterPackages:   clusterEnv.ClusterPackages,
+		HostLocalBackend:  localEnv.Backend,
+		HostLocalPackages: localEnv.Packages,
+		Users:             clusterEnv.Users,
+	}, *plan)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(outputSimulation(*sim, format))
+}
+
 func executeUpdatePhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getClusterUpdater(env, updateEnv, operation, params.SkipVersionCheck)
+	updater, err := getClusterUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.DryRun {
+		return trace.Wrap(simulateUpdatePhase(updater, params))
+	}
+	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func rollbackUpdatePhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getClusterUpdater(env, updateEnv, operation, params.SkipVersionCheck)
+	updater, err := getClusterUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.PhaseID == libfsm.RootPhase {
+		err = updater.RollbackPlan(context.TODO(), params.Timeout, params.Force)
+		return trace.Wrap(err)
+	}
+	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func completeUpdatePlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
-	updater, err := getClusterUpdater(env, updateEnv, operation, true)
+	updater, err := getClusterUpdater(env, updateEnv, operation, PhaseParams{SkipVersionCheck: true})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -131,7 +186,7 @@ func completeUpdatePlan(env, updateEnv *localenv.LocalEnvironment, operation ops
 	return trace.Wrap(updater.Complete(nil))
 }
 
-func getClusterUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, noValidateVersion bool) (*update.Updater, error) {
+func getClusterUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, params PhaseParams) (*update.Updater, error) {
 	clusterEnv, err := localEnv.NewClusterEnvironment()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -152,6 +207,8 @@ func getClusterUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation
 			LocalBackend: updateEnv.Backend,
 			Runner:       runner,
 			Silent:       localEnv.Silent,
+			MaxParallel:  params.MaxParallel,
+			AutoRollback: params.AutoRollback,
 		},
 		Apps:              clusterEnv.Apps,
 		Client:            clusterEnv.Client,
@@ -164,7 +221,7 @@ func getClusterUpdater(localEnv, updateEnv *localenv.LocalEnvironment, operation
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if noValidateVersion {
+	if params.SkipVersionCheck {
 		return updater, nil
 	}
 	if err := validateBinaryVersion(updater); err != nil {
@@ -211,7 +268,7 @@ func (r clusterInitializer) newOperationPlan(
 	return plan, nil
 }
 
-func (clusterInitializer) newUpdater(
+func (r clusterInitializer) newUpdater(
 	ctx context.Context,
 	operator ops.Operator,
 	operation ops.SiteOperation,
@@ -226,6 +283,7 @@ func (clusterInitializer) newUpdater(
 			Backend:      clusterEnv.Backend,
 			LocalBackend: updateEnv.Backend,
 			Runner:       runner,
+			AutoRollback: r.autoRollback,
 		},
 		HostLocalBackend:  localEnv.Backend,
 		HostLocalPackages: localEnv.Packages,
@@ -249,6 +307,7 @@ type clusterInitializer struct {
 	updateLoc     loc.Locator
 	updatePackage string
 	unattended    bool
+	autoRollback  bool
 }
 
 const (
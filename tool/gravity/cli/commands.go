@@ -80,6 +80,10 @@ type Application struct {
 	PlanResumeCmd PlanResumeCmd
 	// PlanCompleteCmd completes the operation plan
 	PlanCompleteCmd PlanCompleteCmd
+	// PlanExportCmd exports the operation plan checkpoint
+	PlanExportCmd PlanExportCmd
+	// PlanImportCmd imports the operation plan checkpoint
+	PlanImportCmd PlanImportCmd
 	// UpdateCmd combines app update related commands
 	UpdateCmd UpdateCmd
 	// UpdateCheckCmd checks if a new app version is available
@@ -138,6 +142,8 @@ type Application struct {
 	AppPackageUninstallCmd AppPackageUninstallCmd
 	// AppStatusCmd output app status
 	AppStatusCmd AppStatusCmd
+	// AppSBOMCmd outputs the software bill of materials of an app
+	AppSBOMCmd AppSBOMCmd
 	// AppPullCmd pulls app from specified cluster
 	AppPullCmd AppPullCmd
 	// AppPushCmd pushes app to specified cluster
@@ -170,6 +176,8 @@ type Application struct {
 	PackExportCmd PackExportCmd
 	// PackListCmd lists packages
 	PackListCmd PackListCmd
+	// PackStatsCmd displays package storage deduplication statistics
+	PackStatsCmd PackStatsCmd
 	// PackDeleteCmd deletes specified package
 	PackDeleteCmd PackDeleteCmd
 	// PackConfigureCmd configures package
@@ -204,6 +212,14 @@ type Application struct {
 	APIKeyDeleteCmd APIKeyDeleteCmd
 	// ReportCmd generates cluster debug report
 	ReportCmd ReportCmd
+	// AuditCmd combines audit log related subcommands
+	AuditCmd AuditCmd
+	// AuditListCmd lists audit log events
+	AuditListCmd AuditListCmd
+	// AlertCmd combines monitoring alert related subcommands
+	AlertCmd AlertCmd
+	// AlertTestCmd evaluates a monitoring alert against historical metrics
+	AlertTestCmd AlertTestCmd
 	// SiteCmd combines cluster related subcommands
 	SiteCmd SiteCmd
 	// SiteListCmd lists all clusters
@@ -232,6 +248,10 @@ type Application struct {
 	RPCAgentInstallCmd RPCAgentInstallCmd
 	// RPCAgentRunCmd runs RPC agent
 	RPCAgentRunCmd RPCAgentRunCmd
+	// RPCAgentShellCmd opens an interactive shell on a node with a running RPC agent
+	RPCAgentShellCmd RPCAgentShellCmd
+	// RPCAgentRotateCredentialsCmd replaces the credentials of RPC agents
+	RPCAgentRotateCredentialsCmd RPCAgentRotateCredentialsCmd
 	// SystemCmd combines system subcommands
 	SystemCmd SystemCmd
 	// SystemRotateCertsCmd renews cluster certificates on local node
@@ -350,6 +370,13 @@ type InstallCmd struct {
 	Role *string
 	// ResourcesPath is the path to user defined Kubernetes resources
 	ResourcesPath *string
+	// ClusterSpecPath is the path to the cluster spec with all cluster nodes
+	ClusterSpecPath *string
+	// VerifyKeyPath is the path to the public key to verify installer packages with
+	VerifyKeyPath *string
+	// VerifyTarballPath is the path to the installer tarball to verify
+	// the detached signature of
+	VerifyTarballPath *string
 	// Wizard launches UI installer mode
 	Wizard *bool
 	// Mode is installation mode
@@ -480,6 +507,8 @@ type PlanDisplayCmd struct {
 	*kingpin.CmdClause
 	// Output is output format
 	Output *constants.Format
+	// Format is the alias for Output
+	Format *constants.Format
 }
 
 // PlanExecuteCmd executes a phase of an active operation
@@ -491,6 +520,15 @@ type PlanExecuteCmd struct {
 	Force *bool
 	// PhaseTimeout is the execution timeout
 	PhaseTimeout *time.Duration
+	// DryRun describes the phase actions without executing them
+	DryRun *bool
+	// Output specifies the output format for the dry run
+	Output *constants.Format
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel *int
+	// Attempt is the number of the attempt assigned by the node
+	// that dispatched the phase to this node
+	Attempt *int
 }
 
 // PlanRollbackCmd rolls back a phase of an active operation
@@ -502,6 +540,11 @@ type PlanRollbackCmd struct {
 	Force *bool
 	// PhaseTimeout is the rollback timeout
 	PhaseTimeout *time.Duration
+	// All rolls back all phases of the operation
+	All *bool
+	// Attempt is the number of the attempt assigned by the node
+	// that dispatched the phase to this node
+	Attempt *int
 }
 
 // PlanResumeCmd resumes active operation
@@ -511,6 +554,10 @@ type PlanResumeCmd struct {
 	Force *bool
 	// PhaseTimeout is the rollback timeout
 	PhaseTimeout *time.Duration
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel *int
+	// AutoRollback rolls back the operation automatically if it fails
+	AutoRollback *bool
 }
 
 // PlanCompleteCmd completes the operation plan
@@ -523,6 +570,22 @@ type InstallPlanCmd struct {
 	*kingpin.CmdClause
 }
 
+// PlanExportCmd exports the checkpoint of an operation
+type PlanExportCmd struct {
+	*kingpin.CmdClause
+	// Path is the path to the checkpoint bundle
+	Path *string
+}
+
+// PlanImportCmd imports the checkpoint of an operation
+type PlanImportCmd struct {
+	*kingpin.CmdClause
+	// Path is the path to the checkpoint bundle
+	Path *string
+	// SkipVerify disables verification of the checkpoint signature
+	SkipVerify *bool
+}
+
 // InstallPlanDisplayCmd displays install operation plan
 type InstallPlanDisplayCmd struct {
 	*kingpin.CmdClause
@@ -573,6 +636,11 @@ type UpdateUploadCmd struct {
 	*kingpin.CmdClause
 	// OpsCenterURL is cluster URL
 	OpsCenterURL *string
+	// VerifyKeyPath is the path to the public key to verify update packages with
+	VerifyKeyPath *string
+	// VerifyTarballPath is the path to the update tarball to verify
+	// the detached signature of
+	VerifyTarballPath *string
 }
 
 // UpdateCompleteCmd marks update operation as completed
@@ -620,6 +688,14 @@ type UpgradeCmd struct {
 	Resume *bool
 	// SkipVersionCheck suppresses version mismatch errors
 	SkipVersionCheck *bool
+	// DryRun describes the upgrade actions without executing them
+	DryRun *bool
+	// Output specifies the output format for the dry run
+	Output *constants.Format
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel *int
+	// AutoRollback rolls back the operation automatically if it fails
+	AutoRollback *bool
 }
 
 // StatusCmd displays cluster status
@@ -880,6 +956,17 @@ type AppStatusCmd struct {
 	OpsCenterURL *string
 }
 
+// AppSBOMCmd outputs the software bill of materials of an app
+type AppSBOMCmd struct {
+	*kingpin.CmdClause
+	// Locator is app locator
+	Locator *loc.Locator
+	// Format is the bill of materials format
+	Format *string
+	// OpsCenterURL is app service URL
+	OpsCenterURL *string
+}
+
 // AppPullCmd pulls app from specified cluster
 type AppPullCmd struct {
 	*kingpin.CmdClause
@@ -1044,6 +1131,13 @@ type PackListCmd struct {
 	OpsCenterURL *string
 }
 
+// PackStatsCmd displays package storage deduplication statistics
+type PackStatsCmd struct {
+	*kingpin.CmdClause
+	// OpsCenterURL is pack service URL
+	OpsCenterURL *string
+}
+
 // PackDeleteCmd deletes specified package
 type PackDeleteCmd struct {
 	*kingpin.CmdClause
@@ -1207,6 +1301,42 @@ type ReportCmd struct {
 	FilePath *string
 }
 
+// AuditCmd combines audit log related subcommands
+type AuditCmd struct {
+	*kingpin.CmdClause
+}
+
+// AuditListCmd lists audit log events
+type AuditListCmd struct {
+	*kingpin.CmdClause
+	// Since limits the events to the specified time period
+	Since *time.Duration
+	// User limits the events to those triggered by the user
+	User *string
+	// Code limits the events to those with the event code
+	Code *string
+	// Limit is the maximum number of events to display
+	Limit *int
+	// Format is the output format
+	Format *constants.Format
+}
+
+// AlertCmd combines monitoring alert related subcommands
+type AlertCmd struct {
+	*kingpin.CmdClause
+}
+
+// AlertTestCmd evaluates a monitoring alert against historical metrics
+type AlertTestCmd struct {
+	*kingpin.CmdClause
+	// Name is the name of the alert to evaluate
+	Name *string
+	// Since is the evaluated time period
+	Since *time.Duration
+	// Format is the output format
+	Format *constants.Format
+}
+
 // SiteCmd combines cluster related subcommands
 type SiteCmd struct {
 	*kingpin.CmdClause
@@ -1301,6 +1431,24 @@ type RPCAgentRunCmd struct {
 	Args *[]string
 }
 
+// RPCAgentShellCmd opens an interactive shell on a node with a running RPC agent
+type RPCAgentShellCmd struct {
+	*kingpin.CmdClause
+	// Node is the name or address of the node to open the shell on
+	Node *string
+	// OperationID is the ID of the operation to record the session to
+	OperationID *string
+	// Args is the command to run instead of the shell
+	Args *[]string
+	// RecordInput enables recording of the session input
+	RecordInput *bool
+}
+
+// RPCAgentRotateCredentialsCmd replaces the credentials of RPC agents
+type RPCAgentRotateCredentialsCmd struct {
+	*kingpin.CmdClause
+}
+
 // SystemCmd combines system subcommands
 type SystemCmd struct {
 	*kingpin.CmdClause
@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"reflect"
 
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
@@ -72,6 +73,15 @@ type InstallConfig struct {
 	Role string
 	// ResourcesPath is the additional Kubernetes resources to create
 	ResourcesPath string
+	// ClusterSpecPath is the path to the cluster spec with all cluster nodes
+	ClusterSpecPath string
+	// ClusterSpec is the cluster spec read from ClusterSpecPath
+	ClusterSpec *install.ClusterSpec
+	// VerifyKeyPath is the path to the public key to verify installer packages with
+	VerifyKeyPath string
+	// VerifyTarballPath is the path to the installer tarball to verify
+	// the detached signature of
+	VerifyTarballPath string
 	// SystemDevice is the block device to use for gravity data
 	SystemDevice string
 	// DockerDevice is the block device to use for Docker data
@@ -122,27 +132,30 @@ func NewInstallConfig(g *Application) InstallConfig {
 	}
 
 	return InstallConfig{
-		Mode:          mode,
-		Insecure:      *g.Insecure,
-		ReadStateDir:  *g.InstallCmd.Path,
-		UserLogFile:   *g.UserLogFile,
-		SystemLogFile: *g.SystemLogFile,
-		AdvertiseAddr: *g.InstallCmd.AdvertiseAddr,
-		InstallToken:  *g.InstallCmd.Token,
-		CloudProvider: *g.InstallCmd.CloudProvider,
-		SiteDomain:    *g.InstallCmd.Cluster,
-		AppPackage:    *g.InstallCmd.App,
-		Flavor:        *g.InstallCmd.Flavor,
-		Role:          *g.InstallCmd.Role,
-		ResourcesPath: *g.InstallCmd.ResourcesPath,
-		SystemDevice:  *g.InstallCmd.SystemDevice,
-		DockerDevice:  *g.InstallCmd.DockerDevice,
-		Mounts:        *g.InstallCmd.Mounts,
-		DNSHosts:      *g.InstallCmd.DNSHosts,
-		DNSZones:      *g.InstallCmd.DNSZones,
-		PodCIDR:       *g.InstallCmd.PodCIDR,
-		ServiceCIDR:   *g.InstallCmd.ServiceCIDR,
-		VxlanPort:     *g.InstallCmd.VxlanPort,
+		Mode:              mode,
+		Insecure:          *g.Insecure,
+		ReadStateDir:      *g.InstallCmd.Path,
+		UserLogFile:       *g.UserLogFile,
+		SystemLogFile:     *g.SystemLogFile,
+		AdvertiseAddr:     *g.InstallCmd.AdvertiseAddr,
+		InstallToken:      *g.InstallCmd.Token,
+		CloudProvider:     *g.InstallCmd.CloudProvider,
+		SiteDomain:        *g.InstallCmd.Cluster,
+		AppPackage:        *g.InstallCmd.App,
+		Flavor:            *g.InstallCmd.Flavor,
+		Role:              *g.InstallCmd.Role,
+		ResourcesPath:     *g.InstallCmd.ResourcesPath,
+		ClusterSpecPath:   *g.InstallCmd.ClusterSpecPath,
+		VerifyKeyPath:     *g.InstallCmd.VerifyKeyPath,
+		VerifyTarballPath: *g.InstallCmd.VerifyTarballPath,
+		SystemDevice:      *g.InstallCmd.SystemDevice,
+		DockerDevice:      *g.InstallCmd.DockerDevice,
+		Mounts:            *g.InstallCmd.Mounts,
+		DNSHosts:          *g.InstallCmd.DNSHosts,
+		DNSZones:          *g.InstallCmd.DNSZones,
+		PodCIDR:           *g.InstallCmd.PodCIDR,
+		ServiceCIDR:       *g.InstallCmd.ServiceCIDR,
+		VxlanPort:         *g.InstallCmd.VxlanPort,
 		Docker: storage.DockerConfig{
 			StorageDriver: g.InstallCmd.DockerStorageDriver.value,
 			Args:          *g.InstallCmd.DockerArgs,
@@ -198,6 +211,11 @@ func (i *InstallConfig) CheckAndSetDefaults() (err error) {
 	if i.VxlanPort == 0 {
 		i.VxlanPort = defaults.VxlanPort
 	}
+	if i.ClusterSpecPath != "" {
+		if err := i.applyClusterSpec(); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	if err := i.validateDNSConfig(); err != nil {
 		return trace.Wrap(err)
 	}
@@ -208,6 +226,72 @@ func (i *InstallConfig) CheckAndSetDefaults() (err error) {
 	return nil
 }
 
+// applyClusterSpec reads the cluster spec and configures the cluster and
+// this node from it. The flags that configure the same settings
+// must either be omitted or match the cluster spec
+func (i *InstallConfig) applyClusterSpec() error {
+	if i.Mode != constants.InstallModeCLI {
+		return trace.BadParameter("cluster spec is only supported in %v install mode",
+			constants.InstallModeCLI)
+	}
+	spec, err := install.ReadClusterSpec(i.ClusterSpecPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var node *storage.NodeSpec
+	if i.AdvertiseAddr != "" {
+		node, err = spec.FindNode(i.AdvertiseAddr)
+	} else {
+		node, err = spec.FindLocalNode()
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	mounts := make(map[string]string)
+	for name, source := range node.Mounts {
+		mounts[name] = source
+	}
+	for _, flag := range []struct{ name, value, spec string }{
+		{"cluster", i.SiteDomain, spec.Metadata.Name},
+		{"flavor", i.Flavor, spec.Spec.Flavor},
+		{"role", i.Role, node.Profile},
+		{"docker-device", i.DockerDevice, node.DockerDevice},
+		{"system-device", i.SystemDevice, node.SystemDevice},
+	} {
+		if flag.value != "" && flag.value != flag.spec {
+			return trace.BadParameter("--%v=%v does not match %q from the cluster spec",
+				flag.name, flag.value, flag.spec)
+		}
+	}
+	if len(i.Mounts) != 0 && !reflect.DeepEqual(i.Mounts, mounts) {
+		return trace.BadParameter("--mount does not match the mounts of %v from the cluster spec", node)
+	}
+	i.AdvertiseAddr = node.AdvertiseAddr
+	i.SiteDomain = spec.Metadata.Name
+	i.Flavor = spec.Spec.Flavor
+	i.Role = node.Profile
+	i.DockerDevice = node.DockerDevice
+	i.SystemDevice = node.SystemDevice
+	i.Mounts = mounts
+	if spec.Spec.PodCIDR != "" {
+		i.PodCIDR = spec.Spec.PodCIDR
+	}
+	if spec.Spec.ServiceCIDR != "" {
+		i.ServiceCIDR = spec.Spec.ServiceCIDR
+	}
+	if spec.Spec.DNS != nil {
+		if len(spec.Spec.DNS.Addrs) != 0 {
+			i.DNSConfig.Addrs = spec.Spec.DNS.Addrs
+		}
+		if spec.Spec.DNS.Port != 0 {
+			i.DNSConfig.Port = spec.Spec.DNS.Port
+		}
+	}
+	i.ClusterSpec = spec
+	log.Infof("Installing cluster %v from cluster spec %v as %v.", i.SiteDomain, i.ClusterSpecPath, node)
+	return nil
+}
+
 // GetAdvertiseAddr return the advertise address provided in the config, or
 // asks the user to choose it among the host's interfaces
 func (i *InstallConfig) GetAdvertiseAddr() (string, error) {
@@ -337,6 +421,7 @@ func (i *InstallConfig) ToInstallerConfig(env *localenv.LocalEnvironment, valida
 		SystemDevice:       i.SystemDevice,
 		DockerDevice:       i.DockerDevice,
 		Mounts:             i.Mounts,
+		ClusterSpec:        i.ClusterSpec,
 		DNSOverrides:       *dnsOverrides,
 		DNSConfig:          i.DNSConfig,
 		Mode:               i.Mode,
@@ -73,27 +73,34 @@ func newEnvironUpdater(ctx context.Context, localEnv, updateEnv *localenv.LocalE
 }
 
 func executeEnvironPhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getEnvironUpdater(env, updateEnv, operation)
+	updater, err := getEnvironUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.DryRun {
+		return trace.Wrap(simulateUpdatePhase(updater, params))
+	}
+	err = updater.RunPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func rollbackEnvironPhase(env, updateEnv *localenv.LocalEnvironment, params PhaseParams, operation ops.SiteOperation) error {
-	updater, err := getEnvironUpdater(env, updateEnv, operation)
+	updater, err := getEnvironUpdater(env, updateEnv, operation, params)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 	defer updater.Close()
-	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.PhaseID == libfsm.RootPhase {
+		err = updater.RollbackPlan(context.TODO(), params.Timeout, params.Force)
+		return trace.Wrap(err)
+	}
+	err = updater.RollbackPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
 func completeEnvironPlan(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) error {
-	updater, err := getEnvironUpdater(env, updateEnv, operation)
+	updater, err := getEnvironUpdater(env, updateEnv, operation, PhaseParams{})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -101,7 +108,7 @@ func completeEnvironPlan(env, updateEnv *localenv.LocalEnvironment, operation op
 	return trace.Wrap(updater.Complete(nil))
 }
 
-func getEnvironUpdater(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation) (*update.Updater, error) {
+func getEnvironUpdater(env, updateEnv *localenv.LocalEnvironment, operation ops.SiteOperation, params PhaseParams) (*update.Updater, error) {
 	clusterEnv, err := env.NewClusterEnvironment()
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -122,6 +129,8 @@ func getEnvironUpdater(env, updateEnv *localenv.LocalEnvironment, operation ops.
 			LocalBackend: updateEnv.Backend,
 			Silent:       env.Silent,
 			Runner:       runner,
+			MaxParallel:  params.MaxParallel,
+			AutoRollback: params.AutoRollback,
 			FieldLogger: logrus.WithFields(logrus.Fields{
 				trace.Component: "update:environ",
 				"operation":     operation,
@@ -258,12 +258,21 @@ func executeGarbageCollectPhase(env *localenv.LocalEnvironment, params PhasePara
 		RuntimePath:   runtimePath,
 		Silent:        env.Silent,
 		Runner:        runner,
+		MaxParallel:   params.MaxParallel,
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	err = collector.RunPhase(context.TODO(), params.PhaseID, params.Timeout, params.Force)
+	if params.DryRun {
+		sim, err := collector.SimulatePhase(context.TODO(), params.PhaseID, params.Force)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(outputSimulation(*sim, params.Output))
+	}
+
+	err = collector.RunPhase(context.TODO(), params.PhaseID, params.Attempt, params.Timeout, params.Force)
 	return trace.Wrap(err)
 }
 
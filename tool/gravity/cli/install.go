@@ -19,6 +19,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -34,8 +36,10 @@ import (
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/ops/resources"
 	"github.com/gravitational/gravity/lib/ops/resources/gravity"
+	"github.com/gravitational/gravity/lib/pack"
 	pb "github.com/gravitational/gravity/lib/rpc/proto"
 	rpcserver "github.com/gravitational/gravity/lib/rpc/server"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/systeminfo"
 	"github.com/gravitational/gravity/lib/systemservice"
@@ -59,6 +63,16 @@ func startInstall(env *localenv.LocalEnvironment, i InstallConfig) error {
 		return trace.Wrap(err)
 	}
 
+	if i.VerifyKeyPath != "" {
+		env.PrintStep("Verifying installer signatures")
+		err = verifyInstaller(i.ReadStateDir, i.VerifyTarballPath, i.VerifyKeyPath)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	} else if i.VerifyTarballPath != "" {
+		return trace.BadParameter("--verify-tarball requires --verify-key")
+	}
+
 	installerConfig, err := i.ToInstallerConfig(env, resources.ValidateFunc(gravity.Validate))
 	if err != nil {
 		return trace.Wrap(err)
@@ -94,6 +108,61 @@ func startInstall(env *localenv.LocalEnvironment, i InstallConfig) error {
 	return trace.Wrap(err)
 }
 
+// verifyInstaller verifies the signatures of the installer in stateDir
+// with the public key from verifyKeyPath.
+// If tarballPath is set, the detached signature of the installer tarball
+// is verified as well
+func verifyInstaller(stateDir, tarballPath, verifyKeyPath string) error {
+	verifier, err := signing.ReadVerifier(verifyKeyPath)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if tarballPath != "" {
+		if err := verifyTarball(tarballPath, verifier); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	installerEnv, err := localenv.NewLocalEnvironment(localenv.LocalEnvironmentArgs{
+		StateDir: stateDir,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer installerEnv.Close()
+	return pack.ForeachPackage(installerEnv.Packages, func(env pack.PackageEnvelope) error {
+		if env.Encrypted {
+			// package signatures cover the decrypted packages and the encryption
+			// key only becomes available with the license after the installer
+			// starts, so rely on the tarball signature which covers the
+			// encrypted packages
+			if tarballPath != "" {
+				return nil
+			}
+			return trace.BadParameter("package %v is encrypted, pass the installer "+
+				"tarball with --verify-tarball to verify its signature instead", env.Locator)
+		}
+		return trace.Wrap(pack.VerifyPackage(installerEnv.Packages, env, verifier))
+	})
+}
+
+// verifyTarball verifies the detached signature of the tarball at path,
+// see signing.SignatureFile
+func verifyTarball(path string, verifier *signing.Verifier) error {
+	signature, err := ioutil.ReadFile(signing.SignatureFile(path))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+	if err := verifier.VerifyReader(f, signature); err != nil {
+		return trace.AccessDenied("tarball %v failed signature verification", path)
+	}
+	return nil
+}
+
 func Join(env, joinEnv *localenv.LocalEnvironment, j JoinConfig) error {
 	err := CheckLocalState(env)
 	if err != nil {
@@ -487,11 +556,16 @@ func executeInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams, ope
 		LocalApps:          localApps,
 		LocalBackend:       localEnv.Backend,
 		Insecure:           localEnv.Insecure,
+		MaxParallel:        p.MaxParallel,
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
+	if p.DryRun {
+		return trace.Wrap(simulatePhase(installFSM, p))
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
 	defer cancel()
 	progress := utils.NewProgress(ctx, fmt.Sprintf("Executing install phase %q", p.PhaseID), -1, false)
@@ -505,6 +579,7 @@ func executeInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams, ope
 		PhaseID:  p.PhaseID,
 		Force:    p.Force,
 		Progress: progress,
+		Attempt:  p.Attempt,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -544,10 +619,14 @@ func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParam
 		JoinBackend:   joinEnv.Backend,
 		DebugMode:     localEnv.Debug,
 		Insecure:      localEnv.Insecure,
+		MaxParallel:   p.MaxParallel,
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if p.DryRun {
+		return trace.Wrap(simulatePhase(joinFSM, p))
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
 	defer cancel()
 	progress := utils.NewProgress(ctx, fmt.Sprintf("Executing join phase %q", p.PhaseID), -1, false)
@@ -559,6 +638,7 @@ func executeJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhaseParam
 		PhaseID:  p.PhaseID,
 		Force:    p.Force,
 		Progress: progress,
+		Attempt:  p.Attempt,
 	})
 }
 
@@ -594,6 +674,7 @@ func rollbackJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhasePara
 		JoinBackend:   joinEnv.Backend,
 		DebugMode:     localEnv.Debug,
 		Insecure:      localEnv.Insecure,
+		MaxParallel:   p.MaxParallel,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -602,10 +683,14 @@ func rollbackJoinPhase(localEnv, joinEnv *localenv.LocalEnvironment, p PhasePara
 	defer cancel()
 	progress := utils.NewProgress(ctx, fmt.Sprintf("Rolling back join phase %q", p.PhaseID), -1, false)
 	defer progress.Stop()
+	if p.PhaseID == fsm.RootPhase {
+		return trace.Wrap(joinFSM.RollbackPlan(ctx, progress, p.Force))
+	}
 	return joinFSM.RollbackPhase(ctx, fsm.Params{
 		PhaseID:  p.PhaseID,
 		Force:    p.Force,
 		Progress: progress,
+		Attempt:  p.Attempt,
 	})
 }
 
@@ -660,10 +745,14 @@ func rollbackInstallPhase(localEnv *localenv.LocalEnvironment, p PhaseParams, op
 	progress := utils.NewProgress(ctx, fmt.Sprintf("Rolling back install phase %q", p.PhaseID), -1, false)
 	defer progress.Stop()
 
+	if p.PhaseID == fsm.RootPhase {
+		return trace.Wrap(installFSM.RollbackPlan(ctx, progress, p.Force))
+	}
 	return installFSM.RollbackPhase(ctx, fsm.Params{
 		PhaseID:  p.PhaseID,
 		Force:    p.Force,
 		Progress: progress,
+		Attempt:  p.Attempt,
 	})
 }
 
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/storage"
@@ -43,6 +44,29 @@ type PhaseParams struct {
 	Timeout time.Duration
 	// SkipVersionCheck overrides the verification of binary version compatibility
 	SkipVersionCheck bool
+	// DryRun describes the phase actions instead of executing them
+	DryRun bool
+	// Output specifies the output format for the dry run
+	Output constants.Format
+	// MaxParallel limits the number of phases executed concurrently
+	MaxParallel int
+	// AutoRollback specifies whether the operation is rolled back
+	// automatically if it fails
+	AutoRollback bool
+	// Attempt is the number of the attempt assigned by the node
+	// that dispatched the phase to this node
+	Attempt int
+}
+
+// supportsAutoRollback returns true if the operation of the specified type
+// can be rolled back automatically if it fails
+func supportsAutoRollback(operationType string) bool {
+	switch operationType {
+	case ops.OperationUpdate, ops.OperationUpdateRuntimeEnviron, ops.OperationUpdateConfig:
+		return true
+	default:
+		return false
+	}
 }
 
 func executePhase(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, params PhaseParams) error {
@@ -50,6 +74,9 @@ func executePhase(localEnv, updateEnv, joinEnv *localenv.LocalEnvironment, param
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if params.AutoRollback && !supportsAutoRollback(op.Type) {
+		return trace.BadParameter("operation type %q does not support automatic rollback", op.Type)
+	}
 	switch op.Type {
 	case ops.OperationInstall:
 		return executeInstallPhase(localEnv, params, op)
@@ -23,15 +23,18 @@ import (
 	_ "net/http/pprof"
 	"strings"
 
+	"github.com/gravitational/gravity/lib/app/delta"
 	"github.com/gravitational/gravity/lib/app/docker"
 	appservice "github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/install"
+	"github.com/gravitational/gravity/lib/loc"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/pack"
 	"github.com/gravitational/gravity/lib/pack/encryptedpack"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/state"
 	"github.com/gravitational/gravity/lib/storage"
 	"github.com/gravitational/gravity/lib/users"
@@ -86,7 +89,23 @@ func appPackage(env *localenv.LocalEnvironment) error {
 	return nil
 }
 
-func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
+func uploadUpdate(env *localenv.LocalEnvironment, opsURL, verifyKeyPath, verifyTarballPath string) error {
+	var verifier *signing.Verifier
+	if verifyKeyPath != "" {
+		var err error
+		if verifier, err = signing.ReadVerifier(verifyKeyPath); err != nil {
+			return trace.Wrap(err)
+		}
+		if verifyTarballPath != "" {
+			env.PrintStep("Verifying update tarball signature")
+			if err := verifyTarball(verifyTarballPath, verifier); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	} else if verifyTarballPath != "" {
+		return trace.BadParameter("--verify-tarball requires --verify-key")
+	}
+
 	// create local environment with gravity state dir because the environment
 	// provided above has upgrade tarball as a state dir
 	localStateDir, err := localenv.LocalGravityDir()
@@ -136,6 +155,28 @@ func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
 		return trace.Wrap(err)
 	}
 
+	// restore the full application version if this is a delta installer
+	manifest, err := delta.ReadManifest(env.StateDir)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	var verified []loc.Locator
+	if manifest != nil {
+		env.PrintStep("Restoring application %v from delta against %v",
+			manifest.Application, manifest.Base)
+		_, err = delta.Apply(delta.ApplyRequest{
+			Dir:          env.StateDir,
+			Packages:     tarballPackages,
+			BasePackages: clusterPackages,
+			Verifier:     verifier,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// patched packages have been verified against the signed manifest
+		verified = manifest.Patched()
+	}
+
 	clusterApps, err := defaultEnv.SiteApps()
 	if err != nil {
 		return trace.Wrap(err)
@@ -155,11 +196,13 @@ func uploadUpdate(env *localenv.LocalEnvironment, opsURL string) error {
 
 	env.PrintStep("Importing application %v v%v", appPackage.Name, appPackage.Version)
 	_, err = appservice.PullApp(appservice.AppPullRequest{
-		SrcPack: tarballPackages,
-		SrcApp:  tarballApps,
-		DstPack: clusterPackages,
-		DstApp:  clusterApps,
-		Package: *appPackage,
+		SrcPack:  tarballPackages,
+		SrcApp:   tarballApps,
+		DstPack:  clusterPackages,
+		DstApp:   clusterApps,
+		Package:  *appPackage,
+		Verifier: verifier,
+		Verified: verified,
 	})
 	if err != nil {
 		if !trace.IsAlreadyExists(err) {
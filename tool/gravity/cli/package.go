@@ -35,6 +35,7 @@ import (
 	"github.com/gravitational/gravity/tool/common"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/dustin/go-humanize"
 	"github.com/gravitational/configure"
 	"github.com/gravitational/trace"
 )
@@ -164,6 +165,27 @@ func listPackages(app *localenv.LocalEnvironment, repositoryFilter string, opsCe
 	})
 }
 
+func displayDedupStats(app *localenv.LocalEnvironment, opsCenterURL string) error {
+	packageService, err := app.PackageService(opsCenterURL)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	reporter, ok := packageService.(pack.DedupReporter)
+	if !ok {
+		return trace.NotImplemented("package service does not report deduplication statistics")
+	}
+	stats, err := reporter.GetDedupStats()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	app.Printf("Packages stored as chunks: %v\n", stats.Packages)
+	app.Printf("Unique chunks:             %v\n", stats.Chunks)
+	app.Printf("Total package size:        %v\n", humanize.Bytes(uint64(stats.LogicalBytes)))
+	app.Printf("Stored size:               %v\n", humanize.Bytes(uint64(stats.StoredBytes)))
+	app.Printf("Deduplication ratio:       %.2f\n", stats.Ratio())
+	return nil
+}
+
 func foreachPackage(app *localenv.LocalEnvironment, repositoryFilter string, opsCenterURL string, fn func(env pack.PackageEnvelope) error) error {
 	packageService, err := app.PackageService(opsCenterURL)
 	if err != nil {
@@ -164,6 +164,10 @@ func outputPlan(plan storage.OperationPlan, format constants.Format) (err error)
 		err = fsm.FormatOperationPlanYAML(os.Stdout, plan)
 	case constants.EncodingJSON:
 		err = fsm.FormatOperationPlanJSON(os.Stdout, plan)
+	case constants.EncodingDOT:
+		err = fsm.FormatOperationPlanDot(os.Stdout, plan)
+	case constants.EncodingMermaid:
+		err = fsm.FormatOperationPlanMermaid(os.Stdout, plan)
 	case constants.EncodingText:
 		fsm.FormatOperationPlanText(os.Stdout, plan)
 		err = explainPlan(plan.Phases)
@@ -177,6 +181,41 @@ func outputPlan(plan storage.OperationPlan, format constants.Format) (err error)
 	return nil
 }
 
+// simulatePhase describes the phase specified with params using the given
+// state machine and outputs the result
+func simulatePhase(machine *fsm.FSM, params PhaseParams) error {
+	sim, err := machine.SimulatePhase(context.TODO(), fsm.Params{
+		PhaseID: params.PhaseID,
+		Force:   params.Force,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(outputSimulation(*sim, params.Output))
+}
+
+// simulateUpdatePhase describes the phase specified with params using the given
+// updater and outputs the result
+func simulateUpdatePhase(updater *update.Updater, params PhaseParams) error {
+	sim, err := updater.SimulatePhase(context.TODO(), params.PhaseID, params.Force)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(outputSimulation(*sim, params.Output))
+}
+
+func outputSimulation(sim fsm.Simulation, format constants.Format) (err error) {
+	switch format {
+	case constants.EncodingJSON:
+		err = fsm.FormatSimulationJSON(os.Stdout, sim)
+	case constants.EncodingText:
+		fsm.FormatSimulationText(os.Stdout, sim)
+	default:
+		return trace.BadParameter("unknown output format %q", format)
+	}
+	return trace.Wrap(err)
+}
+
 func explainPlan(phases []storage.OperationPhase) (err error) {
 	for _, phase := range phases {
 		if phase.State == storage.OperationPhaseStateFailed {
@@ -194,16 +233,38 @@ func explainPlan(phases []storage.OperationPhase) (err error) {
 
 func outputPhaseError(phase storage.OperationPhase) error {
 	fmt.Printf(color.RedString("The %v phase (%q) has failed", phase.ID, phase.Description))
+	if phase.Attempt > 1 {
+		fmt.Print(color.RedString(" after %v attempts", phase.Attempt))
+	}
 	if phase.Error != nil {
-		var phaseErr trace.TraceErr
-		if err := utils.UnmarshalError(phase.Error.Err, &phaseErr); err != nil {
-			return trace.Wrap(err, "failed to unmarshal phase error from JSON")
+		message, err := formatPhaseError(phase.Error)
+		if err != nil {
+			return trace.Wrap(err)
 		}
-		fmt.Printf(color.RedString("\n\t%v\n", phaseErr.Err))
+		fmt.Printf(color.RedString("\n\t%v\n", message))
+	}
+	for _, attempt := range phase.FailedAttempts {
+		if attempt.Error == nil {
+			continue
+		}
+		message, err := formatPhaseError(attempt.Error)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Print(color.YellowString("\tAttempt %v failed at %v: %v\n", attempt.Attempt,
+			attempt.Failed.Format(constants.HumanDateFormat), message))
 	}
 	return nil
 }
 
+func formatPhaseError(rawErr *trace.RawTrace) (string, error) {
+	var phaseErr trace.TraceErr
+	if err := utils.UnmarshalError(rawErr.Err, &phaseErr); err != nil {
+		return "", trace.Wrap(err, "failed to unmarshal phase error from JSON")
+	}
+	return fmt.Sprint(phaseErr.Err), nil
+}
+
 func tryReconcilePlan(ctx context.Context, localEnv, updateEnv *localenv.LocalEnvironment, plan storage.OperationPlan) (*storage.OperationPlan, error) {
 	clusterEnv, err := localEnv.NewClusterEnvironment(localenv.WithEtcdTimeout(1 * time.Second))
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gravitational/gravity/lib/app/sbom"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
 	"github.com/gravitational/gravity/lib/loc"
@@ -64,6 +65,9 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.InstallCmd.Flavor = g.InstallCmd.Flag("flavor", "Application flavor, optional").String()
 	g.InstallCmd.Role = g.InstallCmd.Flag("role", "Role of this node, optional").String()
 	g.InstallCmd.ResourcesPath = g.InstallCmd.Flag("config", "Kubernetes configuration resources, will be injected at cluster creation time").String()
+	g.InstallCmd.ClusterSpecPath = g.InstallCmd.Flag("cluster-spec", "Cluster spec listing all cluster nodes, the installer waits for exactly these nodes to join").String()
+	g.InstallCmd.VerifyKeyPath = g.InstallCmd.Flag("verify-key", "Path to the PEM-encoded public key or certificate to verify the installer package signatures with").String()
+	g.InstallCmd.VerifyTarballPath = g.InstallCmd.Flag("verify-tarball", "Path to the installer tarball to verify the detached signature of with --verify-key, required for encrypted installers").String()
 	g.InstallCmd.Wizard = g.InstallCmd.Flag("wizard", "(Obsolete, superseded by 'mode') Start installer with web wizard interface").Bool()
 	g.InstallCmd.Mode = g.InstallCmd.Flag("mode", fmt.Sprintf("Install mode, one of %v",
 		modules.Get().InstallModes())).Default(constants.InstallModeCLI).Hidden().String()
@@ -138,24 +142,40 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PlanCmd.SkipVersionCheck = g.PlanCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
 
 	g.PlanDisplayCmd.CmdClause = g.PlanCmd.Command("display", "Display a plan for an ongoing operation").Default()
-	g.PlanDisplayCmd.Output = common.Format(g.PlanDisplayCmd.Flag("output", "Output format for the plan, text, json or yaml").Short('o').Default(string(constants.EncodingText)))
+	g.PlanDisplayCmd.Output = common.Format(g.PlanDisplayCmd.Flag("output", "Output format for the plan, text, json, yaml, dot or mermaid").Short('o').Default(string(constants.EncodingText)))
+	g.PlanDisplayCmd.Format = common.Format(g.PlanDisplayCmd.Flag("format", "Alias for --output"))
 
 	g.PlanExecuteCmd.CmdClause = g.PlanCmd.Command("execute", "Execute specified operation phase")
 	g.PlanExecuteCmd.Phase = g.PlanExecuteCmd.Flag("phase", "Phase ID to execute").String()
 	g.PlanExecuteCmd.Force = g.PlanExecuteCmd.Flag("force", "Force execution of specified phase").Bool()
 	g.PlanExecuteCmd.PhaseTimeout = g.PlanExecuteCmd.Flag("timeout", "Phase timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
+	g.PlanExecuteCmd.DryRun = g.PlanExecuteCmd.Flag("dry-run", "Describe the actions the phase would perform without executing it").Bool()
+	g.PlanExecuteCmd.Output = common.Format(g.PlanExecuteCmd.Flag("output", "Output format for --dry-run, text or json").Short('o').Default(string(constants.EncodingText)))
+	g.PlanExecuteCmd.MaxParallel = g.PlanExecuteCmd.Flag("max-parallel", "Maximum number of phases to execute concurrently, 0 means unlimited").Default("0").Int()
+	g.PlanExecuteCmd.Attempt = g.PlanExecuteCmd.Flag("attempt", "Number of the attempt assigned by the node dispatching the phase").Hidden().Int()
 
 	g.PlanRollbackCmd.CmdClause = g.PlanCmd.Command("rollback", "Rollback specified operation phase")
 	g.PlanRollbackCmd.Phase = g.PlanRollbackCmd.Flag("phase", "Phase ID to execute").String()
 	g.PlanRollbackCmd.Force = g.PlanRollbackCmd.Flag("force", "Force rollback of specified phase").Bool()
 	g.PlanRollbackCmd.PhaseTimeout = g.PlanRollbackCmd.Flag("timeout", "Phase timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
+	g.PlanRollbackCmd.All = g.PlanRollbackCmd.Flag("all", "Rollback all executed phases of the operation in reverse order").Bool()
+	g.PlanRollbackCmd.Attempt = g.PlanRollbackCmd.Flag("attempt", "Number of the attempt assigned by the node dispatching the phase").Hidden().Int()
 
 	g.PlanResumeCmd.CmdClause = g.PlanCmd.Command("resume", "Resume last aborted operation")
 	g.PlanResumeCmd.Force = g.PlanResumeCmd.Flag("force", "Force execution of specified phase").Bool()
 	g.PlanResumeCmd.PhaseTimeout = g.PlanResumeCmd.Flag("timeout", "Phase timeout").Default(defaults.PhaseTimeout).Hidden().Duration()
+	g.PlanResumeCmd.MaxParallel = g.PlanResumeCmd.Flag("max-parallel", "Maximum number of phases to execute concurrently, 0 means unlimited").Default("0").Int()
+	g.PlanResumeCmd.AutoRollback = g.PlanResumeCmd.Flag("auto-rollback", "Rollback the operation automatically if it fails. Only supported for update, runtime environment and configuration update operations").Bool()
 
 	g.PlanCompleteCmd.CmdClause = g.PlanCmd.Command("complete", "Mark operation as completed")
 
+	g.PlanExportCmd.CmdClause = g.PlanCmd.Command("export", "Export operation plan, its state and progress as a signed bundle")
+	g.PlanExportCmd.Path = g.PlanExportCmd.Arg("path", "Path to save the bundle to, '-' for stdout").Required().String()
+
+	g.PlanImportCmd.CmdClause = g.PlanCmd.Command("import", "Import operation plan from a bundle to resume the operation on this node")
+	g.PlanImportCmd.Path = g.PlanImportCmd.Arg("path", "Path to the bundle, '-' for stdin").Required().String()
+	g.PlanImportCmd.SkipVerify = g.PlanImportCmd.Flag("insecure-skip-verify", "Do not verify the bundle signature").Hidden().Bool()
+
 	g.UpdateCmd.CmdClause = g.Command("update", "Update actions on cluster")
 
 	g.UpdateCheckCmd.CmdClause = g.UpdateCmd.Command("check", "Check if an update is available for the specified application").Hidden()
@@ -185,9 +205,15 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.UpgradeCmd.Force = g.UpgradeCmd.Flag("force", "Force phase execution even if pre-conditions are not satisfied").Bool()
 	g.UpgradeCmd.Resume = g.UpgradeCmd.Flag("resume", "Resume upgrade from the last failed step").Bool()
 	g.UpgradeCmd.SkipVersionCheck = g.UpgradeCmd.Flag("skip-version-check", "Bypass version compatibility check").Hidden().Bool()
+	g.UpgradeCmd.DryRun = g.UpgradeCmd.Flag("dry-run", "Describe the actions the upgrade would perform without executing it").Bool()
+	g.UpgradeCmd.Output = common.Format(g.UpgradeCmd.Flag("output", "Output format for --dry-run, text or json").Short('o').Default(string(constants.EncodingText)))
+	g.UpgradeCmd.MaxParallel = g.UpgradeCmd.Flag("max-parallel", "Maximum number of phases to execute concurrently, 0 means unlimited").Default("0").Int()
+	g.UpgradeCmd.AutoRollback = g.UpgradeCmd.Flag("auto-rollback", "Rollback the operation automatically if it fails").Bool()
 
 	g.UpdateUploadCmd.CmdClause = g.UpdateCmd.Command("upload", "Upload update package to locally running site").Hidden()
 	g.UpdateUploadCmd.OpsCenterURL = g.UpdateUploadCmd.Flag("ops-url", "Optional OpsCenter URL to upload new packages to (defaults to local gravity site)").Default(defaults.GravityServiceURL).String()
+	g.UpdateUploadCmd.VerifyKeyPath = g.UpdateUploadCmd.Flag("verify-key", "Path to the PEM-encoded public key or certificate to verify the package signatures with").String()
+	g.UpdateUploadCmd.VerifyTarballPath = g.UpdateUploadCmd.Flag("verify-tarball", "Path to the update tarball to verify the detached signature of with --verify-key").String()
 
 	// manual update flow commands
 	g.UpdateCompleteCmd.CmdClause = g.UpdateCmd.Command("complete", "Mark update operation as completed").Hidden()
@@ -329,6 +355,13 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.AppStatusCmd.Locator = Locator(g.AppStatusCmd.Arg("pkg", "application package").Required())
 	g.AppStatusCmd.OpsCenterURL = g.AppStatusCmd.Flag("ops-url", "optional remote OpsCenter").String()
 
+	// output the software bill of materials of an application
+	g.AppSBOMCmd.CmdClause = g.AppCmd.Command("sbom", "output the software bill of materials of an application")
+	g.AppSBOMCmd.Locator = Locator(g.AppSBOMCmd.Arg("pkg", "application package").Required())
+	g.AppSBOMCmd.Format = g.AppSBOMCmd.Flag("format", fmt.Sprintf("bill of materials format, one of %v",
+		strings.Join(sbom.AllFormats, ", "))).Default(sbom.FormatSPDX).Enum(sbom.AllFormats...)
+	g.AppSBOMCmd.OpsCenterURL = g.AppSBOMCmd.Flag("ops-url", "optional remote Ops Center URL").String()
+
 	// pull an application from a remote OpsCenter
 	g.AppPullCmd.CmdClause = g.AppCmd.Command("pull", "pull an application package from remote OpsCenter").Hidden()
 	g.AppPullCmd.Package = Locator(g.AppPullCmd.Arg("pkg", "application package").Required())
@@ -414,6 +447,10 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.PackListCmd.Repository = g.PackListCmd.Arg("repository", "repository name, if omitted will list all packages").String()
 	g.PackListCmd.OpsCenterURL = g.PackListCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
 
+	// display deduplication statistics
+	g.PackStatsCmd.CmdClause = g.PackCmd.Command("stats", "display package storage deduplication statistics").Hidden()
+	g.PackStatsCmd.OpsCenterURL = g.PackStatsCmd.Flag("ops-url", "optional remote OpsCenter URL").String()
+
 	// delete package
 	g.PackDeleteCmd.CmdClause = g.PackCmd.Command("delete", "delete a package from repository").Hidden()
 	g.PackDeleteCmd.Force = g.PackDeleteCmd.Flag("force", "force deletion (ignore errors if not exists)").Bool()
@@ -511,6 +548,22 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.ReportCmd.CmdClause = g.Command("report", "Generate cluster diagnostics report")
 	g.ReportCmd.FilePath = g.ReportCmd.Flag("file", "target report file name").Default("report.tar.gz").String()
 
+	// audit log
+	g.AuditCmd.CmdClause = g.Command("audit", "Operations on the cluster audit log")
+	g.AuditListCmd.CmdClause = g.AuditCmd.Command("ls", "Display the cluster audit log events")
+	g.AuditListCmd.Since = g.AuditListCmd.Flag("since", "Only display the events for the specified time period, e.g. 1h or 30m").Default(defaults.AuditEventsSince.String()).Duration()
+	g.AuditListCmd.User = g.AuditListCmd.Flag("user", "Only display the events triggered by the user").String()
+	g.AuditListCmd.Code = g.AuditListCmd.Flag("code", "Only display the events with the event code, e.g. G0001I").String()
+	g.AuditListCmd.Limit = g.AuditListCmd.Flag("limit", "Maximum number of events to display").Default(strconv.Itoa(defaults.AuditEventsLimit)).Int()
+	g.AuditListCmd.Format = common.Format(g.AuditListCmd.Flag("format", "Output format, text or json").Short('o').Default(string(constants.EncodingText)))
+
+	// monitoring alerts
+	g.AlertCmd.CmdClause = g.Command("alert", "Operations on the cluster monitoring alerts")
+	g.AlertTestCmd.CmdClause = g.AlertCmd.Command("test", "Evaluate the alert against the historical metrics and show when it would have fired")
+	g.AlertTestCmd.Name = g.AlertTestCmd.Arg("name", "Name of the alert to evaluate").Required().String()
+	g.AlertTestCmd.Since = g.AlertTestCmd.Flag("since", "Evaluate the alert for the specified time period, e.g. 1h or 30m").Default(defaults.AlertTestSince.String()).Duration()
+	g.AlertTestCmd.Format = common.Format(g.AlertTestCmd.Flag("format", "Output format, text or json").Short('o').Default(string(constants.EncodingText)))
+
 	// operations on sites
 	g.SiteCmd.CmdClause = g.Command("site", "operations on gravity sites")
 
@@ -560,6 +613,14 @@ func RegisterCommands(app *kingpin.Application) *Application {
 	g.RPCAgentRunCmd.CmdClause = g.RPCAgentCmd.Command("run", "run RPC agent").Hidden()
 	g.RPCAgentRunCmd.Args = g.RPCAgentRunCmd.Arg("arg", "additional arguments").Strings()
 
+	g.RPCAgentShellCmd.CmdClause = g.RPCAgentCmd.Command("shell", "Open an interactive shell on a node with a running agent")
+	g.RPCAgentShellCmd.Node = g.RPCAgentShellCmd.Arg("node", "Name or address of the node").Required().String()
+	g.RPCAgentShellCmd.Args = g.RPCAgentShellCmd.Arg("command", "Command to run instead of the login shell").Strings()
+	g.RPCAgentShellCmd.OperationID = g.RPCAgentShellCmd.Flag("operation-id", "ID of the operation to record the session to. If not specified, the last operation will be used").String()
+	g.RPCAgentShellCmd.RecordInput = g.RPCAgentShellCmd.Flag("record-input", "Record the session input in addition to the output. The input can contain passwords typed at prompts").Bool()
+
+	g.RPCAgentRotateCredentialsCmd.CmdClause = g.RPCAgentCmd.Command("rotate-credentials", "Replace the credentials of agents running on cluster nodes")
+
 	g.SystemCmd.CmdClause = g.Command("system", "operations on system components")
 
 	g.SystemRotateCertsCmd.CmdClause = g.SystemCmd.Command("rotate-certs", "Renew cluster certificates on a node").Hidden()
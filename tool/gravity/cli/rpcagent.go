@@ -38,6 +38,7 @@ import (
 	"github.com/gravitational/gravity/lib/update"
 	clusterupdate "github.com/gravitational/gravity/lib/update/cluster"
 	"github.com/gravitational/gravity/lib/utils"
+	"github.com/gravitational/gravity/lib/utils/kubectl"
 
 	"github.com/cenkalti/backoff"
 	teleclient "github.com/gravitational/teleport/lib/client"
@@ -108,16 +109,23 @@ func startAgent() (rpcserver.Server, error) {
 	}
 
 	config := rpcserver.Config{
-		Credentials: rpcserver.Credentials{
+		Credentials: rpcserver.NewRotatingCredentials(rpcserver.Credentials{
 			Server: serverCreds,
 			Client: clientCreds,
-		},
-		Listener: listener,
+		}),
+		Listener:   listener,
+		SecretsDir: secretsDir,
 	}
 	server, err := rpcserver.New(config, logrus.StandardLogger())
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-server.Done()
+		cancel()
+	}()
+	go rpc.WatchCredentialsExpiry(ctx, secretsDir, logrus.WithField(trace.Component, "rpc:credentials"))
 	log.Infof("Starting RPC agent on %v.", listener.Addr().String())
 
 	return server, nil
@@ -329,6 +337,72 @@ func rpcAgentShutdown(env *localenv.LocalEnvironment) error {
 	return trace.Wrap(err)
 }
 
+// rpcAgentRotateCredentials generates new agent credentials and replaces
+// the credentials of the agents running on all cluster nodes
+func rpcAgentRotateCredentials(env *localenv.LocalEnvironment) error {
+	secretsDir, err := fsm.AgentSecretsDir()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	current, err := rpc.ReadCredentials(secretsDir)
+	if err != nil {
+		return trace.Wrap(err, "failed to read agent credentials, "+
+			"make sure the agents are running")
+	}
+	rotated, err := rpc.RotateAgentCredentials(current)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ctx := context.TODO()
+	nodes, err := kubectl.GetNodesAddr(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("Rotating credentials of agents on %v", strings.Join(nodes, ", "))
+	final, err := rpc.RotateCredentials(ctx, nodes, current, rotated, logrus.WithField(trace.Component, "rpc:rotate"),
+		func(creds credentials.TransportCredentials) rpc.AgentRepository {
+			return fsm.NewAgentRunner(creds)
+		})
+	if final != nil {
+		if err := updateRPCCredentialsPackage(env, final); err != nil {
+			log.WithError(err).Warn("Failed to update agent credentials package.")
+		}
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	expires, err := rpc.CredentialsExpiry(final)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	env.PrintStep("Agent credentials rotated, new credentials expire on %v",
+		expires.UTC().Format(constants.HumanDateFormat))
+	return nil
+}
+
+// updateRPCCredentialsPackage replaces the agent credentials package
+// of the local cluster with the specified credentials
+func updateRPCCredentialsPackage(env *localenv.LocalEnvironment, archive utils.TLSArchive) error {
+	clusterEnv, err := env.NewClusterEnvironment()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	operator, err := env.SiteOperator()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cluster, err := operator.GetLocalSite()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	packageTemplate := loc.Locator{
+		Repository: cluster.Domain,
+		Version:    getGravityPackage().Version,
+	}
+	_, err = rpc.GenerateAgentCredentialsPackage(clusterEnv.ClusterPackages, packageTemplate, archive)
+	return trace.Wrap(err)
+}
+
 func executeAutomaticUpgrade(ctx context.Context, localEnv, upgradeEnv *localenv.LocalEnvironment, args []string) error {
 	return trace.Wrap(clusterupdate.AutomaticUpgrade(ctx, localEnv, upgradeEnv))
 }
@@ -120,6 +120,7 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.UpdatePlanInitCmd.FullCommand(),
 		g.UpgradeCmd.FullCommand(),
 		g.RPCAgentRunCmd.FullCommand(),
+		g.RPCAgentShellCmd.FullCommand(),
 		g.LeaveCmd.FullCommand(),
 		g.RemoveCmd.FullCommand(),
 		g.OpsAgentCmd.FullCommand():
@@ -170,6 +171,8 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.RPCAgentShutdownCmd.FullCommand(),
 		g.RPCAgentInstallCmd.FullCommand(),
 		g.RPCAgentRunCmd.FullCommand(),
+		g.RPCAgentShellCmd.FullCommand(),
+		g.RPCAgentRotateCredentialsCmd.FullCommand(),
 		g.SystemServiceInstallCmd.FullCommand(),
 		g.SystemServiceUninstallCmd.FullCommand(),
 		g.EnterCmd.FullCommand(),
@@ -181,6 +184,8 @@ func InitAndCheck(g *Application, cmd string) error {
 		g.PlanRollbackCmd.FullCommand(),
 		g.PlanResumeCmd.FullCommand(),
 		g.PlanCompleteCmd.FullCommand(),
+		g.PlanExportCmd.FullCommand(),
+		g.PlanImportCmd.FullCommand(),
 		g.InstallCmd.FullCommand(),
 		g.JoinCmd.FullCommand(),
 		g.AutoJoinCmd.FullCommand(),
@@ -346,10 +351,17 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.UpdateTriggerCmd.Manual,
 			*g.UpdateTriggerCmd.Block,
 			*g.UpdateTriggerCmd.SkipVersionCheck,
+			false,
 		)
 	case g.UpdatePlanInitCmd.FullCommand():
 		return initUpdateOperationPlan(localEnv, updateEnv)
 	case g.UpgradeCmd.FullCommand():
+		if *g.UpgradeCmd.DryRun && !*g.UpgradeCmd.Resume && *g.UpgradeCmd.Phase == "" {
+			return simulateClusterUpdate(localEnv, updateEnv,
+				*g.UpgradeCmd.App,
+				*g.UpgradeCmd.Output,
+			)
+		}
 		if *g.UpgradeCmd.Resume {
 			*g.UpgradeCmd.Phase = fsm.RootPhase
 		}
@@ -360,6 +372,10 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 					Force:            *g.UpgradeCmd.Force,
 					Timeout:          *g.UpgradeCmd.Timeout,
 					SkipVersionCheck: *g.UpgradeCmd.SkipVersionCheck,
+					DryRun:           *g.UpgradeCmd.DryRun,
+					Output:           *g.UpgradeCmd.Output,
+					MaxParallel:      *g.UpgradeCmd.MaxParallel,
+					AutoRollback:     *g.UpgradeCmd.AutoRollback,
 				})
 		}
 		return updateTrigger(localEnv,
@@ -368,6 +384,7 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.UpgradeCmd.Manual,
 			*g.UpgradeCmd.Block,
 			*g.UpgradeCmd.SkipVersionCheck,
+			*g.UpgradeCmd.AutoRollback,
 		)
 	case g.PlanExecuteCmd.FullCommand():
 		return executePhase(localEnv, updateEnv, joinEnv,
@@ -377,6 +394,10 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				Timeout:          *g.PlanExecuteCmd.PhaseTimeout,
 				SkipVersionCheck: *g.PlanCmd.SkipVersionCheck,
 				OperationID:      *g.PlanCmd.OperationID,
+				DryRun:           *g.PlanExecuteCmd.DryRun,
+				Output:           *g.PlanExecuteCmd.Output,
+				MaxParallel:      *g.PlanExecuteCmd.MaxParallel,
+				Attempt:          *g.PlanExecuteCmd.Attempt,
 			})
 	case g.PlanResumeCmd.FullCommand():
 		return executePhase(localEnv, updateEnv, joinEnv,
@@ -386,8 +407,16 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				Timeout:          *g.PlanResumeCmd.PhaseTimeout,
 				SkipVersionCheck: *g.PlanCmd.SkipVersionCheck,
 				OperationID:      *g.PlanCmd.OperationID,
+				MaxParallel:      *g.PlanResumeCmd.MaxParallel,
+				AutoRollback:     *g.PlanResumeCmd.AutoRollback,
 			})
 	case g.PlanRollbackCmd.FullCommand():
+		if *g.PlanRollbackCmd.All {
+			if *g.PlanRollbackCmd.Phase != "" {
+				return trace.BadParameter("--phase and --all are mutually exclusive")
+			}
+			*g.PlanRollbackCmd.Phase = fsm.RootPhase
+		}
 		return rollbackPhase(localEnv, updateEnv, joinEnv,
 			PhaseParams{
 				PhaseID:          *g.PlanRollbackCmd.Phase,
@@ -395,12 +424,23 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 				Timeout:          *g.PlanRollbackCmd.PhaseTimeout,
 				SkipVersionCheck: *g.PlanCmd.SkipVersionCheck,
 				OperationID:      *g.PlanCmd.OperationID,
+				Attempt:          *g.PlanRollbackCmd.Attempt,
 			})
 	case g.PlanDisplayCmd.FullCommand():
+		format := *g.PlanDisplayCmd.Output
+		if *g.PlanDisplayCmd.Format != "" {
+			format = *g.PlanDisplayCmd.Format
+		}
 		return displayOperationPlan(localEnv, updateEnv, joinEnv,
-			*g.PlanCmd.OperationID, *g.PlanDisplayCmd.Output)
+			*g.PlanCmd.OperationID, format)
 	case g.PlanCompleteCmd.FullCommand():
 		return completeOperationPlan(localEnv, updateEnv, joinEnv, *g.PlanCmd.OperationID)
+	case g.PlanExportCmd.FullCommand():
+		return exportOperationPlan(localEnv, updateEnv, joinEnv,
+			*g.PlanCmd.OperationID, *g.PlanExportCmd.Path)
+	case g.PlanImportCmd.FullCommand():
+		return importOperationPlan(localEnv, updateEnv, joinEnv,
+			*g.PlanImportCmd.Path, *g.PlanImportCmd.SkipVerify)
 	case g.LeaveCmd.FullCommand():
 		return leave(localEnv, leaveConfig{
 			force:     *g.LeaveCmd.Force,
@@ -428,7 +468,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			return status(localEnv, printOptions)
 		}
 	case g.UpdateUploadCmd.FullCommand():
-		return uploadUpdate(localEnv, *g.UpdateUploadCmd.OpsCenterURL)
+		return uploadUpdate(localEnv, *g.UpdateUploadCmd.OpsCenterURL,
+			*g.UpdateUploadCmd.VerifyKeyPath, *g.UpdateUploadCmd.VerifyTarballPath)
 	case g.AppPackageCmd.FullCommand():
 		return appPackage(localEnv)
 		// app commands
@@ -551,6 +592,11 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return statusApp(localEnv,
 			*g.AppStatusCmd.Locator,
 			*g.AppStatusCmd.OpsCenterURL)
+	case g.AppSBOMCmd.FullCommand():
+		return appSBOM(localEnv,
+			*g.AppSBOMCmd.Locator,
+			*g.AppSBOMCmd.Format,
+			*g.AppSBOMCmd.OpsCenterURL)
 	case g.AppPackageUninstallCmd.FullCommand():
 		return uninstallAppPackage(localEnv,
 			*g.AppPackageUninstallCmd.Locator)
@@ -605,6 +651,8 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 		return listPackages(localEnv,
 			*g.PackListCmd.Repository,
 			*g.PackListCmd.OpsCenterURL)
+	case g.PackStatsCmd.FullCommand():
+		return displayDedupStats(localEnv, *g.PackStatsCmd.OpsCenterURL)
 	case g.PackDeleteCmd.FullCommand():
 		return deletePackage(localEnv,
 			*g.PackDeleteCmd.Locator,
@@ -673,6 +721,16 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.APIKeyDeleteCmd.Token)
 	case g.ReportCmd.FullCommand():
 		return getClusterReport(localEnv, *g.ReportCmd.FilePath)
+	case g.AlertTestCmd.FullCommand():
+		return testAlert(localEnv, *g.AlertTestCmd.Name, *g.AlertTestCmd.Since, *g.AlertTestCmd.Format)
+	case g.AuditListCmd.FullCommand():
+		return listAuditEvents(localEnv, auditListConfig{
+			since:  *g.AuditListCmd.Since,
+			user:   *g.AuditListCmd.User,
+			code:   *g.AuditListCmd.Code,
+			limit:  *g.AuditListCmd.Limit,
+			format: *g.AuditListCmd.Format,
+		})
 	// cluster commands
 	case g.SiteListCmd.FullCommand():
 		return listSites(localEnv, *g.SiteListCmd.OpsCenterURL)
@@ -859,6 +917,14 @@ func Execute(g *Application, cmd string, extraArgs []string) error {
 			*g.RPCAgentRunCmd.Args)
 	case g.RPCAgentShutdownCmd.FullCommand():
 		return rpcAgentShutdown(localEnv)
+	case g.RPCAgentShellCmd.FullCommand():
+		return rpcAgentShell(localEnv, updateEnv, joinEnv,
+			*g.RPCAgentShellCmd.Node,
+			*g.RPCAgentShellCmd.OperationID,
+			*g.RPCAgentShellCmd.Args,
+			*g.RPCAgentShellCmd.RecordInput)
+	case g.RPCAgentRotateCredentialsCmd.FullCommand():
+		return rpcAgentRotateCredentials(localEnv)
 	case g.CheckCmd.FullCommand():
 		return checkManifest(localEnv,
 			*g.CheckCmd.ManifestFile,
@@ -28,6 +28,7 @@ import (
 	"github.com/gravitational/gravity/lib/checks"
 	"github.com/gravitational/gravity/lib/constants"
 	"github.com/gravitational/gravity/lib/defaults"
+	"github.com/gravitational/gravity/lib/fsm"
 	"github.com/gravitational/gravity/lib/localenv"
 	"github.com/gravitational/gravity/lib/ops"
 	"github.com/gravitational/gravity/lib/schema"
@@ -55,6 +56,7 @@ func status(env *localenv.LocalEnvironment, printOptions printOptions) error {
 
 	status, err := statusOnce(context.TODO(), operator, printOptions.operationID)
 	if err == nil {
+		collectAgentMetrics(context.TODO(), status)
 		err = printStatus(operator, clusterStatus{*status, nil}, printOptions)
 		return trace.Wrap(err)
 	} else {
@@ -168,6 +170,20 @@ func statusOnce(ctx context.Context, operator ops.Operator, operationID string)
 	return status, nil
 }
 
+// collectAgentMetrics queries the health of the cluster nodes from the operation
+// agents if there are active operations in the cluster
+func collectAgentMetrics(ctx context.Context, status *statusapi.Status) {
+	if status.Cluster == nil || len(status.Cluster.ActiveOperations) == 0 || status.Agent == nil {
+		return
+	}
+	creds, err := fsm.GetClientCredentials()
+	if err != nil {
+		log.WithError(err).Warn("Failed to read agent credentials.")
+		return
+	}
+	status.Agent.CollectAgentMetrics(ctx, fsm.NewAgentRunner(creds))
+}
+
 // printStatus calls an appropriate "print" method based on the printing options
 func printStatus(operator ops.Operator, status clusterStatus, printOptions printOptions) error {
 	switch {
@@ -376,6 +392,20 @@ func printNodeStatus(node statusapi.ClusterServer, w io.Writer) {
 			fmt.Fprintf(w, "            [%v]\t%v\n", constants.FailureMark, color.New(color.FgRed).SprintFunc()(probe))
 		}
 	}
+	if node.Agent != nil {
+		printAgentMetrics(*node.Agent, w)
+	}
+}
+
+func printAgentMetrics(agent statusapi.AgentMetrics, w io.Writer) {
+	if agent.Metrics == nil {
+		fmt.Fprintf(w, "            Agent:\t%v\n", color.YellowString("unavailable: %v", agent.Error))
+		return
+	}
+	fmt.Fprintf(w, "            Agent:\t%v\n", checks.FormatMetrics(*agent.Metrics))
+	for _, warning := range agent.Warnings {
+		fmt.Fprintf(w, "            [%v]\t%v\n", constants.WarningMark, color.YellowString(warning))
+	}
 }
 
 func unknownFallback(text string) string {
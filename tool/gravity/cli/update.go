@@ -149,7 +149,7 @@ type updateInitializer interface {
 type updater interface {
 	io.Closer
 	Run(ctx context.Context, force bool) error
-	RunPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error
-	RollbackPhase(ctx context.Context, phase string, phaseTimeout time.Duration, force bool) error
+	RunPhase(ctx context.Context, phase string, attempt int, phaseTimeout time.Duration, force bool) error
+	RollbackPhase(ctx context.Context, phase string, attempt int, phaseTimeout time.Duration, force bool) error
 	Complete(error) error
 }
@@ -153,9 +153,12 @@ func (g *Application) isUpdateCommand(cmd string) bool {
 		g.PlanRollbackCmd.FullCommand(),
 		g.PlanResumeCmd.FullCommand(),
 		g.PlanCompleteCmd.FullCommand(),
+		g.PlanExportCmd.FullCommand(),
+		g.PlanImportCmd.FullCommand(),
 		g.UpdatePlanInitCmd.FullCommand(),
 		g.UpdateTriggerCmd.FullCommand(),
-		g.UpgradeCmd.FullCommand():
+		g.UpgradeCmd.FullCommand(),
+		g.RPCAgentShellCmd.FullCommand():
 		return true
 	case g.RPCAgentRunCmd.FullCommand():
 		return len(*g.RPCAgentRunCmd.Args) > 0
@@ -176,7 +179,10 @@ func (g *Application) isExpandCommand(cmd string) bool {
 		g.PlanExecuteCmd.FullCommand(),
 		g.PlanRollbackCmd.FullCommand(),
 		g.PlanCompleteCmd.FullCommand(),
-		g.PlanResumeCmd.FullCommand():
+		g.PlanExportCmd.FullCommand(),
+		g.PlanImportCmd.FullCommand(),
+		g.PlanResumeCmd.FullCommand(),
+		g.RPCAgentShellCmd.FullCommand():
 		return true
 	}
 	return false
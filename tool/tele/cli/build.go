@@ -18,9 +18,12 @@ package cli
 
 import (
 	"context"
+	"strings"
 
+	"github.com/gravitational/gravity/lib/app/docker"
 	"github.com/gravitational/gravity/lib/app/service"
 	"github.com/gravitational/gravity/lib/builder"
+	"github.com/gravitational/gravity/lib/signing"
 	"github.com/gravitational/gravity/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -36,6 +39,9 @@ type BuildParameters struct {
 	OutPath string
 	// Overwrite indicates whether or not to overwrite an existing installer file
 	Overwrite bool
+	// DeltaFrom is the path to the installer of the previous version
+	// to build the delta installer against
+	DeltaFrom string
 	// Repository represents the source package repository
 	Repository string
 	// SkipVersionCheck indicates whether or not to perform the version check of the tele binary with the application's runtime at build time
@@ -44,10 +50,21 @@ type BuildParameters struct {
 	Silent bool
 	// Insecure turns on insecure verify mode
 	Insecure bool
+	// ImageFetcher, if set, configures vendoring images without Docker daemon
+	ImageFetcher *docker.ImageFetcherConfig
+	// SigningKeyPath is the path to the private key to sign the installer with
+	SigningKeyPath string
 }
 
 // build builds an installer tarball according to the provided parameters
 func build(ctx context.Context, params BuildParameters, req service.VendorRequest) (err error) {
+	var signer *signing.Signer
+	if params.SigningKeyPath != "" {
+		signer, err = signing.ReadSigner(params.SigningKeyPath)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	installerBuilder, err := builder.New(builder.Config{
 		Context:          ctx,
 		StateDir:         params.StateDir,
@@ -55,9 +72,12 @@ func build(ctx context.Context, params BuildParameters, req service.VendorReques
 		ManifestPath:     params.ManifestPath,
 		OutPath:          params.OutPath,
 		Overwrite:        params.Overwrite,
+		DeltaFrom:        params.DeltaFrom,
 		Repository:       params.Repository,
 		SkipVersionCheck: params.SkipVersionCheck,
 		VendorReq:        req,
+		ImageFetcher:     params.ImageFetcher,
+		Signer:           signer,
 		Progress:         utils.NewProgress(ctx, "Build", 6, params.Silent),
 	})
 	if err != nil {
@@ -66,3 +86,41 @@ func build(ctx context.Context, params BuildParameters, req service.VendorReques
 	defer installerBuilder.Close()
 	return builder.Build(ctx, installerBuilder)
 }
+
+// imageFetcherFlags are the tele build flags for vendoring images without Docker daemon
+type imageFetcherFlags struct {
+	daemonless bool
+	sources    []string
+	mirrors    []string
+	cacheDir   string
+	platforms  []string
+}
+
+// newImageFetcherConfig returns the configuration for vendoring images
+// without Docker daemon or nil if it has not been requested
+func newImageFetcherConfig(flags imageFetcherFlags) (*docker.ImageFetcherConfig, error) {
+	if !flags.daemonless {
+		if len(flags.sources) != 0 || len(flags.mirrors) != 0 || flags.cacheDir != "" || len(flags.platforms) != 0 {
+			return nil, trace.BadParameter("--image-source, --registry-mirror, --layer-cache-dir and --platform require --daemonless")
+		}
+		return nil, nil
+	}
+	platforms, err := docker.ParsePlatforms(flags.platforms)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	config := &docker.ImageFetcherConfig{
+		Sources:   flags.sources,
+		Mirrors:   make(map[string][]string),
+		CacheDir:  flags.cacheDir,
+		Platforms: platforms,
+	}
+	for _, mirror := range flags.mirrors {
+		parts := strings.SplitN(mirror, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, trace.BadParameter("invalid registry mirror %q, expected <registry>=<mirror>", mirror)
+		}
+		config.Mirrors[parts[0]] = append(config.Mirrors[parts[0]], parts[1])
+	}
+	return config, nil
+}
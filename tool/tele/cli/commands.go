@@ -59,6 +59,8 @@ type BuildCmd struct {
 	OutFile *string
 	// Overwrite overwrites existing tarball
 	Overwrite *bool
+	// DeltaFrom is the installer of the previous version to build delta against
+	DeltaFrom *string
 	// Repository is where packages are downloaded from
 	Repository *string
 	// Name allows to override app name
@@ -79,6 +81,18 @@ type BuildCmd struct {
 	Parallel *int
 	// Quiet allows to suppress console output
 	Quiet *bool
+	// Daemonless enables vendoring images without Docker daemon
+	Daemonless *bool
+	// ImageSources lists OCI layout directories and docker-archive tarballs to take images from
+	ImageSources *[]string
+	// RegistryMirrors lists registry mirrors as <registry>=<mirror>
+	RegistryMirrors *[]string
+	// LayerCacheDir is the directory to cache image layers in
+	LayerCacheDir *string
+	// Platforms lists the platforms to vendor images for
+	Platforms *[]string
+	// SigningKeyPath is the path to the private key to sign the installer with
+	SigningKeyPath *string
 }
 
 type ListCmd struct {
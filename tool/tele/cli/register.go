@@ -44,6 +44,7 @@ func RegisterCommands(app *kingpin.Application) Application {
 	tele.BuildCmd.ManifestPath = tele.BuildCmd.Arg("manifest-path", fmt.Sprintf("Path to the application manifest file, must be %q", defaults.ManifestFileName)).Default(defaults.ManifestFileName).String()
 	tele.BuildCmd.OutFile = tele.BuildCmd.Flag("output", "Name of the generated tarball, defaults to <dirname>.tar.gz where <dirname> is the name of the directory where app manifest is located").Short('o').String()
 	tele.BuildCmd.Overwrite = tele.BuildCmd.Flag("overwrite", "Overwrite the existing tarball").Short('f').Bool()
+	tele.BuildCmd.DeltaFrom = tele.BuildCmd.Flag("delta-from", "Path to the installer of the previous application version to build the delta installer against").String()
 	tele.BuildCmd.Repository = tele.BuildCmd.Flag("repository", "Optional address of Ops Center to download dependencies from").Hidden().String()
 	tele.BuildCmd.Name = tele.BuildCmd.Flag("name", "Optional application name, overrides the one specified in the manifest file").Hidden().String()
 	tele.BuildCmd.Version = tele.BuildCmd.Flag("version", "Optional application version, overrides the one specified in the manifest file").Hidden().String()
@@ -54,6 +55,12 @@ func RegisterCommands(app *kingpin.Application) Application {
 	tele.BuildCmd.SkipVersionCheck = tele.BuildCmd.Flag("skip-version-check", "Skip version compatibility check").Hidden().Bool()
 	tele.BuildCmd.Parallel = tele.BuildCmd.Flag("parallel", "Specifies the number of concurrent tasks. If < 0, the number of tasks is not restricted, if unspecified, then tasks are capped at the number of logical CPU cores").Int()
 	tele.BuildCmd.Quiet = tele.BuildCmd.Flag("quiet", "Suppress any extra output to stdout").Short('q').Bool()
+	tele.BuildCmd.Daemonless = tele.BuildCmd.Flag("daemonless", "Vendor images directly from registries and image archives without Docker daemon").Bool()
+	tele.BuildCmd.ImageSources = tele.BuildCmd.Flag("image-source", "OCI layout directory or docker-archive tarball to take images from before pulling them from registries, can be repeated. Requires --daemonless").Strings()
+	tele.BuildCmd.RegistryMirrors = tele.BuildCmd.Flag("registry-mirror", "Registry mirror to pull images from, e.g. docker.io=mirror.gcr.io, can be repeated. Requires --daemonless").Strings()
+	tele.BuildCmd.LayerCacheDir = tele.BuildCmd.Flag("layer-cache-dir", "Directory to cache image layers in, defaults to ~/.gravity/cache/layers. Requires --daemonless").String()
+	tele.BuildCmd.Platforms = tele.BuildCmd.Flag("platform", "Platform to vendor images for in the os/arch[/variant] format, e.g. linux/arm64, can be repeated. Defaults to linux/amd64. Requires --daemonless").Strings()
+	tele.BuildCmd.SigningKeyPath = tele.BuildCmd.Flag("signing-key", "Path to the PEM-encoded RSA or Ed25519 private key to sign the installer and its packages with").String()
 
 	tele.ListCmd.CmdClause = app.Command("ls", "Display a list of user applications published in remote Ops Center")
 	tele.ListCmd.Runtimes = tele.ListCmd.Flag("runtimes", "Show only runtimes").Short('r').Hidden().Bool()
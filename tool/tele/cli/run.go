@@ -50,15 +50,28 @@ func Run(tele Application) error {
 	case tele.VersionCmd.FullCommand():
 		return printVersion(*tele.VersionCmd.Output)
 	case tele.BuildCmd.FullCommand():
+		imageFetcher, err := newImageFetcherConfig(imageFetcherFlags{
+			daemonless: *tele.BuildCmd.Daemonless,
+			sources:    *tele.BuildCmd.ImageSources,
+			mirrors:    *tele.BuildCmd.RegistryMirrors,
+			cacheDir:   *tele.BuildCmd.LayerCacheDir,
+			platforms:  *tele.BuildCmd.Platforms,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
 		return build(context.Background(), BuildParameters{
 			StateDir:         *tele.StateDir,
 			ManifestPath:     *tele.BuildCmd.ManifestPath,
 			OutPath:          *tele.BuildCmd.OutFile,
 			Overwrite:        *tele.BuildCmd.Overwrite,
+			DeltaFrom:        *tele.BuildCmd.DeltaFrom,
 			Repository:       *tele.BuildCmd.Repository,
 			SkipVersionCheck: *tele.BuildCmd.SkipVersionCheck,
 			Silent:           *tele.BuildCmd.Quiet,
 			Insecure:         *tele.Insecure,
+			ImageFetcher:     imageFetcher,
+			SigningKeyPath:   *tele.BuildCmd.SigningKeyPath,
 		}, service.VendorRequest{
 			PackageName:            *tele.BuildCmd.Name,
 			PackageVersion:         *tele.BuildCmd.Version,